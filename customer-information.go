@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	customerInformation "github.com/xBlaz3kx/ChargePi-go/internal/components/customer-information"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
+)
+
+const (
+	clearFlag   = "clear"
+	logFileFlag = "log-file"
+)
+
+var (
+	clearCustomerInformation bool
+	logFilePath              string
+
+	// customerInformationCmd reports and erases the data about a tag on the sites where the central system cannot
+	// request it, e.g. with OCPP 1.6. ChargePi should not be running, as it would overwrite the files with its data.
+	customerInformationCmd = &cobra.Command{
+		Use:   "customer-information <idTag>",
+		Short: "Report the data stored about the tag and optionally erase it.",
+		Args:  cobra.ExactArgs(1),
+		Run:   runCustomerInformation,
+	}
+)
+
+func runCustomerInformation(cmd *cobra.Command, args []string) {
+	var (
+		idTag              = args[0]
+		authCache          = auth.NewAuthCache(authFilePath)
+		localAuthList      = auth.NewLocalAuthList(localAuthListFilePath)
+		reservationManager = reservations.NewManager(reservationsFilePath)
+		queue              = transactionQueue.NewQueue(txQueueFilePath)
+		manager            = customerInformation.NewManager()
+	)
+
+	authCache.LoadAuthFile()
+	localAuthList.LoadFromFile()
+	reservationManager.LoadFromFile()
+	queue.LoadFromFile()
+	settings.GetConnectors(connectorsFolderPath)
+
+	manager.AddSource(customerInformation.AuthCacheSourceName, customerInformation.NewAuthCacheSource(authCache))
+	manager.AddSource(customerInformation.LocalAuthListSourceName, customerInformation.NewLocalAuthListSource(localAuthList))
+	manager.AddSource(customerInformation.ReservationsSourceName, customerInformation.NewReservationsSource(reservationManager))
+	manager.AddSource(customerInformation.SessionsSourceName, customerInformation.NewSessionSource())
+	manager.AddSource(customerInformation.TransactionQueueSourceName, customerInformation.NewTransactionQueueSource(queue))
+	manager.AddSource(customerInformation.LogsSourceName, customerInformation.NewLogSource(logFilePath, nil))
+
+	report, err := manager.Report(idTag)
+	if err != nil {
+		log.WithError(err).Fatal("Unable to collect the customer information")
+	}
+
+	if len(report) == 0 {
+		fmt.Println("No customer information found")
+	}
+
+	for _, data := range report {
+		fmt.Println(data)
+	}
+
+	if !clearCustomerInformation {
+		return
+	}
+
+	err = manager.Clear(idTag)
+	if err != nil {
+		log.WithError(err).Fatal("Unable to clear the customer information")
+	}
+
+	fmt.Println("Customer information cleared")
+}
+
+func setupCustomerInformationFlags() {
+	customerInformationCmd.Flags().BoolVar(&clearCustomerInformation, clearFlag, false, "erase the data about the tag")
+	customerInformationCmd.Flags().StringVar(&logFilePath, logFileFlag, logging.LogFilePath, "log file path")
+
+	rootCmd.AddCommand(customerInformationCmd)
+}
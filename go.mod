@@ -14,7 +14,8 @@ require (
 	github.com/d2r2/go-logger v0.0.0-20210606094344-60e9d1233e22 // indirect
 	github.com/gemnasium/logrus-graylog-hook/v3 v3.1.0
 	github.com/go-co-op/gocron v1.6.0
-	github.com/go-playground/validator v9.31.0+incompatible // indirect
+	github.com/go-playground/validator v9.31.0+incompatible
+	github.com/gorilla/websocket v1.4.1
 	github.com/kkyr/fig v0.3.0
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/lestrrat-go/strftime v1.0.5 // indirect
@@ -22,6 +22,7 @@ type ChargePointClient interface {
 	StartTransaction(ctx context.Context, in *StartTransactionRequest, opts ...grpc.CallOption) (*StartTransactionResponse, error)
 	StopTransaction(ctx context.Context, in *StopTransactionRequest, opts ...grpc.CallOption) (*StopTransactionResponse, error)
 	HandleCharging(ctx context.Context, in *HandleChargingRequest, opts ...grpc.CallOption) (*HandleChargingResponse, error)
+	GetConnectionStatus(ctx context.Context, in *GetConnectionStatusRequest, opts ...grpc.CallOption) (*GetConnectionStatusResponse, error)
 }
 
 type chargePointClient struct {
@@ -90,6 +91,15 @@ func (c *chargePointClient) HandleCharging(ctx context.Context, in *HandleChargi
 	return out, nil
 }
 
+func (c *chargePointClient) GetConnectionStatus(ctx context.Context, in *GetConnectionStatusRequest, opts ...grpc.CallOption) (*GetConnectionStatusResponse, error) {
+	out := new(GetConnectionStatusResponse)
+	err := c.cc.Invoke(ctx, "/api.ChargePoint/GetConnectionStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChargePointServer is the server API for ChargePoint service.
 // All implementations must embed UnimplementedChargePointServer
 // for forward compatibility
@@ -98,6 +108,7 @@ type ChargePointServer interface {
 	StartTransaction(context.Context, *StartTransactionRequest) (*StartTransactionResponse, error)
 	StopTransaction(context.Context, *StopTransactionRequest) (*StopTransactionResponse, error)
 	HandleCharging(context.Context, *HandleChargingRequest) (*HandleChargingResponse, error)
+	GetConnectionStatus(context.Context, *GetConnectionStatusRequest) (*GetConnectionStatusResponse, error)
 	mustEmbedUnimplementedChargePointServer()
 }
 
@@ -117,6 +128,9 @@ func (UnimplementedChargePointServer) StopTransaction(context.Context, *StopTran
 func (UnimplementedChargePointServer) HandleCharging(context.Context, *HandleChargingRequest) (*HandleChargingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HandleCharging not implemented")
 }
+func (UnimplementedChargePointServer) GetConnectionStatus(context.Context, *GetConnectionStatusRequest) (*GetConnectionStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnectionStatus not implemented")
+}
 func (UnimplementedChargePointServer) mustEmbedUnimplementedChargePointServer() {}
 
 // UnsafeChargePointServer may be embedded to opt out of forward compatibility for this service.
@@ -210,6 +224,24 @@ func _ChargePoint_HandleCharging_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChargePoint_GetConnectionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConnectionStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChargePointServer).GetConnectionStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.ChargePoint/GetConnectionStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChargePointServer).GetConnectionStatus(ctx, req.(*GetConnectionStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChargePoint_ServiceDesc is the grpc.ServiceDesc for ChargePoint service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,6 +261,10 @@ var ChargePoint_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "HandleCharging",
 			Handler:    _ChargePoint_HandleCharging_Handler,
 		},
+		{
+			MethodName: "GetConnectionStatus",
+			Handler:    _ChargePoint_GetConnectionStatus_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
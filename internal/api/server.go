@@ -11,6 +11,7 @@ type (
 		UnimplementedChargePointServer
 		sendChannel    chan Message
 		receiveChannel chan Message
+		isOnline       func() bool
 		logger         *log.Logger
 	}
 
@@ -21,9 +22,10 @@ type (
 	}
 )
 
-func NewApiServer(logger *log.Logger, sendChannel chan Message, receiveChannel chan Message) *GrpcServer {
+func NewApiServer(logger *log.Logger, isOnline func() bool, sendChannel chan Message, receiveChannel chan Message) *GrpcServer {
 	return &GrpcServer{
 		logger:         logger,
+		isOnline:       isOnline,
 		sendChannel:    sendChannel,
 		receiveChannel: receiveChannel,
 	}
@@ -58,5 +60,16 @@ func (s *GrpcServer) HandleCharging(ctx context.Context, request *HandleCharging
 	return response, nil
 }
 
+// GetConnectionStatus returns whether the charge point is connected to the central system.
+func (s *GrpcServer) GetConnectionStatus(ctx context.Context, request *GetConnectionStatusRequest) (*GetConnectionStatusResponse, error) {
+	response := &GetConnectionStatusResponse{}
+
+	if s.isOnline != nil {
+		response.IsOnline = s.isOnline()
+	}
+
+	return response, nil
+}
+
 func (s *GrpcServer) mustEmbedUnimplementedChargePointServer() {
 }
@@ -0,0 +1,108 @@
+package hardware
+
+import (
+	"context"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"strings"
+	"time"
+)
+
+// SendToLCD sends the messages to the LCD, if the LCD is enabled.
+func SendToLCD(logger *log.Logger, lcd display.LCD, lcdSettings settings.Lcd, messages ...string) {
+	if util.IsNilInterfaceOrPointer(lcd) || lcd.GetLcdChannel() == nil || !lcdSettings.IsEnabled {
+		return
+	}
+
+	logger.Debugf("Sending message(s) to LCD: %v", messages)
+	lcd.GetLcdChannel() <- display.NewMessage(time.Second*5, messages)
+}
+
+// DisplayConnectionStatus displays if the charge point is connected to the central system.
+func DisplayConnectionStatus(logger *log.Logger, lcd display.LCD, lcdSettings settings.Lcd, isOnline bool) {
+	message, err := i18n.TranslateConnectionMessage(lcdSettings.Language, isOnline)
+	if err != nil {
+		logger.WithError(err).Errorf("Error displaying connection status")
+		return
+	}
+
+	SendToLCD(logger, lcd, lcdSettings, message...)
+}
+
+// DisplayLEDStatus displays the status of the connector with the color of the LED.
+func DisplayLEDStatus(logger *log.Logger, ledIndicator indicator.Indicator, indicatorSettings settings.LedIndicator, connectorIndex int, status core.ChargePointStatus) {
+	if !indicatorSettings.Enabled || util.IsNilInterfaceOrPointer(ledIndicator) {
+		return
+	}
+
+	var color = indicator.Off
+
+	switch status {
+	case core.ChargePointStatusFaulted:
+		color = indicator.Red
+		break
+	case core.ChargePointStatusCharging:
+		color = indicator.Blue
+		break
+	case core.ChargePointStatusReserved:
+		color = indicator.Yellow
+		break
+	case core.ChargePointStatusFinishing:
+		color = indicator.Blue
+		break
+	case core.ChargePointStatusAvailable:
+		color = indicator.Green
+		break
+	case core.ChargePointStatusUnavailable:
+		color = indicator.Orange
+		break
+	default:
+		return
+	}
+
+	logger.Debugf("Indicating connector status: %x", color)
+
+	go func() {
+		err := ledIndicator.DisplayColor(connectorIndex, uint32(color))
+		if err != nil {
+			logger.WithError(err).Errorf("Error indicating status")
+		}
+	}()
+}
+
+// IndicateCard Blinks the LED to indicate that the card was read.
+func IndicateCard(logger *log.Logger, ledIndicator indicator.Indicator, indicatorSettings settings.LedIndicator, index int, color uint32) {
+	if !indicatorSettings.Enabled || util.IsNilInterfaceOrPointer(ledIndicator) {
+		return
+	}
+
+	logger.Trace("Indicating tag was read")
+
+	err := ledIndicator.Blink(index, 3, color)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not indicate card was read")
+	}
+}
+
+// ListenForTag Listen for an RFID/NFC tag until the context is done. The tag id is passed to the handler in upper case.
+func ListenForTag(ctx context.Context, logger *log.Logger, tagChannel <-chan string, handler func(tagId string)) {
+	if tagChannel == nil {
+		return
+	}
+
+	logger.Info("Started listening for tags from reader")
+
+	for {
+		select {
+		case tagId := <-tagChannel:
+			handler(strings.ToUpper(tagId))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package hardware
+
+import (
+	"context"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+)
+
+// NewReaderFromSettings creates a TagReader based on the settings. Returns nil if the reader is disabled or cannot be created.
+func NewReaderFromSettings(readerSettings settings.TagReader) reader.Reader {
+	if !readerSettings.IsEnabled {
+		return nil
+	}
+
+	tagReader, err := reader.NewTagReader(readerSettings)
+	if err != nil {
+		return nil
+	}
+
+	return tagReader
+}
+
+// NewDisplayFromSettings creates a LCD based on the settings. Returns nil if the LCD is disabled or cannot be created.
+func NewDisplayFromSettings(lcdSettings settings.Lcd) display.LCD {
+	if !lcdSettings.IsEnabled {
+		return nil
+	}
+
+	lcd, err := display.NewDisplay(lcdSettings)
+	if err != nil {
+		return nil
+	}
+
+	return lcd
+}
+
+// ListenToReader starts the reader and passes the tags it reads to the charge point.
+func ListenToReader(ctx context.Context, tagReader reader.Reader, point chargePoint.ChargePoint) {
+	go tagReader.ListenForTags(ctx)
+	go point.ListenForTag(ctx, tagReader.GetTagChannel())
+}
@@ -7,12 +7,21 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/xBlaz3kx/ChargePi-go/internal/api"
 	"github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
 	v16 "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v16"
+	"github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v201"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
 	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
 	s "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/grpc"
@@ -31,7 +40,14 @@ func CreateChargePoint(
 	manager connectorManager.Manager,
 	sch *gocron.Scheduler,
 	authCache *auth.Cache,
+	localAuthList *auth.LocalAuthList,
+	queue transactionQueue.Queue,
+	reservationManager reservations.Manager,
+	deviceModelStore deviceModel.Store,
 	hardware settings.Hardware,
+	diagnosticFiles diagnostics.Files,
+	dataTransferRegistry dataTransfer.Registry,
+	wsClient *connectionSupervisor.Client,
 ) chargePoint.ChargePoint {
 	switch protocolVersion {
 	case settings.OCPP16:
@@ -40,32 +56,96 @@ func CreateChargePoint(
 			manager,
 			sch,
 			authCache,
+			localAuthList,
+			queue,
+			reservationManager,
 			v16.WithDisplayFromSettings(ctx, hardware.Lcd),
 			v16.WithReaderFromSettings(ctx, hardware.TagReader),
 			v16.WithLogger(logger),
+			v16.WithDiagnosticFiles(diagnosticFiles),
+			v16.WithDataTransfer(dataTransferRegistry),
+			v16.WithClient(wsClient),
 		)
 	case settings.OCPP201:
-		logger.Fatal("Version 2.0.1 is not supported yet.")
-		return nil
+		return v201.NewChargePoint(
+			manager,
+			sch,
+			authCache,
+			localAuthList,
+			queue,
+			deviceModelStore,
+			v201.WithDisplayFromSettings(ctx, hardware.Lcd),
+			v201.WithReaderFromSettings(ctx, hardware.TagReader),
+			v201.WithLogger(logger),
+			v201.WithDataTransfer(dataTransferRegistry),
+			v201.WithReservations(reservationManager),
+			v201.WithClient(wsClient),
+		)
 	default:
 		logger.WithField("protocolVersion", protocolVersion).Fatal("Protocol version not supported")
 		return nil
 	}
 }
 
-func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connector, configurationFilePath, authFilePath string) {
+// negotiateProtocolVersion offers all supported protocol versions to the central system on the connection of the client.
+// The accepted version is remembered in the settings and the OCPP configuration is migrated to it. If the central system
+// is not reachable or does not choose a version, the version from the settings is used.
+func negotiateProtocolVersion(
+	config *settings.Settings,
+	client *connectionSupervisor.Client,
+	deviceModelStore deviceModel.Store,
+	logger *log.Logger,
+) settings.ProtocolVersion {
+	var (
+		info    = config.ChargePoint.Info
+		current = settings.ProtocolVersion(info.ProtocolVersion)
+	)
+
+	version, err := util.NegotiateProtocolVersion(client, util.CreateConnectionUrl(config.ChargePoint), info.Id, current)
+	if err != nil {
+		logger.WithError(err).Warnf("Cannot negotiate the protocol version, using %s", current)
+		return current
+	}
+
+	if version == current {
+		return current
+	}
+
+	logger.Infof("Switching the protocol version from %s to %s", current, version)
+
+	// The device model takes the values from the OCPP configuration every time the 2.0.1 charge point starts
+	if version == settings.OCPP16 {
+		err = deviceModel.UpdateConfiguration(deviceModelStore)
+		if err != nil {
+			logger.WithError(err).Errorf("Cannot migrate the device model to the OCPP configuration")
+		}
+	}
+
+	err = s.UpdateProtocolVersion(version)
+	if err != nil {
+		logger.WithError(err).Errorf("Cannot update the protocol version in the settings")
+	}
+
+	config.ChargePoint.Info.ProtocolVersion = string(version)
+	return version
+}
+
+func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connector, configurationFilePath, authFilePath, localAuthListFilePath, transactionQueueFilePath, reservationsFilePath, deviceModelFilePath string) {
 	var (
 		// ChargePoint components
-		handler   chargePoint.ChargePoint
-		authCache = auth.NewAuthCache(authFilePath)
-		logger    = log.StandardLogger()
-		manager   = connectorManager.GetManager()
-		sch       = scheduler.GetScheduler()
+		handler            chargePoint.ChargePoint
+		authCache          = auth.NewAuthCache(authFilePath)
+		localAuthList      = auth.NewLocalAuthList(localAuthListFilePath)
+		queue              = transactionQueue.NewQueue(transactionQueueFilePath)
+		reservationManager = reservations.NewManager(reservationsFilePath)
+		deviceModelStore   = deviceModel.NewStore(deviceModelFilePath)
+		// The modules register the handlers of their vendor specific messages in the registry
+		dataTransferRegistry = dataTransfer.NewRegistry()
+		logger               = log.StandardLogger()
+		manager              = connectorManager.GetManager()
+		sch                  = scheduler.GetScheduler()
 		// Settings
-		chargePointInfo = config.ChargePoint.Info
-		hardware        = config.ChargePoint.Hardware
-		serverUrl       = util.CreateConnectionUrl(config.ChargePoint)
-		protocolVersion = settings.ProtocolVersion(chargePointInfo.ProtocolVersion)
+		hardware = config.ChargePoint.Hardware
 		// Execution
 		ctx, cancel = context.WithCancel(context.Background())
 		quitChannel = make(chan os.Signal, 5)
@@ -79,21 +159,48 @@ func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connect
 
 	// Load tags
 	go authCache.LoadAuthFile()
+	localAuthList.LoadFromFile()
+
+	// Load the transaction messages that weren't sent before the shutdown
+	queue.LoadFromFile()
+	reservationManager.LoadFromFile()
+	deviceModelStore.LoadFromFile()
 
-	// Setup OCPP configuration manager
+	// Setup OCPP configuration manager. The connection settings of both versions are read from it.
 	s.SetupOcppConfigurationManager(
 		configurationFilePath,
-		configuration.ProtocolVersion(config.ChargePoint.Info.ProtocolVersion),
+		configuration.OCPP16,
 		core.ProfileName,
 		reservation.ProfileName)
 
+	// The central system chooses the protocol version from the offered subprotocols. The charge point of the chosen
+	// version continues on the same connection.
+	wsClient := util.CreateClient(
+		config.ChargePoint.Info.Id,
+		config.ChargePoint.Info.BasicAuthUsername,
+		config.ChargePoint.Info.BasicAuthPassword,
+		config.ChargePoint.TLS,
+		certificates.NewManager(config.ChargePoint.TLS.CertificateStorePath),
+	)
+	protocolVersion := negotiateProtocolVersion(config, wsClient, deviceModelStore, logger)
+
+	// Files included in the diagnostics
+	diagnosticFiles := diagnostics.Files{
+		LogFile:           logging.LogFilePath,
+		SecurityLogFile:   logging.SecurityLogFilePath,
+		SettingsFile:      viper.ConfigFileUsed(),
+		OcppConfiguration: configurationFilePath,
+		AuthFile:          authFilePath,
+		ConnectorFiles:    s.GetConnectorFiles(),
+	}
+
 	// Initialize the client
-	handler = CreateChargePoint(ctx, protocolVersion, logger, manager, sch, authCache, hardware)
+	handler = CreateChargePoint(ctx, protocolVersion, logger, manager, sch, authCache, localAuthList, queue, reservationManager, deviceModelStore, hardware, diagnosticFiles, dataTransferRegistry, wsClient)
 	handler.Init(config)
 	handler.AddConnectors(connectors)
 
-	// Finally, connect to the central system
-	handler.Connect(ctx, serverUrl)
+	// Finally, connect to the central system. The URL depends on the SecurityProfile from the OCPP configuration
+	handler.Connect(ctx, util.CreateConnectionUrl(config.ChargePoint))
 
 	if config.Api.Enabled {
 		var (
@@ -104,7 +211,7 @@ func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connect
 		// Expose the API endpoints
 		go func() {
 			address := fmt.Sprintf("%s:%d", config.Api.Address, config.Api.Port)
-			grpc.CreateAndRunGrpcServer(address, apiSendChannel, apiReceiveChannel)
+			grpc.CreateAndRunGrpcServer(address, handler.IsOnline, apiSendChannel, apiReceiveChannel)
 		}()
 	}
 
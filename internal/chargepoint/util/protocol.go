@@ -0,0 +1,73 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"strings"
+)
+
+var ErrProtocolNotNegotiated = errors.New("the central system did not choose a subprotocol")
+
+// subprotocols maps the protocol versions to the websocket subprotocols.
+var subprotocols = map[settings.ProtocolVersion]string{
+	settings.OCPP16:  types.V16Subprotocol,
+	settings.OCPP201: ocpp201.Subprotocol,
+}
+
+// NegotiateProtocolVersion connects the client to the central system offering the subprotocols of all supported versions
+// and returns the version of the subprotocol the central system accepted. The preferred version is offered first.
+// The connection stays open and is used by the charge point of the negotiated version, which must connect to the same
+// server url. From then on, the client offers only the subprotocol of the negotiated version, or of the preferred
+// version if the negotiation failed.
+func NegotiateProtocolVersion(
+	client *connectionSupervisor.Client,
+	serverUrl, chargePointId string,
+	preferred settings.ProtocolVersion,
+) (settings.ProtocolVersion, error) {
+	client.SetSubprotocols(getOfferedSubprotocols(preferred)...)
+
+	// The OCPP client appends the charge point id to the server url the same way
+	err := client.Dial(fmt.Sprintf("%s/%s", strings.TrimSuffix(serverUrl, "/"), chargePointId))
+	if err != nil {
+		useProtocolVersion(client, preferred)
+		return "", err
+	}
+
+	subprotocol := client.Subprotocol()
+	log.Debugf("The central system accepted the subprotocol %s", subprotocol)
+
+	for version, protocol := range subprotocols {
+		if protocol == subprotocol {
+			useProtocolVersion(client, version)
+			return version, nil
+		}
+	}
+
+	useProtocolVersion(client, preferred)
+	return "", ErrProtocolNotNegotiated
+}
+
+// useProtocolVersion restricts the client to the subprotocol of the version. For an unknown version, the subprotocol
+// added by the OCPP client is offered.
+func useProtocolVersion(client *connectionSupervisor.Client, version settings.ProtocolVersion) {
+	if subprotocol, isFound := subprotocols[version]; isFound {
+		client.SetSubprotocols(subprotocol)
+		return
+	}
+
+	client.SetSubprotocols()
+}
+
+// getOfferedSubprotocols returns the subprotocols of all supported versions, starting with the preferred version.
+func getOfferedSubprotocols(preferred settings.ProtocolVersion) []string {
+	if preferred == settings.OCPP16 {
+		return []string{types.V16Subprotocol, ocpp201.Subprotocol}
+	}
+
+	return []string{ocpp201.Subprotocol, types.V16Subprotocol}
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"github.com/xBlaz3kx/ocppManager-go/manager"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const chargePointId = "ChargePi"
+
+type protocolTestSuite struct {
+	suite.Suite
+	certificateManager certificates.Manager
+}
+
+func (s *protocolTestSuite) SetupTest() {
+	s.certificateManager = certificates.NewManager(s.T().TempDir())
+}
+
+// newServer creates a central system, which accepts the first of its subprotocols offered by the charge point. The
+// subprotocols offered on every connection are recorded.
+func (s *protocolTestSuite) newServer(subprotocols ...string) (string, *[][]string) {
+	var (
+		upgrader = websocket.Upgrader{Subprotocols: subprotocols}
+		mu       sync.Mutex
+		offered  [][]string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Assert().Equal("/"+chargePointId, r.URL.Path)
+
+		mu.Lock()
+		offered = append(offered, websocket.Subprotocols(r))
+		mu.Unlock()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+		_, _, _ = conn.ReadMessage()
+	}))
+	s.T().Cleanup(server.Close)
+
+	return strings.Replace(server.URL, "http", "ws", 1), &offered
+}
+
+func (s *protocolTestSuite) negotiate(serverUrl string, preferred settings.ProtocolVersion) (settings.ProtocolVersion, error) {
+	client := CreateClient(chargePointId, "", "", settings.TLS{}, s.certificateManager)
+	s.T().Cleanup(client.Stop)
+	return NegotiateProtocolVersion(client, serverUrl, chargePointId, preferred)
+}
+
+func (s *protocolTestSuite) TestNegotiateProtocolVersion() {
+	serverUrl, _ := s.newServer(ocpp201.Subprotocol)
+	version, err := s.negotiate(serverUrl, settings.OCPP16)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(settings.OCPP201, version)
+
+	serverUrl, _ = s.newServer(types.V16Subprotocol)
+	version, err = s.negotiate(serverUrl, settings.OCPP201)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(settings.OCPP16, version)
+
+	// The central system chooses the version, even if the charge point prefers the other one
+	serverUrl, offered := s.newServer(types.V16Subprotocol, ocpp201.Subprotocol)
+	version, err = s.negotiate(serverUrl+"/", settings.OCPP201)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(settings.OCPP16, version)
+	s.Assert().EqualValues([][]string{{ocpp201.Subprotocol, types.V16Subprotocol}}, *offered)
+
+	// The central system does not choose a subprotocol
+	serverUrl, _ = s.newServer()
+	_, err = s.negotiate(serverUrl, settings.OCPP16)
+	s.Assert().ErrorIs(err, ErrProtocolNotNegotiated)
+
+	// The central system is not reachable
+	_, err = s.negotiate("ws://127.0.0.1:1", settings.OCPP16)
+	s.Assert().Error(err)
+}
+
+func (s *protocolTestSuite) TestNegotiatedConnection() {
+	serverUrl, offered := s.newServer(types.V16Subprotocol, ocpp201.Subprotocol)
+
+	client := CreateClient(chargePointId, "", "", settings.TLS{}, s.certificateManager)
+	defer client.Stop()
+
+	version, err := NegotiateProtocolVersion(client, serverUrl, chargePointId, settings.OCPP201)
+	s.Require().NoError(err)
+	s.Require().EqualValues(settings.OCPP16, version)
+
+	// The charge point continues on the negotiated connection
+	err = client.Start(serverUrl + "/" + chargePointId)
+	s.Require().NoError(err)
+	s.Assert().True(client.IsConnected())
+	s.Assert().Len(*offered, 1)
+
+	// After the negotiation, only the subprotocol of the negotiated version is offered
+	client.Stop()
+	err = client.Start(serverUrl + "/" + chargePointId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues([][]string{{ocpp201.Subprotocol, types.V16Subprotocol}, {types.V16Subprotocol}}, *offered)
+}
+
+func (s *protocolTestSuite) TestCreateConnectionUrl() {
+	point := settings.ChargePoint{Info: settings.Info{ServerUri: "example.com:8080/ocpp/"}}
+	s.Assert().EqualValues("ws://example.com:8080/ocpp", CreateConnectionUrl(point))
+
+	point.Info.ServerUri = "example.com:8080/ocpp"
+	s.Assert().EqualValues("ws://example.com:8080/ocpp", CreateConnectionUrl(point))
+}
+
+func (s *protocolTestSuite) TestGetOfferedSubprotocols() {
+	s.Assert().EqualValues([]string{types.V16Subprotocol, ocpp201.Subprotocol}, getOfferedSubprotocols(settings.OCPP16))
+	s.Assert().EqualValues([]string{ocpp201.Subprotocol, types.V16Subprotocol}, getOfferedSubprotocols(settings.OCPP201))
+	s.Assert().EqualValues([]string{ocpp201.Subprotocol, types.V16Subprotocol}, getOfferedSubprotocols(""))
+}
+
+func TestProtocol(t *testing.T) {
+	// The connection without the security profile
+	ocppConfigManager.SetManager(manager.NewManager())
+	err := ocppConfigManager.GetManager().SetConfiguration(configuration.Config{
+		Version: 1,
+		Keys:    []core.ConfigurationKey{{Key: securityExtension.SecurityProfile.String(), Value: "0"}},
+	})
+	assert.NoError(t, err)
+
+	suite.Run(t, new(protocolTestSuite))
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	cryptoTls "crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/agrison/go-commons-lang/stringUtils"
 	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
@@ -12,33 +14,80 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
 	"github.com/lorenzodonini/ocpp-go/ws"
 	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/tls"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// CreateConnectionUrl creates a connection url from the provided settings
+// Security profiles of the OCPP 1.6 Security Extension
+const (
+	SecurityProfileNone = iota
+	SecurityProfileBasicAuth
+	SecurityProfileTLS
+	SecurityProfileMutualTLS
+)
+
+// CreateConnectionUrl creates a connection url from the provided settings. The trailing slash is removed, since the OCPP
+// client appends the charge point id to the url.
 func CreateConnectionUrl(point settings.ChargePoint) string {
 	var (
 		chargePointInfo = point.Info
-		serverUrl       = fmt.Sprintf("ws://%s", chargePointInfo.ServerUri)
+		serverUrl       = fmt.Sprintf("ws://%s", strings.TrimSuffix(chargePointInfo.ServerUri, "/"))
 	)
 
 	// Replace insecure Websockets
-	if point.TLS.IsEnabled {
+	if point.TLS.IsEnabled || GetSecurityProfile() >= SecurityProfileTLS {
 		serverUrl = strings.Replace(serverUrl, "ws", "wss", 1)
 	}
 
 	return serverUrl
 }
 
-// CreateClient creates a Websocket client based on the settings.
-func CreateClient(basicAuthUser, basicAuthPass string, tlsConfig settings.TLS) *ws.Client {
+// GetSecurityProfile returns the SecurityProfile from the OCPP configuration. If the key is not set, the connection is
+// configured only with the settings.
+func GetSecurityProfile() int {
+	value, err := ocppConfigManager.GetConfigurationValue(securityExtension.SecurityProfile.String())
+	if err != nil {
+		return SecurityProfileNone
+	}
+
+	securityProfile, err := strconv.Atoi(value)
+	if err != nil {
+		return SecurityProfileNone
+	}
+
+	return securityProfile
+}
+
+// CreateClient creates a Websocket client based on the settings and the SecurityProfile. With the security profiles,
+// the charge point id is the basic auth username and the AuthorizationKey is the password. The root certificates and
+// the charge point certificate installed by the central system are used in addition to the ones from the settings.
+func CreateClient(chargePointId, basicAuthUser, basicAuthPass string, tlsConfig settings.TLS, certificateManager certificates.Manager) *connectionSupervisor.Client {
+	config := createTLSConfig(tlsConfig, certificateManager)
+	if config != nil {
+		log.Debugf("Creating a TLS client")
+	}
+
+	client := connectionSupervisor.NewClient(config)
+
+	if username, password := getBasicAuth(chargePointId, basicAuthUser, basicAuthPass); username != "" {
+		client.SetBasicAuth(username, password)
+	}
+
+	return client
+}
+
+// CreateTimeoutConfig creates the timeout configuration of the Websocket client with the WebSocketPingInterval.
+// The reconnect backoff is set by the connection supervisor.
+func CreateTimeoutConfig() ws.ClientTimeoutConfig {
 	var (
-		client            = ws.NewClient()
 		clientConfig      = ws.NewClientTimeoutConfig()
 		pingInterval, err = ocppConfigManager.GetConfigurationValue(v16.WebSocketPingInterval.String())
 	)
@@ -50,18 +99,62 @@ func CreateClient(basicAuthUser, basicAuthPass string, tlsConfig settings.TLS) *
 		}
 	}
 
-	// Check if the client has TLS
-	if tlsConfig.IsEnabled {
-		client = tls.GetTLSClient(tlsConfig.CACertificatePath, tlsConfig.ClientCertificatePath, tlsConfig.ClientKeyPath)
+	return clientConfig
+}
+
+// createTLSConfig creates the TLS configuration based on the settings and the SecurityProfile. Without TLS, nil is returned.
+func createTLSConfig(tlsConfig settings.TLS, certificateManager certificates.Manager) *cryptoTls.Config {
+	securityProfile := GetSecurityProfile()
+
+	switch securityProfile {
+	case SecurityProfileNone:
+		// Check if the client has TLS
+		if tlsConfig.IsEnabled {
+			return tls.GetTLSConfig(tlsConfig.CACertificatePath, tlsConfig.ClientCertificatePath, tlsConfig.ClientKeyPath)
+		}
+	case SecurityProfileTLS, SecurityProfileMutualTLS:
+		rootCAs := tls.GetCertificatePool(tlsConfig.CACertificatePath)
+		certificateManager.AppendRootCertificates(rootCAs, securityExtension.CentralSystemRootCertificate)
+
+		var getCertificate func() (*cryptoTls.Certificate, error)
+		if securityProfile == SecurityProfileMutualTLS {
+			getCertificate = func() (*cryptoTls.Certificate, error) {
+				certificate, err := certificateManager.GetChargePointCertificate()
+				if errors.Is(err, certificates.ErrCertificateNotFound) {
+					// Use the certificate from the settings until the central system signs a new one
+					fallbackCertificate, err := cryptoTls.LoadX509KeyPair(tlsConfig.ClientCertificatePath, tlsConfig.ClientKeyPath)
+					return &fallbackCertificate, err
+				}
+
+				return certificate, err
+			}
+		}
+
+		return tls.NewTLSConfig(rootCAs, getCertificate)
 	}
 
-	// If HTTP basic auth is provided, set it in the Websocket client
-	if stringUtils.IsNoneEmpty(basicAuthUser, basicAuthPass) {
-		client.SetBasicAuth(basicAuthUser, basicAuthPass)
+	return nil
+}
+
+// getBasicAuth returns the HTTP basic auth credentials based on the settings and the SecurityProfile. Without basic auth,
+// the username is empty.
+func getBasicAuth(chargePointId, basicAuthUser, basicAuthPass string) (string, string) {
+	switch GetSecurityProfile() {
+	case SecurityProfileBasicAuth, SecurityProfileTLS:
+		password, err := ocppConfigManager.GetConfigurationValue(securityExtension.AuthorizationKey.String())
+		if err != nil || stringUtils.IsEmpty(password) {
+			password = basicAuthPass
+		}
+
+		return chargePointId, password
+	case SecurityProfileNone:
+		// If HTTP basic auth is provided, set it in the Websocket client
+		if stringUtils.IsNoneEmpty(basicAuthUser, basicAuthPass) {
+			return basicAuthUser, basicAuthPass
+		}
 	}
 
-	client.SetTimeoutConfig(clientConfig)
-	return client
+	return "", ""
 }
 
 // SetProfilesFromConfig based on the provided OCPP configuration, set the profiles
@@ -70,6 +163,9 @@ func SetProfilesFromConfig(
 	coreHandler core.ChargePointHandler,
 	reservationHandler reservation.ChargePointHandler,
 	triggerHandler remotetrigger.ChargePointHandler,
+	smartChargingHandler smartcharging.ChargePointHandler,
+	localAuthListHandler localauth.ChargePointHandler,
+	firmwareHandler firmware.ChargePointHandler,
 ) {
 	// Set handlers based on configuration
 	profiles, err := ocppConfigManager.GetConfigurationValue(v16.SupportedFeatureProfiles.String())
@@ -88,17 +184,20 @@ func SetProfilesFromConfig(
 			log.Debug("Setting reservation handler")
 			break
 		case strings.ToLower(smartcharging.ProfileName):
-			//chargePoint.SetSmartChargingHandler(cp)
+			log.Debug("Setting smart charging handler")
+			chargePoint.SetSmartChargingHandler(smartChargingHandler)
 			break
 		case strings.ToLower(localauth.ProfileName):
-			//chargePoint.SetLocalAuthListHandler(cp)
+			log.Debug("Setting local auth list handler")
+			chargePoint.SetLocalAuthListHandler(localAuthListHandler)
 			break
 		case strings.ToLower(remotetrigger.ProfileName):
 			log.Debug("Setting remote trigger handler")
 			chargePoint.SetRemoteTriggerHandler(triggerHandler)
 			break
 		case strings.ToLower(firmware.ProfileName):
-			//chargePoint.SetFirmwareManagementHandler(cp)
+			log.Debug("Setting firmware management handler")
+			chargePoint.SetFirmwareManagementHandler(firmwareHandler)
 			break
 		}
 	}
@@ -10,8 +10,13 @@ import (
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 )
 
-// bootNotification Notify the central system that the charging point is online. Set the setHeartbeat interval and call restoreState.
-// If the central system does not accept the charge point, exit the client.
+// defaultBootRetryInterval is used when the central system does not specify the interval or the BootNotification fails.
+const defaultBootRetryInterval = 60
+
+// bootNotification Notify the central system that the charging point is online. If the charge point is accepted, set
+// the setHeartbeat interval, call restoreState after the first boot and notify the central system about the connector statuses.
+// If the registration is pending or rejected, retry after the interval from the response. Until the charge point is accepted,
+// the transactions cannot be started.
 func (cp *ChargePoint) bootNotification() {
 	var (
 		ocppInfo = cp.Settings.ChargePoint.Info.OCPPInfo
@@ -27,20 +32,35 @@ func (cp *ChargePoint) bootNotification() {
 	)
 
 	callback := func(confirmation ocpp.Response, protoError error) {
-		bootConf := confirmation.(*core.BootNotificationConfirmation)
+		bootConf, isBootConf := confirmation.(*core.BootNotificationConfirmation)
+		if protoError != nil || !isBootConf || bootConf == nil {
+			cp.logger.WithError(protoError).Errorf("Invalid BootNotification response")
+			cp.scheduleBootNotification(defaultBootRetryInterval)
+			return
+		}
+
+		cp.setRegistrationStatus(bootConf.Status)
 
 		switch bootConf.Status {
 		case core.RegistrationStatusAccepted:
 			cp.logger.Info("Notified and accepted from the central system")
+			_ = cp.scheduler.RemoveByTag("bootNotification")
 			cp.setHeartbeat(bootConf.Interval)
-			cp.restoreState()
-			break
+			cp.restoreOnce.Do(cp.restoreState)
+			go cp.validateOfflineTags()
+			go cp.sendPendingSecurityEvents()
+			go cp.checkCertificateExpiry()
+
+			// The statuses might have changed while the charge point was offline or not accepted
+			for _, c := range cp.connectorManager.GetConnectors() {
+				cp.notifyConnectorStatus(c)
+			}
 		case core.RegistrationStatusPending:
 			cp.logger.Info("Registration status pending")
-			//todo reschedule boot notification
-			break
+			cp.scheduleBootNotification(bootConf.Interval)
 		default:
-			cp.logger.Fatal("Denied by the central system.")
+			cp.logger.Warn("Rejected by the central system")
+			cp.scheduleBootNotification(bootConf.Interval)
 		}
 	}
 
@@ -48,6 +68,50 @@ func (cp *ChargePoint) bootNotification() {
 	util.HandleRequestErr(err, "Error sending BootNotification")
 }
 
+// scheduleBootNotification schedules the next BootNotification after the interval (in seconds).
+func (cp *ChargePoint) scheduleBootNotification(interval int) {
+	if interval <= 0 {
+		interval = defaultBootRetryInterval
+	}
+
+	cp.logger.Infof("Retrying the BootNotification in %d seconds", interval)
+
+	_ = cp.scheduler.RemoveByTag("bootNotification")
+	_, err := cp.scheduler.Every(interval).Seconds().LimitRunsTo(1).Tag("bootNotification").Do(cp.bootNotification)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error scheduling the BootNotification")
+	}
+}
+
+func (cp *ChargePoint) setRegistrationStatus(status core.RegistrationStatus) {
+	cp.registrationMu.Lock()
+	defer cp.registrationMu.Unlock()
+	cp.registrationStatus = status
+}
+
+// canStartTransactions checks if the central system did not reject the charge point or put it on hold. Until the first
+// BootNotification response, the transactions can be started offline.
+func (cp *ChargePoint) canStartTransactions() bool {
+	cp.registrationMu.Lock()
+	defer cp.registrationMu.Unlock()
+
+	switch cp.registrationStatus {
+	case core.RegistrationStatusPending, core.RegistrationStatusRejected:
+		return false
+	default:
+		return true
+	}
+}
+
+// isRegistered checks if the charge point is connected and accepted by the central system.
+func (cp *ChargePoint) isRegistered() bool {
+	cp.registrationMu.Lock()
+	isAccepted := cp.registrationStatus == core.RegistrationStatusAccepted
+	cp.registrationMu.Unlock()
+
+	return isAccepted && cp.IsOnline()
+}
+
 func (cp *ChargePoint) setHeartbeat(interval int) {
 	cp.logger.Infof("Setting a heartbeat schedule")
 
@@ -57,6 +121,8 @@ func (cp *ChargePoint) setHeartbeat(interval int) {
 	}
 
 	heartBeatInterval = fmt.Sprintf("%ss", heartBeatInterval)
+	// The heartbeat is rescheduled after every reconnect
+	_ = scheduler.GetScheduler().RemoveByTag("heartbeat")
 	_, err := scheduler.GetScheduler().Every(heartBeatInterval).Tag("heartbeat").Do(cp.sendHeartBeat)
 	if err != nil {
 		cp.logger.WithError(err).Errorf("Error scheduling heartbeat")
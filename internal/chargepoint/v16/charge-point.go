@@ -10,21 +10,41 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/internal/api"
 	chargePointUtil "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	firmwareUpdater "github.com/xBlaz3kx/ChargePi-go/internal/components/firmware-updater"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"os"
+	"sync"
 )
 
 type (
 	ChargePoint struct {
-		chargePoint  ocpp16.ChargePoint
-		availability core.AvailabilityType
-		Settings     *settings.Settings
+		chargePoint ocpp16.ChargePoint
+		supervisor  connectionSupervisor.Supervisor
+		wsClient    *connectionSupervisor.Client
+		// Availability of the whole charge point (connector 0)
+		availability   core.AvailabilityType
+		availabilityMu sync.Mutex
+		// Registration status from the last BootNotification
+		registrationStatus core.RegistrationStatus
+		registrationMu     sync.Mutex
+		Settings           *settings.Settings
 		// Hardware components
 		TagReader reader.Reader
 		Indicator indicator.Indicator
@@ -32,10 +52,31 @@ type (
 		// Software components
 		connectorManager   connectorManager.Manager
 		connectorChannel   chan rxgo.Item
-		meterValuesChannel chan rxgo.Item
+		meterValuesChannel chan models.MeterValueNotification
 		scheduler          *gocron.Scheduler
 		authCache          *auth.Cache
-		logger             *log.Logger
+		localAuthList      *auth.LocalAuthList
+		reservations       reservations.Manager
+		// Tags authorized while offline, validated after the reconnect
+		offlineTags        map[string]struct{}
+		offlineTagsMu      sync.Mutex
+		restoreOnce        sync.Once
+		transactionQueue   transactionQueue.Queue
+		profileManager     smartCharging.ProfileManager
+		firmwareUpdater    firmwareUpdater.Updater
+		diagnosticsManager diagnostics.Manager
+		diagnosticFiles    diagnostics.Files
+		// Handlers of the vendor specific messages
+		dataTransfer dataTransfer.Registry
+		// Security Extension
+		securityEndpoint        securityExtension.Endpoint
+		certificateManager      certificates.Manager
+		logManager              diagnostics.Manager
+		securityMu              sync.Mutex
+		pendingSecurityEvents   []*securityExtension.SecurityEventNotificationRequest
+		signedFirmwareRequestId *int
+		logRequestId            int
+		logger                  *log.Logger
 	}
 
 	ChargePointV16 interface {
@@ -51,20 +92,55 @@ type (
 )
 
 // NewChargePoint creates a new ChargePoint for OCPP version 1.6.
-func NewChargePoint(manager connectorManager.Manager, scheduler *gocron.Scheduler, cache *auth.Cache, opts ...Options) *ChargePoint {
-	ch := make(chan rxgo.Item, 5)
-	// Set the channel
+func NewChargePoint(
+	manager connectorManager.Manager,
+	scheduler *gocron.Scheduler,
+	cache *auth.Cache,
+	localAuthList *auth.LocalAuthList,
+	queue transactionQueue.Queue,
+	reservationManager reservations.Manager,
+	opts ...Options,
+) *ChargePoint {
+	var (
+		ch            = make(chan rxgo.Item, 5)
+		meterValuesCh = make(chan models.MeterValueNotification, 5)
+	)
+
+	// Set the channels
 	manager.SetNotificationChannel(ch)
+	manager.SetMeterValuesChannel(meterValuesCh)
 
 	cp := &ChargePoint{
-		availability:     core.AvailabilityTypeInoperative,
-		connectorChannel: ch,
-		scheduler:        scheduler,
-		connectorManager: manager,
-		authCache:        cache,
-		logger:           log.StandardLogger(),
+		availability:       core.AvailabilityTypeInoperative,
+		connectorChannel:   ch,
+		meterValuesChannel: meterValuesCh,
+		scheduler:          scheduler,
+		connectorManager:   manager,
+		authCache:          cache,
+		localAuthList:      localAuthList,
+		transactionQueue:   queue,
+		reservations:       reservationManager,
+		profileManager:     smartCharging.NewProfileManager(),
+		dataTransfer:       dataTransfer.NewRegistry(),
+		logger:             log.StandardLogger(),
 	}
 
+	binaryPath, err := os.Executable()
+	if err != nil {
+		log.WithError(err).Error("Cannot determine the executable path")
+	}
+
+	cp.firmwareUpdater = firmwareUpdater.NewUpdater(
+		binaryPath,
+		scheduler,
+		cp.sendFirmwareStatusNotification,
+		cp.isIdle,
+		cp.restartAfterUpdate,
+	)
+	cp.diagnosticsManager = diagnostics.NewManager(scheduler, cp.sendDiagnosticsStatusNotification)
+	cp.logManager = diagnostics.NewManager(scheduler, cp.onLogStatus)
+	cp.transactionQueue.SetTransactionStartedHandler(cp.onTransactionStarted)
+
 	// Apply options
 	for _, opt := range opts {
 		opt(cp)
@@ -81,42 +157,123 @@ func (cp *ChargePoint) Init(settings *settings.Settings) {
 
 	cp.Settings = settings
 
+	// The charge point stays inoperative after a reboot
+	cp.setAvailability(core.AvailabilityTypeOperative)
+	if settings.ChargePoint.Info.Availability == string(core.AvailabilityTypeInoperative) {
+		cp.setAvailability(core.AvailabilityTypeInoperative)
+	}
+
 	var (
 		info      = settings.ChargePoint.Info
 		tlsConfig = settings.ChargePoint.TLS
-		wsClient  = chargePointUtil.CreateClient(
-			settings.ChargePoint.Info.BasicAuthUsername,
-			settings.ChargePoint.Info.BasicAuthPassword,
-			tlsConfig)
-		logInfo = log.WithFields(log.Fields{
+		logInfo   = log.WithFields(log.Fields{
 			"chargePointId": info.Id,
 		})
 	)
 
+	if util.IsNilInterfaceOrPointer(cp.certificateManager) {
+		cp.certificateManager = certificates.NewManager(tlsConfig.CertificateStorePath)
+	}
+	cp.setMaxCertificates()
+
+	if cp.wsClient == nil {
+		cp.wsClient = chargePointUtil.CreateClient(
+			info.Id,
+			info.BasicAuthUsername,
+			info.BasicAuthPassword,
+			tlsConfig,
+			cp.certificateManager,
+		)
+	}
+
 	logInfo.Debug("Creating charge point")
-	cp.chargePoint = ocpp16.NewChargePoint(info.Id, nil, wsClient)
+	cp.supervisor = connectionSupervisor.NewSupervisor(cp.wsClient, chargePointUtil.CreateTimeoutConfig())
+	cp.supervisor.AddStateHandler(cp.onConnectionStateChange)
+
+	// The Security Extension messages are handled by the endpoint, since the OCPP library does not support them
+	cp.securityEndpoint = securityExtension.NewEndpoint(info.Id, cp.supervisor)
+	cp.securityEndpoint.SetHandler(cp)
+	cp.chargePoint = ocpp16.NewChargePoint(info.Id, cp.securityEndpoint.GetClient(), cp.securityEndpoint)
 
 	// Set charging profiles
-	chargePointUtil.SetProfilesFromConfig(cp.chargePoint, cp, cp, cp)
+	chargePointUtil.SetProfilesFromConfig(cp.chargePoint, cp, cp, cp, cp, cp, cp)
+	cp.dataTransfer.SetSender(cp.sendDataTransfer)
 
 	cp.setMaxCachedTags()
+	cp.setMaxLocalListTags()
+	cp.setMaxChargingProfiles()
+	cp.scheduleChargingLimits()
+	cp.scheduleAlignedMeterValues()
+	cp.scheduleCertificateRenewal()
 }
 
-// Connect to the central system and send a BootNotification
+// Connect to the central system in the background. The charge point operates offline until the connection is established.
+// After every (re)connect, a BootNotification and the connector statuses are sent to the central system.
 func (cp *ChargePoint) Connect(ctx context.Context, serverUrl string) {
-	cp.logger.Infof("Trying to connect to the central system: %s", serverUrl)
-	connectErr := cp.chargePoint.Start(serverUrl)
+	go cp.ListenForConnectorStatusChange(ctx, cp.connectorChannel)
+	// The tags authorized offline before a restart are validated after the reconnect
+	cp.restoreOfflineTags()
+	// Send the transaction messages queued while offline
+	go cp.transactionQueue.Run(ctx, cp.chargePoint.SendRequest, cp.isRegistered)
+
+	// Sent after the BootNotification is accepted
+	go cp.sendSecurityEvent(securityExtension.StartupOfTheDevice, "")
+	// Restore the previous SecurityProfile if the central system does not accept the new one
+	cp.scheduleSecurityProfileFallback()
+
+	go func() {
+		cp.logger.Infof("Trying to connect to the central system: %s", serverUrl)
+		err := cp.supervisor.Connect(ctx, func() error {
+			return cp.chargePoint.Start(serverUrl)
+		})
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Stopped connecting to the central system")
+			return
+		}
+
+		cp.logger.Infof("Successfully connected to: %s", serverUrl)
+	}()
+}
+
+// IsOnline checks if the client is connected to the central system.
+func (cp *ChargePoint) IsOnline() bool {
+	return !util.IsNilInterfaceOrPointer(cp.supervisor) && cp.supervisor.IsConnected()
+}
 
-	// Check if the connection was successful
-	if connectErr != nil {
-		//cp.CleanUp(core.ReasonOther)
-		cp.logger.WithError(connectErr).Fatalf("Cannot connect to the central system")
+func (cp *ChargePoint) setAvailability(availability core.AvailabilityType) {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+	cp.availability = availability
+}
+
+// storeAvailability saves the availability of the whole charge point in the settings, so it is restored after a reboot.
+func (cp *ChargePoint) storeAvailability(availability core.AvailabilityType) {
+	if cp.Settings != nil {
+		cp.Settings.ChargePoint.Info.Availability = string(availability)
 	}
 
-	cp.logger.Infof("Successfully connected to: %s", serverUrl)
-	cp.availability = core.AvailabilityTypeOperative
+	err := settingsManager.UpdateChargePointAvailability(availability)
+	if err != nil {
+		cp.logger.WithError(err).Error("Cannot save the charge point availability")
+	}
+}
 
-	go cp.ListenForConnectorStatusChange(ctx, cp.connectorChannel)
+func (cp *ChargePoint) getAvailability() core.AvailabilityType {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+	return cp.availability
+}
+
+// onConnectionStateChange displays the connection state. When the charge point goes online, it sends a BootNotification.
+func (cp *ChargePoint) onConnectionStateChange(isOnline bool) {
+	go cp.displayConnectionStatus(isOnline)
+
+	if !isOnline {
+		return
+	}
+
+	// The central system accepted the connection with the current SecurityProfile
+	cp.clearSecurityProfileFallback()
 	cp.bootNotification()
 }
 
@@ -167,8 +324,10 @@ func (cp *ChargePoint) CleanUp(reason core.Reason) {
 		break
 	}
 
-	cp.logger.Infof("Disconnecting the client..")
-	cp.chargePoint.Stop()
+	if !util.IsNilInterfaceOrPointer(cp.supervisor) && cp.supervisor.IsStarted() {
+		cp.logger.Infof("Disconnecting the client..")
+		cp.chargePoint.Stop()
+	}
 
 	if !util.IsNilInterfaceOrPointer(cp.TagReader) {
 		cp.logger.Info("Cleaning up the Tag Reader")
@@ -1,8 +1,21 @@
 package v16
 
 import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	"testing"
+	"time"
 )
 
 type chargePointTestSuite struct {
@@ -11,6 +24,16 @@ type chargePointTestSuite struct {
 }
 
 func (s *chargePointTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		reservations: reservations.NewManager(""),
+		Settings:     &settings.Settings{},
+		logger:       log.StandardLogger(),
+		scheduler:    scheduler.GetScheduler(),
+	}
+}
+
+func (s *chargePointTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
 }
 
 func (s *chargePointTestSuite) TestRestoreState() {
@@ -19,6 +42,89 @@ func (s *chargePointTestSuite) TestRestoreState() {
 func (s *chargePointTestSuite) TestNotifyConnectorStatus() {
 }
 
+func (s *chargePointTestSuite) TestOnConnectionStateChange() {
+	var (
+		chargePoint   = new(chargePointMock)
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		bootConf      = core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), 60, core.RegistrationStatusAccepted)
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
+	connectorMock.On("IsReserved").Return(false)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("core.BootNotificationRequest")).Return(bootConf, nil, nil)
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("*core.StatusNotificationRequest")).Return(core.NewStatusNotificationConfirmation(), nil, nil)
+
+	s.cp.chargePoint = chargePoint
+	s.cp.connectorManager = managerMock
+
+	// Nothing is sent while offline
+	s.cp.onConnectionStateChange(false)
+	chargePoint.AssertNotCalled(s.T(), "SendRequestAsync", mock.Anything)
+
+	// Send a BootNotification and the connector statuses after every (re)connect
+	s.cp.onConnectionStateChange(true)
+	time.Sleep(time.Millisecond * 300)
+	s.cp.onConnectionStateChange(true)
+	time.Sleep(time.Millisecond * 300)
+
+	chargePoint.AssertNumberOfCalls(s.T(), "SendRequestAsync", 4)
+
+	// The heartbeat is not scheduled twice
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+}
+
+func (s *chargePointTestSuite) TestBootNotificationPending() {
+	var (
+		chargePoint   = new(chargePointMock)
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		pendingConf   = core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), 30, core.RegistrationStatusPending)
+		acceptedConf  = core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), 60, core.RegistrationStatusAccepted)
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(true)
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
+	connectorMock.On("IsReserved").Return(false)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("core.BootNotificationRequest")).Return(pendingConf, nil, nil).Once()
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("core.BootNotificationRequest")).Return(acceptedConf, nil, nil).Once()
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("*core.StatusNotificationRequest")).Return(core.NewStatusNotificationConfirmation(), nil, nil)
+
+	s.cp.chargePoint = chargePoint
+	s.cp.connectorManager = managerMock
+	s.cp.setAvailability(core.AvailabilityTypeOperative)
+
+	// The BootNotification is retried after the interval
+	s.cp.bootNotification()
+	time.Sleep(time.Millisecond * 300)
+
+	s.Assert().False(s.cp.canStartTransactions())
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+	s.Assert().EqualValues([]string{"bootNotification"}, s.cp.scheduler.Jobs()[0].Tags())
+
+	// Transactions cannot be started until the charge point is accepted
+	s.Assert().ErrorIs(s.cp.startChargingConnector(connectorMock, tagId), errors.ErrChargePointNotAccepted)
+
+	s.cp.bootNotification()
+	time.Sleep(time.Millisecond * 300)
+
+	s.Assert().True(s.cp.canStartTransactions())
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+	s.Assert().EqualValues([]string{"heartbeat"}, s.cp.scheduler.Jobs()[0].Tags())
+	chargePoint.AssertNumberOfCalls(s.T(), "SendRequestAsync", 3)
+}
+
 func TestChargePoint(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
 	suite.Run(t, new(chargePointTestSuite))
 }
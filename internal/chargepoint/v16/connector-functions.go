@@ -73,6 +73,8 @@ func (cp *ChargePoint) restoreState() {
 			}
 		}
 	}
+
+	cp.restoreReservations()
 }
 
 // notifyConnectorStatus Notify the central system about the connector's status and updates the LED indicator.
@@ -97,6 +99,24 @@ func (cp *ChargePoint) notifyConnectorStatus(connector connector.Connector) {
 	util.HandleRequestErr(err, "Cannot send status of connector")
 }
 
+// notifyChargePointStatus sends the status of the whole charge point (connector 0), based on its availability.
+func (cp *ChargePoint) notifyChargePointStatus() {
+	status := core.ChargePointStatusAvailable
+	if cp.getAvailability() == core.AvailabilityTypeInoperative {
+		status = core.ChargePointStatusUnavailable
+	}
+
+	request := core.NewStatusNotificationRequest(0, core.NoError, status)
+	request.Timestamp = types.NewDateTime(time.Now())
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		cp.logger.Infof("Notified status of the charge point: %s", status)
+	}
+
+	err := util.SendRequest(cp.chargePoint, request, callback)
+	util.HandleRequestErr(err, "Cannot send status of the charge point")
+}
+
 // ListenForConnectorStatusChange listen for change in connector and notify the central system about the state
 func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-chan rxgo.Item) {
 	cp.logger.Debug("Starting to listen for connector status change")
@@ -119,15 +139,8 @@ func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-
 					cp.notifyConnectorStatus(c)
 				}
 				break
-			case meterVal := <-cp.meterValuesChannel:
-				meterValues, canCast := meterVal.V.(models.MeterValueNotification)
-				if canCast {
-					values := core.NewMeterValuesRequest(meterValues.ConnectorId, meterValues.MeterValues)
-					err := util.SendRequest(cp.chargePoint, values, func(confirmation ocpp.Response, protoError error) {})
-					if err != nil {
-						cp.logger.WithError(err).Errorf("Cannot send meter values")
-					}
-				}
+			case meterValues := <-cp.meterValuesChannel:
+				cp.sendMeterValues(meterValues)
 				break
 			case <-ctx.Done():
 				break Listener
@@ -137,6 +150,27 @@ func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-
 	}
 }
 
+// sendMeterValues sends the meter values to the central system. Transaction related meter values are queued.
+func (cp *ChargePoint) sendMeterValues(meterValues models.MeterValueNotification) {
+	request := core.NewMeterValuesRequest(meterValues.ConnectorId, meterValues.MeterValues)
+
+	if meterValues.TransactionId != nil {
+		request.TransactionId = meterValues.TransactionId
+
+		err := cp.transactionQueue.Enqueue(request)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Cannot queue meter values")
+		}
+
+		return
+	}
+
+	err := util.SendRequest(cp.chargePoint, request, func(confirmation ocpp.Response, protoError error) {})
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot send meter values")
+	}
+}
+
 func (cp *ChargePoint) displayConnectorStatus(connectorId int, status core.ChargePointStatus) {
 	var (
 		language = cp.Settings.ChargePoint.Hardware.Lcd.Language
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	ocppManager "github.com/xBlaz3kx/ocppManager-go"
@@ -158,6 +159,26 @@ var (
 				Readonly: false,
 				Value:    "20",
 			},
+			{
+				Key:      "SecurityProfile",
+				Readonly: false,
+				Value:    "0",
+			},
+			{
+				Key:      "AuthorizationKey",
+				Readonly: false,
+				Value:    "",
+			},
+			{
+				Key:      "CertificateSignedMaxChainSize",
+				Readonly: false,
+				Value:    "10000",
+			},
+			{
+				Key:      "CpoName",
+				Readonly: false,
+				Value:    "ChargePi",
+			},
 		},
 	}
 )
@@ -169,7 +190,8 @@ type connectorFunctionsTestSuite struct {
 
 func (s *connectorFunctionsTestSuite) SetupTest() {
 	s.cp = &ChargePoint{
-		logger: log.StandardLogger(),
+		reservations: reservations.NewManager(""),
+		logger:       log.StandardLogger(),
 	}
 }
 
@@ -250,7 +272,7 @@ func (s *connectorFunctionsTestSuite) TestNotifyConnectorStatus() {
 		connectorMock = new(test.ConnectorMock)
 	)
 
-	connectorMock.On("GetStatus").Return("Available", "NoError")
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
 	connectorMock.On("GetConnectorId").Return(1)
 
 	chargePoint.On("SendRequestAsync", mock.Anything).Run(func(args mock.Arguments) {
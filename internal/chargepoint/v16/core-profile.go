@@ -5,22 +5,49 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
+	chargePointUtil "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
-	"os/exec"
+	"time"
 )
 
+// OnChangeAvailability changes the availability of a single connector or the whole charge point (connector 0). If a transaction
+// is in progress on the connector, the change is scheduled after the transaction ends. The charge point does not start new
+// transactions or accept reservations while it is inoperative. The availability is kept after a reboot.
 func (cp *ChargePoint) OnChangeAvailability(request *core.ChangeAvailabilityRequest) (confirmation *core.ChangeAvailabilityConfirmation, err error) {
-	var response = core.AvailabilityStatusRejected
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	var (
+		response   = core.AvailabilityStatusAccepted
+		connectors []connector.Connector
+	)
 
 	if request.ConnectorId == 0 {
-		// todo check if there are ongoing transactions
-		cp.availability = request.Type
-		response = core.AvailabilityStatusAccepted
+		cp.setAvailability(request.Type)
+		cp.storeAvailability(request.Type)
+		connectors = cp.connectorManager.GetConnectors()
+
+		// Sent after the response
+		defer func() {
+			go cp.notifyChargePointStatus()
+		}()
 	} else {
-		// todo
+		c := cp.connectorManager.FindConnector(1, request.ConnectorId)
+		if util.IsNilInterfaceOrPointer(c) {
+			return core.NewChangeAvailabilityConfirmation(core.AvailabilityStatusRejected), nil
+		}
+
+		connectors = append(connectors, c)
+	}
+
+	for _, c := range connectors {
+		if c.SetAvailability(request.Type) {
+			response = core.AvailabilityStatusScheduled
+		}
 	}
 
 	return core.NewChangeAvailabilityConfirmation(response), nil
@@ -31,6 +58,20 @@ func (cp *ChargePoint) OnChangeConfiguration(request *core.ChangeConfigurationRe
 
 	cp.logger.Infof("Received request %s", request.GetFeatureName())
 
+	// The previous SecurityProfile is restored if the central system does not accept the new one
+	previousSecurityProfile := chargePointUtil.GetSecurityProfile()
+
+	switch request.Key {
+	case securityExtension.SecurityProfile.String():
+		if !cp.validateSecurityProfile(request.Value) {
+			return core.NewChangeConfigurationConfirmation(response), nil
+		}
+	case securityExtension.AuthorizationKey.String():
+		if !validateAuthorizationKey(request.Value) {
+			return core.NewChangeConfigurationConfirmation(response), nil
+		}
+	}
+
 	err = ocppManager.UpdateKey(request.Key, request.Value)
 	if err == nil {
 		response = core.ConfigurationStatusAccepted
@@ -41,6 +82,19 @@ func (cp *ChargePoint) OnChangeConfiguration(request *core.ChangeConfigurationRe
 		response = core.ConfigurationStatusRejected
 	}
 
+	if response == core.ConfigurationStatusAccepted {
+		switch request.Key {
+		case v16.ClockAlignedDataInterval.String():
+			cp.scheduleAlignedMeterValues()
+		case securityExtension.SecurityProfile.String():
+			// Reconnect with the new security parameters
+			cp.saveSecurityProfileFallback(previousSecurityProfile)
+			cp.reconfigureSecurity()
+		case securityExtension.AuthorizationKey.String():
+			cp.reconfigureSecurity()
+		}
+	}
+
 	return core.NewChangeConfigurationConfirmation(response), nil
 }
 
@@ -65,13 +119,6 @@ func (cp *ChargePoint) OnClearCache(request *core.ClearCacheRequest) (confirmati
 	return core.NewClearCacheConfirmation(response), nil
 }
 
-func (cp *ChargePoint) OnDataTransfer(request *core.DataTransferRequest) (confirmation *core.DataTransferConfirmation, err error) {
-	cp.logger.Infof("Received request %s", request.GetFeatureName())
-	var response = core.DataTransferStatusRejected
-
-	return core.NewDataTransferConfirmation(response), nil
-}
-
 func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest) (confirmation *core.GetConfigurationConfirmation, err error) {
 	cp.logger.Infof("Received request %s", request.GetFeatureName())
 
@@ -86,7 +133,7 @@ func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest)
 		return response, nil
 	}
 
-	configArray = configuration
+	configArray = hideWriteOnlyKeys(configuration)
 
 	// Get all configuration variables
 	if request.Key == nil || len(request.Key) == 0 {
@@ -120,29 +167,60 @@ func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest)
 	return response, nil
 }
 
+// hideWriteOnlyKeys returns a copy of the configuration without the value of the AuthorizationKey, which must not be readable.
+func hideWriteOnlyKeys(configuration []core.ConfigurationKey) []core.ConfigurationKey {
+	keys := make([]core.ConfigurationKey, len(configuration))
+	copy(keys, configuration)
+
+	for i, key := range keys {
+		if key.Key == securityExtension.AuthorizationKey.String() {
+			keys[i].Value = ""
+		}
+	}
+
+	return keys
+}
+
 func (cp *ChargePoint) OnReset(request *core.ResetRequest) (confirmation *core.ResetConfirmation, err error) {
 	cp.logger.Infof("Received request %s", request.GetFeatureName())
 	var response = core.ResetStatusRejected
 
 	switch request.Type {
-	case core.ResetTypeHard:
-		_, err = cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.CleanUp, core.ReasonHardReset)
-		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(exec.Command, "sudo reboot")
+	case core.ResetTypeHard, core.ResetTypeSoft:
+		// Delay the reset, so the response is sent to the central system
+		_, err = cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Tag("reset").Do(cp.reset, request.Type)
 		if err == nil {
 			response = core.ResetStatusAccepted
 		}
-		break
-	case core.ResetTypeSoft:
-		_, err = cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.CleanUp, core.ReasonSoftReset)
-		// todo restart ChargePi client only
-		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(exec.Command, "sudo reboot")
+	}
+
+	return core.NewResetConfirmation(response), nil
+}
+
+// reset stops the transactions, cleans up the hardware and restarts the client process. A hard reset runs the reboot
+// command from the settings instead. If the reboot command is not configured or fails, the client process is restarted.
+func (cp *ChargePoint) reset(resetType core.ResetType) {
+	reason := core.ReasonSoftReset
+	if resetType == core.ResetTypeHard {
+		reason = core.ReasonHardReset
+	}
+
+	cp.sendSecurityEvent(securityExtension.ResetOrReboot, string(resetType))
+	cp.CleanUp(reason)
+
+	if resetType == core.ResetTypeHard {
+		err := util.Reboot(cp.Settings.ChargePoint.Info.RebootCommand)
 		if err == nil {
-			response = core.ResetStatusAccepted
+			return
 		}
-		break
+
+		cp.logger.WithError(err).Warn("Cannot reboot, restarting the client instead")
 	}
 
-	return core.NewResetConfirmation(response), nil
+	err := util.RestartProcess()
+	if err != nil {
+		cp.logger.WithError(err).Fatal("Cannot restart the client")
+	}
 }
 
 func (cp *ChargePoint) OnUnlockConnector(request *core.UnlockConnectorRequest) (confirmation *core.UnlockConnectorConfirmation, err error) {
@@ -176,7 +254,7 @@ func (cp *ChargePoint) OnRemoteStopTransaction(request *core.RemoteStopTransacti
 		conn          = cp.connectorManager.FindConnectorWithTransactionId(transactionId)
 	)
 
-	if !util.IsNilInterfaceOrPointer(conn) && conn.IsCharging() {
+	if !util.IsNilInterfaceOrPointer(conn) && (conn.IsCharging() || conn.IsSuspended()) {
 		response = types.RemoteStartStopStatusAccepted
 		// Delay stopping the transaction by 3 seconds
 		_, schedulerErr := cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.stopChargingConnectorWithTransactionId, transactionId)
@@ -203,16 +281,28 @@ func (cp *ChargePoint) OnRemoteStartTransaction(request *core.RemoteStartTransac
 	if request.ConnectorId != nil {
 		conn = cp.connectorManager.FindConnector(1, *request.ConnectorId)
 	} else {
-		conn = cp.connectorManager.FindAvailableConnector()
+		conn = cp.findConnectorForTag(request.IdTag)
 	}
 
-	if !util.IsNilInterfaceOrPointer(conn) && conn.IsAvailable() {
+	if !util.IsNilInterfaceOrPointer(conn) && (conn.IsAvailable() || conn.IsReserved()) && cp.canStartTransactions() {
 		// Delay the charging by 3 seconds
 		response = types.RemoteStartStopStatusAccepted
 		_, schedulerErr := cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.startChargingConnector, conn, request.IdTag)
 		if schedulerErr != nil {
 			response = types.RemoteStartStopStatusRejected
 		}
+
+		// The profile applies to the transaction that is about to start
+		if response == types.RemoteStartStopStatusAccepted && request.ChargingProfile != nil {
+			profileErr := cp.profileManager.AddProfile(
+				conn.GetConnectorId(),
+				request.ChargingProfile,
+				&smartCharging.Transaction{Started: time.Now()},
+			)
+			if profileErr != nil {
+				logInfo.WithError(profileErr).Warn("Cannot set the charging profile for the transaction")
+			}
+		}
 	}
 
 	return core.NewRemoteStartTransactionConfirmation(response), nil
@@ -1,16 +1,26 @@
 package v16
 
 import (
+	"errors"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 type coreTestSuite struct {
@@ -20,18 +30,91 @@ type coreTestSuite struct {
 
 func (s *coreTestSuite) SetupTest() {
 	s.cp = &ChargePoint{
-		chargePoint: nil,
-		scheduler:   scheduler.GetScheduler(),
-		logger:      log.StandardLogger(),
+		reservations: reservations.NewManager(""),
+		chargePoint:  nil,
+		scheduler:    scheduler.GetScheduler(),
+		dataTransfer: dataTransfer.NewRegistry(),
+		logger:       log.StandardLogger(),
+	}
+}
+
+func (s *coreTestSuite) waitForStatus(statuses chan core.ChargePointStatus) core.ChargePointStatus {
+	select {
+	case status := <-statuses:
+		return status
+	case <-time.After(time.Second):
+		s.FailNow("the status notification was not sent")
+		return ""
 	}
 }
 
 func (s *coreTestSuite) TestChangeAvailability() {
+	var (
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		chargePoint    = new(chargePointMock)
+		statuses       = make(chan core.ChargePointStatus, 2)
+		settingsFile   = filepath.Join(s.T().TempDir(), "settings.yaml")
+		previousFile   = viper.ConfigFileUsed()
+	)
+
+	// The availability of the whole charge point is stored in the settings
+	s.Require().NoError(os.WriteFile(settingsFile, []byte("chargePoint:\n  info:\n    id: ChargePi\n"), 0644))
+	viper.SetConfigFile(settingsFile)
+	defer viper.SetConfigFile(previousFile)
+
+	// The status of the whole charge point is sent with connector 0
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("*core.StatusNotificationRequest")).Run(func(args mock.Arguments) {
+		notification := args.Get(0).(*core.StatusNotificationRequest)
+		s.Assert().EqualValues(0, notification.ConnectorId)
+		statuses <- notification.Status
+	}).Return(core.NewStatusNotificationConfirmation(), nil, nil)
+	s.cp.chargePoint = chargePoint
+
+	connectorMock.On("SetAvailability", core.AvailabilityTypeInoperative).Return(false)
+	connectorMock.On("SetAvailability", core.AvailabilityTypeOperative).Return(false)
+	// Transaction in progress
+	connector2Mock.On("SetAvailability", core.AvailabilityTypeInoperative).Return(true)
+	connector2Mock.On("SetAvailability", core.AvailabilityTypeOperative).Return(false)
+
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, connector2Mock})
+	managerMock.On("FindConnector", 1, 1).Return(connectorMock)
+	managerMock.On("FindConnector", 1, 2).Return(connector2Mock)
+	managerMock.On("FindConnector", 1, 3).Return(nil)
+	s.cp.connectorManager = managerMock
+
 	availability, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeOperative))
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.AvailabilityStatusAccepted, availability.Status)
+	connectorMock.AssertCalled(s.T(), "SetAvailability", core.AvailabilityTypeOperative)
+	connector2Mock.AssertCalled(s.T(), "SetAvailability", core.AvailabilityTypeOperative)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.cp.getAvailability())
+	s.Assert().EqualValues(core.ChargePointStatusAvailable, s.waitForStatus(statuses))
+
+	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeInoperative))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, availability.Status)
 
-	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeOperative))
+	// The change is applied after the transaction
+	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(2, core.AvailabilityTypeInoperative))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusScheduled, availability.Status)
+
+	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeInoperative))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusScheduled, availability.Status)
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.cp.getAvailability())
+	s.Assert().EqualValues(core.ChargePointStatusUnavailable, s.waitForStatus(statuses))
+
+	storedSettings := viper.New()
+	storedSettings.SetConfigFile(settingsFile)
+	s.Require().NoError(storedSettings.ReadInConfig())
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, storedSettings.GetString(settingsManager.Availability))
+	s.Assert().EqualValues("ChargePi", storedSettings.GetString("chargePoint.info.id"))
+
+	// Connector doesn't exist
+	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(3, core.AvailabilityTypeInoperative))
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.AvailabilityStatusRejected, availability.Status)
 }
@@ -56,11 +139,61 @@ func (s *coreTestSuite) TestOnChangeConfiguration() {
 func (s *coreTestSuite) TestOnClearCache() {}
 
 func (s *coreTestSuite) TestOnDataTransfer() {
+	s.cp.dataTransfer.AddHandler("ChargePi", "echo", func(data interface{}) (interface{}, error) {
+		return data, nil
+	})
+	s.cp.dataTransfer.AddHandler("ChargePi", "reject", func(data interface{}) (interface{}, error) {
+		return nil, errors.New("invalid data")
+	})
+
 	resp, err := s.cp.OnDataTransfer(core.NewDataTransferRequest(""))
 	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusUnknownVendorId, resp.Status)
+
+	request := core.NewDataTransferRequest("ChargePi")
+	request.MessageId = "unknown"
+	resp, err = s.cp.OnDataTransfer(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusUnknownMessageId, resp.Status)
+
+	request.MessageId = "echo"
+	request.Data = "data"
+	resp, err = s.cp.OnDataTransfer(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, resp.Status)
+	s.Assert().EqualValues("data", resp.Data)
+
+	request.MessageId = "reject"
+	resp, err = s.cp.OnDataTransfer(request)
+	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.DataTransferStatusRejected, resp.Status)
 }
 
+func (s *coreTestSuite) TestSendDataTransfer() {
+	chargePoint := new(chargePointMock)
+	chargePoint.On("DataTransfer", "ChargePi").Return(&core.DataTransferConfirmation{
+		Status: core.DataTransferStatusAccepted,
+		Data:   "response",
+	}, nil).Once()
+	chargePoint.On("DataTransfer", "ChargePi").Return(core.NewDataTransferConfirmation(core.DataTransferStatusUnknownMessageId), nil).Once()
+	chargePoint.On("DataTransfer", "Unknown").Return(core.NewDataTransferConfirmation(core.DataTransferStatusUnknownVendorId), nil)
+	chargePoint.On("DataTransfer", "Rejecting").Return(core.NewDataTransferConfirmation(core.DataTransferStatusRejected), nil)
+	s.cp.chargePoint = chargePoint
+
+	data, err := s.cp.sendDataTransfer("ChargePi", "echo", "data")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("response", data)
+
+	_, err = s.cp.sendDataTransfer("ChargePi", "unknown", nil)
+	s.Assert().ErrorIs(err, dataTransfer.ErrUnknownMessageId)
+
+	_, err = s.cp.sendDataTransfer("Unknown", "", nil)
+	s.Assert().ErrorIs(err, dataTransfer.ErrUnknownVendorId)
+
+	_, err = s.cp.sendDataTransfer("Rejecting", "", nil)
+	s.Assert().ErrorIs(err, dataTransfer.ErrRejected)
+}
+
 func (s *coreTestSuite) TestGetConfiguration() {
 	// Get all configuration vars
 	resp, err := s.cp.OnGetConfiguration(core.NewGetConfigurationRequest([]string{}))
@@ -80,7 +213,29 @@ func (s *coreTestSuite) TestGetConfiguration() {
 	s.Assert().Len(resp.ConfigurationKey, 0)
 }
 
-func (s *coreTestSuite) TestOnReset() {}
+func (s *coreTestSuite) TestOnReset() {
+	s.cp.scheduler.Clear()
+	defer s.cp.scheduler.Clear()
+
+	resp, err := s.cp.OnReset(core.NewResetRequest(core.ResetTypeSoft))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusAccepted, resp.Status)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+
+	s.cp.scheduler.Clear()
+
+	resp, err = s.cp.OnReset(core.NewResetRequest(core.ResetTypeHard))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusAccepted, resp.Status)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+
+	s.cp.scheduler.Clear()
+
+	resp, err = s.cp.OnReset(core.NewResetRequest("invalid"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusRejected, resp.Status)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 0)
+}
 
 func (s *coreTestSuite) TestOnUnlockConnector() {}
 
@@ -117,6 +272,7 @@ func (s *coreTestSuite) TestOnRemoteStopTransaction() {
 
 	// Connector not charging
 	connector.On("IsCharging").Return(false).Once()
+	connector.On("IsSuspended").Return(false).Once()
 	connectorManager.On("FindConnectorWithTransactionId", transactionIdStr).Return(connector).Once()
 	req = core.NewRemoteStopTransactionRequest(transactionId)
 	response, err = s.cp.OnRemoteStopTransaction(req)
@@ -10,6 +10,7 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/stretchr/testify/mock"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
 	"time"
 )
 
@@ -159,3 +160,13 @@ func (c *chargePointMock) Errors() <-chan error {
 	c.Called()
 	return nil
 }
+
+type securityEndpointMock struct {
+	securityExtension.Endpoint
+	mock.Mock
+}
+
+func (e *securityEndpointMock) SendRequest(request ocpp.Request) (ocpp.Response, error) {
+	args := e.Called(request)
+	return args.Get(0).(ocpp.Response), args.Error(1)
+}
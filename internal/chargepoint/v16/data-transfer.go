@@ -0,0 +1,48 @@
+package v16
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+)
+
+func (cp *ChargePoint) OnDataTransfer(request *core.DataTransferRequest) (confirmation *core.DataTransferConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	data, err := cp.dataTransfer.Handle(request.VendorId, request.MessageId, request.Data)
+	switch {
+	case errors.Is(err, dataTransfer.ErrUnknownVendorId):
+		return core.NewDataTransferConfirmation(core.DataTransferStatusUnknownVendorId), nil
+	case errors.Is(err, dataTransfer.ErrUnknownMessageId):
+		return core.NewDataTransferConfirmation(core.DataTransferStatusUnknownMessageId), nil
+	case err != nil:
+		cp.logger.WithError(err).Warnf("Rejected the data transfer of the vendor %s", request.VendorId)
+		return core.NewDataTransferConfirmation(core.DataTransferStatusRejected), nil
+	}
+
+	response := core.NewDataTransferConfirmation(core.DataTransferStatusAccepted)
+	response.Data = data
+	return response, nil
+}
+
+// sendDataTransfer sends the vendor specific message to the central system for the modules using the registry.
+func (cp *ChargePoint) sendDataTransfer(vendorId, messageId string, data interface{}) (interface{}, error) {
+	response, err := cp.chargePoint.DataTransfer(vendorId, func(request *core.DataTransferRequest) {
+		request.MessageId = messageId
+		request.Data = data
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.Status {
+	case core.DataTransferStatusAccepted:
+		return response.Data, nil
+	case core.DataTransferStatusUnknownVendorId:
+		return nil, dataTransfer.ErrUnknownVendorId
+	case core.DataTransferStatusUnknownMessageId:
+		return nil, dataTransfer.ErrUnknownMessageId
+	default:
+		return nil, dataTransfer.ErrRejected
+	}
+}
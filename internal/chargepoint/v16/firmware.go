@@ -0,0 +1,140 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"time"
+)
+
+func (cp *ChargePoint) OnUpdateFirmware(request *firmware.UpdateFirmwareRequest) (confirmation *firmware.UpdateFirmwareConfirmation, err error) {
+	var (
+		retries       = 0
+		retryInterval = 30
+		retrieveDate  = time.Now()
+		logInfo       = cp.logger.WithFields(log.Fields{
+			"location":     request.Location,
+			"retrieveDate": request.RetrieveDate,
+		})
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if request.Retries != nil {
+		retries = *request.Retries
+	}
+
+	if request.RetryInterval != nil {
+		retryInterval = *request.RetryInterval
+	}
+
+	if request.RetrieveDate != nil {
+		retrieveDate = request.RetrieveDate.Time
+	}
+
+	// The confirmation has no status, so the errors can only be reported through the FirmwareStatusNotification
+	err = cp.firmwareUpdater.UpdateFirmware(request.Location, retrieveDate, retries, retryInterval)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot update the firmware")
+	}
+
+	return firmware.NewUpdateFirmwareConfirmation(), nil
+}
+
+func (cp *ChargePoint) OnGetDiagnostics(request *firmware.GetDiagnosticsRequest) (confirmation *firmware.GetDiagnosticsConfirmation, err error) {
+	var (
+		retries       = 0
+		retryInterval = 30
+		startTime     *time.Time
+		stopTime      *time.Time
+		logInfo       = cp.logger.WithField("location", request.Location)
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if request.Retries != nil {
+		retries = *request.Retries
+	}
+
+	if request.RetryInterval != nil {
+		retryInterval = *request.RetryInterval
+	}
+
+	if request.StartTime != nil {
+		startTime = &request.StartTime.Time
+	}
+
+	if request.EndTime != nil {
+		stopTime = &request.EndTime.Time
+	}
+
+	// Include the latest state of the authorization cache
+	cp.authCache.DumpTags()
+
+	confirmation = firmware.NewGetDiagnosticsConfirmation()
+
+	// An empty file name indicates there is no file to upload
+	fileName, err := cp.diagnosticsManager.GetDiagnostics(cp.diagnosticFiles, request.Location, startTime, stopTime, retries, retryInterval)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot get the diagnostics")
+		return confirmation, nil
+	}
+
+	confirmation.FileName = fileName
+	return confirmation, nil
+}
+
+// sendFirmwareStatusNotification notifies the central system about the firmware status. The status of the signed
+// firmware update is sent with the SignedFirmwareStatusNotification.
+func (cp *ChargePoint) sendFirmwareStatusNotification(status firmware.FirmwareStatus) {
+	if requestId := cp.getSignedFirmwareRequestId(); requestId != nil {
+		cp.sendSignedFirmwareStatusNotification(status, *requestId)
+		return
+	}
+
+	cp.logger.Infof("Sending firmware status notification: %s", status)
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			cp.logger.WithError(protoError).Errorf("Server responded with error for firmware status notification")
+		}
+	}
+
+	err := util.SendRequest(cp.chargePoint, firmware.NewFirmwareStatusNotificationRequest(status), callback)
+	util.HandleRequestErr(err, "Cannot send firmware status notification")
+}
+
+// sendDiagnosticsStatusNotification notifies the central system about the diagnostics upload status.
+func (cp *ChargePoint) sendDiagnosticsStatusNotification(status firmware.DiagnosticsStatus) {
+	cp.logger.Infof("Sending diagnostics status notification: %s", status)
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			cp.logger.WithError(protoError).Errorf("Server responded with error for diagnostics status notification")
+		}
+	}
+
+	err := util.SendRequest(cp.chargePoint, firmware.NewDiagnosticsStatusNotificationRequest(status), callback)
+	util.HandleRequestErr(err, "Cannot send diagnostics status notification")
+}
+
+// isIdle checks if there are no ongoing transactions on the charge point.
+func (cp *ChargePoint) isIdle() bool {
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.GetSession().IsActive {
+			return false
+		}
+	}
+
+	return true
+}
+
+// restartAfterUpdate cleans up and restarts the process with the newly installed firmware.
+func (cp *ChargePoint) restartAfterUpdate() {
+	cp.CleanUp(core.ReasonOther)
+
+	err := util.RestartProcess()
+	if err != nil {
+		cp.logger.WithError(err).Fatal("Cannot restart after the firmware update")
+	}
+}
@@ -0,0 +1,69 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+)
+
+func (cp *ChargePoint) OnGetLocalListVersion(request *localauth.GetLocalListVersionRequest) (confirmation *localauth.GetLocalListVersionConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	localListEnabled, confErr := ocppConfigManager.GetConfigurationValue(v16.LocalAuthListEnabled.String())
+	if confErr != nil || localListEnabled != "true" {
+		return localauth.NewGetLocalListVersionConfirmation(-1), nil
+	}
+
+	return localauth.NewGetLocalListVersionConfirmation(cp.localAuthList.GetVersion()), nil
+}
+
+func (cp *ChargePoint) OnSendLocalList(request *localauth.SendLocalListRequest) (confirmation *localauth.SendLocalListConfirmation, err error) {
+	var (
+		logInfo = cp.logger.WithFields(log.Fields{
+			"version":    request.ListVersion,
+			"updateType": request.UpdateType,
+		})
+		localListEnabled, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListEnabled.String())
+		maxLength, lengthErr      = ocppConfigManager.GetConfigurationValue(v16.SendLocalListMaxLength.String())
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if confErr != nil || localListEnabled != "true" {
+		return localauth.NewSendLocalListConfirmation(localauth.UpdateStatusNotSupported), nil
+	}
+
+	if lengthErr == nil {
+		maxListLength, convErr := strconv.Atoi(maxLength)
+		if convErr == nil && len(request.LocalAuthorizationList) > maxListLength {
+			logInfo.Warn("Local list update exceeds the maximum length")
+			return localauth.NewSendLocalListConfirmation(localauth.UpdateStatusFailed), nil
+		}
+	}
+
+	updateType, authorizationData := authData.FromIdTagAuthorizationData(request.UpdateType, request.LocalAuthorizationList)
+	err = cp.localAuthList.UpdateList(request.ListVersion, updateType, authorizationData)
+	switch err {
+	case nil:
+		return localauth.NewSendLocalListConfirmation(localauth.UpdateStatusAccepted), nil
+	case auth.ErrVersionMismatch:
+		return localauth.NewSendLocalListConfirmation(localauth.UpdateStatusVersionMismatch), nil
+	default:
+		logInfo.WithError(err).Warn("Cannot update the local authorization list")
+		return localauth.NewSendLocalListConfirmation(localauth.UpdateStatusFailed), nil
+	}
+}
+
+func (cp *ChargePoint) setMaxLocalListTags() {
+	var (
+		maxTagsString, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListMaxLength.String())
+		maxTags, convErr       = strconv.Atoi(maxTagsString)
+	)
+
+	if confErr == nil && convErr == nil {
+		cp.localAuthList.SetMaxTags(maxTags)
+	}
+}
@@ -0,0 +1,103 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"os"
+	"testing"
+)
+
+const localListFile = "./local-auth-list.json"
+
+// updateLocalList updates the local authorization list with the entries of the SendLocalList request.
+func updateLocalList(list *auth.LocalAuthList, version int, updateType localauth.UpdateType, data []localauth.AuthorizationData) error {
+	listUpdateType, authorizationData := authData.FromIdTagAuthorizationData(updateType, data)
+	return list.UpdateList(version, listUpdateType, authorizationData)
+}
+
+type localAuthTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *localAuthTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		logger:        log.StandardLogger(),
+		scheduler:     scheduler.GetScheduler(),
+		localAuthList: auth.NewLocalAuthList(localListFile),
+	}
+}
+
+func (s *localAuthTestSuite) TearDownTest() {
+	_ = os.Remove(localListFile)
+	_ = ocppManager.UpdateKey(v16.LocalAuthListEnabled.String(), "true")
+}
+
+func (s *localAuthTestSuite) TestSendLocalList() {
+	request := localauth.NewSendLocalListRequest(1, localauth.UpdateTypeFull)
+	request.LocalAuthorizationList = []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted)},
+	}
+
+	response, err := s.cp.OnSendLocalList(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(localauth.UpdateStatusAccepted, response.Status)
+
+	version, err := s.cp.OnGetLocalListVersion(localauth.NewGetLocalListVersionRequest())
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(1, version.ListVersion)
+
+	// Differential update with the same version
+	request = localauth.NewSendLocalListRequest(1, localauth.UpdateTypeDifferential)
+	response, err = s.cp.OnSendLocalList(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(localauth.UpdateStatusVersionMismatch, response.Status)
+
+	// Too many tags in a single update
+	request = localauth.NewSendLocalListRequest(2, localauth.UpdateTypeFull)
+	for i := 0; i < 21; i++ {
+		request.LocalAuthorizationList = append(request.LocalAuthorizationList, localauth.AuthorizationData{
+			IdTag:     string(rune('a' + i)),
+			IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted),
+		})
+	}
+
+	response, err = s.cp.OnSendLocalList(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(localauth.UpdateStatusFailed, response.Status)
+
+	// Local list disabled
+	s.Require().NoError(ocppManager.UpdateKey(v16.LocalAuthListEnabled.String(), "false"))
+	response, err = s.cp.OnSendLocalList(localauth.NewSendLocalListRequest(3, localauth.UpdateTypeFull))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(localauth.UpdateStatusNotSupported, response.Status)
+
+	version, err = s.cp.OnGetLocalListVersion(localauth.NewGetLocalListVersionRequest())
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(-1, version.ListVersion)
+}
+
+func (s *localAuthTestSuite) TestIsTagAuthorized() {
+	err := updateLocalList(s.cp.localAuthList, 1, localauth.UpdateTypeFull, []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted)},
+	})
+	s.Require().NoError(err)
+
+	// Authorized from the local list without contacting the central system
+	s.Assert().True(s.cp.isTagAuthorized(tagId))
+}
+
+func TestLocalAuth(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(localAuthTestSuite))
+}
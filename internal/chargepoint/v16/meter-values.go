@@ -0,0 +1,132 @@
+package v16
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const alignedMeterValuesTag = "alignedMeterValues"
+
+// scheduleAlignedMeterValues schedules the clock-aligned meter values every ClockAlignedDataInterval seconds, aligned
+// to midnight. If the interval is zero, the clock-aligned meter values are disabled.
+func (cp *ChargePoint) scheduleAlignedMeterValues() {
+	_ = cp.scheduler.RemoveByTag(alignedMeterValuesTag)
+
+	intervalValue, err := ocppManager.GetConfigurationValue(v16.ClockAlignedDataInterval.String())
+	if err != nil {
+		return
+	}
+
+	interval, err := strconv.Atoi(intervalValue)
+	if err != nil || interval <= 0 {
+		cp.logger.Debug("Clock-aligned meter values are disabled")
+		return
+	}
+
+	var (
+		duration = time.Duration(interval) * time.Second
+		// Truncate rounds relative to the zero time, so the boundaries are aligned to midnight (UTC)
+		start = time.Now().Truncate(duration).Add(duration)
+	)
+
+	cp.logger.Infof("Scheduling clock-aligned meter values every %d seconds, starting at %s", interval, start)
+
+	_, err = cp.scheduler.Every(interval).Seconds().StartAt(start).Tag(alignedMeterValuesTag).Do(cp.sendAlignedMeterValues)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error scheduling clock-aligned meter values")
+	}
+}
+
+// sendAlignedMeterValues samples the MeterValuesAlignedData measurands on all connectors and sends them with the Sample.Clock context.
+// The meter values of connector 0 are the sum of all the connectors' meter values. The StopTxnAlignedData measurands are
+// added to the transaction data of the ongoing transactions.
+func (cp *ChargePoint) sendAlignedMeterValues() {
+	var (
+		measurands        = util.GetMeasurands(v16.MeterValuesAlignedData.String())
+		stopTxnMeasurands = util.GetMeasurands(v16.StopTxnAlignedData.String())
+		chargePointValue  []types.MeterValue
+	)
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		isTransactionActive := c.GetSession().IsActive
+
+		if isTransactionActive && len(stopTxnMeasurands) > 0 {
+			if meterValue := c.GetMeterValue(stopTxnMeasurands, types.ReadingContextSampleClock); meterValue != nil {
+				c.AddTransactionData(*meterValue)
+			}
+		}
+
+		if len(measurands) == 0 {
+			continue
+		}
+
+		meterValue := c.GetMeterValue(measurands, types.ReadingContextSampleClock)
+		if meterValue == nil {
+			continue
+		}
+
+		chargePointValue = append(chargePointValue, *meterValue)
+
+		// Aligned meter values during a transaction belong to the transaction
+		var transactionId *int
+		if id, err := strconv.Atoi(c.GetTransactionId()); err == nil && isTransactionActive {
+			transactionId = &id
+		}
+
+		cp.sendMeterValues(models.NewMeterValueNotification(c.GetEvseId(), c.GetConnectorId(), transactionId, *meterValue))
+	}
+
+	if len(chargePointValue) > 0 {
+		cp.sendMeterValues(models.NewMeterValueNotification(0, 0, nil, sumMeterValues(chargePointValue)))
+	}
+}
+
+// sumMeterValues sums the sampled values with the same measurand. The voltage is averaged instead.
+func sumMeterValues(meterValues []types.MeterValue) types.MeterValue {
+	var (
+		sums       = map[types.Measurand]float64{}
+		counts     = map[types.Measurand]int{}
+		measurands []types.Measurand
+		context    types.ReadingContext
+	)
+
+	for _, meterValue := range meterValues {
+		for _, sample := range meterValue.SampledValue {
+			value, err := strconv.ParseFloat(strings.TrimSpace(sample.Value), 64)
+			if err != nil {
+				continue
+			}
+
+			if _, isFound := sums[sample.Measurand]; !isFound {
+				measurands = append(measurands, sample.Measurand)
+			}
+
+			sums[sample.Measurand] += value
+			counts[sample.Measurand]++
+			context = sample.Context
+		}
+	}
+
+	meterValue := types.MeterValue{Timestamp: types.NewDateTime(time.Now())}
+	for _, measurand := range measurands {
+		value := sums[measurand]
+		if measurand == types.MeasurandVoltage {
+			value = value / float64(counts[measurand])
+		}
+
+		meterValue.SampledValue = append(meterValue.SampledValue, types.SampledValue{
+			Value:     fmt.Sprintf("%.3f", value),
+			Context:   context,
+			Measurand: measurand,
+		})
+	}
+
+	return meterValue
+}
@@ -0,0 +1,137 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"testing"
+	"time"
+)
+
+type meterValuesTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *meterValuesTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		logger:           log.StandardLogger(),
+		scheduler:        scheduler.GetScheduler(),
+		transactionQueue: transactionQueue.NewQueue(""),
+	}
+}
+
+func (s *meterValuesTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+	_ = ocppManager.UpdateKey(v16.ClockAlignedDataInterval.String(), "0")
+	_ = ocppManager.UpdateKey(v16.MeterValuesAlignedData.String(), "false")
+	_ = ocppManager.UpdateKey(v16.StopTxnAlignedData.String(), "")
+}
+
+func (s *meterValuesTestSuite) TestScheduleAlignedMeterValues() {
+	// Disabled
+	s.cp.scheduleAlignedMeterValues()
+	s.Assert().Len(s.cp.scheduler.Jobs(), 0)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.ClockAlignedDataInterval.String(), "900"))
+	s.cp.scheduleAlignedMeterValues()
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+
+	// The first run is at the next quarter-hour
+	nextRun := s.cp.scheduler.Jobs()[0].NextRun()
+	s.Assert().EqualValues(0, nextRun.Round(time.Second).Unix()%900)
+	s.Assert().True(nextRun.After(time.Now()))
+
+	// Rescheduling replaces the job
+	s.cp.scheduleAlignedMeterValues()
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+}
+
+func (s *meterValuesTestSuite) TestSendAlignedMeterValues() {
+	var (
+		chargePoint    = new(chargePointMock)
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		measurands     = []types.Measurand{types.MeasurandEnergyActiveImportRegister, types.MeasurandVoltage}
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.MeterValuesAlignedData.String(), "Energy.Active.Import.Register, Voltage"))
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTxnAlignedData.String(), "Energy.Active.Import.Register"))
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(1)
+	connectorMock.On("GetTransactionId").Return("")
+	connectorMock.On("GetSession").Return(*session.NewEmptySession())
+	connectorMock.On("GetMeterValue", measurands, types.ReadingContextSampleClock).Return(&types.MeterValue{
+		Timestamp: types.NewDateTime(time.Now()),
+		SampledValue: []types.SampledValue{
+			{Value: "10.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister},
+			{Value: "230.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandVoltage},
+		},
+	})
+
+	// Connector with a transaction
+	transactionSession := session.NewEmptySession()
+	s.Require().NoError(transactionSession.StartSession("1234", tagId))
+	connector2Mock.On("GetEvseId").Return(1)
+	connector2Mock.On("GetConnectorId").Return(2)
+	connector2Mock.On("GetTransactionId").Return("1234")
+	connector2Mock.On("GetSession").Return(*transactionSession)
+	connector2Mock.On("GetMeterValue", measurands, types.ReadingContextSampleClock).Return(&types.MeterValue{
+		Timestamp: types.NewDateTime(time.Now()),
+		SampledValue: []types.SampledValue{
+			{Value: "5.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister},
+			{Value: "240.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandVoltage},
+		},
+	})
+
+	// The StopTxnAlignedData measurands are added to the transaction data
+	stopTxnMeterValue := types.MeterValue{
+		Timestamp:    types.NewDateTime(time.Now()),
+		SampledValue: []types.SampledValue{{Value: "5.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister}},
+	}
+	connector2Mock.On("GetMeterValue", []types.Measurand{types.MeasurandEnergyActiveImportRegister}, types.ReadingContextSampleClock).Return(&stopTxnMeterValue)
+	connector2Mock.On("AddTransactionData", []types.MeterValue{stopTxnMeterValue}).Return()
+
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, connector2Mock})
+	chargePoint.On("SendRequestAsync", mock.AnythingOfType("*core.MeterValuesRequest")).Return(core.NewMeterValuesConfirmation(), nil, nil)
+
+	s.cp.chargePoint = chargePoint
+	s.cp.connectorManager = managerMock
+
+	s.cp.sendAlignedMeterValues()
+
+	// Connector 1 and connector 0 are sent immediately, the transaction meter values are queued
+	chargePoint.AssertNumberOfCalls(s.T(), "SendRequestAsync", 2)
+	s.Assert().EqualValues(1, s.cp.transactionQueue.Len())
+
+	connectorMock.AssertNotCalled(s.T(), "AddTransactionData", mock.Anything)
+	connector2Mock.AssertCalled(s.T(), "AddTransactionData", []types.MeterValue{stopTxnMeterValue})
+
+	request := chargePoint.Calls[1].Arguments.Get(0).(*core.MeterValuesRequest)
+	s.Assert().EqualValues(0, request.ConnectorId)
+	s.Assert().Nil(request.TransactionId)
+	s.Require().Len(request.MeterValue, 1)
+	s.Assert().EqualValues([]types.SampledValue{
+		{Value: "15.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister},
+		{Value: "235.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandVoltage},
+	}, request.MeterValue[0].SampledValue)
+}
+
+func TestMeterValues(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(meterValuesTestSuite))
+}
@@ -3,6 +3,10 @@ package v16
 import (
 	"context"
 	log "github.com/sirupsen/logrus"
+	chargePointHardware "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/hardware"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
@@ -20,23 +24,7 @@ func WithLogger(logger *log.Logger) Options {
 
 // WithReaderFromSettings creates a TagReader based on the settings.
 func WithReaderFromSettings(ctx context.Context, readerSettings settings.TagReader) Options {
-	return func(point *ChargePoint) {
-		if !readerSettings.IsEnabled {
-			return
-		}
-
-		// Create reader based on settings
-		tagReader, err := reader.NewTagReader(readerSettings)
-		if err != nil {
-			return
-		}
-
-		point.TagReader = tagReader
-
-		// Listen for incoming tags
-		go tagReader.ListenForTags(ctx)
-		go point.ListenForTag(ctx, tagReader.GetTagChannel())
-	}
+	return WithReader(ctx, chargePointHardware.NewReaderFromSettings(readerSettings))
 }
 
 // WithReader adds the reader to the charge point and starts listening to the Reader.
@@ -45,39 +33,52 @@ func WithReader(ctx context.Context, tagReader reader.Reader) Options {
 		if util.IsNilInterfaceOrPointer(tagReader) {
 			return
 		}
-		point.TagReader = tagReader
 
-		// Listen for incoming tags
-		go tagReader.ListenForTags(ctx)
-		go point.ListenForTag(ctx, tagReader.GetTagChannel())
+		point.TagReader = tagReader
+		chargePointHardware.ListenToReader(ctx, tagReader, point)
 	}
 }
 
 // WithDisplayFromSettings create a LCD based on the provided settings.
 func WithDisplayFromSettings(ctx context.Context, lcdSettings settings.Lcd) Options {
+	return WithDisplay(ctx, chargePointHardware.NewDisplayFromSettings(lcdSettings))
+}
+
+// WithDisplay add the provided LCD to the ChargePoint.
+func WithDisplay(ctx context.Context, display display.LCD) Options {
 	return func(point *ChargePoint) {
-		if !lcdSettings.IsEnabled {
+		if util.IsNilInterfaceOrPointer(display) {
 			return
 		}
 
-		lcd, err := display.NewDisplay(lcdSettings)
-		if err != nil {
+		point.LCD = display
+		go display.ListenForMessages(ctx)
+	}
+}
+
+// WithDataTransfer sets the registry of the vendor specific messages, which is shared with the other modules.
+func WithDataTransfer(registry dataTransfer.Registry) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(registry) {
 			return
 		}
 
-		point.LCD = lcd
-		go lcd.ListenForMessages(ctx)
+		point.dataTransfer = registry
 	}
 }
 
-// WithDisplay add the provided LCD to the ChargePoint.
-func WithDisplay(ctx context.Context, display display.LCD) Options {
+// WithDiagnosticFiles sets the files that are included in the diagnostics.
+func WithDiagnosticFiles(files diagnostics.Files) Options {
 	return func(point *ChargePoint) {
-		if util.IsNilInterfaceOrPointer(display) {
-			return
-		}
+		point.diagnosticFiles = files
+	}
+}
 
-		point.LCD = display
-		go display.ListenForMessages(ctx)
+// WithClient sets the websocket client, which may already be connected to the central system with the negotiated subprotocol.
+func WithClient(client *connectionSupervisor.Client) Options {
+	return func(point *ChargePoint) {
+		if client != nil {
+			point.wsClient = client
+		}
 	}
 }
@@ -2,49 +2,287 @@ package v16
 
 import (
 	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"time"
 )
 
 func (cp *ChargePoint) OnReserveNow(request *reservation.ReserveNowRequest) (confirmation *reservation.ReserveNowConfirmation, err error) {
 	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ConnectorId)
-	connector := cp.connectorManager.FindConnector(1, request.ConnectorId)
 
-	if util.IsNilInterfaceOrPointer(connector) {
+	if cp.getAvailability() != core.AvailabilityTypeOperative {
 		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusUnavailable), nil
-	} else if !connector.IsAvailable() {
-		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusOccupied), nil
 	}
 
-	err = connector.ReserveConnector(request.ReservationId, request.IdTag)
-	if err != nil {
+	if request.ExpiryDate == nil || !request.ExpiryDate.After(time.Now()) {
 		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusRejected), nil
 	}
 
-	timeFormat := fmt.Sprintf("%d:%d", request.ExpiryDate.Hour(), request.ExpiryDate.Minute())
-	_, schedulerErr := cp.scheduler.Every(1).Day().At(timeFormat).LimitRunsTo(1).Do(connector.RemoveReservation)
-	if schedulerErr != nil {
+	newReservation := settingsData.Reservation{
+		ReservationId: request.ReservationId,
+		ConnectorId:   request.ConnectorId,
+		IdTag:         request.IdTag,
+		ParentIdTag:   request.ParentIdTag,
+		ExpiryDate:    request.ExpiryDate.Time,
+	}
+
+	// A reservation with the same id replaces the previous one
+	if previous, isFound := cp.reservations.GetReservation(request.ReservationId); isFound && previous.ConnectorId != request.ConnectorId {
+		cp.removeReservation(*previous)
+	}
+
+	var status reservation.ReservationStatus
+	if request.ConnectorId == 0 {
+		status = cp.reserveChargePoint(request.ReservationId)
+	} else {
+		status = cp.reserveConnector(cp.connectorManager.FindConnector(1, request.ConnectorId), request.ReservationId, request.IdTag)
+	}
+
+	if status != reservation.ReservationStatusAccepted {
+		return reservation.NewReserveNowConfirmation(status), nil
+	}
+
+	err = cp.reservations.AddReservation(newReservation)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot store the reservation")
+		cp.removeReservation(newReservation)
 		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusRejected), nil
 	}
 
+	cp.scheduleReservationExpiry(newReservation)
 	return reservation.NewReserveNowConfirmation(reservation.ReservationStatusAccepted), nil
 }
 
 func (cp *ChargePoint) OnCancelReservation(request *reservation.CancelReservationRequest) (confirmation *reservation.CancelReservationConfirmation, err error) {
 	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ReservationId)
-	var (
-		connector = cp.connectorManager.FindConnectorWithReservationId(request.ReservationId)
-		status    = reservation.CancelReservationStatusAccepted
-	)
 
-	if util.IsNilInterfaceOrPointer(connector) {
+	existingReservation, isFound := cp.reservations.GetReservation(request.ReservationId)
+	if !isFound {
 		return reservation.NewCancelReservationConfirmation(reservation.CancelReservationStatusRejected), nil
 	}
 
-	err = connector.RemoveReservation()
+	cp.removeReservation(*existingReservation)
+	return reservation.NewCancelReservationConfirmation(reservation.CancelReservationStatusAccepted), nil
+}
+
+// reserveConnector reserves the connector, if its status allows it.
+func (cp *ChargePoint) reserveConnector(c connector.Connector, reservationId int, tagId string) reservation.ReservationStatus {
+	if util.IsNilInterfaceOrPointer(c) {
+		return reservation.ReservationStatusUnavailable
+	}
+
+	status, _ := c.GetStatus()
+	switch status {
+	case core.ChargePointStatusAvailable:
+	case core.ChargePointStatusReserved:
+		if c.GetReservationId() != reservationId {
+			return reservation.ReservationStatusOccupied
+		}
+	case core.ChargePointStatusFaulted:
+		return reservation.ReservationStatusFaulted
+	case core.ChargePointStatusUnavailable:
+		return reservation.ReservationStatusUnavailable
+	default:
+		return reservation.ReservationStatusOccupied
+	}
+
+	err := c.ReserveConnector(reservationId, tagId)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot reserve the connector")
+		return reservation.ReservationStatusRejected
+	}
+
+	return reservation.ReservationStatusAccepted
+}
+
+// reserveChargePoint checks if the charge point can be reserved. The connectors' status does not change, but at least
+// one connector must remain available for every reservation of the charge point.
+func (cp *ChargePoint) reserveChargePoint(reservationId int) reservation.ReservationStatus {
+	isSupported, err := ocppConfigManager.GetConfigurationValue(v16.ReserveConnectorZeroSupported.String())
+	if err != nil || isSupported != "true" {
+		return reservation.ReservationStatusRejected
+	}
+
+	reserved := 0
+	for _, r := range cp.reservations.GetReservations() {
+		if r.ConnectorId == 0 && r.ReservationId != reservationId {
+			reserved++
+		}
+	}
+
+	if cp.getNumberOfAvailableConnectors() <= reserved {
+		return reservation.ReservationStatusOccupied
+	}
+
+	return reservation.ReservationStatusAccepted
+}
+
+// scheduleReservationExpiry removes the reservation at its expiry date.
+func (cp *ChargePoint) scheduleReservationExpiry(r settingsData.Reservation) {
+	tag := fmt.Sprintf("reservation%d", r.ReservationId)
+	_ = cp.scheduler.RemoveByTag(tag)
+
+	_, err := cp.scheduler.Every(1).Day().StartAt(r.ExpiryDate).LimitRunsTo(1).Tag(tag).Do(cp.expireReservation, r.ReservationId)
 	if err != nil {
-		status = reservation.CancelReservationStatusRejected
+		cp.logger.WithError(err).Errorf("Cannot schedule the reservation expiry")
+	}
+}
+
+func (cp *ChargePoint) expireReservation(reservationId int) {
+	r, isFound := cp.reservations.GetReservation(reservationId)
+	if !isFound {
+		return
+	}
+
+	cp.logger.WithField("reservationId", reservationId).Info("Reservation expired")
+	cp.removeReservation(*r)
+}
+
+// removeReservation removes the reservation, its expiry and makes the reserved connector available.
+func (cp *ChargePoint) removeReservation(r settingsData.Reservation) {
+	logInfo := cp.logger.WithFields(log.Fields{
+		"reservationId": r.ReservationId,
+		"connectorId":   r.ConnectorId,
+	})
+	logInfo.Debug("Removing the reservation")
+
+	_ = cp.reservations.RemoveReservation(r.ReservationId)
+	_ = cp.scheduler.RemoveByTag(fmt.Sprintf("reservation%d", r.ReservationId))
+
+	if r.ConnectorId == 0 {
+		return
+	}
+
+	c := cp.connectorManager.FindConnector(1, r.ConnectorId)
+	if !util.IsNilInterfaceOrPointer(c) && c.IsReserved() && c.GetReservationId() == r.ReservationId {
+		err := c.RemoveReservation()
+		if err != nil {
+			logInfo.WithError(err).Errorf("Cannot remove the reservation from the connector")
+		}
+	}
+}
+
+// restoreReservations reserves the connectors with the stored reservations and schedules their expiry.
+// The expired reservations are removed.
+func (cp *ChargePoint) restoreReservations() {
+	for _, r := range cp.reservations.GetReservations() {
+		if !r.ExpiryDate.After(time.Now()) {
+			cp.removeReservation(r)
+			continue
+		}
+
+		if r.ConnectorId > 0 {
+			c := cp.connectorManager.FindConnector(1, r.ConnectorId)
+			if util.IsNilInterfaceOrPointer(c) || c.ReserveConnector(r.ReservationId, r.IdTag) != nil {
+				cp.removeReservation(r)
+				continue
+			}
+		}
+
+		cp.scheduleReservationExpiry(r)
+	}
+
+	// The connectors which were reserved with a reservation that is no longer stored
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.IsReserved() && c.GetReservationId() <= 0 {
+			_ = c.RemoveReservation()
+		}
+	}
+}
+
+// getReservation returns the reservation the tag uses to start charging on the connector. Returns an error if the connector
+// is reserved for another tag or if charging would leave no available connector for the reservations of the charge point.
+func (cp *ChargePoint) getReservation(c connector.Connector, tagId string) (*settingsData.Reservation, error) {
+	if c.IsReserved() {
+		r, isFound := cp.reservations.GetReservation(c.GetReservationId())
+		if !isFound || !cp.isReservedFor(*r, tagId) {
+			return nil, errors.ErrConnectorReserved
+		}
+
+		return r, nil
+	}
+
+	reserved := 0
+	for _, r := range cp.reservations.GetReservations() {
+		if r.ConnectorId != 0 {
+			continue
+		}
+
+		if cp.isReservedFor(r, tagId) {
+			return &r, nil
+		}
+
+		reserved++
+	}
+
+	if reserved > 0 && cp.getNumberOfAvailableConnectors()-1 < reserved {
+		return nil, errors.ErrConnectorReserved
+	}
+
+	return nil, nil
+}
+
+// isReservedFor checks if the reservation is for the tag or for the group of tags the tag belongs to.
+func (cp *ChargePoint) isReservedFor(r settingsData.Reservation, tagId string) bool {
+	if r.IdTag == tagId {
+		return true
+	}
+
+	if r.ParentIdTag == "" {
+		return false
+	}
+
+	return cp.getParentIdTag(tagId) == r.ParentIdTag
+}
+
+// getParentIdTag returns the parent id tag from the local authorization list or the cache. If the tag is not found
+// and the charge point is online, the central system is asked for the tag info.
+func (cp *ChargePoint) getParentIdTag(tagId string) string {
+	if tokenInfo, isFound := auth.GetTokenInfo(cp.authCache, cp.localAuthList, authData.NewIdTag(tagId)); isFound {
+		return tokenInfo.GetGroupId()
+	}
+
+	if !cp.IsOnline() {
+		return ""
+	}
+
+	tagInfo, err := cp.sendAuthorizeRequest(tagId)
+	if err != nil || tagInfo == nil {
+		return ""
+	}
+
+	return tagInfo.ParentIdTag
+}
+
+// findConnectorForTag returns the connector reserved for the tag or the first available connector.
+func (cp *ChargePoint) findConnectorForTag(tagId string) connector.Connector {
+	for _, r := range cp.reservations.GetReservations() {
+		if r.ConnectorId > 0 && r.IdTag == tagId {
+			if c := cp.connectorManager.FindConnector(1, r.ConnectorId); !util.IsNilInterfaceOrPointer(c) && c.IsReserved() {
+				return c
+			}
+		}
+	}
+
+	return cp.connectorManager.FindAvailableConnector()
+}
+
+func (cp *ChargePoint) getNumberOfAvailableConnectors() int {
+	available := 0
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.IsAvailable() {
+			available++
+		}
 	}
 
-	return reservation.NewCancelReservationConfirmation(status), nil
+	return available
 }
@@ -2,12 +2,26 @@ package v16
 
 import (
 	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	chargePointErrors "github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -24,28 +38,45 @@ type reservationTestSuite struct {
 }
 
 func (s *reservationTestSuite) SetupTest() {
+	localAuthList := auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json"))
+	s.Require().NoError(updateLocalList(localAuthList, 1, localauth.UpdateTypeFull, []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted)},
+	}))
+
 	s.cp = &ChargePoint{
-		logger:    log.StandardLogger(),
-		scheduler: scheduler.GetScheduler(),
+		availability:     core.AvailabilityTypeOperative,
+		logger:           log.StandardLogger(),
+		scheduler:        scheduler.GetScheduler(),
+		localAuthList:    localAuthList,
+		authCache:        auth.NewAuthCache(""),
+		reservations:     reservations.NewManager(""),
+		profileManager:   smartCharging.NewProfileManager(),
+		transactionQueue: transactionQueue.NewQueue(""),
 	}
 }
 
+func (s *reservationTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+	_ = ocppManager.UpdateKey(v16.ReserveConnectorZeroSupported.String(), "false")
+}
+
 func (s *reservationTestSuite) TestReservation() {
 	var (
-		connectorMock = new(test.ConnectorMock)
-		managerMock   = new(test.ManagerMock)
-		expiryDate    = types.NewDateTime(time.Now().Add(time.Minute))
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		expiryDate     = types.NewDateTime(time.Now().Add(time.Minute))
 	)
 
 	// Set connector expectations
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError).Once()
 	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
-	connectorMock.On("IsAvailable").Return(true).Once()
-	connectorMock.On("RemoveReservation").Return()
 
 	// Set manager expectations
-	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock).Twice()
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, 2).Return(connector2Mock)
 	// Connector not found
-	managerMock.On("FindConnector", 1, 2).Return(nil).Once()
+	managerMock.On("FindConnector", 1, 3).Return(nil)
 	s.cp.connectorManager = managerMock
 
 	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, reservationId))
@@ -53,19 +84,144 @@ func (s *reservationTestSuite) TestReservation() {
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
 
+	// The reservation is stored and expires at the expiry date
+	storedReservation, isFound := s.cp.reservations.GetReservation(reservationId)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(tagId, storedReservation.IdTag)
+	s.Assert().EqualValues(connectorId, storedReservation.ConnectorId)
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+	s.Assert().EqualValues(expiryDate.Round(time.Second).Unix(), s.cp.scheduler.Jobs()[0].NextRun().Round(time.Second).Unix())
+
+	// The expiry date is in the past
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, types.NewDateTime(time.Now().Add(-time.Minute)), tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
 	// No connector with connectorId
-	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, reservationId))
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(3, expiryDate, tagId, 2))
 	s.Assert().NoError(err)
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusUnavailable, response.Status)
 
+	// The connector status doesn't allow the reservation
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusCharging, core.NoError).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusOccupied, response.Status)
+
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusFaulted, core.InternalError).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusFaulted, response.Status)
+
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusUnavailable, core.NoError).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusUnavailable, response.Status)
+
+	// The connector is reserved with another reservation
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusReserved, core.NoError).Once()
+	connector2Mock.On("GetReservationId").Return(5).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusOccupied, response.Status)
+
 	// Unable to reserve for whatever reason
-	connectorMock.On("IsAvailable").Return(true).Once()
-	connectorMock.On("ReserveConnector", 2, tagId).Return(errors.New("unable to reserve the connector")).Once()
-	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, 2))
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError).Once()
+	connector2Mock.On("ReserveConnector", 2, tagId).Return(errors.New("unable to reserve the connector")).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
+	_, isFound = s.cp.reservations.GetReservation(2)
+	s.Assert().False(isFound)
+
+	// The charge point is unavailable
+	s.cp.setAvailability(core.AvailabilityTypeInoperative)
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusUnavailable, response.Status)
+}
+
+func (s *reservationTestSuite) TestReplaceReservation() {
+	var (
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		expiryDate     = types.NewDateTime(time.Now().Add(time.Minute))
+	)
+
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError).Once()
+	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+	connector2Mock.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError).Once()
+	connector2Mock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, 2).Return(connector2Mock)
+	s.cp.connectorManager = managerMock
+
+	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+
+	// The reservation with the same id moves to the other connector
+	connectorMock.On("IsReserved").Return(true).Once()
+	connectorMock.On("GetReservationId").Return(reservationId).Once()
+	connectorMock.On("RemoveReservation").Return(nil).Once()
+
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
+
+	storedReservation, isFound := s.cp.reservations.GetReservation(reservationId)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(2, storedReservation.ConnectorId)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+}
+
+func (s *reservationTestSuite) TestReserveChargePoint() {
+	var (
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		expiryDate     = types.NewDateTime(time.Now().Add(time.Minute))
+	)
+
+	connectorMock.On("IsAvailable").Return(true)
+	connector2Mock.On("IsAvailable").Return(true)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, connector2Mock})
+	s.cp.connectorManager = managerMock
+
+	// Connector 0 reservations are not supported
+	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.ReserveConnectorZeroSupported.String(), "true"))
+
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, "tag2", 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+
+	// Every available connector is reserved
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, "tag3", 3))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusOccupied, response.Status)
+
+	// Only the tags with the reservation can start charging
+	connectorMock.On("IsReserved").Return(false)
+	_, err = s.cp.getReservation(connectorMock, "tag3")
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorReserved)
+
+	chargePointReservation, err := s.cp.getReservation(connectorMock, "tag2")
+	s.Assert().NoError(err)
+	s.Require().NotNil(chargePointReservation)
+	s.Assert().EqualValues(2, chargePointReservation.ReservationId)
 }
 
 func (s *reservationTestSuite) TestCancelReservation() {
@@ -74,34 +230,168 @@ func (s *reservationTestSuite) TestCancelReservation() {
 		managerMock   = new(test.ManagerMock)
 	)
 
+	s.Require().NoError(s.cp.reservations.AddReservation(settingsData.Reservation{
+		ReservationId: reservationId,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+
 	// Set connector expectations
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
 	connectorMock.On("RemoveReservation").Return(nil).Once()
 
 	// Set manager expectations
-	managerMock.On("FindConnectorWithReservationId", reservationId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
 	s.cp.connectorManager = managerMock
 
-	response, err := s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(1))
+	response, err := s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(reservationId))
 	s.Assert().NoError(err)
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.CancelReservationStatusAccepted, response.Status)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
 
-	// Something went wrong with the reservation
-	connectorMock.On("RemoveReservation").Return(errors.New("something went wrong")).Once()
+	// The reservation no longer exists
 	response, err = s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(reservationId))
 	s.Assert().NoError(err)
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.CancelReservationStatusRejected, response.Status)
+}
+
+func (s *reservationTestSuite) TestExpireReservation() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		expired       = settingsData.Reservation{
+			ReservationId: reservationId,
+			ConnectorId:   connectorId,
+			IdTag:         tagId,
+			ExpiryDate:    time.Now().Add(time.Minute),
+		}
+	)
+
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+	connectorMock.On("RemoveReservation").Return(nil).Once()
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	s.Require().NoError(s.cp.reservations.AddReservation(expired))
+	s.cp.scheduleReservationExpiry(expired)
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+
+	s.cp.expireReservation(reservationId)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
+	s.Assert().Empty(s.cp.reservations.GetReservations())
+	s.Assert().Empty(s.cp.scheduler.Jobs())
+}
+
+func (s *reservationTestSuite) TestRestoreReservations() {
+	var (
+		connectorMock  = new(test.ConnectorMock)
+		connector2Mock = new(test.ConnectorMock)
+		managerMock    = new(test.ManagerMock)
+		filePath       = filepath.Join(s.T().TempDir(), "reservations.json")
+	)
+
+	// One of the reservations expired while the charge point was offline
+	s.Require().NoError(settings.WriteToFile(filePath, settingsData.ReservationsFile{
+		Reservations: []settingsData.Reservation{
+			{ReservationId: reservationId, ConnectorId: connectorId, IdTag: tagId, ExpiryDate: time.Now().Add(time.Minute)},
+			{ReservationId: 2, ConnectorId: 2, IdTag: tagId, ExpiryDate: time.Now().Add(-time.Minute)},
+		},
+	}))
+	s.cp.reservations = reservations.NewManager(filePath)
+	s.cp.reservations.LoadFromFile()
 
-	// No connector with the reservation
-	managerMock.On("FindConnectorWithReservationId", 2).Return(nil)
-	response, err = s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(2))
+	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+	connector2Mock.On("IsReserved").Return(true)
+	connector2Mock.On("GetReservationId").Return(-1)
+	connector2Mock.On("RemoveReservation").Return(nil).Once()
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, 2).Return(connector2Mock)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, connector2Mock})
+	s.cp.connectorManager = managerMock
+
+	s.cp.restoreReservations()
+
+	connectorMock.AssertCalled(s.T(), "ReserveConnector", reservationId, tagId)
+	connectorMock.AssertNotCalled(s.T(), "RemoveReservation")
+	connector2Mock.AssertCalled(s.T(), "RemoveReservation")
+	s.Assert().Len(s.cp.reservations.GetReservations(), 1)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+}
+
+func (s *reservationTestSuite) TestStartChargingWithReservation() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	s.Require().NoError(s.cp.reservations.AddReservation(settingsData.Reservation{
+		ReservationId: reservationId,
+		ConnectorId:   connectorId,
+		IdTag:         "reservationTag",
+		ParentIdTag:   "groupTag",
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(false)
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+	connectorMock.On("GetMaxChargingTime").Return(15)
+	connectorMock.On("GetMeterReading").Return(0)
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	// The tag is not in the reserving group
+	err := s.cp.startChargingConnector(connectorMock, tagId)
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorReserved)
+	s.Assert().EqualValues(0, s.cp.transactionQueue.Len())
+
+	// The tag belongs to the reserving group
+	tagInfo := types.NewIdTagInfo(types.AuthorizationStatusAccepted)
+	tagInfo.ParentIdTag = "groupTag"
+	s.Require().NoError(updateLocalList(s.cp.localAuthList, 2, localauth.UpdateTypeDifferential, []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: tagInfo},
+	}))
+
+	// The reservation is kept if the connector cannot start charging
+	connectorMock.On("StartCharging", "-1", tagId).Return(errors.New("relay failure")).Once()
+
+	err = s.cp.startChargingConnector(connectorMock, tagId)
+	s.Assert().Error(err)
+	connectorMock.AssertNotCalled(s.T(), "RemoveReservation")
+	s.Assert().Len(s.cp.reservations.GetReservations(), 1)
+	_ = (&transactionTestSuite{Suite: s.Suite, cp: s.cp}).sendQueuedRequests()
+
+	connectorMock.On("RemoveReservation").Return(nil).Once()
+	connectorMock.On("StartCharging", "-2", tagId).Return(nil).Once()
+
+	err = s.cp.startChargingConnector(connectorMock, tagId)
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
-	s.Assert().EqualValues(reservation.CancelReservationStatusRejected, response.Status)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
+	connectorMock.AssertCalled(s.T(), "StartCharging", "-2", tagId)
+
+	// The reservation is used by the transaction
+	s.Assert().Empty(s.cp.reservations.GetReservations())
+	requests := (&transactionTestSuite{Suite: s.Suite, cp: s.cp}).sendQueuedRequests()
+	s.Require().Len(requests, 1)
+	s.Require().NotNil(requests[0].(*core.StartTransactionRequest).ReservationId)
+	s.Assert().EqualValues(reservationId, *requests[0].(*core.StartTransactionRequest).ReservationId)
 }
 
 func TestReservation(t *testing.T) {
 	log.SetLevel(log.DebugLevel)
+
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
 	suite.Run(t, new(reservationTestSuite))
 }
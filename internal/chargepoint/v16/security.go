@@ -0,0 +1,612 @@
+package v16
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	chargePointUtil "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	firmwareUpdater "github.com/xBlaz3kx/ChargePi-go/internal/components/firmware-updater"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// certificateRenewalPeriod is the time before the expiry, when a new charge point certificate is requested.
+	certificateRenewalPeriod = 30 * 24 * time.Hour
+	certificateRenewalTag    = "certificateRenewal"
+	reconfigurationTag       = "securityReconfiguration"
+	// securityProfileFallbackTimeout is the time for the charge point to connect with the new SecurityProfile,
+	// before the previous one is restored.
+	securityProfileFallbackTimeout = 5 * time.Minute
+	securityProfileFallbackTag     = "securityProfileFallback"
+	securityProfileFallbackFile    = "security-profile-fallback.json"
+)
+
+var ErrSecurityExtensionDisabled = errors.New("security extension not enabled")
+
+func (cp *ChargePoint) OnCertificateSigned(request *securityExtension.CertificateSignedRequest) (confirmation *securityExtension.CertificateSignedConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	maxChainSize, confErr := ocppManager.GetConfigurationValue(securityExtension.CertificateSignedMaxChainSize.String())
+	if maxSize, convErr := strconv.Atoi(maxChainSize); confErr == nil && convErr == nil && len(request.CertificateChain) > maxSize {
+		return securityExtension.NewCertificateSignedConfirmation(securityExtension.CertificateSignedStatusRejected), nil
+	}
+
+	err = cp.certificateManager.InstallChargePointCertificate(request.CertificateChain)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot install the charge point certificate")
+		go cp.sendSecurityEvent(securityExtension.InvalidChargePointCertificate, err.Error())
+		return securityExtension.NewCertificateSignedConfirmation(securityExtension.CertificateSignedStatusRejected), nil
+	}
+
+	// The new certificate is used after the reconnect
+	return securityExtension.NewCertificateSignedConfirmation(securityExtension.CertificateSignedStatusAccepted), nil
+}
+
+func (cp *ChargePoint) OnDeleteCertificate(request *securityExtension.DeleteCertificateRequest) (confirmation *securityExtension.DeleteCertificateConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	err = cp.certificateManager.DeleteRootCertificate(request.CertificateHashData)
+	switch {
+	case err == nil:
+		return securityExtension.NewDeleteCertificateConfirmation(securityExtension.DeleteCertificateStatusAccepted), nil
+	case errors.Is(err, certificates.ErrCertificateNotFound):
+		return securityExtension.NewDeleteCertificateConfirmation(securityExtension.DeleteCertificateStatusNotFound), nil
+	default:
+		cp.logger.WithError(err).Errorf("Cannot delete the certificate")
+		return securityExtension.NewDeleteCertificateConfirmation(securityExtension.DeleteCertificateStatusFailed), nil
+	}
+}
+
+func (cp *ChargePoint) OnExtendedTriggerMessage(request *securityExtension.ExtendedTriggerMessageRequest) (confirmation *securityExtension.ExtendedTriggerMessageConfirmation, err error) {
+	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.RequestedMessage)
+	status := securityExtension.TriggerMessageStatusRejected
+
+	switch request.RequestedMessage {
+	case securityExtension.MessageTriggerSignChargePointCertificate:
+		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(cp.signCertificate)
+		if err == nil {
+			status = securityExtension.TriggerMessageStatusAccepted
+		}
+	case securityExtension.MessageTriggerLogStatusNotification:
+		// Report Idle unless the log is being uploaded
+		logStatus := securityExtension.UploadLogStatusIdle
+		if cp.logManager.GetStatus() == firmware.DiagnosticsStatusUploading {
+			logStatus = securityExtension.UploadLogStatusUploading
+		}
+
+		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(cp.sendLogStatusNotification, logStatus, cp.getLogRequestId())
+		if err == nil {
+			status = securityExtension.TriggerMessageStatusAccepted
+		}
+	default:
+		// The other messages are the same as in the TriggerMessage
+		triggerConfirmation, _ := cp.OnTriggerMessage(&remotetrigger.TriggerMessageRequest{
+			RequestedMessage: remotetrigger.MessageTrigger(request.RequestedMessage),
+			ConnectorId:      request.ConnectorId,
+		})
+		status = securityExtension.TriggerMessageStatus(triggerConfirmation.Status)
+	}
+
+	return securityExtension.NewExtendedTriggerMessageConfirmation(status), nil
+}
+
+func (cp *ChargePoint) OnGetInstalledCertificateIds(request *securityExtension.GetInstalledCertificateIdsRequest) (confirmation *securityExtension.GetInstalledCertificateIdsConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	hashData := cp.certificateManager.GetRootCertificates(request.CertificateType)
+	if len(hashData) == 0 {
+		return securityExtension.NewGetInstalledCertificateIdsConfirmation(securityExtension.GetInstalledCertificateStatusNotFound), nil
+	}
+
+	confirmation = securityExtension.NewGetInstalledCertificateIdsConfirmation(securityExtension.GetInstalledCertificateStatusAccepted)
+	confirmation.CertificateHashData = hashData
+	return confirmation, nil
+}
+
+func (cp *ChargePoint) OnGetLog(request *securityExtension.GetLogRequest) (confirmation *securityExtension.GetLogConfirmation, err error) {
+	var (
+		retries       = 0
+		retryInterval = 30
+		startTime     *time.Time
+		stopTime      *time.Time
+		files         = cp.diagnosticFiles
+		logInfo       = cp.logger.WithFields(log.Fields{
+			"location": request.Log.RemoteLocation,
+			"logType":  request.LogType,
+		})
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if request.Retries != nil {
+		retries = *request.Retries
+	}
+
+	if request.RetryInterval != nil {
+		retryInterval = *request.RetryInterval
+	}
+
+	if request.Log.OldestTimestamp != nil {
+		startTime = &request.Log.OldestTimestamp.Time
+	}
+
+	if request.Log.LatestTimestamp != nil {
+		stopTime = &request.Log.LatestTimestamp.Time
+	}
+
+	if request.LogType == securityExtension.SecurityLog {
+		files = diagnostics.Files{LogFile: cp.diagnosticFiles.SecurityLogFile}
+	} else {
+		// Include the latest state of the authorization cache
+		cp.authCache.DumpTags()
+	}
+
+	// An ongoing upload cannot be canceled
+	fileName, err := cp.logManager.GetDiagnostics(files, request.Log.RemoteLocation, startTime, stopTime, retries, retryInterval)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot get the log")
+		return securityExtension.NewGetLogConfirmation(securityExtension.LogStatusRejected), nil
+	}
+
+	cp.setLogRequestId(request.RequestId)
+
+	confirmation = securityExtension.NewGetLogConfirmation(securityExtension.LogStatusAccepted)
+	confirmation.Filename = fileName
+	return confirmation, nil
+}
+
+func (cp *ChargePoint) OnInstallCertificate(request *securityExtension.InstallCertificateRequest) (confirmation *securityExtension.InstallCertificateConfirmation, err error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	err = cp.certificateManager.InstallRootCertificate(request.CertificateType, request.Certificate)
+	switch {
+	case err == nil:
+		return securityExtension.NewInstallCertificateConfirmation(securityExtension.InstallCertificateStatusAccepted), nil
+	case errors.Is(err, certificates.ErrInvalidCertificate), errors.Is(err, certificates.ErrStoreFull):
+		cp.logger.WithError(err).Warnf("Rejected the certificate")
+		return securityExtension.NewInstallCertificateConfirmation(securityExtension.InstallCertificateStatusRejected), nil
+	default:
+		cp.logger.WithError(err).Errorf("Cannot install the certificate")
+		return securityExtension.NewInstallCertificateConfirmation(securityExtension.InstallCertificateStatusFailed), nil
+	}
+}
+
+func (cp *ChargePoint) OnSignedUpdateFirmware(request *securityExtension.SignedUpdateFirmwareRequest) (confirmation *securityExtension.SignedUpdateFirmwareConfirmation, err error) {
+	var (
+		retries       = 0
+		retryInterval = 30
+		firmwareInfo  = request.Firmware
+		logInfo       = cp.logger.WithFields(log.Fields{
+			"location":  firmwareInfo.Location,
+			"requestId": request.RequestId,
+		})
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if request.Retries != nil {
+		retries = *request.Retries
+	}
+
+	if request.RetryInterval != nil {
+		retryInterval = *request.RetryInterval
+	}
+
+	err = cp.certificateManager.VerifySigningCertificate(firmwareInfo.SigningCertificate)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Invalid firmware signing certificate")
+		go cp.sendSecurityEvent(securityExtension.InvalidFirmwareSigningCertificate, err.Error())
+		return securityExtension.NewSignedUpdateFirmwareConfirmation(securityExtension.UpdateFirmwareStatusInvalidCertificate), nil
+	}
+
+	verify := func(path string) error {
+		err := cp.certificateManager.VerifyFirmware(path, firmwareInfo.SigningCertificate, firmwareInfo.Signature)
+		if err != nil {
+			cp.sendSecurityEvent(securityExtension.InvalidFirmwareSignature, err.Error())
+		}
+
+		return err
+	}
+
+	err = cp.firmwareUpdater.UpdateSignedFirmware(firmwareInfo.Location, firmwareInfo.RetrieveDateTime.Time, retries, retryInterval, verify)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot update the firmware")
+		return securityExtension.NewSignedUpdateFirmwareConfirmation(securityExtension.UpdateFirmwareStatusRejected), nil
+	}
+
+	cp.setSignedFirmwareRequestId(&request.RequestId)
+	return securityExtension.NewSignedUpdateFirmwareConfirmation(securityExtension.UpdateFirmwareStatusAccepted), nil
+}
+
+// sendSecurityRequest sends the request of the Security Extension, if the endpoint is available.
+func (cp *ChargePoint) sendSecurityRequest(request ocpp.Request) (ocpp.Response, error) {
+	if util.IsNilInterfaceOrPointer(cp.securityEndpoint) {
+		return nil, ErrSecurityExtensionDisabled
+	}
+
+	return cp.securityEndpoint.SendRequest(request)
+}
+
+// sendSecurityEvent stores the security event in the security log and notifies the central system. The events which
+// could not be sent are sent after the next BootNotification is accepted.
+func (cp *ChargePoint) sendSecurityEvent(event securityExtension.SecurityEvent, techInfo string) {
+	request := securityExtension.NewSecurityEventNotificationRequest(event, types.NewDateTime(time.Now()))
+	if len(techInfo) > 255 {
+		techInfo = techInfo[:255]
+	}
+	request.TechInfo = techInfo
+
+	cp.logger.WithField("techInfo", techInfo).Infof("Security event: %s", event)
+	cp.writeSecurityLog(request)
+
+	_, err := cp.sendSecurityRequest(request)
+	switch {
+	case errors.Is(err, ErrSecurityExtensionDisabled):
+		return
+	case err != nil:
+		cp.logger.WithError(err).Warnf("Cannot send the security event, sending it after the reconnect")
+
+		cp.securityMu.Lock()
+		cp.pendingSecurityEvents = append(cp.pendingSecurityEvents, request)
+		cp.securityMu.Unlock()
+	}
+}
+
+// sendPendingSecurityEvents sends the security events that occurred while offline.
+func (cp *ChargePoint) sendPendingSecurityEvents() {
+	cp.securityMu.Lock()
+	events := cp.pendingSecurityEvents
+	cp.pendingSecurityEvents = nil
+	cp.securityMu.Unlock()
+
+	for i, event := range events {
+		_, err := cp.sendSecurityRequest(event)
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Cannot send the security event")
+
+			cp.securityMu.Lock()
+			cp.pendingSecurityEvents = append(events[i:], cp.pendingSecurityEvents...)
+			cp.securityMu.Unlock()
+			return
+		}
+	}
+}
+
+// writeSecurityLog appends the security event to the security log, which is uploaded with GetLog.
+func (cp *ChargePoint) writeSecurityLog(event *securityExtension.SecurityEventNotificationRequest) {
+	logPath := cp.diagnosticFiles.SecurityLogFile
+	if logPath == "" {
+		return
+	}
+
+	err := os.MkdirAll(filepath.Dir(logPath), os.ModePerm)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot create the security log directory")
+		return
+	}
+
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot open the security log")
+		return
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s %s %s\n", event.Timestamp.FormatTimestamp(), event.Type, event.TechInfo)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot write to the security log")
+	}
+}
+
+// signCertificate generates a new key pair and sends the CSR to the central system, which signs it with CertificateSigned.
+func (cp *ChargePoint) signCertificate() {
+	cpoName, _ := ocppManager.GetConfigurationValue(securityExtension.CpoName.String())
+
+	csr, err := cp.certificateManager.GenerateCSR(cp.Settings.ChargePoint.Info.Id, cpoName)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot generate the certificate signing request")
+		return
+	}
+
+	response, err := cp.sendSecurityRequest(securityExtension.NewSignCertificateRequest(csr))
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot send the certificate signing request")
+		return
+	}
+
+	if response.(*securityExtension.SignCertificateConfirmation).Status != securityExtension.GenericStatusAccepted {
+		cp.logger.Warn("Central system rejected the certificate signing request")
+	}
+}
+
+// checkCertificateExpiry requests a new charge point certificate, if the current one is about to expire.
+func (cp *ChargePoint) checkCertificateExpiry() {
+	if cp.certificateManager == nil {
+		return
+	}
+
+	certificate, err := cp.certificateManager.GetChargePointCertificate()
+	if err != nil {
+		if !errors.Is(err, certificates.ErrCertificateNotFound) {
+			cp.logger.WithError(err).Warn("Cannot check the charge point certificate")
+		}
+
+		return
+	}
+
+	if isCertificateExpiring(certificate.Leaf) {
+		cp.logger.Info("Charge point certificate is about to expire, requesting a new one")
+		cp.signCertificate()
+	}
+}
+
+func isCertificateExpiring(certificate *x509.Certificate) bool {
+	return time.Until(certificate.NotAfter) < certificateRenewalPeriod
+}
+
+// sendLogStatusNotification notifies the central system about the log upload status.
+func (cp *ChargePoint) sendLogStatusNotification(status securityExtension.UploadLogStatus, requestId int) {
+	cp.logger.Infof("Sending log status notification: %s", status)
+
+	_, err := cp.sendSecurityRequest(securityExtension.NewLogStatusNotificationRequest(status, requestId))
+	util.HandleRequestErr(err, "Cannot send log status notification")
+}
+
+// onLogStatus maps the upload status of the log to the LogStatusNotification.
+func (cp *ChargePoint) onLogStatus(status firmware.DiagnosticsStatus) {
+	var logStatus securityExtension.UploadLogStatus
+
+	switch status {
+	case firmware.DiagnosticsStatusUploading:
+		logStatus = securityExtension.UploadLogStatusUploading
+	case firmware.DiagnosticsStatusUploaded:
+		logStatus = securityExtension.UploadLogStatusUploaded
+	case firmware.DiagnosticsStatusUploadFailed:
+		logStatus = securityExtension.UploadLogStatusUploadFailure
+	default:
+		logStatus = securityExtension.UploadLogStatusIdle
+	}
+
+	cp.sendLogStatusNotification(logStatus, cp.getLogRequestId())
+}
+
+// sendSignedFirmwareStatusNotification notifies the central system about the signed firmware update status.
+// The request id is cleared when the update is finished.
+func (cp *ChargePoint) sendSignedFirmwareStatusNotification(status firmware.FirmwareStatus, requestId int) {
+	cp.logger.Infof("Sending signed firmware status notification: %s", status)
+
+	switch status {
+	case firmware.FirmwareStatusInstalled,
+		firmware.FirmwareStatusInstallationFailed,
+		firmware.FirmwareStatusDownloadFailed,
+		firmwareUpdater.FirmwareStatusInvalidSignature:
+		cp.setSignedFirmwareRequestId(nil)
+	}
+
+	request := securityExtension.NewSignedFirmwareStatusNotificationRequest(securityExtension.FirmwareStatus(status), requestId)
+	_, err := cp.sendSecurityRequest(request)
+	util.HandleRequestErr(err, "Cannot send signed firmware status notification")
+}
+
+// validateSecurityProfile checks if the SecurityProfile can be changed to the value. The profile cannot be lowered,
+// and the certificates needed for the profile must be installed.
+func (cp *ChargePoint) validateSecurityProfile(value string) bool {
+	securityProfile, err := strconv.Atoi(value)
+	if err != nil || securityProfile < chargePointUtil.SecurityProfileNone || securityProfile > chargePointUtil.SecurityProfileMutualTLS {
+		return false
+	}
+
+	if securityProfile < chargePointUtil.GetSecurityProfile() {
+		return false
+	}
+
+	var tlsSettings = cp.Settings.ChargePoint.TLS
+
+	if securityProfile >= chargePointUtil.SecurityProfileTLS {
+		_, caErr := os.Stat(tlsSettings.CACertificatePath)
+		if caErr != nil && len(cp.certificateManager.GetRootCertificates(securityExtension.CentralSystemRootCertificate)) == 0 {
+			return false
+		}
+	}
+
+	if securityProfile == chargePointUtil.SecurityProfileMutualTLS {
+		_, certificateErr := cp.certificateManager.GetChargePointCertificate()
+		_, settingsErr := os.Stat(tlsSettings.ClientCertificatePath)
+		if certificateErr != nil && settingsErr != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateAuthorizationKey checks the length of the basic auth password.
+func validateAuthorizationKey(value string) bool {
+	return len(value) >= 16 && len(value) <= 40
+}
+
+// reconfigureSecurity reports the change of the security parameters and restarts the client, so it reconnects with the new parameters.
+func (cp *ChargePoint) reconfigureSecurity() {
+	go cp.sendSecurityEvent(securityExtension.ReconfigurationOfSecurityParameters, "")
+	cp.restartWhenIdle()
+}
+
+// restartWhenIdle restarts the client once there are no ongoing transactions.
+func (cp *ChargePoint) restartWhenIdle() {
+	_ = cp.scheduler.RemoveByTag(reconfigurationTag)
+	_, err := cp.scheduler.Every(10).Seconds().Tag(reconfigurationTag).Do(func() {
+		if !cp.isIdle() {
+			return
+		}
+
+		_ = cp.scheduler.RemoveByTag(reconfigurationTag)
+		cp.CleanUp(core.ReasonOther)
+
+		err := util.RestartProcess()
+		if err != nil {
+			cp.logger.WithError(err).Fatal("Cannot restart after the security reconfiguration")
+		}
+	})
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the reconnect")
+	}
+}
+
+type securityProfileFallback struct {
+	SecurityProfile int `json:"securityProfile"`
+}
+
+// getSecurityProfileFallbackPath returns the path of the file with the previous SecurityProfile, which is stored next to
+// the OCPP configuration.
+func (cp *ChargePoint) getSecurityProfileFallbackPath() string {
+	if cp.diagnosticFiles.OcppConfiguration == "" {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(cp.diagnosticFiles.OcppConfiguration), securityProfileFallbackFile)
+}
+
+// saveSecurityProfileFallback stores the previous SecurityProfile, so it can be restored after the restart, if the
+// central system rejects the connection with the new one.
+func (cp *ChargePoint) saveSecurityProfileFallback(securityProfile int) {
+	path := cp.getSecurityProfileFallbackPath()
+	if path == "" {
+		return
+	}
+
+	err := settingsManager.WriteToFile(path, securityProfileFallback{SecurityProfile: securityProfile})
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot store the previous security profile")
+	}
+}
+
+// scheduleSecurityProfileFallback restores the previous SecurityProfile, if the charge point cannot connect to the
+// central system with the new one in time.
+func (cp *ChargePoint) scheduleSecurityProfileFallback() {
+	path := cp.getSecurityProfileFallbackPath()
+	if path == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var fallback securityProfileFallback
+	err = json.Unmarshal(data, &fallback)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot read the previous security profile")
+		cp.clearSecurityProfileFallback()
+		return
+	}
+
+	_ = cp.scheduler.RemoveByTag(securityProfileFallbackTag)
+	_, err = cp.scheduler.Every(securityProfileFallbackTimeout).
+		StartAt(time.Now().Add(securityProfileFallbackTimeout)).
+		LimitRunsTo(1).
+		Tag(securityProfileFallbackTag).
+		Do(cp.restoreSecurityProfile, fallback.SecurityProfile)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the security profile fallback")
+	}
+}
+
+// restoreSecurityProfile restores the previous SecurityProfile and restarts the client, so it reconnects with it.
+func (cp *ChargePoint) restoreSecurityProfile(securityProfile int) {
+	if cp.IsOnline() {
+		cp.clearSecurityProfileFallback()
+		return
+	}
+
+	cp.logger.Warnf("Cannot connect with the new security profile, falling back to the security profile %d", securityProfile)
+
+	err := ocppManager.UpdateKey(securityExtension.SecurityProfile.String(), strconv.Itoa(securityProfile))
+	if err == nil {
+		err = ocppManager.UpdateConfigurationFile()
+	}
+
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot restore the previous security profile")
+		return
+	}
+
+	cp.clearSecurityProfileFallback()
+	cp.restartWhenIdle()
+}
+
+// clearSecurityProfileFallback keeps the current SecurityProfile, once the central system accepted the connection.
+func (cp *ChargePoint) clearSecurityProfileFallback() {
+	_ = cp.scheduler.RemoveByTag(securityProfileFallbackTag)
+
+	path := cp.getSecurityProfileFallbackPath()
+	if path == "" {
+		return
+	}
+
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		cp.logger.WithError(err).Warn("Cannot remove the previous security profile")
+	}
+}
+
+func (cp *ChargePoint) getLogRequestId() int {
+	cp.securityMu.Lock()
+	defer cp.securityMu.Unlock()
+	return cp.logRequestId
+}
+
+func (cp *ChargePoint) setLogRequestId(requestId int) {
+	cp.securityMu.Lock()
+	defer cp.securityMu.Unlock()
+	cp.logRequestId = requestId
+}
+
+func (cp *ChargePoint) getSignedFirmwareRequestId() *int {
+	cp.securityMu.Lock()
+	defer cp.securityMu.Unlock()
+	return cp.signedFirmwareRequestId
+}
+
+func (cp *ChargePoint) setSignedFirmwareRequestId(requestId *int) {
+	cp.securityMu.Lock()
+	defer cp.securityMu.Unlock()
+	cp.signedFirmwareRequestId = requestId
+}
+
+// setMaxCertificates limits the certificate store with the CertificateStoreMaxLength.
+func (cp *ChargePoint) setMaxCertificates() {
+	var (
+		maxCertificatesString, confErr = ocppManager.GetConfigurationValue(securityExtension.CertificateStoreMaxLength.String())
+		maxCertificates, convErr       = strconv.Atoi(maxCertificatesString)
+	)
+
+	if confErr == nil && convErr == nil {
+		cp.certificateManager.SetMaxCertificates(maxCertificates)
+	}
+}
+
+// scheduleCertificateRenewal checks the expiry of the charge point certificate every day.
+func (cp *ChargePoint) scheduleCertificateRenewal() {
+	_ = cp.scheduler.RemoveByTag(certificateRenewalTag)
+
+	_, err := cp.scheduler.Every(1).Day().StartAt(time.Now().Add(24 * time.Hour)).Tag(certificateRenewalTag).Do(cp.checkCertificateExpiry)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the certificate renewal")
+	}
+}
@@ -0,0 +1,402 @@
+package v16
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	firmwareUpdater "github.com/xBlaz3kx/ChargePi-go/internal/components/firmware-updater"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type securityTestSuite struct {
+	suite.Suite
+	cp       *ChargePoint
+	endpoint *securityEndpointMock
+}
+
+func createTestCertificate(commonName string, publicKey interface{}, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) string {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	if parent == nil {
+		parent = template
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, publicKey, parentKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw}))
+}
+
+func createTestRootCertificate(commonName string) (string, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certificate := createTestCertificate(commonName, &key.PublicKey, nil, key)
+
+	block, _ := pem.Decode([]byte(certificate))
+	parsedCertificate, _ := x509.ParseCertificate(block.Bytes)
+	return certificate, parsedCertificate, key
+}
+
+func (s *securityTestSuite) SetupTest() {
+	var (
+		directory = s.T().TempDir()
+		scheduler = gocron.NewScheduler(time.UTC)
+	)
+
+	s.endpoint = new(securityEndpointMock)
+	s.cp = &ChargePoint{
+		logger:             log.StandardLogger(),
+		scheduler:          scheduler,
+		authCache:          auth.NewAuthCache(""),
+		securityEndpoint:   s.endpoint,
+		certificateManager: certificates.NewManager(filepath.Join(directory, "certificates")),
+		firmwareUpdater:    firmwareUpdater.NewUpdater("", scheduler, nil, nil, nil),
+		diagnosticFiles: diagnostics.Files{
+			SecurityLogFile:   filepath.Join(directory, "security.log"),
+			OcppConfiguration: filepath.Join(directory, "configuration.json"),
+		},
+		Settings: &settings.Settings{ChargePoint: settings.ChargePoint{
+			Info: settings.Info{Id: "ChargePoint001"},
+			TLS: settings.TLS{
+				CACertificatePath:     filepath.Join(directory, "ca.crt"),
+				ClientCertificatePath: filepath.Join(directory, "charge-point.crt"),
+			},
+		}},
+	}
+	s.cp.logManager = diagnostics.NewManager(scheduler, s.cp.onLogStatus)
+}
+
+// expectSecurityEvents accepts the security events and notifies the returned channel, once an event is written to the log and sent.
+func (s *securityTestSuite) expectSecurityEvents() chan struct{} {
+	sent := make(chan struct{}, 10)
+	s.endpoint.On("SendRequest", mock.AnythingOfType("*securityExtension.SecurityEventNotificationRequest")).
+		Run(func(args mock.Arguments) { sent <- struct{}{} }).
+		Return(&securityExtension.SecurityEventNotificationConfirmation{}, nil)
+	return sent
+}
+
+// waitForSecurityEvent waits for the event sent in the background, so it is not written after the test directory is removed.
+func (s *securityTestSuite) waitForSecurityEvent(sent chan struct{}) {
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		s.FailNow("the security event was not sent")
+	}
+}
+
+func (s *securityTestSuite) hasJob(tag string) bool {
+	for _, job := range s.cp.scheduler.Jobs() {
+		for _, jobTag := range job.Tags() {
+			if jobTag == tag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *securityTestSuite) TearDownTest() {
+	_ = ocppManager.UpdateKey(securityExtension.SecurityProfile.String(), "0")
+	_ = ocppManager.UpdateKey(securityExtension.AuthorizationKey.String(), "")
+}
+
+func (s *securityTestSuite) TestRootCertificates() {
+	rootCertificate, _, _ := createTestRootCertificate("Central system")
+
+	confirmation, err := s.cp.OnInstallCertificate(&securityExtension.InstallCertificateRequest{
+		CertificateType: securityExtension.CentralSystemRootCertificate,
+		Certificate:     "invalid",
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.InstallCertificateStatusRejected, confirmation.Status)
+
+	confirmation, err = s.cp.OnInstallCertificate(&securityExtension.InstallCertificateRequest{
+		CertificateType: securityExtension.CentralSystemRootCertificate,
+		Certificate:     rootCertificate,
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.InstallCertificateStatusAccepted, confirmation.Status)
+
+	installedCertificates, err := s.cp.OnGetInstalledCertificateIds(&securityExtension.GetInstalledCertificateIdsRequest{
+		CertificateType: securityExtension.CentralSystemRootCertificate,
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.GetInstalledCertificateStatusAccepted, installedCertificates.Status)
+	s.Require().Len(installedCertificates.CertificateHashData, 1)
+
+	installedCertificates, err = s.cp.OnGetInstalledCertificateIds(&securityExtension.GetInstalledCertificateIdsRequest{
+		CertificateType: securityExtension.ManufacturerRootCertificate,
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.GetInstalledCertificateStatusNotFound, installedCertificates.Status)
+
+	// The only central system root certificate cannot be deleted
+	deleteConfirmation, err := s.cp.OnDeleteCertificate(&securityExtension.DeleteCertificateRequest{
+		CertificateHashData: s.cp.certificateManager.GetRootCertificates(securityExtension.CentralSystemRootCertificate)[0],
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.DeleteCertificateStatusFailed, deleteConfirmation.Status)
+
+	deleteConfirmation, err = s.cp.OnDeleteCertificate(&securityExtension.DeleteCertificateRequest{
+		CertificateHashData: securityExtension.CertificateHashData{
+			HashAlgorithm:  securityExtension.SHA256,
+			IssuerNameHash: "issuerNameHash",
+			IssuerKeyHash:  "issuerKeyHash",
+			SerialNumber:   "1",
+		},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.DeleteCertificateStatusNotFound, deleteConfirmation.Status)
+}
+
+func (s *securityTestSuite) TestSignCertificate() {
+	rootCertificate, root, rootKey := createTestRootCertificate("Central system")
+	s.Require().NoError(s.cp.certificateManager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, rootCertificate))
+
+	var csr string
+	s.endpoint.On("SendRequest", mock.AnythingOfType("*securityExtension.SignCertificateRequest")).
+		Run(func(args mock.Arguments) {
+			csr = args.Get(0).(*securityExtension.SignCertificateRequest).Csr
+		}).
+		Return(&securityExtension.SignCertificateConfirmation{Status: securityExtension.GenericStatusAccepted}, nil)
+
+	triggerConfirmation, err := s.cp.OnExtendedTriggerMessage(&securityExtension.ExtendedTriggerMessageRequest{
+		RequestedMessage: securityExtension.MessageTriggerSignChargePointCertificate,
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.TriggerMessageStatusAccepted, triggerConfirmation.Status)
+
+	s.cp.signCertificate()
+	s.endpoint.AssertNumberOfCalls(s.T(), "SendRequest", 1)
+
+	block, _ := pem.Decode([]byte(csr))
+	s.Require().NotNil(block)
+	certificateRequest, err := x509.ParseCertificateRequest(block.Bytes)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("ChargePoint001", certificateRequest.Subject.CommonName)
+	s.Assert().EqualValues([]string{"ChargePi"}, certificateRequest.Subject.Organization)
+
+	// Invalid certificate chain
+	s.endpoint.On("SendRequest", mock.AnythingOfType("*securityExtension.SecurityEventNotificationRequest")).
+		Return(&securityExtension.SecurityEventNotificationConfirmation{}, nil)
+
+	confirmation, err := s.cp.OnCertificateSigned(&securityExtension.CertificateSignedRequest{CertificateChain: rootCertificate})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.CertificateSignedStatusRejected, confirmation.Status)
+
+	confirmation, err = s.cp.OnCertificateSigned(&securityExtension.CertificateSignedRequest{
+		CertificateChain: createTestCertificate("ChargePoint001", certificateRequest.PublicKey, root, rootKey),
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.CertificateSignedStatusAccepted, confirmation.Status)
+
+	certificate, err := s.cp.certificateManager.GetChargePointCertificate()
+	s.Require().NoError(err)
+	// The test certificate is only valid for an hour, so it should be renewed
+	s.Assert().True(isCertificateExpiring(certificate.Leaf))
+}
+
+func (s *securityTestSuite) TestSignedUpdateFirmware() {
+	var (
+		manufacturerCertificate, manufacturerRoot, manufacturerKey = createTestRootCertificate("Manufacturer")
+		signingKey, _                                              = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		signingCertificate                                         = createTestCertificate("Firmware signing", &signingKey.PublicKey, manufacturerRoot, manufacturerKey)
+		firmwarePath                                               = filepath.Join(s.T().TempDir(), "firmware")
+		digest                                                     = sha256.Sum256([]byte("firmware"))
+		signature, _                                               = ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+		request                                                    = &securityExtension.SignedUpdateFirmwareRequest{
+			RequestId: 5,
+			Firmware: securityExtension.Firmware{
+				Location:           "http://localhost/firmware",
+				RetrieveDateTime:   types.NewDateTime(time.Now()),
+				SigningCertificate: signingCertificate,
+				Signature:          base64.StdEncoding.EncodeToString(signature),
+			},
+		}
+	)
+
+	events := s.expectSecurityEvents()
+
+	// The manufacturer root certificate is not installed
+	confirmation, err := s.cp.OnSignedUpdateFirmware(request)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.UpdateFirmwareStatusInvalidCertificate, confirmation.Status)
+	s.Assert().Nil(s.cp.getSignedFirmwareRequestId())
+	s.waitForSecurityEvent(events)
+
+	s.Require().NoError(s.cp.certificateManager.InstallRootCertificate(securityExtension.ManufacturerRootCertificate, manufacturerCertificate))
+	s.Require().NoError(ioutil.WriteFile(firmwarePath, []byte("firmware"), 0644))
+
+	confirmation, err = s.cp.OnSignedUpdateFirmware(request)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.UpdateFirmwareStatusAccepted, confirmation.Status)
+	s.Assert().EqualValues(5, *s.cp.getSignedFirmwareRequestId())
+	s.Assert().NoError(s.cp.certificateManager.VerifyFirmware(firmwarePath, signingCertificate, request.Firmware.Signature))
+
+	// The status is reported with the SignedFirmwareStatusNotification
+	s.endpoint.On("SendRequest", securityExtension.NewSignedFirmwareStatusNotificationRequest(securityExtension.FirmwareStatusDownloading, 5)).
+		Return(&securityExtension.SignedFirmwareStatusNotificationConfirmation{}, nil).Once()
+	s.endpoint.On("SendRequest", securityExtension.NewSignedFirmwareStatusNotificationRequest(securityExtension.FirmwareStatusInvalidSignature, 5)).
+		Return(&securityExtension.SignedFirmwareStatusNotificationConfirmation{}, nil).Once()
+
+	s.cp.sendFirmwareStatusNotification(firmware.FirmwareStatusDownloading)
+	s.cp.sendFirmwareStatusNotification(firmwareUpdater.FirmwareStatusInvalidSignature)
+	s.endpoint.AssertExpectations(s.T())
+
+	// The update is finished
+	s.Assert().Nil(s.cp.getSignedFirmwareRequestId())
+}
+
+func (s *securityTestSuite) TestChangeSecurityConfiguration() {
+	events := s.expectSecurityEvents()
+
+	// The certificates for TLS are not installed
+	confirmation, err := s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.SecurityProfile.String(), "2"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, confirmation.Status)
+
+	confirmation, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.SecurityProfile.String(), "invalid"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, confirmation.Status)
+
+	confirmation, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.SecurityProfile.String(), "1"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, confirmation.Status)
+
+	// The client reconnects with the new profile
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+	s.waitForSecurityEvent(events)
+
+	// The security profile cannot be lowered
+	confirmation, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.SecurityProfile.String(), "0"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, confirmation.Status)
+
+	// The authorization key is too short
+	confirmation, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.AuthorizationKey.String(), "key"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, confirmation.Status)
+
+	confirmation, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.AuthorizationKey.String(), "0123456789abcdef"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, confirmation.Status)
+	s.waitForSecurityEvent(events)
+
+	// The authorization key cannot be read
+	configuration, err := s.cp.OnGetConfiguration(core.NewGetConfigurationRequest([]string{securityExtension.AuthorizationKey.String()}))
+	s.Require().NoError(err)
+	s.Require().Len(configuration.ConfigurationKey, 1)
+	s.Assert().Empty(configuration.ConfigurationKey[0].Value)
+
+	authorizationKey, err := ocppManager.GetConfigurationValue(securityExtension.AuthorizationKey.String())
+	s.Require().NoError(err)
+	s.Assert().EqualValues("0123456789abcdef", authorizationKey)
+}
+
+func (s *securityTestSuite) TestSecurityProfileFallback() {
+	events := s.expectSecurityEvents()
+
+	confirmation, err := s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(securityExtension.SecurityProfile.String(), "1"))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, confirmation.Status)
+	s.waitForSecurityEvent(events)
+
+	// After the restart, the previous profile is restored if the charge point cannot connect in time
+	_ = s.cp.scheduler.RemoveByTag(reconfigurationTag)
+	s.cp.scheduleSecurityProfileFallback()
+	s.Assert().True(s.hasJob(securityProfileFallbackTag))
+
+	s.cp.restoreSecurityProfile(0)
+
+	securityProfile, err := ocppManager.GetConfigurationValue(securityExtension.SecurityProfile.String())
+	s.Require().NoError(err)
+	s.Assert().EqualValues("0", securityProfile)
+
+	// The client reconnects with the previous profile and does not fall back again
+	s.Assert().False(s.hasJob(securityProfileFallbackTag))
+	s.Assert().True(s.hasJob(reconfigurationTag))
+
+	s.cp.scheduleSecurityProfileFallback()
+	s.Assert().False(s.hasJob(securityProfileFallbackTag))
+}
+
+func (s *securityTestSuite) TestSecurityEvents() {
+	s.endpoint.On("SendRequest", mock.AnythingOfType("*securityExtension.SecurityEventNotificationRequest")).
+		Return((*securityExtension.SecurityEventNotificationConfirmation)(nil), errors.New("not connected")).Once()
+
+	// The event is sent after the reconnect
+	s.cp.sendSecurityEvent(securityExtension.StartupOfTheDevice, "")
+	s.Assert().Len(s.cp.pendingSecurityEvents, 1)
+
+	s.endpoint.On("SendRequest", mock.AnythingOfType("*securityExtension.SecurityEventNotificationRequest")).
+		Return(&securityExtension.SecurityEventNotificationConfirmation{}, nil).Once()
+	s.cp.sendPendingSecurityEvents()
+	s.Assert().Empty(s.cp.pendingSecurityEvents)
+	s.endpoint.AssertNumberOfCalls(s.T(), "SendRequest", 2)
+
+	securityLog, err := ioutil.ReadFile(s.cp.diagnosticFiles.SecurityLogFile)
+	s.Require().NoError(err)
+	s.Assert().Contains(string(securityLog), string(securityExtension.StartupOfTheDevice))
+}
+
+func (s *securityTestSuite) TestLogStatusNotification() {
+	s.cp.setLogRequestId(3)
+
+	s.endpoint.On("SendRequest", securityExtension.NewLogStatusNotificationRequest(securityExtension.UploadLogStatusUploadFailure, 3)).
+		Return(&securityExtension.LogStatusNotificationConfirmation{}, nil).Once()
+
+	s.cp.onLogStatus(firmware.DiagnosticsStatusUploadFailed)
+	s.endpoint.AssertExpectations(s.T())
+
+	// Upload the security log
+	confirmation, err := s.cp.OnGetLog(&securityExtension.GetLogRequest{
+		Log:       securityExtension.LogParameters{RemoteLocation: "ftp://localhost/logs"},
+		LogType:   securityExtension.SecurityLog,
+		RequestId: 4,
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(securityExtension.LogStatusAccepted, confirmation.Status)
+	s.Assert().NotEmpty(confirmation.Filename)
+	s.Assert().EqualValues(4, s.cp.getLogRequestId())
+}
+
+func TestSecurity(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(securityTestSuite))
+}
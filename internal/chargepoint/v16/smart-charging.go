@@ -0,0 +1,222 @@
+package v16
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrStackLevelTooHigh    = errors.New("stack level exceeds the maximum stack level")
+	ErrTooManyPeriods       = errors.New("charging schedule has too many periods")
+	ErrChargingRateUnitType = errors.New("charging rate unit not allowed")
+)
+
+func (cp *ChargePoint) OnSetChargingProfile(request *smartcharging.SetChargingProfileRequest) (confirmation *smartcharging.SetChargingProfileConfirmation, err error) {
+	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ConnectorId)
+
+	var (
+		transaction *smartCharging.Transaction
+		logInfo     = cp.logger.WithFields(log.Fields{
+			"connectorId": request.ConnectorId,
+			"profileId":   request.ChargingProfile.ChargingProfileId,
+		})
+	)
+
+	validationErr := validateChargingProfile(request.ChargingProfile)
+	if validationErr != nil {
+		logInfo.WithError(validationErr).Warn("Rejected the charging profile")
+		return smartcharging.NewSetChargingProfileConfirmation(smartcharging.ChargingProfileStatusRejected), nil
+	}
+
+	if request.ConnectorId > 0 {
+		conn := cp.connectorManager.FindConnector(1, request.ConnectorId)
+		if util.IsNilInterfaceOrPointer(conn) {
+			return smartcharging.NewSetChargingProfileConfirmation(smartcharging.ChargingProfileStatusRejected), nil
+		}
+
+		transaction = getTransaction(conn)
+	}
+
+	err = cp.profileManager.AddProfile(request.ConnectorId, request.ChargingProfile, transaction)
+	if err != nil {
+		logInfo.WithError(err).Warn("Rejected the charging profile")
+		return smartcharging.NewSetChargingProfileConfirmation(smartcharging.ChargingProfileStatusRejected), nil
+	}
+
+	// Apply the new limits after the response
+	_, err = cp.scheduler.Every(1).Seconds().LimitRunsTo(1).Do(cp.applyChargingLimits)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule applying charging limits")
+	}
+
+	return smartcharging.NewSetChargingProfileConfirmation(smartcharging.ChargingProfileStatusAccepted), nil
+}
+
+func (cp *ChargePoint) OnClearChargingProfile(request *smartcharging.ClearChargingProfileRequest) (confirmation *smartcharging.ClearChargingProfileConfirmation, err error) {
+	cp.logger.Infof("Received %s", request.GetFeatureName())
+
+	numRemoved := cp.profileManager.ClearProfiles(request.Id, request.ConnectorId, request.ChargingProfilePurpose, request.StackLevel)
+	if numRemoved == 0 {
+		return smartcharging.NewClearChargingProfileConfirmation(smartcharging.ClearChargingProfileStatusUnknown), nil
+	}
+
+	_, err = cp.scheduler.Every(1).Seconds().LimitRunsTo(1).Do(cp.applyChargingLimits)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule applying charging limits")
+	}
+
+	return smartcharging.NewClearChargingProfileConfirmation(smartcharging.ClearChargingProfileStatusAccepted), nil
+}
+
+func (cp *ChargePoint) OnGetCompositeSchedule(request *smartcharging.GetCompositeScheduleRequest) (confirmation *smartcharging.GetCompositeScheduleConfirmation, err error) {
+	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ConnectorId)
+
+	var (
+		transaction *smartCharging.Transaction
+		now         = time.Now()
+		response    = smartcharging.NewGetCompositeScheduleConfirmation(smartcharging.GetCompositeScheduleStatusRejected)
+	)
+
+	if request.ChargingRateUnit != "" && !isChargingRateUnitAllowed(request.ChargingRateUnit) {
+		return response, nil
+	}
+
+	if request.ConnectorId > 0 {
+		conn := cp.connectorManager.FindConnector(1, request.ConnectorId)
+		if util.IsNilInterfaceOrPointer(conn) {
+			return response, nil
+		}
+
+		transaction = getTransaction(conn)
+	}
+
+	schedule := cp.profileManager.GetCompositeSchedule(request.ConnectorId, now, request.Duration, request.ChargingRateUnit, transaction)
+	if schedule == nil {
+		return response, nil
+	}
+
+	connectorId := request.ConnectorId
+	response.Status = smartcharging.GetCompositeScheduleStatusAccepted
+	response.ConnectorId = &connectorId
+	response.ScheduleStart = types.NewDateTime(now)
+	response.ChargingSchedule = schedule
+	return response, nil
+}
+
+// applyChargingLimits calculates the current limit of each connector from the charging profiles and applies it.
+func (cp *ChargePoint) applyChargingLimits() {
+	for _, c := range cp.connectorManager.GetConnectors() {
+		limit := cp.profileManager.GetLimit(c.GetConnectorId(), time.Now(), getTransaction(c))
+		if limit == nil {
+			c.SetChargingLimit(nil)
+			continue
+		}
+
+		current := limit.ConvertTo(types.ChargingRateUnitAmperes).Value
+		c.SetChargingLimit(&current)
+	}
+}
+
+// scheduleChargingLimits periodically re-applies the charging limits, since the schedules change over time.
+func (cp *ChargePoint) scheduleChargingLimits() {
+	_ = cp.scheduler.RemoveByTag("smartCharging")
+
+	_, err := cp.scheduler.Every(30).Seconds().Tag("smartCharging").Do(cp.applyChargingLimits)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule applying charging limits")
+	}
+}
+
+func (cp *ChargePoint) setMaxChargingProfiles() {
+	var (
+		maxProfilesString, confErr = ocppConfigManager.GetConfigurationValue(v16.MaxChargingProfilesInstalled.String())
+		maxProfiles, convErr       = strconv.Atoi(maxProfilesString)
+	)
+
+	if confErr == nil && convErr == nil {
+		cp.profileManager.SetMaxProfiles(maxProfiles)
+	}
+}
+
+// getTransaction returns the transaction information of the connector, if the connector has an active session.
+func getTransaction(c connector.Connector) *smartCharging.Transaction {
+	session := c.GetSession()
+	if !session.IsActive {
+		return nil
+	}
+
+	var (
+		transactionId, _ = strconv.Atoi(session.TransactionId)
+		started, err     = time.Parse(time.RFC3339, session.Started)
+	)
+
+	if err != nil {
+		started = time.Now()
+	}
+
+	return &smartCharging.Transaction{
+		Id:      transactionId,
+		Started: started,
+	}
+}
+
+// validateChargingProfile checks the charging profile against the smart charging configuration.
+func validateChargingProfile(profile *types.ChargingProfile) error {
+	maxStackLevel, err := getIntConfigurationValue(v16.ChargeProfileMaxStackLevel.String())
+	if err == nil && profile.StackLevel > maxStackLevel {
+		return ErrStackLevelTooHigh
+	}
+
+	maxPeriods, err := getIntConfigurationValue(v16.ChargingScheduleMaxPeriods.String())
+	if err == nil && profile.ChargingSchedule != nil && len(profile.ChargingSchedule.ChargingSchedulePeriod) > maxPeriods {
+		return ErrTooManyPeriods
+	}
+
+	if profile.ChargingSchedule != nil && !isChargingRateUnitAllowed(profile.ChargingSchedule.ChargingRateUnit) {
+		return ErrChargingRateUnitType
+	}
+
+	return nil
+}
+
+// isChargingRateUnitAllowed checks if the unit is in the ChargingScheduleAllowedChargingRateUnit list.
+func isChargingRateUnitAllowed(unit types.ChargingRateUnitType) bool {
+	allowedUnits, err := ocppConfigManager.GetConfigurationValue(v16.ChargingScheduleAllowedChargingRateUnit.String())
+	if err != nil {
+		return true
+	}
+
+	for _, allowedUnit := range strings.Split(allowedUnits, ",") {
+		switch strings.TrimSpace(allowedUnit) {
+		case "Current":
+			if unit == types.ChargingRateUnitAmperes {
+				return true
+			}
+		case "Power":
+			if unit == types.ChargingRateUnitWatts {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func getIntConfigurationValue(key string) (int, error) {
+	value, err := ocppConfigManager.GetConfigurationValue(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,163 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+	"time"
+)
+
+type smartChargingTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *smartChargingTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		logger:         log.StandardLogger(),
+		scheduler:      scheduler.GetScheduler(),
+		profileManager: smartCharging.NewProfileManager(),
+	}
+}
+
+func (s *smartChargingTestSuite) TestSetChargingProfile() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		txProfile     = types.NewChargingProfile(1, 0, types.ChargingProfilePurposeTxProfile, types.ChargingProfileKindRelative,
+			types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16)))
+		maxProfile = types.NewChargingProfile(2, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 32)))
+	)
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, 2).Return(nil)
+	s.cp.connectorManager = managerMock
+
+	// No transaction on the connector
+	connectorMock.On("GetSession").Return(session.Session{}).Once()
+	response, err := s.cp.OnSetChargingProfile(smartcharging.NewSetChargingProfileRequest(connectorId, txProfile))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ChargingProfileStatusRejected, response.Status)
+
+	// Ongoing transaction
+	connectorMock.On("GetSession").Return(session.Session{
+		IsActive:      true,
+		TransactionId: "1",
+		TagId:         tagId,
+		Started:       time.Now().Format(time.RFC3339),
+	}).Once()
+	response, err = s.cp.OnSetChargingProfile(smartcharging.NewSetChargingProfileRequest(connectorId, txProfile))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ChargingProfileStatusAccepted, response.Status)
+	s.Assert().EqualValues(1, s.cp.scheduler.Len())
+	s.cp.scheduler.Clear()
+
+	// TxProfile for another transaction
+	otherTxProfile := *txProfile
+	otherTxProfile.TransactionId = 2
+	connectorMock.On("GetSession").Return(session.Session{
+		IsActive:      true,
+		TransactionId: "1",
+		TagId:         tagId,
+		Started:       time.Now().Format(time.RFC3339),
+	}).Once()
+	response, err = s.cp.OnSetChargingProfile(smartcharging.NewSetChargingProfileRequest(connectorId, &otherTxProfile))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ChargingProfileStatusRejected, response.Status)
+
+	// Connector doesn't exist
+	response, err = s.cp.OnSetChargingProfile(smartcharging.NewSetChargingProfileRequest(2, txProfile))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ChargingProfileStatusRejected, response.Status)
+
+	// ChargePointMaxProfile on connector 0
+	response, err = s.cp.OnSetChargingProfile(smartcharging.NewSetChargingProfileRequest(0, maxProfile))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ChargingProfileStatusAccepted, response.Status)
+	s.cp.scheduler.Clear()
+
+	s.Assert().Len(s.cp.profileManager.GetProfiles(), 2)
+}
+
+func (s *smartChargingTestSuite) TestClearChargingProfile() {
+	var (
+		profileId  = 1
+		maxProfile = types.NewChargingProfile(profileId, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 32)))
+	)
+
+	s.Require().NoError(s.cp.profileManager.AddProfile(0, maxProfile, nil))
+
+	request := smartcharging.NewClearChargingProfileRequest()
+	request.Id = &profileId
+	response, err := s.cp.OnClearChargingProfile(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ClearChargingProfileStatusAccepted, response.Status)
+	s.cp.scheduler.Clear()
+
+	response, err = s.cp.OnClearChargingProfile(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.ClearChargingProfileStatusUnknown, response.Status)
+}
+
+func (s *smartChargingTestSuite) TestGetCompositeSchedule() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		maxProfile    = types.NewChargingProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 32)))
+	)
+
+	connectorMock.On("GetSession").Return(session.Session{})
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	// No profiles installed
+	response, err := s.cp.OnGetCompositeSchedule(smartcharging.NewGetCompositeScheduleRequest(connectorId, 600))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.GetCompositeScheduleStatusRejected, response.Status)
+
+	s.Require().NoError(s.cp.profileManager.AddProfile(0, maxProfile, nil))
+	response, err = s.cp.OnGetCompositeSchedule(smartcharging.NewGetCompositeScheduleRequest(connectorId, 600))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(smartcharging.GetCompositeScheduleStatusAccepted, response.Status)
+	s.Require().NotNil(response.ChargingSchedule)
+	s.Assert().EqualValues(32, response.ChargingSchedule.ChargingSchedulePeriod[0].Limit)
+}
+
+func (s *smartChargingTestSuite) TestApplyChargingLimits() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		limit         = 16.0
+		maxProfile    = types.NewChargingProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, limit)))
+	)
+
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetSession").Return(session.Session{})
+	connectorMock.On("SetChargingLimit", mock.Anything).Return()
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+	s.cp.connectorManager = managerMock
+
+	// No profiles - no limit
+	s.cp.applyChargingLimits()
+	connectorMock.AssertCalled(s.T(), "SetChargingLimit", (*float64)(nil))
+
+	s.Require().NoError(s.cp.profileManager.AddProfile(0, maxProfile, nil))
+	s.cp.applyChargingLimits()
+	connectorMock.AssertCalled(s.T(), "SetChargingLimit", &limit)
+}
+
+func TestSmartCharging(t *testing.T) {
+	suite.Run(t, new(smartChargingTestSuite))
+}
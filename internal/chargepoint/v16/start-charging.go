@@ -2,20 +2,24 @@ package v16
 
 import (
 	"fmt"
-	"github.com/lorenzodonini/ocpp-go/ocpp"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"strconv"
 	"time"
 )
 
+// maxEnergyCheckInterval is the interval (in seconds) of checking the energy delivered to a deauthorized transaction.
+const maxEnergyCheckInterval = 5
+
 // startCharging Start charging on the first available Connector. If there is no available Connector, reject the request.
 func (cp *ChargePoint) startCharging(tagId string) error {
-	if c := cp.connectorManager.FindAvailableConnector(); !util.IsNilInterfaceOrPointer(c) {
+	if c := cp.findConnectorForTag(tagId); !util.IsNilInterfaceOrPointer(c) {
 		return cp.startChargingConnector(c, tagId)
 	}
 
@@ -36,57 +40,161 @@ func (cp *ChargePoint) startChargingConnector(connector connector.Connector, tag
 		"tagId":       tagId,
 	})
 
-	if !connector.IsAvailable() {
+	if !(connector.IsAvailable() || connector.IsReserved()) {
 		return errors.ErrConnectorUnavailable
 	}
 
-	if cp.availability != core.AvailabilityTypeOperative {
+	if cp.getAvailability() != core.AvailabilityTypeOperative {
 		return errors.ErrChargePointUnavailable
 	}
 
+	if !cp.canStartTransactions() {
+		return errors.ErrChargePointNotAccepted
+	}
+
 	if !cp.isTagAuthorized(tagId) {
 		return errors.ErrTagUnauthorized
 	}
 
+	reservation, err := cp.getReservation(connector, tagId)
+	if err != nil {
+		return err
+	}
+
+	meterStart := connector.GetMeterReading()
 	request := core.NewStartTransactionRequest(
 		connector.GetConnectorId(),
 		tagId,
-		0,
+		meterStart,
 		types.NewDateTime(time.Now()),
 	)
 
-	callback := func(confirmation ocpp.Response, protoError error) {
-		if protoError != nil {
-			logInfo.WithError(protoError).Errorf("Server responded with error when starting a transaction")
-			return
+	if reservation != nil {
+		logInfo.Infof("Using the reservation %d", reservation.ReservationId)
+		request.ReservationId = &reservation.ReservationId
+	}
+
+	// The transaction is started with a temporary id, which is replaced after the central system confirms the transaction
+	localTransactionId, err := cp.transactionQueue.StartTransaction(request)
+	if err != nil {
+		return err
+	}
+
+	err = connector.StartCharging(strconv.Itoa(localTransactionId), tagId)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to start charging connector")
+
+		// End the queued transaction, so the central system doesn't keep it open
+		stopRequest := core.NewStopTransactionRequest(meterStart, types.NewDateTime(time.Now()), localTransactionId)
+		stopRequest.Reason = core.ReasonOther
+		queueErr := cp.transactionQueue.Enqueue(stopRequest)
+		if queueErr != nil {
+			logInfo.WithError(queueErr).Errorf("Cannot queue the stop transaction request")
 		}
 
-		startTransactionConf := confirmation.(*core.StartTransactionConfirmation)
-
-		switch startTransactionConf.IdTagInfo.Status {
-		case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
-			// Attempt to start charging
-			err := connector.StartCharging(strconv.Itoa(startTransactionConf.TransactionId), tagId)
-			if err != nil {
-				logInfo.WithError(err).Errorf("Unable to start charging connector")
-				return
-			}
-
-			logInfo.Infof("Started charging connector at %s", time.Now())
-
-			// Schedule timer to stop the transaction at the time limit
-			_, err = cp.scheduler.Every(connector.GetMaxChargingTime()).Minutes().LimitRunsTo(1).
-				Tag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId())).Do(cp.stopChargingConnector, connector, core.ReasonOther)
-			if err != nil {
-				logInfo.WithError(err).Errorf("Cannot schedule stop charging")
-			}
-			break
-		case types.AuthorizationStatusBlocked, types.AuthorizationStatusInvalid, types.AuthorizationStatusExpired:
-			fallthrough
-		default:
-			logInfo.Errorf("Transaction unauthorized")
+		return err
+	}
+
+	// The reservation ends when the transaction starts, so it is kept if the connector could not start charging
+	if reservation != nil {
+		cp.removeReservation(*reservation)
+	}
+
+	logInfo.Infof("Started charging connector at %s", time.Now())
+
+	// Schedule timer to stop the transaction at the time limit
+	_, err = cp.scheduler.Every(connector.GetMaxChargingTime()).Minutes().LimitRunsTo(1).
+		Tag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId())).Do(cp.stopChargingConnector, connector, core.ReasonOther)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule stop charging")
+	}
+
+	return nil
+}
+
+// onTransactionStarted replaces the temporary transaction id of the connector with the id from the central system.
+// If the central system did not accept the tag, the transaction is deauthorized.
+func (cp *ChargePoint) onTransactionStarted(localTransactionId int, confirmation *core.StartTransactionConfirmation) {
+	logInfo := cp.logger.WithFields(log.Fields{
+		"localTransactionId": localTransactionId,
+		"transactionId":      confirmation.TransactionId,
+	})
+
+	// The transaction could already be finished
+	connector := cp.connectorManager.FindConnectorWithTransactionId(strconv.Itoa(localTransactionId))
+	if util.IsNilInterfaceOrPointer(connector) {
+		return
+	}
+
+	logInfo.Debug("Central system confirmed the transaction")
+	connector.SetTransactionId(strconv.Itoa(confirmation.TransactionId))
+
+	if confirmation.IdTagInfo == nil {
+		return
+	}
+
+	switch confirmation.IdTagInfo.Status {
+	case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
+	default:
+		logInfo.Errorf("Transaction unauthorized")
+		cp.deauthorizeTransaction(connector)
+	}
+}
+
+// deauthorizeTransaction handles the transaction of a tag the central system invalidated. If StopTransactionOnInvalidId is enabled,
+// the transaction is stopped. Otherwise, the energy delivery is suspended after MaxEnergyOnInvalidId Wh were delivered
+// in the transaction, while the transaction keeps running.
+func (cp *ChargePoint) deauthorizeTransaction(connector connector.Connector) {
+	var (
+		stopOnInvalidId, _ = ocppConfigManager.GetConfigurationValue(v16.StopTransactionOnInvalidId.String())
+		maxEnergyValue, _  = ocppConfigManager.GetConfigurationValue(v16.MaxEnergyOnInvalidId.String())
+		logInfo            = cp.logger.WithFields(log.Fields{
+			"evseId":      connector.GetEvseId(),
+			"connectorId": connector.GetConnectorId(),
+		})
+	)
+
+	if stopOnInvalidId == "true" {
+		err := cp.stopChargingConnector(connector, core.ReasonDeAuthorized)
+		if err != nil {
+			logInfo.WithError(err).Errorf("Unable to stop charging connector")
 		}
+
+		return
+	}
+
+	maxEnergy, err := strconv.Atoi(maxEnergyValue)
+	if err != nil {
+		maxEnergy = 0
+	}
+
+	if cp.limitDeauthorizedEnergy(connector, maxEnergy) {
+		return
+	}
+
+	logInfo.Infof("Delivering up to %d Wh to the deauthorized transaction", maxEnergy)
+
+	_, err = cp.scheduler.Every(maxEnergyCheckInterval).Seconds().
+		Tag(fmt.Sprintf("connector%dMaxEnergy", connector.GetConnectorId())).
+		Do(cp.limitDeauthorizedEnergy, connector, maxEnergy)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule the energy limit")
+	}
+}
+
+// limitDeauthorizedEnergy suspends the charging if the transaction delivered at least maxEnergy Wh and returns true
+// once the charging is suspended.
+func (cp *ChargePoint) limitDeauthorizedEnergy(connector connector.Connector, maxEnergy int) bool {
+	if maxEnergy > 0 && connector.GetMeterReading()-connector.GetSession().MeterStart < maxEnergy {
+		return false
+	}
+
+	_ = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dMaxEnergy", connector.GetConnectorId()))
+
+	err := connector.SuspendCharging()
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to suspend charging the connector")
 	}
 
-	return util.SendRequest(cp.chargePoint, request, callback)
+	return true
 }
@@ -0,0 +1,323 @@
+package v16
+
+import (
+	"context"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type transactionTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *transactionTestSuite) SetupTest() {
+	localAuthList := auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json"))
+	s.Require().NoError(updateLocalList(localAuthList, 1, localauth.UpdateTypeFull, []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted)},
+	}))
+
+	s.cp = &ChargePoint{
+		reservations:     reservations.NewManager(""),
+		availability:     core.AvailabilityTypeOperative,
+		logger:           log.StandardLogger(),
+		scheduler:        scheduler.GetScheduler(),
+		localAuthList:    localAuthList,
+		profileManager:   smartCharging.NewProfileManager(),
+		transactionQueue: transactionQueue.NewQueue(""),
+	}
+}
+
+func (s *transactionTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *transactionTestSuite) TestStartChargingOffline() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(true)
+	connectorMock.On("IsReserved").Return(false)
+	connectorMock.On("GetMaxChargingTime").Return(15)
+	connectorMock.On("StartCharging", "-1", tagId).Return(nil).Once()
+	connectorMock.On("GetMeterReading").Return(1000).Once()
+	s.cp.connectorManager = managerMock
+
+	// The transaction starts with a temporary id, without waiting for the central system
+	err := s.cp.startChargingConnector(connectorMock, tagId)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(1, s.cp.transactionQueue.Len())
+	connectorMock.AssertCalled(s.T(), "StartCharging", "-1", tagId)
+
+	// The central system assigns the transaction id
+	managerMock.On("FindConnectorWithTransactionId", "-1").Return(connectorMock).Once()
+	connectorMock.On("SetTransactionId", "1234").Return().Once()
+	s.cp.onTransactionStarted(-1, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), 1234))
+	connectorMock.AssertCalled(s.T(), "SetTransactionId", "1234")
+
+	// Stopping the transaction queues the StopTransaction
+	connectorMock.On("GetTransactionId").Return("1234")
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(1500).Once()
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: "1234", MeterStart: 1000, TransactionData: []types.MeterValue{}})
+	connectorMock.On("StopCharging", core.ReasonLocal).Return(nil).Once()
+
+	err = s.cp.stopChargingConnector(connectorMock, core.ReasonLocal)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(2, s.cp.transactionQueue.Len())
+	connectorMock.AssertCalled(s.T(), "StopCharging", core.ReasonLocal)
+
+	// The meter values are read from the energy register
+	requests := s.sendQueuedRequests()
+	s.Require().Len(requests, 2)
+	s.Assert().EqualValues(1000, requests[0].(*core.StartTransactionRequest).MeterStart)
+	s.Assert().EqualValues(1500, requests[1].(*core.StopTransactionRequest).MeterStop)
+}
+
+// sendQueuedRequests sends the queued transaction messages and returns the sent requests.
+func (s *transactionTestSuite) sendQueuedRequests() []ocpp.Request {
+	var (
+		requests    []ocpp.Request
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	s.cp.transactionQueue.SetTransactionStartedHandler(func(localTransactionId int, confirmation *core.StartTransactionConfirmation) {})
+	go s.cp.transactionQueue.Run(ctx, func(request ocpp.Request) (ocpp.Response, error) {
+		requests = append(requests, request)
+
+		switch request.(type) {
+		case *core.StartTransactionRequest:
+			return core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), 1234), nil
+		default:
+			return core.NewStopTransactionConfirmation(), nil
+		}
+	}, func() bool {
+		return true
+	})
+
+	s.Require().Eventually(func() bool {
+		return s.cp.transactionQueue.Len() == 0
+	}, time.Second*3, time.Millisecond*10)
+
+	return requests
+}
+
+func (s *transactionTestSuite) TestTransactionRejected() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetTransactionId").Return("1234")
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(200)
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: "1234", MeterStart: 1000})
+	connectorMock.On("SetTransactionId", "1234").Return().Once()
+	connectorMock.On("StopCharging", core.ReasonDeAuthorized).Return(nil).Once()
+	managerMock.On("FindConnectorWithTransactionId", "-1").Return(connectorMock).Once()
+	s.cp.connectorManager = managerMock
+
+	s.cp.onTransactionStarted(-1, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid), 1234))
+	connectorMock.AssertCalled(s.T(), "StopCharging", core.ReasonDeAuthorized)
+	s.Assert().EqualValues(1, s.cp.transactionQueue.Len())
+
+	// The power meter was replaced, so the reading rolled back. The reading is reported as it is.
+	requests := s.sendQueuedRequests()
+	s.Require().Len(requests, 1)
+	s.Assert().EqualValues(200, requests[0].(*core.StopTransactionRequest).MeterStop)
+
+	// The transaction already ended
+	managerMock.On("FindConnectorWithTransactionId", "-2").Return(nil).Once()
+	s.cp.onTransactionStarted(-2, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), 1235))
+	connectorMock.AssertNumberOfCalls(s.T(), "SetTransactionId", 1)
+}
+
+func (s *transactionTestSuite) TestTransactionRejectedMaxEnergy() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "false"))
+	s.Require().NoError(ocppManager.UpdateKey(v16.MaxEnergyOnInvalidId.String(), "500"))
+	defer func() {
+		_ = ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "true")
+		_ = ocppManager.UpdateKey(v16.MaxEnergyOnInvalidId.String(), "0")
+	}()
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: "1234", MeterStart: 1000})
+	connectorMock.On("GetMeterReading").Return(1200).Once()
+	connectorMock.On("SetTransactionId", "1234").Return().Once()
+	managerMock.On("FindConnectorWithTransactionId", "-1").Return(connectorMock).Once()
+	s.cp.connectorManager = managerMock
+
+	// The transaction keeps charging until the maximum energy is delivered
+	s.cp.onTransactionStarted(-1, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid), 1234))
+	connectorMock.AssertNotCalled(s.T(), "StopCharging", mock.Anything)
+	connectorMock.AssertNotCalled(s.T(), "SuspendCharging")
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+
+	connectorMock.On("GetMeterReading").Return(1500)
+	connectorMock.On("SuspendCharging").Return(nil).Once()
+
+	s.Assert().True(s.cp.limitDeauthorizedEnergy(connectorMock, 500))
+	connectorMock.AssertCalled(s.T(), "SuspendCharging")
+	s.Assert().Empty(s.cp.scheduler.Jobs())
+	s.Assert().EqualValues(0, s.cp.transactionQueue.Len())
+}
+
+func (s *transactionTestSuite) TestTransactionRejectedMaxEnergyConnector() {
+	var (
+		relayMock      = new(test.RelayMock)
+		powerMeterMock = new(test.PowerMeterMock)
+		managerMock    = new(test.ManagerMock)
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "false"))
+	s.Require().NoError(ocppManager.UpdateKey(v16.MaxEnergyOnInvalidId.String(), "5000"))
+	defer func() {
+		_ = ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "true")
+		_ = ocppManager.UpdateKey(v16.MaxEnergyOnInvalidId.String(), "0")
+	}()
+
+	// The energy register of the power meter keeps counting across the transactions
+	relayMock.On("Enable").Return()
+	relayMock.On("Disable").Return()
+	energy := powerMeterMock.On("GetEnergy").Return(1254300.4)
+	powerMeterMock.On("GetPower").Return(7200.0)
+	powerMeterMock.On("GetCurrent").Return(31.3)
+	powerMeterMock.On("GetVoltage").Return(230.0)
+
+	c, err := connector.NewConnector(1, connectorId, "Type2", relayMock, powerMeterMock, true, 180)
+	s.Require().NoError(err)
+	s.Require().NoError(c.StartCharging("-1", tagId))
+	defer c.StopCharging(core.ReasonLocal)
+
+	managerMock.On("FindConnectorWithTransactionId", "-1").Return(c).Once()
+	s.cp.connectorManager = managerMock
+
+	s.cp.onTransactionStarted(-1, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid), 1234))
+	s.Require().True(c.IsCharging())
+
+	// 3.5 kWh delivered
+	energy.Return(1257800.6)
+	s.Assert().False(s.cp.limitDeauthorizedEnergy(c, 5000))
+	s.Assert().True(c.IsCharging())
+
+	// 5 kWh delivered, the charging is suspended for the rest of the transaction
+	energy.Return(1259300.7)
+	s.Assert().True(s.cp.limitDeauthorizedEnergy(c, 5000))
+	status, _ := c.GetStatus()
+	s.Assert().EqualValues(core.ChargePointStatusSuspendedEVSE, status)
+	relayMock.AssertCalled(s.T(), "Disable")
+
+	// Lifting the charging limit does not resume the charging
+	c.SetChargingLimit(nil)
+	status, _ = c.GetStatus()
+	s.Assert().EqualValues(core.ChargePointStatusSuspendedEVSE, status)
+}
+
+func (s *transactionTestSuite) TestSamplingAfterStop() {
+	var (
+		relayMock      = new(test.RelayMock)
+		powerMeterMock = new(test.PowerMeterMock)
+		samplingTag    = fmt.Sprintf("Evse%dConnector%dSampling", 1, connectorId)
+	)
+
+	relayMock.On("Enable").Return()
+	relayMock.On("Disable").Return()
+	powerMeterMock.On("GetEnergy").Return(1254300.4)
+	powerMeterMock.On("GetPower").Return(7200.0)
+	powerMeterMock.On("GetCurrent").Return(31.3)
+	powerMeterMock.On("GetVoltage").Return(230.0)
+
+	c, err := connector.NewConnector(1, connectorId, "Type2", relayMock, powerMeterMock, true, 180)
+	s.Require().NoError(err)
+
+	countSamplers := func() int {
+		samplers := 0
+		for _, job := range s.cp.scheduler.Jobs() {
+			for _, tag := range job.Tags() {
+				if tag == samplingTag {
+					samplers++
+				}
+			}
+		}
+
+		return samplers
+	}
+
+	s.Require().NoError(c.StartCharging("1", tagId))
+	s.Require().EqualValues(1, countSamplers())
+
+	// The sampling stops with the transaction
+	s.Require().NoError(s.cp.stopChargingConnector(c, core.ReasonLocal))
+	s.Require().EqualValues(0, countSamplers())
+
+	s.Require().NoError(c.StartCharging("2", tagId))
+	defer c.StopCharging(core.ReasonLocal)
+	s.Assert().EqualValues(1, countSamplers())
+}
+
+func (s *transactionTestSuite) TestTransactionRejectedSuspend() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "false"))
+	defer func() {
+		_ = ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "true")
+	}()
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: "1234", MeterStart: 1000})
+	connectorMock.On("GetMeterReading").Return(1000)
+	connectorMock.On("SetTransactionId", "1234").Return().Once()
+	connectorMock.On("SuspendCharging").Return(nil).Once()
+	managerMock.On("FindConnectorWithTransactionId", "-1").Return(connectorMock).Once()
+	s.cp.connectorManager = managerMock
+
+	// Without MaxEnergyOnInvalidId, the energy delivery is suspended immediately
+	s.cp.onTransactionStarted(-1, core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusBlocked), 1234))
+	connectorMock.AssertCalled(s.T(), "SuspendCharging")
+	connectorMock.AssertNotCalled(s.T(), "StopCharging", mock.Anything)
+	s.Assert().Empty(s.cp.scheduler.Jobs())
+}
+
+func TestTransactions(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(transactionTestSuite))
+}
@@ -2,7 +2,6 @@ package v16
 
 import (
 	"fmt"
-	"github.com/lorenzodonini/ocpp-go/ocpp"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
@@ -39,7 +38,7 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		return convErr
 	}
 
-	if !(connector.IsCharging() || connector.IsPreparing()) {
+	if !(connector.IsCharging() || connector.IsPreparing() || connector.IsSuspended()) {
 		return errors.ErrConnectorNotCharging
 	}
 
@@ -47,40 +46,44 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		return connector.StopCharging(reason)
 	}
 
-	request := core.NewStopTransactionRequest(
-		int(connector.CalculateSessionAvgEnergyConsumption()),
-		types.NewDateTime(time.Now()),
-		transactionId,
-	)
+	// The energy register is persisted, so the reading rolls back only if the power meter was replaced
+	meterStop := connector.GetMeterReading()
+	if meterStart := connector.GetSession().MeterStart; meterStop < meterStart {
+		logInfo.WithError(errors.ErrMeterReadingRolledBack).
+			Warnf("Meter stop %d Wh is lower than the meter start %d Wh", meterStop, meterStart)
+	}
+
+	request := core.NewStopTransactionRequest(meterStop, types.NewDateTime(time.Now()), transactionId)
 	request.Reason = reason
 
-	var callback = func(confirmation ocpp.Response, protoError error) {
-		if protoError != nil {
-			logInfo.WithError(protoError).Errorf("Server responded with error for stopping a transaction")
-			return
-		}
-
-		logInfo.Info("Stopping transaction")
-		err = connector.StopCharging(reason)
-		if err != nil {
-			logInfo.WithError(err).Errorf("Unable to stop charging")
-			return
-		}
-
-		schedulerErr := cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dSampling", connector.GetConnectorId()))
-		if schedulerErr != nil {
-			logInfo.WithError(err).Errorf("Cannot remove sampling schedule")
-		}
-
-		schedulerErr = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId()))
-		if schedulerErr != nil {
-			logInfo.WithError(err).Errorf("Cannot remove stop charging schedule")
-		}
-
-		logInfo.Infof("Stopped charging at %s", time.Now())
+	logInfo.Info("Stopping transaction")
+	err = connector.StopCharging(reason)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to stop charging")
+		return err
+	}
+
+	// The transaction data is kept in the session until the next transaction starts
+	request.TransactionData = connector.GetSession().TransactionData
+
+	cp.profileManager.RemoveTxProfiles(connector.GetConnectorId())
+
+	schedulerErr := cp.scheduler.RemoveByTag(fmt.Sprintf("Evse%dConnector%dSampling", connector.GetEvseId(), connector.GetConnectorId()))
+	if schedulerErr != nil {
+		logInfo.WithError(schedulerErr).Errorf("Cannot remove sampling schedule")
+	}
+
+	schedulerErr = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId()))
+	if schedulerErr != nil {
+		logInfo.WithError(schedulerErr).Errorf("Cannot remove stop charging schedule")
 	}
 
-	return util.SendRequest(cp.chargePoint, request, callback)
+	_ = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dMaxEnergy", connector.GetConnectorId()))
+
+	logInfo.Infof("Stopped charging at %s", time.Now())
+
+	// The StopTransaction is sent as soon as the central system is reachable
+	return cp.transactionQueue.Enqueue(request)
 }
 
 // stopChargingConnectorWithTagId Search for a ConnectorImpl that contains the tagId and stop the charging.
@@ -3,49 +3,47 @@ package v16
 import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"strconv"
 )
 
-// isTagAuthorized Check if the tag is authorized for charging. If the authentication cache is enabled and if it can preauthorize from cache,
+// isTagAuthorized Check if the tag is authorized for charging. If the local authorization list is enabled and the tag is in the list,
+// the tag is authorized without contacting the central system. If the authentication cache is enabled and if it can preauthorize from cache,
 // the program will check the cache first and reauthorize with the sendAuthorizeRequest to the central system after 10 seconds.
 // If cache is not enabled, it will just execute sendAuthorizeRequest and retrieve the status from the request.
+// If the central system is unreachable, the tag is authorized with isTagAuthorizedOffline.
 func (cp *ChargePoint) isTagAuthorized(tagId string) bool {
-	var (
-		response                      = false
-		authCacheEnabled, cacheErr    = ocppConfigManager.GetConfigurationValue(v16.AuthorizationCacheEnabled.String())
-		localPreAuthorize, preAuthErr = ocppConfigManager.GetConfigurationValue(v16.LocalPreAuthorize.String())
-	)
-
-	if cacheErr != nil {
-		authCacheEnabled = "false"
-	}
+	response := false
+
+	switch source, isAuthorized := auth.PreAuthorize(cp.authCache, cp.localAuthList, authData.NewIdTag(tagId)); {
+	case isAuthorized && source == auth.SourceCache:
+		// Reauthorize in 10 seconds
+		_, schedulerErr := cp.scheduler.Every(10).Seconds().LimitRunsTo(1).Do(cp.sendAuthorizeRequest, tagId)
+		if schedulerErr != nil {
+			cp.logger.WithError(schedulerErr).Errorf("Cannot schedule tag authorization with central system")
+		}
 
-	if preAuthErr != nil {
-		localPreAuthorize = "false"
+		fallthrough
+	case isAuthorized:
+		cp.logger.Infof("Authorized tag %s with the %s", tagId, source)
+		return true
 	}
 
-	if authCacheEnabled == "true" && localPreAuthorize == "true" {
-		cp.logger.Infof("Authorizing tag %s with cache", tagId)
-
-		// Check if the tag exists in cache and is valid.
-		if cp.authCache.IsTagAuthorized(tagId) {
-			// Reauthorize in 10 seconds
-			_, schedulerErr := cp.scheduler.Every(10).Seconds().LimitRunsTo(1).Do(cp.sendAuthorizeRequest, tagId)
-			if schedulerErr != nil {
-				cp.logger.WithError(schedulerErr).Errorf("Cannot schedule tag authorization with central system")
-			}
-
-			return true
-		}
+	// The central system cannot authorize the tag while offline
+	if !cp.IsOnline() {
+		return cp.isTagAuthorizedOffline(tagId)
 	}
 
 	// If the card is not in cache or is not authorized, (re)authorize it with the central system
 	cp.logger.Infof("Authorizing tag %s with central system", tagId)
 	tagInfo, err := cp.sendAuthorizeRequest(tagId)
 	if err != nil {
-		return false
+		cp.logger.WithError(err).Warnf("Unable to authorize tag %s with central system", tagId)
+		return cp.isTagAuthorizedOffline(tagId)
 	}
 
 	if tagInfo != nil && tagInfo.Status == types.AuthorizationStatusAccepted {
@@ -56,6 +54,67 @@ func (cp *ChargePoint) isTagAuthorized(tagId string) bool {
 	return response
 }
 
+// isTagAuthorizedOffline Check if the tag is authorized while the central system is unreachable. If LocalAuthorizeOffline is enabled,
+// the tag is authorized with the local authorization list or the cache. If the tag is unknown and AllowOfflineTxForUnknownId is enabled,
+// the tag is authorized as well. The tags authorized offline are validated with the central system after the reconnect.
+func (cp *ChargePoint) isTagAuthorizedOffline(tagId string) bool {
+	source, isAuthorized := auth.AuthorizeOffline(cp.authCache, cp.localAuthList, authData.NewIdTag(tagId))
+	if !isAuthorized {
+		cp.logger.Infof("Tag %s not authorized offline", tagId)
+		return false
+	}
+
+	cp.logger.Infof("Authorized tag %s offline with the %s", tagId, source)
+	cp.addOfflineTag(tagId)
+	return true
+}
+
+// addOfflineTag remembers the tag authorized offline, so it can be validated after the reconnect.
+func (cp *ChargePoint) addOfflineTag(tagId string) {
+	cp.offlineTagsMu.Lock()
+	defer cp.offlineTagsMu.Unlock()
+
+	if cp.offlineTags == nil {
+		cp.offlineTags = map[string]struct{}{}
+	}
+
+	cp.offlineTags[tagId] = struct{}{}
+}
+
+// restoreOfflineTags remembers the tags of the transactions started offline before a restart, so they are validated
+// after the reconnect. The tags are taken from the queued StartTransaction requests, which are persisted.
+func (cp *ChargePoint) restoreOfflineTags() {
+	for _, tagId := range cp.transactionQueue.GetStartTransactionTags() {
+		cp.addOfflineTag(tagId)
+	}
+}
+
+// validateOfflineTags authorizes the tags that were authorized offline with the central system. The transactions of invalid
+// tags are deauthorized by sendAuthorizeRequest. The tags that could not be validated
+// are kept for the next reconnect.
+func (cp *ChargePoint) validateOfflineTags() {
+	cp.offlineTagsMu.Lock()
+	tags := make([]string, 0, len(cp.offlineTags))
+	for tagId := range cp.offlineTags {
+		tags = append(tags, tagId)
+	}
+	cp.offlineTagsMu.Unlock()
+
+	for _, tagId := range tags {
+		cp.logger.Infof("Validating tag %s authorized offline", tagId)
+
+		_, err := cp.sendAuthorizeRequest(tagId)
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Unable to validate tag %s", tagId)
+			continue
+		}
+
+		cp.offlineTagsMu.Lock()
+		delete(cp.offlineTags, tagId)
+		cp.offlineTagsMu.Unlock()
+	}
+}
+
 // sendAuthorizeRequest Send a AuthorizeRequest to the central system to get information on the tagId status.
 // Adds the tag to the cache if it's enabled.
 func (cp *ChargePoint) sendAuthorizeRequest(tagId string) (*types.IdTagInfo, error) {
@@ -69,16 +128,14 @@ func (cp *ChargePoint) sendAuthorizeRequest(tagId string) (*types.IdTagInfo, err
 
 	switch authInfo.IdTagInfo.Status {
 	case types.AuthorizationStatusBlocked, types.AuthorizationStatusExpired, types.AuthorizationStatusInvalid:
-		err = cp.stopChargingConnectorWithTagId(tagId, core.ReasonDeAuthorized)
-		break
+		if c := cp.connectorManager.FindConnectorWithTagId(tagId); !util.IsNilInterfaceOrPointer(c) {
+			cp.deauthorizeTransaction(c)
+		}
 	}
 
-	value, err2 := ocppConfigManager.GetConfigurationValue(v16.AuthorizationCacheEnabled.String())
-	if err2 == nil && value == "true" {
-		cp.authCache.AddTag(tagId, authInfo.IdTagInfo)
-	}
+	auth.UpdateCache(cp.authCache, authData.NewIdTag(tagId), authData.FromIdTagInfo(authInfo.IdTagInfo))
 
-	return authInfo.IdTagInfo, err
+	return authInfo.IdTagInfo, nil
 }
 
 func (cp *ChargePoint) setMaxCachedTags() {
@@ -0,0 +1,186 @@
+package v16
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	smartCharging "github.com/xBlaz3kx/ChargePi-go/internal/components/smart-charging"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tagAuthTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *tagAuthTestSuite) SetupTest() {
+	localAuthList := auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json"))
+	s.Require().NoError(updateLocalList(localAuthList, 1, localauth.UpdateTypeFull, []localauth.AuthorizationData{
+		{IdTag: tagId, IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted)},
+		{IdTag: "blockedTag", IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusBlocked)},
+	}))
+
+	// The supervisor is not set, so the charge point is offline
+	s.cp = &ChargePoint{
+		logger:           log.StandardLogger(),
+		scheduler:        scheduler.GetScheduler(),
+		localAuthList:    localAuthList,
+		authCache:        auth.NewAuthCache(""),
+		profileManager:   smartCharging.NewProfileManager(),
+		transactionQueue: transactionQueue.NewQueue(""),
+	}
+}
+
+func (s *tagAuthTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+	_ = ocppManager.UpdateKey(v16.LocalAuthorizeOffline.String(), "true")
+	_ = ocppManager.UpdateKey(v16.LocalPreAuthorize.String(), "true")
+	_ = ocppManager.UpdateKey(v16.AllowOfflineTxForUnknownId.String(), "false")
+	_ = ocppManager.UpdateKey(v16.AuthorizationCacheEnabled.String(), "false")
+}
+
+func (s *tagAuthTestSuite) TestAuthorizeOffline() {
+	// Pre-authorization is disabled, so the tags are only authorized locally while offline
+	s.Require().NoError(ocppManager.UpdateKey(v16.LocalPreAuthorize.String(), "false"))
+
+	// Authorized with the local authorization list
+	s.Assert().True(s.cp.isTagAuthorized(tagId))
+	s.Assert().Contains(s.cp.offlineTags, tagId)
+
+	// Authorized with the cache
+	s.Require().NoError(ocppManager.UpdateKey(v16.AuthorizationCacheEnabled.String(), "true"))
+	s.cp.authCache.SetMaxCachedTags(10)
+	s.cp.authCache.AddTag(authData.NewIdTag("cachedTag"), authData.TokenInfo{Status: authData.StatusAccepted})
+	s.Assert().True(s.cp.isTagAuthorized("cachedTag"))
+
+	// Unknown tags are not allowed
+	s.Assert().False(s.cp.isTagAuthorized("unknownTag"))
+
+	// The blocked tag is known, so it is never authorized
+	s.Require().NoError(ocppManager.UpdateKey(v16.AllowOfflineTxForUnknownId.String(), "true"))
+	s.Assert().False(s.cp.isTagAuthorized("blockedTag"))
+
+	// Unknown tags are allowed
+	s.Assert().True(s.cp.isTagAuthorized("unknownTag"))
+	s.Assert().Contains(s.cp.offlineTags, "unknownTag")
+
+	// Local authorization while offline is disabled
+	s.Require().NoError(ocppManager.UpdateKey(v16.LocalAuthorizeOffline.String(), "false"))
+	s.Assert().False(s.cp.isTagAuthorized(tagId))
+	s.Assert().False(s.cp.isTagAuthorized("cachedTag"))
+}
+
+func (s *tagAuthTestSuite) TestValidateOfflineTags() {
+	var (
+		chargePoint   = new(chargePointMock)
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	s.cp.addOfflineTag(tagId)
+	s.cp.addOfflineTag("invalidTag")
+	s.cp.addOfflineTag("unreachableTag")
+
+	chargePoint.On("SendRequest", core.NewAuthorizationRequest(tagId)).
+		Return(core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted)), nil)
+	chargePoint.On("SendRequest", core.NewAuthorizationRequest("invalidTag")).
+		Return(core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid)), nil)
+	chargePoint.On("SendRequest", core.NewAuthorizationRequest("unreachableTag")).
+		Return((*core.AuthorizeConfirmation)(nil), errors.New("timeout"))
+
+	// The transaction of the invalid tag is stopped
+	transactionSession := session.NewEmptySession()
+	s.Require().NoError(transactionSession.StartSession("1234", "invalidTag"))
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetTransactionId").Return("1234")
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(0)
+	connectorMock.On("GetSession").Return(*transactionSession)
+	connectorMock.On("StopCharging", core.ReasonDeAuthorized).Return(nil).Once()
+	managerMock.On("FindConnectorWithTagId", "invalidTag").Return(connectorMock)
+
+	s.cp.chargePoint = chargePoint
+	s.cp.connectorManager = managerMock
+
+	s.cp.validateOfflineTags()
+
+	connectorMock.AssertCalled(s.T(), "StopCharging", core.ReasonDeAuthorized)
+	s.Assert().EqualValues(1, s.cp.transactionQueue.Len())
+
+	// The tag that could not be validated is kept for the next reconnect
+	s.Assert().Len(s.cp.offlineTags, 1)
+	s.Assert().Contains(s.cp.offlineTags, "unreachableTag")
+}
+
+func (s *tagAuthTestSuite) TestRestoreOfflineTags() {
+	queuePath := filepath.Join(s.T().TempDir(), "transactions.json")
+
+	queue := transactionQueue.NewQueue(queuePath)
+	_, err := queue.StartTransaction(core.NewStartTransactionRequest(connectorId, "offlineTag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+
+	// The tags authorized offline are validated after a restart
+	s.cp.transactionQueue = transactionQueue.NewQueue(queuePath)
+	s.cp.transactionQueue.LoadFromFile()
+	s.cp.restoreOfflineTags()
+
+	s.Assert().Len(s.cp.offlineTags, 1)
+	s.Assert().Contains(s.cp.offlineTags, "offlineTag")
+}
+
+func (s *tagAuthTestSuite) TestValidateOfflineTagsSuspendCharging() {
+	var (
+		chargePoint   = new(chargePointMock)
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "false"))
+	defer func() {
+		_ = ocppManager.UpdateKey(v16.StopTransactionOnInvalidId.String(), "true")
+	}()
+
+	s.cp.addOfflineTag("invalidTag")
+	chargePoint.On("SendRequest", core.NewAuthorizationRequest("invalidTag")).
+		Return(core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid)), nil)
+
+	// The transaction keeps running, but the energy delivery is suspended
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetMeterReading").Return(0)
+	connectorMock.On("GetSession").Return(*session.NewEmptySession())
+	connectorMock.On("SuspendCharging").Return(nil).Once()
+	managerMock.On("FindConnectorWithTagId", "invalidTag").Return(connectorMock)
+
+	s.cp.chargePoint = chargePoint
+	s.cp.connectorManager = managerMock
+
+	s.cp.validateOfflineTags()
+
+	connectorMock.AssertCalled(s.T(), "SuspendCharging")
+	connectorMock.AssertNotCalled(s.T(), "StopCharging", mock.Anything)
+	s.Assert().Empty(s.cp.offlineTags)
+}
+
+func TestTagAuth(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(tagAuthTestSuite))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
 	"github.com/reactivex/rxgo/v2"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	firmwareUpdater "github.com/xBlaz3kx/ChargePi-go/internal/components/firmware-updater"
 )
 
 func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageRequest) (confirmation *remotetrigger.TriggerMessageConfirmation, err error) {
@@ -21,8 +22,36 @@ func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageReq
 
 		status = remotetrigger.TriggerMessageStatusAccepted
 		break
-	case firmware.DiagnosticsStatusNotificationFeatureName, firmware.FirmwareStatusNotificationFeatureName:
-		status = remotetrigger.TriggerMessageStatusNotImplemented
+	case firmware.DiagnosticsStatusNotificationFeatureName:
+		// Report Idle unless the diagnostics are being uploaded
+		diagnosticsStatus := cp.diagnosticsManager.GetStatus()
+		if diagnosticsStatus != firmware.DiagnosticsStatusUploading {
+			diagnosticsStatus = firmware.DiagnosticsStatusIdle
+		}
+
+		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(cp.sendDiagnosticsStatusNotification, diagnosticsStatus)
+		if err != nil {
+			break
+		}
+
+		status = remotetrigger.TriggerMessageStatusAccepted
+		break
+	case firmware.FirmwareStatusNotificationFeatureName:
+		// Report Idle unless the firmware is being downloaded or installed
+		firmwareStatus := cp.firmwareUpdater.GetStatus()
+		switch firmwareStatus {
+		case firmware.FirmwareStatusDownloading, firmware.FirmwareStatusDownloaded, firmware.FirmwareStatusInstalling,
+			firmwareUpdater.FirmwareStatusSignatureVerified:
+		default:
+			firmwareStatus = firmware.FirmwareStatusIdle
+		}
+
+		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(cp.sendFirmwareStatusNotification, firmwareStatus)
+		if err != nil {
+			break
+		}
+
+		status = remotetrigger.TriggerMessageStatusAccepted
 		break
 	case core.HeartbeatFeatureName:
 		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(cp.sendHeartBeat)
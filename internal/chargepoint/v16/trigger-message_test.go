@@ -2,11 +2,14 @@ package v16
 
 import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
 	"github.com/reactivex/rxgo/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/suite"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	firmwareUpdater "github.com/xBlaz3kx/ChargePi-go/internal/components/firmware-updater"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	"testing"
@@ -23,6 +26,8 @@ func (s *triggerMessageTestSuite) SetupTest() {
 		logger:    log.StandardLogger(),
 		scheduler: scheduler.GetScheduler(),
 	}
+	s.cp.firmwareUpdater = firmwareUpdater.NewUpdater("", s.cp.scheduler, nil, nil, nil)
+	s.cp.diagnosticsManager = diagnostics.NewManager(s.cp.scheduler, nil)
 	s.cp.scheduler.Clear()
 }
 
@@ -77,6 +82,22 @@ func (s *triggerMessageTestSuite) TestTriggerMessage() {
 
 	s.cp.scheduler.Clear()
 
+	response, err = s.cp.OnTriggerMessage(remotetrigger.NewTriggerMessageRequest(firmware.FirmwareStatusNotificationFeatureName))
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+
+	s.cp.scheduler.Clear()
+
+	response, err = s.cp.OnTriggerMessage(remotetrigger.NewTriggerMessageRequest(firmware.DiagnosticsStatusNotificationFeatureName))
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+	s.Assert().Len(s.cp.scheduler.Jobs(), 1)
+
+	s.cp.scheduler.Clear()
+
 	// Get status of all connectors
 	response, err = s.cp.OnTriggerMessage(remotetrigger.NewTriggerMessageRequest(core.StatusNotificationFeatureName))
 	s.Assert().NoError(err)
@@ -0,0 +1,111 @@
+package v201
+
+import (
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+)
+
+// authorize checks if the IdToken is authorized for charging and returns the info of the IdToken, if it's known. If LocalPreAuthorize
+// is enabled, the IdToken is authorized with the local authorization list or the cache without contacting the CSMS. Otherwise, the IdToken
+// is authorized with an Authorize request. If the CSMS is unreachable, the IdToken is authorized with authorizeOffline.
+// The transactions of the IdTokens authorized locally are validated by the CSMS with the TransactionEvent.
+func (cp *ChargePoint) authorize(idToken ocpp201.IdToken) (*authData.TokenInfo, bool) {
+	token := authData.FromIdToken(idToken)
+
+	if idToken.Type == ocpp201.IdTokenTypeNoAuthorization {
+		return nil, true
+	}
+
+	if source, isAuthorized := auth.PreAuthorize(cp.authCache, cp.localAuthList, token); isAuthorized {
+		cp.logger.Infof("Authorized token %s with the %s", token.IdToken, source)
+		tokenInfo, _ := auth.GetTokenInfo(cp.authCache, cp.localAuthList, token)
+		return tokenInfo, true
+	}
+
+	// The CSMS cannot authorize the token while offline
+	if !cp.IsOnline() {
+		return cp.authorizeOffline(token)
+	}
+
+	cp.logger.Infof("Authorizing token %s with the CSMS", token.IdToken)
+	tokenInfo, personalMessage, err := cp.sendAuthorizeRequest(idToken)
+	if err != nil {
+		cp.logger.WithError(err).Warnf("Unable to authorize token %s with the CSMS", token.IdToken)
+		return cp.authorizeOffline(token)
+	}
+
+	isAuthorized := tokenInfo.Status == authData.StatusAccepted
+	cp.logger.Debugf("Token authorization result: %v", isAuthorized)
+
+	if isAuthorized {
+		cp.displayTariff(personalMessage)
+	}
+
+	return tokenInfo, isAuthorized
+}
+
+// authorizeOffline checks if the token is authorized while the CSMS is unreachable. If LocalAuthorizeOffline is enabled,
+// the token is authorized with the local authorization list or the cache. If the token is unknown and OfflineTxForUnknownIdEnabled
+// is enabled, the token is authorized as well.
+func (cp *ChargePoint) authorizeOffline(token authData.Token) (*authData.TokenInfo, bool) {
+	source, isAuthorized := auth.AuthorizeOffline(cp.authCache, cp.localAuthList, token)
+	if !isAuthorized {
+		cp.logger.Infof("Token %s not authorized offline", token.IdToken)
+		return nil, false
+	}
+
+	cp.logger.Infof("Authorized token %s offline with the %s", token.IdToken, source)
+	tokenInfo, _ := auth.GetTokenInfo(cp.authCache, cp.localAuthList, token)
+
+	// The tariff of the driver is not known while offline
+	cp.displayTariff(nil)
+	return tokenInfo, true
+}
+
+// getGroupIdToken returns the group of the IdToken from the local authorization list or the cache. If the IdToken is not found,
+// the CSMS is asked for the info of the IdToken.
+func (cp *ChargePoint) getGroupIdToken(idToken ocpp201.IdToken) *authData.Token {
+	if tokenInfo, isFound := auth.GetTokenInfo(cp.authCache, cp.localAuthList, authData.FromIdToken(idToken)); isFound {
+		return tokenInfo.GroupIdToken
+	}
+
+	if !cp.IsOnline() {
+		return nil
+	}
+
+	tokenInfo, _, err := cp.sendAuthorizeRequest(idToken)
+	if err != nil {
+		return nil
+	}
+
+	return tokenInfo.GroupIdToken
+}
+
+// sendAuthorizeRequest sends an Authorize request to the CSMS and adds the token to the cache if it's enabled.
+// The personal message of the driver, e.g. the tariff, is returned as well.
+func (cp *ChargePoint) sendAuthorizeRequest(idToken ocpp201.IdToken) (*authData.TokenInfo, *ocpp201.MessageContent, error) {
+	response, err := cp.chargingStation.SendRequest(ocpp201.NewAuthorizeRequest(idToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idTokenInfo := response.(*ocpp201.AuthorizeResponse).IdTokenInfo
+	tokenInfo := authData.FromIdTokenInfo(&idTokenInfo)
+	auth.UpdateCache(cp.authCache, authData.FromIdToken(idToken), tokenInfo)
+	return tokenInfo, idTokenInfo.PersonalMessage, nil
+}
+
+func (cp *ChargePoint) setMaxCachedTags() {
+	var (
+		maxCachedTagsString, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListMaxLength.String())
+		maxCachedTags, convErr       = strconv.Atoi(maxCachedTagsString)
+	)
+
+	if confErr == nil && convErr == nil {
+		cp.authCache.SetMaxCachedTags(maxCachedTags)
+	}
+}
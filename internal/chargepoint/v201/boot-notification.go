@@ -0,0 +1,137 @@
+package v201
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	configManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+)
+
+// defaultBootRetryInterval is used when the CSMS does not specify the interval or the BootNotification fails.
+const defaultBootRetryInterval = 60
+
+// bootNotification Notify the CSMS that the charging station is online. If the charging station is accepted, set
+// the heartbeat interval, call restoreState after the first boot and notify the CSMS about the connector statuses.
+// If the registration is pending or rejected, retry after the interval from the response. Until the charging station
+// is accepted, the transactions cannot be started.
+func (cp *ChargePoint) bootNotification() {
+	var (
+		ocppInfo = cp.Settings.ChargePoint.Info.OCPPInfo
+		request  = ocpp201.NewBootNotificationRequest(cp.bootReason, ocppInfo.Model, ocppInfo.Vendor)
+	)
+
+	request.ChargingStation.SerialNumber = ocppInfo.ChargePointSerialNumber
+	request.ChargingStation.FirmwareVersion = "1.0"
+	if ocppInfo.Iccid != "" || ocppInfo.Imsi != "" {
+		request.ChargingStation.Modem = &ocpp201.Modem{Iccid: ocppInfo.Iccid, Imsi: ocppInfo.Imsi}
+	}
+
+	callback := func(response ocpp.Response, protoError error) {
+		bootResponse, isBootResponse := response.(*ocpp201.BootNotificationResponse)
+		if protoError != nil || !isBootResponse || bootResponse == nil {
+			cp.logger.WithError(protoError).Errorf("Invalid BootNotification response")
+			cp.scheduleBootNotification(defaultBootRetryInterval)
+			return
+		}
+
+		cp.setRegistrationStatus(bootResponse.Status)
+
+		switch bootResponse.Status {
+		case ocpp201.RegistrationStatusAccepted:
+			cp.logger.Info("Notified and accepted from the CSMS")
+			_ = cp.scheduler.RemoveByTag("bootNotification")
+			cp.setHeartbeat(bootResponse.Interval)
+			cp.restoreOnce.Do(cp.restoreState)
+
+			// The statuses might have changed while the charging station was offline or not accepted
+			for _, c := range cp.connectorManager.GetConnectors() {
+				cp.notifyConnectorStatus(c)
+			}
+
+			// The events of the problems, which occurred while offline
+			cp.sendQueuedEvents()
+		case ocpp201.RegistrationStatusPending:
+			cp.logger.Info("Registration status pending")
+			cp.scheduleBootNotification(bootResponse.Interval)
+		default:
+			cp.logger.Warn("Rejected by the CSMS")
+			cp.scheduleBootNotification(bootResponse.Interval)
+		}
+	}
+
+	err := cp.chargingStation.SendRequestAsync(request, callback)
+	util.HandleRequestErr(err, "Error sending BootNotification")
+}
+
+// scheduleBootNotification schedules the next BootNotification after the interval (in seconds).
+func (cp *ChargePoint) scheduleBootNotification(interval int) {
+	if interval <= 0 {
+		interval = defaultBootRetryInterval
+	}
+
+	cp.logger.Infof("Retrying the BootNotification in %d seconds", interval)
+
+	_ = cp.scheduler.RemoveByTag("bootNotification")
+	_, err := cp.scheduler.Every(interval).Seconds().LimitRunsTo(1).Tag("bootNotification").Do(cp.bootNotification)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error scheduling the BootNotification")
+	}
+}
+
+func (cp *ChargePoint) setRegistrationStatus(status ocpp201.RegistrationStatus) {
+	cp.registrationMu.Lock()
+	defer cp.registrationMu.Unlock()
+	cp.registrationStatus = status
+}
+
+// canStartTransactions checks if the CSMS did not reject the charging station or put it on hold. Until the first
+// BootNotification response, the transactions can be started offline.
+func (cp *ChargePoint) canStartTransactions() bool {
+	cp.registrationMu.Lock()
+	defer cp.registrationMu.Unlock()
+
+	switch cp.registrationStatus {
+	case ocpp201.RegistrationStatusPending, ocpp201.RegistrationStatusRejected:
+		return false
+	default:
+		return true
+	}
+}
+
+// isRegistered checks if the charging station is connected and accepted by the CSMS.
+func (cp *ChargePoint) isRegistered() bool {
+	cp.registrationMu.Lock()
+	isAccepted := cp.registrationStatus == ocpp201.RegistrationStatusAccepted
+	cp.registrationMu.Unlock()
+
+	return isAccepted && cp.IsOnline()
+}
+
+func (cp *ChargePoint) setHeartbeat(interval int) {
+	cp.logger.Infof("Setting a heartbeat schedule")
+
+	heartBeatInterval, _ := configManager.GetConfigurationValue(v16.HeartbeatInterval.String())
+	if interval > 0 {
+		heartBeatInterval = fmt.Sprintf("%d", interval)
+	}
+
+	heartBeatInterval = fmt.Sprintf("%ss", heartBeatInterval)
+	// The heartbeat is rescheduled after every reconnect
+	_ = scheduler.GetScheduler().RemoveByTag("heartbeat")
+	_, err := scheduler.GetScheduler().Every(heartBeatInterval).Tag("heartbeat").Do(cp.sendHeartBeat)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error scheduling heartbeat")
+	}
+}
+
+// sendHeartBeat Send a heartbeat to the CSMS.
+func (cp *ChargePoint) sendHeartBeat() error {
+	return cp.chargingStation.SendRequestAsync(
+		ocpp201.NewHeartbeatRequest(),
+		func(response ocpp.Response, protoError error) {
+			cp.logger.Info("Sent heartbeat")
+		})
+}
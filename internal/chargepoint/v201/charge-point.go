@@ -0,0 +1,310 @@
+package v201
+
+import (
+	"context"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/reactivex/rxgo/v2"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/api"
+	chargePointUtil "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
+	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	customerInformation "github.com/xBlaz3kx/ChargePi-go/internal/components/customer-information"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	displayMessages "github.com/xBlaz3kx/ChargePi-go/internal/components/display-messages"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/monitoring"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sync"
+)
+
+type (
+	// ChargePoint is an OCPP 2.0.1 charging station. The connectors of the charge point are the EVSEs of the charging station.
+	ChargePoint struct {
+		chargingStation ocpp201.ChargingStation
+		supervisor      connectionSupervisor.Supervisor
+		wsClient        *connectionSupervisor.Client
+		availability    core.AvailabilityType
+		// Registration status from the last BootNotification
+		registrationStatus ocpp201.RegistrationStatus
+		registrationMu     sync.Mutex
+		bootReason         ocpp201.BootReason
+		Settings           *settings.Settings
+		// Hardware components
+		TagReader reader.Reader
+		Indicator indicator.Indicator
+		LCD       display.LCD
+		// Software components
+		connectorManager   connectorManager.Manager
+		connectorChannel   chan rxgo.Item
+		meterValuesChannel chan models.MeterValueNotification
+		scheduler          *gocron.Scheduler
+		authCache          *auth.Cache
+		localAuthList      *auth.LocalAuthList
+		transactionQueue   transactionQueue.Queue
+		reservationManager reservations.Manager
+		certificateManager certificates.Manager
+		deviceModel        deviceModel.Store
+		displayMessages    displayMessages.Manager
+		// Index of the message displayed in the cycle
+		messageIndex int
+		monitoring   monitoring.Manager
+		// Data stored about the customers, reported and cleared with CustomerInformation
+		customerInformation customerInformation.Manager
+		// Handlers of the vendor specific messages
+		dataTransfer dataTransfer.Registry
+		// Events waiting for the connection to the CSMS
+		pendingEvents     []ocpp201.EventData
+		eventsMu          sync.Mutex
+		connectorSettings []*settings.Connector
+		// Ongoing transactions, by the transaction id
+		transactions   map[string]*transaction
+		transactionsMu sync.Mutex
+		restoreOnce    sync.Once
+		logger         *log.Logger
+	}
+
+	Options func(point *ChargePoint)
+)
+
+// NewChargePoint creates a new ChargePoint for OCPP version 2.0.1.
+func NewChargePoint(
+	manager connectorManager.Manager,
+	scheduler *gocron.Scheduler,
+	cache *auth.Cache,
+	localAuthList *auth.LocalAuthList,
+	queue transactionQueue.Queue,
+	store deviceModel.Store,
+	opts ...Options,
+) *ChargePoint {
+	var (
+		ch            = make(chan rxgo.Item, 5)
+		meterValuesCh = make(chan models.MeterValueNotification, 5)
+	)
+
+	// Set the channels
+	manager.SetNotificationChannel(ch)
+	manager.SetMeterValuesChannel(meterValuesCh)
+
+	cp := &ChargePoint{
+		availability:        core.AvailabilityTypeInoperative,
+		bootReason:          ocpp201.BootReasonPowerUp,
+		connectorChannel:    ch,
+		meterValuesChannel:  meterValuesCh,
+		scheduler:           scheduler,
+		connectorManager:    manager,
+		authCache:           cache,
+		localAuthList:       localAuthList,
+		transactionQueue:    queue,
+		deviceModel:         store,
+		displayMessages:     displayMessages.NewManager(),
+		monitoring:          monitoring.NewManager(),
+		customerInformation: customerInformation.NewManager(),
+		dataTransfer:        dataTransfer.NewRegistry(),
+		transactions:        map[string]*transaction{},
+		logger:              log.StandardLogger(),
+	}
+
+	cp.transactionQueue.SetTransactionEventHandler(cp.onTransactionEventResponse)
+
+	// Apply options
+	for _, opt := range opts {
+		opt(cp)
+	}
+
+	cp.setupCustomerInformation(logging.LogFilePath)
+
+	return cp
+}
+
+// Init initializes the charging station based on the settings.
+func (cp *ChargePoint) Init(settings *settings.Settings) {
+	if settings == nil {
+		log.Fatal("no settings provided")
+	}
+
+	cp.Settings = settings
+
+	var (
+		info      = settings.ChargePoint.Info
+		tlsConfig = settings.ChargePoint.TLS
+		logInfo   = log.WithFields(log.Fields{
+			"chargePointId": info.Id,
+		})
+	)
+
+	if util.IsNilInterfaceOrPointer(cp.certificateManager) {
+		cp.certificateManager = certificates.NewManager(tlsConfig.CertificateStorePath)
+	}
+
+	if cp.wsClient == nil {
+		cp.wsClient = chargePointUtil.CreateClient(
+			info.Id,
+			info.BasicAuthUsername,
+			info.BasicAuthPassword,
+			tlsConfig,
+			cp.certificateManager,
+		)
+	}
+
+	logInfo.Debug("Creating charging station")
+	cp.supervisor = connectionSupervisor.NewSupervisor(cp.wsClient, chargePointUtil.CreateTimeoutConfig())
+	cp.supervisor.AddStateHandler(cp.onConnectionStateChange)
+
+	cp.chargingStation = ocpp201.NewChargingStation(info.Id, cp.supervisor)
+	cp.chargingStation.SetRemoteControlHandler(cp)
+	cp.chargingStation.SetDeviceModelHandler(cp)
+	cp.chargingStation.SetAuthorizationHandler(cp)
+	cp.chargingStation.SetLocalAuthListHandler(cp)
+	cp.chargingStation.SetDisplayMessageHandler(cp)
+	cp.chargingStation.SetDiagnosticsHandler(cp)
+	cp.chargingStation.SetTariffCostHandler(cp)
+	cp.chargingStation.SetDataTransferHandler(cp)
+	cp.dataTransfer.SetSender(cp.sendDataTransfer)
+
+	cp.setMaxCachedTags()
+	cp.setMaxLocalListTags()
+	cp.scheduleDisplayMessages()
+	cp.scheduleMonitoring()
+}
+
+// Connect to the CSMS in the background. The charging station operates offline until the connection is established.
+// After every (re)connect, a BootNotification and the connector statuses are sent to the CSMS.
+func (cp *ChargePoint) Connect(ctx context.Context, serverUrl string) {
+	cp.availability = core.AvailabilityTypeOperative
+
+	go cp.ListenForConnectorStatusChange(ctx, cp.connectorChannel)
+	// Send the transaction events queued while offline
+	go cp.transactionQueue.Run(ctx, cp.chargingStation.SendRequest, cp.isRegistered)
+
+	go func() {
+		cp.logger.Infof("Trying to connect to the CSMS: %s", serverUrl)
+		err := cp.supervisor.Connect(ctx, func() error {
+			return cp.chargingStation.Start(serverUrl)
+		})
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Stopped connecting to the CSMS")
+			return
+		}
+
+		cp.logger.Infof("Successfully connected to: %s", serverUrl)
+	}()
+}
+
+// IsOnline checks if the client is connected to the CSMS.
+func (cp *ChargePoint) IsOnline() bool {
+	return !util.IsNilInterfaceOrPointer(cp.supervisor) && cp.supervisor.IsConnected()
+}
+
+// onConnectionStateChange displays the connection state. When the charging station goes online, it sends a BootNotification.
+func (cp *ChargePoint) onConnectionStateChange(isOnline bool) {
+	go cp.displayConnectionStatus(isOnline)
+	cp.notifyConnectionState(isOnline)
+
+	if !isOnline {
+		return
+	}
+
+	cp.bootNotification()
+}
+
+// HandleChargingRequest stops the transaction of the tag or of the tag's group, if there is one. Otherwise, a transaction
+// is started on the first available connector.
+func (cp *ChargePoint) HandleChargingRequest(tagId string) (*api.HandleChargingResponse, error) {
+	cp.logger.Infof("Handling request for tag %s", tagId)
+	idToken := ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443)
+
+	c := cp.connectorManager.FindConnectorWithTagId(tagId)
+	if util.IsNilInterfaceOrPointer(c) {
+		c = cp.findGroupConnector(idToken)
+	}
+
+	if !util.IsNilInterfaceOrPointer(c) {
+		err := cp.stopChargingConnector(c, ocpp201.TriggerReasonStopAuthorized, ocpp201.StoppedReasonLocal)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Error stopping charging the connector")
+		}
+
+		return nil, err
+	}
+
+	err := cp.startCharging(idToken)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot start charging the connector")
+	}
+
+	return nil, err
+}
+
+// CleanUp When exiting the client, stop all the transactions, clean up all the peripherals and terminate the connection.
+func (cp *ChargePoint) CleanUp(reason core.Reason) {
+	cp.logger.Infof("Cleaning up ChargePoint, reason: %s", reason)
+
+	switch reason {
+	case core.ReasonRemote, core.ReasonLocal, core.ReasonHardReset, core.ReasonSoftReset:
+		triggerReason, stoppedReason := getStoppedReason(reason)
+
+		for _, c := range cp.connectorManager.GetConnectors() {
+			// Stop charging the connectors
+			err := cp.stopChargingConnector(c, triggerReason, stoppedReason)
+			if err != nil {
+				cp.logger.WithError(err).Errorf("Cannot stop the transaction at cleanup")
+			}
+		}
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.supervisor) && cp.supervisor.IsStarted() {
+		cp.logger.Infof("Disconnecting the client..")
+		cp.chargingStation.Stop()
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.TagReader) {
+		cp.logger.Info("Cleaning up the Tag Reader")
+		cp.TagReader.Cleanup()
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.LCD) {
+		cp.logger.Info("Cleaning up LCD")
+		cp.LCD.Cleanup()
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.Indicator) {
+		cp.logger.Info("Cleaning up Indicator")
+		cp.Indicator.Cleanup()
+	}
+
+	close(cp.connectorChannel)
+	cp.logger.Info("Clearing the scheduler...")
+	cp.scheduler.Stop()
+	cp.scheduler.Clear()
+
+	cp.authCache.DumpTags()
+}
+
+// getStoppedReason maps the reason of the cleanup to the trigger and stopped reason of the TransactionEvent.
+func getStoppedReason(reason core.Reason) (ocpp201.TriggerReason, ocpp201.StoppedReason) {
+	switch reason {
+	case core.ReasonRemote:
+		return ocpp201.TriggerReasonRemoteStop, ocpp201.StoppedReasonRemote
+	case core.ReasonHardReset, core.ReasonSoftReset:
+		return ocpp201.TriggerReasonResetCommand, ocpp201.StoppedReasonImmediateReset
+	case core.ReasonPowerLoss:
+		return ocpp201.TriggerReasonAbnormalCondition, ocpp201.StoppedReasonPowerLoss
+	case core.ReasonLocal:
+		return ocpp201.TriggerReasonAbnormalCondition, ocpp201.StoppedReasonLocal
+	default:
+		return ocpp201.TriggerReasonAbnormalCondition, ocpp201.StoppedReasonOther
+	}
+}
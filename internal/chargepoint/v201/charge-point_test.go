@@ -0,0 +1,317 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"testing"
+	"time"
+)
+
+const (
+	connectorId = 1
+	tagId       = "exampleTagId"
+)
+
+var (
+	ocppConfig = configuration.Config{
+		Version: 1,
+		Keys: []core.ConfigurationKey{
+			{
+				Key:      "AllowOfflineTxForUnknownId",
+				Readonly: false,
+				Value:    "false",
+			},
+			{
+				Key:      "AuthorizationCacheEnabled",
+				Readonly: false,
+				Value:    "false",
+			},
+			{
+				Key:      "AuthorizeRemoteTxRequests",
+				Readonly: false,
+				Value:    "false",
+			},
+			{
+				Key:      "ClockAlignedDataInterval",
+				Readonly: false,
+				Value:    "0",
+			},
+			{
+				Key:      "ConnectionTimeOut",
+				Readonly: false,
+				Value:    "50",
+			},
+			{
+				Key:      "GetConfigurationMaxKeys",
+				Readonly: false,
+				Value:    "30",
+			},
+			{
+				Key:      "HeartbeatInterval",
+				Readonly: false,
+				Value:    "60",
+			},
+			{
+				Key:      "LocalAuthListEnabled",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "LocalAuthorizeOffline",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "LocalPreAuthorize",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "MaxEnergyOnInvalidId",
+				Readonly: false,
+				Value:    "0",
+			},
+			{
+				Key:      "MeterValuesSampledData",
+				Readonly: false,
+				Value:    "Power.Active.Import",
+			},
+			{
+				Key:      "MeterValuesAlignedData",
+				Readonly: false,
+				Value:    "false",
+			},
+			{
+				Key:      "NumberOfConnectors",
+				Readonly: false,
+				Value:    "6",
+			},
+			{
+				Key:      "MeterValueSampleInterval",
+				Readonly: false,
+				Value:    "60",
+			},
+			{
+				Key:      "ResetRetries",
+				Readonly: false,
+				Value:    "3",
+			},
+			{
+				Key:      "ConnectorPhaseRotation",
+				Readonly: false,
+				Value:    "0.RST, 1.RST, 2.RTS",
+			},
+			{
+				Key:      "SendLocalListMaxLength",
+				Readonly: true,
+				Value:    "2",
+			},
+			{
+				Key:      "StopTransactionOnEVSideDisconnect",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "StopTransactionOnInvalidId",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "StopTxnAlignedData",
+				Readonly: false,
+			},
+			{
+				Key:      "StopTxnSampledData",
+				Readonly: false,
+			},
+			{
+				Key:      "SupportedFeatureProfiles",
+				Readonly: true,
+				Value:    "Core, LocalAuthListManagement, Reservation, RemoteTrigger",
+			},
+			{
+				Key:      "TransactionMessageAttempts",
+				Readonly: false,
+				Value:    "3",
+			},
+			{
+				Key:      "TransactionMessageRetryInterval",
+				Readonly: false,
+				Value:    "60",
+			},
+			{
+				Key:      "UnlockConnectorOnEVSideDisconnect",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "ReserveConnectorZeroSupported",
+				Readonly: false,
+				Value:    "false",
+			},
+			{
+				Key:      "SendLocalListMaxLength",
+				Readonly: false,
+				Value:    "20",
+			},
+			{
+				Key:      "LocalAuthListEnabled",
+				Readonly: false,
+				Value:    "true",
+			},
+			{
+				Key:      "LocalAuthListMaxLength",
+				Readonly: false,
+				Value:    "20",
+			},
+			{
+				Key:      "SecurityProfile",
+				Readonly: false,
+				Value:    "0",
+			},
+			{
+				Key:      "AuthorizationKey",
+				Readonly: false,
+				Value:    "",
+			},
+			{
+				Key:      "CertificateSignedMaxChainSize",
+				Readonly: false,
+				Value:    "10000",
+			},
+			{
+				Key:      "CpoName",
+				Readonly: false,
+				Value:    "ChargePi",
+			},
+		},
+	}
+)
+
+type chargePointTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *chargePointTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		availability: core.AvailabilityTypeOperative,
+		bootReason:   ocpp201.BootReasonPowerUp,
+		Settings:     &settings.Settings{},
+		transactions: map[string]*transaction{},
+		logger:       log.StandardLogger(),
+		scheduler:    scheduler.GetScheduler(),
+	}
+}
+
+func (s *chargePointTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *chargePointTestSuite) TestOnConnectionStateChange() {
+	var (
+		chargingStation = new(chargingStationMock)
+		connectorMock   = new(test.ConnectorMock)
+		managerMock     = new(test.ManagerMock)
+		bootResponse    = &ocpp201.BootNotificationResponse{
+			CurrentTime: types.NewDateTime(time.Now()),
+			Interval:    60,
+			Status:      ocpp201.RegistrationStatusAccepted,
+		}
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusCharging, core.NoError)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+
+	chargingStation.On("SendRequestAsync", mock.AnythingOfType("*ocpp201.BootNotificationRequest")).Return(bootResponse, nil, nil)
+	chargingStation.On("SendRequestAsync", mock.AnythingOfType("*ocpp201.StatusNotificationRequest")).Return(&ocpp201.StatusNotificationResponse{}, nil, nil)
+
+	s.cp.chargingStation = chargingStation
+	s.cp.connectorManager = managerMock
+
+	// Nothing is sent while offline
+	s.cp.onConnectionStateChange(false)
+	chargingStation.AssertNotCalled(s.T(), "SendRequestAsync", mock.Anything)
+
+	// Send a BootNotification and the connector statuses after every (re)connect
+	s.cp.onConnectionStateChange(true)
+	time.Sleep(time.Millisecond * 300)
+	s.cp.onConnectionStateChange(true)
+	time.Sleep(time.Millisecond * 300)
+
+	chargingStation.AssertNumberOfCalls(s.T(), "SendRequestAsync", 4)
+
+	// The connector in a transaction is reported as occupied
+	for _, call := range chargingStation.Calls {
+		if request, isStatus := call.Arguments.Get(0).(*ocpp201.StatusNotificationRequest); isStatus {
+			s.Assert().EqualValues(ocpp201.ConnectorStatusOccupied, request.ConnectorStatus)
+			s.Assert().EqualValues(1, request.EvseId)
+			s.Assert().EqualValues(connectorId, request.ConnectorId)
+		}
+	}
+
+	// The heartbeat is not scheduled twice
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+	s.Assert().EqualValues([]string{"heartbeat"}, s.cp.scheduler.Jobs()[0].Tags())
+}
+
+func (s *chargePointTestSuite) TestBootNotificationPending() {
+	var (
+		chargingStation = new(chargingStationMock)
+		connectorMock   = new(test.ConnectorMock)
+		bootResponse    = &ocpp201.BootNotificationResponse{
+			CurrentTime: types.NewDateTime(time.Now()),
+			Interval:    30,
+			Status:      ocpp201.RegistrationStatusPending,
+		}
+	)
+
+	chargingStation.On("SendRequestAsync", mock.AnythingOfType("*ocpp201.BootNotificationRequest")).Return(bootResponse, nil, nil)
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(true)
+	s.cp.chargingStation = chargingStation
+
+	// The BootNotification is retried after the interval from the response
+	s.cp.bootNotification()
+	time.Sleep(time.Millisecond * 300)
+
+	s.Assert().False(s.cp.canStartTransactions())
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+	s.Assert().EqualValues([]string{"bootNotification"}, s.cp.scheduler.Jobs()[0].Tags())
+
+	// Transactions cannot be started until the charging station is accepted
+	err := s.cp.startChargingConnector(connectorMock, ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443), nil, nil)
+	s.Assert().ErrorIs(err, errors.ErrChargePointNotAccepted)
+}
+
+func (s *chargePointTestSuite) TestGetConnectorStatus() {
+	s.Assert().EqualValues(ocpp201.ConnectorStatusAvailable, getConnectorStatus(core.ChargePointStatusAvailable))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusOccupied, getConnectorStatus(core.ChargePointStatusPreparing))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusOccupied, getConnectorStatus(core.ChargePointStatusSuspendedEV))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusOccupied, getConnectorStatus(core.ChargePointStatusFinishing))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusReserved, getConnectorStatus(core.ChargePointStatusReserved))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusFaulted, getConnectorStatus(core.ChargePointStatusFaulted))
+	s.Assert().EqualValues(ocpp201.ConnectorStatusUnavailable, getConnectorStatus(core.ChargePointStatusUnavailable))
+}
+
+func TestChargePoint(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(chargePointTestSuite))
+}
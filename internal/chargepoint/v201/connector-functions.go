@@ -0,0 +1,208 @@
+package v201
+
+import (
+	"context"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/reactivex/rxgo/v2"
+	"github.com/spf13/viper"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"time"
+)
+
+// AddConnectors Add the Connectors from the connectors.json file to the handler. Create and add all their components and initialize the struct.
+func (cp *ChargePoint) AddConnectors(connectors []*settingsData.Connector) {
+	if util.IsNilInterfaceOrPointer(connectors) {
+		cp.logger.Fatal("no connectors configured")
+	}
+
+	cp.logger.Debugf("Adding connectors")
+	err := cp.connectorManager.AddConnectorsFromConfiguration(cp.Settings.ChargePoint.Info.MaxChargingTime, connectors)
+	if err != nil {
+		cp.logger.WithError(err).Fatalf("Unable to add connectors from configuration")
+	}
+
+	// Add an indicator with the length of valid connectors
+	cp.Indicator = indicator.NewIndicator(len(cp.connectorManager.GetConnectors()))
+
+	// The device model describes the connectors
+	cp.connectorSettings = connectors
+	cp.setupDeviceModel()
+	cp.setupMonitoring()
+}
+
+// restoreState After connecting to the CSMS, try to restore the previous state of each connector. The transactions
+// that can be resumed continue, the others are ended.
+func (cp *ChargePoint) restoreState() {
+	cp.logger.Debugf("Restoring connectors' state")
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		var (
+			cacheKey = fmt.Sprintf("connectorEvse%dId%d", c.GetEvseId(), c.GetConnectorId())
+			conn     settingsData.Connector
+		)
+
+		// Fetch the viper configuration
+		connectorCfg, isFound := settings.ConnectorSettings.Load(cacheKey)
+		if !isFound {
+			continue
+		}
+		cfg := connectorCfg.(*viper.Viper)
+
+		// Unmarshall
+		err := cfg.Unmarshal(&conn)
+		if err != nil {
+			continue
+		}
+
+		err = cp.connectorManager.RestoreConnectorStatus(&conn)
+		switch err {
+		case nil:
+			status, _ := c.GetStatus()
+			chargingState, _ := getChargingState(status)
+
+			tx := &transaction{
+				idToken:       ocpp201.NewIdToken(c.GetTagId(), ocpp201.IdTokenTypeISO14443),
+				chargingState: chargingState,
+			}
+
+			// Restore the transaction stored with the session
+			if conn.Transaction != nil && conn.Transaction.TransactionId == c.GetTransactionId() {
+				tx = newTransactionFromSettings(*conn.Transaction)
+				tx.chargingState = chargingState
+			}
+
+			cp.transactionsMu.Lock()
+			cp.transactions[c.GetTransactionId()] = tx
+			cp.transactionsMu.Unlock()
+
+			_, err = cp.scheduler.Every(c.GetMaxChargingTime()).Minutes().LimitRunsTo(1).
+				Tag(fmt.Sprintf("connector%dTimer", c.GetConnectorId())).
+				Do(cp.stopChargingConnector, c, ocpp201.TriggerReasonTimeLimitReached, ocpp201.StoppedReasonTimeLimitReached)
+		default:
+			// Attempt to stop charging
+			err = cp.stopChargingConnector(c, ocpp201.TriggerReasonAbnormalCondition, ocpp201.StoppedReasonPowerLoss)
+			if err != nil {
+				cp.logger.Debugf("Stopping the charging returned %v", err)
+				c.SetStatus(core.ChargePointStatusFaulted, core.InternalError)
+			}
+		}
+	}
+}
+
+// notifyConnectorStatus Notify the CSMS about the connector's status.
+func (cp *ChargePoint) notifyConnectorStatus(c connector.Connector) {
+	if util.IsNilInterfaceOrPointer(c) {
+		return
+	}
+
+	var (
+		status, _   = c.GetStatus()
+		connectorId = c.GetConnectorId()
+		request     = ocpp201.NewStatusNotificationRequest(
+			types.NewDateTime(time.Now()),
+			getConnectorStatus(status),
+			c.GetEvseId(),
+			connectorId,
+		)
+	)
+
+	callback := func(response ocpp.Response, protoError error) {
+		cp.logger.Infof("Notified status of the connector %d: %s", connectorId, request.ConnectorStatus)
+	}
+
+	err := cp.chargingStation.SendRequestAsync(request, callback)
+	util.HandleRequestErr(err, "Cannot send status of connector")
+}
+
+// ListenForConnectorStatusChange listen for change in connector and notify the CSMS about the state
+func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-chan rxgo.Item) {
+	cp.logger.Debug("Starting to listen for connector status change")
+	observableConnectors := rxgo.FromChannel(ch)
+
+	if observableConnectors != nil {
+	Listener:
+		for {
+			select {
+			// Start observing the connector for changes in status
+			case item := <-observableConnectors.Observe():
+				c, canCast := item.V.(connector.Connector)
+				if canCast {
+					// Connector starts with index 1
+					connectorIndex := c.GetConnectorId() - 1
+					status, _ := c.GetStatus()
+
+					cp.displayLEDStatus(connectorIndex, status)
+					go cp.displayConnectorStatus(c.GetConnectorId(), status)
+					cp.notifyConnectorStatus(c)
+					cp.notifyConnectorProblem(c)
+					cp.onChargingStateChanged(c)
+				}
+			case meterValues := <-cp.meterValuesChannel:
+				cp.sendMeterValues(meterValues)
+			case <-ctx.Done():
+				break Listener
+			default:
+			}
+		}
+	}
+}
+
+func (cp *ChargePoint) displayConnectorStatus(connectorId int, status core.ChargePointStatus) {
+	var (
+		language = cp.Settings.ChargePoint.Hardware.Lcd.Language
+		message  []string
+		err      error
+	)
+
+	// The AlwaysFront message set by the CSMS is not replaced by the connector status
+	if cp.hasAlwaysFrontMessage() {
+		return
+	}
+
+	switch status {
+	case core.ChargePointStatusAvailable:
+		message, err = i18n.TranslateConnectorAvailableMessage(language, connectorId)
+	case core.ChargePointStatusFinishing:
+		message, err = i18n.TranslateConnectorFinishingMessage(language, connectorId)
+	case core.ChargePointStatusCharging:
+		message, err = i18n.TranslateConnectorChargingMessage(language, connectorId)
+	case core.ChargePointStatusFaulted:
+		message, err = i18n.TranslateConnectorFaultedMessage(language, connectorId)
+	default:
+		return
+	}
+
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error displaying status")
+		return
+	}
+
+	cp.sendToLCD(message...)
+}
+
+// getConnectorStatus maps the connector status to the OCPP 2.0.1 connector status. The statuses of an ongoing
+// transaction are reported as occupied.
+func getConnectorStatus(status core.ChargePointStatus) ocpp201.ConnectorStatus {
+	switch status {
+	case core.ChargePointStatusAvailable:
+		return ocpp201.ConnectorStatusAvailable
+	case core.ChargePointStatusPreparing, core.ChargePointStatusCharging, core.ChargePointStatusSuspendedEV,
+		core.ChargePointStatusSuspendedEVSE, core.ChargePointStatusFinishing:
+		return ocpp201.ConnectorStatusOccupied
+	case core.ChargePointStatusReserved:
+		return ocpp201.ConnectorStatusReserved
+	case core.ChargePointStatusFaulted:
+		return ocpp201.ConnectorStatusFaulted
+	default:
+		return ocpp201.ConnectorStatusUnavailable
+	}
+}
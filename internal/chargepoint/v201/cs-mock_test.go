@@ -0,0 +1,76 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/stretchr/testify/mock"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"time"
+)
+
+type chargingStationMock struct {
+	mock.Mock
+}
+
+func (c *chargingStationMock) SetRemoteControlHandler(handler ocpp201.RemoteControlHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetDeviceModelHandler(handler ocpp201.DeviceModelHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetDisplayMessageHandler(handler ocpp201.DisplayMessageHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetDiagnosticsHandler(handler ocpp201.DiagnosticsHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetTariffCostHandler(handler ocpp201.TariffCostHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetDataTransferHandler(handler ocpp201.DataTransferHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetAuthorizationHandler(handler ocpp201.AuthorizationHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetLocalAuthListHandler(handler ocpp201.LocalAuthListHandler) {
+	c.Called()
+}
+
+func (c *chargingStationMock) SetRequestTimeout(timeout time.Duration) {
+	c.Called(timeout)
+}
+
+func (c *chargingStationMock) SendRequest(request ocpp.Request) (ocpp.Response, error) {
+	args := c.Called(request)
+	return args.Get(0).(ocpp.Response), args.Error(1)
+}
+
+func (c *chargingStationMock) SendRequestAsync(request ocpp.Request, callback func(response ocpp.Response, protoError error)) error {
+	args := c.Called(request)
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		callback(args.Get(0).(ocpp.Response), args.Error(1))
+	}()
+
+	return args.Error(2)
+}
+
+func (c *chargingStationMock) Start(csmsUrl string) error {
+	return c.Called(csmsUrl).Error(0)
+}
+
+func (c *chargingStationMock) Stop() {
+	c.Called()
+}
+
+func (c *chargingStationMock) IsConnected() bool {
+	return c.Called().Bool(0)
+}
@@ -0,0 +1,122 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	customerInformation "github.com/xBlaz3kx/ChargePi-go/internal/components/customer-information"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCustomerDataLength is the maximum length of the data in a NotifyCustomerInformation request.
+	maxCustomerDataLength = 512
+
+	noCustomerInformation    = "No customer information found"
+	customerInformationClear = "Customer information cleared"
+	customerInformationError = "Customer information could not be cleared completely"
+)
+
+// setupCustomerInformation adds the places where the charging station keeps the data about the customers.
+func (cp *ChargePoint) setupCustomerInformation(logFile string) {
+	cp.customerInformation.AddSource(customerInformation.AuthCacheSourceName, customerInformation.NewAuthCacheSource(cp.authCache))
+	cp.customerInformation.AddSource(customerInformation.LocalAuthListSourceName, customerInformation.NewLocalAuthListSource(cp.localAuthList))
+	if !util.IsNilInterfaceOrPointer(cp.reservationManager) {
+		cp.customerInformation.AddSource(customerInformation.ReservationsSourceName, customerInformation.NewReservationsSource(cp.reservationManager))
+	}
+
+	cp.customerInformation.AddSource(customerInformation.SessionsSourceName, customerInformation.NewSessionSource())
+	cp.customerInformation.AddSource(customerInformation.TransactionQueueSourceName, customerInformation.NewTransactionQueueSource(cp.transactionQueue))
+	cp.customerInformation.AddSource(customerInformation.LogsSourceName, customerInformation.NewLogSource(logFile, logging.RotateLogFile))
+}
+
+// OnCustomerInformation accepts the request and sends the report of the data about the customer with the
+// NotifyCustomerInformation requests after the response. The data is cleared after it is collected for the report.
+// The customer is identified by the IdToken or the CustomerIdentifier, as the customer certificates are not stored.
+func (cp *ChargePoint) OnCustomerInformation(request *ocpp201.CustomerInformationRequest) (*ocpp201.CustomerInformationResponse, error) {
+	cp.logger.WithField("requestId", request.RequestId).Infof("Received request %s", request.GetFeatureName())
+
+	idToken := request.CustomerIdentifier
+	if request.IdToken != nil {
+		idToken = request.IdToken.IdToken
+	}
+
+	switch {
+	case idToken == "" && request.CustomerCertificate == nil:
+		return ocpp201.NewCustomerInformationResponse(ocpp201.CustomerInformationStatusInvalid), nil
+	case idToken == "":
+		return ocpp201.NewCustomerInformationResponse(ocpp201.CustomerInformationStatusRejected), nil
+	}
+
+	_, err := cp.scheduler.Every(1).Seconds().LimitRunsTo(1).
+		Do(cp.sendCustomerInformation, request.RequestId, idToken, request.Report, request.Clear)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the customer information")
+		return ocpp201.NewCustomerInformationResponse(ocpp201.CustomerInformationStatusRejected), nil
+	}
+
+	return ocpp201.NewCustomerInformationResponse(ocpp201.CustomerInformationStatusAccepted), nil
+}
+
+// sendCustomerInformation collects the report, clears the data if requested and sends the result in parts of at most
+// maxCustomerDataLength characters. The CSMS knows the data is complete when the NotifyCustomerInformation is not to be continued.
+func (cp *ChargePoint) sendCustomerInformation(requestId int, idToken string, report, clear bool) {
+	var (
+		logInfo     = cp.logger.WithField("requestId", requestId)
+		data        []string
+		generatedAt = types.NewDateTime(time.Now())
+	)
+
+	if report {
+		reportData, err := cp.customerInformation.Report(idToken)
+		if err != nil {
+			logInfo.WithError(err).Errorf("Cannot collect the customer information")
+		}
+
+		data = reportData
+	}
+
+	if clear {
+		err := cp.customerInformation.Clear(idToken)
+		if err != nil {
+			data = append(data, customerInformationError)
+		} else {
+			data = append(data, customerInformationClear)
+		}
+	}
+
+	if len(data) == 0 {
+		data = append(data, noCustomerInformation)
+	}
+
+	parts := splitCustomerData(strings.Join(data, "\n"))
+	for seqNo, part := range parts {
+		request := ocpp201.NewNotifyCustomerInformationRequest(requestId, generatedAt, seqNo, part)
+		request.ToBeContinued = seqNo < len(parts)-1
+
+		_, err := cp.chargingStation.SendRequest(request)
+		if err != nil {
+			logInfo.WithError(err).Errorf("Cannot send the customer information")
+			return
+		}
+	}
+
+	logInfo.Infof("Sent the customer information in %d parts", len(parts))
+}
+
+// splitCustomerData splits the data into parts that fit into a NotifyCustomerInformation request.
+func splitCustomerData(data string) []string {
+	var (
+		parts []string
+		runes = []rune(data)
+	)
+
+	for len(runes) > maxCustomerDataLength {
+		parts = append(parts, string(runes[:maxCustomerDataLength]))
+		runes = runes[maxCustomerDataLength:]
+	}
+
+	return append(parts, string(runes))
+}
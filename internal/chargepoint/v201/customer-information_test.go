@@ -0,0 +1,113 @@
+package v201
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	customerInformation "github.com/xBlaz3kx/ChargePi-go/internal/components/customer-information"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type customerInformationTestSuite struct {
+	suite.Suite
+	cp              *ChargePoint
+	chargingStation *chargingStationMock
+}
+
+func (s *customerInformationTestSuite) SetupTest() {
+	dir := s.T().TempDir()
+
+	s.chargingStation = new(chargingStationMock)
+	s.chargingStation.On("SendRequest", mock.AnythingOfType("*ocpp201.NotifyCustomerInformationRequest")).
+		Return(&ocpp201.NotifyCustomerInformationResponse{}, nil)
+
+	s.cp = &ChargePoint{
+		chargingStation:     s.chargingStation,
+		logger:              log.StandardLogger(),
+		scheduler:           scheduler.GetScheduler(),
+		authCache:           auth.NewAuthCache(filepath.Join(dir, "auth.json")),
+		localAuthList:       auth.NewLocalAuthList(filepath.Join(dir, "local-auth-list.json")),
+		transactionQueue:    transactionQueue.NewQueue(filepath.Join(dir, "transaction-queue.json")),
+		customerInformation: customerInformation.NewManager(),
+	}
+
+	s.cp.authCache.SetMaxCachedTags(5)
+	s.cp.authCache.AddTag(authData.NewToken(tagId, authData.TokenTypeISO14443), authData.TokenInfo{Status: authData.StatusAccepted})
+	s.cp.setupCustomerInformation(filepath.Join(dir, "chargepi.log"))
+}
+
+func (s *customerInformationTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *customerInformationTestSuite) getNotifications() []*ocpp201.NotifyCustomerInformationRequest {
+	var requests []*ocpp201.NotifyCustomerInformationRequest
+
+	for _, call := range s.chargingStation.Calls {
+		if request, isRequest := call.Arguments.Get(0).(*ocpp201.NotifyCustomerInformationRequest); isRequest {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+func (s *customerInformationTestSuite) TestOnCustomerInformation() {
+	response, err := s.cp.OnCustomerInformation(&ocpp201.CustomerInformationRequest{RequestId: 1, Report: true})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.CustomerInformationStatusInvalid, response.Status)
+
+	// The customer certificates are not stored
+	response, err = s.cp.OnCustomerInformation(&ocpp201.CustomerInformationRequest{
+		RequestId:           1,
+		Report:              true,
+		CustomerCertificate: &ocpp201.CertificateHashData{},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.CustomerInformationStatusRejected, response.Status)
+
+	response, err = s.cp.OnCustomerInformation(&ocpp201.CustomerInformationRequest{
+		RequestId: 1,
+		Report:    true,
+		IdToken:   &ocpp201.IdToken{IdToken: tagId, Type: ocpp201.IdTokenTypeISO14443},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.CustomerInformationStatusAccepted, response.Status)
+}
+
+func (s *customerInformationTestSuite) TestReportAndClear() {
+	s.cp.sendCustomerInformation(1, tagId, true, true)
+
+	requests := s.getNotifications()
+	s.Require().Len(requests, 1)
+	s.Assert().EqualValues(1, requests[0].RequestId)
+	s.Assert().False(requests[0].ToBeContinued)
+	s.Assert().Equal("Authorization cache: token "+tagId+" of type ISO14443 with status Accepted\n"+customerInformationClear, requests[0].Data)
+
+	_, isFound := s.cp.authCache.FindTag(tagId)
+	s.Assert().False(isFound)
+
+	// Nothing is left after the clear
+	s.cp.sendCustomerInformation(2, tagId, true, false)
+	requests = s.getNotifications()
+	s.Require().Len(requests, 2)
+	s.Assert().Equal(noCustomerInformation, requests[1].Data)
+}
+
+func (s *customerInformationTestSuite) TestSplitCustomerData() {
+	parts := splitCustomerData(strings.Repeat("a", maxCustomerDataLength*2+1))
+	s.Require().Len(parts, 3)
+	s.Assert().Len(parts[0], maxCustomerDataLength)
+	s.Assert().Equal("a", parts[2])
+}
+
+func TestCustomerInformation(t *testing.T) {
+	suite.Run(t, new(customerInformationTestSuite))
+}
@@ -0,0 +1,44 @@
+package v201
+
+import (
+	"errors"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+)
+
+// OnDataTransfer passes the vendor specific message to the handler registered by the module.
+func (cp *ChargePoint) OnDataTransfer(request *ocpp201.DataTransferRequest) (*ocpp201.DataTransferResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	data, err := cp.dataTransfer.Handle(request.VendorId, request.MessageId, request.Data)
+	switch {
+	case errors.Is(err, dataTransfer.ErrUnknownVendorId):
+		return ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusUnknownVendorId, nil), nil
+	case errors.Is(err, dataTransfer.ErrUnknownMessageId):
+		return ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusUnknownMessageId, nil), nil
+	case err != nil:
+		cp.logger.WithError(err).Warnf("Rejected the data transfer of the vendor %s", request.VendorId)
+		return ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusRejected, nil), nil
+	}
+
+	return ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusAccepted, data), nil
+}
+
+// sendDataTransfer sends the vendor specific message to the CSMS for the modules using the registry.
+func (cp *ChargePoint) sendDataTransfer(vendorId, messageId string, data interface{}) (interface{}, error) {
+	response, err := cp.chargingStation.SendRequest(ocpp201.NewDataTransferRequest(vendorId, messageId, data))
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.(*ocpp201.DataTransferResponse).Status {
+	case ocpp201.DataTransferStatusAccepted:
+		return response.(*ocpp201.DataTransferResponse).Data, nil
+	case ocpp201.DataTransferStatusUnknownVendorId:
+		return nil, dataTransfer.ErrUnknownVendorId
+	case ocpp201.DataTransferStatusUnknownMessageId:
+		return nil, dataTransfer.ErrUnknownMessageId
+	default:
+		return nil, dataTransfer.ErrRejected
+	}
+}
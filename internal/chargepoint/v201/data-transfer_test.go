@@ -0,0 +1,80 @@
+package v201
+
+import (
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"testing"
+)
+
+const vendorId = "ChargePi"
+
+type dataTransferTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *dataTransferTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		dataTransfer: dataTransfer.NewRegistry(),
+		logger:       log.StandardLogger(),
+	}
+
+	s.cp.dataTransfer.AddHandler(vendorId, "echo", func(data interface{}) (interface{}, error) {
+		return data, nil
+	})
+	s.cp.dataTransfer.AddHandler(vendorId, "reject", func(data interface{}) (interface{}, error) {
+		return nil, errors.New("invalid data")
+	})
+}
+
+func (s *dataTransferTestSuite) TestOnDataTransfer() {
+	response, err := s.cp.OnDataTransfer(ocpp201.NewDataTransferRequest("Unknown", "echo", nil))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(ocpp201.DataTransferStatusUnknownVendorId, response.Status)
+
+	response, err = s.cp.OnDataTransfer(ocpp201.NewDataTransferRequest(vendorId, "unknown", nil))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(ocpp201.DataTransferStatusUnknownMessageId, response.Status)
+
+	response, err = s.cp.OnDataTransfer(ocpp201.NewDataTransferRequest(vendorId, "echo", "data"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(ocpp201.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues("data", response.Data)
+
+	response, err = s.cp.OnDataTransfer(ocpp201.NewDataTransferRequest(vendorId, "reject", "data"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(ocpp201.DataTransferStatusRejected, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestSendDataTransfer() {
+	chargingStation := new(chargingStationMock)
+	chargingStation.On("SendRequest", mock.MatchedBy(func(request *ocpp201.DataTransferRequest) bool {
+		return request.MessageId == "echo"
+	})).Return(ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusAccepted, "response"), nil)
+	chargingStation.On("SendRequest", mock.MatchedBy(func(request *ocpp201.DataTransferRequest) bool {
+		return request.MessageId == "unknown"
+	})).Return(ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusUnknownMessageId, nil), nil)
+	chargingStation.On("SendRequest", mock.MatchedBy(func(request *ocpp201.DataTransferRequest) bool {
+		return request.MessageId == "reject"
+	})).Return(ocpp201.NewDataTransferResponse(ocpp201.DataTransferStatusRejected, nil), nil)
+	s.cp.chargingStation = chargingStation
+	s.cp.dataTransfer.SetSender(s.cp.sendDataTransfer)
+
+	data, err := s.cp.dataTransfer.Send(vendorId, "echo", "data")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("response", data)
+
+	_, err = s.cp.dataTransfer.Send(vendorId, "unknown", nil)
+	s.Assert().ErrorIs(err, dataTransfer.ErrUnknownMessageId)
+
+	_, err = s.cp.dataTransfer.Send(vendorId, "reject", nil)
+	s.Assert().ErrorIs(err, dataTransfer.ErrRejected)
+}
+
+func TestDataTransfer(t *testing.T) {
+	suite.Run(t, new(dataTransferTestSuite))
+}
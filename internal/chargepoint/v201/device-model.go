@@ -0,0 +1,260 @@
+package v201
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+	"time"
+)
+
+// setupDeviceModel adds the default variables of the hardware and the connectors to the device model. The values
+// of the controllers are taken from the OCPP configuration, which is used by the components shared with OCPP 1.6.
+func (cp *ChargePoint) setupDeviceModel() {
+	cp.deviceModel.SetDefaults(deviceModel.NewDefaultModel(cp.Settings, cp.connectorSettings))
+
+	deviceModel.UpdateFromConfiguration(cp.deviceModel)
+
+	// The cache is configured only with the variables of the AuthCacheCtrlr
+	authCache := ocpp201.Component{Name: deviceModel.AuthCacheCtrlrComponent}
+	for _, variable := range []ocpp201.Variable{{Name: "LifeTime"}, {Name: "Policy"}} {
+		value, err := cp.deviceModel.GetVariable(authCache, variable, ocpp201.AttributeTypeActual)
+		if err == nil {
+			cp.onVariableChanged(authCache, variable, value)
+		}
+	}
+}
+
+// OnGetVariables returns the values of the requested variables.
+func (cp *ChargePoint) OnGetVariables(request *ocpp201.GetVariablesRequest) (*ocpp201.GetVariablesResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	if len(request.GetVariableData) > deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.GetVariablesFeatureName) {
+		return nil, ocpp.NewError(ocppj.OccurrenceConstraintViolation, "too many variables requested", "")
+	}
+
+	var results []ocpp201.GetVariableResult
+	for _, data := range request.GetVariableData {
+		value, err := cp.deviceModel.GetVariable(data.Component, data.Variable, data.AttributeType)
+		results = append(results, ocpp201.GetVariableResult{
+			AttributeStatus: getVariableStatus(err),
+			AttributeType:   data.AttributeType,
+			AttributeValue:  value,
+			Component:       data.Component,
+			Variable:        data.Variable,
+		})
+	}
+
+	return ocpp201.NewGetVariablesResponse(results), nil
+}
+
+// OnSetVariables sets the values of the variables. The variables mapped to the OCPP configuration also update the configuration.
+func (cp *ChargePoint) OnSetVariables(request *ocpp201.SetVariablesRequest) (*ocpp201.SetVariablesResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	if len(request.SetVariableData) > deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.SetVariablesFeatureName) {
+		return nil, ocpp.NewError(ocppj.OccurrenceConstraintViolation, "too many variables requested", "")
+	}
+
+	var (
+		results         []ocpp201.SetVariableResult
+		isConfigChanged = false
+	)
+
+	for _, data := range request.SetVariableData {
+		var (
+			err           error
+			key, isMapped = deviceModel.FindConfigurationKey(data.Component, data.Variable)
+			isActual      = data.AttributeType == "" || data.AttributeType == ocpp201.AttributeTypeActual
+		)
+
+		switch {
+		case isMapped && isActual:
+			err = cp.setConfigurationVariable(data, key)
+			if err == nil {
+				isConfigChanged = true
+				cp.onConfigurationChanged(key, data.AttributeValue)
+			}
+		default:
+			err = cp.deviceModel.SetVariable(data.Component, data.Variable, data.AttributeType, data.AttributeValue)
+			if err == nil && isActual {
+				cp.onVariableChanged(data.Component, data.Variable, data.AttributeValue)
+			}
+		}
+
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Cannot set %s", deviceModel.String(data.Component, data.Variable))
+		}
+
+		results = append(results, ocpp201.SetVariableResult{
+			AttributeType:   data.AttributeType,
+			AttributeStatus: setVariableStatus(err),
+			Component:       data.Component,
+			Variable:        data.Variable,
+		})
+	}
+
+	if isConfigChanged {
+		err := ocppConfigManager.UpdateConfigurationFile()
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Cannot update the configuration file")
+		}
+	}
+
+	return ocpp201.NewSetVariablesResponse(results), nil
+}
+
+// setConfigurationVariable sets the variable mapped to the configuration key. The key is updated first, so the device model
+// never holds a value the configuration rejected. If the device model rejects the value, the key is restored.
+func (cp *ChargePoint) setConfigurationVariable(data ocpp201.SetVariableData, key configuration.Key) error {
+	previousValue, err := ocppConfigManager.GetConfigurationValue(key.String())
+	if err != nil {
+		return err
+	}
+
+	err = ocppConfigManager.UpdateKey(key.String(), data.AttributeValue)
+	if err != nil {
+		return err
+	}
+
+	err = cp.deviceModel.SetVariable(data.Component, data.Variable, data.AttributeType, data.AttributeValue)
+	if err != nil {
+		rollbackErr := ocppConfigManager.UpdateKey(key.String(), previousValue)
+		if rollbackErr != nil {
+			cp.logger.WithError(rollbackErr).Errorf("Cannot restore the configuration key %s", key)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// onConfigurationChanged applies the configuration, which is not read from the configuration every time it is used.
+func (cp *ChargePoint) onConfigurationChanged(key configuration.Key, value string) {
+	switch key {
+	case v16.HeartbeatInterval:
+		if interval, err := strconv.Atoi(value); err == nil && cp.isRegistered() {
+			cp.setHeartbeat(interval)
+		}
+	}
+}
+
+// onVariableChanged applies the variables, which are not mapped to the configuration.
+func (cp *ChargePoint) onVariableChanged(component ocpp201.Component, variable ocpp201.Variable, value string) {
+	if component.Name != deviceModel.AuthCacheCtrlrComponent {
+		return
+	}
+
+	switch variable.Name {
+	case "LifeTime":
+		if lifeTime, err := strconv.Atoi(value); err == nil {
+			cp.authCache.SetLifeTime(time.Duration(lifeTime) * time.Second)
+		}
+	case "Policy":
+		cp.authCache.SetPolicy(auth.CachePolicy(value))
+	}
+}
+
+// OnGetBaseReport accepts the request and sends the report with the NotifyReport requests after the response.
+func (cp *ChargePoint) OnGetBaseReport(request *ocpp201.GetBaseReportRequest) (*ocpp201.GetBaseReportResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	report, err := cp.deviceModel.GetBaseReport(request.ReportBase)
+	switch {
+	case errors.Is(err, deviceModel.ErrReportNotSupported):
+		return ocpp201.NewGetBaseReportResponse(ocpp201.GenericDeviceModelStatusNotSupported), nil
+	case err != nil:
+		return ocpp201.NewGetBaseReportResponse(ocpp201.GenericDeviceModelStatusRejected), nil
+	case len(report) == 0:
+		return ocpp201.NewGetBaseReportResponse(ocpp201.GenericDeviceModelStatusEmptyResultSet), nil
+	}
+
+	return ocpp201.NewGetBaseReportResponse(cp.scheduleReport(request.RequestId, report)), nil
+}
+
+// OnGetReport accepts the request and sends the variables matching the criteria with the NotifyReport requests after the response.
+func (cp *ChargePoint) OnGetReport(request *ocpp201.GetReportRequest) (*ocpp201.GetReportResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	report := cp.deviceModel.GetReport(request.ComponentCriteria, request.ComponentVariable)
+	if len(report) == 0 {
+		return ocpp201.NewGetReportResponse(ocpp201.GenericDeviceModelStatusEmptyResultSet), nil
+	}
+
+	return ocpp201.NewGetReportResponse(cp.scheduleReport(request.RequestId, report)), nil
+}
+
+func (cp *ChargePoint) scheduleReport(requestId int, report []ocpp201.ReportData) ocpp201.GenericDeviceModelStatus {
+	_, err := cp.scheduler.Every(1).Seconds().LimitRunsTo(1).Do(cp.sendReport, requestId, report)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the report")
+		return ocpp201.GenericDeviceModelStatusRejected
+	}
+
+	return ocpp201.GenericDeviceModelStatusAccepted
+}
+
+// sendReport sends the report in pages of ItemsPerMessage variables. The CSMS knows the report is complete when
+// the NotifyReport is not to be continued.
+func (cp *ChargePoint) sendReport(requestId int, report []ocpp201.ReportData) {
+	var (
+		pageSize    = deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.GetReportFeatureName)
+		generatedAt = types.NewDateTime(time.Now())
+	)
+
+	for seqNo := 0; seqNo*pageSize < len(report); seqNo++ {
+		end := (seqNo + 1) * pageSize
+		if end > len(report) {
+			end = len(report)
+		}
+
+		request := ocpp201.NewNotifyReportRequest(requestId, generatedAt, seqNo, report[seqNo*pageSize:end])
+		request.ToBeContinued = end < len(report)
+
+		_, err := cp.chargingStation.SendRequest(request)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Cannot send the report %d", requestId)
+			return
+		}
+	}
+
+	cp.logger.Infof("Sent the report %d with %d variables", requestId, len(report))
+}
+
+func getVariableStatus(err error) ocpp201.GetVariableStatus {
+	switch {
+	case err == nil:
+		return ocpp201.GetVariableStatusAccepted
+	case errors.Is(err, deviceModel.ErrUnknownComponent):
+		return ocpp201.GetVariableStatusUnknownComponent
+	case errors.Is(err, deviceModel.ErrUnknownVariable):
+		return ocpp201.GetVariableStatusUnknownVariable
+	case errors.Is(err, deviceModel.ErrAttributeNotSupported):
+		return ocpp201.GetVariableStatusNotSupportedAttributeType
+	default:
+		return ocpp201.GetVariableStatusRejected
+	}
+}
+
+func setVariableStatus(err error) ocpp201.SetVariableStatus {
+	switch {
+	case err == nil:
+		return ocpp201.SetVariableStatusAccepted
+	case errors.Is(err, deviceModel.ErrUnknownComponent):
+		return ocpp201.SetVariableStatusUnknownComponent
+	case errors.Is(err, deviceModel.ErrUnknownVariable):
+		return ocpp201.SetVariableStatusUnknownVariable
+	case errors.Is(err, deviceModel.ErrAttributeNotSupported):
+		return ocpp201.SetVariableStatusNotSupportedAttributeType
+	default:
+		return ocpp201.SetVariableStatusRejected
+	}
+}
@@ -0,0 +1,206 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"testing"
+	"time"
+)
+
+type deviceModelTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *deviceModelTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		Settings:     &settings.Settings{},
+		transactions: map[string]*transaction{},
+		logger:       log.StandardLogger(),
+		scheduler:    scheduler.GetScheduler(),
+		deviceModel:  deviceModel.NewStore(""),
+		authCache:    auth.NewAuthCache(""),
+	}
+	s.cp.setupDeviceModel()
+}
+
+func (s *deviceModelTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *deviceModelTestSuite) TestGetSetVariables() {
+	var (
+		ocppComm  = ocpp201.Component{Name: deviceModel.OCPPCommCtrlrComponent}
+		heartbeat = ocpp201.Variable{Name: "HeartbeatInterval"}
+		station   = ocpp201.Component{Name: deviceModel.ChargingStationComponent}
+	)
+
+	setResponse, err := s.cp.OnSetVariables(&ocpp201.SetVariablesRequest{
+		SetVariableData: []ocpp201.SetVariableData{
+			{AttributeValue: "120", Component: ocppComm, Variable: heartbeat},
+			{AttributeValue: "false", Component: station, Variable: ocpp201.Variable{Name: "Available"}},
+			{AttributeValue: "1", Component: ocpp201.Component{Name: "Unknown"}, Variable: heartbeat},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(setResponse.SetVariableResult, 3)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusAccepted, setResponse.SetVariableResult[0].AttributeStatus)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusRejected, setResponse.SetVariableResult[1].AttributeStatus)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusUnknownComponent, setResponse.SetVariableResult[2].AttributeStatus)
+
+	// The variable mapped to the configuration also updates the configuration
+	value, err := ocppManager.GetConfigurationValue("HeartbeatInterval")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("120", value)
+
+	getResponse, err := s.cp.OnGetVariables(&ocpp201.GetVariablesRequest{
+		GetVariableData: []ocpp201.GetVariableData{
+			{Component: ocppComm, Variable: heartbeat},
+			{Component: ocppComm, Variable: ocpp201.Variable{Name: "Unknown"}},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(getResponse.GetVariableResult, 2)
+	s.Assert().EqualValues(ocpp201.GetVariableStatusAccepted, getResponse.GetVariableResult[0].AttributeStatus)
+	s.Assert().EqualValues("120", getResponse.GetVariableResult[0].AttributeValue)
+	s.Assert().EqualValues(ocpp201.GetVariableStatusUnknownVariable, getResponse.GetVariableResult[1].AttributeStatus)
+
+	// Too many variables in a single request
+	tooMany := make([]ocpp201.GetVariableData, 21)
+	_, err = s.cp.OnGetVariables(&ocpp201.GetVariablesRequest{GetVariableData: tooMany})
+	var ocppErr *ocpp.Error
+	s.Require().ErrorAs(err, &ocppErr)
+	s.Assert().EqualValues(ocppj.OccurrenceConstraintViolation, ocppErr.Code)
+
+	_ = ocppManager.UpdateKey("HeartbeatInterval", "60")
+}
+
+func (s *deviceModelTestSuite) TestSetVariablesConfigurationRejected() {
+	var (
+		ocppComm       = ocpp201.Component{Name: deviceModel.OCPPCommCtrlrComponent}
+		heartbeat      = ocpp201.Variable{Name: "HeartbeatInterval"}
+		readOnlyConfig = ocppConfig
+		getHeartbeat   = func() string {
+			value, err := s.cp.deviceModel.GetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual)
+			s.Require().NoError(err)
+			return value
+		}
+	)
+
+	// The device model rejects the value, so the configuration key is restored
+	response, err := s.cp.OnSetVariables(&ocpp201.SetVariablesRequest{
+		SetVariableData: []ocpp201.SetVariableData{{AttributeValue: "often", Component: ocppComm, Variable: heartbeat}},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusRejected, response.SetVariableResult[0].AttributeStatus)
+
+	value, err := ocppManager.GetConfigurationValue("HeartbeatInterval")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("60", value)
+	s.Assert().EqualValues("60", getHeartbeat())
+
+	// The configuration rejects the value, so the device model is not changed
+	readOnlyConfig.Keys = make([]core.ConfigurationKey, len(ocppConfig.Keys))
+	copy(readOnlyConfig.Keys, ocppConfig.Keys)
+	for i := range readOnlyConfig.Keys {
+		if readOnlyConfig.Keys[i].Key == "HeartbeatInterval" {
+			readOnlyConfig.Keys[i].Readonly = true
+		}
+	}
+
+	s.Require().NoError(ocppManager.GetManager().SetConfiguration(readOnlyConfig))
+	defer func() {
+		_ = ocppManager.GetManager().SetConfiguration(ocppConfig)
+	}()
+
+	response, err = s.cp.OnSetVariables(&ocpp201.SetVariablesRequest{
+		SetVariableData: []ocpp201.SetVariableData{{AttributeValue: "120", Component: ocppComm, Variable: heartbeat}},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusRejected, response.SetVariableResult[0].AttributeStatus)
+	s.Assert().EqualValues("60", getHeartbeat())
+}
+
+func (s *deviceModelTestSuite) TestAuthCacheCtrlr() {
+	var (
+		authCache = ocpp201.Component{Name: deviceModel.AuthCacheCtrlrComponent}
+		tag1      = authData.NewToken("tag1", authData.TokenTypeISO14443)
+		tag2      = authData.NewToken("tag2", authData.TokenTypeISO14443)
+		accepted  = authData.TokenInfo{Status: authData.StatusAccepted}
+	)
+
+	response, err := s.cp.OnSetVariables(&ocpp201.SetVariablesRequest{
+		SetVariableData: []ocpp201.SetVariableData{
+			{AttributeValue: "FIFO", Component: authCache, Variable: ocpp201.Variable{Name: "Policy"}},
+			{AttributeValue: "MRU", Component: authCache, Variable: ocpp201.Variable{Name: "Policy"}},
+			{AttributeValue: "3600", Component: authCache, Variable: ocpp201.Variable{Name: "LifeTime"}},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(response.SetVariableResult, 3)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusAccepted, response.SetVariableResult[0].AttributeStatus)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusRejected, response.SetVariableResult[1].AttributeStatus)
+	s.Assert().EqualValues(ocpp201.SetVariableStatusAccepted, response.SetVariableResult[2].AttributeStatus)
+
+	// The policy is applied to the full cache
+	s.cp.authCache.SetMaxCachedTags(1)
+	s.cp.authCache.AddTag(tag1, accepted)
+	s.cp.authCache.AddTag(tag2, accepted)
+	s.Assert().False(s.cp.authCache.IsTagAuthorized(tag1))
+	s.Assert().True(s.cp.authCache.IsTagAuthorized(tag2))
+}
+
+func (s *deviceModelTestSuite) TestGetReport() {
+	chargingStation := new(chargingStationMock)
+	chargingStation.On("SendRequest", mock.AnythingOfType("*ocpp201.NotifyReportRequest")).Return(&ocpp201.NotifyReportResponse{}, nil)
+	s.cp.chargingStation = chargingStation
+
+	response, err := s.cp.OnGetBaseReport(&ocpp201.GetBaseReportRequest{RequestId: 1, ReportBase: ocpp201.ReportBaseSummaryInventory})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GenericDeviceModelStatusNotSupported, response.Status)
+
+	reportResponse, err := s.cp.OnGetReport(&ocpp201.GetReportRequest{
+		RequestId:         2,
+		ComponentVariable: []ocpp201.ComponentVariable{{Component: ocpp201.Component{Name: "Unknown"}}},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GenericDeviceModelStatusEmptyResultSet, reportResponse.Status)
+
+	response, err = s.cp.OnGetBaseReport(&ocpp201.GetBaseReportRequest{RequestId: 3, ReportBase: ocpp201.ReportBaseFullInventory})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GenericDeviceModelStatusAccepted, response.Status)
+
+	// The report is sent after the response in pages
+	s.cp.scheduler.StartAsync()
+	time.Sleep(time.Millisecond * 1500)
+
+	report, _ := s.cp.deviceModel.GetBaseReport(ocpp201.ReportBaseFullInventory)
+	pages := (len(report) + 19) / 20
+	chargingStation.AssertNumberOfCalls(s.T(), "SendRequest", pages)
+
+	for i, call := range chargingStation.Calls {
+		request := call.Arguments.Get(0).(*ocpp201.NotifyReportRequest)
+		s.Assert().EqualValues(3, request.RequestId)
+		s.Assert().EqualValues(i, request.SequenceNo)
+		s.Assert().EqualValues(i < pages-1, request.ToBeContinued)
+	}
+}
+
+func TestDeviceModel(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(deviceModelTestSuite))
+}
@@ -0,0 +1,163 @@
+package v201
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	displayMessages "github.com/xBlaz3kx/ChargePi-go/internal/components/display-messages"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"time"
+)
+
+// displayMessageInterval is the number of seconds a message is displayed before the next message in the cycle.
+const displayMessageInterval = 10
+
+// OnSetDisplayMessage stores the message. The InFront and AlwaysFront messages are displayed right away, while the
+// NormalCycle messages are displayed in turns.
+func (cp *ChargePoint) OnSetDisplayMessage(request *ocpp201.SetDisplayMessageRequest) (*ocpp201.SetDisplayMessageResponse, error) {
+	var (
+		message = request.Message
+		logInfo = cp.logger.WithField("messageId", message.Id)
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	switch {
+	case !cp.isDisplayAvailable():
+		return ocpp201.NewSetDisplayMessageResponse(ocpp201.DisplayMessageStatusRejected), nil
+	case message.Message.Format != ocpp201.MessageFormatASCII && message.Message.Format != ocpp201.MessageFormatUTF8:
+		return ocpp201.NewSetDisplayMessageResponse(ocpp201.DisplayMessageStatusNotSupportedMessageFormat), nil
+	case message.TransactionId != "" && !cp.isTransactionOngoing(message.TransactionId):
+		return ocpp201.NewSetDisplayMessageResponse(ocpp201.DisplayMessageStatusUnknownTransaction), nil
+	}
+
+	err := cp.displayMessages.SetMessage(message)
+	if err != nil {
+		logInfo.WithError(err).Warn("Cannot set the display message")
+		return ocpp201.NewSetDisplayMessageResponse(ocpp201.DisplayMessageStatusRejected), nil
+	}
+
+	// The message in front of the others is displayed right away
+	if active := cp.displayMessages.GetActiveMessages(cp.getMessageState(), time.Now()); len(active) > 0 &&
+		active[0].Id == message.Id && message.Priority != ocpp201.MessagePriorityNormalCycle {
+		go cp.displayMessage(message)
+	}
+
+	return ocpp201.NewSetDisplayMessageResponse(ocpp201.DisplayMessageStatusAccepted), nil
+}
+
+// OnGetDisplayMessages accepts the request and sends the messages matching the criteria with a NotifyDisplayMessages request after the response.
+func (cp *ChargePoint) OnGetDisplayMessages(request *ocpp201.GetDisplayMessagesRequest) (*ocpp201.GetDisplayMessagesResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	messages := cp.displayMessages.GetMessages(request.Id, request.Priority, request.State)
+	if len(messages) == 0 {
+		return ocpp201.NewGetDisplayMessagesResponse(ocpp201.GetDisplayMessagesStatusUnknown), nil
+	}
+
+	_, err := cp.scheduler.Every(1).Seconds().LimitRunsTo(1).Do(cp.sendDisplayMessages, request.RequestId, messages)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule sending the display messages")
+		return ocpp201.NewGetDisplayMessagesResponse(ocpp201.GetDisplayMessagesStatusUnknown), nil
+	}
+
+	return ocpp201.NewGetDisplayMessagesResponse(ocpp201.GetDisplayMessagesStatusAccepted), nil
+}
+
+// OnClearDisplayMessage removes the message.
+func (cp *ChargePoint) OnClearDisplayMessage(request *ocpp201.ClearDisplayMessageRequest) (*ocpp201.ClearDisplayMessageResponse, error) {
+	cp.logger.WithField("messageId", request.Id).Infof("Received request %s", request.GetFeatureName())
+
+	err := cp.displayMessages.RemoveMessage(request.Id)
+	if errors.Is(err, displayMessages.ErrMessageNotFound) {
+		return ocpp201.NewClearDisplayMessageResponse(ocpp201.ClearMessageStatusUnknown), nil
+	}
+
+	return ocpp201.NewClearDisplayMessageResponse(ocpp201.ClearMessageStatusAccepted), nil
+}
+
+func (cp *ChargePoint) sendDisplayMessages(requestId int, messages []ocpp201.MessageInfo) {
+	_, err := cp.chargingStation.SendRequest(ocpp201.NewNotifyDisplayMessagesRequest(requestId, messages))
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot send the display messages")
+	}
+}
+
+// scheduleDisplayMessages displays the messages set by the CSMS in turns.
+func (cp *ChargePoint) scheduleDisplayMessages() {
+	_, err := cp.scheduler.Every(displayMessageInterval).Seconds().Tag("displayMessages").Do(cp.displayNextMessage)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the display messages")
+	}
+}
+
+// displayNextMessage displays the next message in the cycle of the messages valid in the current state.
+func (cp *ChargePoint) displayNextMessage() {
+	messages := cp.displayMessages.GetActiveMessages(cp.getMessageState(), time.Now())
+	if len(messages) == 0 {
+		return
+	}
+
+	cp.messageIndex = (cp.messageIndex + 1) % len(messages)
+	cp.displayMessage(messages[cp.messageIndex])
+}
+
+func (cp *ChargePoint) displayMessage(message ocpp201.MessageInfo) {
+	cp.sendToLCD(display.SplitLines(message.Message.Content)...)
+}
+
+// hasAlwaysFrontMessage checks if the CSMS set a message, which is displayed instead of any other message.
+func (cp *ChargePoint) hasAlwaysFrontMessage() bool {
+	if util.IsNilInterfaceOrPointer(cp.displayMessages) {
+		return false
+	}
+
+	messages := cp.displayMessages.GetActiveMessages(cp.getMessageState(), time.Now())
+	return len(messages) > 0 && messages[0].Priority == ocpp201.MessagePriorityAlwaysFront
+}
+
+// removeTransactionMessages removes the messages tied to the transaction after the transaction ends.
+func (cp *ChargePoint) removeTransactionMessages(transactionId string) {
+	if util.IsNilInterfaceOrPointer(cp.displayMessages) {
+		return
+	}
+
+	cp.displayMessages.RemoveTransactionMessages(transactionId)
+}
+
+// getMessageState returns the state of the charging station, in which the messages are displayed.
+func (cp *ChargePoint) getMessageState() ocpp201.MessageState {
+	if cp.availability != core.AvailabilityTypeOperative {
+		return ocpp201.MessageStateUnavailable
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.connectorManager) {
+		for _, c := range cp.connectorManager.GetConnectors() {
+			if status, _ := c.GetStatus(); status == core.ChargePointStatusFaulted {
+				return ocpp201.MessageStateFaulted
+			}
+		}
+	}
+
+	cp.transactionsMu.Lock()
+	defer cp.transactionsMu.Unlock()
+
+	if len(cp.transactions) > 0 {
+		return ocpp201.MessageStateCharging
+	}
+
+	return ocpp201.MessageStateIdle
+}
+
+func (cp *ChargePoint) isDisplayAvailable() bool {
+	return !util.IsNilInterfaceOrPointer(cp.LCD) && !util.IsNilInterfaceOrPointer(cp.Settings) &&
+		cp.Settings.ChargePoint.Hardware.Lcd.IsEnabled
+}
+
+func (cp *ChargePoint) isTransactionOngoing(transactionId string) bool {
+	cp.transactionsMu.Lock()
+	defer cp.transactionsMu.Unlock()
+
+	_, isFound := cp.transactions[transactionId]
+	return isFound
+}
@@ -0,0 +1,139 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	displayMessages "github.com/xBlaz3kx/ChargePi-go/internal/components/display-messages"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+	"time"
+)
+
+type displayMessagesTestSuite struct {
+	suite.Suite
+	cp         *ChargePoint
+	lcdChannel chan display.LCDMessage
+}
+
+func (s *displayMessagesTestSuite) SetupTest() {
+	var (
+		lcd           = new(test.DisplayMock)
+		chargingPoint = &settings.Settings{}
+	)
+
+	s.lcdChannel = make(chan display.LCDMessage, 5)
+	lcd.On("GetLcdChannel").Return(s.lcdChannel)
+	chargingPoint.ChargePoint.Hardware.Lcd.IsEnabled = true
+
+	s.cp = &ChargePoint{
+		Settings:        chargingPoint,
+		LCD:             lcd,
+		availability:    core.AvailabilityTypeOperative,
+		transactions:    map[string]*transaction{},
+		displayMessages: displayMessages.NewManager(),
+		logger:          log.StandardLogger(),
+		scheduler:       scheduler.GetScheduler(),
+	}
+}
+
+func (s *displayMessagesTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *displayMessagesTestSuite) TestSetDisplayMessage() {
+	message := ocpp201.MessageInfo{
+		Id:       1,
+		Priority: ocpp201.MessagePriorityInFront,
+		Message:  ocpp201.MessageContent{Format: ocpp201.MessageFormatUTF8, Content: "Site closes at 22:00"},
+	}
+
+	response, err := s.cp.OnSetDisplayMessage(&ocpp201.SetDisplayMessageRequest{Message: message})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.DisplayMessageStatusAccepted, response.Status)
+
+	// The InFront message is displayed right away
+	select {
+	case lcdMessage := <-s.lcdChannel:
+		s.Assert().EqualValues([]string{"Site closes at", "22:00"}, lcdMessage.Messages)
+	case <-time.After(time.Second):
+		s.Fail("the message was not displayed")
+	}
+
+	// The unsupported format
+	message.Message.Format = ocpp201.MessageFormatHTML
+	response, err = s.cp.OnSetDisplayMessage(&ocpp201.SetDisplayMessageRequest{Message: message})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.DisplayMessageStatusNotSupportedMessageFormat, response.Status)
+
+	// The message of an unknown transaction
+	message.Message.Format = ocpp201.MessageFormatASCII
+	message.TransactionId = "unknown"
+	response, err = s.cp.OnSetDisplayMessage(&ocpp201.SetDisplayMessageRequest{Message: message})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.DisplayMessageStatusUnknownTransaction, response.Status)
+
+	// The message is rejected without a display
+	s.cp.Settings.ChargePoint.Hardware.Lcd.IsEnabled = false
+	message.TransactionId = ""
+	response, err = s.cp.OnSetDisplayMessage(&ocpp201.SetDisplayMessageRequest{Message: message})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.DisplayMessageStatusRejected, response.Status)
+}
+
+func (s *displayMessagesTestSuite) TestGetClearDisplayMessages() {
+	chargingStation := new(chargingStationMock)
+	chargingStation.On("SendRequest", mock.AnythingOfType("*ocpp201.NotifyDisplayMessagesRequest")).Return(&ocpp201.NotifyDisplayMessagesResponse{}, nil)
+	s.cp.chargingStation = chargingStation
+
+	s.cp.transactions["transaction1"] = &transaction{chargingState: ocpp201.ChargingStateCharging}
+
+	for _, message := range []ocpp201.MessageInfo{
+		{Id: 1, Priority: ocpp201.MessagePriorityNormalCycle, State: ocpp201.MessageStateIdle},
+		{Id: 2, Priority: ocpp201.MessagePriorityNormalCycle, TransactionId: "transaction1"},
+	} {
+		message.Message = ocpp201.MessageContent{Format: ocpp201.MessageFormatASCII, Content: "Message"}
+		response, err := s.cp.OnSetDisplayMessage(&ocpp201.SetDisplayMessageRequest{Message: message})
+		s.Require().NoError(err)
+		s.Require().EqualValues(ocpp201.DisplayMessageStatusAccepted, response.Status)
+	}
+
+	response, err := s.cp.OnGetDisplayMessages(&ocpp201.GetDisplayMessagesRequest{RequestId: 1, State: ocpp201.MessageStateCharging})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GetDisplayMessagesStatusUnknown, response.Status)
+
+	response, err = s.cp.OnGetDisplayMessages(&ocpp201.GetDisplayMessagesRequest{RequestId: 2, State: ocpp201.MessageStateIdle})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GetDisplayMessagesStatusAccepted, response.Status)
+
+	// The messages are sent after the response
+	s.cp.scheduler.StartAsync()
+	time.Sleep(time.Millisecond * 1500)
+
+	chargingStation.AssertNumberOfCalls(s.T(), "SendRequest", 1)
+	request := chargingStation.Calls[0].Arguments.Get(0).(*ocpp201.NotifyDisplayMessagesRequest)
+	s.Assert().EqualValues(2, request.RequestId)
+	s.Require().Len(request.MessageInfo, 1)
+	s.Assert().EqualValues(1, request.MessageInfo[0].Id)
+
+	clearResponse, err := s.cp.OnClearDisplayMessage(&ocpp201.ClearDisplayMessageRequest{Id: 1})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.ClearMessageStatusAccepted, clearResponse.Status)
+
+	clearResponse, err = s.cp.OnClearDisplayMessage(&ocpp201.ClearDisplayMessageRequest{Id: 1})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.ClearMessageStatusUnknown, clearResponse.Status)
+
+	// The message of the transaction is removed after the transaction ends
+	s.cp.removeTransactionMessages("transaction1")
+	s.Assert().Empty(s.cp.displayMessages.GetMessages(nil, "", ""))
+}
+
+func TestDisplayMessages(t *testing.T) {
+	suite.Run(t, new(displayMessagesTestSuite))
+}
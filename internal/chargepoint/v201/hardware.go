@@ -0,0 +1,41 @@
+package v201
+
+import (
+	"context"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	chargePointHardware "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/hardware"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+)
+
+func (cp *ChargePoint) sendToLCD(messages ...string) {
+	chargePointHardware.SendToLCD(cp.logger, cp.LCD, cp.Settings.ChargePoint.Hardware.Lcd, messages...)
+}
+
+// displayConnectionStatus displays if the charge point is connected to the central system.
+func (cp *ChargePoint) displayConnectionStatus(isOnline bool) {
+	if util.IsNilInterfaceOrPointer(cp.Settings) {
+		return
+	}
+
+	chargePointHardware.DisplayConnectionStatus(cp.logger, cp.LCD, cp.Settings.ChargePoint.Hardware.Lcd, isOnline)
+}
+
+func (cp *ChargePoint) displayLEDStatus(connectorIndex int, status core.ChargePointStatus) {
+	chargePointHardware.DisplayLEDStatus(cp.logger, cp.Indicator, cp.Settings.ChargePoint.Hardware.LedIndicator, connectorIndex, status)
+}
+
+// indicateCard Blinks the LED to indicate that the card was read.
+func (cp *ChargePoint) indicateCard(index int, color uint32) {
+	chargePointHardware.IndicateCard(cp.logger, cp.Indicator, cp.Settings.ChargePoint.Hardware.LedIndicator, index, color)
+}
+
+// ListenForTag Listen for an RFID/NFC tag on a separate thread. If a tag is detected, call the HandleChargingRequest.
+// Blink the LED if indication is enabled.
+func (cp *ChargePoint) ListenForTag(ctx context.Context, tagChannel <-chan string) {
+	chargePointHardware.ListenForTag(ctx, cp.logger, tagChannel, func(tagId string) {
+		go cp.indicateCard(len(cp.connectorManager.GetConnectors()), indicator.White)
+		go cp.sendToLCD("Read tag:", tagId)
+		_, _ = cp.HandleChargingRequest(tagId)
+	})
+}
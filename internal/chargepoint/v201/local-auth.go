@@ -0,0 +1,85 @@
+package v201
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+)
+
+// OnClearCache removes all tokens from the cache. The request is rejected if the cache is disabled.
+func (cp *ChargePoint) OnClearCache(request *ocpp201.ClearCacheRequest) (*ocpp201.ClearCacheResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	authCacheEnabled, err := ocppConfigManager.GetConfigurationValue(v16.AuthorizationCacheEnabled.String())
+	if err != nil || authCacheEnabled != "true" {
+		return ocpp201.NewClearCacheResponse(ocpp201.ClearCacheStatusRejected), nil
+	}
+
+	cp.authCache.RemoveCachedTags()
+	return ocpp201.NewClearCacheResponse(ocpp201.ClearCacheStatusAccepted), nil
+}
+
+// OnGetLocalListVersion returns the version of the local authorization list. The version is 0 if the list is disabled.
+func (cp *ChargePoint) OnGetLocalListVersion(request *ocpp201.GetLocalListVersionRequest) (*ocpp201.GetLocalListVersionResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	localListEnabled, err := ocppConfigManager.GetConfigurationValue(v16.LocalAuthListEnabled.String())
+	if err != nil || localListEnabled != "true" {
+		return ocpp201.NewGetLocalListVersionResponse(0), nil
+	}
+
+	return ocpp201.NewGetLocalListVersionResponse(cp.localAuthList.GetVersion()), nil
+}
+
+// OnSendLocalList replaces or updates the local authorization list. The update fails if the list is disabled or
+// if the update exceeds the ItemsPerMessage of the LocalAuthListCtrlr.
+func (cp *ChargePoint) OnSendLocalList(request *ocpp201.SendLocalListRequest) (*ocpp201.SendLocalListResponse, error) {
+	var (
+		logInfo = cp.logger.WithFields(log.Fields{
+			"version":    request.VersionNumber,
+			"updateType": request.UpdateType,
+		})
+		localListEnabled, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListEnabled.String())
+		maxLength, lengthErr      = ocppConfigManager.GetConfigurationValue(v16.SendLocalListMaxLength.String())
+	)
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if confErr != nil || localListEnabled != "true" {
+		return ocpp201.NewSendLocalListResponse(ocpp201.SendLocalListStatusFailed), nil
+	}
+
+	if lengthErr == nil {
+		maxListLength, convErr := strconv.Atoi(maxLength)
+		if convErr == nil && len(request.LocalAuthorizationList) > maxListLength {
+			logInfo.Warn("Local list update exceeds the maximum length")
+			return ocpp201.NewSendLocalListResponse(ocpp201.SendLocalListStatusFailed), nil
+		}
+	}
+
+	updateType, authorizationData := authData.FromIdTokenAuthorizationData(request.UpdateType, request.LocalAuthorizationList)
+	err := cp.localAuthList.UpdateList(request.VersionNumber, updateType, authorizationData)
+	switch err {
+	case nil:
+		return ocpp201.NewSendLocalListResponse(ocpp201.SendLocalListStatusAccepted), nil
+	case auth.ErrVersionMismatch:
+		return ocpp201.NewSendLocalListResponse(ocpp201.SendLocalListStatusVersionMismatch), nil
+	default:
+		logInfo.WithError(err).Warn("Cannot update the local authorization list")
+		return ocpp201.NewSendLocalListResponse(ocpp201.SendLocalListStatusFailed), nil
+	}
+}
+
+func (cp *ChargePoint) setMaxLocalListTags() {
+	var (
+		maxTagsString, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListMaxLength.String())
+		maxTags, convErr       = strconv.Atoi(maxTagsString)
+	)
+
+	if confErr == nil && convErr == nil {
+		cp.localAuthList.SetMaxTags(maxTags)
+	}
+}
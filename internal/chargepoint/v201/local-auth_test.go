@@ -0,0 +1,115 @@
+package v201
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"path/filepath"
+	"testing"
+)
+
+type localAuthTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *localAuthTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		logger:        log.StandardLogger(),
+		scheduler:     scheduler.GetScheduler(),
+		authCache:     auth.NewAuthCache(filepath.Join(s.T().TempDir(), "auth.json")),
+		localAuthList: auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json")),
+	}
+}
+
+func (s *localAuthTestSuite) TearDownTest() {
+	_ = ocppManager.UpdateKey(v16.LocalAuthListEnabled.String(), "true")
+	_ = ocppManager.UpdateKey(v16.AuthorizationCacheEnabled.String(), "false")
+}
+
+func (s *localAuthTestSuite) TestSendLocalList() {
+	var (
+		accepted = &ocpp201.IdTokenInfo{Status: ocpp201.AuthorizationStatusAccepted}
+		keyCode  = ocpp201.NewIdToken("1234", ocpp201.IdTokenTypeKeyCode)
+	)
+
+	response, err := s.cp.OnSendLocalList(&ocpp201.SendLocalListRequest{
+		VersionNumber: 1,
+		UpdateType:    ocpp201.UpdateTypeFull,
+		LocalAuthorizationList: []ocpp201.AuthorizationData{
+			{IdToken: ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443), IdTokenInfo: accepted},
+			{IdToken: keyCode, IdTokenInfo: accepted},
+		},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SendLocalListStatusAccepted, response.Status)
+
+	// The token types are kept in the list
+	s.Assert().True(s.cp.localAuthList.IsTagAuthorized(authData.FromIdToken(keyCode)))
+	s.Assert().False(s.cp.localAuthList.IsTagAuthorized(authData.NewToken("1234", authData.TokenTypeISO14443)))
+
+	versionResponse, err := s.cp.OnGetLocalListVersion(&ocpp201.GetLocalListVersionRequest{})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(1, versionResponse.VersionNumber)
+
+	// The differential update must have a higher version
+	response, err = s.cp.OnSendLocalList(&ocpp201.SendLocalListRequest{
+		VersionNumber:          1,
+		UpdateType:             ocpp201.UpdateTypeDifferential,
+		LocalAuthorizationList: []ocpp201.AuthorizationData{{IdToken: keyCode}},
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SendLocalListStatusVersionMismatch, response.Status)
+
+	// The update exceeds SendLocalListMaxLength
+	response, err = s.cp.OnSendLocalList(&ocpp201.SendLocalListRequest{
+		VersionNumber:          2,
+		UpdateType:             ocpp201.UpdateTypeDifferential,
+		LocalAuthorizationList: make([]ocpp201.AuthorizationData, 3),
+	})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SendLocalListStatusFailed, response.Status)
+
+	// The list is disabled
+	s.Require().NoError(ocppManager.UpdateKey(v16.LocalAuthListEnabled.String(), "false"))
+
+	response, err = s.cp.OnSendLocalList(&ocpp201.SendLocalListRequest{VersionNumber: 2, UpdateType: ocpp201.UpdateTypeFull})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.SendLocalListStatusFailed, response.Status)
+
+	versionResponse, err = s.cp.OnGetLocalListVersion(&ocpp201.GetLocalListVersionRequest{})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(0, versionResponse.VersionNumber)
+}
+
+func (s *localAuthTestSuite) TestClearCache() {
+	token := authData.NewToken(tagId, authData.TokenTypeISO14443)
+	s.cp.authCache.SetMaxCachedTags(5)
+	s.cp.authCache.AddTag(token, authData.TokenInfo{Status: authData.StatusAccepted})
+
+	// The cache is disabled
+	response, err := s.cp.OnClearCache(&ocpp201.ClearCacheRequest{})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.ClearCacheStatusRejected, response.Status)
+	s.Assert().True(s.cp.authCache.IsTagAuthorized(token))
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.AuthorizationCacheEnabled.String(), "true"))
+
+	response, err = s.cp.OnClearCache(&ocpp201.ClearCacheRequest{})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.ClearCacheStatusAccepted, response.Status)
+	s.Assert().False(s.cp.authCache.IsTagAuthorized(token))
+}
+
+func TestLocalAuth(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(localAuthTestSuite))
+}
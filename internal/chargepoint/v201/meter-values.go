@@ -0,0 +1,90 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendMeterValues sends the meter values to the CSMS. The meter values sampled during a transaction are sent with the TransactionEvent.
+func (cp *ChargePoint) sendMeterValues(meterValues models.MeterValueNotification) {
+	c := cp.connectorManager.FindConnector(meterValues.EvseId, meterValues.ConnectorId)
+	if !util.IsNilInterfaceOrPointer(c) && c.GetSession().IsActive {
+		cp.sendTransactionMeterValues(c, meterValues.MeterValues)
+		return
+	}
+
+	values := toMeterValues(meterValues.MeterValues)
+	if len(values) == 0 {
+		return
+	}
+
+	err := cp.chargingStation.SendRequestAsync(
+		ocpp201.NewMeterValuesRequest(meterValues.EvseId, values),
+		func(response ocpp.Response, protoError error) {},
+	)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot send meter values")
+	}
+}
+
+// toMeterValues converts the meter values of the connector to the OCPP 2.0.1 meter values, which have numeric values.
+// The samples with invalid values and the meter values without samples are skipped.
+func toMeterValues(meterValues []types.MeterValue) []ocpp201.MeterValue {
+	var converted []ocpp201.MeterValue
+
+	for _, meterValue := range meterValues {
+		timestamp := types.NewDateTime(time.Now())
+		if meterValue.Timestamp != nil {
+			timestamp = meterValue.Timestamp
+		}
+
+		value := ocpp201.MeterValue{Timestamp: *timestamp}
+		for _, sample := range meterValue.SampledValue {
+			sampleValue, err := strconv.ParseFloat(strings.TrimSpace(sample.Value), 64)
+			if err != nil {
+				continue
+			}
+
+			sampledValue := ocpp201.SampledValue{
+				Value:     sampleValue,
+				Context:   ocpp201.ReadingContext(sample.Context),
+				Measurand: ocpp201.Measurand(sample.Measurand),
+				Phase:     ocpp201.Phase(sample.Phase),
+				Location:  ocpp201.Location(sample.Location),
+			}
+
+			if sample.Unit != "" {
+				sampledValue.UnitOfMeasure = &ocpp201.UnitOfMeasure{Unit: string(sample.Unit)}
+			}
+
+			value.SampledValue = append(value.SampledValue, sampledValue)
+		}
+
+		if len(value.SampledValue) > 0 {
+			converted = append(converted, value)
+		}
+	}
+
+	return converted
+}
+
+// energyMeterValue creates a meter value of the energy register (in Wh), which is reported at the start and the end of the transaction.
+func energyMeterValue(energy int, context ocpp201.ReadingContext) ocpp201.MeterValue {
+	return ocpp201.MeterValue{
+		Timestamp: *types.NewDateTime(time.Now()),
+		SampledValue: []ocpp201.SampledValue{
+			{
+				Value:         float64(energy),
+				Context:       context,
+				Measurand:     ocpp201.MeasurandEnergyActiveImportRegister,
+				UnitOfMeasure: &ocpp201.UnitOfMeasure{Unit: "Wh"},
+			},
+		},
+	}
+}
@@ -0,0 +1,387 @@
+package v201
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/monitoring"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"strconv"
+	"time"
+)
+
+const (
+	// monitoringInterval is the number of seconds between the samples of the monitored variables.
+	monitoringInterval = 5
+	// maxQueuedEvents limits the events kept while the charging station is offline. The oldest events are dropped.
+	maxQueuedEvents = 100
+	// Severities of the hard-wired notifications
+	connectorProblemSeverity = 1
+	connectionLostSeverity   = 2
+	// Preconfigured upper threshold of the power meter temperature in °C
+	defaultTemperatureThreshold = 80
+)
+
+var monitoringCtrlr = ocpp201.Component{Name: deviceModel.MonitoringCtrlrComponent}
+
+// setupMonitoring adds the preconfigured monitors of the connectors and activates the monitoring base from the device model.
+func (cp *ChargePoint) setupMonitoring() {
+	var monitors []monitoring.Monitor
+
+	for _, c := range cp.connectorSettings {
+		if c == nil || !c.PowerMeter.Enabled {
+			continue
+		}
+
+		monitors = append(monitors, monitoring.Monitor{
+			Component: connectorComponent(c.EvseId, c.ConnectorId),
+			Variable:  ocpp201.Variable{Name: "Temperature"},
+			Type:      ocpp201.MonitorTypeUpperThreshold,
+			Value:     defaultTemperatureThreshold,
+			Severity:  connectorProblemSeverity,
+		})
+	}
+
+	cp.monitoring.SetDefaults(monitors)
+
+	base, err := cp.deviceModel.GetVariable(monitoringCtrlr, ocpp201.Variable{Name: "ActiveMonitoringBase"}, ocpp201.AttributeTypeActual)
+	if err == nil {
+		cp.monitoring.SetMonitoringBase(ocpp201.MonitoringBase(base))
+	}
+}
+
+// OnSetVariableMonitoring adds the monitors of the variables, which support monitoring. The thresholds can only be set
+// for the numeric variables.
+func (cp *ChargePoint) OnSetVariableMonitoring(request *ocpp201.SetVariableMonitoringRequest) (*ocpp201.SetVariableMonitoringResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	if len(request.SetMonitoringData) > deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.SetVariableMonitoringFeatureName) {
+		return nil, ocpp.NewError(ocppj.OccurrenceConstraintViolation, "too many monitors requested", "")
+	}
+
+	var results []ocpp201.SetMonitoringResult
+	for _, data := range request.SetMonitoringData {
+		id, status := cp.setMonitor(data)
+		results = append(results, ocpp201.SetMonitoringResult{
+			Id:        id,
+			Status:    status,
+			Type:      data.Type,
+			Severity:  data.Severity,
+			Component: data.Component,
+			Variable:  data.Variable,
+		})
+	}
+
+	return ocpp201.NewSetVariableMonitoringResponse(results), nil
+}
+
+// setMonitor returns the id of the monitor, if the monitor was set.
+func (cp *ChargePoint) setMonitor(data ocpp201.SetMonitoringData) (*int, ocpp201.SetMonitoringStatus) {
+	characteristics, err := cp.deviceModel.GetCharacteristics(data.Component, data.Variable)
+	switch {
+	case errors.Is(err, deviceModel.ErrUnknownComponent):
+		return nil, ocpp201.SetMonitoringStatusUnknownComponent
+	case errors.Is(err, deviceModel.ErrUnknownVariable):
+		return nil, ocpp201.SetMonitoringStatusUnknownVariable
+	case err != nil:
+		return nil, ocpp201.SetMonitoringStatusRejected
+	}
+
+	isNumeric := characteristics.DataType == ocpp201.DataTypeInteger || characteristics.DataType == ocpp201.DataTypeDecimal
+	switch data.Type {
+	case ocpp201.MonitorTypeUpperThreshold, ocpp201.MonitorTypeLowerThreshold:
+		if !isNumeric {
+			return nil, ocpp201.SetMonitoringStatusUnsupportedMonitorType
+		}
+	case ocpp201.MonitorTypeDelta:
+		if data.Value < 0 {
+			return nil, ocpp201.SetMonitoringStatusRejected
+		}
+	case ocpp201.MonitorTypePeriodic, ocpp201.MonitorTypePeriodicClockAligned:
+		if data.Value <= 0 {
+			return nil, ocpp201.SetMonitoringStatusRejected
+		}
+	default:
+		return nil, ocpp201.SetMonitoringStatusUnsupportedMonitorType
+	}
+
+	monitor := monitoring.Monitor{
+		Component:   data.Component,
+		Variable:    data.Variable,
+		Type:        data.Type,
+		Value:       data.Value,
+		Severity:    data.Severity,
+		Transaction: data.Transaction,
+	}
+
+	if data.Id != nil {
+		monitor.Id = *data.Id
+	}
+
+	id, err := cp.monitoring.SetMonitor(monitor)
+	switch {
+	case errors.Is(err, monitoring.ErrDuplicateMonitor):
+		return nil, ocpp201.SetMonitoringStatusDuplicate
+	case err != nil:
+		cp.logger.WithError(err).Warnf("Cannot set the monitor of %s", deviceModel.String(data.Component, data.Variable))
+		return nil, ocpp201.SetMonitoringStatusRejected
+	}
+
+	return &id, ocpp201.SetMonitoringStatusAccepted
+}
+
+// OnClearVariableMonitoring removes the monitors.
+func (cp *ChargePoint) OnClearVariableMonitoring(request *ocpp201.ClearVariableMonitoringRequest) (*ocpp201.ClearVariableMonitoringResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	if len(request.Id) > deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.ClearVariableMonitoringFeatureName) {
+		return nil, ocpp.NewError(ocppj.OccurrenceConstraintViolation, "too many monitors requested", "")
+	}
+
+	var results []ocpp201.ClearMonitoringResult
+	for _, id := range request.Id {
+		status := ocpp201.ClearMonitoringStatusAccepted
+		if err := cp.monitoring.ClearMonitor(id); errors.Is(err, monitoring.ErrMonitorNotFound) {
+			status = ocpp201.ClearMonitoringStatusNotFound
+		}
+
+		results = append(results, ocpp201.ClearMonitoringResult{Id: id, Status: status})
+	}
+
+	return ocpp201.NewClearVariableMonitoringResponse(results), nil
+}
+
+// OnSetMonitoringBase activates the monitors of the base. The base is stored in the device model, so it is kept after a restart.
+func (cp *ChargePoint) OnSetMonitoringBase(request *ocpp201.SetMonitoringBaseRequest) (*ocpp201.SetMonitoringBaseResponse, error) {
+	cp.logger.Infof("Received request %s", request.GetFeatureName())
+
+	cp.monitoring.SetMonitoringBase(request.MonitoringBase)
+
+	err := cp.deviceModel.UpdateVariable(
+		monitoringCtrlr,
+		ocpp201.Variable{Name: "ActiveMonitoringBase"},
+		ocpp201.AttributeTypeActual,
+		string(request.MonitoringBase),
+	)
+	if err != nil {
+		cp.logger.WithError(err).Warnf("Cannot store the monitoring base")
+	}
+
+	return ocpp201.NewSetMonitoringBaseResponse(ocpp201.GenericDeviceModelStatusAccepted), nil
+}
+
+// scheduleMonitoring samples the monitored variables of the connectors periodically.
+func (cp *ChargePoint) scheduleMonitoring() {
+	_, err := cp.scheduler.Every(monitoringInterval).Seconds().Tag("monitoring").Do(cp.sampleMonitoredVariables)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the monitoring")
+	}
+}
+
+// sampleMonitoredVariables updates the voltage and the temperature of the power meters and the state of the relays
+// in the device model and reports the events of their monitors.
+func (cp *ChargePoint) sampleMonitoredVariables() {
+	if !cp.isMonitoringEnabled() {
+		return
+	}
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		var (
+			component      = connectorComponent(c.GetEvseId(), c.GetConnectorId())
+			powerContactor = ocpp201.Component{Name: deviceModel.PowerContactorComponent, Evse: component.Evse}
+			transactionId  = c.GetTransactionId()
+		)
+
+		cp.updateMonitoredVariable(powerContactor, ocpp201.Variable{Name: "Active"}, strconv.FormatBool(c.IsCharging()), transactionId)
+
+		meter := c.GetPowerMeter()
+		if util.IsNilInterfaceOrPointer(meter) {
+			continue
+		}
+
+		cp.updateMonitoredVariable(component, ocpp201.Variable{Name: "Voltage"}, fmt.Sprintf("%.1f", meter.GetVoltage()), transactionId)
+		cp.updateMonitoredVariable(component, ocpp201.Variable{Name: "Temperature"}, fmt.Sprintf("%.1f", meter.GetTemperature()), transactionId)
+	}
+}
+
+// updateMonitoredVariable updates the value in the device model and sends the events of the monitors of the variable.
+func (cp *ChargePoint) updateMonitoredVariable(component ocpp201.Component, variable ocpp201.Variable, value, transactionId string) {
+	err := cp.deviceModel.UpdateVariable(component, variable, ocpp201.AttributeTypeActual, value)
+	if err != nil {
+		cp.logger.WithError(err).Debugf("Cannot update %s", deviceModel.String(component, variable))
+		return
+	}
+
+	cp.sendEvents(cp.monitoring.Evaluate(component, variable, value, transactionId, time.Now())...)
+}
+
+// notifyConnectorProblem reports the fault of the connector with the error code as a hard-wired notification.
+func (cp *ChargePoint) notifyConnectorProblem(c connector.Connector) {
+	var (
+		status, errorCode = c.GetStatus()
+		isFaulted         = status == core.ChargePointStatusFaulted
+		techCode          = ""
+	)
+
+	if isFaulted {
+		techCode = string(errorCode)
+	}
+
+	cp.notifyHardWired(
+		connectorComponent(c.GetEvseId(), c.GetConnectorId()),
+		ocpp201.Variable{Name: "Problem"},
+		strconv.FormatBool(isFaulted),
+		isFaulted,
+		techCode,
+		connectorProblemSeverity,
+	)
+}
+
+// notifyConnectionState reports the lost connection to the CSMS as a hard-wired notification, which is sent after reconnecting.
+func (cp *ChargePoint) notifyConnectionState(isOnline bool) {
+	cp.notifyHardWired(
+		ocpp201.Component{Name: deviceModel.ChargingStationComponent},
+		ocpp201.Variable{Name: "Online"},
+		strconv.FormatBool(isOnline),
+		!isOnline,
+		"",
+		connectionLostSeverity,
+	)
+}
+
+// notifyHardWired sends a hard-wired notification when the value of the variable changes. The notification is cleared
+// when the problem is gone.
+func (cp *ChargePoint) notifyHardWired(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	value string,
+	isProblem bool,
+	techCode string,
+	severity int,
+) {
+	if util.IsNilInterfaceOrPointer(cp.deviceModel) || util.IsNilInterfaceOrPointer(cp.monitoring) {
+		return
+	}
+
+	previous, err := cp.deviceModel.GetVariable(component, variable, ocpp201.AttributeTypeActual)
+	if err != nil || previous == value {
+		return
+	}
+
+	err = cp.deviceModel.UpdateVariable(component, variable, ocpp201.AttributeTypeActual, value)
+	if err != nil {
+		cp.logger.WithError(err).Debugf("Cannot update %s", deviceModel.String(component, variable))
+		return
+	}
+
+	cp.sendEvents(cp.monitoring.Notify(component, variable, value, techCode, severity, !isProblem, time.Now()))
+}
+
+// sendEvents sends the events with the severity up to the ActiveMonitoringLevel. While the charging station is offline,
+// the events with the severity up to the OfflineQueuingSeverity are queued and sent after the next BootNotification.
+func (cp *ChargePoint) sendEvents(events ...monitoring.Event) {
+	var (
+		level           = cp.getMonitoringSetting("ActiveMonitoringLevel", 9)
+		offlineSeverity = cp.getMonitoringSetting("OfflineQueuingSeverity", 2)
+		isRegistered    = cp.isRegistered()
+		eventData       []ocpp201.EventData
+	)
+
+	cp.eventsMu.Lock()
+	defer cp.eventsMu.Unlock()
+
+	for _, event := range events {
+		if event.Severity > level || (!isRegistered && event.Severity > offlineSeverity) {
+			continue
+		}
+
+		eventData = append(eventData, event.Data)
+	}
+
+	if len(eventData) == 0 {
+		return
+	}
+
+	// The queued events are sent first to keep the order
+	if !isRegistered || len(cp.pendingEvents) > 0 {
+		cp.pendingEvents = append(cp.pendingEvents, eventData...)
+		if len(cp.pendingEvents) > maxQueuedEvents {
+			cp.pendingEvents = cp.pendingEvents[len(cp.pendingEvents)-maxQueuedEvents:]
+		}
+
+		return
+	}
+
+	cp.sendEventData(eventData)
+}
+
+// sendQueuedEvents sends the events queued while the charging station was offline.
+func (cp *ChargePoint) sendQueuedEvents() {
+	cp.eventsMu.Lock()
+	defer cp.eventsMu.Unlock()
+
+	if len(cp.pendingEvents) == 0 {
+		return
+	}
+
+	cp.sendEventData(cp.pendingEvents)
+	cp.pendingEvents = nil
+}
+
+// sendEventData sends the events in pages of ItemsPerMessage events.
+func (cp *ChargePoint) sendEventData(eventData []ocpp201.EventData) {
+	var (
+		pageSize    = deviceModel.GetItemsPerMessage(cp.deviceModel, ocpp201.NotifyEventFeatureName)
+		generatedAt = types.NewDateTime(time.Now())
+	)
+
+	for seqNo := 0; seqNo*pageSize < len(eventData); seqNo++ {
+		end := (seqNo + 1) * pageSize
+		if end > len(eventData) {
+			end = len(eventData)
+		}
+
+		request := ocpp201.NewNotifyEventRequest(generatedAt, seqNo, eventData[seqNo*pageSize:end])
+		request.ToBeContinued = end < len(eventData)
+
+		err := cp.chargingStation.SendRequestAsync(request, func(response ocpp.Response, protoError error) {
+			if protoError != nil {
+				cp.logger.WithError(protoError).Errorf("Cannot send the events")
+			}
+		})
+		util.HandleRequestErr(err, "Cannot send the events")
+	}
+}
+
+func (cp *ChargePoint) isMonitoringEnabled() bool {
+	value, err := cp.deviceModel.GetVariable(monitoringCtrlr, ocpp201.Variable{Name: "Enabled"}, ocpp201.AttributeTypeActual)
+	return err == nil && value == "true"
+}
+
+// getMonitoringSetting returns the integer variable of the MonitoringCtrlr or the default value.
+func (cp *ChargePoint) getMonitoringSetting(name string, defaultValue int) int {
+	value, err := cp.deviceModel.GetVariable(monitoringCtrlr, ocpp201.Variable{Name: name}, ocpp201.AttributeTypeActual)
+	if err != nil {
+		return defaultValue
+	}
+
+	setting, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return setting
+}
+
+func connectorComponent(evseId, connectorId int) ocpp201.Component {
+	return ocpp201.Component{
+		Name: deviceModel.ConnectorComponent,
+		Evse: &ocpp201.EVSE{Id: evseId, ConnectorId: &connectorId},
+	}
+}
@@ -0,0 +1,147 @@
+package v201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/monitoring"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"testing"
+)
+
+type monitoringTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *monitoringTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		Settings:          &settings.Settings{},
+		transactions:      map[string]*transaction{},
+		logger:            log.StandardLogger(),
+		scheduler:         scheduler.GetScheduler(),
+		deviceModel:       deviceModel.NewStore(""),
+		monitoring:        monitoring.NewManager(),
+		authCache:         auth.NewAuthCache(""),
+		connectorSettings: []*settings.Connector{{EvseId: 1, ConnectorId: connectorId, PowerMeter: settings.PowerMeter{Enabled: true}}},
+	}
+
+	s.cp.setupDeviceModel()
+	s.cp.setupMonitoring()
+}
+
+func (s *monitoringTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *monitoringTestSuite) TestSetVariableMonitoring() {
+	var (
+		component      = connectorComponent(1, connectorId)
+		powerContactor = ocpp201.Component{Name: deviceModel.PowerContactorComponent, Evse: component.Evse}
+		voltage        = ocpp201.Variable{Name: "Voltage"}
+	)
+
+	response, err := s.cp.OnSetVariableMonitoring(&ocpp201.SetVariableMonitoringRequest{
+		SetMonitoringData: []ocpp201.SetMonitoringData{
+			{Value: 10, Type: ocpp201.MonitorTypeDelta, Severity: 5, Component: component, Variable: voltage},
+			{Value: 20, Type: ocpp201.MonitorTypeDelta, Severity: 5, Component: component, Variable: voltage},
+			{Value: 1, Type: ocpp201.MonitorTypeUpperThreshold, Component: powerContactor, Variable: ocpp201.Variable{Name: "Active"}},
+			{Value: 60, Type: ocpp201.MonitorTypePeriodic, Component: ocpp201.Component{Name: "Unknown"}, Variable: voltage},
+			{Value: 60, Type: ocpp201.MonitorTypePeriodic, Component: ocpp201.Component{Name: deviceModel.OCPPCommCtrlrComponent}, Variable: ocpp201.Variable{Name: "HeartbeatInterval"}},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(response.SetMonitoringResult, 5)
+	s.Assert().EqualValues(ocpp201.SetMonitoringStatusAccepted, response.SetMonitoringResult[0].Status)
+	s.Require().NotNil(response.SetMonitoringResult[0].Id)
+	s.Assert().EqualValues(ocpp201.SetMonitoringStatusDuplicate, response.SetMonitoringResult[1].Status)
+	s.Assert().EqualValues(ocpp201.SetMonitoringStatusUnsupportedMonitorType, response.SetMonitoringResult[2].Status)
+	s.Assert().EqualValues(ocpp201.SetMonitoringStatusUnknownComponent, response.SetMonitoringResult[3].Status)
+	s.Assert().EqualValues(ocpp201.SetMonitoringStatusRejected, response.SetMonitoringResult[4].Status)
+
+	id := *response.SetMonitoringResult[0].Id
+	clearResponse, err := s.cp.OnClearVariableMonitoring(&ocpp201.ClearVariableMonitoringRequest{Id: []int{id, 100}})
+	s.Require().NoError(err)
+	s.Require().Len(clearResponse.ClearMonitoringResult, 2)
+	s.Assert().EqualValues(ocpp201.ClearMonitoringStatusAccepted, clearResponse.ClearMonitoringResult[0].Status)
+	s.Assert().EqualValues(ocpp201.ClearMonitoringStatusNotFound, clearResponse.ClearMonitoringResult[1].Status)
+}
+
+func (s *monitoringTestSuite) TestSetMonitoringBase() {
+	// The preconfigured temperature monitor
+	s.Require().Len(s.cp.monitoring.GetMonitors(), 1)
+
+	response, err := s.cp.OnSetMonitoringBase(&ocpp201.SetMonitoringBaseRequest{MonitoringBase: ocpp201.MonitoringBaseHardWiredOnly})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.GenericDeviceModelStatusAccepted, response.Status)
+	s.Assert().Empty(s.cp.monitoring.GetMonitors())
+
+	// The base is restored after a restart
+	s.cp.monitoring = monitoring.NewManager()
+	s.cp.setupMonitoring()
+	s.Assert().Empty(s.cp.monitoring.GetMonitors())
+}
+
+func (s *monitoringTestSuite) TestEventsQueuedWhileOffline() {
+	var (
+		connectorMock   = new(test.ConnectorMock)
+		managerMock     = new(test.ManagerMock)
+		powerMeterMock  = new(test.PowerMeterMock)
+		chargingStation = new(chargingStationMock)
+	)
+
+	powerMeterMock.On("GetVoltage").Return(230.0)
+	powerMeterMock.On("GetTemperature").Return(85.0)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetTransactionId").Return("")
+	connectorMock.On("IsCharging").Return(false)
+	connectorMock.On("GetPowerMeter").Return(powerMeterMock)
+	connectorMock.On("GetStatus").Return(core.ChargePointStatusFaulted, core.GroundFailure)
+
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+	chargingStation.On("SendRequestAsync", mock.AnythingOfType("*ocpp201.NotifyEventRequest")).Return(&ocpp201.NotifyEventResponse{}, nil, nil)
+
+	s.cp.connectorManager = managerMock
+	s.cp.chargingStation = chargingStation
+
+	// The temperature exceeded the preconfigured threshold and the connector is faulted
+	s.cp.sampleMonitoredVariables()
+	s.cp.notifyConnectorProblem(connectorMock)
+	// The same problem is reported only once
+	s.cp.notifyConnectorProblem(connectorMock)
+	chargingStation.AssertNotCalled(s.T(), "SendRequestAsync", mock.Anything)
+
+	s.cp.sendQueuedEvents()
+	chargingStation.AssertNumberOfCalls(s.T(), "SendRequestAsync", 1)
+
+	request := chargingStation.Calls[0].Arguments.Get(0).(*ocpp201.NotifyEventRequest)
+	s.Require().Len(request.EventData, 2)
+	s.Assert().EqualValues("85.0", request.EventData[0].ActualValue)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypePreconfiguredMonitor, request.EventData[0].EventNotificationType)
+	s.Assert().EqualValues("Problem", request.EventData[1].Variable.Name)
+	s.Assert().EqualValues(core.GroundFailure, request.EventData[1].TechCode)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypeHardWiredNotification, request.EventData[1].EventNotificationType)
+
+	// The measured values are in the device model
+	value, err := s.cp.deviceModel.GetVariable(connectorComponent(1, connectorId), ocpp201.Variable{Name: "Voltage"}, ocpp201.AttributeTypeActual)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("230.0", value)
+}
+
+func TestMonitoring(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(monitoringTestSuite))
+}
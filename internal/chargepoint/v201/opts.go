@@ -0,0 +1,88 @@
+package v201
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	chargePointHardware "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/hardware"
+	connectionSupervisor "github.com/xBlaz3kx/ChargePi-go/internal/components/connection-supervisor"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+)
+
+// WithLogger add logger to the ChargePoint
+func WithLogger(logger *log.Logger) Options {
+	return func(point *ChargePoint) {
+		if logger != nil {
+			point.logger = logger
+		}
+	}
+}
+
+// WithReaderFromSettings creates a TagReader based on the settings.
+func WithReaderFromSettings(ctx context.Context, readerSettings settings.TagReader) Options {
+	return WithReader(ctx, chargePointHardware.NewReaderFromSettings(readerSettings))
+}
+
+// WithReader adds the reader to the charge point and starts listening to the Reader.
+func WithReader(ctx context.Context, tagReader reader.Reader) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(tagReader) {
+			return
+		}
+
+		point.TagReader = tagReader
+		chargePointHardware.ListenToReader(ctx, tagReader, point)
+	}
+}
+
+// WithDisplayFromSettings create a LCD based on the provided settings.
+func WithDisplayFromSettings(ctx context.Context, lcdSettings settings.Lcd) Options {
+	return WithDisplay(ctx, chargePointHardware.NewDisplayFromSettings(lcdSettings))
+}
+
+// WithDisplay add the provided LCD to the ChargePoint.
+func WithDisplay(ctx context.Context, display display.LCD) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(display) {
+			return
+		}
+
+		point.LCD = display
+		go display.ListenForMessages(ctx)
+	}
+}
+
+// WithDataTransfer sets the registry of the vendor specific messages, which is shared with the other modules.
+func WithDataTransfer(registry dataTransfer.Registry) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(registry) {
+			return
+		}
+
+		point.dataTransfer = registry
+	}
+}
+
+// WithReservations sets the reservation manager, so the reservations are included in the customer information.
+func WithReservations(manager reservations.Manager) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(manager) {
+			return
+		}
+
+		point.reservationManager = manager
+	}
+}
+
+// WithClient sets the websocket client, which may already be connected to the central system with the negotiated subprotocol.
+func WithClient(client *connectionSupervisor.Client) Options {
+	return func(point *ChargePoint) {
+		if client != nil {
+			point.wsClient = client
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package v201
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+)
+
+// OnRequestStartTransaction starts a transaction on the requested or the first available EVSE. The transaction is started
+// after the response is sent.
+func (cp *ChargePoint) OnRequestStartTransaction(request *ocpp201.RequestStartTransactionRequest) (*ocpp201.RequestStartTransactionResponse, error) {
+	var (
+		logInfo = cp.logger.WithFields(log.Fields{
+			"evseId":  request.EvseId,
+			"idToken": request.IdToken.IdToken,
+		})
+		c connector.Connector
+	)
+
+	logInfo.Infof("Received request %s", request.GetFeatureName())
+
+	if request.EvseId != nil {
+		c = cp.findEvseConnector(*request.EvseId)
+	} else {
+		c = cp.connectorManager.FindAvailableConnector()
+	}
+
+	if util.IsNilInterfaceOrPointer(c) || !c.IsAvailable() || !cp.canStartTransactions() {
+		return ocpp201.NewRequestStartTransactionResponse(ocpp201.RequestStartStopStatusRejected), nil
+	}
+
+	// Delay the charging by 3 seconds
+	_, err := cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.remoteStart, c, request.IdToken, request.GroupIdToken, request.RemoteStartId)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule the remote start")
+		return ocpp201.NewRequestStartTransactionResponse(ocpp201.RequestStartStopStatusRejected), nil
+	}
+
+	return ocpp201.NewRequestStartTransactionResponse(ocpp201.RequestStartStopStatusAccepted), nil
+}
+
+// OnRequestStopTransaction stops the transaction after the response is sent.
+func (cp *ChargePoint) OnRequestStopTransaction(request *ocpp201.RequestStopTransactionRequest) (*ocpp201.RequestStopTransactionResponse, error) {
+	cp.logger.WithField("transactionId", request.TransactionId).Infof("Received request %s", request.GetFeatureName())
+
+	c := cp.connectorManager.FindConnectorWithTransactionId(request.TransactionId)
+	if util.IsNilInterfaceOrPointer(c) || !(c.IsCharging() || c.IsSuspended()) {
+		return ocpp201.NewRequestStopTransactionResponse(ocpp201.RequestStartStopStatusRejected), nil
+	}
+
+	// Delay stopping the transaction by 3 seconds
+	_, err := cp.scheduler.Every(3).Seconds().LimitRunsTo(1).
+		Do(cp.stopChargingConnector, c, ocpp201.TriggerReasonRemoteStop, ocpp201.StoppedReasonRemote)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot schedule the remote stop")
+		return ocpp201.NewRequestStopTransactionResponse(ocpp201.RequestStartStopStatusRejected), nil
+	}
+
+	return ocpp201.NewRequestStopTransactionResponse(ocpp201.RequestStartStopStatusAccepted), nil
+}
+
+func (cp *ChargePoint) remoteStart(c connector.Connector, idToken ocpp201.IdToken, groupIdToken *ocpp201.IdToken, remoteStartId int) {
+	err := cp.startChargingConnector(c, idToken, groupIdToken, &remoteStartId)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot start the transaction requested by the CSMS")
+	}
+}
+
+// findEvseConnector returns the first connector of the EVSE.
+func (cp *ChargePoint) findEvseConnector(evseId int) connector.Connector {
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.GetEvseId() == evseId {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package v201
+
+import (
+	"fmt"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+)
+
+var tariffCostCtrlr = ocpp201.Component{Name: deviceModel.TariffCostCtrlrComponent}
+
+// OnCostUpdated displays the running cost of the ongoing transaction.
+func (cp *ChargePoint) OnCostUpdated(request *ocpp201.CostUpdatedRequest) (*ocpp201.CostUpdatedResponse, error) {
+	cp.logger.WithField("transactionId", request.TransactionId).Infof("Received request %s", request.GetFeatureName())
+
+	if cp.isTransactionOngoing(request.TransactionId) {
+		cp.displayRunningCost(request.TransactionId, request.TotalCost)
+	}
+
+	return ocpp201.NewCostUpdatedResponse(), nil
+}
+
+// displayTransactionCost displays the tariff and the cost from the response to the TransactionEvent. The updated
+// personal message replaces the message of the IdToken. The cost in the response to the Ended event is the final cost.
+func (cp *ChargePoint) displayTransactionCost(request *ocpp201.TransactionEventRequest, response *ocpp201.TransactionEventResponse) {
+	switch {
+	case response.UpdatedPersonalMessage != nil:
+		cp.displayTariff(response.UpdatedPersonalMessage)
+	case response.IdTokenInfo != nil && response.IdTokenInfo.PersonalMessage != nil:
+		cp.displayTariff(response.IdTokenInfo.PersonalMessage)
+	}
+
+	// The final cost of the transactions that ended while offline was already replaced with the fallback message
+	if request.EventType == ocpp201.TransactionEventEnded && !request.Offline {
+		cp.displayTotalCost(response.TotalCost)
+		return
+	}
+
+	if response.TotalCost != nil && request.EventType != ocpp201.TransactionEventEnded {
+		cp.displayRunningCost(request.TransactionInfo.TransactionId, *response.TotalCost)
+	}
+}
+
+// displayTariff displays the personal message with the tariff of the driver. Without the message, e.g. while offline,
+// the TariffFallbackMessage is displayed instead.
+func (cp *ChargePoint) displayTariff(message *ocpp201.MessageContent) {
+	if !cp.isTariffCostEnabled(deviceModel.TariffInstance) {
+		return
+	}
+
+	text := cp.getTariffCostSetting("TariffFallbackMessage")
+	if message != nil {
+		text = message.Content
+	}
+
+	if text == "" {
+		return
+	}
+
+	cp.sendToLCD(display.SplitLines(text)...)
+}
+
+// displayRunningCost displays the cost of the ongoing transaction at its connector.
+func (cp *ChargePoint) displayRunningCost(transactionId string, cost float64) {
+	if !cp.isTariffCostEnabled(deviceModel.CostInstance) || util.IsNilInterfaceOrPointer(cp.connectorManager) {
+		return
+	}
+
+	c := cp.connectorManager.FindConnectorWithTransactionId(transactionId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return
+	}
+
+	message, err := i18n.TranslateRunningCostMessage(cp.Settings.ChargePoint.Hardware.Lcd.Language, c.GetConnectorId(), cp.formatCost(cost))
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error displaying the running cost")
+		return
+	}
+
+	cp.sendToLCD(message...)
+}
+
+// displayTotalCost displays the final cost of the transaction. If the CSMS did not calculate the cost, e.g. because
+// the transaction ended while offline, the TotalCostFallbackMessage is displayed instead.
+func (cp *ChargePoint) displayTotalCost(cost *float64) {
+	if !cp.isTariffCostEnabled(deviceModel.CostInstance) {
+		return
+	}
+
+	if cost == nil {
+		if fallback := cp.getTariffCostSetting("TotalCostFallbackMessage"); fallback != "" {
+			cp.sendToLCD(display.SplitLines(fallback)...)
+		}
+
+		return
+	}
+
+	message, err := i18n.TranslateTotalCostMessage(cp.Settings.ChargePoint.Hardware.Lcd.Language, cp.formatCost(*cost))
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error displaying the total cost")
+		return
+	}
+
+	cp.sendToLCD(message...)
+}
+
+// formatCost formats the cost with the currency of the TariffCostCtrlr.
+func (cp *ChargePoint) formatCost(cost float64) string {
+	return fmt.Sprintf("%.2f %s", cost, cp.getTariffCostSetting("Currency"))
+}
+
+// isTariffCostEnabled checks if displaying the tariff or the cost (depending on the instance) is enabled.
+func (cp *ChargePoint) isTariffCostEnabled(instance string) bool {
+	if !cp.isDisplayAvailable() || util.IsNilInterfaceOrPointer(cp.deviceModel) {
+		return false
+	}
+
+	value, err := cp.deviceModel.GetVariable(
+		tariffCostCtrlr,
+		ocpp201.Variable{Name: "Enabled", Instance: instance},
+		ocpp201.AttributeTypeActual,
+	)
+	return err == nil && value == "true"
+}
+
+func (cp *ChargePoint) getTariffCostSetting(name string) string {
+	value, err := cp.deviceModel.GetVariable(tariffCostCtrlr, ocpp201.Variable{Name: name}, ocpp201.AttributeTypeActual)
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
@@ -0,0 +1,154 @@
+package v201
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	deviceModel "github.com/xBlaz3kx/ChargePi-go/internal/components/device-model"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"testing"
+	"time"
+)
+
+const costTransactionId = "costTransaction"
+
+type tariffCostTestSuite struct {
+	suite.Suite
+	cp         *ChargePoint
+	lcdChannel chan display.LCDMessage
+}
+
+func (s *tariffCostTestSuite) SetupTest() {
+	var (
+		lcd              = new(test.DisplayMock)
+		connectorManager = new(test.ManagerMock)
+		connectorMock    = new(test.ConnectorMock)
+		chargingPoint    = &settings.Settings{}
+	)
+
+	s.lcdChannel = make(chan display.LCDMessage, 5)
+	lcd.On("GetLcdChannel").Return(s.lcdChannel)
+	chargingPoint.ChargePoint.Hardware.Lcd.IsEnabled = true
+	chargingPoint.ChargePoint.Hardware.Lcd.Language = "en"
+
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorManager.On("FindConnectorWithTransactionId", costTransactionId).Return(connectorMock)
+
+	s.cp = &ChargePoint{
+		Settings:         chargingPoint,
+		LCD:              lcd,
+		connectorManager: connectorManager,
+		transactions:     map[string]*transaction{costTransactionId: {}},
+		logger:           log.StandardLogger(),
+		scheduler:        scheduler.GetScheduler(),
+		deviceModel:      deviceModel.NewStore(""),
+		authCache:        auth.NewAuthCache(""),
+	}
+
+	s.cp.setupDeviceModel()
+}
+
+func (s *tariffCostTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *tariffCostTestSuite) setTariffCostVariable(name, instance, value string) {
+	err := s.cp.deviceModel.SetVariable(
+		tariffCostCtrlr,
+		ocpp201.Variable{Name: name, Instance: instance},
+		ocpp201.AttributeTypeActual,
+		value,
+	)
+	s.Require().NoError(err)
+}
+
+func (s *tariffCostTestSuite) expectMessage(expected ...string) {
+	select {
+	case lcdMessage := <-s.lcdChannel:
+		s.Assert().EqualValues(expected, lcdMessage.Messages)
+	case <-time.After(time.Second):
+		s.Fail("the message was not displayed")
+	}
+}
+
+func (s *tariffCostTestSuite) expectNoMessage() {
+	select {
+	case lcdMessage := <-s.lcdChannel:
+		s.Failf("unexpected message", "%v", lcdMessage.Messages)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *tariffCostTestSuite) TestCostUpdated() {
+	response, err := s.cp.OnCostUpdated(&ocpp201.CostUpdatedRequest{TotalCost: 1.5, TransactionId: costTransactionId})
+	s.Require().NoError(err)
+	s.Assert().NotNil(response)
+	s.expectMessage("Connector 1", "Cost: 1.50 EUR")
+
+	// The cost of an unknown transaction
+	_, err = s.cp.OnCostUpdated(&ocpp201.CostUpdatedRequest{TotalCost: 2, TransactionId: "unknown"})
+	s.Require().NoError(err)
+	s.expectNoMessage()
+
+	// Displaying the cost is disabled
+	s.setTariffCostVariable("Enabled", deviceModel.CostInstance, "false")
+	_, err = s.cp.OnCostUpdated(&ocpp201.CostUpdatedRequest{TotalCost: 2, TransactionId: costTransactionId})
+	s.Require().NoError(err)
+	s.expectNoMessage()
+}
+
+func (s *tariffCostTestSuite) TestTransactionEventCost() {
+	var (
+		totalCost = 12.3
+		request   = &ocpp201.TransactionEventRequest{
+			EventType:       ocpp201.TransactionEventStarted,
+			TransactionInfo: ocpp201.Transaction{TransactionId: costTransactionId},
+		}
+		response = &ocpp201.TransactionEventResponse{
+			IdTokenInfo: &ocpp201.IdTokenInfo{
+				Status:          ocpp201.AuthorizationStatusAccepted,
+				PersonalMessage: &ocpp201.MessageContent{Format: ocpp201.MessageFormatUTF8, Content: "0.30 EUR/kWh"},
+			},
+		}
+	)
+
+	s.cp.displayTransactionCost(request, response)
+	s.expectMessage("0.30 EUR/kWh")
+
+	request.EventType = ocpp201.TransactionEventEnded
+	response = &ocpp201.TransactionEventResponse{TotalCost: &totalCost}
+	s.cp.displayTransactionCost(request, response)
+	s.expectMessage("Total cost:", "12.30 EUR")
+
+	// The CSMS did not calculate the total cost
+	s.setTariffCostVariable("TotalCostFallbackMessage", "", "See the receipt")
+	s.cp.displayTransactionCost(request, &ocpp201.TransactionEventResponse{})
+	s.expectMessage("See the receipt")
+}
+
+func (s *tariffCostTestSuite) TestTariffFallback() {
+	// No fallback message is configured
+	s.cp.displayTariff(nil)
+	s.expectNoMessage()
+
+	s.setTariffCostVariable("TariffFallbackMessage", "", "Free charging")
+	s.cp.displayTariff(nil)
+	s.expectMessage("Free charging")
+
+	s.setTariffCostVariable("Enabled", deviceModel.TariffInstance, "false")
+	s.cp.displayTariff(nil)
+	s.expectNoMessage()
+}
+
+func TestTariffCost(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(tariffCostTestSuite))
+}
@@ -0,0 +1,460 @@
+package v201
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"time"
+)
+
+// transaction holds the state of an ongoing transaction, which is not stored in the connector's session.
+// It is persisted in the connector settings next to the session.
+type transaction struct {
+	idToken ocpp201.IdToken
+	// The IdTokens of the same group can stop the transaction
+	groupIdToken *authData.Token
+	// The IdToken that stopped the transaction, if it's not the IdToken that started it
+	stopIdToken   *ocpp201.IdToken
+	chargingState ocpp201.ChargingState
+	remoteStartId *int
+}
+
+func newTransactionFromSettings(tx settingsData.Transaction) *transaction {
+	return &transaction{
+		idToken:       tx.IdToken,
+		groupIdToken:  tx.GroupIdToken,
+		stopIdToken:   tx.StopIdToken,
+		chargingState: tx.ChargingState,
+		remoteStartId: tx.RemoteStartId,
+	}
+}
+
+func (tx *transaction) toSettings(transactionId string) *settingsData.Transaction {
+	return &settingsData.Transaction{
+		TransactionId: transactionId,
+		IdToken:       tx.idToken,
+		GroupIdToken:  tx.groupIdToken,
+		StopIdToken:   tx.stopIdToken,
+		ChargingState: tx.chargingState,
+		RemoteStartId: tx.remoteStartId,
+	}
+}
+
+// persistTransaction stores the transaction in the connector settings, so it can be restored after a reboot.
+// The ended transaction (nil) is removed from the settings.
+func (cp *ChargePoint) persistTransaction(c connector.Connector, transactionId string, tx *transaction) {
+	var txSettings *settingsData.Transaction
+	if tx != nil {
+		txSettings = tx.toSettings(transactionId)
+	}
+
+	settings.UpdateConnectorTransactionInfo(c.GetEvseId(), c.GetConnectorId(), txSettings)
+}
+
+// newTransactionId generates a unique transaction id. In OCPP 2.0.1, the transaction ids are assigned by the charging station.
+func newTransactionId() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// startCharging Start charging on the first available Connector. If there is no available Connector, reject the request.
+func (cp *ChargePoint) startCharging(idToken ocpp201.IdToken) error {
+	if c := cp.connectorManager.FindAvailableConnector(); !util.IsNilInterfaceOrPointer(c) {
+		return cp.startChargingConnector(c, idToken, nil, nil)
+	}
+
+	return errors.ErrNoAvailableConnectors
+}
+
+// startChargingConnector authorizes the IdToken, starts charging the connector and queues the TransactionEvent with
+// the Started event type. The transactions started remotely are authorized only if AuthorizeRemoteTxRequests is enabled.
+// The group of the transaction is the group of the authorized IdToken or the group requested by the CSMS.
+func (cp *ChargePoint) startChargingConnector(c connector.Connector, idToken ocpp201.IdToken, groupIdToken *ocpp201.IdToken, remoteStartId *int) error {
+	if util.IsNilInterfaceOrPointer(c) {
+		return errors.ErrConnectorNil
+	}
+
+	logInfo := cp.logger.WithFields(log.Fields{
+		"evseId":      c.GetEvseId(),
+		"connectorId": c.GetConnectorId(),
+		"idToken":     idToken.IdToken,
+	})
+
+	if !(c.IsAvailable() || c.IsPreparing()) {
+		return errors.ErrConnectorUnavailable
+	}
+
+	if cp.availability != core.AvailabilityTypeOperative {
+		return errors.ErrChargePointUnavailable
+	}
+
+	if !cp.canStartTransactions() {
+		return errors.ErrChargePointNotAccepted
+	}
+
+	var group *authData.Token
+	if groupIdToken != nil {
+		token := authData.FromIdToken(*groupIdToken)
+		group = &token
+	}
+
+	authorizeRemoteStart, _ := ocppConfigManager.GetConfigurationValue(v16.AuthorizeRemoteTxRequests.String())
+	if remoteStartId == nil || authorizeRemoteStart == "true" {
+		tokenInfo, isAuthorized := cp.authorize(idToken)
+		if !isAuthorized {
+			return errors.ErrTagUnauthorized
+		}
+
+		if tokenInfo != nil && tokenInfo.GroupIdToken != nil {
+			group = tokenInfo.GroupIdToken
+		}
+	}
+
+	transactionId, err := newTransactionId()
+	if err != nil {
+		return err
+	}
+
+	err = c.StartCharging(transactionId, idToken.IdToken)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to start charging connector")
+		return err
+	}
+
+	tx := &transaction{
+		idToken:       idToken,
+		groupIdToken:  group,
+		chargingState: ocpp201.ChargingStateCharging,
+		remoteStartId: remoteStartId,
+	}
+
+	cp.transactionsMu.Lock()
+	cp.transactions[transactionId] = tx
+	cp.persistTransaction(c, transactionId, tx)
+	cp.transactionsMu.Unlock()
+
+	triggerReason := ocpp201.TriggerReasonAuthorized
+	if remoteStartId != nil {
+		triggerReason = ocpp201.TriggerReasonRemoteStart
+	}
+
+	request := cp.newTransactionEvent(c, ocpp201.TransactionEventStarted, triggerReason, transactionId)
+	request.IdToken = &idToken
+	request.TransactionInfo.ChargingState = ocpp201.ChargingStateCharging
+	request.TransactionInfo.RemoteStartId = remoteStartId
+	request.MeterValue = append(
+		toMeterValues(c.GetSession().TransactionData),
+		energyMeterValue(c.GetMeterReading(), ocpp201.ReadingContextTransactionBegin),
+	)
+
+	logInfo.Infof("Started charging connector at %s", time.Now())
+	cp.queueTransactionEvent(request)
+
+	// Schedule timer to stop the transaction at the time limit
+	_, err = cp.scheduler.Every(c.GetMaxChargingTime()).Minutes().LimitRunsTo(1).
+		Tag(fmt.Sprintf("connector%dTimer", c.GetConnectorId())).
+		Do(cp.stopChargingConnector, c, ocpp201.TriggerReasonTimeLimitReached, ocpp201.StoppedReasonTimeLimitReached)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule stop charging")
+	}
+
+	return nil
+}
+
+// findGroupConnector returns the connector with a transaction of the same group as the IdToken, so any IdToken
+// of the group can stop the transaction. The IdToken is remembered as the IdToken that stopped the transaction.
+func (cp *ChargePoint) findGroupConnector(idToken ocpp201.IdToken) connector.Connector {
+	cp.transactionsMu.Lock()
+	hasGroups := false
+	for _, tx := range cp.transactions {
+		hasGroups = hasGroups || tx.groupIdToken != nil
+	}
+	cp.transactionsMu.Unlock()
+
+	// Avoid asking the CSMS for the group, if there are no transactions with a group
+	if !hasGroups {
+		return nil
+	}
+
+	groupIdToken := cp.getGroupIdToken(idToken)
+	if groupIdToken == nil {
+		return nil
+	}
+
+	cp.transactionsMu.Lock()
+	defer cp.transactionsMu.Unlock()
+
+	for transactionId, tx := range cp.transactions {
+		if tx.groupIdToken == nil || !tx.groupIdToken.Matches(*groupIdToken) {
+			continue
+		}
+
+		c := cp.connectorManager.FindConnectorWithTransactionId(transactionId)
+		if !util.IsNilInterfaceOrPointer(c) {
+			tx.stopIdToken = &idToken
+			cp.persistTransaction(c, transactionId, tx)
+			return c
+		}
+	}
+
+	return nil
+}
+
+// stopChargingConnector stops charging the connector and queues the TransactionEvent with the Ended event type,
+// which contains the transaction data of the session.
+func (cp *ChargePoint) stopChargingConnector(c connector.Connector, triggerReason ocpp201.TriggerReason, stoppedReason ocpp201.StoppedReason) error {
+	if util.IsNilInterfaceOrPointer(c) {
+		return errors.ErrConnectorNil
+	}
+
+	var (
+		stopOnEVDisconnect, err = ocppConfigManager.GetConfigurationValue(v16.StopTransactionOnEVSideDisconnect.String())
+		transactionId           = c.GetTransactionId()
+		reason                  = toCoreReason(stoppedReason)
+		logInfo                 = cp.logger.WithFields(log.Fields{
+			"evseId":        c.GetEvseId(),
+			"connectorId":   c.GetConnectorId(),
+			"transactionId": transactionId,
+			"reason":        stoppedReason,
+		})
+	)
+
+	if err != nil {
+		stopOnEVDisconnect = "true"
+	}
+
+	if !(c.IsCharging() || c.IsPreparing() || c.IsSuspended()) {
+		return errors.ErrConnectorNotCharging
+	}
+
+	if stopOnEVDisconnect != "true" && stoppedReason == ocpp201.StoppedReasonEVDisconnected {
+		return c.StopCharging(reason)
+	}
+
+	// The energy register is persisted, so the reading rolls back only if the power meter was replaced
+	meterStop := c.GetMeterReading()
+	if meterStart := c.GetSession().MeterStart; meterStop < meterStart {
+		logInfo.WithError(errors.ErrMeterReadingRolledBack).
+			Warnf("Meter stop %d Wh is lower than the meter start %d Wh", meterStop, meterStart)
+	}
+
+	logInfo.Info("Stopping transaction")
+	err = c.StopCharging(reason)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to stop charging")
+		return err
+	}
+
+	request := cp.newTransactionEvent(c, ocpp201.TransactionEventEnded, triggerReason, transactionId)
+	request.TransactionInfo.StoppedReason = stoppedReason
+	// The transaction data is kept in the session until the next transaction starts
+	request.MeterValue = append(
+		toMeterValues(c.GetSession().TransactionData),
+		energyMeterValue(meterStop, ocpp201.ReadingContextTransactionEnd),
+	)
+
+	cp.transactionsMu.Lock()
+	if tx, isFound := cp.transactions[transactionId]; isFound {
+		if triggerReason == ocpp201.TriggerReasonStopAuthorized {
+			request.IdToken = &tx.idToken
+			if tx.stopIdToken != nil {
+				request.IdToken = tx.stopIdToken
+			}
+		}
+
+		delete(cp.transactions, transactionId)
+	}
+	cp.persistTransaction(c, transactionId, nil)
+	cp.transactionsMu.Unlock()
+
+	cp.removeTransactionMessages(transactionId)
+
+	_ = cp.scheduler.RemoveByTag(fmt.Sprintf("Evse%dConnector%dSampling", c.GetEvseId(), c.GetConnectorId()))
+	_ = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dTimer", c.GetConnectorId()))
+
+	logInfo.Infof("Stopped charging at %s", time.Now())
+
+	// The CSMS cannot calculate the total cost until it receives the TransactionEvent
+	if request.Offline {
+		cp.displayTotalCost(nil)
+	}
+
+	// The TransactionEvent is sent as soon as the CSMS is reachable
+	cp.queueTransactionEvent(request)
+	return nil
+}
+
+// onChargingStateChanged sends a TransactionEvent with the Updated event type, when the charging state of an ongoing transaction changes.
+func (cp *ChargePoint) onChargingStateChanged(c connector.Connector) {
+	var (
+		transactionId = c.GetTransactionId()
+		status, _     = c.GetStatus()
+	)
+
+	chargingState, isTransactionState := getChargingState(status)
+	if !c.GetSession().IsActive || !isTransactionState {
+		return
+	}
+
+	cp.transactionsMu.Lock()
+	tx, isFound := cp.transactions[transactionId]
+	if !isFound || tx.chargingState == chargingState {
+		cp.transactionsMu.Unlock()
+		return
+	}
+
+	tx.chargingState = chargingState
+	cp.persistTransaction(c, transactionId, tx)
+	cp.transactionsMu.Unlock()
+
+	request := cp.newTransactionEvent(c, ocpp201.TransactionEventUpdated, ocpp201.TriggerReasonChargingStateChanged, transactionId)
+	request.TransactionInfo.ChargingState = chargingState
+	cp.queueTransactionEvent(request)
+}
+
+// sendTransactionMeterValues sends the meter values sampled during the transaction with a TransactionEvent with the Updated event type.
+func (cp *ChargePoint) sendTransactionMeterValues(c connector.Connector, meterValues []types.MeterValue) {
+	transactionId := c.GetTransactionId()
+
+	cp.transactionsMu.Lock()
+	_, isFound := cp.transactions[transactionId]
+	cp.transactionsMu.Unlock()
+
+	// The transaction is not started yet or has already ended
+	if !isFound {
+		return
+	}
+
+	request := cp.newTransactionEvent(c, ocpp201.TransactionEventUpdated, ocpp201.TriggerReasonMeterValuePeriodic, transactionId)
+	request.MeterValue = toMeterValues(meterValues)
+	if len(request.MeterValue) == 0 {
+		return
+	}
+
+	cp.queueTransactionEvent(request)
+}
+
+// onTransactionEventResponse displays the tariff and the cost and deauthorizes the transaction if the CSMS did not
+// accept the IdToken of the transaction.
+func (cp *ChargePoint) onTransactionEventResponse(request *ocpp201.TransactionEventRequest, response *ocpp201.TransactionEventResponse) {
+	cp.displayTransactionCost(request, response)
+
+	if response.IdTokenInfo == nil || request.EventType == ocpp201.TransactionEventEnded {
+		return
+	}
+
+	switch response.IdTokenInfo.Status {
+	case ocpp201.AuthorizationStatusAccepted, ocpp201.AuthorizationStatusConcurrentTx:
+		return
+	}
+
+	c := cp.connectorManager.FindConnectorWithTransactionId(request.TransactionInfo.TransactionId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return
+	}
+
+	cp.logger.WithField("transactionId", request.TransactionInfo.TransactionId).Errorf("Transaction unauthorized")
+	cp.deauthorizeTransaction(c)
+}
+
+// deauthorizeTransaction stops the transaction if StopTransactionOnInvalidId is enabled. Otherwise, the energy delivery is suspended,
+// while the transaction keeps running.
+func (cp *ChargePoint) deauthorizeTransaction(c connector.Connector) {
+	stopOnInvalidId, _ := ocppConfigManager.GetConfigurationValue(v16.StopTransactionOnInvalidId.String())
+
+	if stopOnInvalidId == "true" {
+		err := cp.stopChargingConnector(c, ocpp201.TriggerReasonDeauthorized, ocpp201.StoppedReasonDeAuthorized)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Unable to stop charging connector")
+		}
+
+		return
+	}
+
+	err := c.SuspendCharging()
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to suspend charging the connector")
+	}
+}
+
+// newTransactionEvent creates a TransactionEvent for the connector's EVSE. The sequence number is assigned by the transaction queue.
+func (cp *ChargePoint) newTransactionEvent(
+	c connector.Connector,
+	eventType ocpp201.TransactionEventType,
+	triggerReason ocpp201.TriggerReason,
+	transactionId string,
+) *ocpp201.TransactionEventRequest {
+	connectorId := c.GetConnectorId()
+	request := ocpp201.NewTransactionEventRequest(
+		eventType,
+		types.NewDateTime(time.Now()),
+		triggerReason,
+		ocpp201.Transaction{TransactionId: transactionId},
+	)
+	request.Evse = &ocpp201.EVSE{Id: c.GetEvseId(), ConnectorId: &connectorId}
+	request.Offline = !cp.isRegistered()
+	return request
+}
+
+func (cp *ChargePoint) queueTransactionEvent(request *ocpp201.TransactionEventRequest) {
+	err := cp.transactionQueue.Enqueue(request)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot queue the %s TransactionEvent", request.EventType)
+	}
+}
+
+// getChargingState maps the connector status to the charging state of the transaction. The statuses that are not
+// reported with the TransactionEvent return false.
+func getChargingState(status core.ChargePointStatus) (ocpp201.ChargingState, bool) {
+	switch status {
+	case core.ChargePointStatusCharging:
+		return ocpp201.ChargingStateCharging, true
+	case core.ChargePointStatusSuspendedEV:
+		return ocpp201.ChargingStateSuspendedEV, true
+	case core.ChargePointStatusSuspendedEVSE:
+		return ocpp201.ChargingStateSuspendedEVSE, true
+	default:
+		return "", false
+	}
+}
+
+// toCoreReason maps the stopped reason to the reason of the connector, which determines the connector status after the transaction.
+func toCoreReason(reason ocpp201.StoppedReason) core.Reason {
+	switch reason {
+	case ocpp201.StoppedReasonDeAuthorized:
+		return core.ReasonDeAuthorized
+	case ocpp201.StoppedReasonEmergencyStop:
+		return core.ReasonEmergencyStop
+	case ocpp201.StoppedReasonEVDisconnected:
+		return core.ReasonEVDisconnected
+	case ocpp201.StoppedReasonImmediateReset:
+		return core.ReasonHardReset
+	case ocpp201.StoppedReasonLocal:
+		return core.ReasonLocal
+	case ocpp201.StoppedReasonPowerLoss:
+		return core.ReasonPowerLoss
+	case ocpp201.StoppedReasonReboot:
+		return core.ReasonReboot
+	case ocpp201.StoppedReasonRemote:
+		return core.ReasonRemote
+	default:
+		return core.ReasonOther
+	}
+}
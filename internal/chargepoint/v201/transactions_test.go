@@ -0,0 +1,287 @@
+package v201
+
+import (
+	"context"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type transactionTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *transactionTestSuite) SetupTest() {
+	s.cp = &ChargePoint{
+		availability:       core.AvailabilityTypeOperative,
+		registrationStatus: ocpp201.RegistrationStatusAccepted,
+		transactions:       map[string]*transaction{},
+		logger:             log.StandardLogger(),
+		scheduler:          scheduler.GetScheduler(),
+		transactionQueue:   transactionQueue.NewQueue(""),
+		authCache:          auth.NewAuthCache(filepath.Join(s.T().TempDir(), "auth.json")),
+		localAuthList:      auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json")),
+	}
+	s.cp.transactionQueue.SetTransactionEventHandler(s.cp.onTransactionEventResponse)
+}
+
+func (s *transactionTestSuite) TearDownTest() {
+	s.cp.scheduler.Clear()
+}
+
+func (s *transactionTestSuite) TestStartStopTransaction() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		remoteStartId = 12
+		idToken       = ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443)
+		transactionId string
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(true)
+	connectorMock.On("GetMaxChargingTime").Return(15)
+	connectorMock.On("GetMeterReading").Return(1000).Once()
+	connectorMock.On("GetSession").Return(session.Session{}).Once()
+	connectorMock.On("StartCharging", mock.AnythingOfType("string"), tagId).Run(func(args mock.Arguments) {
+		transactionId = args.String(0)
+	}).Return(nil).Once()
+	s.cp.connectorManager = managerMock
+
+	// The remote start is not authorized without AuthorizeRemoteTxRequests
+	err := s.cp.startChargingConnector(connectorMock, idToken, nil, &remoteStartId)
+	s.Require().NoError(err)
+	s.Assert().Len(transactionId, 32)
+	s.Assert().EqualValues(1, s.cp.transactionQueue.Len())
+	s.Require().Len(s.cp.scheduler.Jobs(), 1)
+
+	// Stopping the transaction queues the Ended event
+	connectorMock.On("GetTransactionId").Return(transactionId)
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(1500).Once()
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: transactionId, MeterStart: 1000, IsActive: true})
+	connectorMock.On("StopCharging", core.ReasonRemote).Return(nil).Once()
+
+	err = s.cp.stopChargingConnector(connectorMock, ocpp201.TriggerReasonRemoteStop, ocpp201.StoppedReasonRemote)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(2, s.cp.transactionQueue.Len())
+	s.Assert().Empty(s.cp.transactions)
+	s.Assert().Empty(s.cp.scheduler.Jobs())
+
+	requests := s.sendQueuedRequests(ocpp201.AuthorizationStatusAccepted)
+	s.Require().Len(requests, 2)
+
+	started := requests[0].(*ocpp201.TransactionEventRequest)
+	s.Assert().EqualValues(ocpp201.TransactionEventStarted, started.EventType)
+	s.Assert().EqualValues(ocpp201.TriggerReasonRemoteStart, started.TriggerReason)
+	s.Assert().EqualValues(0, started.SequenceNo)
+	s.Assert().EqualValues(&remoteStartId, started.TransactionInfo.RemoteStartId)
+	s.Assert().EqualValues(&idToken, started.IdToken)
+	// The events queued while the charging station is not connected are marked as offline
+	s.Assert().True(started.Offline)
+	s.Require().Len(started.MeterValue, 1)
+	s.Assert().EqualValues(1000, started.MeterValue[0].SampledValue[0].Value)
+
+	ended := requests[1].(*ocpp201.TransactionEventRequest)
+	s.Assert().EqualValues(ocpp201.TransactionEventEnded, ended.EventType)
+	s.Assert().EqualValues(ocpp201.StoppedReasonRemote, ended.TransactionInfo.StoppedReason)
+	s.Assert().EqualValues(1, ended.SequenceNo)
+	s.Assert().EqualValues(transactionId, ended.TransactionInfo.TransactionId)
+	s.Require().Len(ended.MeterValue, 1)
+	s.Assert().EqualValues(1500, ended.MeterValue[0].SampledValue[0].Value)
+}
+
+func (s *transactionTestSuite) TestStartChargingUnauthorized() {
+	connectorMock := new(test.ConnectorMock)
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsAvailable").Return(true)
+
+	// An unknown token is not authorized while offline
+	err := s.cp.startChargingConnector(connectorMock, ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443), nil, nil)
+	s.Assert().ErrorIs(err, errors.ErrTagUnauthorized)
+	connectorMock.AssertNotCalled(s.T(), "StartCharging", mock.Anything, mock.Anything)
+	s.Assert().EqualValues(0, s.cp.transactionQueue.Len())
+}
+
+func (s *transactionTestSuite) TestTransactionDeauthorized() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		transactionId = "a1b2c3"
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetTransactionId").Return(transactionId)
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(1200)
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: transactionId, MeterStart: 1000, IsActive: true})
+	connectorMock.On("StopCharging", core.ReasonDeAuthorized).Return(nil).Once()
+	managerMock.On("FindConnectorWithTransactionId", transactionId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	s.cp.transactions[transactionId] = &transaction{
+		idToken:       ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443),
+		chargingState: ocpp201.ChargingStateCharging,
+	}
+	s.cp.queueTransactionEvent(s.cp.newTransactionEvent(connectorMock, ocpp201.TransactionEventStarted, ocpp201.TriggerReasonAuthorized, transactionId))
+
+	// The CSMS rejects the token, so the transaction is stopped
+	requests := s.sendQueuedRequests(ocpp201.AuthorizationStatusInvalid)
+	s.Require().Len(requests, 2)
+	connectorMock.AssertCalled(s.T(), "StopCharging", core.ReasonDeAuthorized)
+	s.Assert().Empty(s.cp.transactions)
+
+	ended := requests[1].(*ocpp201.TransactionEventRequest)
+	s.Assert().EqualValues(ocpp201.TriggerReasonDeauthorized, ended.TriggerReason)
+	s.Assert().EqualValues(ocpp201.StoppedReasonDeAuthorized, ended.TransactionInfo.StoppedReason)
+}
+
+func (s *transactionTestSuite) TestStopTransactionWithGroup() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		transactionId = "a1b2c3"
+		groupToken    = "groupTag"
+		group         = authData.NewToken("group1", authData.TokenTypeCentral)
+	)
+
+	s.Require().NoError(s.cp.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		{
+			Token:     authData.NewToken(groupToken, authData.TokenTypeISO14443),
+			TokenInfo: &authData.TokenInfo{Status: authData.StatusAccepted, GroupIdToken: &group},
+		},
+	}))
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("GetTransactionId").Return(transactionId)
+	connectorMock.On("IsCharging").Return(true)
+	connectorMock.On("GetMeterReading").Return(1200)
+	connectorMock.On("GetSession").Return(session.Session{TransactionId: transactionId, MeterStart: 1000, IsActive: true})
+	connectorMock.On("StopCharging", core.ReasonLocal).Return(nil).Once()
+	managerMock.On("FindConnectorWithTagId", groupToken).Return(nil)
+	managerMock.On("FindConnectorWithTransactionId", transactionId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	s.cp.transactions[transactionId] = &transaction{
+		idToken:       ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443),
+		groupIdToken:  &group,
+		chargingState: ocpp201.ChargingStateCharging,
+	}
+
+	// The token of the same group stops the transaction
+	_, err := s.cp.HandleChargingRequest(groupToken)
+	s.Require().NoError(err)
+	connectorMock.AssertCalled(s.T(), "StopCharging", core.ReasonLocal)
+	s.Assert().Empty(s.cp.transactions)
+
+	requests := s.sendQueuedRequests(ocpp201.AuthorizationStatusAccepted)
+	s.Require().Len(requests, 1)
+
+	ended := requests[0].(*ocpp201.TransactionEventRequest)
+	s.Assert().EqualValues(ocpp201.TriggerReasonStopAuthorized, ended.TriggerReason)
+	s.Require().NotNil(ended.IdToken)
+	s.Assert().EqualValues(groupToken, ended.IdToken.IdToken)
+}
+
+// sendQueuedRequests sends the queued transaction events and returns the sent requests. The CSMS responds with the status.
+func (s *transactionTestSuite) TestPersistTransaction() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		remoteStartId = 12
+		groupIdToken  = authData.NewToken("group", authData.TokenTypeISO14443)
+		stopIdToken   = ocpp201.NewIdToken("stopTag", ocpp201.IdTokenTypeISO14443)
+		cfg           = viper.New()
+		connectorFile settingsData.Connector
+	)
+
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+
+	cfg.SetConfigFile(filepath.Join(s.T().TempDir(), "connector-1.json"))
+	cfg.Set("EvseId", 1)
+	cfg.Set("ConnectorId", connectorId)
+	s.Require().NoError(cfg.WriteConfig())
+	settings.ConnectorSettings.Store("connectorEvse1Id1", cfg)
+	defer settings.ConnectorSettings.Delete("connectorEvse1Id1")
+
+	tx := &transaction{
+		idToken:       ocpp201.NewIdToken(tagId, ocpp201.IdTokenTypeISO14443),
+		groupIdToken:  &groupIdToken,
+		stopIdToken:   &stopIdToken,
+		chargingState: ocpp201.ChargingStateSuspendedEV,
+		remoteStartId: &remoteStartId,
+	}
+	s.cp.persistTransaction(connectorMock, "transaction1", tx)
+
+	// The transaction is restored from the settings
+	s.Require().NoError(cfg.Unmarshal(&connectorFile))
+	s.Require().NotNil(connectorFile.Transaction)
+	s.Assert().EqualValues("transaction1", connectorFile.Transaction.TransactionId)
+	s.Assert().EqualValues(tx, newTransactionFromSettings(*connectorFile.Transaction))
+
+	// The ended transaction is removed from the settings
+	s.cp.persistTransaction(connectorMock, "transaction1", nil)
+	connectorFile = settingsData.Connector{}
+	s.Require().NoError(cfg.Unmarshal(&connectorFile))
+	s.Assert().Nil(connectorFile.Transaction)
+}
+
+func (s *transactionTestSuite) sendQueuedRequests(status ocpp201.AuthorizationStatus) []ocpp.Request {
+	var (
+		requests    []ocpp.Request
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	go s.cp.transactionQueue.Run(ctx, func(request ocpp.Request) (ocpp.Response, error) {
+		requests = append(requests, request)
+		return &ocpp201.TransactionEventResponse{IdTokenInfo: &ocpp201.IdTokenInfo{Status: status}}, nil
+	}, func() bool {
+		return true
+	})
+
+	s.Require().Eventually(func() bool {
+		return s.cp.transactionQueue.Len() == 0
+	}, time.Second*3, time.Millisecond*10)
+
+	return requests
+}
+
+func TestTransactions(t *testing.T) {
+	err := ocppManager.GetManager().SetConfiguration(ocppConfig)
+	assert.NoError(t, err)
+
+	suite.Run(t, new(transactionTestSuite))
+}
+
+func TestGetChargingState(t *testing.T) {
+	state, isTransactionState := getChargingState(core.ChargePointStatusSuspendedEVSE)
+	assert.True(t, isTransactionState)
+	assert.EqualValues(t, ocpp201.ChargingStateSuspendedEVSE, state)
+
+	_, isTransactionState = getChargingState(core.ChargePointStatusAvailable)
+	assert.False(t, isTransactionState)
+}
@@ -1,95 +1,162 @@
 package auth
 
 import (
+	"encoding/json"
 	"fmt"
-	"github.com/kkyr/fig"
-	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	goCache "github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
 	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	VersionKey = "AuthCacheVersion"
 	MaxTagsKey = "AuthCacheMaxTags"
+
+	defaultLifeTime = time.Minute * 10
+)
+
+// CachePolicy decides which token is removed from the full cache to make room for a new token.
+type CachePolicy string
+
+const (
+	// CachePolicyNone does not remove any token, so the new tokens are not cached while the cache is full.
+	CachePolicyNone CachePolicy = ""
+	// CachePolicyLRU removes the least recently used token.
+	CachePolicyLRU CachePolicy = "LRU"
+	// CachePolicyLFU removes the least frequently used token.
+	CachePolicyLFU CachePolicy = "LFU"
+	// CachePolicyFIFO removes the token that was cached first.
+	CachePolicyFIFO CachePolicy = "FIFO"
 )
 
 type (
 	Cache struct {
+		mu       sync.Mutex
 		cache    *goCache.Cache
 		filePath string
+		lifeTime time.Duration
+		policy   CachePolicy
+	}
+
+	cacheEntry struct {
+		data     authData.AuthorizationData
+		added    time.Time
+		lastUsed time.Time
+		uses     int
 	}
 )
 
 func NewAuthCache(filePath string) *Cache {
-	cache := goCache.New(time.Minute*10, time.Minute*10)
+	cache := goCache.New(defaultLifeTime, time.Minute*10)
 	// Defaults
 	cache.Set(VersionKey, 1, goCache.NoExpiration)
 	cache.Set(MaxTagsKey, 0, goCache.NoExpiration)
 
 	return &Cache{
+		mu:       sync.Mutex{},
 		cache:    cache,
 		filePath: filePath,
+		lifeTime: defaultLifeTime,
+		policy:   CachePolicyNone,
 	}
 }
 
 // LoadAuthFile loads tags from the cache file
 func (c *Cache) LoadAuthFile() {
-	var (
-		auth settingsData.AuthorizationFile
-		err  error
-	)
+	var auth settingsData.AuthorizationFile
+
+	data, err := ioutil.ReadFile(c.filePath)
+	if os.IsNotExist(err) {
+		log.Debugf("No authorization file found")
+		return
+	} else if err != nil {
+		log.WithError(err).Errorf("Unable to read authorization file")
+		return
+	}
+
+	switch filepath.Ext(c.filePath) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &auth)
+	default:
+		err = json.Unmarshal(data, &auth)
+	}
 
-	err = fig.Load(&auth,
-		fig.File(filepath.Base(c.filePath)),
-		fig.Dirs(filepath.Dir(c.filePath)))
 	if err != nil {
-		//todo temporary fix - tags with ExpiryDate won't unmarshall successfully
 		log.WithError(err).Errorf("Unable to load authorization file")
+		return
 	}
 
 	c.cache.Set(VersionKey, auth.Version, goCache.NoExpiration)
 	c.cache.Set(MaxTagsKey, auth.MaxCachedTags, goCache.NoExpiration)
-	loadTags(c.cache, auth.Tags)
+	c.loadTags(auth.Tags)
 
-	log.Infof("Read auth file version %d with Tags %s", auth.Version, auth.Tags)
+	log.Infof("Read auth file version %d with %d tags", auth.Version, len(auth.Tags))
 }
 
-// AddTag Add a tag to the global authorization cache.
-func (c *Cache) AddTag(tagId string, tagInfo *types.IdTagInfo) {
+// AddTag Add a token to the global authorization cache or update the info of the cached token. The token expires at the expiry date
+// of the token info or after the lifetime of the cache. An already expired token is removed from the cache instead. If the cache
+// is full, a token is removed according to the policy.
+func (c *Cache) AddTag(token authData.Token, tokenInfo authData.TokenInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var (
-		maxTags        int
-		expirationTime = time.Minute * 10
+		key            = getKey(token)
+		expirationTime = c.lifeTime
+		now            = time.Now()
 	)
 
-	cacheMaxTags, isFound := c.cache.Get(MaxTagsKey)
-	if !isFound {
-		maxTags = 0
+	if tokenInfo.ExpiryDate != nil {
+		// The cache would keep the token with a negative expiration forever
+		if !tokenInfo.ExpiryDate.After(now) {
+			c.cache.Delete(key)
+			return
+		}
+
+		expirationTime = tokenInfo.ExpiryDate.Sub(now)
 	}
-	maxTags = cacheMaxTags.(int)
 
-	if c.cache.ItemCount() >= maxTags+2 {
+	entry := &cacheEntry{
+		data:     authData.AuthorizationData{Token: token, TokenInfo: &tokenInfo},
+		added:    now,
+		lastUsed: now,
+	}
+
+	// Update the token if it's already cached
+	if cached, isFound := c.cache.Get(key); isFound {
+		entry.added = cached.(*cacheEntry).added
+		entry.uses = cached.(*cacheEntry).uses
+		c.cache.Set(key, entry, expirationTime)
 		return
 	}
 
-	if tagInfo.ExpiryDate != nil {
-		expirationTime = tagInfo.ExpiryDate.Sub(time.Now())
+	if c.isFull() && !c.evict() {
+		return
 	}
 
-	// Add a tag if it doesn't exist in the cache already
-	err := c.cache.Add(fmt.Sprintf("AuthTag%s", tagId), *tagInfo, expirationTime)
+	err := c.cache.Add(key, entry, expirationTime)
 	if err != nil {
 		log.WithError(err).Errorf("Error adding tag to cache")
 	}
 }
 
-// RemoveTag Remove a tag from the global authorization cache.
+// RemoveTag Remove the tokens with the id from the global authorization cache, regardless of the token type.
 func (c *Cache) RemoveTag(tagId string) {
-	c.cache.Delete(fmt.Sprintf("AuthTag%s", tagId))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range c.findKeys(tagId) {
+		c.cache.Delete(key)
+	}
 }
 
 // RemoveCachedTags Remove all Tags from the global authorization cache.
@@ -123,10 +190,28 @@ func (c *Cache) SetMaxCachedTags(number int) {
 	}
 }
 
+// SetLifeTime Set how long the tokens without an expiry date stay in the cache.
+func (c *Cache) SetLifeTime(lifeTime time.Duration) {
+	if lifeTime > 0 {
+		log.Debugf("Set the cache lifetime to %v", lifeTime)
+		c.mu.Lock()
+		c.lifeTime = lifeTime
+		c.mu.Unlock()
+	}
+}
+
+// SetPolicy Set the policy of removing the tokens from the full cache.
+func (c *Cache) SetPolicy(policy CachePolicy) {
+	log.Debugf("Set the cache policy to %s", policy)
+	c.mu.Lock()
+	c.policy = policy
+	c.mu.Unlock()
+}
+
 func (c *Cache) DumpTags() {
 	log.Debug("Writing tags to file..")
 	var (
-		authTags                  []types.IdTagInfo
+		authTags                  []authData.AuthorizationData
 		version, isVersionFound   = c.cache.Get(VersionKey)
 		maxCachedTags, isMaxFound = c.cache.Get(MaxTagsKey)
 	)
@@ -140,8 +225,8 @@ func (c *Cache) DumpTags() {
 	}
 
 	for key, item := range c.cache.Items() {
-		if strings.Contains(key, "AuthTag") && !item.Expired() {
-			authTags = append(authTags, item.Object.(types.IdTagInfo))
+		if strings.HasPrefix(key, "AuthTag") && !item.Expired() {
+			authTags = append(authTags, item.Object.(*cacheEntry).data)
 		}
 	}
 
@@ -155,45 +240,180 @@ func (c *Cache) DumpTags() {
 	}
 }
 
-// IsTagAuthorized Check if the tag exists in the global authorization cache, the status of the tag is "Accepted" and if it has not expired yet.
-func (c *Cache) IsTagAuthorized(tagId string) bool {
-	log.Infof("Checking if tag authorized %s", tagId)
+// GetTag returns the token info, if the token is in the cache.
+func (c *Cache) GetTag(token authData.Token) (*authData.TokenInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	tagObject, isFound := c.cache.Get(fmt.Sprintf("AuthTag%s", tagId))
-	if isFound {
-		tagInfo := tagObject.(types.IdTagInfo)
-		switch tagInfo.Status {
-		case types.AuthorizationStatusAccepted,
-			types.AuthorizationStatusConcurrentTx:
-			if tagInfo.ExpiryDate != nil && tagInfo.ExpiryDate.Before(time.Now()) {
-				return false
-			}
+	entry, isFound := c.find(token)
+	if !isFound {
+		return nil, false
+	}
+
+	entry.lastUsed = time.Now()
+	entry.uses++
+
+	tokenInfo := *entry.data.TokenInfo
+	return &tokenInfo, true
+}
+
+// FindTag returns the cached authorization of the token with the id, regardless of the token type. Unlike GetTag,
+// it does not count as a use of the token.
+func (c *Cache) FindTag(idToken string) (*authData.AuthorizationData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.findKeys(idToken)
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	cached, isFound := c.cache.Get(keys[0])
+	if !isFound {
+		return nil, false
+	}
+
+	data := cached.(*cacheEntry).data
+	return &data, true
+}
+
+// IsTagAuthorized Check if the token exists in the global authorization cache, the status of the token is "Accepted" and if it has not expired yet.
+func (c *Cache) IsTagAuthorized(token authData.Token) bool {
+	log.Infof("Checking if tag authorized %s", token.IdToken)
+
+	tokenInfo, isFound := c.GetTag(token)
+	if !isFound || !tokenInfo.IsAuthorized() {
+		return false
+	}
 
-			log.Infof("Tag %s authorized with cache", tagId)
-			return true
-		case types.AuthorizationStatusInvalid,
-			types.AuthorizationStatusBlocked,
-			types.AuthorizationStatusExpired:
-			return false
-		default:
-			return false
+	log.Infof("Tag %s authorized with cache", token.IdToken)
+	return true
+}
+
+// find returns the cached entry of the token. The tokens without a type match the cached token with the same id of any type
+// and vice versa. The lock must be held by the caller.
+func (c *Cache) find(token authData.Token) (*cacheEntry, bool) {
+	if cached, isFound := c.cache.Get(getKey(token)); isFound {
+		return cached.(*cacheEntry), true
+	}
+
+	for _, key := range c.findKeys(token.IdToken) {
+		cached, isFound := c.cache.Get(key)
+		if !isFound {
+			continue
+		}
+
+		if entry := cached.(*cacheEntry); entry.data.Token.Matches(token) {
+			return entry, true
+		}
+	}
+
+	return nil, false
+}
+
+// findKeys returns the keys of the cached tokens with the id of any type. The lock must be held by the caller.
+func (c *Cache) findKeys(idToken string) []string {
+	var keys []string
+
+	for key, item := range c.cache.Items() {
+		if entry, isEntry := item.Object.(*cacheEntry); isEntry && entry.data.Token.IdToken == idToken {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// isFull checks if the number of cached tokens reached the maximum. The lock must be held by the caller.
+func (c *Cache) isFull() bool {
+	maxTags, isFound := c.cache.Get(MaxTagsKey)
+	if !isFound {
+		maxTags = 0
+	}
+
+	// The version and the max tags are stored in the cache as well
+	return c.cache.ItemCount() >= maxTags.(int)+2
+}
+
+// evict removes a token according to the policy to make room for a new token. The lock must be held by the caller.
+func (c *Cache) evict() bool {
+	c.cache.DeleteExpired()
+	if !c.isFull() {
+		return true
+	}
+
+	if c.policy == CachePolicyNone {
+		return false
+	}
+
+	var (
+		evictKey string
+		evict    *cacheEntry
+	)
+
+	for key, item := range c.cache.Items() {
+		entry, isEntry := item.Object.(*cacheEntry)
+		if !isEntry {
+			continue
+		}
+
+		if evict == nil || isEvictedBefore(c.policy, entry, evict) {
+			evictKey = key
+			evict = entry
 		}
 	}
 
-	return false
+	if evict == nil {
+		return false
+	}
+
+	log.Debugf("Removing tag %s from the full cache", evict.data.Token.IdToken)
+	c.cache.Delete(evictKey)
+	return true
 }
 
-// loadTags loads the tags into the cache
-func loadTags(cache *goCache.Cache, tags []types.IdTagInfo) {
-	if tags != nil {
-		for _, tag := range tags {
-			log.Tracef("Adding tag: %v", tag)
-			if tag.ExpiryDate != nil {
-				cache.Set(fmt.Sprintf("AuthTag%s", tag.ParentIdTag), tag, tag.ExpiryDate.Sub(time.Now()))
+// isEvictedBefore checks if the entry should be removed from the cache before the other entry.
+func isEvictedBefore(policy CachePolicy, entry, other *cacheEntry) bool {
+	switch policy {
+	case CachePolicyLFU:
+		if entry.uses != other.uses {
+			return entry.uses < other.uses
+		}
+
+		return entry.lastUsed.Before(other.lastUsed)
+	case CachePolicyFIFO:
+		return entry.added.Before(other.added)
+	default:
+		return entry.lastUsed.Before(other.lastUsed)
+	}
+}
+
+// loadTags loads the tags into the cache. The expired tags are skipped.
+func (c *Cache) loadTags(tags []authData.AuthorizationData) {
+	now := time.Now()
+
+	for _, tag := range tags {
+		if tag.TokenInfo == nil {
+			continue
+		}
+
+		log.Tracef("Adding tag: %v", tag)
+		entry := &cacheEntry{data: tag, added: now, lastUsed: now}
+
+		if tag.TokenInfo.ExpiryDate != nil {
+			if !tag.TokenInfo.ExpiryDate.After(now) {
 				continue
 			}
 
-			cache.SetDefault(fmt.Sprintf("AuthTag%s", tag.ParentIdTag), tag)
+			c.cache.Set(getKey(tag.Token), entry, tag.TokenInfo.ExpiryDate.Sub(now))
+			continue
 		}
+
+		c.cache.SetDefault(getKey(tag.Token), entry)
 	}
 }
+
+// getKey returns the cache key of the token. The tokens with the same id and a different type are different tokens.
+func getKey(token authData.Token) string {
+	return fmt.Sprintf("AuthTag%s/%s", token.Type, token.IdToken)
+}
@@ -1,78 +1,161 @@
 package auth
 
 import (
-	"fmt"
-	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/stretchr/testify/suite"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
 type AuthCacheTestSuite struct {
 	suite.Suite
-	tag        *types.IdTagInfo
-	blockedTag *types.IdTagInfo
-	expiredTag *types.IdTagInfo
+	tag        authData.AuthorizationData
+	blockedTag authData.AuthorizationData
+	expiredTag authData.AuthorizationData
 	authCache  *Cache
 }
 
-func (s *AuthCacheTestSuite) SetupTest() {
-	s.authCache = NewAuthCache("./auth.json")
-	s.tag = &types.IdTagInfo{
-		ParentIdTag: "123",
-		ExpiryDate:  types.NewDateTime(time.Now().Add(10 * time.Minute)),
-		Status:      types.AuthorizationStatusAccepted,
-	}
-
-	s.blockedTag = &types.IdTagInfo{
-		ParentIdTag: "BlockedTag123",
-		ExpiryDate:  types.NewDateTime(time.Now().Add(40 * time.Minute)),
-		Status:      types.AuthorizationStatusBlocked,
+func newCachedTag(tagId string, status authData.Status, expiryDate time.Time) authData.AuthorizationData {
+	return authData.AuthorizationData{
+		Token:     authData.NewIdTag(tagId),
+		TokenInfo: &authData.TokenInfo{Status: status, ExpiryDate: &expiryDate},
 	}
+}
 
-	s.expiredTag = &types.IdTagInfo{
-		ParentIdTag: "ExpiredTag123",
-		ExpiryDate:  types.NewDateTime(time.Date(1999, 1, 1, 1, 1, 1, 0, time.Local)),
-		Status:      types.AuthorizationStatusAccepted,
-	}
+func (s *AuthCacheTestSuite) SetupTest() {
+	s.authCache = NewAuthCache("./auth.json")
+	s.tag = newCachedTag("123", authData.StatusAccepted, time.Now().Add(10*time.Minute))
+	s.blockedTag = newCachedTag("BlockedTag123", authData.StatusBlocked, time.Now().Add(40*time.Minute))
+	s.expiredTag = newCachedTag("ExpiredTag123", authData.StatusAccepted, time.Date(1999, 1, 1, 1, 1, 1, 0, time.Local))
+}
 
+func (s *AuthCacheTestSuite) addTag(tag authData.AuthorizationData) {
+	s.authCache.AddTag(tag.Token, *tag.TokenInfo)
 }
 
 func (s *AuthCacheTestSuite) TestAddTag() {
 	s.authCache.SetMaxCachedTags(1)
-	s.authCache.AddTag(s.tag.ParentIdTag, s.tag)
+	s.addTag(s.tag)
 
-	s.Require().True(s.authCache.IsTagAuthorized(s.tag.ParentIdTag))
+	s.Require().True(s.authCache.IsTagAuthorized(s.tag.Token))
 
-	overLimitTag := types.IdTagInfo{
-		ParentIdTag: "testTag123",
-		ExpiryDate:  types.NewDateTime(time.Now().Add(10 * time.Minute)),
-		Status:      types.AuthorizationStatusAccepted,
-	}
+	overLimitTag := newCachedTag("testTag123", authData.StatusAccepted, time.Now().Add(10*time.Minute))
 
 	// Test cached tag limit
-	s.authCache.AddTag(overLimitTag.ParentIdTag, &overLimitTag)
-	s.Require().False(s.authCache.IsTagAuthorized(overLimitTag.ParentIdTag))
+	s.addTag(overLimitTag)
+	s.Require().False(s.authCache.IsTagAuthorized(overLimitTag.Token))
+
+	// The cached tag is updated
+	s.authCache.AddTag(s.tag.Token, authData.TokenInfo{Status: authData.StatusBlocked})
+	s.Require().False(s.authCache.IsTagAuthorized(s.tag.Token))
 }
 
 func (s *AuthCacheTestSuite) TestIsTagAuthorized() {
 	s.authCache.SetMaxCachedTags(5)
 
-	s.authCache.AddTag(s.tag.ParentIdTag, s.tag)
-	s.authCache.AddTag(s.blockedTag.ParentIdTag, s.blockedTag)
-	s.authCache.AddTag(s.expiredTag.ParentIdTag, s.expiredTag)
+	s.addTag(s.tag)
+	s.addTag(s.blockedTag)
+	s.addTag(s.expiredTag)
+
+	s.Require().True(s.authCache.IsTagAuthorized(s.tag.Token))
+	s.Require().False(s.authCache.IsTagAuthorized(s.blockedTag.Token))
+	s.Require().False(s.authCache.IsTagAuthorized(s.expiredTag.Token))
+
+	// The OCPP 1.6 tag matches the tokens of any type, while the token of a different type is not the same token
+	s.Require().True(s.authCache.IsTagAuthorized(authData.NewToken(s.tag.Token.IdToken, authData.TokenTypeKeyCode)))
+
+	keyCode := authData.NewToken("1234", authData.TokenTypeKeyCode)
+	s.authCache.AddTag(keyCode, authData.TokenInfo{Status: authData.StatusAccepted})
+	s.Require().True(s.authCache.IsTagAuthorized(keyCode))
+	s.Require().False(s.authCache.IsTagAuthorized(authData.NewToken("1234", authData.TokenTypeISO14443)))
+}
+
+func (s *AuthCacheTestSuite) TestPolicy() {
+	var (
+		tag1 = authData.NewToken("tag1", authData.TokenTypeISO14443)
+		tag2 = authData.NewToken("tag2", authData.TokenTypeISO14443)
+		tag3 = authData.NewToken("tag3", authData.TokenTypeISO14443)
+		info = authData.TokenInfo{Status: authData.StatusAccepted}
+	)
+
+	s.authCache.SetMaxCachedTags(2)
+
+	// The least recently used tag is removed
+	s.authCache.SetPolicy(CachePolicyLRU)
+	s.authCache.AddTag(tag1, info)
+	time.Sleep(time.Millisecond * 5)
+	s.authCache.AddTag(tag2, info)
+	time.Sleep(time.Millisecond * 5)
+	s.Require().True(s.authCache.IsTagAuthorized(tag1))
+
+	s.authCache.AddTag(tag3, info)
+	s.Assert().True(s.authCache.IsTagAuthorized(tag1))
+	s.Assert().False(s.authCache.IsTagAuthorized(tag2))
+	s.Assert().True(s.authCache.IsTagAuthorized(tag3))
+
+	// The tag that was cached first is removed
+	s.authCache.RemoveCachedTags()
+	s.authCache.SetPolicy(CachePolicyFIFO)
+	s.authCache.AddTag(tag1, info)
+	time.Sleep(time.Millisecond * 5)
+	s.authCache.AddTag(tag2, info)
+	s.Require().True(s.authCache.IsTagAuthorized(tag1))
+
+	s.authCache.AddTag(tag3, info)
+	s.Assert().False(s.authCache.IsTagAuthorized(tag1))
+	s.Assert().True(s.authCache.IsTagAuthorized(tag2))
 
-	s.Require().True(s.authCache.IsTagAuthorized(s.tag.ParentIdTag))
-	s.Require().False(s.authCache.IsTagAuthorized(s.blockedTag.ParentIdTag))
-	s.Require().False(s.authCache.IsTagAuthorized(s.expiredTag.ParentIdTag))
+	// The least frequently used tag is removed
+	s.authCache.RemoveCachedTags()
+	s.authCache.SetPolicy(CachePolicyLFU)
+	s.authCache.AddTag(tag1, info)
+	s.authCache.AddTag(tag2, info)
+	s.Require().True(s.authCache.IsTagAuthorized(tag2))
+	s.Require().True(s.authCache.IsTagAuthorized(tag2))
+	s.Require().True(s.authCache.IsTagAuthorized(tag1))
+
+	s.authCache.AddTag(tag3, info)
+	s.Assert().False(s.authCache.IsTagAuthorized(tag1))
+	s.Assert().True(s.authCache.IsTagAuthorized(tag2))
+}
+
+func (s *AuthCacheTestSuite) TestLifeTime() {
+	tag := authData.NewIdTag("tag1")
+	s.authCache.SetMaxCachedTags(5)
+	s.authCache.SetLifeTime(time.Millisecond * 50)
+
+	s.authCache.AddTag(tag, authData.TokenInfo{Status: authData.StatusAccepted})
+	s.Require().True(s.authCache.IsTagAuthorized(tag))
+
+	time.Sleep(time.Millisecond * 100)
+	s.Require().False(s.authCache.IsTagAuthorized(tag))
+}
+
+func (s *AuthCacheTestSuite) TestPersistence() {
+	filePath := filepath.Join(s.T().TempDir(), "auth.json")
+	s.authCache = NewAuthCache(filePath)
+	s.authCache.SetMaxCachedTags(5)
+
+	s.addTag(s.tag)
+	s.addTag(s.blockedTag)
+	s.authCache.DumpTags()
+
+	loadedCache := NewAuthCache(filePath)
+	loadedCache.LoadAuthFile()
+	s.Require().True(loadedCache.IsTagAuthorized(s.tag.Token))
+
+	tokenInfo, isFound := loadedCache.GetTag(s.blockedTag.Token)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(authData.StatusBlocked, tokenInfo.Status)
 }
 
 func (s *AuthCacheTestSuite) TestRemoveCachedTags() {
 	s.authCache.SetMaxCachedTags(5)
 
-	s.authCache.AddTag(s.tag.ParentIdTag, s.tag)
-	s.authCache.AddTag(s.blockedTag.ParentIdTag, s.blockedTag)
-	s.authCache.AddTag(s.expiredTag.ParentIdTag, s.expiredTag)
+	s.addTag(s.tag)
+	s.addTag(s.blockedTag)
+	s.addTag(s.expiredTag)
 
 	s.authCache.RemoveCachedTags()
 
@@ -82,21 +165,67 @@ func (s *AuthCacheTestSuite) TestRemoveCachedTags() {
 func (s *AuthCacheTestSuite) TestRemoveTag() {
 	s.authCache.SetMaxCachedTags(15)
 
-	s.authCache.AddTag(s.tag.ParentIdTag, s.tag)
-	s.authCache.AddTag(s.blockedTag.ParentIdTag, s.blockedTag)
-	s.authCache.AddTag(s.expiredTag.ParentIdTag, s.expiredTag)
+	s.addTag(s.tag)
+	s.addTag(s.blockedTag)
+	s.addTag(s.expiredTag)
 
-	s.authCache.RemoveTag(s.blockedTag.ParentIdTag)
+	s.authCache.RemoveTag(s.blockedTag.Token.IdToken)
 
-	_, isFound := s.authCache.cache.Get(fmt.Sprintf("AuthTag%s", s.blockedTag.ParentIdTag))
+	_, isFound := s.authCache.cache.Get(getKey(s.blockedTag.Token))
 	s.Require().False(isFound)
 
-	_, isFound = s.authCache.cache.Get(fmt.Sprintf("AuthTag%s", s.tag.ParentIdTag))
+	_, isFound = s.authCache.cache.Get(getKey(s.tag.Token))
 	s.Require().True(isFound)
 
 	s.authCache.RemoveTag("AuthTag1234")
-	_, isFound = s.authCache.cache.Get(fmt.Sprintf("AuthTag%s", s.tag.ParentIdTag))
+	_, isFound = s.authCache.cache.Get(getKey(s.tag.Token))
 	s.Require().True(isFound)
+
+	// The tokens of all types are removed
+	keyCode := authData.NewToken(s.tag.Token.IdToken, authData.TokenTypeKeyCode)
+	s.authCache.AddTag(keyCode, authData.TokenInfo{Status: authData.StatusAccepted})
+	s.authCache.RemoveTag(s.tag.Token.IdToken)
+
+	_, isFound = s.authCache.FindTag(s.tag.Token.IdToken)
+	s.Require().False(isFound)
+}
+
+func (s *AuthCacheTestSuite) TestTokenTypes() {
+	s.authCache.SetMaxCachedTags(5)
+
+	var (
+		keyCode  = authData.NewToken("1234", authData.TokenTypeKeyCode)
+		iso14443 = authData.NewToken("1234", authData.TokenTypeISO14443)
+	)
+
+	// The tokens with the same id and a different type do not overwrite each other
+	s.authCache.AddTag(keyCode, authData.TokenInfo{Status: authData.StatusAccepted})
+	s.authCache.AddTag(iso14443, authData.TokenInfo{Status: authData.StatusBlocked})
+	s.Require().True(s.authCache.IsTagAuthorized(keyCode))
+	s.Require().False(s.authCache.IsTagAuthorized(iso14443))
+
+	// The OCPP 1.6 tag matches the cached token of any type
+	s.Require().True(s.authCache.IsTagAuthorized(authData.NewToken("1234", authData.TokenTypeKeyCode)))
+	_, isFound := s.authCache.GetTag(authData.NewIdTag("1234"))
+	s.Require().True(isFound)
+}
+
+func (s *AuthCacheTestSuite) TestExpiredTag() {
+	s.authCache.SetMaxCachedTags(5)
+
+	// The expired token is not stored
+	s.addTag(s.expiredTag)
+	_, isFound := s.authCache.cache.Get(getKey(s.expiredTag.Token))
+	s.Require().False(isFound)
+
+	// The cached token is removed once the central system reports it expired
+	s.addTag(s.tag)
+	s.authCache.AddTag(s.tag.Token, *s.expiredTag.TokenInfo)
+	_, isFound = s.authCache.GetTag(s.tag.Token)
+	s.Require().False(isFound)
+
+	s.authCache.AddTag(s.tag.Token, authData.TokenInfo{Status: authData.StatusAccepted, ExpiryDate: &time.Time{}})
+	s.Require().Equal(2, s.authCache.cache.ItemCount())
 }
 
 func (s *AuthCacheTestSuite) TestSetMaxCachedTags() {
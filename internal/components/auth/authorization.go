@@ -0,0 +1,101 @@
+package auth
+
+import (
+	log "github.com/sirupsen/logrus"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+)
+
+// Source of the local authorization decision.
+type Source string
+
+const (
+	SourceNone      Source = ""
+	SourceLocalList Source = "local authorization list"
+	SourceCache     Source = "cache"
+	SourceUnknownId Source = "unknown id"
+)
+
+// The local authorization decisions are shared by both protocol versions, so the tokens are authorized the same way
+// regardless of the version. The OCPP 2.0.1 variables of the AuthCtrlr, AuthCacheCtrlr and LocalAuthListCtrlr are
+// mapped to the same configuration keys.
+
+// PreAuthorize checks if the token can be authorized without the central system. If LocalPreAuthorize is enabled, the token
+// is authorized with the local authorization list or the cache. The local authorization list takes precedence over the cache.
+func PreAuthorize(cache *Cache, localList *LocalAuthList, token authData.Token) (Source, bool) {
+	if !isEnabled(v16.LocalPreAuthorize) {
+		return SourceNone, false
+	}
+
+	if isEnabled(v16.LocalAuthListEnabled) && localList.IsTagAuthorized(token) {
+		return SourceLocalList, true
+	}
+
+	if isEnabled(v16.AuthorizationCacheEnabled) && cache.IsTagAuthorized(token) {
+		return SourceCache, true
+	}
+
+	return SourceNone, false
+}
+
+// AuthorizeOffline checks if the token is authorized while the central system is unreachable. If LocalAuthorizeOffline is enabled,
+// the token is authorized with the local authorization list or the cache. If the token is unknown and AllowOfflineTxForUnknownId
+// is enabled, the token is authorized as well. A known token that is not valid is never authorized.
+func AuthorizeOffline(cache *Cache, localList *LocalAuthList, token authData.Token) (Source, bool) {
+	var (
+		localAuthorizeOffline = isEnabled(v16.LocalAuthorizeOffline)
+		isKnown               = false
+	)
+
+	if isEnabled(v16.LocalAuthListEnabled) {
+		if tokenInfo, isFound := localList.GetTag(token); isFound {
+			isKnown = true
+
+			if localAuthorizeOffline && tokenInfo.IsAuthorized() {
+				return SourceLocalList, true
+			}
+		}
+	}
+
+	if isEnabled(v16.AuthorizationCacheEnabled) {
+		if tokenInfo, isFound := cache.GetTag(token); isFound {
+			isKnown = true
+
+			if localAuthorizeOffline && tokenInfo.IsAuthorized() {
+				return SourceCache, true
+			}
+		}
+	}
+
+	if !isKnown && isEnabled(v16.AllowOfflineTxForUnknownId) {
+		return SourceUnknownId, true
+	}
+
+	return SourceNone, false
+}
+
+// GetTokenInfo returns the token info from the local authorization list or the cache, e.g. to find the group of the token.
+func GetTokenInfo(cache *Cache, localList *LocalAuthList, token authData.Token) (*authData.TokenInfo, bool) {
+	if tokenInfo, isFound := localList.GetTag(token); isFound {
+		return tokenInfo, true
+	}
+
+	return cache.GetTag(token)
+}
+
+// UpdateCache adds the token info from the central system to the cache, if the cache is enabled.
+func UpdateCache(cache *Cache, token authData.Token, tokenInfo *authData.TokenInfo) {
+	if tokenInfo == nil || !isEnabled(v16.AuthorizationCacheEnabled) {
+		return
+	}
+
+	log.Debugf("Caching the tag %s with status %s", token.IdToken, tokenInfo.Status)
+	cache.AddTag(token, *tokenInfo)
+}
+
+func isEnabled(key configuration.Key) bool {
+	value, err := ocppConfigManager.GetConfigurationValue(key.String())
+	return err == nil && value == "true"
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrVersionMismatch   = errors.New("local list version mismatch")
+	ErrLocalListFull     = errors.New("local list would exceed the maximum number of tags")
+	ErrMissingIdTagInfo  = errors.New("full update contains a tag without tag info")
+	ErrInvalidVersion    = errors.New("invalid list version")
+	ErrInvalidUpdateType = errors.New("invalid update type")
+)
+
+type (
+	// LocalAuthList is the Local Authorization List, managed by the central system. Unlike the Cache, the tags
+	// do not get evicted and the list is persisted after every update. The list is shared by both protocol versions.
+	LocalAuthList struct {
+		mu       sync.Mutex
+		version  int
+		maxTags  int
+		tags     map[string]authData.AuthorizationData
+		filePath string
+	}
+)
+
+func NewLocalAuthList(filePath string) *LocalAuthList {
+	return &LocalAuthList{
+		mu:       sync.Mutex{},
+		version:  0,
+		maxTags:  0,
+		tags:     map[string]authData.AuthorizationData{},
+		filePath: filePath,
+	}
+}
+
+// LoadFromFile loads the list version and tags from the file.
+func (l *LocalAuthList) LoadFromFile() {
+	var (
+		list settingsData.LocalAuthListFile
+		err  error
+	)
+
+	data, err := ioutil.ReadFile(l.filePath)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to read the local authorization list file")
+		return
+	}
+
+	switch filepath.Ext(l.filePath) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &list)
+	default:
+		err = json.Unmarshal(data, &list)
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("Unable to load the local authorization list file")
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.version = list.Version
+	l.tags = map[string]authData.AuthorizationData{}
+	for _, tag := range list.Tags {
+		if tag.TokenInfo != nil {
+			l.tags[tag.Token.IdToken] = tag
+		}
+	}
+
+	log.Infof("Read local authorization list version %d with %d tags", l.version, len(l.tags))
+}
+
+// GetVersion returns the version of the local authorization list.
+func (l *LocalAuthList) GetVersion() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
+
+// SetMaxTags Set the maximum number of tags allowed in the local authorization list.
+func (l *LocalAuthList) SetMaxTags(number int) {
+	if number > 0 {
+		log.Debugf("Set max local list tags to %d", number)
+		l.mu.Lock()
+		l.maxTags = number
+		l.mu.Unlock()
+	}
+}
+
+// UpdateList applies a full or a differential update to the list. A full update replaces the list, while a differential
+// update adds or updates the tags with the tag info and removes the tags without it. The list is persisted after the update.
+// The version 0 is reserved for the empty list, so only a full update without tags can set it.
+func (l *LocalAuthList) UpdateList(version int, updateType authData.UpdateType, data []authData.AuthorizationData) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var tags = map[string]authData.AuthorizationData{}
+
+	if version < 0 || (version == 0 && (updateType != authData.UpdateTypeFull || len(data) > 0)) {
+		return ErrInvalidVersion
+	}
+
+	switch updateType {
+	case authData.UpdateTypeFull:
+		for _, entry := range data {
+			if entry.TokenInfo == nil {
+				return ErrMissingIdTagInfo
+			}
+
+			tags[entry.Token.IdToken] = entry
+		}
+	case authData.UpdateTypeDifferential:
+		if version <= l.version {
+			return ErrVersionMismatch
+		}
+
+		for tagId, entry := range l.tags {
+			tags[tagId] = entry
+		}
+
+		for _, entry := range data {
+			if entry.TokenInfo == nil {
+				delete(tags, entry.Token.IdToken)
+				continue
+			}
+
+			tags[entry.Token.IdToken] = entry
+		}
+	default:
+		return ErrInvalidUpdateType
+	}
+
+	if l.maxTags > 0 && len(tags) > l.maxTags {
+		return ErrLocalListFull
+	}
+
+	l.version = version
+	l.tags = tags
+	l.dump()
+	return nil
+}
+
+// GetTag returns the token info, if the token is in the list. The tokens of the list with a type only match the tokens of
+// the same type or the OCPP 1.6 tags.
+func (l *LocalAuthList) GetTag(token authData.Token) (*authData.TokenInfo, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, isFound := l.tags[token.IdToken]
+	if !isFound || !entry.Token.Matches(token) {
+		return nil, false
+	}
+
+	tokenInfo := *entry.TokenInfo
+	return &tokenInfo, true
+}
+
+// FindTag returns the entry of the token with the id, regardless of the token type.
+func (l *LocalAuthList) FindTag(idToken string) (*authData.AuthorizationData, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, isFound := l.tags[idToken]
+	if !isFound {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// IsTagAuthorized Check if the tag exists in the local authorization list, the status of the tag is "Accepted" and if it has not expired yet.
+func (l *LocalAuthList) IsTagAuthorized(token authData.Token) bool {
+	tokenInfo, isFound := l.GetTag(token)
+	if !isFound || !tokenInfo.IsAuthorized() {
+		return false
+	}
+
+	log.Infof("Tag %s authorized with the local authorization list", token.IdToken)
+	return true
+}
+
+// dump writes the list to the file. The lock must be held by the caller.
+func (l *LocalAuthList) dump() {
+	log.Debug("Writing the local authorization list to file..")
+
+	list := settingsData.LocalAuthListFile{Version: l.version}
+	for _, entry := range l.tags {
+		list.Tags = append(list.Tags, entry)
+	}
+
+	err := settings.WriteToFile(l.filePath, list)
+	if err != nil {
+		log.WithError(err).Errorf("Error updating the local authorization list file")
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/suite"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"os"
+	"testing"
+	"time"
+)
+
+const localListFile = "./local-auth-list.json"
+
+type LocalAuthListTestSuite struct {
+	suite.Suite
+	localAuthList *LocalAuthList
+}
+
+func newAuthorizationData(tagId string, tokenInfo *authData.TokenInfo) authData.AuthorizationData {
+	return authData.AuthorizationData{Token: authData.NewIdTag(tagId), TokenInfo: tokenInfo}
+}
+
+func (s *LocalAuthListTestSuite) SetupTest() {
+	s.localAuthList = NewLocalAuthList(localListFile)
+}
+
+func (s *LocalAuthListTestSuite) TearDownTest() {
+	_ = os.Remove(localListFile)
+}
+
+func (s *LocalAuthListTestSuite) TestFullUpdate() {
+	var (
+		accepted = &authData.TokenInfo{Status: authData.StatusAccepted}
+		blocked  = &authData.TokenInfo{Status: authData.StatusBlocked}
+	)
+
+	err := s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+		newAuthorizationData("tag2", blocked),
+	})
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, s.localAuthList.GetVersion())
+	s.Require().True(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag1")))
+	s.Require().False(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag2")))
+
+	// Full update replaces the list, regardless of the version
+	err = s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag3", accepted),
+	})
+	s.Require().NoError(err)
+	s.Require().False(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag1")))
+	s.Require().True(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag3")))
+
+	// Full update requires the tag info
+	err = s.localAuthList.UpdateList(2, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", nil),
+	})
+	s.Require().ErrorIs(err, ErrMissingIdTagInfo)
+	s.Require().EqualValues(1, s.localAuthList.GetVersion())
+
+	// The version 0 is reserved for the empty list
+	err = s.localAuthList.UpdateList(0, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+	})
+	s.Require().ErrorIs(err, ErrInvalidVersion)
+
+	// Empty full update clears the list
+	err = s.localAuthList.UpdateList(0, authData.UpdateTypeFull, nil)
+	s.Require().NoError(err)
+	s.Require().EqualValues(0, s.localAuthList.GetVersion())
+	s.Require().False(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag3")))
+}
+
+func (s *LocalAuthListTestSuite) TestDifferentialUpdate() {
+	var (
+		accepted   = &authData.TokenInfo{Status: authData.StatusAccepted}
+		expiryDate = time.Now().Add(-time.Minute)
+		expired    = &authData.TokenInfo{
+			ExpiryDate: &expiryDate,
+			Status:     authData.StatusAccepted,
+		}
+	)
+
+	err := s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+		newAuthorizationData("tag2", accepted),
+	})
+	s.Require().NoError(err)
+
+	// Add, update and remove tags
+	err = s.localAuthList.UpdateList(2, authData.UpdateTypeDifferential, []authData.AuthorizationData{
+		newAuthorizationData("tag1", nil),
+		newAuthorizationData("tag2", expired),
+		newAuthorizationData("tag3", accepted),
+	})
+	s.Require().NoError(err)
+	s.Require().EqualValues(2, s.localAuthList.GetVersion())
+
+	_, isFound := s.localAuthList.GetTag(authData.NewIdTag("tag1"))
+	s.Require().False(isFound)
+	s.Require().False(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag2")))
+	s.Require().True(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag3")))
+
+	// Version must be higher than the current version
+	err = s.localAuthList.UpdateList(2, authData.UpdateTypeDifferential, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+	})
+	s.Require().ErrorIs(err, ErrVersionMismatch)
+	s.Require().False(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag1")))
+}
+
+func (s *LocalAuthListTestSuite) TestTokenTypes() {
+	var (
+		accepted = &authData.TokenInfo{Status: authData.StatusAccepted}
+		group    = authData.NewToken("group1", authData.TokenTypeCentral)
+	)
+
+	err := s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		{Token: authData.NewToken("tag1", authData.TokenTypeISO14443), TokenInfo: &authData.TokenInfo{Status: authData.StatusAccepted, GroupIdToken: &group}},
+		{Token: authData.NewToken("1234", authData.TokenTypeKeyCode), TokenInfo: accepted},
+	})
+	s.Require().NoError(err)
+
+	// The token must be of the same type, while the OCPP 1.6 tags match any type
+	s.Assert().True(s.localAuthList.IsTagAuthorized(authData.NewToken("tag1", authData.TokenTypeISO14443)))
+	s.Assert().True(s.localAuthList.IsTagAuthorized(authData.NewIdTag("tag1")))
+	s.Assert().False(s.localAuthList.IsTagAuthorized(authData.NewToken("tag1", authData.TokenTypeISO15693)))
+	s.Assert().False(s.localAuthList.IsTagAuthorized(authData.NewToken("1234", authData.TokenTypeISO14443)))
+
+	tokenInfo, isFound := s.localAuthList.GetTag(authData.NewIdTag("tag1"))
+	s.Require().True(isFound)
+	s.Assert().EqualValues("group1", tokenInfo.GetGroupId())
+}
+
+func (s *LocalAuthListTestSuite) TestMaxTags() {
+	accepted := &authData.TokenInfo{Status: authData.StatusAccepted}
+	s.localAuthList.SetMaxTags(1)
+
+	err := s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+	})
+	s.Require().NoError(err)
+
+	err = s.localAuthList.UpdateList(2, authData.UpdateTypeDifferential, []authData.AuthorizationData{
+		newAuthorizationData("tag2", accepted),
+	})
+	s.Require().ErrorIs(err, ErrLocalListFull)
+	s.Require().EqualValues(1, s.localAuthList.GetVersion())
+
+	// Negative or zero values are ignored
+	s.localAuthList.SetMaxTags(0)
+	err = s.localAuthList.UpdateList(2, authData.UpdateTypeDifferential, []authData.AuthorizationData{
+		newAuthorizationData("tag2", accepted),
+	})
+	s.Require().ErrorIs(err, ErrLocalListFull)
+}
+
+func (s *LocalAuthListTestSuite) TestPersistence() {
+	accepted := &authData.TokenInfo{Status: authData.StatusAccepted}
+
+	err := s.localAuthList.UpdateList(5, authData.UpdateTypeFull, []authData.AuthorizationData{
+		newAuthorizationData("tag1", accepted),
+	})
+	s.Require().NoError(err)
+
+	loadedList := NewLocalAuthList(localListFile)
+	loadedList.LoadFromFile()
+	s.Require().EqualValues(5, loadedList.GetVersion())
+	s.Require().True(loadedList.IsTagAuthorized(authData.NewIdTag("tag1")))
+}
+
+func TestLocalAuthList(t *testing.T) {
+	suite.Run(t, new(LocalAuthListTestSuite))
+}
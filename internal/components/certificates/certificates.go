@@ -0,0 +1,528 @@
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	chargePointCertificateFile = "charge-point.pem"
+	chargePointKeyFile         = "charge-point.key"
+	pendingKeyFile             = "charge-point-pending.key"
+)
+
+var (
+	ErrInvalidCertificate         = errors.New("invalid certificate")
+	ErrCertificateNotFound        = errors.New("certificate not found")
+	ErrCertificateInUse           = errors.New("certificate in use")
+	ErrStoreFull                  = errors.New("certificate store is full")
+	ErrNoPendingKey               = errors.New("no certificate signing request pending")
+	ErrKeyMismatch                = errors.New("certificate does not match the private key")
+	ErrInvalidSigningCertificate  = errors.New("invalid signing certificate")
+	ErrInvalidSignature           = errors.New("invalid signature")
+	ErrUnsupportedHashAlgorithm   = errors.New("unsupported hash algorithm")
+	ErrUnsupportedPublicKeyFormat = errors.New("unsupported public key")
+)
+
+type (
+	// Manager stores the root certificates, installed by the central system, and the charge point certificate, signed by
+	// the central system. The root certificates are stored in a directory per use, named by the certificate fingerprint.
+	Manager interface {
+		SetMaxCertificates(maxCertificates int)
+		InstallRootCertificate(use securityExtension.CertificateUse, certificate string) error
+		DeleteRootCertificate(hashData securityExtension.CertificateHashData) error
+		GetRootCertificates(use securityExtension.CertificateUse) []securityExtension.CertificateHashData
+		AppendRootCertificates(pool *x509.CertPool, use securityExtension.CertificateUse)
+		GenerateCSR(commonName, organization string) (string, error)
+		InstallChargePointCertificate(certificateChain string) error
+		GetChargePointCertificate() (*tls.Certificate, error)
+		VerifySigningCertificate(certificate string) error
+		VerifyFirmware(path, signingCertificate, signature string) error
+	}
+
+	managerImpl struct {
+		mu              sync.Mutex
+		directory       string
+		maxCertificates int
+		pendingKey      *ecdsa.PrivateKey
+	}
+)
+
+func NewManager(directory string) Manager {
+	return &managerImpl{
+		mu:        sync.Mutex{},
+		directory: directory,
+	}
+}
+
+// SetMaxCertificates limits the number of the installed root certificates. Zero means there is no limit.
+func (m *managerImpl) SetMaxCertificates(maxCertificates int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxCertificates = maxCertificates
+}
+
+// InstallRootCertificate validates and stores the PEM encoded root certificate.
+func (m *managerImpl) InstallRootCertificate(use securityExtension.CertificateUse, certificate string) error {
+	certificates, err := parseCertificates(certificate)
+	if err != nil || len(certificates) != 1 || !isValid(certificates[0]) {
+		return ErrInvalidCertificate
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fileName := m.certificatePath(use, certificates[0])
+	if _, err := os.Stat(fileName); err == nil {
+		// Already installed
+		return nil
+	}
+
+	if m.maxCertificates > 0 && len(m.getCertificates(securityExtension.CentralSystemRootCertificate))+
+		len(m.getCertificates(securityExtension.ManufacturerRootCertificate)) >= m.maxCertificates {
+		return ErrStoreFull
+	}
+
+	err = os.MkdirAll(filepath.Dir(fileName), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Installing a %s", use)
+	return ioutil.WriteFile(fileName, encodeCertificate(certificates[0]), 0644)
+}
+
+// DeleteRootCertificate deletes the root certificate matching the hash data. The last central system root certificate cannot be deleted.
+func (m *managerImpl) DeleteRootCertificate(hashData securityExtension.CertificateHashData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, use := range []securityExtension.CertificateUse{securityExtension.CentralSystemRootCertificate, securityExtension.ManufacturerRootCertificate} {
+		certificates := m.getCertificates(use)
+
+		for _, certificate := range certificates {
+			certificateHashData, err := getHashData(certificate, hashData.HashAlgorithm)
+			if err != nil {
+				return err
+			}
+
+			if *certificateHashData != hashData {
+				continue
+			}
+
+			if use == securityExtension.CentralSystemRootCertificate && len(certificates) == 1 {
+				return ErrCertificateInUse
+			}
+
+			log.Infof("Deleting a %s", use)
+			return os.Remove(m.certificatePath(use, certificate))
+		}
+	}
+
+	return ErrCertificateNotFound
+}
+
+// GetRootCertificates returns the SHA256 hash data of the installed root certificates.
+func (m *managerImpl) GetRootCertificates(use securityExtension.CertificateUse) []securityExtension.CertificateHashData {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hashData := []securityExtension.CertificateHashData{}
+	for _, certificate := range m.getCertificates(use) {
+		certificateHashData, err := getHashData(certificate, securityExtension.SHA256)
+		if err == nil {
+			hashData = append(hashData, *certificateHashData)
+		}
+	}
+
+	return hashData
+}
+
+// AppendRootCertificates adds the installed root certificates to the pool.
+func (m *managerImpl) AppendRootCertificates(pool *x509.CertPool, use securityExtension.CertificateUse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, certificate := range m.getCertificates(use) {
+		pool.AddCert(certificate)
+	}
+}
+
+// GenerateCSR generates a new key pair and a PEM encoded certificate signing request. The private key is kept until the
+// signed certificate is installed, so the certificate can be installed after a restart.
+func (m *managerImpl) GenerateCSR(commonName, organization string) (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{organization},
+		},
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err = m.writePrivateKey(pendingKeyFile, key)
+	if err != nil {
+		return "", err
+	}
+
+	m.pendingKey = key
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})), nil
+}
+
+// InstallChargePointCertificate validates the signed certificate chain and stores it with the key of the pending CSR.
+func (m *managerImpl) InstallChargePointCertificate(certificateChain string) error {
+	certificates, err := parseCertificates(certificateChain)
+	if err != nil || len(certificates) == 0 || !isValid(certificates[0]) {
+		return ErrInvalidCertificate
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pendingKey == nil {
+		m.pendingKey = m.readPendingKey()
+	}
+
+	if m.pendingKey == nil {
+		return ErrNoPendingKey
+	}
+
+	publicKey, isEcdsa := certificates[0].PublicKey.(*ecdsa.PublicKey)
+	if !isEcdsa || !publicKey.Equal(m.pendingKey.Public()) {
+		return ErrKeyMismatch
+	}
+
+	// Verify the chain if the central system root certificates are installed
+	roots := x509.NewCertPool()
+	rootCertificates := m.getCertificates(securityExtension.CentralSystemRootCertificate)
+	if len(rootCertificates) > 0 {
+		intermediates := x509.NewCertPool()
+		for _, certificate := range certificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		for _, certificate := range rootCertificates {
+			roots.AddCert(certificate)
+		}
+
+		_, err = certificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+	}
+
+	var chain []byte
+	for _, certificate := range certificates {
+		chain = append(chain, encodeCertificate(certificate)...)
+	}
+
+	err = m.writePrivateKey(chargePointKeyFile, m.pendingKey)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(m.directory, chargePointCertificateFile), chain, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Installed a new charge point certificate")
+	m.pendingKey = nil
+
+	err = os.Remove(filepath.Join(m.directory, pendingKeyFile))
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warn("Unable to remove the pending private key")
+	}
+
+	return nil
+}
+
+// writePrivateKey stores the PEM encoded private key, readable only by the owner.
+func (m *managerImpl) writePrivateKey(fileName string, key *ecdsa.PrivateKey) error {
+	data, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(m.directory, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(m.directory, fileName)
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: data}), 0600)
+	if err != nil {
+		return err
+	}
+
+	// WriteFile does not change the permissions of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// readPendingKey reads the private key of the CSR sent before the restart. Returns nil if there is no pending CSR.
+func (m *managerImpl) readPendingKey() *ecdsa.PrivateKey {
+	data, err := ioutil.ReadFile(filepath.Join(m.directory, pendingKeyFile))
+	if err != nil {
+		return nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		log.WithError(err).Warn("Unable to parse the pending private key")
+		return nil
+	}
+
+	return key
+}
+
+// GetChargePointCertificate returns the installed charge point certificate with the parsed leaf.
+func (m *managerImpl) GetChargePointCertificate() (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	certificate, err := tls.LoadX509KeyPair(
+		filepath.Join(m.directory, chargePointCertificateFile),
+		filepath.Join(m.directory, chargePointKeyFile),
+	)
+	if os.IsNotExist(err) {
+		return nil, ErrCertificateNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+// VerifySigningCertificate checks if the firmware signing certificate was issued by one of the manufacturer root certificates.
+func (m *managerImpl) VerifySigningCertificate(certificate string) error {
+	_, err := m.verifySigningCertificate(certificate)
+	return err
+}
+
+// VerifyFirmware verifies the base64 encoded signature of the SHA256 digest of the firmware file.
+func (m *managerImpl) VerifyFirmware(path, signingCertificate, signature string) error {
+	certificate, err := m.verifySigningCertificate(signingCertificate)
+	if err != nil {
+		return err
+	}
+
+	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	digest := hash.Sum(nil)
+
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(publicKey, digest, decodedSignature) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, decodedSignature) != nil {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrUnsupportedPublicKeyFormat
+	}
+
+	return nil
+}
+
+func (m *managerImpl) verifySigningCertificate(signingCertificate string) (*x509.Certificate, error) {
+	certificates, err := parseCertificates(signingCertificate)
+	if err != nil || len(certificates) == 0 || !isValid(certificates[0]) {
+		return nil, ErrInvalidSigningCertificate
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	roots := x509.NewCertPool()
+	m.AppendRootCertificates(roots, securityExtension.ManufacturerRootCertificate)
+
+	_, err = certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSigningCertificate, err)
+	}
+
+	return certificates[0], nil
+}
+
+func (m *managerImpl) certificatePath(use securityExtension.CertificateUse, certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return filepath.Join(m.directory, string(use), fmt.Sprintf("%x.pem", fingerprint))
+}
+
+func (m *managerImpl) getCertificates(use securityExtension.CertificateUse) []*x509.Certificate {
+	var certificates []*x509.Certificate
+
+	files, err := filepath.Glob(filepath.Join(m.directory, string(use), "*.pem"))
+	if err != nil {
+		return certificates
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.WithError(err).Errorf("Unable to read the certificate %s", file)
+			continue
+		}
+
+		parsedCertificates, err := parseCertificates(string(data))
+		if err != nil {
+			log.WithError(err).Errorf("Unable to parse the certificate %s", file)
+			continue
+		}
+
+		certificates = append(certificates, parsedCertificates...)
+	}
+
+	return certificates
+}
+
+func parseCertificates(data string) ([]*x509.Certificate, error) {
+	var (
+		certificates []*x509.Certificate
+		rest         = []byte(strings.TrimSpace(data))
+		block        *pem.Block
+	)
+
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, ErrInvalidCertificate
+	}
+
+	return certificates, nil
+}
+
+func isValid(certificate *x509.Certificate) bool {
+	now := time.Now()
+	return now.After(certificate.NotBefore) && now.Before(certificate.NotAfter)
+}
+
+func encodeCertificate(certificate *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+}
+
+// getHashData calculates the hash data of a root certificate. Since the root certificate is self-signed, the issuer key
+// is the public key of the certificate.
+func getHashData(certificate *x509.Certificate, algorithm securityExtension.HashAlgorithm) (*securityExtension.CertificateHashData, error) {
+	var hashFunction crypto.Hash
+	switch algorithm {
+	case securityExtension.SHA256:
+		hashFunction = crypto.SHA256
+	case securityExtension.SHA384:
+		hashFunction = crypto.SHA384
+	case securityExtension.SHA512:
+		hashFunction = crypto.SHA512
+	default:
+		return nil, ErrUnsupportedHashAlgorithm
+	}
+
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+
+	_, err := asn1.Unmarshal(certificate.RawSubjectPublicKeyInfo, &publicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	nameHash := hashFunction.New()
+	nameHash.Write(certificate.RawIssuer)
+
+	keyHash := hashFunction.New()
+	keyHash.Write(publicKeyInfo.PublicKey.Bytes)
+
+	return &securityExtension.CertificateHashData{
+		HashAlgorithm:  algorithm,
+		IssuerNameHash: hex.EncodeToString(nameHash.Sum(nil)),
+		IssuerKeyHash:  hex.EncodeToString(keyHash.Sum(nil)),
+		SerialNumber:   certificate.SerialNumber.Text(16),
+	}, nil
+}
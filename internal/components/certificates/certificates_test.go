@@ -0,0 +1,207 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/suite"
+	securityExtension "github.com/xBlaz3kx/ChargePi-go/pkg/security-extension"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type certificatesTestSuite struct {
+	suite.Suite
+	manager Manager
+}
+
+func createCertificate(commonName string, serialNumber int64, publicKey interface{}, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serialNumber),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	if parent == nil {
+		parent = template
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, publicKey, parentKey)
+	if err != nil {
+		panic(err)
+	}
+
+	certificate, err := x509.ParseCertificate(raw)
+	if err != nil {
+		panic(err)
+	}
+
+	return certificate
+}
+
+func createRootCertificate(commonName string, serialNumber int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return createCertificate(commonName, serialNumber, &key.PublicKey, nil, key), key
+}
+
+func (s *certificatesTestSuite) SetupTest() {
+	s.manager = NewManager(s.T().TempDir())
+}
+
+func (s *certificatesTestSuite) TestInstallRootCertificate() {
+	root, _ := createRootCertificate("Central system", 1)
+	manufacturerRoot, _ := createRootCertificate("Manufacturer", 2)
+
+	s.Assert().NoError(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(root))))
+	s.Assert().NoError(s.manager.InstallRootCertificate(securityExtension.ManufacturerRootCertificate, string(encodeCertificate(manufacturerRoot))))
+
+	hashData := s.manager.GetRootCertificates(securityExtension.CentralSystemRootCertificate)
+	s.Require().Len(hashData, 1)
+	s.Assert().EqualValues(securityExtension.SHA256, hashData[0].HashAlgorithm)
+	s.Assert().EqualValues("1", hashData[0].SerialNumber)
+	s.Assert().Len(s.manager.GetRootCertificates(securityExtension.ManufacturerRootCertificate), 1)
+
+	// Installing the same certificate again has no effect
+	s.Assert().NoError(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(root))))
+	s.Assert().Len(s.manager.GetRootCertificates(securityExtension.CentralSystemRootCertificate), 1)
+
+	// Invalid certificate
+	s.Assert().ErrorIs(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, "invalid"), ErrInvalidCertificate)
+
+	// The store is full
+	s.manager.SetMaxCertificates(2)
+	anotherRoot, _ := createRootCertificate("Another central system", 3)
+	s.Assert().ErrorIs(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(anotherRoot))), ErrStoreFull)
+}
+
+func (s *certificatesTestSuite) TestDeleteRootCertificate() {
+	root, _ := createRootCertificate("Central system", 1)
+	anotherRoot, _ := createRootCertificate("Another central system", 2)
+	s.Require().NoError(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(root))))
+	s.Require().NoError(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(anotherRoot))))
+
+	hashData, err := getHashData(root, securityExtension.SHA256)
+	s.Require().NoError(err)
+
+	s.Assert().NoError(s.manager.DeleteRootCertificate(*hashData))
+	s.Assert().ErrorIs(s.manager.DeleteRootCertificate(*hashData), ErrCertificateNotFound)
+
+	// The last central system root certificate cannot be deleted
+	hashData, err = getHashData(anotherRoot, securityExtension.SHA384)
+	s.Require().NoError(err)
+	s.Assert().ErrorIs(s.manager.DeleteRootCertificate(*hashData), ErrCertificateInUse)
+}
+
+func (s *certificatesTestSuite) TestInstallChargePointCertificate() {
+	root, rootKey := createRootCertificate("Central system", 1)
+	s.Require().NoError(s.manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(root))))
+
+	// No CSR was generated
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(string(encodeCertificate(root))), ErrNoPendingKey)
+
+	csrPem, err := s.manager.GenerateCSR("ChargePoint001", "ChargePi")
+	s.Require().NoError(err)
+
+	block, _ := pem.Decode([]byte(csrPem))
+	s.Require().NotNil(block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("ChargePoint001", csr.Subject.CommonName)
+	s.Assert().EqualValues([]string{"ChargePi"}, csr.Subject.Organization)
+
+	// The certificate is not signed for the generated key
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherCertificate := createCertificate("ChargePoint001", 10, &otherKey.PublicKey, root, rootKey)
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(string(encodeCertificate(otherCertificate))), ErrKeyMismatch)
+
+	// The certificate is not signed by the central system
+	anotherRoot, anotherRootKey := createRootCertificate("Another central system", 2)
+	untrustedCertificate := createCertificate("ChargePoint001", 11, csr.PublicKey, anotherRoot, anotherRootKey)
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(string(encodeCertificate(untrustedCertificate))), ErrInvalidCertificate)
+
+	_, err = s.manager.GetChargePointCertificate()
+	s.Assert().ErrorIs(err, ErrCertificateNotFound)
+
+	certificate := createCertificate("ChargePoint001", 12, csr.PublicKey, root, rootKey)
+	s.Assert().NoError(s.manager.InstallChargePointCertificate(string(encodeCertificate(certificate))))
+
+	chargePointCertificate, err := s.manager.GetChargePointCertificate()
+	s.Require().NoError(err)
+	s.Assert().EqualValues("ChargePoint001", chargePointCertificate.Leaf.Subject.CommonName)
+}
+
+func (s *certificatesTestSuite) TestInstallChargePointCertificateAfterRestart() {
+	directory := s.T().TempDir()
+	manager := NewManager(directory)
+
+	root, rootKey := createRootCertificate("Central system", 1)
+	s.Require().NoError(manager.InstallRootCertificate(securityExtension.CentralSystemRootCertificate, string(encodeCertificate(root))))
+
+	csrPem, err := manager.GenerateCSR("ChargePoint001", "ChargePi")
+	s.Require().NoError(err)
+
+	// The private key of the CSR is readable only by the owner
+	info, err := os.Stat(filepath.Join(directory, pendingKeyFile))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(os.FileMode(0600), info.Mode().Perm())
+
+	block, _ := pem.Decode([]byte(csrPem))
+	s.Require().NotNil(block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	s.Require().NoError(err)
+
+	// The central system signs the certificate after the restart
+	manager = NewManager(directory)
+	certificate := createCertificate("ChargePoint001", 12, csr.PublicKey, root, rootKey)
+	s.Require().NoError(manager.InstallChargePointCertificate(string(encodeCertificate(certificate))))
+
+	info, err = os.Stat(filepath.Join(directory, chargePointKeyFile))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(os.FileMode(0600), info.Mode().Perm())
+
+	_, err = os.Stat(filepath.Join(directory, pendingKeyFile))
+	s.Assert().True(os.IsNotExist(err))
+}
+
+func (s *certificatesTestSuite) TestVerifyFirmware() {
+	var (
+		manufacturerRoot, manufacturerKey = createRootCertificate("Manufacturer", 1)
+		signingKey, _                     = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		signingCertificate                = string(encodeCertificate(createCertificate("Firmware signing", 2, &signingKey.PublicKey, manufacturerRoot, manufacturerKey)))
+		firmwarePath                      = filepath.Join(s.T().TempDir(), "firmware.bin")
+		firmware                          = []byte("firmware")
+		digest                            = sha256.Sum256(firmware)
+	)
+
+	s.Require().NoError(ioutil.WriteFile(firmwarePath, firmware, 0644))
+	signature, err := ecdsa.SignASN1(rand.Reader, signingKey, digest[:])
+	s.Require().NoError(err)
+
+	// The manufacturer root certificate is not installed
+	s.Assert().ErrorIs(s.manager.VerifySigningCertificate(signingCertificate), ErrInvalidSigningCertificate)
+
+	s.Require().NoError(s.manager.InstallRootCertificate(securityExtension.ManufacturerRootCertificate, string(encodeCertificate(manufacturerRoot))))
+	s.Assert().NoError(s.manager.VerifySigningCertificate(signingCertificate))
+	s.Assert().NoError(s.manager.VerifyFirmware(firmwarePath, signingCertificate, base64.StdEncoding.EncodeToString(signature)))
+
+	// Invalid signature
+	s.Assert().ErrorIs(s.manager.VerifyFirmware(firmwarePath, signingCertificate, base64.StdEncoding.EncodeToString([]byte("invalid"))), ErrInvalidSignature)
+	s.Assert().ErrorIs(s.manager.VerifyFirmware(firmwarePath, signingCertificate, "invalid base64"), ErrInvalidSignature)
+}
+
+func TestCertificates(t *testing.T) {
+	suite.Run(t, new(certificatesTestSuite))
+}
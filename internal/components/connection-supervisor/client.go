@@ -0,0 +1,444 @@
+package connectionSupervisor
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrNotConnected = errors.New("client is currently not connected, cannot send data")
+
+// Client is a websocket client compatible with the OCPP clients. Unlike the websocket client of the OCPP library, it
+// can dial the central system before the OCPP client is created and exposes the subprotocol the central system accepted,
+// so the charge point can choose the protocol version on the same connection. The dialed connection is used when the
+// OCPP client starts with the same url.
+type Client struct {
+	mu             sync.Mutex
+	dialOptions    []func(*websocket.Dialer)
+	subprotocols   []string
+	header         http.Header
+	timeoutConfig  ws.ClientTimeoutConfig
+	messageHandler func(data []byte) error
+	onDisconnected func(err error)
+	onReconnected  func()
+	url            string
+	connection     *websocket.Conn
+	subprotocol    string
+	isStarted      bool
+	isConnected    bool
+	outQueue       chan []byte
+	closeSignal    chan error
+	stopSignal     chan struct{}
+	done           chan struct{}
+	errC           chan error
+}
+
+// NewClient creates a websocket client. If the TLS configuration is provided, the client uses it for the secure websockets.
+func NewClient(tlsConfig *tls.Config) *Client {
+	client := &Client{
+		mu:            sync.Mutex{},
+		dialOptions:   []func(*websocket.Dialer){},
+		header:        http.Header{},
+		timeoutConfig: ws.NewClientTimeoutConfig(),
+	}
+
+	if tlsConfig != nil {
+		client.dialOptions = append(client.dialOptions, func(dialer *websocket.Dialer) {
+			dialer.TLSClientConfig = tlsConfig
+		})
+	}
+
+	return client
+}
+
+// SetSubprotocols sets the subprotocols offered to the central system, in the order of preference. The subprotocols
+// override the ones added by the OCPP client. Without them, the subprotocols of the OCPP client are offered.
+func (c *Client) SetSubprotocols(subprotocols ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subprotocols = subprotocols
+}
+
+// Subprotocol returns the subprotocol the central system accepted on the last connection.
+func (c *Client) Subprotocol() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subprotocol
+}
+
+// Dial connects to the central system without processing the messages. The connection is used by Start with the same url.
+func (c *Client) Dial(url string) error {
+	connection, err := c.dial(url)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isStarted {
+		_ = connection.Close()
+		return errors.New("client is already started")
+	}
+
+	if c.connection != nil {
+		_ = c.connection.Close()
+	}
+
+	c.url = url
+	c.connection = connection
+	return nil
+}
+
+// Start connects to the central system, unless the client already dialed the url, and starts processing the messages.
+func (c *Client) Start(url string) error {
+	c.mu.Lock()
+	connection := c.connection
+	if connection != nil && c.url != url {
+		_ = connection.Close()
+		connection = nil
+	}
+	c.connection = nil
+	c.mu.Unlock()
+
+	if connection == nil {
+		var err error
+		connection, err = c.dial(url)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.run(url, connection)
+	return nil
+}
+
+// Stop closes the connection to the central system with a normal closure. The disconnected handler is not called.
+func (c *Client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connection != nil {
+		_ = c.connection.Close()
+		c.connection = nil
+	}
+
+	if c.isStarted {
+		c.isStarted = false
+		c.isConnected = false
+		close(c.stopSignal)
+	}
+
+	if c.errC != nil {
+		close(c.errC)
+		c.errC = nil
+	}
+}
+
+func (c *Client) Errors() <-chan error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.errC == nil {
+		c.errC = make(chan error, 1)
+	}
+
+	return c.errC
+}
+
+func (c *Client) SetMessageHandler(handler func(data []byte) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messageHandler = handler
+}
+
+func (c *Client) SetTimeoutConfig(config ws.ClientTimeoutConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeoutConfig = config
+}
+
+func (c *Client) SetDisconnectedHandler(handler func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDisconnected = handler
+}
+
+func (c *Client) SetReconnectedHandler(handler func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReconnected = handler
+}
+
+func (c *Client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isConnected
+}
+
+// Write queues the message, which is sent to the central system in the background. If the connection is closed before
+// the message is queued, ErrNotConnected is returned.
+func (c *Client) Write(data []byte) error {
+	c.mu.Lock()
+	isConnected, outQueue, done := c.isConnected, c.outQueue, c.done
+	c.mu.Unlock()
+
+	if !isConnected {
+		return ErrNotConnected
+	}
+
+	select {
+	case outQueue <- data:
+		return nil
+	case <-done:
+		return ErrNotConnected
+	}
+}
+
+func (c *Client) AddOption(option interface{}) {
+	dialOption, ok := option.(func(*websocket.Dialer))
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialOptions = append(c.dialOptions, dialOption)
+}
+
+func (c *Client) SetBasicAuth(username string, password string) {
+	c.SetHeaderValue("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
+func (c *Client) SetHeaderValue(key string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header.Set(key, value)
+}
+
+func (c *Client) dial(url string) (*websocket.Conn, error) {
+	c.mu.Lock()
+	dialer := websocket.Dialer{
+		ReadBufferSize:   1024,
+		WriteBufferSize:  1024,
+		HandshakeTimeout: c.timeoutConfig.HandshakeTimeout,
+		Subprotocols:     []string{},
+	}
+
+	for _, option := range c.dialOptions {
+		option(&dialer)
+	}
+
+	if len(c.subprotocols) > 0 {
+		dialer.Subprotocols = c.subprotocols
+	}
+
+	header := c.header.Clone()
+	c.mu.Unlock()
+
+	connection, response, err := dialer.Dial(url, header)
+	if err != nil {
+		if response != nil {
+			httpError := ws.HttpConnectionError{Message: err.Error(), HttpStatus: response.Status, HttpCode: response.StatusCode}
+			defer response.Body.Close()
+
+			if body, _ := ioutil.ReadAll(response.Body); body != nil {
+				httpError.Details = string(body)
+			}
+
+			return nil, httpError
+		}
+
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.subprotocol = connection.Subprotocol()
+	c.mu.Unlock()
+
+	return connection, nil
+}
+
+// run starts processing the messages of the connection.
+func (c *Client) run(url string, connection *websocket.Conn) {
+	var (
+		outQueue    = make(chan []byte)
+		closeSignal = make(chan error, 1)
+		stopSignal  = make(chan struct{})
+		done        = make(chan struct{})
+	)
+
+	c.mu.Lock()
+	c.url = url
+	c.outQueue = outQueue
+	c.closeSignal = closeSignal
+	c.stopSignal = stopSignal
+	c.done = done
+	c.isStarted = true
+	c.isConnected = true
+	c.mu.Unlock()
+
+	go c.writePump(connection, outQueue, closeSignal, stopSignal, done)
+	go c.readPump(connection, closeSignal)
+}
+
+// writePump sends the queued messages until the connection is closed or the client is stopped. The done channel is
+// closed when the pump exits, so the writers waiting for the pump are released. The message queue is never closed,
+// as the writers may still use it.
+func (c *Client) writePump(connection *websocket.Conn, outQueue chan []byte, closeSignal chan error, stopSignal, done chan struct{}) {
+	timeoutConfig := c.getTimeoutConfig()
+	ticker := time.NewTicker(timeoutConfig.PingPeriod)
+
+	// Close the connection and notify the handler, unless the client was stopped
+	closure := func(err error) {
+		ticker.Stop()
+		_ = connection.Close()
+
+		c.mu.Lock()
+		c.isConnected = false
+		handler := c.onDisconnected
+		c.mu.Unlock()
+		close(done)
+
+		if handler != nil && err != nil {
+			handler(err)
+		}
+	}
+
+	for {
+		select {
+		case <-stopSignal:
+			_ = connection.SetWriteDeadline(time.Now().Add(timeoutConfig.WriteWait))
+			err := connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			if err != nil {
+				c.error(fmt.Errorf("close failed: %w", err))
+			}
+
+			closure(nil)
+			return
+		case data := <-outQueue:
+			_ = connection.SetWriteDeadline(time.Now().Add(timeoutConfig.WriteWait))
+			err := connection.WriteMessage(websocket.TextMessage, data)
+			if err != nil {
+				c.error(fmt.Errorf("write failed: %w", err))
+				closure(err)
+				c.reconnect()
+				return
+			}
+		case <-ticker.C:
+			_ = connection.SetWriteDeadline(time.Now().Add(timeoutConfig.WriteWait))
+			if err := connection.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+				c.error(fmt.Errorf("write failed: %w", err))
+				closure(err)
+				c.reconnect()
+				return
+			}
+		case err := <-closeSignal:
+			closure(err)
+			c.reconnect()
+			return
+		}
+	}
+}
+
+func (c *Client) readPump(connection *websocket.Conn, closeSignal chan error) {
+	pongWait := c.getTimeoutConfig().PongWait
+
+	_ = connection.SetReadDeadline(time.Now().Add(pongWait))
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, message, err := connection.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
+				c.error(fmt.Errorf("read failed: %w", err))
+			}
+
+			// The write pump handles the disconnect
+			closeSignal <- err
+			return
+		}
+
+		c.mu.Lock()
+		handler := c.messageHandler
+		c.mu.Unlock()
+
+		if handler != nil {
+			if err = handler(message); err != nil {
+				c.error(fmt.Errorf("handle failed: %w", err))
+			}
+		}
+	}
+}
+
+// reconnect redials the central system with the backoff from the timeout configuration, until the client is stopped.
+func (c *Client) reconnect() {
+	timeoutConfig := c.getTimeoutConfig()
+	delay := timeoutConfig.ReconnectBackoff
+
+	for {
+		time.Sleep(delay)
+
+		c.mu.Lock()
+		url, isStarted := c.url, c.isStarted
+		c.mu.Unlock()
+
+		if !isStarted {
+			return
+		}
+
+		connection, err := c.dial(url)
+		if err == nil {
+			c.mu.Lock()
+			isStarted = c.isStarted
+			handler := c.onReconnected
+			c.mu.Unlock()
+
+			if !isStarted {
+				_ = connection.Close()
+				return
+			}
+
+			c.run(url, connection)
+			if handler != nil {
+				handler()
+			}
+
+			return
+		}
+
+		delay *= 2
+		if delay >= timeoutConfig.ReconnectMaxBackoff {
+			delay = timeoutConfig.ReconnectMaxBackoff
+		}
+	}
+}
+
+func (c *Client) getTimeoutConfig() ws.ClientTimeoutConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.timeoutConfig
+}
+
+func (c *Client) error(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.errC == nil {
+		return
+	}
+
+	select {
+	case c.errC <- err:
+	default:
+	}
+}
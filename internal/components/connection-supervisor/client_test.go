@@ -0,0 +1,189 @@
+package connectionSupervisor
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ClientTestSuite struct {
+	suite.Suite
+	serverUrl   string
+	mu          sync.Mutex
+	connections []*websocket.Conn
+}
+
+// SetupTest creates a central system, which accepts the ocpp1.6 subprotocol and echoes the messages.
+func (s *ClientTestSuite) SetupTest() {
+	s.mu.Lock()
+	s.connections = nil
+	s.mu.Unlock()
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{"ocpp1.6"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.connections = append(s.connections, conn)
+		s.mu.Unlock()
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			_ = conn.WriteMessage(messageType, message)
+		}
+	}))
+	s.T().Cleanup(server.Close)
+
+	s.serverUrl = strings.Replace(server.URL, "http", "ws", 1) + "/ChargePi"
+}
+
+func (s *ClientTestSuite) getConnections() []*websocket.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func (s *ClientTestSuite) TestDial() {
+	client := NewClient(nil)
+	defer client.Stop()
+
+	client.SetSubprotocols("ocpp2.0.1", "ocpp1.6")
+	s.Require().NoError(client.Dial(s.serverUrl))
+	s.Assert().EqualValues("ocpp1.6", client.Subprotocol())
+	s.Assert().False(client.IsConnected())
+
+	// The dialed connection is used after the start
+	s.Require().NoError(client.Start(s.serverUrl))
+	s.Assert().True(client.IsConnected())
+	s.Assert().Len(s.getConnections(), 1)
+
+	// Only the subprotocols from the client are offered
+	client.Stop()
+	client.SetSubprotocols("ocpp2.0.1")
+	s.Require().NoError(client.Start(s.serverUrl))
+	s.Assert().Empty(client.Subprotocol())
+}
+
+func (s *ClientTestSuite) TestWrite() {
+	client := NewClient(nil)
+	defer client.Stop()
+
+	messages := make(chan string, 1)
+	client.SetMessageHandler(func(data []byte) error {
+		messages <- string(data)
+		return nil
+	})
+
+	s.Assert().ErrorIs(client.Write([]byte("message")), ErrNotConnected)
+
+	s.Require().NoError(client.Start(s.serverUrl))
+	s.Require().NoError(client.Write([]byte("message")))
+
+	select {
+	case message := <-messages:
+		s.Assert().EqualValues("message", message)
+	case <-time.After(time.Second):
+		s.Fail("the message was not received")
+	}
+}
+
+func (s *ClientTestSuite) TestWriteAfterStop() {
+	client := NewClient(nil)
+
+	received := make(chan struct{}, 1)
+	client.SetMessageHandler(func(data []byte) error {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	s.Require().NoError(client.Start(s.serverUrl))
+
+	var (
+		wg      sync.WaitGroup
+		written = make(chan struct{})
+	)
+
+	// The writers are released when the client stops instead of blocking or panicking
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for client.Write([]byte("message")) == nil {
+			}
+		}()
+	}
+
+	// Stop the client while the writers are sending the messages
+	<-received
+	client.Stop()
+
+	go func() {
+		wg.Wait()
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		s.Assert().ErrorIs(client.Write([]byte("message")), ErrNotConnected)
+	case <-time.After(time.Second):
+		s.Fail("the writers are blocked")
+	}
+}
+
+func (s *ClientTestSuite) TestReconnect() {
+	client := NewClient(nil)
+	defer client.Stop()
+
+	timeoutConfig := ws.NewClientTimeoutConfig()
+	timeoutConfig.ReconnectBackoff = time.Millisecond * 50
+	client.SetTimeoutConfig(timeoutConfig)
+
+	var (
+		disconnected = make(chan error, 1)
+		reconnected  = make(chan struct{}, 1)
+	)
+	client.SetDisconnectedHandler(func(err error) {
+		disconnected <- err
+	})
+	client.SetReconnectedHandler(func() {
+		reconnected <- struct{}{}
+	})
+
+	s.Require().NoError(client.Start(s.serverUrl))
+	_ = s.getConnections()[0].Close()
+
+	select {
+	case err := <-disconnected:
+		s.Assert().Error(err)
+	case <-time.After(time.Second):
+		s.Fail("the client did not disconnect")
+	}
+
+	select {
+	case <-reconnected:
+		s.Assert().True(client.IsConnected())
+		s.Assert().Len(s.getConnections(), 2)
+	case <-time.After(time.Second):
+		s.Fail("the client did not reconnect")
+	}
+}
+
+func TestClient(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
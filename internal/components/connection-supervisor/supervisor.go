@@ -0,0 +1,187 @@
+package connectionSupervisor
+
+import (
+	"context"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	MinBackoff = 5 * time.Second
+	MaxBackoff = 5 * time.Minute
+)
+
+var (
+	randomMu = sync.Mutex{}
+	random   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+type (
+	// StateHandler is called when the charge point goes online or offline.
+	StateHandler func(isOnline bool)
+
+	// Supervisor wraps the websocket client and keeps the charge point connected to the central system. The initial
+	// connection is retried with an exponential backoff, while the reconnects are handled by the websocket client
+	// with a new random backoff after every disconnect.
+	// The state handlers are notified after every connect, disconnect and reconnect.
+	Supervisor interface {
+		ws.WsClient
+		Connect(ctx context.Context, connect func() error) error
+		IsStarted() bool
+		AddStateHandler(handler StateHandler)
+	}
+
+	supervisorImpl struct {
+		*Client
+		mu             sync.Mutex
+		timeoutConfig  ws.ClientTimeoutConfig
+		isStarted      bool
+		onDisconnected func(err error)
+		onReconnected  func()
+		stateHandlers  []StateHandler
+	}
+)
+
+// NewSupervisor creates a supervisor for the websocket client and sets the timeout configuration of the client with the
+// reconnect backoff. The supervisor must be passed to the OCPP client instead of the websocket client.
+func NewSupervisor(client *Client, timeoutConfig ws.ClientTimeoutConfig) Supervisor {
+	supervisor := &supervisorImpl{
+		Client:        client,
+		mu:            sync.Mutex{},
+		timeoutConfig: timeoutConfig,
+		stateHandlers: []StateHandler{},
+	}
+
+	supervisor.timeoutConfig.ReconnectMaxBackoff = MaxBackoff
+	supervisor.updateBackoff()
+
+	client.SetDisconnectedHandler(supervisor.disconnected)
+	client.SetReconnectedHandler(supervisor.reconnected)
+	return supervisor
+}
+
+// Connect tries to connect to the central system until the connection succeeds or the context is cancelled.
+func (s *supervisorImpl) Connect(ctx context.Context, connect func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := connect()
+		if err == nil {
+			s.mu.Lock()
+			s.isStarted = true
+			s.mu.Unlock()
+
+			s.notify(true)
+			return nil
+		}
+
+		delay := GetBackoff(attempt)
+		log.WithError(err).Warnf("Cannot connect to the central system, retrying in %s", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// IsStarted returns true if the client connected to the central system at least once. Until then, the client cannot be stopped.
+func (s *supervisorImpl) IsStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isStarted
+}
+
+// SetDisconnectedHandler sets the handler of the OCPP client. The handler is called before the state handlers.
+func (s *supervisorImpl) SetDisconnectedHandler(handler func(err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDisconnected = handler
+}
+
+// SetReconnectedHandler sets the handler of the OCPP client. The handler is called before the state handlers.
+func (s *supervisorImpl) SetReconnectedHandler(handler func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onReconnected = handler
+}
+
+// AddStateHandler adds a handler, which is notified when the charge point goes online or offline.
+func (s *supervisorImpl) AddStateHandler(handler StateHandler) {
+	if handler == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateHandlers = append(s.stateHandlers, handler)
+}
+
+// updateBackoff randomizes the reconnect backoff, so the charge points do not reconnect at the same time. The websocket
+// client reads the backoff after the disconnected handler and doubles it on every failed attempt.
+func (s *supervisorImpl) updateBackoff() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timeoutConfig.ReconnectBackoff = GetBackoff(0)
+	s.Client.SetTimeoutConfig(s.timeoutConfig)
+}
+
+func (s *supervisorImpl) disconnected(err error) {
+	log.WithError(err).Warn("Disconnected from the central system")
+	s.updateBackoff()
+
+	s.mu.Lock()
+	handler := s.onDisconnected
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+
+	s.notify(false)
+}
+
+func (s *supervisorImpl) reconnected() {
+	log.Info("Reconnected to the central system")
+
+	s.mu.Lock()
+	handler := s.onReconnected
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler()
+	}
+
+	s.notify(true)
+}
+
+func (s *supervisorImpl) notify(isOnline bool) {
+	s.mu.Lock()
+	handlers := make([]StateHandler, len(s.stateHandlers))
+	copy(handlers, s.stateHandlers)
+	s.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(isOnline)
+	}
+}
+
+// GetBackoff returns the delay before the next connection attempt. The delay doubles with every attempt up to the MaxBackoff,
+// and is randomized between half and the full delay, so the charge points do not reconnect at the same time.
+func GetBackoff(attempt int) time.Duration {
+	delay := MaxBackoff
+	if attempt >= 0 && attempt < 16 {
+		delay = MinBackoff << attempt
+	}
+
+	if delay > MaxBackoff {
+		delay = MaxBackoff
+	}
+
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	return delay/2 + time.Duration(random.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,106 @@
+package connectionSupervisor
+
+import (
+	"context"
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type SupervisorTestSuite struct {
+	suite.Suite
+	supervisor *supervisorImpl
+	states     []bool
+}
+
+func (s *SupervisorTestSuite) SetupTest() {
+	s.states = nil
+	s.supervisor = NewSupervisor(NewClient(nil), ws.NewClientTimeoutConfig()).(*supervisorImpl)
+	s.supervisor.AddStateHandler(func(isOnline bool) {
+		s.states = append(s.states, isOnline)
+	})
+}
+
+func (s *SupervisorTestSuite) TestConnect() {
+	attempts := 0
+	err := s.supervisor.Connect(context.Background(), func() error {
+		attempts++
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, attempts)
+	s.Require().True(s.supervisor.IsStarted())
+	s.Require().EqualValues([]bool{true}, s.states)
+}
+
+func (s *SupervisorTestSuite) TestConnectCancelled() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	attempts := 0
+	err := s.supervisor.Connect(ctx, func() error {
+		attempts++
+		return errors.New("connection refused")
+	})
+	s.Require().ErrorIs(err, context.DeadlineExceeded)
+	s.Require().EqualValues(1, attempts)
+	s.Require().False(s.supervisor.IsStarted())
+	s.Require().Empty(s.states)
+}
+
+func (s *SupervisorTestSuite) TestHandlers() {
+	var disconnected, reconnected bool
+
+	s.supervisor.SetDisconnectedHandler(func(err error) {
+		disconnected = true
+		// The OCPP client must be notified before the state handlers
+		s.Require().Empty(s.states)
+	})
+	s.supervisor.SetReconnectedHandler(func() {
+		reconnected = true
+	})
+
+	s.supervisor.disconnected(errors.New("connection lost"))
+	s.supervisor.reconnected()
+
+	s.Require().True(disconnected)
+	s.Require().True(reconnected)
+	s.Require().EqualValues([]bool{false, true}, s.states)
+}
+
+func (s *SupervisorTestSuite) TestReconnectBackoff() {
+	backoffs := map[time.Duration]bool{}
+
+	for i := 0; i < 10; i++ {
+		s.supervisor.disconnected(errors.New("connection lost"))
+
+		s.supervisor.mu.Lock()
+		backoff := s.supervisor.timeoutConfig.ReconnectBackoff
+		s.Require().EqualValues(MaxBackoff, s.supervisor.timeoutConfig.ReconnectMaxBackoff)
+		s.supervisor.mu.Unlock()
+
+		s.Require().GreaterOrEqual(backoff, MinBackoff/2)
+		s.Require().LessOrEqual(backoff, MinBackoff)
+		backoffs[backoff] = true
+	}
+
+	// The backoff is calculated after every disconnect
+	s.Assert().Greater(len(backoffs), 1)
+}
+
+func (s *SupervisorTestSuite) TestGetBackoff() {
+	for attempt := 0; attempt < 100; attempt++ {
+		delay := GetBackoff(attempt)
+		s.Require().GreaterOrEqual(delay, MinBackoff/2)
+		s.Require().LessOrEqual(delay, MaxBackoff)
+	}
+
+	s.Require().LessOrEqual(GetBackoff(0), MinBackoff)
+	s.Require().GreaterOrEqual(GetBackoff(20), MaxBackoff/2)
+}
+
+func TestSupervisor(t *testing.T) {
+	suite.Run(t, new(SupervisorTestSuite))
+}
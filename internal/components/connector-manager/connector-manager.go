@@ -9,6 +9,7 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware"
 	powerMeter "github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/power-meter"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
@@ -250,7 +251,9 @@ func (m *managerImpl) AddConnectorFromSettings(maxChargingTime int, c *settings.
 			c.Relay.InverseLogic,
 		)
 		// Create a PowerMeter from connector settings
-		meter, powerMeterErr = powerMeter.NewPowerMeter(c.PowerMeter)
+		meter, powerMeterErr = powerMeter.NewPowerMeter(c.PowerMeter, func(state settings.EnergyRegister) {
+			settingsManager.UpdateConnectorEnergyRegister(c.EvseId, c.ConnectorId, state)
+		})
 	)
 
 	if powerMeterErr != nil {
@@ -271,6 +274,11 @@ func (m *managerImpl) AddConnectorFromSettings(maxChargingTime int, c *settings.
 		return err
 	}
 
+	// The connectors with an active session become unavailable after the transaction ends
+	if core.AvailabilityType(c.Availability) == core.AvailabilityTypeInoperative && !c.Session.IsActive {
+		connectorObj.SetAvailability(core.AvailabilityTypeInoperative)
+	}
+
 	return m.AddConnector(connectorObj)
 }
 
@@ -312,8 +320,13 @@ func (m *managerImpl) RestoreConnectorStatus(c *settings.Connector) error {
 	connectorPreviousStatus := core.ChargePointStatus(c.Status)
 	conn.SetStatus(connectorPreviousStatus, core.NoError)
 
+	// Schedule the availability change after the restored transaction
+	if core.AvailabilityType(c.Availability) == core.AvailabilityTypeInoperative {
+		defer conn.SetAvailability(core.AvailabilityTypeInoperative)
+	}
+
 	switch connectorPreviousStatus {
-	case core.ChargePointStatusAvailable:
+	case core.ChargePointStatusAvailable, core.ChargePointStatusUnavailable:
 		return nil
 	case core.ChargePointStatusReserved,
 		core.ChargePointStatusFinishing,
@@ -34,7 +34,7 @@ func CreateNewConnectorMock(evseId, connectorId int, session session.Session) *t
 	connector1.On("GetReservationId", 123).Return(0)
 	connector1.On("GetConnectorId").Return(connectorId)
 	connector1.On("GetEvseId").Return(evseId)
-	connector1.On("CalculateSessionAvgEnergyConsumption").Return(30.0)
+	connector1.On("GetMeterReading").Return(30)
 	connector1.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
 	connector1.On("IsAvailable").Return(true)
 	connector1.On("IsPreparing").Return(false)
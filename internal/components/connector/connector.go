@@ -17,6 +17,8 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -33,17 +35,21 @@ var (
 
 type (
 	connectorImpl struct {
-		mu                           sync.Mutex
-		EvseId                       int
-		ConnectorId                  int
-		ConnectorType                string
-		ConnectorStatus              core.ChargePointStatus
-		ErrorCode                    core.ChargePointErrorCode
-		relay                        hardware.Relay
-		powerMeter                   powerMeter.PowerMeter
-		PowerMeterEnabled            bool
-		MaxChargingTime              int
-		reservationId                int
+		mu                sync.Mutex
+		EvseId            int
+		ConnectorId       int
+		ConnectorType     string
+		ConnectorStatus   core.ChargePointStatus
+		ErrorCode         core.ChargePointErrorCode
+		availability      core.AvailabilityType
+		relay             hardware.Relay
+		powerMeter        powerMeter.PowerMeter
+		PowerMeterEnabled bool
+		MaxChargingTime   int
+		reservationId     int
+		chargingLimit     *float64
+		// The charging was suspended for the rest of the transaction
+		isChargingBlocked            bool
 		session                      *session.Session
 		ConnectorNotificationChannel chan<- rxgo.Item
 		meterValuesChannel           chan<- models.MeterValueNotification
@@ -60,19 +66,29 @@ type (
 		GetReservationId() int
 		GetTagId() string
 		GetTransactionId() string
+		SetTransactionId(transactionId string)
 		GetConnectorId() int
 		GetEvseId() int
-		CalculateSessionAvgEnergyConsumption() float64
+		GetMeterReading() int
 		SamplePowerMeter(measurands []types.Measurand)
+		GetMeterValue(measurands []types.Measurand, readingContext types.ReadingContext) *types.MeterValue
+		AddTransactionData(meterValues ...types.MeterValue)
 		SetStatus(status core.ChargePointStatus, errCode core.ChargePointErrorCode)
+		SetAvailability(availability core.AvailabilityType) bool
+		GetAvailability() core.AvailabilityType
 		GetStatus() (core.ChargePointStatus, core.ChargePointErrorCode)
 		IsAvailable() bool
 		IsPreparing() bool
 		IsCharging() bool
 		IsReserved() bool
 		IsUnavailable() bool
+		IsSuspended() bool
 		GetPowerMeter() powerMeter.PowerMeter
 		GetMaxChargingTime() int
+		GetSession() session.Session
+		SetChargingLimit(limit *float64)
+		GetChargingLimit() *float64
+		SuspendCharging() error
 	}
 )
 
@@ -116,12 +132,13 @@ func NewConnector(evseId int, connectorId int, connectorType string, relay hardw
 		PowerMeterEnabled: powerMeterEnabled,
 		MaxChargingTime:   maxChargingTime,
 		ConnectorStatus:   core.ChargePointStatusAvailable,
+		availability:      core.AvailabilityTypeOperative,
 		session:           session.NewEmptySession(),
 	}, nil
 }
 
-// StartCharging Start charging a connector if connector is available and session could be started.
-// It turns on the relay (even if negative logic applies).
+// StartCharging Start charging a connector if connector is available or reserved and session could be started.
+// The reservation of the connector ends with the start of the session. It turns on the relay (even if negative logic applies).
 func (connector *connectorImpl) StartCharging(transactionId string, tagId string) error {
 	logInfo := log.WithFields(log.Fields{
 		"evseId":        connector.EvseId,
@@ -131,29 +148,26 @@ func (connector *connectorImpl) StartCharging(transactionId string, tagId string
 	})
 	logInfo.Debugf("Trying to start charging on connector")
 
-	if !(connector.IsAvailable() || connector.IsPreparing()) {
+	if !(connector.IsAvailable() || connector.IsPreparing() || connector.IsReserved()) {
 		return ErrInvalidConnectorStatus
 	}
 
 	connector.SetStatus(core.ChargePointStatusPreparing, core.NoError)
 	sessionErr := connector.session.StartSession(transactionId, tagId)
 	if sessionErr != nil {
+		connector.setIdleStatus()
 		return sessionErr
 	}
 
+	connector.reservationId = -1
+
+	connector.session.MeterStart = connector.GetMeterReading()
+	connector.setChargingBlocked(false)
+	connector.sampleTransactionData(types.ReadingContextTransactionBegin)
 	connector.relay.Enable()
 	connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
 
-	settings.UpdateConnectorSessionInfo(
-		connector.EvseId,
-		connector.ConnectorId,
-		&settingsModel.Session{
-			IsActive:      connector.session.IsActive,
-			TagId:         connector.session.TagId,
-			TransactionId: connector.session.TransactionId,
-			Started:       connector.session.Started,
-			Consumption:   connector.session.Consumption,
-		})
+	connector.persistSession()
 
 	if connector.PowerMeterEnabled && connector.GetPowerMeter() != nil {
 		sampleError := connector.preparePowerMeterAtConnector()
@@ -198,7 +212,9 @@ func (connector *connectorImpl) ResumeCharging(session session.Session) (err err
 
 		connector.relay.Enable()
 		connector.session.Started = session.Started
+		connector.session.MeterStart = session.MeterStart
 		connector.session.Consumption = append(connector.session.Consumption, session.Consumption...)
+		connector.session.TransactionData = append(connector.session.TransactionData, session.TransactionData...)
 		return nil, chargingTimeElapsed
 	}
 
@@ -213,21 +229,18 @@ func (connector *connectorImpl) StopCharging(reason core.Reason) error {
 		"reason":      reason,
 	})
 
-	if connector.IsCharging() || connector.IsPreparing() {
+	if connector.IsCharging() || connector.IsPreparing() || (connector.IsSuspended() && connector.session.IsActive) {
 		logInfo.Debugf("Stopping charging")
+		connector.sampleTransactionData(types.ReadingContextTransactionEnd)
 		connector.session.EndSession()
+
+		// The meter stop must survive a restart
+		if persister, isPersister := connector.powerMeter.(powerMeter.Persister); isPersister {
+			persister.Persist()
+		}
 		connector.relay.Disable()
 
-		settings.UpdateConnectorSessionInfo(
-			connector.EvseId,
-			connector.ConnectorId,
-			&settingsModel.Session{
-				IsActive:      connector.session.IsActive,
-				TagId:         connector.session.TagId,
-				TransactionId: connector.session.TransactionId,
-				Started:       connector.session.Started,
-				Consumption:   connector.session.Consumption,
-			})
+		connector.persistSession()
 
 		switch reason {
 		case core.ReasonEVDisconnected:
@@ -238,7 +251,7 @@ func (connector *connectorImpl) StopCharging(reason core.Reason) error {
 			break
 		default:
 			connector.SetStatus(core.ChargePointStatusFinishing, core.NoError)
-			connector.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+			connector.setIdleStatus()
 		}
 		return nil
 	}
@@ -262,47 +275,85 @@ func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand) {
 	var (
 		meterValues []types.MeterValue
 		samples     []types.SampledValue
-		value       = 0.0
 	)
 
 	for _, measurand := range measurands {
-
-		switch measurand {
-		case types.MeasurandEnergyActiveImportInterval, types.MeasurandEnergyActiveImportRegister,
-			types.MeasurandEnergyActiveExportInterval, types.MeasurandEnergyActiveExportRegister:
-			value = connector.powerMeter.GetEnergy()
-			break
-		case types.MeasurandCurrentImport, types.MeasurandCurrentExport:
-			value = connector.powerMeter.GetCurrent()
-			break
-		case types.MeasurandPowerActiveImport, types.MeasurandPowerActiveExport:
-			value = connector.powerMeter.GetPower()
-			break
-		case types.MeasurandVoltage:
-			value = connector.powerMeter.GetVoltage()
-			break
+		value, err := powerMeter.ReadMeasurand(connector.powerMeter, measurand)
+		if err != nil {
+			logInfo.WithError(err).Debugf("Cannot sample %s", measurand)
+			continue
 		}
 
-		if value != 0.0 {
-			sample := types.SampledValue{
-				Value:     fmt.Sprintf("%.3f", value),
-				Measurand: measurand,
-			}
-
-			meterValues = append(meterValues, types.MeterValue{SampledValue: []types.SampledValue{sample}, Timestamp: types.NewDateTime(time.Now())})
+		sample := types.SampledValue{
+			Value:     fmt.Sprintf("%.3f", value),
+			Measurand: measurand,
 		}
+
+		meterValues = append(meterValues, types.MeterValue{SampledValue: []types.SampledValue{sample}, Timestamp: types.NewDateTime(time.Now())})
 	}
 
 	if connector.meterValuesChannel != nil {
+		// Meter values sampled during a transaction are transaction related
+		var transactionId *int
+		if id, err := strconv.Atoi(connector.session.TransactionId); connector.session.IsActive && err == nil {
+			transactionId = &id
+		}
+
 		connector.meterValuesChannel <- models.NewMeterValueNotification(
 			connector.EvseId,
 			connector.ConnectorId,
-			nil,
+			transactionId,
 			meterValues...,
 		)
 	}
 
 	connector.session.AddSampledValue(samples)
+	connector.sampleTransactionData(types.ReadingContextSamplePeriodic)
+}
+
+// sampleTransactionData samples the StopTxnSampledData measurands and adds them to the transaction data of the active session.
+func (connector *connectorImpl) sampleTransactionData(readingContext types.ReadingContext) {
+	if !connector.PowerMeterEnabled || util.IsNilInterfaceOrPointer(connector.powerMeter) {
+		return
+	}
+
+	measurands := util.GetMeasurands(v16.StopTxnSampledData.String())
+	if meterValue := connector.GetMeterValue(measurands, readingContext); meterValue != nil {
+		connector.session.AddTransactionData(*meterValue)
+	}
+}
+
+// AddTransactionData adds the meter values to the transaction data of the active session, e.g. the clock-aligned meter values.
+func (connector *connectorImpl) AddTransactionData(meterValues ...types.MeterValue) {
+	connector.session.AddTransactionData(meterValues...)
+}
+
+// GetMeterValue reads the measurands from the power meter and returns them as a single meter value with the reading context.
+// Returns nil if the power meter is not enabled or none of the measurands is supported.
+func (connector *connectorImpl) GetMeterValue(measurands []types.Measurand, readingContext types.ReadingContext) *types.MeterValue {
+	if !connector.PowerMeterEnabled || util.IsNilInterfaceOrPointer(connector.powerMeter) {
+		return nil
+	}
+
+	var samples []types.SampledValue
+	for _, measurand := range measurands {
+		value, err := powerMeter.ReadMeasurand(connector.powerMeter, measurand)
+		if err != nil {
+			continue
+		}
+
+		samples = append(samples, types.SampledValue{
+			Value:     fmt.Sprintf("%.3f", value),
+			Context:   readingContext,
+			Measurand: measurand,
+		})
+	}
+
+	if len(samples) == 0 {
+		return nil
+	}
+
+	return &types.MeterValue{Timestamp: types.NewDateTime(time.Now()), SampledValue: samples}
 }
 
 // preparePowerMeterAtConnector
@@ -352,6 +403,12 @@ func (connector *connectorImpl) IsUnavailable() bool {
 	return connector.ConnectorStatus == core.ChargePointStatusUnavailable
 }
 
+func (connector *connectorImpl) IsSuspended() bool {
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
+	return connector.ConnectorStatus == core.ChargePointStatusSuspendedEVSE || connector.ConnectorStatus == core.ChargePointStatusSuspendedEV
+}
+
 func (connector *connectorImpl) SetStatus(status core.ChargePointStatus, errCode core.ChargePointErrorCode) {
 	logInfo := log.WithFields(log.Fields{
 		"evseId":      connector.EvseId,
@@ -371,9 +428,74 @@ func (connector *connectorImpl) SetStatus(status core.ChargePointStatus, errCode
 	connector.mu.Unlock()
 }
 
+// SetAvailability changes the availability of the connector and persists it. If a transaction is in progress, the change
+// is scheduled and applied after the transaction ends. Returns true if the change was scheduled.
+func (connector *connectorImpl) SetAvailability(availability core.AvailabilityType) bool {
+	log.WithFields(log.Fields{
+		"evseId":      connector.EvseId,
+		"connectorId": connector.ConnectorId,
+	}).Debugf("Changing connector availability to %s", availability)
+
+	connector.mu.Lock()
+	connector.availability = availability
+	connector.mu.Unlock()
+
+	settings.UpdateConnectorAvailability(connector.EvseId, connector.ConnectorId, availability)
+
+	if connector.session.IsActive {
+		return true
+	}
+
+	// The reservation is kept while the connector is unavailable, until it is cancelled or expires
+	switch {
+	case availability == core.AvailabilityTypeInoperative && !connector.IsUnavailable():
+		connector.SetStatus(core.ChargePointStatusUnavailable, core.NoError)
+	case availability == core.AvailabilityTypeOperative && connector.IsUnavailable():
+		connector.setIdleStatus()
+	}
+
+	return false
+}
+
+func (connector *connectorImpl) GetAvailability() core.AvailabilityType {
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
+	return connector.availability
+}
+
+// setIdleStatus sets the status of the connector without a transaction, based on the availability and the reservation.
+func (connector *connectorImpl) setIdleStatus() {
+	switch {
+	case connector.GetAvailability() == core.AvailabilityTypeInoperative:
+		connector.SetStatus(core.ChargePointStatusUnavailable, core.NoError)
+	case connector.reservationId > 0:
+		connector.SetStatus(core.ChargePointStatusReserved, core.NoError)
+	default:
+		connector.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+	}
+}
+
 func (connector *connectorImpl) GetTransactionId() string {
 	return connector.session.TransactionId
 }
+
+// SetTransactionId replaces the transaction id of the active session, e.g. when the central system assigns an id to a transaction started offline.
+func (connector *connectorImpl) SetTransactionId(transactionId string) {
+	if !connector.session.IsActive {
+		return
+	}
+
+	connector.session.TransactionId = transactionId
+
+	connector.persistSession()
+}
+
+// persistSession stores the session in the connector settings, so it can be restored after a reboot.
+func (connector *connectorImpl) persistSession() {
+	sessionInfo := settingsModel.Session(*connector.session)
+	settings.UpdateConnectorSessionInfo(connector.EvseId, connector.ConnectorId, &sessionInfo)
+}
+
 func (connector *connectorImpl) GetTagId() string {
 	return connector.session.TagId
 }
@@ -390,7 +512,9 @@ func (connector *connectorImpl) ReserveConnector(reservationId int, tagId string
 		return ErrInvalidReservationId
 	}
 
-	if !connector.IsAvailable() {
+	// A reservation can be replaced by a reservation with the same id. A restored connector is reserved without an id.
+	isReplaced := connector.IsReserved() && (connector.reservationId == reservationId || connector.reservationId <= 0)
+	if !(connector.IsAvailable() || isReplaced) {
 		return ErrInvalidConnectorStatus
 	}
 
@@ -398,8 +522,10 @@ func (connector *connectorImpl) ReserveConnector(reservationId int, tagId string
 	connector.SetStatus(core.ChargePointStatusReserved, core.NoError)
 	return nil
 }
+
+// RemoveReservation removes the reservation of the connector, also if the connector became unavailable after it was reserved.
 func (connector *connectorImpl) RemoveReservation() error {
-	if !connector.IsReserved() {
+	if !connector.IsReserved() && connector.reservationId <= 0 {
 		return ErrInvalidConnectorStatus
 	}
 
@@ -410,7 +536,7 @@ func (connector *connectorImpl) RemoveReservation() error {
 	logInfo.Debugf("Removing reservation")
 
 	connector.reservationId = -1
-	connector.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+	connector.setIdleStatus()
 	return nil
 }
 
@@ -426,8 +552,13 @@ func (connector *connectorImpl) GetEvseId() int {
 	return connector.EvseId
 }
 
-func (connector *connectorImpl) CalculateSessionAvgEnergyConsumption() float64 {
-	return connector.session.CalculateEnergyConsumptionWithAvgPower()
+// GetMeterReading returns the energy register reading of the power meter, rounded to Wh. Returns zero if the power meter is not enabled.
+func (connector *connectorImpl) GetMeterReading() int {
+	if !connector.PowerMeterEnabled || util.IsNilInterfaceOrPointer(connector.powerMeter) {
+		return 0
+	}
+
+	return int(math.Round(connector.powerMeter.GetEnergy()))
 }
 
 func (connector *connectorImpl) GetPowerMeter() powerMeter.PowerMeter {
@@ -438,7 +569,74 @@ func (connector *connectorImpl) GetMaxChargingTime() int {
 	return connector.MaxChargingTime
 }
 
+func (connector *connectorImpl) GetSession() session.Session {
+	return *connector.session
+}
+
+// SetChargingLimit sets the charging limit (in Amperes) from the smart charging profiles. Since the connector can only
+// switch the relay, a limit of zero suspends the charging, while any other limit resumes it. A nil limit removes the limit.
+func (connector *connectorImpl) SetChargingLimit(limit *float64) {
+	logInfo := log.WithFields(log.Fields{
+		"evseId":      connector.EvseId,
+		"connectorId": connector.ConnectorId,
+	})
+
+	connector.mu.Lock()
+	connector.chargingLimit = limit
+	canResume := connector.ConnectorStatus == core.ChargePointStatusSuspendedEVSE && connector.session.IsActive && !connector.isChargingBlocked
+	connector.mu.Unlock()
+
+	isSuspended := limit != nil && *limit <= 0
+	switch {
+	case isSuspended && connector.IsCharging():
+		logInfo.Info("Suspending charging due to the charging limit")
+		connector.relay.Disable()
+		connector.SetStatus(core.ChargePointStatusSuspendedEVSE, core.NoError)
+	case !isSuspended && canResume:
+		logInfo.Info("Resuming charging, the charging limit was lifted")
+		connector.relay.Enable()
+		connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
+	}
+}
+
+// SuspendCharging stops the energy delivery, but keeps the transaction running. Unlike with the charging limit,
+// the charging cannot be resumed until the transaction ends.
+func (connector *connectorImpl) SuspendCharging() error {
+	if !connector.session.IsActive {
+		return ErrNotCharging
+	}
+
+	log.WithFields(log.Fields{
+		"evseId":      connector.EvseId,
+		"connectorId": connector.ConnectorId,
+	}).Info("Suspending charging until the transaction ends")
+
+	connector.setChargingBlocked(true)
+	connector.relay.Disable()
+
+	if status, _ := connector.GetStatus(); status != core.ChargePointStatusSuspendedEVSE {
+		connector.SetStatus(core.ChargePointStatusSuspendedEVSE, core.NoError)
+	}
+
+	return nil
+}
+
+// setChargingBlocked blocks or unblocks resuming the charging for the rest of the transaction.
+func (connector *connectorImpl) setChargingBlocked(isBlocked bool) {
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
+	connector.isChargingBlocked = isBlocked
+}
+
+func (connector *connectorImpl) GetChargingLimit() *float64 {
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
+	return connector.chargingLimit
+}
+
 func (connector *connectorImpl) GetStatus() (core.ChargePointStatus, core.ChargePointErrorCode) {
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
 	return connector.ConnectorStatus, connector.ErrorCode
 }
 
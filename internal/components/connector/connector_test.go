@@ -8,6 +8,9 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"golang.org/x/net/context"
 	"os/exec"
 	"testing"
@@ -71,6 +74,11 @@ func (p *PowerMeterMock) GetRMSVoltage() float64 {
 	return args.Get(0).(float64)
 }
 
+func (p *PowerMeterMock) GetTemperature() float64 {
+	args := p.Called()
+	return args.Get(0).(float64)
+}
+
 /*---------------------- Relay Mock ----------------------*/
 
 func (r *RelayMock) Enable() {
@@ -89,6 +97,20 @@ func NewConnectorTestSuite() *ConnectorTestSuite {
 	}
 }
 
+func (s *ConnectorTestSuite) SetupSuite() {
+	ocppConfig := configuration.Config{Version: 1}
+	for _, key := range v16.MandatoryCoreKeys {
+		value := ""
+		if key == v16.MeterValueSampleInterval {
+			value = "60"
+		}
+
+		ocppConfig.Keys = append(ocppConfig.Keys, core.ConfigurationKey{Key: key.String(), Readonly: false, Value: value})
+	}
+
+	s.Require().NoError(ocppManager.GetManager().SetConfiguration(ocppConfig))
+}
+
 func (s *ConnectorTestSuite) SetupTest() {
 	cmd := exec.Command("touch", fileName)
 	err := cmd.Run()
@@ -204,6 +226,112 @@ func (s *ConnectorTestSuite) TestStopCharging() {
 	//s.relayMock.AssertNotCalled(s.T(), "Disable")
 }
 
+func (s *ConnectorTestSuite) TestSetAvailability() {
+	// Idle connector becomes unavailable immediately
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeInoperative))
+	s.Require().True(s.connector.IsUnavailable())
+	s.Require().Error(s.connector.StartCharging("1234", "1234"))
+
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeOperative))
+	s.Require().True(s.connector.IsAvailable())
+
+	// The change is scheduled until the transaction ends
+	err := s.connector.StartCharging("1234", "1234")
+	s.Require().NoError(err)
+
+	s.Require().True(s.connector.SetAvailability(core.AvailabilityTypeInoperative))
+	s.Require().True(s.connector.IsCharging())
+	s.Require().EqualValues(core.AvailabilityTypeInoperative, s.connector.GetAvailability())
+
+	err = s.connector.StopCharging(core.ReasonLocal)
+	s.Require().NoError(err)
+	s.Require().True(s.connector.IsUnavailable())
+}
+
+func (s *ConnectorTestSuite) TestSetAvailabilityReserved() {
+	s.Require().NoError(s.connector.ReserveConnector(1, "1234"))
+
+	// The reservation is kept while the connector is unavailable
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeInoperative))
+	s.Require().True(s.connector.IsUnavailable())
+	s.Require().EqualValues(1, s.connector.GetReservationId())
+
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeOperative))
+	s.Require().True(s.connector.IsReserved())
+	s.Require().EqualValues(1, s.connector.GetReservationId())
+
+	// The reservation can be removed while the connector is unavailable
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeInoperative))
+	s.Require().NoError(s.connector.RemoveReservation())
+	s.Require().True(s.connector.IsUnavailable())
+	s.Require().EqualValues(-1, s.connector.GetReservationId())
+	s.Require().Error(s.connector.RemoveReservation())
+
+	s.Require().False(s.connector.SetAvailability(core.AvailabilityTypeOperative))
+	s.Require().True(s.connector.IsAvailable())
+}
+
+func (s *ConnectorTestSuite) TestSetChargingLimit() {
+	var (
+		limit     = 16.0
+		zeroLimit = 0.0
+	)
+
+	err := s.connector.StartCharging("1234", "1234")
+	s.Require().NoError(err)
+
+	s.connector.SetChargingLimit(&limit)
+	s.Require().EqualValues(&limit, s.connector.GetChargingLimit())
+	s.Require().True(s.connector.IsCharging())
+
+	// Limit of 0 suspends charging
+	s.connector.SetChargingLimit(&zeroLimit)
+	s.Require().True(s.connector.IsSuspended())
+	s.relayMock.AssertCalled(s.T(), "Disable")
+
+	// Lifting the limit resumes charging
+	s.connector.SetChargingLimit(nil)
+	s.Require().Nil(s.connector.GetChargingLimit())
+	s.Require().True(s.connector.IsCharging())
+
+	// A suspended connector can be stopped
+	s.connector.SetChargingLimit(&zeroLimit)
+	err = s.connector.StopCharging(core.ReasonLocal)
+	s.Require().NoError(err)
+	s.Require().True(s.connector.IsAvailable())
+}
+
+func (s *ConnectorTestSuite) TestSuspendCharging() {
+	limit := 16.0
+
+	// Not charging
+	err := s.connector.SuspendCharging()
+	s.Require().ErrorIs(err, ErrNotCharging)
+
+	err = s.connector.StartCharging("1234", "1234")
+	s.Require().NoError(err)
+
+	err = s.connector.SuspendCharging()
+	s.Require().NoError(err)
+	s.Require().True(s.connector.IsSuspended())
+	s.relayMock.AssertCalled(s.T(), "Disable")
+
+	// The charging limit cannot resume the charging
+	s.connector.SetChargingLimit(&limit)
+	s.Require().True(s.connector.IsSuspended())
+
+	// The transaction can still be stopped
+	err = s.connector.StopCharging(core.ReasonLocal)
+	s.Require().NoError(err)
+	s.Require().True(s.connector.IsAvailable())
+
+	// The next transaction is not suspended
+	err = s.connector.StartCharging("1235", "1234")
+	s.Require().NoError(err)
+	s.connector.SetChargingLimit(&limit)
+	s.Require().True(s.connector.IsCharging())
+}
+
 func (s *ConnectorTestSuite) TestResumeCharging() {
 	var (
 		maxChargingTime = s.connector.GetMaxChargingTime()
@@ -298,6 +426,23 @@ func (s *ConnectorTestSuite) TestRemoveReservation() {
 	s.Require().Error(err)
 }
 
+func (s *ConnectorTestSuite) TestStartChargingReserved() {
+	s.Require().NoError(s.connector.ReserveConnector(1, "1234"))
+
+	// The reservation is kept if the session cannot be started
+	s.Require().Error(s.connector.StartCharging("", "1234"))
+	s.Require().True(s.connector.IsReserved())
+	s.Require().EqualValues(1, s.connector.GetReservationId())
+
+	// The reservation ends when the session starts
+	s.Require().NoError(s.connector.StartCharging("1234", "1234"))
+	s.Require().True(s.connector.IsCharging())
+	s.Require().EqualValues(-1, s.connector.GetReservationId())
+
+	s.Require().NoError(s.connector.StopCharging(core.ReasonLocal))
+	s.Require().True(s.connector.IsAvailable())
+}
+
 func (s *ConnectorTestSuite) TestSamplePowerMeter() {
 	s.powerMeterMock = new(PowerMeterMock)
 
@@ -344,6 +489,95 @@ func (s *ConnectorTestSuite) TestSamplePowerMeter() {
 	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandVoltage, types.MeasurandCurrentImport, types.MeasurandEnergyActiveImportInterval})
 }
 
+func (s *ConnectorTestSuite) TestGetMeterValue() {
+	measurands := []types.Measurand{types.MeasurandVoltage, types.MeasurandEnergyActiveImportRegister, types.MeasurandTemperature}
+
+	// Power meter not enabled
+	s.Require().Nil(s.connector.GetMeterValue(measurands, types.ReadingContextSampleClock))
+
+	s.powerMeterMock = new(PowerMeterMock)
+	s.powerMeterMock.On("GetEnergy").Return(2.0)
+	s.powerMeterMock.On("GetVoltage").Return(230.0)
+	s.connector.PowerMeterEnabled = true
+	s.connector.powerMeter = s.powerMeterMock
+
+	meterValue := s.connector.GetMeterValue(measurands, types.ReadingContextSampleClock)
+	s.Require().NotNil(meterValue)
+	s.Require().EqualValues([]types.SampledValue{
+		{Value: "230.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandVoltage},
+		{Value: "2.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister},
+	}, meterValue.SampledValue)
+
+	// The zero readings are reported, while the unsupported measurands are skipped
+	s.powerMeterMock = new(PowerMeterMock)
+	s.powerMeterMock.On("GetEnergy").Return(0.0)
+	s.powerMeterMock.On("GetVoltage").Return(0.0)
+	s.connector.powerMeter = s.powerMeterMock
+
+	meterValue = s.connector.GetMeterValue(measurands, types.ReadingContextSampleClock)
+	s.Require().NotNil(meterValue)
+	s.Require().EqualValues([]types.SampledValue{
+		{Value: "0.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandVoltage},
+		{Value: "0.000", Context: types.ReadingContextSampleClock, Measurand: types.MeasurandEnergyActiveImportRegister},
+	}, meterValue.SampledValue)
+
+	s.Require().Nil(s.connector.GetMeterValue([]types.Measurand{types.MeasurandTemperature}, types.ReadingContextSampleClock))
+}
+
+func (s *ConnectorTestSuite) TestGetMeterReading() {
+	// Power meter not enabled
+	s.Require().EqualValues(0, s.connector.GetMeterReading())
+
+	s.powerMeterMock = new(PowerMeterMock)
+	s.powerMeterMock.On("GetEnergy").Return(1234.56)
+	s.connector.PowerMeterEnabled = true
+	s.connector.powerMeter = s.powerMeterMock
+
+	// The reading is rounded to the nearest Wh
+	s.Require().EqualValues(1235, s.connector.GetMeterReading())
+}
+
+func (s *ConnectorTestSuite) TestTransactionData() {
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTxnSampledData.String(), "Energy.Active.Import.Register"))
+	defer ocppManager.UpdateKey(v16.StopTxnSampledData.String(), "")
+
+	s.powerMeterMock = new(PowerMeterMock)
+	s.powerMeterMock.On("GetEnergy").Return(2.0)
+	s.connector.PowerMeterEnabled = true
+	s.connector.powerMeter = s.powerMeterMock
+
+	err := s.connector.StartCharging("1234", "1234")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, s.connector.GetSession().MeterStart)
+
+	s.connector.SamplePowerMeter([]types.Measurand{})
+	s.connector.AddTransactionData(types.MeterValue{
+		SampledValue: []types.SampledValue{{Value: "2.000", Context: types.ReadingContextSampleClock}},
+	})
+
+	err = s.connector.StopCharging(core.ReasonLocal)
+	s.Require().NoError(err)
+
+	// The transaction data is kept after the transaction ends
+	transactionData := s.connector.GetSession().TransactionData
+	s.Require().Len(transactionData, 4)
+	s.Assert().EqualValues(types.ReadingContextTransactionBegin, transactionData[0].SampledValue[0].Context)
+	s.Assert().EqualValues(types.MeasurandEnergyActiveImportRegister, transactionData[0].SampledValue[0].Measurand)
+	s.Assert().EqualValues("2.000", transactionData[0].SampledValue[0].Value)
+	s.Assert().EqualValues(types.ReadingContextSamplePeriodic, transactionData[1].SampledValue[0].Context)
+	s.Assert().EqualValues(types.ReadingContextSampleClock, transactionData[2].SampledValue[0].Context)
+	s.Assert().EqualValues(types.ReadingContextTransactionEnd, transactionData[3].SampledValue[0].Context)
+
+	// No transaction data is added without a transaction
+	s.connector.AddTransactionData(types.MeterValue{})
+	s.Assert().Len(s.connector.GetSession().TransactionData, 4)
+
+	// The transaction data is cleared when a new transaction starts
+	err = s.connector.StartCharging("1235", "1234")
+	s.Require().NoError(err)
+	s.Assert().Len(s.connector.GetSession().TransactionData, 1)
+}
+
 func TestConnector(t *testing.T) {
 	log.SetLevel(log.DebugLevel)
 	suite.Run(t, NewConnectorTestSuite())
@@ -0,0 +1,106 @@
+package customerInformation
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+const (
+	AuthCacheSourceName        = "Authorization cache"
+	LocalAuthListSourceName    = "Local authorization list"
+	ReservationsSourceName     = "Reservations"
+	SessionsSourceName         = "Sessions"
+	TransactionQueueSourceName = "Transaction queue"
+	LogsSourceName             = "Logs"
+)
+
+var ErrInvalidToken = errors.New("token must not be empty")
+
+type (
+	// Source is a place where ChargePi keeps the data about the customers, e.g. the authorization cache or the logs.
+	Source interface {
+		// Collect returns the data stored about the token in a readable form.
+		Collect(idToken string) []string
+		// Erase removes the data about the token. The sources that must keep the data, e.g. the local authorization
+		// list managed by the central system, leave it as it is.
+		Erase(idToken string) error
+	}
+
+	// Manager collects and erases the data about a customer from all the sources, e.g. for a GDPR request.
+	Manager interface {
+		AddSource(name string, source Source)
+		// Report returns the data about the token from all the sources, prefixed with the name of the source.
+		Report(idToken string) ([]string, error)
+		// Clear erases the data about the token from all the sources. The first error is returned, but all the sources are cleared.
+		Clear(idToken string) error
+	}
+
+	namedSource struct {
+		name   string
+		source Source
+	}
+
+	managerImpl struct {
+		mu      sync.Mutex
+		sources []namedSource
+	}
+)
+
+func NewManager() Manager {
+	return &managerImpl{
+		mu: sync.Mutex{},
+	}
+}
+
+func (m *managerImpl) AddSource(name string, source Source) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sources = append(m.sources, namedSource{name: name, source: source})
+}
+
+func (m *managerImpl) Report(idToken string) ([]string, error) {
+	if strings.TrimSpace(idToken) == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var report []string
+	for _, source := range m.getSources() {
+		for _, data := range source.source.Collect(idToken) {
+			report = append(report, fmt.Sprintf("%s: %s", source.name, data))
+		}
+	}
+
+	return report, nil
+}
+
+func (m *managerImpl) Clear(idToken string) error {
+	if strings.TrimSpace(idToken) == "" {
+		return ErrInvalidToken
+	}
+
+	var firstErr error
+	for _, source := range m.getSources() {
+		err := source.source.Erase(idToken)
+		if err == nil {
+			continue
+		}
+
+		log.WithError(err).Errorf("Cannot erase the customer information from %s", source.name)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (m *managerImpl) getSources() []namedSource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]namedSource{}, m.sources...)
+}
@@ -0,0 +1,168 @@
+package customerInformation
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const tagId = "Tag1"
+
+type customerInformationTestSuite struct {
+	suite.Suite
+	manager       Manager
+	authCache     *auth.Cache
+	localAuthList *auth.LocalAuthList
+	reservations  reservations.Manager
+	queue         transactionQueue.Queue
+	logFile       string
+	// currentLogFile is the log file the logger writes to after the rotation
+	currentLogFile string
+}
+
+func (s *customerInformationTestSuite) SetupTest() {
+	var (
+		dir       = s.T().TempDir()
+		tokenInfo = authData.TokenInfo{Status: authData.StatusAccepted}
+	)
+
+	s.authCache = auth.NewAuthCache(filepath.Join(dir, "auth.json"))
+	s.authCache.SetMaxCachedTags(5)
+	s.authCache.AddTag(authData.NewIdTag(tagId), tokenInfo)
+	s.authCache.AddTag(authData.NewIdTag("Tag12"), tokenInfo)
+
+	s.localAuthList = auth.NewLocalAuthList(filepath.Join(dir, "local-auth-list.json"))
+	err := s.localAuthList.UpdateList(1, authData.UpdateTypeFull, []authData.AuthorizationData{
+		{Token: authData.NewToken(tagId, authData.TokenTypeISO14443), TokenInfo: &tokenInfo},
+	})
+	s.Require().NoError(err)
+
+	s.reservations = reservations.NewManager(filepath.Join(dir, "reservations.json"))
+	err = s.reservations.AddReservation(settingsData.Reservation{ReservationId: 1, ConnectorId: 1, IdTag: tagId, ExpiryDate: time.Now().Add(time.Hour)})
+	s.Require().NoError(err)
+
+	s.queue = transactionQueue.NewQueue(filepath.Join(dir, "transaction-queue.json"))
+	_, err = s.queue.StartTransaction(core.NewStartTransactionRequest(1, tagId, 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+
+	s.logFile = filepath.Join(dir, "chargepi.log")
+	err = ioutil.WriteFile(s.logFile, []byte("Checking if tag authorized Tag1\nTag12 authorized\nStarted session for Tag1: Tag1\n"), 0644)
+	s.Require().NoError(err)
+
+	s.currentLogFile = s.logFile + ".1"
+	rotate := func() (string, error) {
+		return s.currentLogFile, ioutil.WriteFile(s.currentLogFile, []byte("Clearing the data of Tag1\n"), 0644)
+	}
+
+	s.manager = NewManager()
+	s.manager.AddSource(AuthCacheSourceName, NewAuthCacheSource(s.authCache))
+	s.manager.AddSource(LocalAuthListSourceName, NewLocalAuthListSource(s.localAuthList))
+	s.manager.AddSource(ReservationsSourceName, NewReservationsSource(s.reservations))
+	s.manager.AddSource(TransactionQueueSourceName, NewTransactionQueueSource(s.queue))
+	s.manager.AddSource(LogsSourceName, NewLogSource(s.logFile, rotate))
+}
+
+func (s *customerInformationTestSuite) TestReport() {
+	report, err := s.manager.Report(tagId)
+	s.Require().NoError(err)
+	s.Require().Len(report, 5)
+	s.Assert().Equal("Authorization cache: token Tag1 with status Accepted", report[0])
+	s.Assert().Equal("Local authorization list: token Tag1 of type ISO14443 with status Accepted", report[1])
+	s.Assert().Contains(report[2], "Reservations: reservation 1 of connector 1")
+	s.Assert().Equal("Transaction queue: queued StartTransaction of connector 1", report[3])
+	s.Assert().Equal("Logs: 2 entries in "+s.logFile, report[4])
+
+	report, err = s.manager.Report("Unknown")
+	s.Require().NoError(err)
+	s.Assert().Empty(report)
+
+	_, err = s.manager.Report(" ")
+	s.Assert().ErrorIs(err, ErrInvalidToken)
+}
+
+func (s *customerInformationTestSuite) TestClear() {
+	s.Require().NoError(s.manager.Clear(tagId))
+
+	_, isFound := s.authCache.FindTag(tagId)
+	s.Assert().False(isFound)
+	_, isFound = s.authCache.FindTag("Tag12")
+	s.Assert().True(isFound)
+
+	// The local authorization list is managed by the central system
+	_, isFound = s.localAuthList.FindTag(tagId)
+	s.Assert().True(isFound)
+
+	s.Assert().Empty(s.reservations.GetReservations())
+	s.Assert().Empty(s.queue.FindMessages(tagId))
+
+	content, err := ioutil.ReadFile(s.logFile)
+	s.Require().NoError(err)
+	s.Assert().Equal("Checking if tag authorized <redacted>\nTag12 authorized\nStarted session for <redacted>: <redacted>\n", string(content))
+
+	// The logger writes to the new log file, which is not rewritten
+	content, err = ioutil.ReadFile(s.currentLogFile)
+	s.Require().NoError(err)
+	s.Assert().Equal("Clearing the data of Tag1\n", string(content))
+
+	report, err := s.manager.Report(tagId)
+	s.Require().NoError(err)
+	s.Require().Len(report, 2)
+	s.Assert().Contains(report[0], LocalAuthListSourceName)
+	s.Assert().Equal("Logs: 1 entries in "+s.currentLogFile, report[1])
+
+	s.Assert().ErrorIs(s.manager.Clear(""), ErrInvalidToken)
+}
+
+func (s *customerInformationTestSuite) TestEraseSessions() {
+	var (
+		source        = NewSessionSource()
+		dir           = s.T().TempDir()
+		activeSession = settingsData.Session{IsActive: true, TransactionId: "1", TagId: tagId}
+		endedSession  = settingsData.Session{TransactionId: "2", TagId: tagId}
+	)
+
+	for connectorId, session := range map[int]settingsData.Session{1: activeSession, 2: endedSession} {
+		var (
+			filePath = filepath.Join(dir, fmt.Sprintf("connector-%d.json", connectorId))
+			cfg      = viper.New()
+		)
+
+		s.Require().NoError(settings.WriteToFile(filePath, settingsData.Connector{EvseId: 1, ConnectorId: connectorId, Session: session}))
+		cfg.SetConfigFile(filePath)
+		s.Require().NoError(cfg.ReadInConfig())
+
+		key := fmt.Sprintf("connectorEvse1Id%d", connectorId)
+		settings.ConnectorSettings.Store(key, cfg)
+		defer settings.ConnectorSettings.Delete(key)
+	}
+
+	s.Assert().Len(source.Collect(tagId), 2)
+	s.Require().NoError(source.Erase(tagId))
+
+	// The ongoing session is needed to stop the transaction
+	data := source.Collect(tagId)
+	s.Require().Len(data, 1)
+	s.Assert().Contains(data[0], "session 1 on EVSE 1 connector 1")
+}
+
+func (s *customerInformationTestSuite) TestReplaceToken() {
+	text, replaced := replaceToken("111 11 a11 11", "11")
+	s.Assert().Equal("111 <redacted> a11 <redacted>", text)
+	s.Assert().EqualValues(2, replaced)
+}
+
+func TestCustomerInformation(t *testing.T) {
+	suite.Run(t, new(customerInformationTestSuite))
+}
@@ -0,0 +1,382 @@
+package customerInformation
+
+import (
+	"fmt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
+	authData "github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// redacted replaces the token in the log files.
+const redacted = "<redacted>"
+
+type (
+	authCacheSource struct {
+		cache *auth.Cache
+	}
+
+	localAuthListSource struct {
+		list *auth.LocalAuthList
+	}
+
+	reservationsSource struct {
+		manager reservations.Manager
+	}
+
+	sessionSource struct {
+	}
+
+	transactionQueueSource struct {
+		queue transactionQueue.Queue
+	}
+
+	logSource struct {
+		logFile string
+		rotate  func() (string, error)
+	}
+)
+
+// NewAuthCacheSource reports and removes the cached authorization of the token.
+func NewAuthCacheSource(cache *auth.Cache) Source {
+	return &authCacheSource{cache: cache}
+}
+
+func (s *authCacheSource) Collect(idToken string) []string {
+	data, isFound := s.cache.FindTag(idToken)
+	if !isFound {
+		return nil
+	}
+
+	return []string{formatAuthorization(*data)}
+}
+
+func (s *authCacheSource) Erase(idToken string) error {
+	if _, isFound := s.cache.FindTag(idToken); !isFound {
+		return nil
+	}
+
+	s.cache.RemoveTag(idToken)
+	s.cache.DumpTags()
+	return nil
+}
+
+// NewLocalAuthListSource reports the entry of the token in the local authorization list. The list is managed by the
+// central system, so the entry is not erased.
+func NewLocalAuthListSource(list *auth.LocalAuthList) Source {
+	return &localAuthListSource{list: list}
+}
+
+func (s *localAuthListSource) Collect(idToken string) []string {
+	data, isFound := s.list.FindTag(idToken)
+	if !isFound {
+		return nil
+	}
+
+	return []string{formatAuthorization(*data)}
+}
+
+func (s *localAuthListSource) Erase(idToken string) error {
+	return nil
+}
+
+// NewReservationsSource reports and cancels the reservations for the token.
+func NewReservationsSource(manager reservations.Manager) Source {
+	return &reservationsSource{manager: manager}
+}
+
+func (s *reservationsSource) Collect(idToken string) []string {
+	var data []string
+
+	for _, reservation := range s.getReservations(idToken) {
+		data = append(data, fmt.Sprintf("reservation %d of connector %d until %s",
+			reservation.ReservationId, reservation.ConnectorId, reservation.ExpiryDate.Format(time.RFC3339)))
+	}
+
+	return data
+}
+
+func (s *reservationsSource) Erase(idToken string) error {
+	for _, reservation := range s.getReservations(idToken) {
+		err := s.manager.RemoveReservation(reservation.ReservationId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *reservationsSource) getReservations(idToken string) []settingsData.Reservation {
+	var found []settingsData.Reservation
+
+	for _, reservation := range s.manager.GetReservations() {
+		if reservation.IdTag == idToken {
+			found = append(found, reservation)
+		}
+	}
+
+	return found
+}
+
+// NewSessionSource reports the sessions and transactions of the token stored in the connector files and erases the
+// ended sessions. The ongoing sessions are kept, as the token is needed to stop the transaction.
+func NewSessionSource() Source {
+	return &sessionSource{}
+}
+
+func (s *sessionSource) Collect(idToken string) []string {
+	var data []string
+
+	for _, connector := range settings.GetConnectorSettings() {
+		session := connector.Session
+		if session.TagId == idToken {
+			data = append(data, fmt.Sprintf("session %s on EVSE %d connector %d started at %s",
+				session.TransactionId, connector.EvseId, connector.ConnectorId, session.Started))
+		}
+
+		transaction := connector.Transaction
+		if transaction != nil && isTransactionToken(*transaction, idToken) {
+			data = append(data, fmt.Sprintf("transaction %s on EVSE %d connector %d",
+				transaction.TransactionId, connector.EvseId, connector.ConnectorId))
+		}
+	}
+
+	return data
+}
+
+func (s *sessionSource) Erase(idToken string) error {
+	for _, connector := range settings.GetConnectorSettings() {
+		if connector.Session.IsActive || connector.Session.TagId != idToken {
+			continue
+		}
+
+		settings.UpdateConnectorSessionInfo(connector.EvseId, connector.ConnectorId, &settingsData.Session{})
+	}
+
+	return nil
+}
+
+func isTransactionToken(transaction settingsData.Transaction, idToken string) bool {
+	return transaction.IdToken.IdToken == idToken ||
+		(transaction.StopIdToken != nil && transaction.StopIdToken.IdToken == idToken)
+}
+
+// NewTransactionQueueSource reports and redacts the queued transaction messages of the token.
+func NewTransactionQueueSource(queue transactionQueue.Queue) Source {
+	return &transactionQueueSource{queue: queue}
+}
+
+func (s *transactionQueueSource) Collect(idToken string) []string {
+	var data []string
+
+	for _, message := range s.queue.FindMessages(idToken) {
+		switch {
+		case message.StartTransaction != nil:
+			data = append(data, fmt.Sprintf("queued StartTransaction of connector %d", message.StartTransaction.ConnectorId))
+		case message.StopTransaction != nil:
+			data = append(data, fmt.Sprintf("queued StopTransaction of transaction %d", message.StopTransaction.TransactionId))
+		case message.TransactionEvent != nil:
+			data = append(data, fmt.Sprintf("queued TransactionEvent of transaction %s", message.TransactionEvent.TransactionInfo.TransactionId))
+		}
+	}
+
+	return data
+}
+
+func (s *transactionQueueSource) Erase(idToken string) error {
+	s.queue.RedactToken(idToken, redacted)
+	return nil
+}
+
+// NewLogSource reports and redacts the entries of the log file and its rotated files that mention the token.
+// The rotate function starts a new log file before the erasure and returns its name, so the logger does not write
+// to the redacted files. If rotate is nil, the logger is not writing to the files.
+func NewLogSource(logFile string, rotate func() (string, error)) Source {
+	return &logSource{logFile: logFile, rotate: rotate}
+}
+
+func (s *logSource) Collect(idToken string) []string {
+	var data []string
+
+	for _, file := range s.getLogFiles() {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		entries := 0
+		for _, line := range strings.Split(string(content), "\n") {
+			if _, replaced := replaceToken(line, idToken); replaced > 0 {
+				entries++
+			}
+		}
+
+		if entries > 0 {
+			data = append(data, fmt.Sprintf("%d entries in %s", entries, file))
+		}
+	}
+
+	return data
+}
+
+// Erase replaces the token in the log files. The log file the logger writes to is rotated first, as the logger keeps
+// appending to its open file. The files are replaced with the redacted copies, so a failure does not corrupt them.
+func (s *logSource) Erase(idToken string) error {
+	var currentFile string
+
+	if s.rotate != nil {
+		fileName, err := s.rotate()
+		if err != nil {
+			return err
+		}
+
+		currentFile = fileName
+	}
+
+	for _, file := range s.getLogFiles() {
+		if currentFile != "" && filepath.Clean(file) == filepath.Clean(currentFile) {
+			continue
+		}
+
+		err := redactFile(file, idToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// redactFile writes the redacted content to a temporary file and renames it to the log file.
+func redactFile(file, idToken string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	redactedContent, replaced := replaceToken(string(content), idToken)
+	if replaced == 0 {
+		return nil
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.WriteString(redactedContent)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tempFile.Name(), info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), file)
+}
+
+// getLogFiles returns the log file and the rotated log files. The link to the current log file is skipped.
+func (s *logSource) getLogFiles() []string {
+	var logFiles []string
+
+	if s.logFile == "" {
+		return logFiles
+	}
+
+	matches, err := filepath.Glob(s.logFile + "*")
+	if err != nil {
+		return logFiles
+	}
+
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		logFiles = append(logFiles, match)
+	}
+
+	return logFiles
+}
+
+// replaceToken redacts the token where it is not a part of a longer id, so a short token does not match other ids.
+// It returns the text and the number of replacements.
+func replaceToken(text, idToken string) (string, int) {
+	var (
+		builder  strings.Builder
+		replaced = 0
+		// start is the position of the text that was not written yet, while the search continues from the position
+		start    = 0
+		position = 0
+	)
+
+	for {
+		index := strings.Index(text[position:], idToken)
+		if index < 0 {
+			builder.WriteString(text[start:])
+			return builder.String(), replaced
+		}
+
+		index += position
+		end := index + len(idToken)
+		if (index > 0 && isIdCharacter(text[index-1])) || (end < len(text) && isIdCharacter(text[end])) {
+			position = index + 1
+			continue
+		}
+
+		builder.WriteString(text[start:index])
+		builder.WriteString(redacted)
+		start = end
+		position = end
+		replaced++
+	}
+}
+
+func isIdCharacter(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func formatAuthorization(data authData.AuthorizationData) string {
+	var info = fmt.Sprintf("token %s", data.Token.IdToken)
+
+	if data.Token.Type != authData.TokenTypeIdTag {
+		info += fmt.Sprintf(" of type %s", data.Token.Type)
+	}
+
+	if data.TokenInfo == nil {
+		return info
+	}
+
+	info += fmt.Sprintf(" with status %s", data.TokenInfo.Status)
+
+	if data.TokenInfo.ExpiryDate != nil {
+		info += fmt.Sprintf(" until %s", data.TokenInfo.ExpiryDate.Format(time.RFC3339))
+	}
+
+	if data.TokenInfo.GroupIdToken != nil {
+		info += fmt.Sprintf(" in the group %s", data.TokenInfo.GroupIdToken.IdToken)
+	}
+
+	return info
+}
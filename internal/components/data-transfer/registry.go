@@ -0,0 +1,112 @@
+package dataTransfer
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrUnknownVendorId  = errors.New("unknown vendor id")
+	ErrUnknownMessageId = errors.New("unknown message id")
+	ErrRejected         = errors.New("data transfer rejected")
+	ErrNoSender         = errors.New("no sender set")
+)
+
+type (
+	// Handler handles the data of the vendor specific message sent by the central system and returns the data of
+	// the response. The message is rejected if the handler returns an error.
+	Handler func(data interface{}) (interface{}, error)
+
+	// Sender sends the vendor specific message to the central system and returns the data of the response. If the central
+	// system does not accept the message, ErrRejected, ErrUnknownVendorId or ErrUnknownMessageId is returned.
+	Sender func(vendorId, messageId string, data interface{}) (interface{}, error)
+
+	// Registry dispatches the DataTransfer requests of the central system to the handlers registered by the modules,
+	// e.g. the display or the pricing, and lets the modules send DataTransfer requests regardless of the OCPP version.
+	Registry interface {
+		// AddHandler registers the handler of the message of the vendor. The handler with an empty message id handles
+		// the messages without the message id. The handler replaces the previously registered handler of the message.
+		AddHandler(vendorId, messageId string, handler Handler)
+		RemoveHandler(vendorId, messageId string)
+		// Handle passes the data to the handler of the message. If the vendor or the message has no handler,
+		// ErrUnknownVendorId or ErrUnknownMessageId is returned.
+		Handle(vendorId, messageId string, data interface{}) (interface{}, error)
+		// SetSender sets the function, which sends the DataTransfer requests with the charge point.
+		SetSender(sender Sender)
+		Send(vendorId, messageId string, data interface{}) (interface{}, error)
+	}
+
+	registryImpl struct {
+		mu       sync.Mutex
+		handlers map[string]map[string]Handler
+		sender   Sender
+	}
+)
+
+func NewRegistry() Registry {
+	return &registryImpl{
+		mu:       sync.Mutex{},
+		handlers: map[string]map[string]Handler{},
+	}
+}
+
+func (r *registryImpl) AddHandler(vendorId, messageId string, handler Handler) {
+	if handler == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isFound := r.handlers[vendorId]; !isFound {
+		r.handlers[vendorId] = map[string]Handler{}
+	}
+
+	r.handlers[vendorId][messageId] = handler
+}
+
+func (r *registryImpl) RemoveHandler(vendorId, messageId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.handlers[vendorId], messageId)
+	if len(r.handlers[vendorId]) == 0 {
+		delete(r.handlers, vendorId)
+	}
+}
+
+func (r *registryImpl) Handle(vendorId, messageId string, data interface{}) (interface{}, error) {
+	r.mu.Lock()
+	messages, isVendorFound := r.handlers[vendorId]
+	handler, isMessageFound := messages[messageId]
+	r.mu.Unlock()
+
+	switch {
+	case !isVendorFound:
+		return nil, ErrUnknownVendorId
+	case !isMessageFound:
+		return nil, ErrUnknownMessageId
+	}
+
+	// The handler is called without the lock, so it can use the registry
+	return handler(data)
+}
+
+func (r *registryImpl) SetSender(sender Sender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sender = sender
+}
+
+func (r *registryImpl) Send(vendorId, messageId string, data interface{}) (interface{}, error) {
+	r.mu.Lock()
+	sender := r.sender
+	r.mu.Unlock()
+
+	if sender == nil {
+		return nil, ErrNoSender
+	}
+
+	return sender(vendorId, messageId, data)
+}
@@ -0,0 +1,98 @@
+package dataTransfer
+
+import (
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+const vendorId = "ChargePi"
+
+type registryTestSuite struct {
+	suite.Suite
+	registry Registry
+}
+
+func echo(data interface{}) (interface{}, error) {
+	return data, nil
+}
+
+func (s *registryTestSuite) SetupTest() {
+	s.registry = NewRegistry()
+}
+
+func (s *registryTestSuite) TestHandle() {
+	s.registry.AddHandler(vendorId, "echo", echo)
+	s.registry.AddHandler(vendorId, "", func(data interface{}) (interface{}, error) {
+		return "noMessageId", nil
+	})
+	s.registry.AddHandler(vendorId, "nil", nil)
+
+	data, err := s.registry.Handle(vendorId, "echo", "data")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("data", data)
+
+	data, err = s.registry.Handle(vendorId, "", nil)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("noMessageId", data)
+
+	_, err = s.registry.Handle("Unknown", "echo", nil)
+	s.Assert().ErrorIs(err, ErrUnknownVendorId)
+
+	_, err = s.registry.Handle(vendorId, "unknown", nil)
+	s.Assert().ErrorIs(err, ErrUnknownMessageId)
+
+	// The nil handler is not registered
+	_, err = s.registry.Handle(vendorId, "nil", nil)
+	s.Assert().ErrorIs(err, ErrUnknownMessageId)
+
+	// The error of the handler is returned
+	handlerErr := errors.New("invalid data")
+	s.registry.AddHandler(vendorId, "echo", func(data interface{}) (interface{}, error) {
+		return nil, handlerErr
+	})
+
+	_, err = s.registry.Handle(vendorId, "echo", nil)
+	s.Assert().ErrorIs(err, handlerErr)
+}
+
+func (s *registryTestSuite) TestRemoveHandler() {
+	s.registry.AddHandler(vendorId, "echo", echo)
+	s.registry.AddHandler(vendorId, "echo2", echo)
+
+	s.registry.RemoveHandler(vendorId, "echo")
+	_, err := s.registry.Handle(vendorId, "echo", nil)
+	s.Assert().ErrorIs(err, ErrUnknownMessageId)
+
+	// The vendor is unknown after its last handler is removed
+	s.registry.RemoveHandler(vendorId, "echo2")
+	_, err = s.registry.Handle(vendorId, "echo2", nil)
+	s.Assert().ErrorIs(err, ErrUnknownVendorId)
+
+	// Removing a handler of an unknown vendor does nothing
+	s.registry.RemoveHandler("Unknown", "echo")
+}
+
+func (s *registryTestSuite) TestSend() {
+	_, err := s.registry.Send(vendorId, "echo", nil)
+	s.Assert().ErrorIs(err, ErrNoSender)
+
+	s.registry.SetSender(func(vendorId, messageId string, data interface{}) (interface{}, error) {
+		if messageId != "echo" {
+			return nil, ErrUnknownMessageId
+		}
+
+		return data, nil
+	})
+
+	data, err := s.registry.Send(vendorId, "echo", "data")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("data", data)
+
+	_, err = s.registry.Send(vendorId, "unknown", nil)
+	s.Assert().ErrorIs(err, ErrUnknownMessageId)
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(registryTestSuite))
+}
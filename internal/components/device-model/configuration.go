@@ -0,0 +1,92 @@
+package deviceModel
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+)
+
+// configurationVariable maps the variable of the device model to the configuration key, which is read by the components
+// shared with OCPP 1.6.
+type configurationVariable struct {
+	component string
+	variable  string
+	instance  string
+	key       configuration.Key
+}
+
+var configurationVariables = []configurationVariable{
+	{component: OCPPCommCtrlrComponent, variable: "HeartbeatInterval", key: v16.HeartbeatInterval},
+	{component: OCPPCommCtrlrComponent, variable: "MessageAttempts", instance: "TransactionEvent", key: v16.TransactionMessageAttempts},
+	{component: OCPPCommCtrlrComponent, variable: "MessageAttemptInterval", instance: "TransactionEvent", key: v16.TransactionMessageRetryInterval},
+	{component: AuthCtrlrComponent, variable: "AuthorizeRemoteStart", key: v16.AuthorizeRemoteTxRequests},
+	{component: AuthCtrlrComponent, variable: "LocalAuthorizeOffline", key: v16.LocalAuthorizeOffline},
+	{component: AuthCtrlrComponent, variable: "LocalPreAuthorize", key: v16.LocalPreAuthorize},
+	{component: AuthCtrlrComponent, variable: "OfflineTxForUnknownIdEnabled", key: v16.AllowOfflineTxForUnknownId},
+	{component: AuthCacheCtrlrComponent, variable: "Enabled", key: v16.AuthorizationCacheEnabled},
+	{component: LocalAuthListCtrlrComponent, variable: "Enabled", key: v16.LocalAuthListEnabled},
+	{component: LocalAuthListCtrlrComponent, variable: ItemsPerMessageVariable, key: v16.SendLocalListMaxLength},
+	{component: TxCtrlrComponent, variable: "EVConnectionTimeOut", key: v16.ConnectionTimeOut},
+	{component: TxCtrlrComponent, variable: "StopTxOnEVSideDisconnect", key: v16.StopTransactionOnEVSideDisconnect},
+	{component: TxCtrlrComponent, variable: "StopTxOnInvalidId", key: v16.StopTransactionOnInvalidId},
+	{component: SampledDataCtrlrComponent, variable: "TxUpdatedInterval", key: v16.MeterValueSampleInterval},
+	{component: SampledDataCtrlrComponent, variable: "TxUpdatedMeasurands", key: v16.MeterValuesSampledData},
+	{component: SampledDataCtrlrComponent, variable: "TxEndedMeasurands", key: v16.StopTxnSampledData},
+	{component: AlignedDataCtrlrComponent, variable: "Interval", key: v16.ClockAlignedDataInterval},
+	{component: AlignedDataCtrlrComponent, variable: "Measurands", key: v16.MeterValuesAlignedData},
+}
+
+// FindConfigurationKey returns the configuration key of the variable, if the variable has one.
+func FindConfigurationKey(component ocpp201.Component, variable ocpp201.Variable) (configuration.Key, bool) {
+	for _, mapping := range configurationVariables {
+		if mapping.component == component.Name && mapping.variable == variable.Name && mapping.instance == variable.Instance {
+			return mapping.key, true
+		}
+	}
+
+	return "", false
+}
+
+// UpdateFromConfiguration sets the variables mapped to the configuration keys to the values from the OCPP configuration.
+func UpdateFromConfiguration(store Store) {
+	for _, mapping := range configurationVariables {
+		value, err := ocppConfigManager.GetConfigurationValue(mapping.key.String())
+		if err != nil {
+			continue
+		}
+
+		err = store.UpdateVariable(
+			ocpp201.Component{Name: mapping.component},
+			ocpp201.Variable{Name: mapping.variable, Instance: mapping.instance},
+			ocpp201.AttributeTypeActual,
+			value,
+		)
+		if err != nil {
+			log.WithError(err).Debugf("Cannot set the variable from the configuration key %s", mapping.key)
+		}
+	}
+}
+
+// UpdateConfiguration sets the configuration keys to the values of the variables they are mapped to and updates the
+// configuration file, so the values set with OCPP 2.0.1 are kept when the charge point switches to OCPP 1.6.
+func UpdateConfiguration(store Store) error {
+	for _, mapping := range configurationVariables {
+		value, err := store.GetVariable(
+			ocpp201.Component{Name: mapping.component},
+			ocpp201.Variable{Name: mapping.variable, Instance: mapping.instance},
+			ocpp201.AttributeTypeActual,
+		)
+		if err != nil {
+			continue
+		}
+
+		err = ocppConfigManager.UpdateKey(mapping.key.String(), value)
+		if err != nil {
+			log.WithError(err).Debugf("Cannot set the configuration key %s from the variable", mapping.key)
+		}
+	}
+
+	return ocppConfigManager.UpdateConfigurationFile()
+}
@@ -0,0 +1,299 @@
+package deviceModel
+
+import (
+	"fmt"
+	displayMessages "github.com/xBlaz3kx/ChargePi-go/internal/components/display-messages"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"strconv"
+)
+
+// Names of the components of the default device model.
+const (
+	ChargingStationComponent         = "ChargingStation"
+	EVSEComponent                    = "EVSE"
+	ConnectorComponent               = "Connector"
+	PowerContactorComponent          = "PowerContactor"
+	TokenReaderComponent             = "TokenReader"
+	DisplayComponent                 = "Display"
+	ChargingStatusIndicatorComponent = "ChargingStatusIndicator"
+	DisplayMessageCtrlrComponent     = "DisplayMessageCtrlr"
+	TariffCostCtrlrComponent         = "TariffCostCtrlr"
+	DeviceDataCtrlrComponent         = "DeviceDataCtrlr"
+	MonitoringCtrlrComponent         = "MonitoringCtrlr"
+	OCPPCommCtrlrComponent           = "OCPPCommCtrlr"
+	AuthCtrlrComponent               = "AuthCtrlr"
+	AuthCacheCtrlrComponent          = "AuthCacheCtrlr"
+	LocalAuthListCtrlrComponent      = "LocalAuthListCtrlr"
+	TxCtrlrComponent                 = "TxCtrlr"
+	SampledDataCtrlrComponent        = "SampledDataCtrlr"
+	AlignedDataCtrlrComponent        = "AlignedDataCtrlr"
+)
+
+const (
+	// TariffInstance and CostInstance are the instances of the TariffCostCtrlr variables for the tariff and the cost.
+	TariffInstance = "Tariff"
+	CostInstance   = "Cost"
+
+	// ItemsPerMessageVariable limits the number of variables in a single NotifyReport (instance GetReport) or
+	// in a single GetVariables and SetVariables request.
+	ItemsPerMessageVariable = "ItemsPerMessage"
+	defaultItemsPerMessage  = 20
+
+	measurands = "Current.Import,Current.Offered,Energy.Active.Import.Register,Power.Active.Import,Voltage"
+)
+
+var (
+	booleanCharacteristics = ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeBoolean}
+	stringCharacteristics  = ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeString}
+)
+
+// NewDefaultModel creates the device model of the charging station from the settings and the connectors.
+// The hardware is described with read-only variables, while the controllers can be configured by the CSMS.
+func NewDefaultModel(config *settings.Settings, connectors []*settings.Connector) []ocpp201.ReportData {
+	var (
+		info      = config.ChargePoint.Info.OCPPInfo
+		hardware  = config.ChargePoint.Hardware
+		station   = ocpp201.Component{Name: ChargingStationComponent}
+		variables = []ocpp201.ReportData{
+			readOnly(station, "Model", info.Model, stringCharacteristics),
+			readOnly(station, "VendorName", info.Vendor, stringCharacteristics),
+			readOnly(station, "SerialNumber", info.ChargePointSerialNumber, stringCharacteristics),
+			readOnly(station, "Available", "true", booleanCharacteristics),
+			measured(station, "Online", "true", booleanCharacteristics),
+		}
+	)
+
+	// Every EVSE is reported only once, even if it has multiple connectors
+	evses := map[int]bool{}
+	for _, connector := range connectors {
+		if connector == nil {
+			continue
+		}
+
+		if !evses[connector.EvseId] {
+			evses[connector.EvseId] = true
+			evse := ocpp201.Component{Name: EVSEComponent, Evse: &ocpp201.EVSE{Id: connector.EvseId}}
+			variables = append(variables, readOnly(evse, "Available", "true", booleanCharacteristics))
+		}
+
+		connectorId := connector.ConnectorId
+		component := ocpp201.Component{
+			Name: ConnectorComponent,
+			Evse: &ocpp201.EVSE{Id: connector.EvseId, ConnectorId: &connectorId},
+		}
+
+		variables = append(variables,
+			readOnly(component, "Available", "true", booleanCharacteristics),
+			readOnly(component, "ConnectorType", connector.Type, stringCharacteristics),
+			readOnly(component, "PowerMeter", strconv.FormatBool(connector.PowerMeter.Enabled), booleanCharacteristics),
+			measured(component, "Problem", "false", booleanCharacteristics),
+			measured(ocpp201.Component{Name: PowerContactorComponent, Evse: component.Evse}, "Active", "false", booleanCharacteristics),
+		)
+
+		if connector.PowerMeter.Enabled {
+			variables = append(variables,
+				measured(component, "Voltage", "0", decimal("V")),
+				measured(component, "Temperature", "0", decimal("Celsius")),
+			)
+		}
+	}
+
+	tokenReader := ocpp201.Component{Name: TokenReaderComponent}
+	variables = append(variables,
+		readOnly(tokenReader, "Enabled", strconv.FormatBool(hardware.TagReader.IsEnabled), booleanCharacteristics),
+		readOnly(tokenReader, "Model", hardware.TagReader.ReaderModel, stringCharacteristics),
+	)
+
+	display := ocpp201.Component{Name: DisplayComponent}
+	displayMessage := ocpp201.Component{Name: DisplayMessageCtrlrComponent}
+	variables = append(variables,
+		readOnly(display, "Enabled", strconv.FormatBool(hardware.Lcd.IsEnabled), booleanCharacteristics),
+		readOnly(display, "Language", hardware.Lcd.Language, stringCharacteristics),
+		readOnly(displayMessage, "Enabled", strconv.FormatBool(hardware.Lcd.IsEnabled), booleanCharacteristics),
+		readOnly(displayMessage, "DisplayMessages", strconv.Itoa(displayMessages.MaxMessages), integer(0, 100, "")),
+		readOnly(displayMessage, "SupportedFormats", "ASCII,UTF8", memberList("ASCII,HTML,URI,UTF8")),
+		readOnly(displayMessage, "SupportedPriorities", "AlwaysFront,InFront,NormalCycle", memberList("AlwaysFront,InFront,NormalCycle")),
+	)
+
+	// The tariff and the cost are shown on the display
+	var (
+		tariffCost  = ocpp201.Component{Name: TariffCostCtrlrComponent}
+		isAvailable = strconv.FormatBool(hardware.Lcd.IsEnabled)
+	)
+	variables = append(variables,
+		withInstance(readOnly(tariffCost, "Available", isAvailable, booleanCharacteristics), TariffInstance),
+		withInstance(readOnly(tariffCost, "Available", isAvailable, booleanCharacteristics), CostInstance),
+		withInstance(readWrite(tariffCost, "Enabled", isAvailable, booleanCharacteristics), TariffInstance),
+		withInstance(readWrite(tariffCost, "Enabled", isAvailable, booleanCharacteristics), CostInstance),
+		readWrite(tariffCost, "Currency", "EUR", stringCharacteristics),
+		readWrite(tariffCost, "TariffFallbackMessage", "", stringCharacteristics),
+		readWrite(tariffCost, "TotalCostFallbackMessage", "", stringCharacteristics),
+	)
+
+	indicator := ocpp201.Component{Name: ChargingStatusIndicatorComponent}
+	variables = append(variables,
+		readOnly(indicator, "Enabled", strconv.FormatBool(hardware.LedIndicator.Enabled), booleanCharacteristics),
+	)
+
+	return append(variables, newControllers()...)
+}
+
+func newControllers() []ocpp201.ReportData {
+	var (
+		deviceData      = ocpp201.Component{Name: DeviceDataCtrlrComponent}
+		monitoring      = ocpp201.Component{Name: MonitoringCtrlrComponent}
+		ocppComm        = ocpp201.Component{Name: OCPPCommCtrlrComponent}
+		auth            = ocpp201.Component{Name: AuthCtrlrComponent}
+		authCache       = ocpp201.Component{Name: AuthCacheCtrlrComponent}
+		localAuthList   = ocpp201.Component{Name: LocalAuthListCtrlrComponent}
+		tx              = ocpp201.Component{Name: TxCtrlrComponent}
+		sampledData     = ocpp201.Component{Name: SampledDataCtrlrComponent}
+		alignedData     = ocpp201.Component{Name: AlignedDataCtrlrComponent}
+		itemsPerMsg     = strconv.Itoa(defaultItemsPerMessage)
+		measurandList   = ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeMemberList, ValuesList: measurands}
+		cachePolicies   = ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeOptionList, ValuesList: "LRU,LFU,FIFO"}
+		monitoringBases = ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeOptionList, ValuesList: "All,FactoryDefault,HardWiredOnly"}
+	)
+
+	itemsPerMessage := func(instance string) ocpp201.ReportData {
+		variable := readOnly(deviceData, ItemsPerMessageVariable, itemsPerMsg, integer(1, 1000, ""))
+		variable.Variable.Instance = instance
+		return variable
+	}
+
+	messageAttempts := readWrite(ocppComm, "MessageAttempts", "3", integer(0, 10, ""))
+	messageAttempts.Variable.Instance = "TransactionEvent"
+	messageAttemptInterval := readWrite(ocppComm, "MessageAttemptInterval", "60", integer(0, 3600, "s"))
+	messageAttemptInterval.Variable.Instance = "TransactionEvent"
+
+	return []ocpp201.ReportData{
+		itemsPerMessage(ocpp201.GetReportFeatureName),
+		itemsPerMessage(ocpp201.GetVariablesFeatureName),
+		itemsPerMessage(ocpp201.SetVariablesFeatureName),
+		itemsPerMessage(ocpp201.SetVariableMonitoringFeatureName),
+		itemsPerMessage(ocpp201.ClearVariableMonitoringFeatureName),
+		readWrite(monitoring, "Enabled", "true", booleanCharacteristics),
+		readOnly(monitoring, "ActiveMonitoringBase", string(ocpp201.MonitoringBaseAll), monitoringBases),
+		readWrite(monitoring, "ActiveMonitoringLevel", "9", integer(0, 9, "")),
+		readWrite(monitoring, "OfflineQueuingSeverity", "2", integer(0, 9, "")),
+		readWrite(ocppComm, "HeartbeatInterval", "60", integer(1, 86400, "s")),
+		messageAttempts,
+		messageAttemptInterval,
+		readWrite(auth, "AuthorizeRemoteStart", "true", booleanCharacteristics),
+		readWrite(auth, "LocalAuthorizeOffline", "true", booleanCharacteristics),
+		readWrite(auth, "LocalPreAuthorize", "false", booleanCharacteristics),
+		readWrite(auth, "OfflineTxForUnknownIdEnabled", "false", booleanCharacteristics),
+		readWrite(authCache, "Enabled", "true", booleanCharacteristics),
+		readWrite(authCache, "LifeTime", "86400", integer(1, 31536000, "s")),
+		readWrite(authCache, "Policy", "LRU", cachePolicies),
+		readWrite(localAuthList, "Enabled", "true", booleanCharacteristics),
+		readOnly(localAuthList, ItemsPerMessageVariable, "50", integer(1, 1000, "")),
+		readWrite(tx, "EVConnectionTimeOut", "50", integer(0, 3600, "s")),
+		readWrite(tx, "StopTxOnEVSideDisconnect", "true", booleanCharacteristics),
+		readWrite(tx, "StopTxOnInvalidId", "true", booleanCharacteristics),
+		readWrite(sampledData, "TxUpdatedInterval", "0", integer(0, 86400, "s")),
+		readWrite(sampledData, "TxUpdatedMeasurands", "Energy.Active.Import.Register", measurandList),
+		readWrite(sampledData, "TxEndedMeasurands", "Energy.Active.Import.Register", measurandList),
+		readWrite(alignedData, "Interval", "0", integer(0, 86400, "s")),
+		readWrite(alignedData, "Measurands", "", measurandList),
+	}
+}
+
+func readOnly(component ocpp201.Component, name, value string, characteristics ocpp201.VariableCharacteristics) ocpp201.ReportData {
+	return newVariable(component, name, value, ocpp201.MutabilityReadOnly, characteristics)
+}
+
+func readWrite(component ocpp201.Component, name, value string, characteristics ocpp201.VariableCharacteristics) ocpp201.ReportData {
+	return newVariable(component, name, value, ocpp201.MutabilityReadWrite, characteristics)
+}
+
+func newVariable(
+	component ocpp201.Component,
+	name, value string,
+	mutability ocpp201.Mutability,
+	characteristics ocpp201.VariableCharacteristics,
+) ocpp201.ReportData {
+	return ocpp201.ReportData{
+		Component: component,
+		Variable:  ocpp201.Variable{Name: name},
+		VariableAttribute: []ocpp201.VariableAttribute{
+			{
+				Type:       ocpp201.AttributeTypeActual,
+				Value:      value,
+				Mutability: mutability,
+				Persistent: true,
+			},
+		},
+		VariableCharacteristics: &characteristics,
+	}
+}
+
+func withInstance(variable ocpp201.ReportData, instance string) ocpp201.ReportData {
+	variable.Variable.Instance = instance
+	return variable
+}
+
+// measured creates a read-only variable, which is measured by the charging station and can be monitored by the CSMS.
+// The value is not persisted, as it changes often.
+func measured(component ocpp201.Component, name, value string, characteristics ocpp201.VariableCharacteristics) ocpp201.ReportData {
+	characteristics.SupportsMonitoring = true
+	variable := readOnly(component, name, value, characteristics)
+	variable.VariableAttribute[0].Persistent = false
+	return variable
+}
+
+func decimal(unit string) ocpp201.VariableCharacteristics {
+	return ocpp201.VariableCharacteristics{Unit: unit, DataType: ocpp201.DataTypeDecimal}
+}
+
+func memberList(values string) ocpp201.VariableCharacteristics {
+	return ocpp201.VariableCharacteristics{DataType: ocpp201.DataTypeMemberList, ValuesList: values}
+}
+
+func integer(min, max float64, unit string) ocpp201.VariableCharacteristics {
+	return ocpp201.VariableCharacteristics{
+		Unit:     unit,
+		DataType: ocpp201.DataTypeInteger,
+		MinLimit: &min,
+		MaxLimit: &max,
+	}
+}
+
+// GetItemsPerMessage returns the maximum number of items in a message of the feature from the DeviceDataCtrlr.
+func GetItemsPerMessage(store Store, feature string) int {
+	value, err := store.GetVariable(
+		ocpp201.Component{Name: DeviceDataCtrlrComponent},
+		ocpp201.Variable{Name: ItemsPerMessageVariable, Instance: feature},
+		ocpp201.AttributeTypeActual,
+	)
+	if err != nil {
+		return defaultItemsPerMessage
+	}
+
+	items, err := strconv.Atoi(value)
+	if err != nil || items <= 0 {
+		return defaultItemsPerMessage
+	}
+
+	return items
+}
+
+// String returns a readable name of the component and the variable, used for logging.
+func String(component ocpp201.Component, variable ocpp201.Variable) string {
+	name := component.Name
+	if component.Instance != "" {
+		name = fmt.Sprintf("%s[%s]", name, component.Instance)
+	}
+
+	if component.Evse != nil {
+		name = fmt.Sprintf("%s(evse %d)", name, component.Evse.Id)
+	}
+
+	name = fmt.Sprintf("%s.%s", name, variable.Name)
+	if variable.Instance != "" {
+		name = fmt.Sprintf("%s[%s]", name, variable.Instance)
+	}
+
+	return name
+}
@@ -0,0 +1,525 @@
+package deviceModel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownComponent      = errors.New("unknown component")
+	ErrUnknownVariable       = errors.New("unknown variable")
+	ErrAttributeNotSupported = errors.New("attribute type not supported")
+	ErrReadOnly              = errors.New("attribute is read only")
+	ErrWriteOnly             = errors.New("attribute is write only")
+	ErrInvalidValue          = errors.New("invalid attribute value")
+	ErrReportNotSupported    = errors.New("report not supported")
+	ErrMonitoringUnsupported = errors.New("variable cannot be monitored")
+)
+
+type (
+	// Store holds the components and the variables of the OCPP 2.0.1 device model. The variables are persisted after every change,
+	// so the configured values survive a restart.
+	Store interface {
+		LoadFromFile()
+		// SetDefaults adds the default variables to the store. The values of the persistent, writable attributes from the file are kept.
+		SetDefaults(variables []ocpp201.ReportData)
+		// GetVariable returns the attribute value, which can be read by the CSMS.
+		GetVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType) (string, error)
+		// SetVariable sets the attribute value, which can be written by the CSMS.
+		SetVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType, value string) error
+		// UpdateVariable sets the attribute value regardless of the mutability. Used by the charging station to report its state.
+		UpdateVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType, value string) error
+		// GetCharacteristics returns the characteristics of the variable, if the variable can be monitored.
+		GetCharacteristics(component ocpp201.Component, variable ocpp201.Variable) (*ocpp201.VariableCharacteristics, error)
+		GetBaseReport(reportBase ocpp201.ReportBase) ([]ocpp201.ReportData, error)
+		GetReport(criteria []ocpp201.ComponentCriterion, componentVariables []ocpp201.ComponentVariable) []ocpp201.ReportData
+	}
+
+	storeImpl struct {
+		mu       sync.Mutex
+		filePath string
+		// Variables in the order they were added, so the reports are always in the same order
+		variables []ocpp201.ReportData
+	}
+)
+
+func NewStore(filePath string) Store {
+	return &storeImpl{
+		mu:        sync.Mutex{},
+		filePath:  filePath,
+		variables: []ocpp201.ReportData{},
+	}
+}
+
+// LoadFromFile loads the device model from the file.
+func (s *storeImpl) LoadFromFile() {
+	var deviceModelFile settingsData.DeviceModelFile
+
+	data, err := ioutil.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		log.Debugf("No device model file found")
+		return
+	} else if err != nil {
+		log.WithError(err).Errorf("Unable to read the device model file")
+		return
+	}
+
+	switch filepath.Ext(s.filePath) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &deviceModelFile)
+	default:
+		err = json.Unmarshal(data, &deviceModelFile)
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("Unable to load the device model file")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.variables = deviceModelFile.Variables
+	log.Infof("Loaded %d variables of the device model", len(s.variables))
+}
+
+func (s *storeImpl) SetDefaults(variables []ocpp201.ReportData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, variable := range variables {
+		index := s.find(variable.Component, variable.Variable)
+		if index < 0 {
+			s.variables = append(s.variables, variable)
+			continue
+		}
+
+		// Keep the values configured by the CSMS
+		for i, attribute := range variable.VariableAttribute {
+			stored, isFound := findAttribute(s.variables[index].VariableAttribute, attribute.Type)
+			if isFound && attribute.Persistent && !attribute.Constant && attribute.Mutability != ocpp201.MutabilityReadOnly {
+				variable.VariableAttribute[i].Value = stored.Value
+			}
+		}
+
+		s.variables[index] = variable
+	}
+
+	s.dump()
+}
+
+func (s *storeImpl) GetVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attribute, _, err := s.getAttribute(component, variable, attributeType)
+	if err != nil {
+		return "", err
+	}
+
+	if attribute.Mutability == ocpp201.MutabilityWriteOnly {
+		return "", ErrWriteOnly
+	}
+
+	return attribute.Value, nil
+}
+
+func (s *storeImpl) SetVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType, value string) error {
+	return s.setVariable(component, variable, attributeType, value, false)
+}
+
+func (s *storeImpl) UpdateVariable(component ocpp201.Component, variable ocpp201.Variable, attributeType ocpp201.AttributeType, value string) error {
+	return s.setVariable(component, variable, attributeType, value, true)
+}
+
+func (s *storeImpl) setVariable(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	attributeType ocpp201.AttributeType,
+	value string,
+	ignoreMutability bool,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attribute, data, err := s.getAttribute(component, variable, attributeType)
+	if err != nil {
+		return err
+	}
+
+	if !ignoreMutability && (attribute.Mutability == ocpp201.MutabilityReadOnly || attribute.Constant) {
+		return ErrReadOnly
+	}
+
+	err = validateValue(data, attribute.Type, value)
+	if err != nil {
+		return err
+	}
+
+	attribute.Value = value
+
+	// The values measured by the charging station change often and are not kept after a restart
+	if attribute.Persistent {
+		s.dump()
+	}
+
+	return nil
+}
+
+func (s *storeImpl) GetCharacteristics(component ocpp201.Component, variable ocpp201.Variable) (*ocpp201.VariableCharacteristics, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, data, err := s.getAttribute(component, variable, ocpp201.AttributeTypeActual)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.VariableCharacteristics == nil || !data.VariableCharacteristics.SupportsMonitoring {
+		return nil, ErrMonitoringUnsupported
+	}
+
+	characteristics := *data.VariableCharacteristics
+	return &characteristics, nil
+}
+
+// GetBaseReport returns the variables of the report. The ConfigurationInventory contains only the variables that can be
+// configured by the CSMS, while the FullInventory contains all the variables.
+func (s *storeImpl) GetBaseReport(reportBase ocpp201.ReportBase) ([]ocpp201.ReportData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report []ocpp201.ReportData
+
+	switch reportBase {
+	case ocpp201.ReportBaseFullInventory:
+		for _, variable := range s.variables {
+			report = append(report, toReportData(variable))
+		}
+	case ocpp201.ReportBaseConfigurationInventory:
+		for _, variable := range s.variables {
+			if isConfigurable(variable) {
+				report = append(report, toReportData(variable))
+			}
+		}
+	default:
+		return nil, ErrReportNotSupported
+	}
+
+	return report, nil
+}
+
+// GetReport returns the variables of the components matching the criteria and the requested components and variables.
+// A component matches the criterion, if its variable with the name of the criterion is true (e.g. Enabled).
+func (s *storeImpl) GetReport(criteria []ocpp201.ComponentCriterion, componentVariables []ocpp201.ComponentVariable) []ocpp201.ReportData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report []ocpp201.ReportData
+
+	for _, variable := range s.variables {
+		if len(componentVariables) > 0 && !matchesAny(variable, componentVariables) {
+			continue
+		}
+
+		if !s.matchesCriteria(variable.Component, criteria) {
+			continue
+		}
+
+		report = append(report, toReportData(variable))
+	}
+
+	return report
+}
+
+func (s *storeImpl) matchesCriteria(component ocpp201.Component, criteria []ocpp201.ComponentCriterion) bool {
+	for _, criterion := range criteria {
+		index := s.find(component, ocpp201.Variable{Name: string(criterion)})
+		if index < 0 {
+			continue
+		}
+
+		attribute, isFound := findAttribute(s.variables[index].VariableAttribute, ocpp201.AttributeTypeActual)
+		if isFound && attribute.Value == "true" {
+			return true
+		}
+	}
+
+	return len(criteria) == 0
+}
+
+// getAttribute returns the attribute of the variable. The lock must be held by the caller.
+func (s *storeImpl) getAttribute(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	attributeType ocpp201.AttributeType,
+) (*ocpp201.VariableAttribute, *ocpp201.ReportData, error) {
+	if attributeType == "" {
+		attributeType = ocpp201.AttributeTypeActual
+	}
+
+	index := s.find(component, variable)
+	if index < 0 {
+		if s.hasComponent(component) {
+			return nil, nil, ErrUnknownVariable
+		}
+
+		return nil, nil, ErrUnknownComponent
+	}
+
+	data := &s.variables[index]
+	for i := range data.VariableAttribute {
+		if getAttributeType(data.VariableAttribute[i]) == attributeType {
+			return &data.VariableAttribute[i], data, nil
+		}
+	}
+
+	return nil, nil, ErrAttributeNotSupported
+}
+
+// find returns the index of the variable of the component or -1 if the variable does not exist.
+func (s *storeImpl) find(component ocpp201.Component, variable ocpp201.Variable) int {
+	for i, data := range s.variables {
+		if isSameComponent(data.Component, component) && data.Variable == variable {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (s *storeImpl) hasComponent(component ocpp201.Component) bool {
+	for _, data := range s.variables {
+		if isSameComponent(data.Component, component) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dump writes the device model to the file. The lock must be held by the caller.
+func (s *storeImpl) dump() {
+	if s.filePath == "" {
+		return
+	}
+
+	err := settings.WriteToFile(s.filePath, settingsData.DeviceModelFile{Variables: s.variables})
+	if err != nil {
+		log.WithError(err).Errorf("Error updating the device model file")
+	}
+}
+
+func isSameComponent(a, b ocpp201.Component) bool {
+	if a.Name != b.Name || a.Instance != b.Instance {
+		return false
+	}
+
+	if a.Evse == nil || b.Evse == nil {
+		return a.Evse == b.Evse
+	}
+
+	return a.Evse.Id == b.Evse.Id && getConnectorId(a.Evse) == getConnectorId(b.Evse)
+}
+
+func getConnectorId(evse *ocpp201.EVSE) int {
+	if evse.ConnectorId == nil {
+		return 0
+	}
+
+	return *evse.ConnectorId
+}
+
+// matchesAny checks if the variable is one of the requested variables or belongs to one of the requested components.
+// The instance and the EVSE of the requested component are optional.
+func matchesAny(data ocpp201.ReportData, componentVariables []ocpp201.ComponentVariable) bool {
+	for _, componentVariable := range componentVariables {
+		component := componentVariable.Component
+		if component.Name != data.Component.Name {
+			continue
+		}
+
+		if component.Instance != "" && component.Instance != data.Component.Instance {
+			continue
+		}
+
+		if component.Evse != nil {
+			if data.Component.Evse == nil || data.Component.Evse.Id != component.Evse.Id {
+				continue
+			}
+
+			if component.Evse.ConnectorId != nil && getConnectorId(data.Component.Evse) != *component.Evse.ConnectorId {
+				continue
+			}
+		}
+
+		variable := componentVariable.Variable
+		if variable == nil {
+			return true
+		}
+
+		if variable.Name == data.Variable.Name && (variable.Instance == "" || variable.Instance == data.Variable.Instance) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isConfigurable(data ocpp201.ReportData) bool {
+	for _, attribute := range data.VariableAttribute {
+		if attribute.Mutability != ocpp201.MutabilityReadOnly && !attribute.Constant {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toReportData copies the variable for the report. The values of the write-only attributes are not reported.
+func toReportData(data ocpp201.ReportData) ocpp201.ReportData {
+	report := data
+	report.VariableAttribute = make([]ocpp201.VariableAttribute, len(data.VariableAttribute))
+
+	for i, attribute := range data.VariableAttribute {
+		if attribute.Mutability == ocpp201.MutabilityWriteOnly {
+			attribute.Value = ""
+		}
+
+		report.VariableAttribute[i] = attribute
+	}
+
+	return report
+}
+
+func findAttribute(attributes []ocpp201.VariableAttribute, attributeType ocpp201.AttributeType) (ocpp201.VariableAttribute, bool) {
+	if attributeType == "" {
+		attributeType = ocpp201.AttributeTypeActual
+	}
+
+	for _, attribute := range attributes {
+		if getAttributeType(attribute) == attributeType {
+			return attribute, true
+		}
+	}
+
+	return ocpp201.VariableAttribute{}, false
+}
+
+// getAttributeType returns the type of the attribute. The attribute without the type is the Actual attribute.
+func getAttributeType(attribute ocpp201.VariableAttribute) ocpp201.AttributeType {
+	if attribute.Type == "" {
+		return ocpp201.AttributeTypeActual
+	}
+
+	return attribute.Type
+}
+
+// validateValue checks if the value matches the characteristics of the variable. The numeric values of the Actual attribute
+// must also be between the MinSet and the MaxSet attributes, if the variable has them.
+func validateValue(data *ocpp201.ReportData, attributeType ocpp201.AttributeType, value string) error {
+	characteristics := data.VariableCharacteristics
+	if characteristics == nil {
+		return nil
+	}
+
+	switch characteristics.DataType {
+	case ocpp201.DataTypeInteger, ocpp201.DataTypeDecimal:
+		var (
+			number float64
+			err    error
+		)
+
+		if characteristics.DataType == ocpp201.DataTypeInteger {
+			var integer int
+			integer, err = strconv.Atoi(value)
+			number = float64(integer)
+		} else {
+			number, err = strconv.ParseFloat(value, 64)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %s is not a number", ErrInvalidValue, value)
+		}
+
+		if characteristics.MinLimit != nil && number < *characteristics.MinLimit {
+			return fmt.Errorf("%w: %s is lower than the minimum", ErrInvalidValue, value)
+		}
+
+		if characteristics.MaxLimit != nil && number > *characteristics.MaxLimit {
+			return fmt.Errorf("%w: %s is higher than the maximum", ErrInvalidValue, value)
+		}
+
+		if attributeType != ocpp201.AttributeTypeActual && attributeType != "" {
+			return nil
+		}
+
+		if minSet, isFound := findAttribute(data.VariableAttribute, ocpp201.AttributeTypeMinSet); isFound {
+			if limit, err := strconv.ParseFloat(minSet.Value, 64); err == nil && number < limit {
+				return fmt.Errorf("%w: %s is lower than MinSet", ErrInvalidValue, value)
+			}
+		}
+
+		if maxSet, isFound := findAttribute(data.VariableAttribute, ocpp201.AttributeTypeMaxSet); isFound {
+			if limit, err := strconv.ParseFloat(maxSet.Value, 64); err == nil && number > limit {
+				return fmt.Errorf("%w: %s is higher than MaxSet", ErrInvalidValue, value)
+			}
+		}
+	case ocpp201.DataTypeBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %s is not a boolean", ErrInvalidValue, value)
+		}
+	case ocpp201.DataTypeDateTime:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("%w: %s is not a date", ErrInvalidValue, value)
+		}
+	case ocpp201.DataTypeOptionList:
+		if !isInList(characteristics.ValuesList, value) {
+			return fmt.Errorf("%w: %s is not an option", ErrInvalidValue, value)
+		}
+	case ocpp201.DataTypeSequenceList, ocpp201.DataTypeMemberList:
+		if value == "" {
+			return nil
+		}
+
+		for _, member := range strings.Split(value, ",") {
+			if !isInList(characteristics.ValuesList, member) {
+				return fmt.Errorf("%w: %s is not an option", ErrInvalidValue, member)
+			}
+		}
+	case ocpp201.DataTypeString:
+		if characteristics.MaxLimit != nil && float64(len(value)) > *characteristics.MaxLimit {
+			return fmt.Errorf("%w: the value is too long", ErrInvalidValue)
+		}
+	}
+
+	return nil
+}
+
+// isInList checks if the value is in the comma-separated list. An empty list accepts any value.
+func isInList(list, value string) bool {
+	if list == "" {
+		return true
+	}
+
+	for _, option := range strings.Split(list, ",") {
+		if strings.TrimSpace(option) == strings.TrimSpace(value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,226 @@
+package deviceModel
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"github.com/xBlaz3kx/ocppManager-go/manager"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	connectorId = 1
+	connectors  = []*settings.Connector{
+		{EvseId: 1, ConnectorId: 1, Type: "Type2"},
+	}
+	ocppComm  = ocpp201.Component{Name: OCPPCommCtrlrComponent}
+	heartbeat = ocpp201.Variable{Name: "HeartbeatInterval"}
+)
+
+type deviceModelTestSuite struct {
+	suite.Suite
+	filePath string
+	store    Store
+}
+
+func (s *deviceModelTestSuite) SetupTest() {
+	s.filePath = filepath.Join(s.T().TempDir(), "device-model.json")
+	s.store = NewStore(s.filePath)
+	s.store.SetDefaults(NewDefaultModel(&settings.Settings{}, connectors))
+}
+
+func (s *deviceModelTestSuite) TestGetVariable() {
+	value, err := s.store.GetVariable(ocppComm, heartbeat, "")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("60", value)
+
+	connector := ocpp201.Component{Name: ConnectorComponent, Evse: &ocpp201.EVSE{Id: 1, ConnectorId: &connectorId}}
+	value, err = s.store.GetVariable(connector, ocpp201.Variable{Name: "ConnectorType"}, ocpp201.AttributeTypeActual)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("Type2", value)
+
+	// The connector of another EVSE does not exist
+	connector.Evse.Id = 2
+	_, err = s.store.GetVariable(connector, ocpp201.Variable{Name: "ConnectorType"}, ocpp201.AttributeTypeActual)
+	s.Assert().ErrorIs(err, ErrUnknownComponent)
+
+	_, err = s.store.GetVariable(ocppComm, ocpp201.Variable{Name: "Unknown"}, ocpp201.AttributeTypeActual)
+	s.Assert().ErrorIs(err, ErrUnknownVariable)
+
+	_, err = s.store.GetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeTarget)
+	s.Assert().ErrorIs(err, ErrAttributeNotSupported)
+}
+
+func (s *deviceModelTestSuite) TestSetVariable() {
+	s.Assert().NoError(s.store.SetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual, "120"))
+
+	value, err := s.store.GetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("120", value)
+
+	// Values outside the limits or of the wrong type
+	s.Assert().ErrorIs(s.store.SetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual, "0"), ErrInvalidValue)
+	s.Assert().ErrorIs(s.store.SetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual, "abc"), ErrInvalidValue)
+
+	tx := ocpp201.Component{Name: TxCtrlrComponent}
+	s.Assert().ErrorIs(s.store.SetVariable(tx, ocpp201.Variable{Name: "StopTxOnInvalidId"}, "", "yes"), ErrInvalidValue)
+
+	sampledData := ocpp201.Component{Name: SampledDataCtrlrComponent}
+	measurands := ocpp201.Variable{Name: "TxUpdatedMeasurands"}
+	s.Assert().NoError(s.store.SetVariable(sampledData, measurands, "", "Energy.Active.Import.Register,Voltage"))
+	s.Assert().ErrorIs(s.store.SetVariable(sampledData, measurands, "", "Voltage,Frequency"), ErrInvalidValue)
+
+	// The hardware cannot be configured by the CSMS, but can be updated by the charging station
+	station := ocpp201.Component{Name: ChargingStationComponent}
+	available := ocpp201.Variable{Name: "Available"}
+	s.Assert().ErrorIs(s.store.SetVariable(station, available, "", "false"), ErrReadOnly)
+	s.Assert().NoError(s.store.UpdateVariable(station, available, "", "false"))
+}
+
+func (s *deviceModelTestSuite) TestMinSetMaxSet() {
+	component := ocpp201.Component{Name: "SmartChargingCtrlr"}
+	variable := ocpp201.Variable{Name: "Limit"}
+	limit := readWrite(component, variable.Name, "10", integer(0, 100, "A"))
+	limit.VariableAttribute = append(limit.VariableAttribute,
+		ocpp201.VariableAttribute{Type: ocpp201.AttributeTypeMinSet, Value: "6", Mutability: ocpp201.MutabilityReadWrite},
+		ocpp201.VariableAttribute{Type: ocpp201.AttributeTypeMaxSet, Value: "32", Mutability: ocpp201.MutabilityReadWrite},
+	)
+	s.store.SetDefaults([]ocpp201.ReportData{limit})
+
+	s.Assert().NoError(s.store.SetVariable(component, variable, ocpp201.AttributeTypeActual, "16"))
+	s.Assert().ErrorIs(s.store.SetVariable(component, variable, ocpp201.AttributeTypeActual, "5"), ErrInvalidValue)
+	s.Assert().ErrorIs(s.store.SetVariable(component, variable, ocpp201.AttributeTypeActual, "40"), ErrInvalidValue)
+
+	// The MaxSet is only checked against the limits of the variable
+	s.Assert().NoError(s.store.SetVariable(component, variable, ocpp201.AttributeTypeMaxSet, "50"))
+	s.Assert().NoError(s.store.SetVariable(component, variable, ocpp201.AttributeTypeActual, "40"))
+}
+
+func (s *deviceModelTestSuite) TestPersistence() {
+	s.Require().NoError(s.store.SetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual, "300"))
+
+	// The configured value is kept after a restart, while the hardware is described from the settings again
+	store := NewStore(s.filePath)
+	store.LoadFromFile()
+	store.SetDefaults(NewDefaultModel(&settings.Settings{}, nil))
+
+	value, err := store.GetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("300", value)
+}
+
+func (s *deviceModelTestSuite) TestGetBaseReport() {
+	full, err := s.store.GetBaseReport(ocpp201.ReportBaseFullInventory)
+	s.Assert().NoError(err)
+
+	configuration, err := s.store.GetBaseReport(ocpp201.ReportBaseConfigurationInventory)
+	s.Assert().NoError(err)
+	s.Assert().NotEmpty(configuration)
+	s.Assert().Less(len(configuration), len(full))
+
+	for _, data := range configuration {
+		s.Assert().NotEqualValues(ocpp201.MutabilityReadOnly, data.VariableAttribute[0].Mutability)
+	}
+
+	_, err = s.store.GetBaseReport(ocpp201.ReportBaseSummaryInventory)
+	s.Assert().ErrorIs(err, ErrReportNotSupported)
+}
+
+func (s *deviceModelTestSuite) TestGetReport() {
+	// All the variables of the component
+	report := s.store.GetReport(nil, []ocpp201.ComponentVariable{{Component: ocpp201.Component{Name: TxCtrlrComponent}}})
+	s.Assert().Len(report, 3)
+
+	// A single variable
+	report = s.store.GetReport(nil, []ocpp201.ComponentVariable{{Component: ocppComm, Variable: &heartbeat}})
+	s.Require().Len(report, 1)
+	s.Assert().EqualValues(heartbeat, report[0].Variable)
+
+	// The connectors of the EVSE
+	evse := ocpp201.Component{Name: ConnectorComponent, Evse: &ocpp201.EVSE{Id: 1}}
+	report = s.store.GetReport(nil, []ocpp201.ComponentVariable{{Component: evse}})
+	s.Assert().Len(report, 4)
+
+	// The components, which are enabled
+	report = s.store.GetReport([]ocpp201.ComponentCriterion{ocpp201.ComponentCriterionEnabled}, nil)
+	s.Assert().NotEmpty(report)
+	for _, data := range report {
+		s.Assert().Contains([]string{AuthCacheCtrlrComponent, LocalAuthListCtrlrComponent, MonitoringCtrlrComponent}, data.Component.Name)
+	}
+}
+
+func (s *deviceModelTestSuite) TestGetCharacteristics() {
+	connector := ocpp201.Component{Name: ConnectorComponent, Evse: &ocpp201.EVSE{Id: 1, ConnectorId: &connectorId}}
+
+	characteristics, err := s.store.GetCharacteristics(connector, ocpp201.Variable{Name: "Problem"})
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocpp201.DataTypeBoolean, characteristics.DataType)
+
+	_, err = s.store.GetCharacteristics(ocppComm, heartbeat)
+	s.Assert().ErrorIs(err, ErrMonitoringUnsupported)
+
+	_, err = s.store.GetCharacteristics(connector, ocpp201.Variable{Name: "Voltage"})
+	s.Assert().ErrorIs(err, ErrUnknownVariable)
+
+	// The measured values are not persisted
+	s.Require().NoError(s.store.UpdateVariable(connector, ocpp201.Variable{Name: "Problem"}, ocpp201.AttributeTypeActual, "true"))
+	store := NewStore(s.filePath)
+	store.LoadFromFile()
+	value, err := store.GetVariable(connector, ocpp201.Variable{Name: "Problem"}, ocpp201.AttributeTypeActual)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("false", value)
+}
+
+func (s *deviceModelTestSuite) TestConfigurationMigration() {
+	// The configuration without the mandatory keys
+	previousManager := ocppConfigManager.GetManager()
+	defer ocppConfigManager.SetManager(previousManager)
+	ocppConfigManager.SetManager(manager.NewManager())
+	ocppConfigManager.SetFilePath(s.T().TempDir())
+	ocppConfigManager.SetFileName("configuration")
+	ocppConfigManager.SetFileFormat(manager.JSON)
+
+	err := ocppConfigManager.GetManager().SetConfiguration(configuration.Config{
+		Version: 1,
+		Keys: []core.ConfigurationKey{
+			{Key: v16.HeartbeatInterval.String(), Value: "30"},
+			{Key: v16.StopTransactionOnInvalidId.String(), Value: "false"},
+		},
+	})
+	s.Require().NoError(err)
+
+	// From OCPP 1.6 to OCPP 2.0.1
+	UpdateFromConfiguration(s.store)
+
+	value, err := s.store.GetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("30", value)
+
+	value, err = s.store.GetVariable(ocpp201.Component{Name: TxCtrlrComponent}, ocpp201.Variable{Name: "StopTxOnInvalidId"}, ocpp201.AttributeTypeActual)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("false", value)
+
+	// From OCPP 2.0.1 to OCPP 1.6
+	s.Require().NoError(s.store.SetVariable(ocppComm, heartbeat, ocpp201.AttributeTypeActual, "120"))
+	s.Require().NoError(UpdateConfiguration(s.store))
+
+	value, err = ocppConfigManager.GetConfigurationValue(v16.HeartbeatInterval.String())
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("120", value)
+
+	key, isMapped := FindConfigurationKey(ocppComm, heartbeat)
+	s.Assert().True(isMapped)
+	s.Assert().EqualValues(v16.HeartbeatInterval, key)
+
+	_, isMapped = FindConfigurationKey(ocppComm, ocpp201.Variable{Name: "Unknown"})
+	s.Assert().False(isMapped)
+}
+
+func TestDeviceModel(t *testing.T) {
+	suite.Run(t, new(deviceModelTestSuite))
+}
@@ -0,0 +1,232 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// rotatedLogTimeFormat matches the suffix of the rotated log files (see logging.fileLogging).
+	rotatedLogTimeFormat = "200601021504"
+	redactedValue        = "********"
+)
+
+var (
+	// sensitiveSettings are the (lowercase) keys of the settings with credentials, which are redacted in the archive.
+	sensitiveSettings = map[string]struct{}{
+		"basicauthpass": {},
+		"tls":           {},
+	}
+	// sensitiveConfigurationKeys are the OCPP configuration keys with credentials, which are redacted in the archive.
+	sensitiveConfigurationKeys = map[string]struct{}{
+		"AuthorizationKey": {},
+	}
+)
+
+type Files struct {
+	// LogFile is the path of the log file. Rotated files share the same prefix.
+	LogFile string
+	// SecurityLogFile is the path of the security event log, uploaded only with the GetLog request.
+	SecurityLogFile   string
+	SettingsFile      string
+	OcppConfiguration string
+	AuthFile          string
+	ConnectorFiles    []string
+}
+
+// createArchive writes a gzipped tar archive with the log files in the time window and all the configuration files.
+// The credentials in the settings and the OCPP configuration are redacted.
+func createArchive(writer io.Writer, files Files, startTime, stopTime *time.Time) error {
+	var (
+		gzipWriter = gzip.NewWriter(writer)
+		tarWriter  = tar.NewWriter(gzipWriter)
+	)
+
+	for _, logFile := range getLogFiles(files.LogFile, startTime, stopTime) {
+		err := addFile(tarWriter, logFile, "logs")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, configFile := range []string{files.SettingsFile, files.OcppConfiguration} {
+		err := addRedactedFile(tarWriter, configFile, "configs")
+		if err != nil {
+			return err
+		}
+	}
+
+	err := addFile(tarWriter, files.AuthFile, "configs")
+	if err != nil {
+		return err
+	}
+
+	for _, connectorFile := range files.ConnectorFiles {
+		err := addFile(tarWriter, connectorFile, "connectors")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+// addRedactedFile adds a copy of the settings or OCPP configuration file without the credentials to the directory in the archive.
+// The copy keeps the format of the file. Missing files are skipped.
+func addRedactedFile(tarWriter *tar.Writer, path, dir string) error {
+	if path == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	original := viper.New()
+	original.SetConfigFile(path)
+	err := original.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
+	redacted := viper.New()
+	err = redacted.MergeConfigMap(redact(original.AllSettings()).(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := ioutil.TempDir("", "diagnostics")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	redactedPath := filepath.Join(tempDir, filepath.Base(path))
+	err = redacted.WriteConfigAs(redactedPath)
+	if err != nil {
+		return err
+	}
+
+	return addFile(tarWriter, redactedPath, dir)
+}
+
+// redact replaces the values of the sensitive settings and the values of the sensitive OCPP configuration keys.
+func redact(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		// An OCPP configuration key, e.g. {"key": "AuthorizationKey", "value": "secret"}
+		if key, isString := value["key"].(string); isString {
+			if _, isSensitive := sensitiveConfigurationKeys[key]; isSensitive {
+				value["value"] = redactedValue
+			}
+		}
+
+		for key, nested := range value {
+			if _, isSensitive := sensitiveSettings[strings.ToLower(key)]; isSensitive {
+				value[key] = redactedValue
+				continue
+			}
+
+			value[key] = redact(nested)
+		}
+	case map[interface{}]interface{}:
+		// The YAML decoder returns the maps nested in lists with interface keys
+		converted := make(map[string]interface{}, len(value))
+		for key, nested := range value {
+			converted[fmt.Sprint(key)] = nested
+		}
+
+		return redact(converted)
+	case []interface{}:
+		for i, nested := range value {
+			value[i] = redact(nested)
+		}
+	}
+
+	return value
+}
+
+// addFile adds the file to the directory in the archive. Missing files are skipped.
+func addFile(tarWriter *tar.Writer, path, dir string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.Join(dir, filepath.Base(path))
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
+
+// getLogFiles returns the log files with entries in the time window. A log file was written to between the
+// rotation time in its name (if it has one) and its modification time.
+func getLogFiles(logFile string, startTime, stopTime *time.Time) []string {
+	var logFiles []string
+
+	if logFile == "" {
+		return logFiles
+	}
+
+	matches, err := filepath.Glob(logFile + "*")
+	if err != nil {
+		return logFiles
+	}
+
+	for _, match := range matches {
+		// Skip the link to the current log file, it is included as a rotated file
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if startTime != nil && info.ModTime().Before(*startTime) {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(strings.TrimPrefix(match, logFile), ".")
+		rotatedAt, err := time.ParseInLocation(rotatedLogTimeFormat, suffix, time.Local)
+		if stopTime != nil && err == nil && rotatedAt.After(*stopTime) {
+			continue
+		}
+
+		logFiles = append(logFiles, match)
+	}
+
+	return logFiles
+}
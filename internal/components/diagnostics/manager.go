@@ -0,0 +1,150 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/avast/retry-go"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/transfer"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const uploadTimeout = 10 * time.Minute
+
+var ErrUploadInProgress = errors.New("diagnostics upload already in progress")
+
+type (
+	// StatusHandler is called every time the diagnostics status changes.
+	StatusHandler func(status firmware.DiagnosticsStatus)
+
+	Manager interface {
+		GetDiagnostics(files Files, location string, startTime, stopTime *time.Time, retries, retryInterval int) (string, error)
+		GetStatus() firmware.DiagnosticsStatus
+	}
+
+	managerImpl struct {
+		mu        sync.Mutex
+		status    firmware.DiagnosticsStatus
+		scheduler *gocron.Scheduler
+		onStatus  StatusHandler
+	}
+)
+
+func NewManager(scheduler *gocron.Scheduler, onStatus StatusHandler) Manager {
+	return &managerImpl{
+		mu:        sync.Mutex{},
+		status:    firmware.DiagnosticsStatusIdle,
+		scheduler: scheduler,
+		onStatus:  onStatus,
+	}
+}
+
+// GetDiagnostics schedules the creation of the diagnostics archive and its upload to the location. Returns the name of the archive.
+func (m *managerImpl) GetDiagnostics(files Files, location string, startTime, stopTime *time.Time, retries, retryInterval int) (string, error) {
+	if m.GetStatus() == firmware.DiagnosticsStatusUploading {
+		return "", ErrUploadInProgress
+	}
+
+	fileName := fmt.Sprintf("diagnostics-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+
+	if retries < 0 {
+		retries = 0
+	}
+
+	_, err := m.scheduler.Every(1).Seconds().LimitRunsTo(1).Do(m.upload, files, startTime, stopTime, location, fileName, retries, retryInterval)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func (m *managerImpl) GetStatus() firmware.DiagnosticsStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+func (m *managerImpl) setStatus(status firmware.DiagnosticsStatus) {
+	m.mu.Lock()
+	m.status = status
+	m.mu.Unlock()
+
+	if m.onStatus != nil {
+		m.onStatus(status)
+	}
+}
+
+// upload creates the archive, uploads it to the location and removes it afterwards.
+func (m *managerImpl) upload(files Files, startTime, stopTime *time.Time, location, fileName string, retries, retryInterval int) {
+	logInfo := log.WithField("location", location)
+
+	m.setStatus(firmware.DiagnosticsStatusUploading)
+
+	archivePath, err := writeArchive(files, fileName, startTime, stopTime)
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to create the diagnostics archive")
+		m.setStatus(firmware.DiagnosticsStatusUploadFailed)
+		return
+	}
+	defer os.Remove(archivePath)
+
+	err = retry.Do(
+		func() error {
+			archive, err := os.Open(archivePath)
+			if err != nil {
+				return retry.Unrecoverable(err)
+			}
+			defer archive.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+			defer cancel()
+
+			return transfer.Upload(ctx, location, filepath.Base(archivePath), archive)
+		},
+		retry.Attempts(uint(retries+1)),
+		retry.Delay(time.Duration(retryInterval)*time.Second),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			logInfo.WithError(err).Warnf("Diagnostics upload attempt %d failed", n+1)
+		}),
+	)
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to upload the diagnostics")
+		m.setStatus(firmware.DiagnosticsStatusUploadFailed)
+		return
+	}
+
+	logInfo.Info("Uploaded the diagnostics")
+	m.setStatus(firmware.DiagnosticsStatusUploaded)
+}
+
+// writeArchive creates the archive with the file name in the temporary directory and returns its path.
+func writeArchive(files Files, fileName string, startTime, stopTime *time.Time) (string, error) {
+	archivePath := filepath.Join(os.TempDir(), fileName)
+
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	err = createArchive(archive, files, startTime, stopTime)
+	closeErr := archive.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(archivePath)
+		return "", err
+	}
+
+	return archivePath, nil
+}
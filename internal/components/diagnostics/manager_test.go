@@ -0,0 +1,254 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ManagerTestSuite struct {
+	suite.Suite
+	scheduler *gocron.Scheduler
+	files     Files
+	mu        sync.Mutex
+	statuses  []firmware.DiagnosticsStatus
+	manager   Manager
+}
+
+func (s *ManagerTestSuite) SetupTest() {
+	var (
+		dir     = s.T().TempDir()
+		logFile = filepath.Join(dir, "chargepi.log")
+		oldLog  = logFile + ".202001010000"
+		newLog  = logFile + "." + time.Now().Format(rotatedLogTimeFormat)
+	)
+
+	s.Require().NoError(os.WriteFile(oldLog, []byte("old log"), 0644))
+	s.Require().NoError(os.Chtimes(oldLog, time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)))
+	s.Require().NoError(os.WriteFile(newLog, []byte("new log"), 0644))
+	s.Require().NoError(os.Symlink(newLog, logFile))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "settings.yaml"), []byte("chargePoint:"), 0644))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "connector-1.json"), []byte("{}"), 0644))
+
+	s.files = Files{
+		LogFile:           logFile,
+		SettingsFile:      filepath.Join(dir, "settings.yaml"),
+		OcppConfiguration: filepath.Join(dir, "configuration.json"),
+		ConnectorFiles:    []string{filepath.Join(dir, "connector-1.json")},
+	}
+
+	s.scheduler = gocron.NewScheduler(time.UTC)
+	s.scheduler.WaitForScheduleAll()
+	s.scheduler.StartAsync()
+	s.statuses = nil
+
+	s.manager = NewManager(s.scheduler, func(status firmware.DiagnosticsStatus) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.statuses = append(s.statuses, status)
+	})
+}
+
+func (s *ManagerTestSuite) TearDownTest() {
+	s.scheduler.Stop()
+}
+
+func (s *ManagerTestSuite) getStatuses() []firmware.DiagnosticsStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]firmware.DiagnosticsStatus{}, s.statuses...)
+}
+
+func (s *ManagerTestSuite) TestCreateArchive() {
+	var (
+		archivePath = filepath.Join(s.T().TempDir(), "diagnostics.tar.gz")
+		startTime   = time.Now().Add(-time.Hour)
+	)
+
+	archive, err := os.Create(archivePath)
+	s.Require().NoError(err)
+	s.Require().NoError(createArchive(archive, s.files, &startTime, nil))
+	s.Require().NoError(archive.Close())
+
+	// The old log and the missing configuration file are excluded
+	s.Assert().EqualValues([]string{
+		"configs/settings.yaml",
+		"connectors/connector-1.json",
+		"logs/" + filepath.Base(s.files.LogFile) + "." + time.Now().Format(rotatedLogTimeFormat),
+	}, s.readArchive(archivePath))
+
+	// Without the time window, all logs are included
+	archive, err = os.Create(archivePath)
+	s.Require().NoError(err)
+	s.Require().NoError(createArchive(archive, s.files, nil, nil))
+	s.Require().NoError(archive.Close())
+	s.Assert().Len(s.readArchive(archivePath), 4)
+}
+
+func (s *ManagerTestSuite) TestCreateArchiveRedacted() {
+	var (
+		dir          = s.T().TempDir()
+		archivePath  = filepath.Join(dir, "diagnostics.tar.gz")
+		settingsFile = filepath.Join(dir, "settings.yaml")
+		ocppFile     = filepath.Join(dir, "configuration.yaml")
+	)
+
+	s.Require().NoError(os.WriteFile(settingsFile, []byte(`chargePoint:
+  info:
+    id: ChargePi
+    basicAuthUser: user
+    basicAuthPass: password
+  tls:
+    isEnabled: true
+    ClientKeyPath: /etc/chargepi/client.key
+`), 0644))
+	s.Require().NoError(os.WriteFile(ocppFile, []byte(`version: 1
+keys:
+  - key: AuthorizationKey
+    readOnly: false
+    value: authorizationKey
+  - key: HeartbeatInterval
+    readOnly: false
+    value: "60"
+`), 0644))
+
+	archive, err := os.Create(archivePath)
+	s.Require().NoError(err)
+	s.Require().NoError(createArchive(archive, Files{SettingsFile: settingsFile, OcppConfiguration: ocppFile}, nil, nil))
+	s.Require().NoError(archive.Close())
+
+	contents := s.readArchiveContents(archivePath)
+	s.Require().Contains(contents, "configs/settings.yaml")
+	s.Require().Contains(contents, "configs/configuration.yaml")
+
+	s.Assert().Contains(contents["configs/settings.yaml"], "user")
+	s.Assert().NotContains(contents["configs/settings.yaml"], "password")
+	s.Assert().NotContains(contents["configs/settings.yaml"], "client.key")
+	s.Assert().Contains(contents["configs/settings.yaml"], redactedValue)
+
+	s.Assert().Contains(contents["configs/configuration.yaml"], "HeartbeatInterval")
+	s.Assert().NotContains(contents["configs/configuration.yaml"], "authorizationKey")
+	s.Assert().Contains(contents["configs/configuration.yaml"], redactedValue)
+
+	// The original files are not changed
+	data, err := os.ReadFile(settingsFile)
+	s.Require().NoError(err)
+	s.Assert().Contains(string(data), "password")
+}
+
+func (s *ManagerTestSuite) TestGetDiagnostics() {
+	var uploaded = make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		uploaded <- header.Filename
+	}))
+	defer server.Close()
+
+	fileName, err := s.manager.GetDiagnostics(s.files, server.URL, nil, nil, 0, 1)
+	s.Require().NoError(err)
+	s.Assert().NotEmpty(fileName)
+
+	select {
+	case name := <-uploaded:
+		s.Assert().EqualValues(fileName, name)
+	case <-time.After(5 * time.Second):
+		s.Fail("diagnostics not uploaded")
+	}
+
+	s.Eventually(func() bool {
+		return s.manager.GetStatus() == firmware.DiagnosticsStatusUploaded
+	}, 2*time.Second, 50*time.Millisecond)
+	s.Assert().EqualValues([]firmware.DiagnosticsStatus{firmware.DiagnosticsStatusUploading, firmware.DiagnosticsStatusUploaded}, s.getStatuses())
+
+	// The archive is removed after the upload
+	s.Assert().NoFileExists(filepath.Join(os.TempDir(), fileName))
+}
+
+func (s *ManagerTestSuite) TestGetDiagnosticsUploadFailed() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := s.manager.GetDiagnostics(s.files, server.URL, nil, nil, 1, 0)
+	s.Require().NoError(err)
+
+	s.Eventually(func() bool {
+		return s.manager.GetStatus() == firmware.DiagnosticsStatusUploadFailed
+	}, 5*time.Second, 50*time.Millisecond)
+	s.Assert().EqualValues([]firmware.DiagnosticsStatus{firmware.DiagnosticsStatusUploading, firmware.DiagnosticsStatusUploadFailed}, s.getStatuses())
+}
+
+// readArchive returns the sorted names of the files in the archive.
+func (s *ManagerTestSuite) readArchive(archivePath string) []string {
+	var names []string
+
+	archive, err := os.Open(archivePath)
+	s.Require().NoError(err)
+	defer archive.Close()
+
+	gzipReader, err := gzip.NewReader(archive)
+	s.Require().NoError(err)
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		s.Require().NoError(err)
+		names = append(names, header.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// readArchiveContents returns the contents of the files in the archive by their names.
+func (s *ManagerTestSuite) readArchiveContents(archivePath string) map[string]string {
+	contents := map[string]string{}
+
+	archive, err := os.Open(archivePath)
+	s.Require().NoError(err)
+	defer archive.Close()
+
+	gzipReader, err := gzip.NewReader(archive)
+	s.Require().NoError(err)
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		s.Require().NoError(err)
+		data, err := io.ReadAll(tarReader)
+		s.Require().NoError(err)
+		contents[header.Name] = string(data)
+	}
+
+	return contents
+}
+
+func TestDiagnostics(t *testing.T) {
+	suite.Run(t, new(ManagerTestSuite))
+}
@@ -0,0 +1,185 @@
+package displayMessages
+
+import (
+	"errors"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxMessages is the maximum number of messages stored by the charging station.
+const MaxMessages = 10
+
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	ErrMessageExpired  = errors.New("message already expired")
+	ErrTooManyMessages = errors.New("too many messages")
+)
+
+type (
+	// Manager stores the messages set by the CSMS by their id and decides which messages are displayed.
+	Manager interface {
+		SetMessage(message ocpp201.MessageInfo) error
+		RemoveMessage(id int) error
+		RemoveTransactionMessages(transactionId string)
+		// GetMessages returns the messages matching all the criteria. The empty criteria match any message.
+		GetMessages(ids []int, priority ocpp201.MessagePriority, state ocpp201.MessageState) []ocpp201.MessageInfo
+		// GetActiveMessages returns the messages to display in the state, ordered by the priority.
+		GetActiveMessages(state ocpp201.MessageState, now time.Time) []ocpp201.MessageInfo
+	}
+
+	managerImpl struct {
+		mu       sync.Mutex
+		messages map[int]ocpp201.MessageInfo
+	}
+)
+
+func NewManager() Manager {
+	return &managerImpl{
+		mu:       sync.Mutex{},
+		messages: map[int]ocpp201.MessageInfo{},
+	}
+}
+
+// SetMessage adds the message or replaces the message with the same id.
+func (m *managerImpl) SetMessage(message ocpp201.MessageInfo) error {
+	if message.EndDateTime != nil && message.EndDateTime.Before(time.Now()) {
+		return ErrMessageExpired
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired(time.Now())
+
+	if _, isFound := m.messages[message.Id]; !isFound && len(m.messages) >= MaxMessages {
+		return ErrTooManyMessages
+	}
+
+	m.messages[message.Id] = message
+	return nil
+}
+
+// RemoveMessage removes the message with the id.
+func (m *managerImpl) RemoveMessage(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, isFound := m.messages[id]; !isFound {
+		return ErrMessageNotFound
+	}
+
+	delete(m.messages, id)
+	return nil
+}
+
+// RemoveTransactionMessages removes the messages tied to the transaction, after the transaction ends.
+func (m *managerImpl) RemoveTransactionMessages(transactionId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, message := range m.messages {
+		if message.TransactionId != "" && message.TransactionId == transactionId {
+			delete(m.messages, id)
+		}
+	}
+}
+
+func (m *managerImpl) GetMessages(ids []int, priority ocpp201.MessagePriority, state ocpp201.MessageState) []ocpp201.MessageInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired(time.Now())
+
+	var messages []ocpp201.MessageInfo
+	for _, message := range m.messages {
+		if len(ids) > 0 && !containsId(ids, message.Id) {
+			continue
+		}
+
+		if priority != "" && message.Priority != priority {
+			continue
+		}
+
+		if state != "" && message.State != state {
+			continue
+		}
+
+		messages = append(messages, message)
+	}
+
+	sortMessages(messages)
+	return messages
+}
+
+// GetActiveMessages returns the messages valid at the time, which are displayed in any state or in the given state.
+// If there is an AlwaysFront message, only the AlwaysFront message is displayed.
+func (m *managerImpl) GetActiveMessages(state ocpp201.MessageState, now time.Time) []ocpp201.MessageInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeExpired(now)
+
+	var messages []ocpp201.MessageInfo
+	for _, message := range m.messages {
+		if message.StartDateTime != nil && message.StartDateTime.After(now) {
+			continue
+		}
+
+		if message.State != "" && message.State != state {
+			continue
+		}
+
+		messages = append(messages, message)
+	}
+
+	sortMessages(messages)
+
+	if len(messages) > 0 && messages[0].Priority == ocpp201.MessagePriorityAlwaysFront {
+		return messages[:1]
+	}
+
+	return messages
+}
+
+// removeExpired removes the messages past their end date. The lock must be held by the caller.
+func (m *managerImpl) removeExpired(now time.Time) {
+	for id, message := range m.messages {
+		if message.EndDateTime != nil && message.EndDateTime.Before(now) {
+			delete(m.messages, id)
+		}
+	}
+}
+
+// sortMessages orders the messages by the priority and then by the id.
+func sortMessages(messages []ocpp201.MessageInfo) {
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Priority != messages[j].Priority {
+			return priorityOrder(messages[i].Priority) < priorityOrder(messages[j].Priority)
+		}
+
+		return messages[i].Id < messages[j].Id
+	})
+}
+
+func priorityOrder(priority ocpp201.MessagePriority) int {
+	switch priority {
+	case ocpp201.MessagePriorityAlwaysFront:
+		return 0
+	case ocpp201.MessagePriorityInFront:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func containsId(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
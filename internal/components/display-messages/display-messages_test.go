@@ -0,0 +1,120 @@
+package displayMessages
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"testing"
+	"time"
+)
+
+type displayMessagesTestSuite struct {
+	suite.Suite
+	manager Manager
+}
+
+func newMessage(id int, priority ocpp201.MessagePriority, state ocpp201.MessageState, content string) ocpp201.MessageInfo {
+	return ocpp201.MessageInfo{
+		Id:       id,
+		Priority: priority,
+		State:    state,
+		Message:  ocpp201.MessageContent{Format: ocpp201.MessageFormatASCII, Content: content},
+	}
+}
+
+func (s *displayMessagesTestSuite) SetupTest() {
+	s.manager = NewManager()
+}
+
+func (s *displayMessagesTestSuite) TestSetMessage() {
+	message := newMessage(1, ocpp201.MessagePriorityNormalCycle, "", "Site closes at 22:00")
+	s.Require().NoError(s.manager.SetMessage(message))
+
+	// The message with the same id is replaced
+	message.Message.Content = "Site closes at 23:00"
+	s.Require().NoError(s.manager.SetMessage(message))
+
+	messages := s.manager.GetMessages(nil, "", "")
+	s.Require().Len(messages, 1)
+	s.Assert().EqualValues("Site closes at 23:00", messages[0].Message.Content)
+
+	// The expired message is rejected
+	expired := newMessage(2, ocpp201.MessagePriorityNormalCycle, "", "Expired")
+	expired.EndDateTime = types.NewDateTime(time.Now().Add(-time.Minute))
+	s.Assert().ErrorIs(s.manager.SetMessage(expired), ErrMessageExpired)
+
+	// The number of messages is limited
+	for i := 2; i <= MaxMessages; i++ {
+		s.Require().NoError(s.manager.SetMessage(newMessage(i, ocpp201.MessagePriorityNormalCycle, "", "Message")))
+	}
+
+	s.Assert().ErrorIs(s.manager.SetMessage(newMessage(MaxMessages+1, ocpp201.MessagePriorityNormalCycle, "", "Message")), ErrTooManyMessages)
+}
+
+func (s *displayMessagesTestSuite) TestGetMessages() {
+	s.Require().NoError(s.manager.SetMessage(newMessage(1, ocpp201.MessagePriorityNormalCycle, ocpp201.MessageStateIdle, "Idle")))
+	s.Require().NoError(s.manager.SetMessage(newMessage(2, ocpp201.MessagePriorityInFront, ocpp201.MessageStateCharging, "Charging")))
+	s.Require().NoError(s.manager.SetMessage(newMessage(3, ocpp201.MessagePriorityNormalCycle, "", "Any")))
+
+	s.Assert().Len(s.manager.GetMessages(nil, "", ""), 3)
+	s.Assert().Len(s.manager.GetMessages([]int{1, 2}, "", ""), 2)
+	s.Assert().Len(s.manager.GetMessages(nil, ocpp201.MessagePriorityNormalCycle, ""), 2)
+	s.Assert().Len(s.manager.GetMessages([]int{1}, ocpp201.MessagePriorityInFront, ""), 0)
+
+	messages := s.manager.GetMessages(nil, "", ocpp201.MessageStateCharging)
+	s.Require().Len(messages, 1)
+	s.Assert().EqualValues(2, messages[0].Id)
+}
+
+func (s *displayMessagesTestSuite) TestGetActiveMessages() {
+	var (
+		now      = time.Now()
+		upcoming = newMessage(4, ocpp201.MessagePriorityNormalCycle, "", "Upcoming")
+	)
+
+	upcoming.StartDateTime = types.NewDateTime(now.Add(time.Hour))
+
+	s.Require().NoError(s.manager.SetMessage(newMessage(1, ocpp201.MessagePriorityNormalCycle, ocpp201.MessageStateIdle, "Idle")))
+	s.Require().NoError(s.manager.SetMessage(newMessage(2, ocpp201.MessagePriorityInFront, "", "In front")))
+	s.Require().NoError(s.manager.SetMessage(newMessage(3, ocpp201.MessagePriorityNormalCycle, ocpp201.MessageStateCharging, "Charging")))
+	s.Require().NoError(s.manager.SetMessage(upcoming))
+
+	// The messages are ordered by the priority
+	messages := s.manager.GetActiveMessages(ocpp201.MessageStateIdle, now)
+	s.Require().Len(messages, 2)
+	s.Assert().EqualValues(2, messages[0].Id)
+	s.Assert().EqualValues(1, messages[1].Id)
+
+	// The message is displayed after the start date
+	messages = s.manager.GetActiveMessages(ocpp201.MessageStateCharging, now.Add(2*time.Hour))
+	s.Require().Len(messages, 3)
+	s.Assert().EqualValues(4, messages[2].Id)
+
+	// Only the AlwaysFront message is displayed
+	s.Require().NoError(s.manager.SetMessage(newMessage(5, ocpp201.MessagePriorityAlwaysFront, "", "Always front")))
+	messages = s.manager.GetActiveMessages(ocpp201.MessageStateIdle, now)
+	s.Require().Len(messages, 1)
+	s.Assert().EqualValues(5, messages[0].Id)
+}
+
+func (s *displayMessagesTestSuite) TestRemoveMessage() {
+	transactionMessage := newMessage(2, ocpp201.MessagePriorityNormalCycle, "", "Transaction")
+	transactionMessage.TransactionId = "transaction1"
+
+	s.Require().NoError(s.manager.SetMessage(newMessage(1, ocpp201.MessagePriorityNormalCycle, "", "Message")))
+	s.Require().NoError(s.manager.SetMessage(transactionMessage))
+
+	s.Assert().NoError(s.manager.RemoveMessage(1))
+	s.Assert().ErrorIs(s.manager.RemoveMessage(1), ErrMessageNotFound)
+
+	// The messages of the transaction are removed after the transaction ends
+	s.manager.RemoveTransactionMessages("transaction2")
+	s.Assert().Len(s.manager.GetMessages(nil, "", ""), 1)
+
+	s.manager.RemoveTransactionMessages("transaction1")
+	s.Assert().Empty(s.manager.GetMessages(nil, "", ""))
+}
+
+func TestDisplayMessages(t *testing.T) {
+	suite.Run(t, new(displayMessagesTestSuite))
+}
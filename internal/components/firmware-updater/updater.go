@@ -0,0 +1,303 @@
+package firmwareUpdater
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/avast/retry-go"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/transfer"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	checksumExtension   = ".sha256"
+	downloadTimeout     = 10 * time.Minute
+	installCheckTag     = "firmwareInstall"
+	installCheckSeconds = 10
+	restartDelaySeconds = 5
+)
+
+// Statuses of the signed firmware update, reported in addition to the firmware.FirmwareStatus values.
+const (
+	FirmwareStatusSignatureVerified firmware.FirmwareStatus = "SignatureVerified"
+	FirmwareStatusInvalidSignature  firmware.FirmwareStatus = "InvalidSignature"
+)
+
+var (
+	ErrUpdateInProgress = errors.New("firmware update already in progress")
+	ErrChecksumMismatch = errors.New("firmware checksum mismatch")
+	ErrInvalidChecksum  = errors.New("invalid checksum file")
+)
+
+type (
+	// StatusHandler is called every time the firmware status changes.
+	StatusHandler func(status firmware.FirmwareStatus)
+
+	// IdleCheck reports whether the firmware can be installed, i.e. there are no ongoing transactions.
+	IdleCheck func() bool
+
+	// SignatureCheck verifies the signature of the downloaded firmware file.
+	SignatureCheck func(path string) error
+
+	Updater interface {
+		UpdateFirmware(location string, retrieveDate time.Time, retries, retryInterval int) error
+		UpdateSignedFirmware(location string, retrieveDate time.Time, retries, retryInterval int, verify SignatureCheck) error
+		GetStatus() firmware.FirmwareStatus
+	}
+
+	updaterImpl struct {
+		mu         sync.Mutex
+		status     firmware.FirmwareStatus
+		binaryPath string
+		scheduler  *gocron.Scheduler
+		onStatus   StatusHandler
+		isIdle     IdleCheck
+		restart    func()
+	}
+)
+
+// NewUpdater creates a firmware updater, which replaces the binary at binaryPath with the downloaded firmware.
+// The restart function is called after the firmware is installed.
+func NewUpdater(binaryPath string, scheduler *gocron.Scheduler, onStatus StatusHandler, isIdle IdleCheck, restart func()) Updater {
+	return &updaterImpl{
+		mu:         sync.Mutex{},
+		status:     firmware.FirmwareStatusIdle,
+		binaryPath: binaryPath,
+		scheduler:  scheduler,
+		onStatus:   onStatus,
+		isIdle:     isIdle,
+		restart:    restart,
+	}
+}
+
+// UpdateFirmware schedules the download of the firmware at the retrieve date. The download is attempted retries+1 times,
+// waiting retryInterval seconds between the attempts.
+func (u *updaterImpl) UpdateFirmware(location string, retrieveDate time.Time, retries, retryInterval int) error {
+	return u.scheduleUpdate(location, retrieveDate, retries, retryInterval, nil)
+}
+
+// UpdateSignedFirmware schedules the download of the signed firmware. Instead of the checksum file, the firmware is
+// verified with the signature before it is installed.
+func (u *updaterImpl) UpdateSignedFirmware(location string, retrieveDate time.Time, retries, retryInterval int, verify SignatureCheck) error {
+	if verify == nil {
+		return errors.New("signature check not provided")
+	}
+
+	return u.scheduleUpdate(location, retrieveDate, retries, retryInterval, verify)
+}
+
+func (u *updaterImpl) scheduleUpdate(location string, retrieveDate time.Time, retries, retryInterval int, verify SignatureCheck) error {
+	switch u.GetStatus() {
+	case firmware.FirmwareStatusDownloading, firmware.FirmwareStatusDownloaded, firmware.FirmwareStatusInstalling, FirmwareStatusSignatureVerified:
+		return ErrUpdateInProgress
+	}
+
+	delay := time.Until(retrieveDate)
+	if delay < time.Second {
+		delay = time.Second
+	}
+
+	if retries < 0 {
+		retries = 0
+	}
+
+	log.WithFields(log.Fields{
+		"location":     location,
+		"retrieveDate": retrieveDate,
+		"retries":      retries,
+	}).Info("Scheduled a firmware update")
+
+	_, err := u.scheduler.Every(delay).LimitRunsTo(1).Do(u.update, location, retries, retryInterval, verify)
+	return err
+}
+
+func (u *updaterImpl) GetStatus() firmware.FirmwareStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.status
+}
+
+func (u *updaterImpl) setStatus(status firmware.FirmwareStatus) {
+	u.mu.Lock()
+	u.status = status
+	u.mu.Unlock()
+
+	if u.onStatus != nil {
+		u.onStatus(status)
+	}
+}
+
+// update downloads and verifies the firmware, then waits until the charge point is idle to install it.
+func (u *updaterImpl) update(location string, retries, retryInterval int, verify SignatureCheck) {
+	var (
+		logInfo     = log.WithField("location", location)
+		stagingPath = u.binaryPath + ".new"
+	)
+
+	u.setStatus(firmware.FirmwareStatusDownloading)
+
+	err := retry.Do(
+		func() error {
+			return u.download(location, stagingPath, verify == nil)
+		},
+		retry.Attempts(uint(retries+1)),
+		retry.Delay(time.Duration(retryInterval)*time.Second),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			logInfo.WithError(err).Warnf("Firmware download attempt %d failed", n+1)
+		}),
+	)
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to download the firmware")
+		_ = os.Remove(stagingPath)
+		u.setStatus(firmware.FirmwareStatusDownloadFailed)
+		return
+	}
+
+	u.setStatus(firmware.FirmwareStatusDownloaded)
+
+	if verify != nil {
+		err = verify(stagingPath)
+		if err != nil {
+			logInfo.WithError(err).Error("Invalid firmware signature")
+			_ = os.Remove(stagingPath)
+			u.setStatus(FirmwareStatusInvalidSignature)
+			return
+		}
+
+		u.setStatus(FirmwareStatusSignatureVerified)
+	}
+
+	_, err = u.scheduler.Every(installCheckSeconds).Seconds().Tag(installCheckTag).Do(u.tryInstall, stagingPath)
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to schedule the firmware installation")
+		u.setStatus(firmware.FirmwareStatusInstallationFailed)
+	}
+}
+
+// download fetches the firmware and its checksum, and writes the firmware to the staging path if the checksum matches.
+// The firmware is rejected if there is no checksum file at the location. The checksum is skipped for the signed firmware.
+func (u *updaterImpl) download(location, stagingPath string, withChecksum bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	var expectedChecksum []byte
+	if withChecksum {
+		var err error
+		expectedChecksum, err = downloadChecksum(ctx, location)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(stagingPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	err = transfer.Download(ctx, location, io.MultiWriter(file, hash))
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+
+	if closeErr != nil {
+		return closeErr
+	}
+
+	if withChecksum && !bytes.Equal(hash.Sum(nil), expectedChecksum) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// downloadChecksum fetches the SHA256 checksum of the firmware from the checksum file next to the firmware. The extension
+// is appended to the path, so the query of the location is kept.
+func downloadChecksum(ctx context.Context, location string) ([]byte, error) {
+	checksumUrl, err := url.Parse(location)
+	if err != nil {
+		return nil, transfer.ErrInvalidLocation
+	}
+
+	checksumUrl.Path += checksumExtension
+	if checksumUrl.RawPath != "" {
+		checksumUrl.RawPath += checksumExtension
+	}
+
+	var checksumFile bytes.Buffer
+	err = transfer.Download(ctx, checksumUrl.String(), &checksumFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot download the checksum: %w", err)
+	}
+
+	fields := strings.Fields(checksumFile.String())
+	if len(fields) == 0 {
+		return nil, ErrInvalidChecksum
+	}
+
+	checksum, err := hex.DecodeString(fields[0])
+	if err != nil || len(checksum) != sha256.Size {
+		return nil, ErrInvalidChecksum
+	}
+
+	return checksum, nil
+}
+
+// tryInstall installs the staged firmware once there are no ongoing transactions.
+func (u *updaterImpl) tryInstall(stagingPath string) {
+	if u.isIdle != nil && !u.isIdle() {
+		log.Debug("Waiting for the transactions to end before installing the firmware")
+		return
+	}
+
+	_ = u.scheduler.RemoveByTag(installCheckTag)
+	u.setStatus(firmware.FirmwareStatusInstalling)
+
+	err := u.install(stagingPath)
+	if err != nil {
+		log.WithError(err).Error("Unable to install the firmware")
+		_ = os.Remove(stagingPath)
+		u.setStatus(firmware.FirmwareStatusInstallationFailed)
+		return
+	}
+
+	u.setStatus(firmware.FirmwareStatusInstalled)
+
+	if u.restart != nil {
+		log.Info("Firmware installed, restarting")
+		_, err = u.scheduler.Every(restartDelaySeconds).Seconds().LimitRunsTo(1).Do(u.restart)
+		if err != nil {
+			log.WithError(err).Error("Unable to schedule the restart")
+		}
+	}
+}
+
+// install keeps a backup of the current binary and atomically replaces it with the staged firmware.
+func (u *updaterImpl) install(stagingPath string) error {
+	backupPath := u.binaryPath + ".bak"
+
+	_ = os.Remove(backupPath)
+	if err := os.Link(u.binaryPath, backupPath); err != nil {
+		log.WithError(err).Warn("Unable to back up the current binary")
+	}
+
+	if err := os.Chmod(stagingPath, 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(stagingPath, u.binaryPath)
+}
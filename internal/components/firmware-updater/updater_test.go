@@ -0,0 +1,200 @@
+package firmwareUpdater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/transfer"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var newFirmware = []byte("new firmware")
+
+type UpdaterTestSuite struct {
+	suite.Suite
+	server     *httptest.Server
+	scheduler  *gocron.Scheduler
+	binaryPath string
+	statuses   []firmware.FirmwareStatus
+	isIdle     bool
+	updater    *updaterImpl
+}
+
+func (s *UpdaterTestSuite) SetupSuite() {
+	checksum := sha256.Sum256(newFirmware)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chargepi", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(newFirmware)
+	})
+	mux.HandleFunc("/chargepi.sha256", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s  chargepi\n", hex.EncodeToString(checksum[:]))
+	})
+	mux.HandleFunc("/unverified", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(newFirmware)
+	})
+	mux.HandleFunc("/corrupted", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("corrupted firmware"))
+	})
+	mux.HandleFunc("/corrupted.sha256", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s  corrupted\n", hex.EncodeToString(checksum[:]))
+	})
+
+	s.server = httptest.NewServer(mux)
+}
+
+func (s *UpdaterTestSuite) TearDownSuite() {
+	s.server.Close()
+}
+
+func (s *UpdaterTestSuite) SetupTest() {
+	s.binaryPath = filepath.Join(s.T().TempDir(), "chargepi")
+	s.Require().NoError(os.WriteFile(s.binaryPath, []byte("old firmware"), 0755))
+
+	s.scheduler = gocron.NewScheduler(time.UTC)
+	s.scheduler.WaitForScheduleAll()
+	s.scheduler.StartAsync()
+	s.statuses = nil
+	s.isIdle = true
+
+	s.updater = NewUpdater(
+		s.binaryPath,
+		s.scheduler,
+		func(status firmware.FirmwareStatus) {
+			s.statuses = append(s.statuses, status)
+		},
+		func() bool {
+			return s.isIdle
+		},
+		nil,
+	).(*updaterImpl)
+}
+
+func (s *UpdaterTestSuite) TearDownTest() {
+	s.scheduler.Stop()
+}
+
+func (s *UpdaterTestSuite) TestUpdate() {
+	s.isIdle = false
+	s.updater.update(s.server.URL+"/chargepi", 0, 0, nil)
+	s.Require().EqualValues(firmware.FirmwareStatusDownloaded, s.updater.GetStatus())
+	s.Require().Len(s.scheduler.Jobs(), 1)
+
+	// Cannot schedule another update while one is in progress
+	s.Require().ErrorIs(s.updater.UpdateFirmware(s.server.URL+"/chargepi", time.Now(), 0, 0), ErrUpdateInProgress)
+
+	// Wait for the transactions to finish
+	s.updater.tryInstall(s.binaryPath + ".new")
+	s.Require().EqualValues(firmware.FirmwareStatusDownloaded, s.updater.GetStatus())
+
+	s.isIdle = true
+	s.updater.tryInstall(s.binaryPath + ".new")
+	s.Require().EqualValues(firmware.FirmwareStatusInstalled, s.updater.GetStatus())
+	s.Require().Len(s.scheduler.Jobs(), 0)
+
+	binary, err := os.ReadFile(s.binaryPath)
+	s.Require().NoError(err)
+	s.Require().EqualValues(newFirmware, binary)
+
+	backup, err := os.ReadFile(s.binaryPath + ".bak")
+	s.Require().NoError(err)
+	s.Require().EqualValues("old firmware", string(backup))
+
+	s.Require().EqualValues([]firmware.FirmwareStatus{
+		firmware.FirmwareStatusDownloading,
+		firmware.FirmwareStatusDownloaded,
+		firmware.FirmwareStatusInstalling,
+		firmware.FirmwareStatusInstalled,
+	}, s.statuses)
+}
+
+func (s *UpdaterTestSuite) TestDownloadFailed() {
+	// Checksum mismatch
+	s.updater.update(s.server.URL+"/corrupted", 1, 0, nil)
+	s.Require().EqualValues(firmware.FirmwareStatusDownloadFailed, s.updater.GetStatus())
+
+	_, err := os.Stat(s.binaryPath + ".new")
+	s.Require().True(os.IsNotExist(err))
+
+	// The firmware without the checksum file is not installed
+	s.updater.update(s.server.URL+"/unverified", 0, 0, nil)
+	s.Require().EqualValues(firmware.FirmwareStatusDownloadFailed, s.updater.GetStatus())
+
+	err = s.updater.download(s.server.URL+"/unverified", s.binaryPath+".new", true)
+	s.Require().ErrorIs(err, transfer.ErrFileNotFound)
+
+	// Firmware not found
+	s.updater.update(s.server.URL+"/missing", 0, 0, nil)
+	s.Require().EqualValues(firmware.FirmwareStatusDownloadFailed, s.updater.GetStatus())
+
+	// Unsupported scheme
+	s.updater.update("sftp://localhost/chargepi", 0, 0, nil)
+	s.Require().EqualValues(firmware.FirmwareStatusDownloadFailed, s.updater.GetStatus())
+
+	binary, err := os.ReadFile(s.binaryPath)
+	s.Require().NoError(err)
+	s.Require().EqualValues("old firmware", string(binary))
+}
+
+func (s *UpdaterTestSuite) TestDownload() {
+	stagingPath := s.binaryPath + ".new"
+
+	// The checksum extension is added to the path, not after the query
+	s.Require().NoError(s.updater.download(s.server.URL+"/chargepi?token=secret", stagingPath, true))
+
+	staged, err := os.ReadFile(stagingPath)
+	s.Require().NoError(err)
+	s.Require().EqualValues(newFirmware, staged)
+}
+
+func (s *UpdaterTestSuite) TestUpdateFirmware() {
+	s.Require().NoError(s.updater.UpdateFirmware(s.server.URL+"/chargepi", time.Now().Add(time.Hour), 3, 10))
+	s.Require().Len(s.scheduler.Jobs(), 1)
+	s.Require().EqualValues(firmware.FirmwareStatusIdle, s.updater.GetStatus())
+}
+
+func (s *UpdaterTestSuite) TestUpdateSignedFirmware() {
+	var verifiedPath string
+
+	// The signed firmware has no checksum file
+	s.updater.update(s.server.URL+"/corrupted", 0, 0, func(path string) error {
+		verifiedPath = path
+		return nil
+	})
+	s.Require().EqualValues(FirmwareStatusSignatureVerified, s.updater.GetStatus())
+	s.Require().EqualValues(s.binaryPath+".new", verifiedPath)
+	s.Require().Len(s.scheduler.Jobs(), 1)
+
+	s.Require().EqualValues([]firmware.FirmwareStatus{
+		firmware.FirmwareStatusDownloading,
+		firmware.FirmwareStatusDownloaded,
+		FirmwareStatusSignatureVerified,
+	}, s.statuses)
+}
+
+func (s *UpdaterTestSuite) TestInvalidSignature() {
+	s.updater.update(s.server.URL+"/chargepi", 0, 0, func(path string) error {
+		return errors.New("invalid signature")
+	})
+	s.Require().EqualValues(FirmwareStatusInvalidSignature, s.updater.GetStatus())
+	s.Require().Len(s.scheduler.Jobs(), 0)
+
+	_, err := os.Stat(s.binaryPath + ".new")
+	s.Require().True(os.IsNotExist(err))
+
+	// The signature check is mandatory
+	s.Require().Error(s.updater.UpdateSignedFirmware(s.server.URL+"/chargepi", time.Now(), 0, 0, nil))
+}
+
+func TestUpdater(t *testing.T) {
+	suite.Run(t, new(UpdaterTestSuite))
+}
@@ -5,11 +5,16 @@ import (
 	"errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const (
 	DriverHD44780 = "hd44780"
+
+	// LineLength is the number of characters in a line of the LCD.
+	LineLength = 16
 )
 
 var (
@@ -43,6 +48,43 @@ func NewMessage(duration time.Duration, messages []string) LCDMessage {
 	}
 }
 
+// SplitLines splits the text into the lines of the LCD. The words are kept on the same line, unless they are longer than the line.
+func SplitLines(text string) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, field := range strings.Fields(text) {
+		word := []rune(field)
+		for len(word) > LineLength {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			lines = append(lines, string(word[:LineLength]))
+			word = word[LineLength:]
+		}
+
+		switch {
+		case line == "":
+			line = string(word)
+		case utf8.RuneCountInString(line)+len(word)+1 <= LineLength:
+			line += " " + string(word)
+		default:
+			lines = append(lines, line)
+			line = string(word)
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
 // NewDisplay returns a concrete implementation of an LCD based on the drivers that are supported.
 // The LCD is built with the settings from the settings file.
 func NewDisplay(lcdSettings settings.Lcd) (LCD, error) {
@@ -57,6 +57,14 @@ func init() {
 			ID:    "ConnectorFaulted",
 			Other: "has faulted.",
 		})
+		addDefaultMessage(i18n.Message{
+			ID:    "RunningCost",
+			Other: "Cost: {{.Cost}}",
+		})
+		addDefaultMessage(i18n.Message{
+			ID:    "TotalCost",
+			Other: "Total cost:",
+		})
 		addDefaultMessage(i18n.Message{
 			ID:    "WelcomeMessage",
 			Other: "Welcome to",
@@ -65,6 +73,18 @@ func init() {
 			ID:    "WelcomeMessage2",
 			Other: "ChargePi!",
 		})
+		addDefaultMessage(i18n.Message{
+			ID:    "ConnectionTemplate",
+			Other: "Central system",
+		})
+		addDefaultMessage(i18n.Message{
+			ID:    "ConnectionOnline",
+			Other: "online.",
+		})
+		addDefaultMessage(i18n.Message{
+			ID:    "ConnectionOffline",
+			Other: "offline.",
+		})
 	})
 }
 
@@ -52,6 +52,32 @@ func TranslateConnectorChargingMessage(lang string, connectorId int) ([]string,
 	return []string{firstPart, secondPart}, nil
 }
 
+// TranslateRunningCostMessage translates the cost of the ongoing transaction at the connector. The cost includes the currency.
+func TranslateRunningCostMessage(lang string, connectorId int, cost string) ([]string, error) {
+	data := make(map[string]interface{})
+	data["Id"] = connectorId
+	costData := make(map[string]interface{})
+	costData["Cost"] = cost
+
+	firstPart, err := Localize(lang, "ConnectorTemplate", data, nil)
+	secondPart, err := Localize(lang, "RunningCost", costData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{firstPart, secondPart}, nil
+}
+
+// TranslateTotalCostMessage translates the final cost of the transaction. The cost includes the currency.
+func TranslateTotalCostMessage(lang string, cost string) ([]string, error) {
+	firstPart, err := Localize(lang, "TotalCost", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{firstPart, cost}, nil
+}
+
 func TranslateWelcomeMessage(lang string) ([]string, error) {
 	firstPart, err := Localize(lang, "WelcomeMessage", nil, nil)
 	secondPart, err := Localize(lang, "WelcomeMessage2", nil, nil)
@@ -61,3 +87,18 @@ func TranslateWelcomeMessage(lang string) ([]string, error) {
 
 	return []string{firstPart, secondPart}, nil
 }
+
+func TranslateConnectionMessage(lang string, isOnline bool) ([]string, error) {
+	messageId := "ConnectionOffline"
+	if isOnline {
+		messageId = "ConnectionOnline"
+	}
+
+	firstPart, err := Localize(lang, "ConnectionTemplate", nil, nil)
+	secondPart, err := Localize(lang, messageId, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{firstPart, secondPart}, nil
+}
@@ -41,6 +41,7 @@ const (
 	RmsVoltageRegister              = 0x0C << 1
 	TimeBaseCaliRegister            = 0x0D << 1
 	StatusRegister                  = 0x0F << 1
+	TemperatureRegister             = 0x13 << 1
 	InterruptMaskRegister           = 0x1A << 1
 	WriteRegister           int32   = 0x40
 	ReadRegister            int32   = ^WriteRegister
@@ -50,6 +51,13 @@ const (
 	ConversionReady                 = 0x01 << 20
 )
 
+const (
+	// RegisterFullScale is the value of the 24-bit signed registers at the full scale of the input ranges
+	RegisterFullScale float64 = 0x01 << 23
+	// ComputationCycle is the time in hours, in which the energy register accumulates the energy (4000 conversions at 4 kHz)
+	ComputationCycle float64 = 1.0 / 3600
+)
+
 type C5460A struct {
 	EnablePin            int
 	chipSelect           *gpiod.Line
@@ -59,6 +67,7 @@ type C5460A struct {
 	VoltageMultiplier    float64
 	CurrentMultiplier    float64
 	PowerMultiplier      float64
+	EnergyMultiplier     float64
 }
 
 func NewCS5460PowerMeter(enablePin int, spiBus int, voltageDividerOffset float64, shuntOffset float64) (*C5460A, error) {
@@ -93,6 +102,7 @@ func (receiver *C5460A) init() error {
 	receiver.VoltageMultiplier = VoltageRange * receiver.VoltageDividerOffset
 	receiver.CurrentMultiplier = CurrentRange * receiver.ShuntOffset
 	receiver.PowerMultiplier = receiver.VoltageDividerOffset * receiver.ShuntOffset
+	receiver.EnergyMultiplier = receiver.PowerMultiplier * ComputationCycle
 	// Refer to gpiod docs
 	c, err := gpiod.NewChip("gpiochip0")
 	if err != nil {
@@ -194,9 +204,30 @@ func (receiver *C5460A) setCurrentOffset(value int32) {
 	receiver.startConverting()
 }
 
+// GetEnergy reads the energy register in Wh. The register holds the energy of the last computation cycle only, as a
+// signed fraction of the full scale energy, which is accumulated during a computation cycle at the full scale power.
+// The register is cleared at the start of every cycle, so the total energy is counted by the energy register wrapper.
 func (receiver *C5460A) GetEnergy() float64 {
-	return float64(receiver.readFromRegister(TotalEnergyRegister) << 16)
+	value := receiver.readFromRegister(TotalEnergyRegister)
+	if value&SignBit != 0 {
+		value -= 1 << 24
+	}
+
+	return float64(value) / RegisterFullScale * receiver.EnergyMultiplier
 }
+
+// ReadCycleEnergy reads the energy register if a computation cycle ended since the last read, which is signalled by the
+// DataReady bit of the status register.
+func (receiver *C5460A) ReadCycleEnergy() (float64, bool) {
+	if receiver.getStatus()&DataReady == 0 {
+		return 0, false
+	}
+
+	energy := receiver.GetEnergy()
+	receiver.clearStatus(DataReady)
+	return energy, true
+}
+
 func (receiver *C5460A) GetPower() float64 {
 	return float64(receiver.readFromRegister(LastPowerRegister)) * receiver.PowerMultiplier
 }
@@ -212,3 +243,13 @@ func (receiver *C5460A) GetRMSCurrent() float64 {
 func (receiver *C5460A) GetRMSVoltage() float64 {
 	return float64(receiver.readFromRegister(RmsVoltageRegister)) * receiver.VoltageMultiplier
 }
+
+// GetTemperature reads the temperature register. The register is a signed value in °C with 16 fractional bits.
+func (receiver *C5460A) GetTemperature() float64 {
+	value := receiver.readFromRegister(TemperatureRegister)
+	if value&SignBit != 0 {
+		value -= 1 << 24
+	}
+
+	return float64(value) / (1 << 16)
+}
@@ -0,0 +1,108 @@
+package powerMeter
+
+import (
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is shorter than the computation cycle of the power meter, so no cycle is missed.
+	pollInterval = 250 * time.Millisecond
+	// persistThreshold is the energy in Wh counted before the state of the register is persisted again, so the
+	// storage is not written on every computation cycle.
+	persistThreshold = 100.0
+)
+
+type (
+	// CycleMeter is a power meter, whose energy register holds only the energy of the last computation cycle and is
+	// cleared at the start of every cycle, like the CS5460A.
+	CycleMeter interface {
+		PowerMeter
+		// ReadCycleEnergy returns the energy of the last computation cycle in Wh and true, if the cycle ended after
+		// the previous read.
+		ReadCycleEnergy() (float64, bool)
+	}
+
+	// Persister is a power meter, which keeps its state in memory and persists it only periodically.
+	Persister interface {
+		// Persist passes the current state to the handler, regardless of the energy counted since the last time.
+		Persist()
+	}
+
+	// energyRegister counts the total energy of a power meter by adding up the energy of every computation cycle.
+	energyRegister struct {
+		CycleMeter
+		mu        sync.Mutex
+		energy    float64
+		persisted float64
+		onChange  func(state settings.EnergyRegister)
+	}
+)
+
+// NewEnergyRegister wraps the power meter, so it counts the total energy across the computation cycles. The register
+// continues from the stored state and passes the state to the handler every persistThreshold Wh and when Persist is
+// called, so it can be persisted. The power meter is polled for as long as the client runs.
+func NewEnergyRegister(meter CycleMeter, state settings.EnergyRegister, onChange func(state settings.EnergyRegister)) PowerMeter {
+	register := newEnergyRegister(meter, state, onChange)
+	go register.run()
+	return register
+}
+
+func newEnergyRegister(meter CycleMeter, state settings.EnergyRegister, onChange func(state settings.EnergyRegister)) *energyRegister {
+	return &energyRegister{
+		CycleMeter: meter,
+		energy:     state.Energy,
+		persisted:  state.Energy,
+		onChange:   onChange,
+	}
+}
+
+// GetEnergy returns the energy in Wh, counted since the power meter was created.
+func (r *energyRegister) GetEnergy() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.energy
+}
+
+// Persist passes the current state of the register to the handler.
+func (r *energyRegister) Persist() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify()
+}
+
+func (r *energyRegister) run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.accumulate()
+	}
+}
+
+// accumulate adds the energy of the last computation cycle, if it ended since the last poll.
+func (r *energyRegister) accumulate() {
+	cycleEnergy, isReady := r.CycleMeter.ReadCycleEnergy()
+
+	// The charge point only imports the energy, the negative readings are noise around zero
+	if !isReady || cycleEnergy <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.energy += cycleEnergy
+	if r.energy-r.persisted >= persistThreshold {
+		r.notify()
+	}
+}
+
+func (r *energyRegister) notify() {
+	r.persisted = r.energy
+
+	if r.onChange != nil {
+		r.onChange(settings.EnergyRegister{Energy: r.energy})
+	}
+}
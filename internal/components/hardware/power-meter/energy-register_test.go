@@ -0,0 +1,83 @@
+package powerMeter
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"testing"
+)
+
+type (
+	powerMeterMock struct {
+		mock.Mock
+		PowerMeter
+	}
+
+	EnergyRegisterTestSuite struct {
+		suite.Suite
+	}
+)
+
+func (p *powerMeterMock) ReadCycleEnergy() (float64, bool) {
+	args := p.Called()
+	return args.Get(0).(float64), args.Bool(1)
+}
+
+func (s *EnergyRegisterTestSuite) TestGetEnergy() {
+	meter := new(powerMeterMock)
+	register := newEnergyRegister(meter, settings.EnergyRegister{}, nil)
+
+	meter.On("ReadCycleEnergy").Return(2.0, true).Once()
+	register.accumulate()
+	s.Require().EqualValues(2, register.GetEnergy())
+
+	// The energy register is cleared every computation cycle, so the energy of every cycle is added
+	meter.On("ReadCycleEnergy").Return(1.5, true).Once()
+	register.accumulate()
+	s.Require().EqualValues(3.5, register.GetEnergy())
+
+	// The cycle did not end since the last poll
+	meter.On("ReadCycleEnergy").Return(1.5, false).Once()
+	register.accumulate()
+	s.Require().EqualValues(3.5, register.GetEnergy())
+
+	// The noise without a load is ignored
+	meter.On("ReadCycleEnergy").Return(-0.01, true).Once()
+	register.accumulate()
+	s.Require().EqualValues(3.5, register.GetEnergy())
+}
+
+func (s *EnergyRegisterTestSuite) TestPersistence() {
+	var (
+		meter  = new(powerMeterMock)
+		stored []settings.EnergyRegister
+	)
+
+	// The register continues from the stored state after a restart
+	register := newEnergyRegister(meter, settings.EnergyRegister{Energy: 1000}, func(state settings.EnergyRegister) {
+		stored = append(stored, state)
+	})
+	s.Require().EqualValues(1000, register.GetEnergy())
+
+	// The state is not persisted on every computation cycle
+	meter.On("ReadCycleEnergy").Return(60.0, true).Once()
+	register.accumulate()
+	s.Require().EqualValues(1060, register.GetEnergy())
+	s.Assert().Empty(stored)
+
+	meter.On("ReadCycleEnergy").Return(40.0, true).Once()
+	register.accumulate()
+	s.Assert().Equal([]settings.EnergyRegister{{Energy: 1100}}, stored)
+
+	// The state is persisted on demand, e.g. at the end of a transaction
+	meter.On("ReadCycleEnergy").Return(5.0, true).Once()
+	register.accumulate()
+	s.Assert().Len(stored, 1)
+
+	register.Persist()
+	s.Assert().Equal([]settings.EnergyRegister{{Energy: 1100}, {Energy: 1105}}, stored)
+}
+
+func TestEnergyRegister(t *testing.T) {
+	suite.Run(t, new(EnergyRegisterTestSuite))
+}
@@ -2,6 +2,7 @@ package powerMeter
 
 import (
 	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 )
@@ -14,34 +15,44 @@ const (
 var (
 	ErrPowerMeterUnsupported = errors.New("power meter type not supported")
 	ErrPowerMeterDisabled    = errors.New("power meter not enabled")
+	ErrMeasurandUnsupported  = errors.New("measurand not supported")
 )
 
 // PowerMeter is an abstraction for measurement hardware.
 type (
 	PowerMeter interface {
 		Reset()
+		// GetEnergy returns the energy in Wh. The power meters created by NewPowerMeter return the total energy.
 		GetEnergy() float64
 		GetPower() float64
 		GetCurrent() float64
 		GetVoltage() float64
 		GetRMSCurrent() float64
 		GetRMSVoltage() float64
+		// GetTemperature returns the temperature of the power meter in °C.
+		GetTemperature() float64
 	}
 )
 
-// NewPowerMeter creates a new power meter based on the connector settings.
-func NewPowerMeter(meterSettings settings.PowerMeter) (PowerMeter, error) {
+// NewPowerMeter creates a new power meter based on the connector settings. The energy register continues from the
+// state in the settings and its state is passed to the handler periodically.
+func NewPowerMeter(meterSettings settings.PowerMeter, onEnergyRegisterChange func(state settings.EnergyRegister)) (PowerMeter, error) {
 	if meterSettings.Enabled {
 		log.Infof("Creating a new power meter: %s", meterSettings.Type)
 
 		switch meterSettings.Type {
 		case TypeC5460A:
-			return NewCS5460PowerMeter(
+			meter, err := NewCS5460PowerMeter(
 				meterSettings.PowerMeterPin,
 				meterSettings.SpiBus,
-				meterSettings.ShuntOffset,
 				meterSettings.VoltageDividerOffset,
+				meterSettings.ShuntOffset,
 			)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewEnergyRegister(meter, meterSettings.EnergyRegister, onEnergyRegisterChange), nil
 		default:
 			return nil, ErrPowerMeterUnsupported
 		}
@@ -49,3 +60,21 @@ func NewPowerMeter(meterSettings settings.PowerMeter) (PowerMeter, error) {
 
 	return nil, ErrPowerMeterDisabled
 }
+
+// ReadMeasurand reads the measurand from the power meter. ErrMeasurandUnsupported is returned if the power meter cannot
+// measure it, so the zero readings can be told apart from the unsupported measurands.
+func ReadMeasurand(meter PowerMeter, measurand types.Measurand) (float64, error) {
+	switch measurand {
+	case types.MeasurandEnergyActiveImportInterval, types.MeasurandEnergyActiveImportRegister,
+		types.MeasurandEnergyActiveExportInterval, types.MeasurandEnergyActiveExportRegister:
+		return meter.GetEnergy(), nil
+	case types.MeasurandCurrentImport, types.MeasurandCurrentExport:
+		return meter.GetCurrent(), nil
+	case types.MeasurandPowerActiveImport, types.MeasurandPowerActiveExport:
+		return meter.GetPower(), nil
+	case types.MeasurandVoltage:
+		return meter.GetVoltage(), nil
+	default:
+		return 0, ErrMeasurandUnsupported
+	}
+}
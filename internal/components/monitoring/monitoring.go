@@ -0,0 +1,369 @@
+package monitoring
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMonitorNotFound  = errors.New("monitor not found")
+	ErrDuplicateMonitor = errors.New("monitor already exists")
+)
+
+type (
+	// Monitor triggers the events of a variable. The Value is the threshold, the delta or the interval in seconds,
+	// depending on the type. The monitor with Transaction set is active only during a transaction.
+	Monitor struct {
+		Id          int
+		Component   ocpp201.Component
+		Variable    ocpp201.Variable
+		Type        ocpp201.MonitorType
+		Value       float64
+		Severity    int
+		Transaction bool
+		// Kind is either a PreconfiguredMonitor or a CustomMonitor set by the CSMS.
+		Kind ocpp201.EventNotificationType
+	}
+
+	// Event is an event with the severity of the monitor that triggered it.
+	Event struct {
+		Data     ocpp201.EventData
+		Severity int
+	}
+
+	// Manager holds the monitors of the variables and triggers the events when the values of the variables change.
+	Manager interface {
+		// SetDefaults sets the preconfigured monitors, which are restored by the FactoryDefault monitoring base.
+		SetDefaults(monitors []Monitor)
+		// SetMonitor adds the monitor and returns its id. The monitor with an id replaces the existing monitor.
+		SetMonitor(monitor Monitor) (int, error)
+		ClearMonitor(id int) error
+		GetMonitors() []Monitor
+		SetMonitoringBase(base ocpp201.MonitoringBase)
+		// Evaluate checks the value of the variable against its monitors and returns the triggered events.
+		Evaluate(component ocpp201.Component, variable ocpp201.Variable, value, transactionId string, now time.Time) []Event
+		// Notify returns the hard-wired notification of a problem, which is reported regardless of the monitors.
+		Notify(component ocpp201.Component, variable ocpp201.Variable, value, techCode string, severity int, cleared bool, now time.Time) Event
+	}
+
+	monitorState struct {
+		Monitor
+		hasReference bool
+		reference    string
+		isAlerting   bool
+		nextReport   time.Time
+	}
+
+	managerImpl struct {
+		mu          sync.Mutex
+		defaults    []Monitor
+		monitors    map[int]*monitorState
+		nextId      int
+		lastEventId int
+	}
+)
+
+func NewManager() Manager {
+	return &managerImpl{
+		mu:       sync.Mutex{},
+		monitors: map[int]*monitorState{},
+		nextId:   1,
+	}
+}
+
+// SetDefaults replaces the preconfigured monitors. The custom monitors are kept.
+func (m *managerImpl) SetDefaults(monitors []Monitor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.defaults = nil
+	for _, monitor := range monitors {
+		monitor.Id = m.nextId
+		monitor.Kind = ocpp201.EventNotificationTypePreconfiguredMonitor
+		m.nextId++
+		m.defaults = append(m.defaults, monitor)
+	}
+
+	m.removeKind(ocpp201.EventNotificationTypePreconfiguredMonitor)
+	m.restoreDefaults()
+}
+
+func (m *managerImpl) SetMonitor(monitor Monitor) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if monitor.Kind == "" {
+		monitor.Kind = ocpp201.EventNotificationTypeCustomMonitor
+	}
+
+	if monitor.Id != 0 {
+		existing, isFound := m.monitors[monitor.Id]
+		if !isFound {
+			return 0, ErrMonitorNotFound
+		}
+
+		// The preconfigured monitor stays preconfigured
+		monitor.Kind = existing.Kind
+		m.monitors[monitor.Id] = &monitorState{Monitor: monitor}
+		return monitor.Id, nil
+	}
+
+	for _, existing := range m.monitors {
+		if isSameComponent(existing.Component, monitor.Component) && existing.Variable == monitor.Variable &&
+			existing.Type == monitor.Type && existing.Severity == monitor.Severity {
+			return 0, ErrDuplicateMonitor
+		}
+	}
+
+	monitor.Id = m.nextId
+	m.nextId++
+	m.monitors[monitor.Id] = &monitorState{Monitor: monitor}
+	return monitor.Id, nil
+}
+
+func (m *managerImpl) ClearMonitor(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, isFound := m.monitors[id]; !isFound {
+		return ErrMonitorNotFound
+	}
+
+	delete(m.monitors, id)
+	return nil
+}
+
+// GetMonitors returns the active monitors ordered by the id.
+func (m *managerImpl) GetMonitors() []Monitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var monitors []Monitor
+	for _, state := range m.monitors {
+		monitors = append(monitors, state.Monitor)
+	}
+
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Id < monitors[j].Id
+	})
+
+	return monitors
+}
+
+// SetMonitoringBase activates the monitors of the base. All activates the preconfigured monitors and keeps the custom
+// monitors, FactoryDefault restores only the preconfigured monitors and HardWiredOnly removes all monitors.
+func (m *managerImpl) SetMonitoringBase(base ocpp201.MonitoringBase) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch base {
+	case ocpp201.MonitoringBaseAll:
+		m.restoreDefaults()
+	case ocpp201.MonitoringBaseFactoryDefault:
+		m.monitors = map[int]*monitorState{}
+		m.restoreDefaults()
+	case ocpp201.MonitoringBaseHardWiredOnly:
+		m.monitors = map[int]*monitorState{}
+	}
+}
+
+func (m *managerImpl) Evaluate(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	value, transactionId string,
+	now time.Time,
+) []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []Event
+	for _, state := range m.sortedMonitors() {
+		if !isSameComponent(state.Component, component) || state.Variable != variable {
+			continue
+		}
+
+		if state.Transaction && transactionId == "" {
+			continue
+		}
+
+		trigger, cleared, isTriggered := state.evaluate(value, now)
+		if !isTriggered {
+			continue
+		}
+
+		monitorId := state.Id
+		event := m.newEvent(component, variable, value, trigger, state.Kind, now)
+		event.Data.Cleared = cleared
+		event.Data.TransactionId = transactionId
+		event.Data.VariableMonitoringId = &monitorId
+		event.Severity = state.Severity
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func (m *managerImpl) Notify(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	value, techCode string,
+	severity int,
+	cleared bool,
+	now time.Time,
+) Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event := m.newEvent(component, variable, value, ocpp201.EventTriggerAlerting, ocpp201.EventNotificationTypeHardWiredNotification, now)
+	event.Data.TechCode = techCode
+	event.Data.Cleared = cleared
+	event.Severity = severity
+	return event
+}
+
+// newEvent creates an event with the next event id. The lock must be held by the caller.
+func (m *managerImpl) newEvent(
+	component ocpp201.Component,
+	variable ocpp201.Variable,
+	value string,
+	trigger ocpp201.EventTrigger,
+	kind ocpp201.EventNotificationType,
+	now time.Time,
+) Event {
+	m.lastEventId++
+
+	return Event{
+		Data: ocpp201.EventData{
+			EventId:               m.lastEventId,
+			Timestamp:             types.NewDateTime(now),
+			Trigger:               trigger,
+			ActualValue:           value,
+			Component:             component,
+			Variable:              variable,
+			EventNotificationType: kind,
+		},
+	}
+}
+
+// restoreDefaults adds the preconfigured monitors, which were removed or changed. The lock must be held by the caller.
+func (m *managerImpl) restoreDefaults() {
+	for _, monitor := range m.defaults {
+		m.monitors[monitor.Id] = &monitorState{Monitor: monitor}
+	}
+}
+
+// removeKind removes the monitors of the kind. The lock must be held by the caller.
+func (m *managerImpl) removeKind(kind ocpp201.EventNotificationType) {
+	for id, state := range m.monitors {
+		if state.Kind == kind {
+			delete(m.monitors, id)
+		}
+	}
+}
+
+// sortedMonitors returns the monitors ordered by the id, so the events are always in the same order. The lock must be held by the caller.
+func (m *managerImpl) sortedMonitors() []*monitorState {
+	var monitors []*monitorState
+	for _, state := range m.monitors {
+		monitors = append(monitors, state)
+	}
+
+	sort.Slice(monitors, func(i, j int) bool {
+		return monitors[i].Id < monitors[j].Id
+	})
+
+	return monitors
+}
+
+// evaluate checks if the value triggers the monitor. The threshold monitors trigger an event when the value crosses
+// the threshold and a cleared event when the value returns. The delta monitors trigger an event when the value changes
+// by more than the delta from the last reported value, or on any change of a non-numeric value. The periodic monitors
+// trigger an event every interval.
+func (s *monitorState) evaluate(value string, now time.Time) (ocpp201.EventTrigger, bool, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	isNumeric := err == nil
+
+	switch s.Type {
+	case ocpp201.MonitorTypeUpperThreshold, ocpp201.MonitorTypeLowerThreshold:
+		if !isNumeric {
+			return "", false, false
+		}
+
+		isExceeded := number > s.Value
+		if s.Type == ocpp201.MonitorTypeLowerThreshold {
+			isExceeded = number < s.Value
+		}
+
+		if isExceeded == s.isAlerting {
+			return "", false, false
+		}
+
+		s.isAlerting = isExceeded
+		return ocpp201.EventTriggerAlerting, !isExceeded, true
+	case ocpp201.MonitorTypeDelta:
+		if !s.hasReference {
+			s.hasReference = true
+			s.reference = value
+			return "", false, false
+		}
+
+		reference, err := strconv.ParseFloat(s.reference, 64)
+		if isNumeric && err == nil {
+			if math.Abs(number-reference) < s.Value {
+				return "", false, false
+			}
+		} else if value == s.reference {
+			return "", false, false
+		}
+
+		s.reference = value
+		return ocpp201.EventTriggerDelta, false, true
+	case ocpp201.MonitorTypePeriodic, ocpp201.MonitorTypePeriodicClockAligned:
+		interval := time.Duration(s.Value * float64(time.Second))
+		if interval <= 0 {
+			return "", false, false
+		}
+
+		isDue := !s.nextReport.IsZero() && !now.Before(s.nextReport)
+		if !s.nextReport.IsZero() && !isDue {
+			return "", false, false
+		}
+
+		if s.Type == ocpp201.MonitorTypePeriodicClockAligned {
+			midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			s.nextReport = midnight.Add((now.Sub(midnight)/interval + 1) * interval)
+		} else {
+			s.nextReport = now.Add(interval)
+		}
+
+		return ocpp201.EventTriggerPeriodic, false, isDue
+	default:
+		return "", false, false
+	}
+}
+
+func isSameComponent(a, b ocpp201.Component) bool {
+	if a.Name != b.Name || a.Instance != b.Instance {
+		return false
+	}
+
+	if a.Evse == nil || b.Evse == nil {
+		return a.Evse == b.Evse
+	}
+
+	return a.Evse.Id == b.Evse.Id && getConnectorId(a.Evse) == getConnectorId(b.Evse)
+}
+
+func getConnectorId(evse *ocpp201.EVSE) int {
+	if evse.ConnectorId == nil {
+		return 0
+	}
+
+	return *evse.ConnectorId
+}
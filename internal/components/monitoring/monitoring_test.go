@@ -0,0 +1,173 @@
+package monitoring
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"testing"
+	"time"
+)
+
+var (
+	connectorId = 1
+	connector   = ocpp201.Component{Name: "Connector", Evse: &ocpp201.EVSE{Id: 1, ConnectorId: &connectorId}}
+	temperature = ocpp201.Variable{Name: "Temperature"}
+	voltage     = ocpp201.Variable{Name: "Voltage"}
+)
+
+type monitoringTestSuite struct {
+	suite.Suite
+	manager Manager
+}
+
+func newMonitor(monitorType ocpp201.MonitorType, variable ocpp201.Variable, value float64) Monitor {
+	return Monitor{
+		Component: connector,
+		Variable:  variable,
+		Type:      monitorType,
+		Value:     value,
+		Severity:  5,
+	}
+}
+
+func (s *monitoringTestSuite) SetupTest() {
+	s.manager = NewManager()
+	s.manager.SetDefaults([]Monitor{newMonitor(ocpp201.MonitorTypeUpperThreshold, temperature, 80)})
+}
+
+func (s *monitoringTestSuite) TestSetMonitor() {
+	id, err := s.manager.SetMonitor(newMonitor(ocpp201.MonitorTypeDelta, voltage, 10))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, id)
+
+	// The same type and severity of the variable
+	_, err = s.manager.SetMonitor(newMonitor(ocpp201.MonitorTypeDelta, voltage, 20))
+	s.Assert().ErrorIs(err, ErrDuplicateMonitor)
+
+	// The monitor with the id is replaced
+	monitor := newMonitor(ocpp201.MonitorTypeDelta, voltage, 20)
+	monitor.Id = id
+	_, err = s.manager.SetMonitor(monitor)
+	s.Require().NoError(err)
+
+	monitor.Id = 100
+	_, err = s.manager.SetMonitor(monitor)
+	s.Assert().ErrorIs(err, ErrMonitorNotFound)
+
+	monitors := s.manager.GetMonitors()
+	s.Require().Len(monitors, 2)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypePreconfiguredMonitor, monitors[0].Kind)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypeCustomMonitor, monitors[1].Kind)
+	s.Assert().EqualValues(20, monitors[1].Value)
+
+	s.Assert().NoError(s.manager.ClearMonitor(id))
+	s.Assert().ErrorIs(s.manager.ClearMonitor(id), ErrMonitorNotFound)
+}
+
+func (s *monitoringTestSuite) TestSetMonitoringBase() {
+	_, err := s.manager.SetMonitor(newMonitor(ocpp201.MonitorTypeDelta, voltage, 10))
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.ClearMonitor(1))
+
+	// The preconfigured monitor is restored
+	s.manager.SetMonitoringBase(ocpp201.MonitoringBaseAll)
+	s.Assert().Len(s.manager.GetMonitors(), 2)
+
+	s.manager.SetMonitoringBase(ocpp201.MonitoringBaseFactoryDefault)
+	monitors := s.manager.GetMonitors()
+	s.Require().Len(monitors, 1)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypePreconfiguredMonitor, monitors[0].Kind)
+
+	s.manager.SetMonitoringBase(ocpp201.MonitoringBaseHardWiredOnly)
+	s.Assert().Empty(s.manager.GetMonitors())
+}
+
+func (s *monitoringTestSuite) TestThreshold() {
+	now := time.Now()
+
+	s.Assert().Empty(s.manager.Evaluate(connector, temperature, "50.0", "", now))
+
+	events := s.manager.Evaluate(connector, temperature, "85.0", "", now)
+	s.Require().Len(events, 1)
+	s.Assert().EqualValues(ocpp201.EventTriggerAlerting, events[0].Data.Trigger)
+	s.Assert().EqualValues("85.0", events[0].Data.ActualValue)
+	s.Assert().EqualValues(1, *events[0].Data.VariableMonitoringId)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypePreconfiguredMonitor, events[0].Data.EventNotificationType)
+	s.Assert().False(events[0].Data.Cleared)
+
+	// The event is not repeated while the threshold is exceeded
+	s.Assert().Empty(s.manager.Evaluate(connector, temperature, "90.0", "", now))
+
+	events = s.manager.Evaluate(connector, temperature, "70.0", "", now)
+	s.Require().Len(events, 1)
+	s.Assert().True(events[0].Data.Cleared)
+
+	// Other variables and components are not monitored
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "90.0", "", now))
+	s.Assert().Empty(s.manager.Evaluate(ocpp201.Component{Name: "Connector"}, temperature, "90.0", "", now))
+}
+
+func (s *monitoringTestSuite) TestDelta() {
+	var (
+		now     = time.Now()
+		contact = ocpp201.Component{Name: "PowerContactor", Evse: connector.Evse}
+		active  = ocpp201.Variable{Name: "Active"}
+	)
+
+	_, err := s.manager.SetMonitor(newMonitor(ocpp201.MonitorTypeDelta, voltage, 10))
+	s.Require().NoError(err)
+	_, err = s.manager.SetMonitor(Monitor{Component: contact, Variable: active, Type: ocpp201.MonitorTypeDelta})
+	s.Require().NoError(err)
+
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "230", "", now))
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "235", "", now))
+
+	events := s.manager.Evaluate(connector, voltage, "241", "", now)
+	s.Require().Len(events, 1)
+	s.Assert().EqualValues(ocpp201.EventTriggerDelta, events[0].Data.Trigger)
+
+	// The delta is measured from the last reported value
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "235", "", now))
+
+	// Any change of a non-numeric value is reported
+	s.Assert().Empty(s.manager.Evaluate(contact, active, "false", "", now))
+	s.Assert().Len(s.manager.Evaluate(contact, active, "true", "", now), 1)
+	s.Assert().Empty(s.manager.Evaluate(contact, active, "true", "", now))
+}
+
+func (s *monitoringTestSuite) TestPeriodic() {
+	now := time.Now()
+
+	monitor := newMonitor(ocpp201.MonitorTypePeriodic, voltage, 60)
+	monitor.Transaction = true
+	_, err := s.manager.SetMonitor(monitor)
+	s.Require().NoError(err)
+
+	// The monitor is active only during the transaction
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "230", "", now.Add(time.Hour)))
+
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "230", "transaction1", now))
+	s.Assert().Empty(s.manager.Evaluate(connector, voltage, "230", "transaction1", now.Add(30*time.Second)))
+
+	events := s.manager.Evaluate(connector, voltage, "230", "transaction1", now.Add(time.Minute))
+	s.Require().Len(events, 1)
+	s.Assert().EqualValues(ocpp201.EventTriggerPeriodic, events[0].Data.Trigger)
+	s.Assert().EqualValues("transaction1", events[0].Data.TransactionId)
+}
+
+func (s *monitoringTestSuite) TestNotify() {
+	now := time.Now()
+
+	first := s.manager.Notify(connector, ocpp201.Variable{Name: "Problem"}, "true", "GroundFailure", 1, false, now)
+	s.Assert().EqualValues(ocpp201.EventNotificationTypeHardWiredNotification, first.Data.EventNotificationType)
+	s.Assert().EqualValues("GroundFailure", first.Data.TechCode)
+	s.Assert().EqualValues(1, first.Severity)
+	s.Assert().Nil(first.Data.VariableMonitoringId)
+
+	second := s.manager.Notify(connector, ocpp201.Variable{Name: "Problem"}, "false", "", 1, true, now)
+	s.Assert().True(second.Data.Cleared)
+	s.Assert().Greater(second.Data.EventId, first.Data.EventId)
+}
+
+func TestMonitoring(t *testing.T) {
+	suite.Run(t, new(monitoringTestSuite))
+}
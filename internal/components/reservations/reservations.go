@@ -0,0 +1,174 @@
+package reservations
+
+import (
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidReservationId = errors.New("invalid reservation id")
+	ErrReservationExpired   = errors.New("reservation already expired")
+	ErrReservationNotFound  = errors.New("reservation not found")
+)
+
+type (
+	// Manager stores the reservations by their id. The reservations are persisted after every change, so they survive a restart.
+	Manager interface {
+		LoadFromFile()
+		AddReservation(reservation settingsData.Reservation) error
+		RemoveReservation(reservationId int) error
+		GetReservation(reservationId int) (*settingsData.Reservation, bool)
+		GetConnectorReservation(connectorId int) (*settingsData.Reservation, bool)
+		GetReservations() []settingsData.Reservation
+	}
+
+	managerImpl struct {
+		mu           sync.Mutex
+		filePath     string
+		reservations map[int]settingsData.Reservation
+	}
+)
+
+func NewManager(filePath string) Manager {
+	return &managerImpl{
+		mu:           sync.Mutex{},
+		filePath:     filePath,
+		reservations: map[int]settingsData.Reservation{},
+	}
+}
+
+// LoadFromFile loads the reservations from the file.
+func (m *managerImpl) LoadFromFile() {
+	var reservationsFile settingsData.ReservationsFile
+
+	data, err := ioutil.ReadFile(m.filePath)
+	if os.IsNotExist(err) {
+		log.Debugf("No reservations file found")
+		return
+	} else if err != nil {
+		log.WithError(err).Errorf("Unable to read the reservations file")
+		return
+	}
+
+	switch filepath.Ext(m.filePath) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &reservationsFile)
+	default:
+		err = json.Unmarshal(data, &reservationsFile)
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("Unable to load the reservations file")
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reservations = map[int]settingsData.Reservation{}
+	for _, reservation := range reservationsFile.Reservations {
+		m.reservations[reservation.ReservationId] = reservation
+	}
+
+	log.Infof("Loaded %d reservations", len(m.reservations))
+}
+
+// AddReservation adds the reservation. A reservation with the same id is replaced.
+func (m *managerImpl) AddReservation(reservation settingsData.Reservation) error {
+	if reservation.ReservationId <= 0 {
+		return ErrInvalidReservationId
+	}
+
+	if !reservation.ExpiryDate.After(time.Now()) {
+		return ErrReservationExpired
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reservations[reservation.ReservationId] = reservation
+	m.dump()
+	return nil
+}
+
+// RemoveReservation removes the reservation with the id.
+func (m *managerImpl) RemoveReservation(reservationId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, isFound := m.reservations[reservationId]; !isFound {
+		return ErrReservationNotFound
+	}
+
+	delete(m.reservations, reservationId)
+	m.dump()
+	return nil
+}
+
+// GetReservation returns the reservation with the id.
+func (m *managerImpl) GetReservation(reservationId int) (*settingsData.Reservation, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reservation, isFound := m.reservations[reservationId]
+	if !isFound {
+		return nil, false
+	}
+
+	return &reservation, true
+}
+
+// GetConnectorReservation returns the reservation of the connector.
+func (m *managerImpl) GetConnectorReservation(connectorId int) (*settingsData.Reservation, bool) {
+	for _, reservation := range m.GetReservations() {
+		if reservation.ConnectorId == connectorId {
+			return &reservation, true
+		}
+	}
+
+	return nil, false
+}
+
+// GetReservations returns all the reservations, sorted by their expiry date.
+func (m *managerImpl) GetReservations() []settingsData.Reservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reservations := []settingsData.Reservation{}
+	for _, reservation := range m.reservations {
+		reservations = append(reservations, reservation)
+	}
+
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].ExpiryDate.Before(reservations[j].ExpiryDate)
+	})
+
+	return reservations
+}
+
+// dump writes the reservations to the file. The lock must be held by the caller.
+func (m *managerImpl) dump() {
+	if m.filePath == "" {
+		return
+	}
+
+	reservationsFile := settingsData.ReservationsFile{Reservations: []settingsData.Reservation{}}
+	for _, reservation := range m.reservations {
+		reservationsFile.Reservations = append(reservationsFile.Reservations, reservation)
+	}
+
+	err := settings.WriteToFile(m.filePath, reservationsFile)
+	if err != nil {
+		log.WithError(err).Errorf("Error updating the reservations file")
+	}
+}
@@ -0,0 +1,124 @@
+package reservations
+
+import (
+	"github.com/stretchr/testify/suite"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type reservationsTestSuite struct {
+	suite.Suite
+	filePath string
+	manager  Manager
+}
+
+func (s *reservationsTestSuite) SetupTest() {
+	s.filePath = filepath.Join(s.T().TempDir(), "reservations.json")
+	s.manager = NewManager(s.filePath)
+}
+
+func (s *reservationsTestSuite) TestAddReservation() {
+	reservation := settingsData.Reservation{
+		ReservationId: 1,
+		ConnectorId:   1,
+		IdTag:         "tag1",
+		ParentIdTag:   "group1",
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}
+
+	s.Assert().NoError(s.manager.AddReservation(reservation))
+
+	storedReservation, isFound := s.manager.GetReservation(1)
+	s.Require().True(isFound)
+	s.Assert().EqualValues("tag1", storedReservation.IdTag)
+	s.Assert().EqualValues("group1", storedReservation.ParentIdTag)
+
+	// The reservation with the same id is replaced
+	reservation.ConnectorId = 2
+	s.Assert().NoError(s.manager.AddReservation(reservation))
+	s.Assert().Len(s.manager.GetReservations(), 1)
+
+	storedReservation, isFound = s.manager.GetConnectorReservation(2)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(1, storedReservation.ReservationId)
+
+	_, isFound = s.manager.GetConnectorReservation(1)
+	s.Assert().False(isFound)
+
+	// Invalid reservations
+	reservation.ReservationId = 0
+	s.Assert().ErrorIs(s.manager.AddReservation(reservation), ErrInvalidReservationId)
+
+	reservation.ReservationId = 2
+	reservation.ExpiryDate = time.Now().Add(-time.Minute)
+	s.Assert().ErrorIs(s.manager.AddReservation(reservation), ErrReservationExpired)
+	s.Assert().Len(s.manager.GetReservations(), 1)
+}
+
+func (s *reservationsTestSuite) TestRemoveReservation() {
+	s.Require().NoError(s.manager.AddReservation(settingsData.Reservation{
+		ReservationId: 1,
+		ConnectorId:   1,
+		IdTag:         "tag1",
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+
+	s.Assert().NoError(s.manager.RemoveReservation(1))
+	s.Assert().Empty(s.manager.GetReservations())
+
+	s.Assert().ErrorIs(s.manager.RemoveReservation(1), ErrReservationNotFound)
+}
+
+func (s *reservationsTestSuite) TestGetReservations() {
+	s.Require().NoError(s.manager.AddReservation(settingsData.Reservation{
+		ReservationId: 1,
+		ConnectorId:   1,
+		IdTag:         "tag1",
+		ExpiryDate:    time.Now().Add(time.Hour),
+	}))
+	s.Require().NoError(s.manager.AddReservation(settingsData.Reservation{
+		ReservationId: 2,
+		ConnectorId:   2,
+		IdTag:         "tag2",
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+
+	// Sorted by the expiry date
+	reservations := s.manager.GetReservations()
+	s.Require().Len(reservations, 2)
+	s.Assert().EqualValues(2, reservations[0].ReservationId)
+	s.Assert().EqualValues(1, reservations[1].ReservationId)
+}
+
+func (s *reservationsTestSuite) TestLoadFromFile() {
+	expiryDate := time.Now().Add(time.Minute).Round(time.Second)
+	s.Require().NoError(s.manager.AddReservation(settingsData.Reservation{
+		ReservationId: 1,
+		ConnectorId:   1,
+		IdTag:         "tag1",
+		ParentIdTag:   "group1",
+		ExpiryDate:    expiryDate,
+	}))
+
+	// The reservations survive a restart
+	manager := NewManager(s.filePath)
+	manager.LoadFromFile()
+
+	reservation, isFound := manager.GetReservation(1)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(1, reservation.ConnectorId)
+	s.Assert().EqualValues("tag1", reservation.IdTag)
+	s.Assert().EqualValues("group1", reservation.ParentIdTag)
+	s.Assert().True(expiryDate.Equal(reservation.ExpiryDate))
+
+	// No file
+	manager = NewManager(filepath.Join(s.T().TempDir(), "missing.json"))
+	manager.LoadFromFile()
+	s.Assert().Empty(manager.GetReservations())
+}
+
+func TestReservations(t *testing.T) {
+	suite.Run(t, new(reservationsTestSuite))
+}
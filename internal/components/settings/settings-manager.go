@@ -23,15 +23,17 @@ const (
 	connectorFolder = "./configs/connectors"
 	dockerFolder    = "/etc/ChargePi/configs"
 
-	Model           = "chargepoint.info.ocpp.model"
-	Vendor          = "chargepoint.info.ocpp.vendor"
-	MaxChargingTime = "chargepoint.info.maxChargingTime"
-	ProtocolVersion = "chargepoint.info.protocolVersion"
-	LoggingFormat   = "chargepoint.logging.format"
-	Debug           = "debug"
-	ApiEnabled      = "api.enabled"
-	ApiAddress      = "api.address"
-	ApiPort         = "api.port"
+	Model            = "chargepoint.info.ocpp.model"
+	Vendor           = "chargepoint.info.ocpp.vendor"
+	MaxChargingTime  = "chargepoint.info.maxChargingTime"
+	ProtocolVersion  = "chargepoint.info.protocolVersion"
+	Availability     = "chargepoint.info.availability"
+	LoggingFormat    = "chargepoint.logging.format"
+	CertificateStore = "chargepoint.tls.certificateStorePath"
+	Debug            = "debug"
+	ApiEnabled       = "api.enabled"
+	ApiAddress       = "api.address"
+	ApiPort          = "api.port"
 )
 
 var (
@@ -75,6 +77,7 @@ func setDefaults() {
 	viper.SetDefault(MaxChargingTime, 180)
 	viper.SetDefault(ProtocolVersion, "1.6")
 	viper.SetDefault(LoggingFormat, "gelf")
+	viper.SetDefault(CertificateStore, "./configs/certificates")
 }
 
 func SetupOcppConfigurationManager(filePath string, version configuration.ProtocolVersion, supportedProfiles ...string) {
@@ -111,6 +114,34 @@ func GetSettings() *settings.Settings {
 	return &conf
 }
 
+// UpdateProtocolVersion updates the protocol version in the settings file, so the charge point starts with the version
+// negotiated with the central system, even if the central system is not reachable.
+func UpdateProtocolVersion(version settings.ProtocolVersion) error {
+	return updateSetting(ProtocolVersion, string(version))
+}
+
+// UpdateChargePointAvailability updates the availability of the whole charge point (connector 0) in the settings file,
+// so the charge point stays inoperative after a reboot.
+func UpdateChargePointAvailability(availability core.AvailabilityType) error {
+	return updateSetting(Availability, string(availability))
+}
+
+// updateSetting writes the value to the settings file, without the defaults and the environment variables.
+func updateSetting(key string, value interface{}) error {
+	cfg := viper.New()
+	cfg.SetConfigFile(viper.ConfigFileUsed())
+
+	err := cfg.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg.Set(key, value)
+	viper.Set(key, value)
+
+	return cfg.WriteConfig()
+}
+
 // loadConnectorFromPath loads a connector from file
 func loadConnectorFromPath(name, path string) (*settings.Connector, error) {
 	// Read the connector settings from the file in the directory
@@ -166,6 +197,46 @@ func GetConnectors(connectorsFolderPath string) []*settings.Connector {
 	return connectors
 }
 
+// GetConnectorFiles returns the paths of the cached connector settings files.
+func GetConnectorFiles() []string {
+	var files []string
+
+	ConnectorSettings.Range(func(key, value interface{}) bool {
+		if cfg, isViper := value.(*viper.Viper); isViper && cfg.ConfigFileUsed() != "" {
+			files = append(files, cfg.ConfigFileUsed())
+		}
+
+		return true
+	})
+
+	return files
+}
+
+// GetConnectorSettings returns the current settings of the cached connectors, including their sessions.
+func GetConnectorSettings() []*settings.Connector {
+	var connectors []*settings.Connector
+
+	ConnectorSettings.Range(func(key, value interface{}) bool {
+		var connector settings.Connector
+
+		cfg, isViper := value.(*viper.Viper)
+		if !isViper {
+			return true
+		}
+
+		err := cfg.Unmarshal(&connector)
+		if err != nil {
+			log.WithError(err).Errorf("Cannot read connector settings")
+			return true
+		}
+
+		connectors = append(connectors, &connector)
+		return true
+	})
+
+	return connectors
+}
+
 // UpdateConnectorStatus update the Connector's status in the connector configuration file
 func UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatus) {
 	var (
@@ -205,6 +276,64 @@ func UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatu
 	logInfo.Debugf("Updated status at connector %d", connectorId)
 }
 
+// UpdateConnectorAvailability update the Connector's availability in the connector configuration file
+func UpdateConnectorAvailability(evseId, connectorId int, availability core.AvailabilityType) {
+	var (
+		cachePathKey = fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
+		logInfo      = log.WithFields(log.Fields{
+			"evseId":       evseId,
+			"connectorId":  connectorId,
+			"availability": availability,
+		})
+	)
+
+	viperCfg, isFound := ConnectorSettings.Load(cachePathKey)
+	if !isFound {
+		logInfo.Errorf("Error updating connector availability")
+		return
+	}
+
+	cfg := viperCfg.(*viper.Viper)
+	cfg.Set("availability", availability)
+
+	err := cfg.WriteConfig()
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector availability")
+		return
+	}
+
+	logInfo.Debugf("Updated availability at connector %d", connectorId)
+}
+
+// UpdateConnectorEnergyRegister update the state of the Connector's energy register in the connector configuration file
+func UpdateConnectorEnergyRegister(evseId, connectorId int, energyRegister settings.EnergyRegister) {
+	var (
+		cachePathKey = fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
+		logInfo      = log.WithFields(log.Fields{
+			"evseId":         evseId,
+			"connectorId":    connectorId,
+			"energyRegister": energyRegister,
+		})
+	)
+
+	viperCfg, isFound := ConnectorSettings.Load(cachePathKey)
+	if !isFound {
+		logInfo.Errorf("Error updating connector energy register")
+		return
+	}
+
+	cfg := viperCfg.(*viper.Viper)
+	cfg.Set("powermeter.energyregister.energy", energyRegister.Energy)
+
+	err := cfg.WriteConfig()
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector energy register")
+		return
+	}
+
+	logInfo.Tracef("Updated energy register at connector %d", connectorId)
+}
+
 // UpdateConnectorSessionInfo update the Connector's Session object in the connector configuration file
 func UpdateConnectorSessionInfo(evseId, connectorId int, session *settings.Session) {
 	var (
@@ -255,3 +384,54 @@ func UpdateConnectorSessionInfo(evseId, connectorId int, session *settings.Sessi
 
 	logInfo.Debugf("Updated session for connector")
 }
+
+// UpdateConnectorTransactionInfo update the Connector's OCPP 2.0.1 transaction in the connector configuration file.
+// The transaction is removed from the file if it is nil.
+func UpdateConnectorTransactionInfo(evseId, connectorId int, transaction *settings.Transaction) {
+	var (
+		cachePathKey = fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
+		connector    *settings.Connector
+		err          error
+		logInfo      = log.WithFields(log.Fields{
+			"evseId":      evseId,
+			"connectorId": connectorId,
+		})
+	)
+
+	logInfo.Debugf("Updating transaction info")
+	viperCfg, isFound := ConnectorSettings.Load(cachePathKey)
+	if !isFound {
+		logInfo.Errorf("Error updating connector transaction")
+		return
+	}
+
+	cfg := viperCfg.(*viper.Viper)
+
+	err = cfg.Unmarshal(&connector)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector transaction")
+		return
+	}
+
+	connector.Transaction = transaction
+
+	marshal, err := json.Marshal(connector)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector transaction")
+		return
+	}
+
+	err = cfg.ReadConfig(bytes.NewReader(marshal))
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector transaction")
+		return
+	}
+
+	err = cfg.WriteConfig()
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error updating connector transaction")
+		return
+	}
+
+	logInfo.Debugf("Updated transaction for connector")
+}
@@ -0,0 +1,509 @@
+package smartCharging
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// nominalVoltage is used for converting between Amperes and Watts.
+	nominalVoltage = 230.0
+	// defaultNumberPhases is the number of phases assumed when the schedule period does not specify it.
+	defaultNumberPhases = 3
+)
+
+var (
+	ErrProfileNil               = errors.New("charging profile cannot be nil")
+	ErrInvalidConnectorId       = errors.New("invalid connector id for the charging profile purpose")
+	ErrNoTransaction            = errors.New("no transaction active on the connector")
+	ErrTransactionMismatch      = errors.New("the transaction id of the profile does not match the active transaction")
+	ErrProfileNotFound          = errors.New("charging profile not found")
+	ErrMaxProfilesInstalled     = errors.New("maximum number of charging profiles reached")
+	ErrMissingScheduleStartDate = errors.New("charging schedule start is required for absolute and recurring profiles")
+)
+
+type (
+	// Transaction contains the transaction information needed to evaluate the TxProfiles and relative schedules.
+	Transaction struct {
+		Id      int
+		Started time.Time
+	}
+
+	// installedProfile is a charging profile installed on a specific connector.
+	installedProfile struct {
+		connectorId int
+		profile     types.ChargingProfile
+	}
+
+	// Limit is a charging limit resulting from the composite schedule at a point in time.
+	Limit struct {
+		Value        float64
+		Unit         types.ChargingRateUnitType
+		NumberPhases int
+	}
+
+	ProfileManager interface {
+		AddProfile(connectorId int, profile *types.ChargingProfile, transaction *Transaction) error
+		RemoveProfile(profileId int) error
+		ClearProfiles(profileId, connectorId *int, purpose types.ChargingProfilePurposeType, stackLevel *int) int
+		RemoveTxProfiles(connectorId int)
+		GetProfiles() []types.ChargingProfile
+		GetLimit(connectorId int, at time.Time, transaction *Transaction) *Limit
+		GetCompositeSchedule(connectorId int, start time.Time, duration int, unit types.ChargingRateUnitType, transaction *Transaction) *types.ChargingSchedule
+		SetMaxProfiles(maxProfiles int)
+	}
+
+	profileManagerImpl struct {
+		mu          sync.Mutex
+		profiles    []installedProfile
+		maxProfiles int
+	}
+)
+
+// NewProfileManager creates a new store for the charging profiles.
+func NewProfileManager() ProfileManager {
+	return &profileManagerImpl{
+		mu:       sync.Mutex{},
+		profiles: []installedProfile{},
+	}
+}
+
+// SetMaxProfiles sets the maximum number of profiles that can be installed. Zero or less means no limit.
+func (m *profileManagerImpl) SetMaxProfiles(maxProfiles int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxProfiles = maxProfiles
+}
+
+// AddProfile installs the charging profile on a connector. A profile with the same id, or a profile with the same
+// purpose and stack level on the same connector, is replaced by the new profile.
+func (m *profileManagerImpl) AddProfile(connectorId int, profile *types.ChargingProfile, transaction *Transaction) error {
+	if profile == nil || profile.ChargingSchedule == nil {
+		return ErrProfileNil
+	}
+
+	switch profile.ChargingProfilePurpose {
+	case types.ChargingProfilePurposeChargePointMaxProfile:
+		if connectorId != 0 {
+			return ErrInvalidConnectorId
+		}
+	case types.ChargingProfilePurposeTxProfile:
+		if connectorId <= 0 {
+			return ErrInvalidConnectorId
+		}
+
+		if transaction == nil {
+			return ErrNoTransaction
+		}
+
+		// The profile would never apply to the active transaction
+		if profile.TransactionId != 0 && profile.TransactionId != transaction.Id {
+			return ErrTransactionMismatch
+		}
+	}
+
+	switch profile.ChargingProfileKind {
+	case types.ChargingProfileKindAbsolute, types.ChargingProfileKindRecurring:
+		if profile.ChargingSchedule.StartSchedule == nil {
+			return ErrMissingScheduleStartDate
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"connectorId": connectorId,
+		"profileId":   profile.ChargingProfileId,
+		"purpose":     profile.ChargingProfilePurpose,
+		"stackLevel":  profile.StackLevel,
+	}).Debug("Adding a charging profile")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var profiles []installedProfile
+	for _, p := range m.profiles {
+		isSameId := p.profile.ChargingProfileId == profile.ChargingProfileId
+		isSameLevel := p.connectorId == connectorId &&
+			p.profile.ChargingProfilePurpose == profile.ChargingProfilePurpose &&
+			p.profile.StackLevel == profile.StackLevel
+		if !isSameId && !isSameLevel {
+			profiles = append(profiles, p)
+		}
+	}
+
+	if m.maxProfiles > 0 && len(profiles) >= m.maxProfiles {
+		return ErrMaxProfilesInstalled
+	}
+
+	m.profiles = append(profiles, installedProfile{connectorId: connectorId, profile: *profile})
+	return nil
+}
+
+// RemoveProfile removes the profile with the profile id.
+func (m *profileManagerImpl) RemoveProfile(profileId int) error {
+	if m.ClearProfiles(&profileId, nil, "", nil) == 0 {
+		return ErrProfileNotFound
+	}
+
+	return nil
+}
+
+// ClearProfiles removes all the profiles that match the criteria and returns the number of removed profiles.
+// If the profile id is specified, other criteria are ignored.
+func (m *profileManagerImpl) ClearProfiles(profileId, connectorId *int, purpose types.ChargingProfilePurposeType, stackLevel *int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		profiles []installedProfile
+		removed  = 0
+	)
+
+	for _, p := range m.profiles {
+		matches := true
+
+		if profileId != nil {
+			matches = p.profile.ChargingProfileId == *profileId
+		} else {
+			if connectorId != nil && p.connectorId != *connectorId {
+				matches = false
+			}
+
+			if purpose != "" && p.profile.ChargingProfilePurpose != purpose {
+				matches = false
+			}
+
+			if stackLevel != nil && p.profile.StackLevel != *stackLevel {
+				matches = false
+			}
+		}
+
+		if matches {
+			removed++
+			continue
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	m.profiles = profiles
+	return removed
+}
+
+// RemoveTxProfiles removes the TxProfiles from the connector after the transaction ends.
+func (m *profileManagerImpl) RemoveTxProfiles(connectorId int) {
+	m.ClearProfiles(nil, &connectorId, types.ChargingProfilePurposeTxProfile, nil)
+}
+
+// GetProfiles returns all installed profiles.
+func (m *profileManagerImpl) GetProfiles() []types.ChargingProfile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var profiles []types.ChargingProfile
+	for _, p := range m.profiles {
+		profiles = append(profiles, p.profile)
+	}
+
+	return profiles
+}
+
+// GetLimit calculates the limit for the connector at the specified time. The limit is the lowest of the
+// ChargePointMaxProfile and the TxProfile (or TxDefaultProfile if there is no TxProfile) with the highest stack level.
+// Returns nil if no profile applies.
+func (m *profileManagerImpl) GetLimit(connectorId int, at time.Time, transaction *Transaction) *Limit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		maxLimit = m.getPurposeLimit(types.ChargingProfilePurposeChargePointMaxProfile, 0, at, transaction)
+		txLimit  *Limit
+	)
+
+	if connectorId == 0 {
+		return maxLimit
+	}
+
+	if transaction != nil {
+		txLimit = m.getPurposeLimit(types.ChargingProfilePurposeTxProfile, connectorId, at, transaction)
+	}
+
+	if txLimit == nil {
+		// A TxDefaultProfile on a connector overrides the TxDefaultProfile on connector 0
+		txLimit = m.getPurposeLimit(types.ChargingProfilePurposeTxDefaultProfile, connectorId, at, transaction)
+		if txLimit == nil {
+			txLimit = m.getPurposeLimit(types.ChargingProfilePurposeTxDefaultProfile, 0, at, transaction)
+		}
+	}
+
+	return minLimit(maxLimit, txLimit)
+}
+
+// GetCompositeSchedule calculates the composite schedule for the connector, starting at start and lasting duration seconds.
+// Periods without any applicable profile are not reported. Returns nil if no profile applies in the interval.
+func (m *profileManagerImpl) GetCompositeSchedule(connectorId int, start time.Time, duration int, unit types.ChargingRateUnitType, transaction *Transaction) *types.ChargingSchedule {
+	var (
+		end         = start.Add(time.Duration(duration) * time.Second)
+		breakpoints = m.getBreakpoints(start, end, transaction)
+		periods     []types.ChargingSchedulePeriod
+		lastLimit   *Limit
+	)
+
+	for _, breakpoint := range breakpoints {
+		limit := m.GetLimit(connectorId, breakpoint, transaction)
+		if limit == nil {
+			continue
+		}
+
+		// Report all the periods in the same unit
+		if unit == "" {
+			unit = limit.Unit
+		}
+
+		converted := limit.ConvertTo(unit)
+		limit = &converted
+
+		if lastLimit != nil && *lastLimit == *limit {
+			continue
+		}
+
+		numberPhases := limit.NumberPhases
+		periods = append(periods, types.ChargingSchedulePeriod{
+			StartPeriod:  int(breakpoint.Sub(start).Seconds()),
+			Limit:        limit.Value,
+			NumberPhases: &numberPhases,
+		})
+		lastLimit = limit
+	}
+
+	if len(periods) == 0 {
+		return nil
+	}
+
+	schedule := types.NewChargingSchedule(unit, periods...)
+	schedule.Duration = &duration
+	schedule.StartSchedule = types.NewDateTime(start)
+	return schedule
+}
+
+// getBreakpoints returns all the points in time in the interval where the composite limit might change.
+func (m *profileManagerImpl) getBreakpoints(start, end time.Time, transaction *Transaction) []time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		points   = []time.Time{start}
+		addPoint = func(point time.Time) {
+			if !point.Before(start) && point.Before(end) {
+				points = append(points, point)
+			}
+		}
+	)
+
+	for _, p := range m.profiles {
+		profile := p.profile
+
+		if profile.ValidFrom != nil {
+			addPoint(profile.ValidFrom.Time)
+		}
+
+		if profile.ValidTo != nil {
+			addPoint(profile.ValidTo.Time)
+		}
+
+		for _, scheduleStart := range getScheduleStarts(profile, start, end, transaction) {
+			addPoint(scheduleStart)
+
+			for _, period := range profile.ChargingSchedule.ChargingSchedulePeriod {
+				addPoint(scheduleStart.Add(time.Duration(period.StartPeriod) * time.Second))
+			}
+
+			if profile.ChargingSchedule.Duration != nil {
+				addPoint(scheduleStart.Add(time.Duration(*profile.ChargingSchedule.Duration) * time.Second))
+			}
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Before(points[j])
+	})
+
+	return points
+}
+
+// getPurposeLimit finds the limit of the profile with the highest stack level for the purpose that is active at the time.
+func (m *profileManagerImpl) getPurposeLimit(purpose types.ChargingProfilePurposeType, connectorId int, at time.Time, transaction *Transaction) *Limit {
+	var (
+		limit      *Limit
+		stackLevel = -1
+	)
+
+	for _, p := range m.profiles {
+		if p.connectorId != connectorId || p.profile.ChargingProfilePurpose != purpose || p.profile.StackLevel <= stackLevel {
+			continue
+		}
+
+		// A TxProfile with a transaction id only applies to that transaction
+		if purpose == types.ChargingProfilePurposeTxProfile && p.profile.TransactionId != 0 &&
+			(transaction == nil || transaction.Id != p.profile.TransactionId) {
+			continue
+		}
+
+		profileLimit := getProfileLimit(p.profile, at, transaction)
+		if profileLimit != nil {
+			limit = profileLimit
+			stackLevel = p.profile.StackLevel
+		}
+	}
+
+	return limit
+}
+
+// getProfileLimit returns the limit of the profile at the time or nil, if the profile is not active at the time.
+func getProfileLimit(profile types.ChargingProfile, at time.Time, transaction *Transaction) *Limit {
+	if profile.ValidFrom != nil && at.Before(profile.ValidFrom.Time) {
+		return nil
+	}
+
+	if profile.ValidTo != nil && !at.Before(profile.ValidTo.Time) {
+		return nil
+	}
+
+	scheduleStart := getScheduleStart(profile, at, transaction)
+	if scheduleStart == nil || at.Before(*scheduleStart) {
+		return nil
+	}
+
+	var (
+		schedule = profile.ChargingSchedule
+		elapsed  = int(at.Sub(*scheduleStart).Seconds())
+		period   *types.ChargingSchedulePeriod
+	)
+
+	if schedule.Duration != nil && elapsed >= *schedule.Duration {
+		return nil
+	}
+
+	for i, schedulePeriod := range schedule.ChargingSchedulePeriod {
+		if schedulePeriod.StartPeriod <= elapsed && (period == nil || schedulePeriod.StartPeriod >= period.StartPeriod) {
+			period = &schedule.ChargingSchedulePeriod[i]
+		}
+	}
+
+	if period == nil {
+		return nil
+	}
+
+	numberPhases := defaultNumberPhases
+	if period.NumberPhases != nil && *period.NumberPhases > 0 {
+		numberPhases = *period.NumberPhases
+	}
+
+	return &Limit{
+		Value:        period.Limit,
+		Unit:         schedule.ChargingRateUnit,
+		NumberPhases: numberPhases,
+	}
+}
+
+// getScheduleStart determines when the schedule of the profile started relative to the time.
+func getScheduleStart(profile types.ChargingProfile, at time.Time, transaction *Transaction) *time.Time {
+	schedule := profile.ChargingSchedule
+
+	switch profile.ChargingProfileKind {
+	case types.ChargingProfileKindAbsolute:
+		if schedule.StartSchedule == nil {
+			return nil
+		}
+
+		return &schedule.StartSchedule.Time
+	case types.ChargingProfileKindRelative:
+		if transaction == nil {
+			// Without a transaction, a relative schedule would start right away
+			return &at
+		}
+
+		return &transaction.Started
+	case types.ChargingProfileKindRecurring:
+		if schedule.StartSchedule == nil {
+			return nil
+		}
+
+		var (
+			recurrence = getRecurrence(profile.RecurrencyKind)
+			start      = schedule.StartSchedule.Time
+		)
+
+		if at.Before(start) {
+			return &start
+		}
+
+		start = start.Add(at.Sub(start) / recurrence * recurrence)
+		return &start
+	}
+
+	return nil
+}
+
+// getScheduleStarts returns all the schedule starts of the profile that affect the interval.
+func getScheduleStarts(profile types.ChargingProfile, start, end time.Time, transaction *Transaction) []time.Time {
+	scheduleStart := getScheduleStart(profile, start, transaction)
+	if scheduleStart == nil {
+		return nil
+	}
+
+	starts := []time.Time{*scheduleStart}
+	if profile.ChargingProfileKind == types.ChargingProfileKindRecurring {
+		recurrence := getRecurrence(profile.RecurrencyKind)
+		for next := scheduleStart.Add(recurrence); next.Before(end); next = next.Add(recurrence) {
+			starts = append(starts, next)
+		}
+	}
+
+	return starts
+}
+
+func getRecurrence(kind types.RecurrencyKindType) time.Duration {
+	if kind == types.RecurrencyKindWeekly {
+		return time.Hour * 24 * 7
+	}
+
+	return time.Hour * 24
+}
+
+// ConvertTo converts the limit to the unit specified.
+func (l Limit) ConvertTo(unit types.ChargingRateUnitType) Limit {
+	if l.Unit == unit {
+		return l
+	}
+
+	converted := Limit{Unit: unit, NumberPhases: l.NumberPhases}
+	switch unit {
+	case types.ChargingRateUnitWatts:
+		converted.Value = l.Value * nominalVoltage * float64(l.NumberPhases)
+	case types.ChargingRateUnitAmperes:
+		converted.Value = l.Value / (nominalVoltage * float64(l.NumberPhases))
+	}
+
+	return converted
+}
+
+// minLimit returns the lower of the two limits. If only one limit is set, it returns that limit.
+func minLimit(a, b *Limit) *Limit {
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	if b.ConvertTo(a.Unit).Value < a.Value {
+		return b
+	}
+
+	return a
+}
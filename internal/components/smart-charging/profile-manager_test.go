@@ -0,0 +1,219 @@
+package smartCharging
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type ProfileManagerTestSuite struct {
+	suite.Suite
+	profileManager ProfileManager
+	transaction    *Transaction
+	now            time.Time
+}
+
+func newProfile(id, stackLevel int, purpose types.ChargingProfilePurposeType, kind types.ChargingProfileKindType, unit types.ChargingRateUnitType, periods ...types.ChargingSchedulePeriod) *types.ChargingProfile {
+	return types.NewChargingProfile(id, stackLevel, purpose, kind, types.NewChargingSchedule(unit, periods...))
+}
+
+func (s *ProfileManagerTestSuite) SetupTest() {
+	s.profileManager = NewProfileManager()
+	s.now = time.Now()
+	s.transaction = &Transaction{
+		Id:      1,
+		Started: s.now.Add(-time.Minute),
+	}
+}
+
+func (s *ProfileManagerTestSuite) TestAddProfile() {
+	var (
+		maxProfile = newProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 32))
+		txProfile = newProfile(2, 0, types.ChargingProfilePurposeTxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16))
+		absoluteProfile = newProfile(3, 0, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindAbsolute,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16))
+	)
+
+	s.Require().NoError(s.profileManager.AddProfile(0, maxProfile, nil))
+	s.Require().Len(s.profileManager.GetProfiles(), 1)
+
+	// ChargePointMaxProfile can only be set on connector 0
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, maxProfile, nil), ErrInvalidConnectorId)
+
+	// TxProfile requires an ongoing transaction
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, txProfile, nil), ErrNoTransaction)
+	s.Require().ErrorIs(s.profileManager.AddProfile(0, txProfile, s.transaction), ErrInvalidConnectorId)
+	s.Require().NoError(s.profileManager.AddProfile(1, txProfile, s.transaction))
+	s.Require().Len(s.profileManager.GetProfiles(), 2)
+
+	// TxProfile for another transaction
+	otherTxProfile := *txProfile
+	otherTxProfile.TransactionId = s.transaction.Id + 1
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, &otherTxProfile, s.transaction), ErrTransactionMismatch)
+	s.Require().Len(s.profileManager.GetProfiles(), 2)
+
+	// Absolute profile requires a start schedule
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, absoluteProfile, nil), ErrMissingScheduleStartDate)
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, nil, nil), ErrProfileNil)
+
+	// Profile with the same id is replaced
+	txProfile.StackLevel = 2
+	s.Require().NoError(s.profileManager.AddProfile(1, txProfile, s.transaction))
+	s.Require().Len(s.profileManager.GetProfiles(), 2)
+
+	// Profile with the same purpose and stack level is replaced
+	replacingProfile := *txProfile
+	replacingProfile.ChargingProfileId = 5
+	s.Require().NoError(s.profileManager.AddProfile(1, &replacingProfile, s.transaction))
+	s.Require().Len(s.profileManager.GetProfiles(), 2)
+
+	// Profile limit
+	s.profileManager.SetMaxProfiles(2)
+	absoluteProfile.ChargingSchedule.StartSchedule = types.NewDateTime(s.now)
+	s.Require().ErrorIs(s.profileManager.AddProfile(1, absoluteProfile, nil), ErrMaxProfilesInstalled)
+}
+
+func (s *ProfileManagerTestSuite) TestClearProfiles() {
+	var (
+		maxProfile = newProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 32))
+		txProfile = newProfile(2, 0, types.ChargingProfilePurposeTxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16))
+		txDefaultProfile = newProfile(3, 1, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16))
+		profileId   = 1
+		connectorId = 1
+		stackLevel  = 1
+	)
+
+	s.Require().NoError(s.profileManager.AddProfile(0, maxProfile, nil))
+	s.Require().NoError(s.profileManager.AddProfile(1, txProfile, s.transaction))
+	s.Require().NoError(s.profileManager.AddProfile(1, txDefaultProfile, nil))
+
+	s.Require().EqualValues(0, s.profileManager.ClearProfiles(nil, &connectorId, types.ChargingProfilePurposeChargePointMaxProfile, nil))
+	s.Require().EqualValues(1, s.profileManager.ClearProfiles(nil, &connectorId, "", &stackLevel))
+	s.Require().Len(s.profileManager.GetProfiles(), 2)
+
+	s.profileManager.RemoveTxProfiles(connectorId)
+	s.Require().Len(s.profileManager.GetProfiles(), 1)
+
+	s.Require().NoError(s.profileManager.RemoveProfile(profileId))
+	s.Require().ErrorIs(s.profileManager.RemoveProfile(profileId), ErrProfileNotFound)
+	s.Require().Len(s.profileManager.GetProfiles(), 0)
+}
+
+func (s *ProfileManagerTestSuite) TestGetLimit() {
+	var (
+		maxProfile = newProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 20))
+		txDefaultProfile = newProfile(2, 0, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 10))
+		higherTxDefaultProfile = newProfile(3, 1, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16), types.NewChargingSchedulePeriod(120, 6))
+		txProfile = newProfile(4, 0, types.ChargingProfilePurposeTxProfile, types.ChargingProfileKindRelative,
+			types.ChargingRateUnitWatts, types.NewChargingSchedulePeriod(0, 34500))
+	)
+
+	s.Require().Nil(s.profileManager.GetLimit(1, s.now, s.transaction))
+
+	// TxDefaultProfile on connector 0 applies to all connectors
+	s.Require().NoError(s.profileManager.AddProfile(0, txDefaultProfile, nil))
+	limit := s.profileManager.GetLimit(1, s.now, s.transaction)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(10, limit.Value)
+
+	// Higher stack level wins, and the schedule is relative to the transaction start
+	s.Require().NoError(s.profileManager.AddProfile(1, higherTxDefaultProfile, nil))
+	limit = s.profileManager.GetLimit(1, s.now, s.transaction)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(16, limit.Value)
+
+	limit = s.profileManager.GetLimit(1, s.now.Add(2*time.Minute), s.transaction)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(6, limit.Value)
+
+	// TxProfile overrides the TxDefaultProfile, but is capped by the ChargePointMaxProfile
+	s.Require().NoError(s.profileManager.AddProfile(1, txProfile, s.transaction))
+	s.Require().NoError(s.profileManager.AddProfile(0, maxProfile, nil))
+	limit = s.profileManager.GetLimit(1, s.now, s.transaction)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(20, limit.ConvertTo(types.ChargingRateUnitAmperes).Value)
+
+	s.profileManager.RemoveTxProfiles(1)
+	s.Require().NoError(s.profileManager.RemoveProfile(1))
+	limit = s.profileManager.GetLimit(1, s.now, s.transaction)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(16, limit.Value)
+}
+
+func (s *ProfileManagerTestSuite) TestGetLimitValidity() {
+	var (
+		absoluteProfile = newProfile(1, 0, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindAbsolute,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 10))
+		recurringProfile = newProfile(2, 1, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindRecurring,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 6))
+		duration = 3600
+	)
+
+	absoluteProfile.ChargingSchedule.StartSchedule = types.NewDateTime(s.now.Add(-time.Hour))
+	absoluteProfile.ValidTo = types.NewDateTime(s.now.Add(time.Hour))
+	s.Require().NoError(s.profileManager.AddProfile(0, absoluteProfile, nil))
+
+	// Recurring daily from 10 minutes in the future, lasting an hour
+	recurringProfile.RecurrencyKind = types.RecurrencyKindDaily
+	recurringProfile.ChargingSchedule.StartSchedule = types.NewDateTime(s.now.Add(-24*time.Hour + 10*time.Minute))
+	recurringProfile.ChargingSchedule.Duration = &duration
+	s.Require().NoError(s.profileManager.AddProfile(0, recurringProfile, nil))
+
+	limit := s.profileManager.GetLimit(1, s.now, nil)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(10, limit.Value)
+
+	limit = s.profileManager.GetLimit(1, s.now.Add(20*time.Minute), nil)
+	s.Require().NotNil(limit)
+	s.Assert().EqualValues(6, limit.Value)
+
+	// The absolute profile is no longer valid and the recurring profile is outside its duration
+	s.Require().Nil(s.profileManager.GetLimit(1, s.now.Add(2*time.Hour), nil))
+}
+
+func (s *ProfileManagerTestSuite) TestGetCompositeSchedule() {
+	var (
+		maxProfile = newProfile(1, 0, types.ChargingProfilePurposeChargePointMaxProfile, types.ChargingProfileKindAbsolute,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 20), types.NewChargingSchedulePeriod(600, 8))
+		txDefaultProfile = newProfile(2, 0, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindAbsolute,
+			types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16), types.NewChargingSchedulePeriod(300, 10))
+	)
+
+	s.Require().Nil(s.profileManager.GetCompositeSchedule(1, s.now, 900, "", nil))
+
+	maxProfile.ChargingSchedule.StartSchedule = types.NewDateTime(s.now)
+	txDefaultProfile.ChargingSchedule.StartSchedule = types.NewDateTime(s.now)
+	s.Require().NoError(s.profileManager.AddProfile(0, maxProfile, nil))
+	s.Require().NoError(s.profileManager.AddProfile(0, txDefaultProfile, nil))
+
+	schedule := s.profileManager.GetCompositeSchedule(1, s.now, 900, types.ChargingRateUnitAmperes, nil)
+	s.Require().NotNil(schedule)
+	s.Assert().EqualValues(types.ChargingRateUnitAmperes, schedule.ChargingRateUnit)
+	s.Require().Len(schedule.ChargingSchedulePeriod, 3)
+	s.Assert().EqualValues(0, schedule.ChargingSchedulePeriod[0].StartPeriod)
+	s.Assert().EqualValues(16, schedule.ChargingSchedulePeriod[0].Limit)
+	s.Assert().EqualValues(300, schedule.ChargingSchedulePeriod[1].StartPeriod)
+	s.Assert().EqualValues(10, schedule.ChargingSchedulePeriod[1].Limit)
+	s.Assert().EqualValues(600, schedule.ChargingSchedulePeriod[2].StartPeriod)
+	s.Assert().EqualValues(8, schedule.ChargingSchedulePeriod[2].Limit)
+
+	// Connector 0 only reports the ChargePointMaxProfile
+	schedule = s.profileManager.GetCompositeSchedule(0, s.now, 900, types.ChargingRateUnitWatts, nil)
+	s.Require().NotNil(schedule)
+	s.Assert().EqualValues(types.ChargingRateUnitWatts, schedule.ChargingRateUnit)
+	s.Require().Len(schedule.ChargingSchedulePeriod, 2)
+	s.Assert().EqualValues(20*nominalVoltage*defaultNumberPhases, schedule.ChargingSchedulePeriod[0].Limit)
+}
+
+func TestProfileManager(t *testing.T) {
+	suite.Run(t, new(ProfileManagerTestSuite))
+}
@@ -0,0 +1,612 @@
+package transactionQueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMessageAttempts = 5
+	defaultRetryInterval   = 30
+	journalSuffix          = ".journal"
+)
+
+var (
+	ErrUnsupportedRequest = errors.New("request is not a transaction related message")
+	ErrUnknownTransaction = errors.New("transaction was never started at the central system")
+)
+
+type (
+	// SendFunc sends the request to the central system and waits for the response.
+	SendFunc func(request ocpp.Request) (ocpp.Response, error)
+
+	// TransactionStartedHandler is called when the central system confirms a StartTransaction with the temporary transaction id.
+	TransactionStartedHandler func(localTransactionId int, confirmation *core.StartTransactionConfirmation)
+
+	// TransactionEventHandler is called when the CSMS responds to an OCPP 2.0.1 TransactionEvent.
+	TransactionEventHandler func(request *ocpp201.TransactionEventRequest, response *ocpp201.TransactionEventResponse)
+
+	// Queue is a persistent queue of the transaction related messages (StartTransaction, StopTransaction and MeterValues
+	// with a transaction id). The messages are sent in order, once the central system is reachable. Transactions get a temporary
+	// (negative) transaction id, which is replaced with the id from the central system when the StartTransaction is confirmed.
+	// For OCPP 2.0.1, the queue holds the TransactionEvent requests and assigns their sequence numbers.
+	Queue interface {
+		LoadFromFile()
+		StartTransaction(request *core.StartTransactionRequest) (int, error)
+		Enqueue(request ocpp.Request) error
+		SetTransactionStartedHandler(handler TransactionStartedHandler)
+		SetTransactionEventHandler(handler TransactionEventHandler)
+		GetTransactionId(transactionId int) int
+		// FindMessages returns the queued messages that contain the token.
+		FindMessages(idToken string) []settingsData.TransactionMessage
+		// GetStartTransactionTags returns the tags of the queued StartTransaction requests.
+		GetStartTransactionTags() []string
+		// RedactToken replaces the token in the queued messages and returns the number of changed messages.
+		RedactToken(idToken, replacement string) int
+		Len() int
+		Run(ctx context.Context, send SendFunc, isConnected func() bool)
+	}
+
+	queueImpl struct {
+		mu                     sync.Mutex
+		filePath               string
+		lastMessageId          int
+		lastLocalTransactionId int
+		transactionIds         map[int]int
+		sequenceNumbers        map[string]int
+		messages               []settingsData.TransactionMessage
+		nextAttempt            time.Time
+		onTransactionStarted   TransactionStartedHandler
+		onTransactionEvent     TransactionEventHandler
+		notify                 chan struct{}
+	}
+)
+
+func NewQueue(filePath string) Queue {
+	return &queueImpl{
+		mu:              sync.Mutex{},
+		filePath:        filePath,
+		transactionIds:  map[int]int{},
+		sequenceNumbers: map[string]int{},
+		messages:        []settingsData.TransactionMessage{},
+		notify:          make(chan struct{}, 1),
+	}
+}
+
+// LoadFromFile loads the messages that were not sent before the shutdown. The messages appended to the journal
+// after the last dump are replayed and the queue is compacted.
+func (q *queueImpl) LoadFromFile() {
+	var queueFile settingsData.TransactionQueueFile
+
+	data, err := ioutil.ReadFile(q.filePath)
+	switch {
+	case os.IsNotExist(err):
+		log.Debugf("No transaction queue file found")
+	case err != nil:
+		log.WithError(err).Errorf("Unable to read the transaction queue file")
+		return
+	default:
+		err = json.Unmarshal(data, &queueFile)
+		if err != nil {
+			log.WithError(err).Errorf("Unable to load the transaction queue file")
+			return
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastMessageId = queueFile.LastMessageId
+	q.lastLocalTransactionId = queueFile.LastLocalTransactionId
+	q.messages = queueFile.Messages
+	if q.messages == nil {
+		q.messages = []settingsData.TransactionMessage{}
+	}
+
+	q.transactionIds = map[int]int{}
+	for localId, transactionId := range queueFile.TransactionIds {
+		q.transactionIds[localId] = transactionId
+	}
+
+	q.sequenceNumbers = map[string]int{}
+	for transactionId, sequenceNo := range queueFile.SequenceNumbers {
+		q.sequenceNumbers[transactionId] = sequenceNo
+	}
+
+	if q.replayJournal() > 0 {
+		q.dump()
+	}
+
+	log.Infof("Loaded %d queued transaction messages", len(q.messages))
+}
+
+// replayJournal appends the messages from the journal, which are not in the queue file yet, and restores the
+// temporary transaction ids and sequence numbers they were assigned. Returns the number of replayed messages.
+// The lock must be held by the caller.
+func (q *queueImpl) replayJournal() int {
+	file, err := os.Open(q.getJournalPath())
+	if os.IsNotExist(err) {
+		return 0
+	} else if err != nil {
+		log.WithError(err).Errorf("Unable to read the transaction queue journal")
+		return 0
+	}
+	defer file.Close()
+
+	replayed := 0
+	decoder := json.NewDecoder(file)
+	for {
+		var message settingsData.TransactionMessage
+
+		err = decoder.Decode(&message)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// The last line could be incomplete if the charge point lost power while writing it
+			log.WithError(err).Warnf("Unable to read the rest of the transaction queue journal")
+			break
+		}
+
+		if message.Id <= q.lastMessageId {
+			continue
+		}
+
+		q.lastMessageId = message.Id
+		if message.StartTransaction != nil && message.LocalTransactionId < q.lastLocalTransactionId {
+			q.lastLocalTransactionId = message.LocalTransactionId
+		}
+
+		if event := message.TransactionEvent; event != nil {
+			if event.EventType == ocpp201.TransactionEventEnded {
+				delete(q.sequenceNumbers, event.TransactionInfo.TransactionId)
+			} else {
+				q.sequenceNumbers[event.TransactionInfo.TransactionId] = event.SequenceNo + 1
+			}
+		}
+
+		q.messages = append(q.messages, message)
+		replayed++
+	}
+
+	return replayed
+}
+
+// StartTransaction queues the StartTransaction request and returns the temporary transaction id.
+func (q *queueImpl) StartTransaction(request *core.StartTransactionRequest) (int, error) {
+	if request == nil {
+		return 0, ErrUnsupportedRequest
+	}
+
+	q.mu.Lock()
+	q.lastLocalTransactionId--
+	localTransactionId := q.lastLocalTransactionId
+	q.add(settingsData.TransactionMessage{
+		LocalTransactionId: localTransactionId,
+		StartTransaction:   request,
+	})
+	q.mu.Unlock()
+
+	q.wakeUp()
+	return localTransactionId, nil
+}
+
+// Enqueue queues the StopTransaction or MeterValues request. The transaction id can be a temporary one.
+// The TransactionEvent request gets the next sequence number of its transaction.
+func (q *queueImpl) Enqueue(request ocpp.Request) error {
+	var message settingsData.TransactionMessage
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch request := request.(type) {
+	case *core.StopTransactionRequest:
+		message.StopTransaction = request
+	case *core.MeterValuesRequest:
+		if request.TransactionId == nil {
+			return ErrUnsupportedRequest
+		}
+
+		message.MeterValues = request
+	case *ocpp201.TransactionEventRequest:
+		transactionId := request.TransactionInfo.TransactionId
+		request.SequenceNo = q.sequenceNumbers[transactionId]
+
+		// The sequence number is not needed after the transaction ends
+		if request.EventType == ocpp201.TransactionEventEnded {
+			delete(q.sequenceNumbers, transactionId)
+		} else {
+			q.sequenceNumbers[transactionId] = request.SequenceNo + 1
+		}
+
+		message.TransactionEvent = request
+	default:
+		return ErrUnsupportedRequest
+	}
+
+	q.add(message)
+	q.wakeUp()
+	return nil
+}
+
+func (q *queueImpl) SetTransactionStartedHandler(handler TransactionStartedHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onTransactionStarted = handler
+}
+
+func (q *queueImpl) SetTransactionEventHandler(handler TransactionEventHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onTransactionEvent = handler
+}
+
+// GetTransactionId returns the central system's transaction id, if the transaction id is a temporary one and
+// the StartTransaction was already confirmed. Otherwise, returns the same transaction id.
+func (q *queueImpl) GetTransactionId(transactionId int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if id, isFound := q.transactionIds[transactionId]; isFound {
+		return id
+	}
+
+	return transactionId
+}
+
+// FindMessages returns the queued messages that contain the token.
+func (q *queueImpl) FindMessages(idToken string) []settingsData.TransactionMessage {
+	var messages []settingsData.TransactionMessage
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, message := range q.messages {
+		if token := getIdToken(message); token != nil && *token == idToken {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+// GetStartTransactionTags returns the tags of the queued StartTransaction requests, which the central system has not
+// seen yet. Every tag is returned once.
+func (q *queueImpl) GetStartTransactionTags() []string {
+	var (
+		tags   []string
+		isSeen = map[string]bool{}
+	)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, message := range q.messages {
+		if message.StartTransaction == nil || isSeen[message.StartTransaction.IdTag] {
+			continue
+		}
+
+		isSeen[message.StartTransaction.IdTag] = true
+		tags = append(tags, message.StartTransaction.IdTag)
+	}
+
+	return tags
+}
+
+// RedactToken replaces the token in the queued messages and persists the queue. Returns the number of changed messages.
+func (q *queueImpl) RedactToken(idToken, replacement string) int {
+	replaced := 0
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, message := range q.messages {
+		if token := getIdToken(message); token != nil && *token == idToken {
+			*token = replacement
+			replaced++
+		}
+	}
+
+	if replaced > 0 {
+		q.dump()
+	}
+
+	return replaced
+}
+
+// Len returns the number of queued messages.
+func (q *queueImpl) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// Run sends the queued messages in order while the central system is reachable, until the context is cancelled.
+func (q *queueImpl) Run(ctx context.Context, send SendFunc, isConnected func() bool) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.notify:
+		case <-ticker.C:
+		}
+
+		for isConnected() && q.sendNext(send, isConnected) {
+		}
+	}
+}
+
+// sendNext sends the first message in the queue. Returns true if the next message can be sent immediately.
+func (q *queueImpl) sendNext(send SendFunc, isConnected func() bool) bool {
+	q.mu.Lock()
+	if len(q.messages) == 0 || time.Now().Before(q.nextAttempt) {
+		q.mu.Unlock()
+		return false
+	}
+
+	message := q.messages[0]
+	request, err := q.getRequest(message)
+	q.mu.Unlock()
+
+	logInfo := log.WithField("messageId", message.Id)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Dropping the transaction message")
+		q.complete(message, nil)
+		return true
+	}
+
+	response, err := send(request)
+	if err != nil {
+		// The message will be sent after the connection is restored, without counting as an attempt
+		if !isConnected() {
+			return false
+		}
+
+		logInfo.WithError(err).Warnf("Cannot send the %s request", request.GetFeatureName())
+		return q.retry(message)
+	}
+
+	logInfo.Debugf("Sent the queued %s request", request.GetFeatureName())
+	q.complete(message, response)
+	return true
+}
+
+// getRequest returns the request of the message, with the temporary transaction id replaced. The lock must be held by the caller.
+func (q *queueImpl) getRequest(message settingsData.TransactionMessage) (ocpp.Request, error) {
+	switch {
+	case message.StartTransaction != nil:
+		return message.StartTransaction, nil
+	case message.StopTransaction != nil:
+		transactionId, err := q.resolveTransactionId(message.StopTransaction.TransactionId)
+		if err != nil {
+			return nil, err
+		}
+
+		message.StopTransaction.TransactionId = transactionId
+		return message.StopTransaction, nil
+	case message.MeterValues != nil && message.MeterValues.TransactionId != nil:
+		transactionId, err := q.resolveTransactionId(*message.MeterValues.TransactionId)
+		if err != nil {
+			return nil, err
+		}
+
+		message.MeterValues.TransactionId = &transactionId
+		return message.MeterValues, nil
+	case message.TransactionEvent != nil:
+		return message.TransactionEvent, nil
+	default:
+		return nil, ErrUnsupportedRequest
+	}
+}
+
+// resolveTransactionId maps a temporary transaction id to the central system's transaction id. The lock must be held by the caller.
+func (q *queueImpl) resolveTransactionId(transactionId int) (int, error) {
+	if transactionId >= 0 {
+		return transactionId, nil
+	}
+
+	id, isFound := q.transactionIds[transactionId]
+	if !isFound {
+		return 0, ErrUnknownTransaction
+	}
+
+	return id, nil
+}
+
+// retry counts the failed attempt and postpones the message. If the message exceeded TransactionMessageAttempts, it is dropped.
+// Returns true if the message was dropped.
+func (q *queueImpl) retry(message settingsData.TransactionMessage) bool {
+	var (
+		maxAttempts   = getIntConfigurationValue(v16.TransactionMessageAttempts.String(), defaultMessageAttempts)
+		retryInterval = getIntConfigurationValue(v16.TransactionMessageRetryInterval.String(), defaultRetryInterval)
+	)
+
+	q.mu.Lock()
+	if len(q.messages) == 0 || q.messages[0].Id != message.Id {
+		q.mu.Unlock()
+		return true
+	}
+
+	q.messages[0].Attempts++
+	attempts := q.messages[0].Attempts
+	if attempts < maxAttempts {
+		// The interval increases with every attempt
+		q.nextAttempt = time.Now().Add(time.Duration(retryInterval*attempts) * time.Second)
+		q.dump()
+		q.mu.Unlock()
+		return false
+	}
+	q.mu.Unlock()
+
+	log.WithField("messageId", message.Id).Errorf("Dropping the transaction message after %d attempts", attempts)
+	q.complete(message, nil)
+	return true
+}
+
+// complete removes the message from the queue and stores the transaction id from the StartTransaction response.
+func (q *queueImpl) complete(message settingsData.TransactionMessage, response ocpp.Response) {
+	var (
+		startConfirmation *core.StartTransactionConfirmation
+		eventResponse     *ocpp201.TransactionEventResponse
+	)
+
+	q.mu.Lock()
+	if len(q.messages) > 0 && q.messages[0].Id == message.Id {
+		q.messages = q.messages[1:]
+	}
+
+	q.nextAttempt = time.Time{}
+
+	switch {
+	case message.StartTransaction != nil:
+		if confirmation, isStart := response.(*core.StartTransactionConfirmation); isStart && confirmation != nil {
+			startConfirmation = confirmation
+			q.transactionIds[message.LocalTransactionId] = confirmation.TransactionId
+		}
+	case message.StopTransaction != nil:
+		// The transaction has ended, the temporary id is not needed anymore
+		for localId, transactionId := range q.transactionIds {
+			if transactionId == message.StopTransaction.TransactionId {
+				delete(q.transactionIds, localId)
+			}
+		}
+	case message.TransactionEvent != nil:
+		if eventResp, isEvent := response.(*ocpp201.TransactionEventResponse); isEvent && eventResp != nil {
+			eventResponse = eventResp
+		}
+	}
+
+	q.dump()
+	handler := q.onTransactionStarted
+	eventHandler := q.onTransactionEvent
+	q.mu.Unlock()
+
+	if startConfirmation != nil && handler != nil {
+		handler(message.LocalTransactionId, startConfirmation)
+	}
+
+	if eventResponse != nil && eventHandler != nil {
+		eventHandler(message.TransactionEvent, eventResponse)
+	}
+}
+
+// add appends the message to the queue and to the journal. The lock must be held by the caller.
+func (q *queueImpl) add(message settingsData.TransactionMessage) {
+	q.lastMessageId++
+	message.Id = q.lastMessageId
+	q.messages = append(q.messages, message)
+	q.appendToJournal(message)
+}
+
+// getIdToken returns the token field of the message, if the message has one.
+func getIdToken(message settingsData.TransactionMessage) *string {
+	switch {
+	case message.StartTransaction != nil:
+		return &message.StartTransaction.IdTag
+	case message.StopTransaction != nil && message.StopTransaction.IdTag != "":
+		return &message.StopTransaction.IdTag
+	case message.TransactionEvent != nil && message.TransactionEvent.IdToken != nil:
+		return &message.TransactionEvent.IdToken.IdToken
+	default:
+		return nil
+	}
+}
+
+// wakeUp notifies the sender about a new message.
+func (q *queueImpl) wakeUp() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// getJournalPath returns the path of the journal, which holds the messages queued after the last dump.
+func (q *queueImpl) getJournalPath() string {
+	return q.filePath + journalSuffix
+}
+
+// appendToJournal appends the message as a JSON line to the journal, so the queue file is not rewritten
+// with every message. If the message cannot be appended, the whole queue is dumped. The lock must be held by the caller.
+func (q *queueImpl) appendToJournal(message settingsData.TransactionMessage) {
+	if q.filePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to encode the transaction message")
+		return
+	}
+
+	file, err := os.OpenFile(q.getJournalPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to open the transaction queue journal")
+		q.dump()
+		return
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		log.WithError(err).Errorf("Unable to append to the transaction queue journal")
+		q.dump()
+	}
+}
+
+// dump writes the queue to the file and truncates the journal. The lock must be held by the caller.
+func (q *queueImpl) dump() {
+	if q.filePath == "" {
+		return
+	}
+
+	queueFile := settingsData.TransactionQueueFile{
+		LastMessageId:          q.lastMessageId,
+		LastLocalTransactionId: q.lastLocalTransactionId,
+		TransactionIds:         q.transactionIds,
+		Messages:               q.messages,
+		SequenceNumbers:        q.sequenceNumbers,
+	}
+
+	err := settings.WriteToFile(q.filePath, queueFile)
+	if err != nil {
+		log.WithError(err).Errorf("Error updating the transaction queue file")
+		return
+	}
+
+	// The messages from the journal are in the queue file now
+	err = os.Remove(q.getJournalPath())
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Errorf("Unable to remove the transaction queue journal")
+	}
+}
+
+func getIntConfigurationValue(key string, defaultValue int) int {
+	value, err := ocppConfigManager.GetConfigurationValue(key)
+	if err != nil {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil || intValue <= 0 {
+		return defaultValue
+	}
+
+	return intValue
+}
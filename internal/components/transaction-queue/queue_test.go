@@ -0,0 +1,353 @@
+package transactionQueue
+
+import (
+	"context"
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errConnection = errors.New("connection lost")
+
+type (
+	centralSystemMock struct {
+		mu            sync.Mutex
+		isConnected   bool
+		failRequests  bool
+		transactionId int
+		requests      []ocpp.Request
+	}
+
+	QueueTestSuite struct {
+		suite.Suite
+		filePath      string
+		queue         Queue
+		centralSystem *centralSystemMock
+	}
+)
+
+func (c *centralSystemMock) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isConnected
+}
+
+func (c *centralSystemMock) SetConnected(isConnected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.isConnected = isConnected
+}
+
+func (c *centralSystemMock) SendRequest(request ocpp.Request) (ocpp.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.isConnected {
+		return nil, errConnection
+	}
+
+	if c.failRequests {
+		return nil, ocpp.NewError("InternalError", "cannot process the request", "")
+	}
+
+	c.requests = append(c.requests, request)
+
+	switch request.(type) {
+	case *core.StartTransactionRequest:
+		c.transactionId++
+		return core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), c.transactionId), nil
+	case *core.StopTransactionRequest:
+		return core.NewStopTransactionConfirmation(), nil
+	case *ocpp201.TransactionEventRequest:
+		return &ocpp201.TransactionEventResponse{}, nil
+	default:
+		return core.NewMeterValuesConfirmation(), nil
+	}
+}
+
+func (c *centralSystemMock) GetRequests() []ocpp.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ocpp.Request{}, c.requests...)
+}
+
+func (s *QueueTestSuite) SetupSuite() {
+	ocppConfig := configuration.Config{Version: 1}
+	for _, key := range v16.MandatoryCoreKeys {
+		value := "0"
+		switch key {
+		case v16.TransactionMessageAttempts:
+			value = "3"
+		case v16.TransactionMessageRetryInterval:
+			value = "60"
+		}
+
+		ocppConfig.Keys = append(ocppConfig.Keys, core.ConfigurationKey{Key: key.String(), Readonly: false, Value: value})
+	}
+
+	s.Require().NoError(ocppManager.GetManager().SetConfiguration(ocppConfig))
+}
+
+func (s *QueueTestSuite) SetupTest() {
+	s.filePath = filepath.Join(s.T().TempDir(), "transaction-queue.json")
+	s.queue = NewQueue(s.filePath)
+	s.centralSystem = &centralSystemMock{transactionId: 100}
+}
+
+func (s *QueueTestSuite) run() context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.queue.Run(ctx, s.centralSystem.SendRequest, s.centralSystem.IsConnected)
+	return cancel
+}
+
+func (s *QueueTestSuite) TestReplayInOrder() {
+	var (
+		startedTransactions = map[int]int{}
+		mu                  sync.Mutex
+	)
+
+	s.queue.SetTransactionStartedHandler(func(localTransactionId int, confirmation *core.StartTransactionConfirmation) {
+		mu.Lock()
+		defer mu.Unlock()
+		startedTransactions[localTransactionId] = confirmation.TransactionId
+	})
+
+	cancel := s.run()
+	defer cancel()
+
+	// Offline transaction
+	localTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Assert().Less(localTransactionId, 0)
+
+	meterValues := core.NewMeterValuesRequest(1, []types.MeterValue{})
+	meterValues.TransactionId = &localTransactionId
+	s.Require().NoError(s.queue.Enqueue(meterValues))
+	s.Require().NoError(s.queue.Enqueue(core.NewStopTransactionRequest(10, types.NewDateTime(time.Now()), localTransactionId)))
+
+	// Only transaction related messages can be queued
+	s.Assert().ErrorIs(s.queue.Enqueue(core.NewHeartbeatRequest()), ErrUnsupportedRequest)
+	s.Assert().ErrorIs(s.queue.Enqueue(core.NewMeterValuesRequest(1, []types.MeterValue{})), ErrUnsupportedRequest)
+
+	time.Sleep(time.Millisecond * 100)
+	s.Assert().EqualValues(3, s.queue.Len())
+	s.Assert().Empty(s.centralSystem.GetRequests())
+
+	// Reconnect
+	s.centralSystem.SetConnected(true)
+	s.Eventually(func() bool {
+		return s.queue.Len() == 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	requests := s.centralSystem.GetRequests()
+	s.Require().Len(requests, 3)
+	s.Assert().IsType(&core.StartTransactionRequest{}, requests[0])
+	s.Assert().EqualValues(101, *requests[1].(*core.MeterValuesRequest).TransactionId)
+	s.Assert().EqualValues(101, requests[2].(*core.StopTransactionRequest).TransactionId)
+
+	mu.Lock()
+	s.Assert().EqualValues(map[int]int{localTransactionId: 101}, startedTransactions)
+	mu.Unlock()
+}
+
+func (s *QueueTestSuite) TestPersistence() {
+	localTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Require().NoError(s.queue.Enqueue(core.NewStopTransactionRequest(10, types.NewDateTime(time.Now()), localTransactionId)))
+
+	// Restart
+	s.queue = NewQueue(s.filePath)
+	s.queue.LoadFromFile()
+	s.Assert().EqualValues(2, s.queue.Len())
+
+	// Temporary ids are not reused
+	newLocalTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Assert().NotEqual(localTransactionId, newLocalTransactionId)
+
+	s.centralSystem.SetConnected(true)
+	cancel := s.run()
+	defer cancel()
+
+	s.Eventually(func() bool {
+		return s.queue.Len() == 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	requests := s.centralSystem.GetRequests()
+	s.Require().Len(requests, 3)
+	s.Assert().EqualValues(101, requests[1].(*core.StopTransactionRequest).TransactionId)
+	s.Assert().EqualValues(102, s.queue.GetTransactionId(newLocalTransactionId))
+}
+
+func (s *QueueTestSuite) TestJournal() {
+	journalPath := s.filePath + journalSuffix
+
+	localTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Require().NoError(s.queue.Enqueue(core.NewStopTransactionRequest(10, types.NewDateTime(time.Now()), localTransactionId)))
+
+	// The queued messages are only appended to the journal
+	s.Assert().NoFileExists(s.filePath)
+	data, err := ioutil.ReadFile(journalPath)
+	s.Require().NoError(err)
+	s.Assert().Len(strings.Split(strings.TrimSpace(string(data)), "\n"), 2)
+
+	// The charge point lost power while writing the last line
+	file, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	s.Require().NoError(err)
+	_, err = file.WriteString(`{"id":3,"stopTrans`)
+	s.Require().NoError(err)
+	s.Require().NoError(file.Close())
+
+	// The journal is replayed and compacted after the restart
+	s.queue = NewQueue(s.filePath)
+	s.queue.LoadFromFile()
+	s.Assert().EqualValues(2, s.queue.Len())
+	s.Assert().FileExists(s.filePath)
+	s.Assert().NoFileExists(journalPath)
+
+	newLocalTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Assert().Less(newLocalTransactionId, localTransactionId)
+	s.Assert().FileExists(journalPath)
+
+	// Sending a message compacts the queue
+	s.centralSystem.SetConnected(true)
+	s.Assert().True(s.queue.(*queueImpl).sendNext(s.centralSystem.SendRequest, s.centralSystem.IsConnected))
+	s.Assert().NoFileExists(journalPath)
+
+	s.queue = NewQueue(s.filePath)
+	s.queue.LoadFromFile()
+	s.Assert().EqualValues(2, s.queue.Len())
+}
+
+func (s *QueueTestSuite) TestMessageAttempts() {
+	s.centralSystem.SetConnected(true)
+	s.centralSystem.failRequests = true
+
+	localTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Require().NoError(s.queue.Enqueue(core.NewStopTransactionRequest(10, types.NewDateTime(time.Now()), localTransactionId)))
+
+	impl := s.queue.(*queueImpl)
+
+	// The first attempt fails and the message is postponed
+	s.Assert().False(impl.sendNext(s.centralSystem.SendRequest, s.centralSystem.IsConnected))
+	s.Assert().EqualValues(2, s.queue.Len())
+	s.Assert().EqualValues(1, impl.messages[0].Attempts)
+	s.Assert().WithinDuration(time.Now().Add(time.Minute), impl.nextAttempt, time.Second)
+	s.Assert().False(impl.sendNext(s.centralSystem.SendRequest, s.centralSystem.IsConnected))
+
+	// The message is dropped after the last attempt
+	for attempt := 1; attempt < 3; attempt++ {
+		impl.nextAttempt = time.Time{}
+		impl.sendNext(s.centralSystem.SendRequest, s.centralSystem.IsConnected)
+	}
+	s.Assert().EqualValues(1, s.queue.Len())
+
+	// The transaction was never started, so the StopTransaction is dropped as well
+	s.centralSystem.failRequests = false
+	s.Assert().True(impl.sendNext(s.centralSystem.SendRequest, s.centralSystem.IsConnected))
+	s.Assert().EqualValues(0, s.queue.Len())
+	s.Assert().Empty(s.centralSystem.GetRequests())
+}
+
+func (s *QueueTestSuite) TestTransactionEvents() {
+	var (
+		responses = 0
+		mu        sync.Mutex
+	)
+
+	s.queue.SetTransactionEventHandler(func(request *ocpp201.TransactionEventRequest, response *ocpp201.TransactionEventResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses++
+	})
+
+	newEvent := func(eventType ocpp201.TransactionEventType, transactionId string) *ocpp201.TransactionEventRequest {
+		return ocpp201.NewTransactionEventRequest(
+			eventType,
+			types.NewDateTime(time.Now()),
+			ocpp201.TriggerReasonAuthorized,
+			ocpp201.Transaction{TransactionId: transactionId},
+		)
+	}
+
+	s.Require().NoError(s.queue.Enqueue(newEvent(ocpp201.TransactionEventStarted, "tx1")))
+	s.Require().NoError(s.queue.Enqueue(newEvent(ocpp201.TransactionEventStarted, "tx2")))
+	s.Require().NoError(s.queue.Enqueue(newEvent(ocpp201.TransactionEventUpdated, "tx1")))
+
+	// The sequence numbers are kept after the restart
+	s.queue = NewQueue(s.filePath)
+	s.queue.LoadFromFile()
+	s.queue.SetTransactionEventHandler(func(request *ocpp201.TransactionEventRequest, response *ocpp201.TransactionEventResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses++
+	})
+
+	s.Require().NoError(s.queue.Enqueue(newEvent(ocpp201.TransactionEventEnded, "tx1")))
+	s.Assert().EqualValues(4, s.queue.Len())
+
+	s.centralSystem.SetConnected(true)
+	cancel := s.run()
+	defer cancel()
+
+	s.Eventually(func() bool {
+		return s.queue.Len() == 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	requests := s.centralSystem.GetRequests()
+	s.Require().Len(requests, 4)
+	s.Assert().EqualValues(0, requests[0].(*ocpp201.TransactionEventRequest).SequenceNo)
+	s.Assert().EqualValues(0, requests[1].(*ocpp201.TransactionEventRequest).SequenceNo)
+	s.Assert().EqualValues(1, requests[2].(*ocpp201.TransactionEventRequest).SequenceNo)
+	s.Assert().EqualValues(2, requests[3].(*ocpp201.TransactionEventRequest).SequenceNo)
+
+	mu.Lock()
+	s.Assert().EqualValues(4, responses)
+	mu.Unlock()
+
+	// The sequence number of the ended transaction is removed
+	s.Assert().NotContains(s.queue.(*queueImpl).sequenceNumbers, "tx1")
+	s.Assert().Contains(s.queue.(*queueImpl).sequenceNumbers, "tx2")
+}
+
+func (s *QueueTestSuite) TestRedactToken() {
+	localTransactionId, err := s.queue.StartTransaction(core.NewStartTransactionRequest(1, "tag", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+
+	stopTransaction := core.NewStopTransactionRequest(10, types.NewDateTime(time.Now()), localTransactionId)
+	stopTransaction.IdTag = "tag"
+	s.Require().NoError(s.queue.Enqueue(stopTransaction))
+	_, err = s.queue.StartTransaction(core.NewStartTransactionRequest(2, "tag2", 0, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+
+	s.Assert().Len(s.queue.FindMessages("tag"), 2)
+	s.Assert().EqualValues([]string{"tag", "tag2"}, s.queue.GetStartTransactionTags())
+	s.Assert().EqualValues(2, s.queue.RedactToken("tag", "<redacted>"))
+	s.Assert().Empty(s.queue.FindMessages("tag"))
+
+	// The redacted messages are persisted
+	s.queue = NewQueue(s.filePath)
+	s.queue.LoadFromFile()
+	s.Assert().Len(s.queue.FindMessages("<redacted>"), 2)
+	s.Assert().Len(s.queue.FindMessages("tag2"), 1)
+}
+
+func TestTransactionQueue(t *testing.T) {
+	suite.Run(t, new(QueueTestSuite))
+}
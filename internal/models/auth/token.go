@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+	"time"
+)
+
+type (
+	TokenType  string
+	Status     string
+	UpdateType string
+)
+
+const (
+	// TokenTypeIdTag is the type of the OCPP 1.6 tags, which do not have a type. It matches the tokens of any type.
+	TokenTypeIdTag           TokenType = ""
+	TokenTypeCentral         TokenType = "Central"
+	TokenTypeEMAID           TokenType = "eMAID"
+	TokenTypeISO14443        TokenType = "ISO14443"
+	TokenTypeISO15693        TokenType = "ISO15693"
+	TokenTypeKeyCode         TokenType = "KeyCode"
+	TokenTypeLocal           TokenType = "Local"
+	TokenTypeMacAddress      TokenType = "MacAddress"
+	TokenTypeNoAuthorization TokenType = "NoAuthorization"
+
+	StatusAccepted           Status = "Accepted"
+	StatusBlocked            Status = "Blocked"
+	StatusConcurrentTx       Status = "ConcurrentTx"
+	StatusExpired            Status = "Expired"
+	StatusInvalid            Status = "Invalid"
+	StatusNoCredit           Status = "NoCredit"
+	StatusNotAllowedTypeEVSE Status = "NotAllowedTypeEVSE"
+	StatusNotAtThisLocation  Status = "NotAtThisLocation"
+	StatusNotAtThisTime      Status = "NotAtThisTime"
+	StatusUnknown            Status = "Unknown"
+
+	UpdateTypeFull         UpdateType = "Full"
+	UpdateTypeDifferential UpdateType = "Differential"
+)
+
+type (
+	// Token identifies the user or the device authorizing the transaction. It is the IdTag in OCPP 1.6 and the IdToken in OCPP 2.0.1.
+	Token struct {
+		IdToken string    `json:"idToken" yaml:"idToken"`
+		Type    TokenType `json:"type,omitempty" yaml:"type"`
+	}
+
+	// TokenInfo is the authorization status of the token, shared by both protocol versions.
+	TokenInfo struct {
+		Status       Status     `json:"status" yaml:"status"`
+		ExpiryDate   *time.Time `json:"expiryDate,omitempty" yaml:"expiryDate"`
+		GroupIdToken *Token     `json:"groupIdToken,omitempty" yaml:"groupIdToken"`
+	}
+
+	// AuthorizationData is an entry of the local authorization list or the cache. The entry without the token info
+	// removes the token from the list with a differential update.
+	AuthorizationData struct {
+		Token     Token      `json:"token" yaml:"token"`
+		TokenInfo *TokenInfo `json:"tokenInfo,omitempty" yaml:"tokenInfo"`
+	}
+)
+
+func NewToken(idToken string, tokenType TokenType) Token {
+	return Token{IdToken: idToken, Type: tokenType}
+}
+
+// NewIdTag creates a token from the OCPP 1.6 tag.
+func NewIdTag(tagId string) Token {
+	return Token{IdToken: tagId, Type: TokenTypeIdTag}
+}
+
+// Matches checks if the tokens are the same. The tokens without a type match the token with the same id of any type.
+func (t Token) Matches(token Token) bool {
+	if t.IdToken != token.IdToken {
+		return false
+	}
+
+	return t.Type == TokenTypeIdTag || token.Type == TokenTypeIdTag || t.Type == token.Type
+}
+
+// IsAuthorized checks if the status of the token is Accepted or ConcurrentTx and the token has not expired yet.
+func (i TokenInfo) IsAuthorized() bool {
+	switch i.Status {
+	case StatusAccepted, StatusConcurrentTx:
+		return i.ExpiryDate == nil || i.ExpiryDate.After(time.Now())
+	default:
+		return false
+	}
+}
+
+// GetGroupId returns the id of the group token or an empty string if the token does not belong to a group.
+func (i TokenInfo) GetGroupId() string {
+	if i.GroupIdToken == nil {
+		return ""
+	}
+
+	return i.GroupIdToken.IdToken
+}
+
+// FromIdTagInfo converts the OCPP 1.6 tag info. The parent tag is the group token.
+func FromIdTagInfo(tagInfo *types.IdTagInfo) *TokenInfo {
+	if tagInfo == nil {
+		return nil
+	}
+
+	info := &TokenInfo{Status: Status(tagInfo.Status)}
+	if tagInfo.ExpiryDate != nil {
+		expiryDate := tagInfo.ExpiryDate.Time
+		info.ExpiryDate = &expiryDate
+	}
+
+	if tagInfo.ParentIdTag != "" {
+		groupIdToken := NewIdTag(tagInfo.ParentIdTag)
+		info.GroupIdToken = &groupIdToken
+	}
+
+	return info
+}
+
+// FromIdTagAuthorizationData converts the entries of the OCPP 1.6 SendLocalList request.
+func FromIdTagAuthorizationData(updateType localauth.UpdateType, authData []localauth.AuthorizationData) (UpdateType, []AuthorizationData) {
+	var data []AuthorizationData
+	for _, entry := range authData {
+		data = append(data, AuthorizationData{
+			Token:     NewIdTag(entry.IdTag),
+			TokenInfo: FromIdTagInfo(entry.IdTagInfo),
+		})
+	}
+
+	return UpdateType(updateType), data
+}
+
+// FromIdToken converts the OCPP 2.0.1 IdToken.
+func FromIdToken(idToken ocpp201.IdToken) Token {
+	return NewToken(idToken.IdToken, TokenType(idToken.Type))
+}
+
+// FromIdTokenInfo converts the OCPP 2.0.1 IdTokenInfo. The CacheExpiryDateTime is the expiry date of the token.
+func FromIdTokenInfo(tokenInfo *ocpp201.IdTokenInfo) *TokenInfo {
+	if tokenInfo == nil {
+		return nil
+	}
+
+	info := &TokenInfo{Status: Status(tokenInfo.Status)}
+	if tokenInfo.CacheExpiryDateTime != nil {
+		expiryDate := tokenInfo.CacheExpiryDateTime.Time
+		info.ExpiryDate = &expiryDate
+	}
+
+	if tokenInfo.GroupIdToken != nil {
+		groupIdToken := FromIdToken(*tokenInfo.GroupIdToken)
+		info.GroupIdToken = &groupIdToken
+	}
+
+	return info
+}
+
+// FromIdTokenAuthorizationData converts the entries of the OCPP 2.0.1 SendLocalList request.
+func FromIdTokenAuthorizationData(updateType ocpp201.UpdateType, authData []ocpp201.AuthorizationData) (UpdateType, []AuthorizationData) {
+	var data []AuthorizationData
+	for _, entry := range authData {
+		data = append(data, AuthorizationData{
+			Token:     FromIdToken(entry.IdToken),
+			TokenInfo: FromIdTokenInfo(entry.IdTokenInfo),
+		})
+	}
+
+	return UpdateType(updateType), data
+}
@@ -12,6 +12,7 @@ type (
 	ChargePoint interface {
 		Init(settings *settings.Settings)
 		Connect(ctx context.Context, serverUrl string)
+		IsOnline() bool
 		HandleChargingRequest(tagId string) (*api.HandleChargingResponse, error)
 		StartCharging(tagId string, connectorId int) (*api.StartTransactionResponse, error)
 		StopCharging(tagId string, connectorId int) (*api.StopTransactionResponse, error)
@@ -11,4 +11,7 @@ var (
 	ErrConnectorUnavailable       = errors.New("connector unavailable")
 	ErrChargePointUnavailable     = errors.New("charge point unavailable")
 	ErrTagUnauthorized            = errors.New("tag unauthorized")
+	ErrChargePointNotAccepted     = errors.New("charge point not accepted by the central system")
+	ErrConnectorReserved          = errors.New("connector reserved for another tag")
+	ErrMeterReadingRolledBack     = errors.New("meter reading rolled back")
 )
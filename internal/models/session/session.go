@@ -6,6 +6,7 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,13 +22,18 @@ type (
 		TransactionId string
 		TagId         string
 		Started       string
-		Consumption   []types.MeterValue
+		// MeterStart is the energy register reading in Wh at the start of the session
+		MeterStart  int
+		Consumption []types.MeterValue
+		// TransactionData holds the meter values included in the StopTransaction request
+		TransactionData []types.MeterValue
 	}
 
 	SessionInterface interface {
 		StartSession(transactionId string, tagId string) error
 		EndSession()
 		AddSampledValue(samples []types.SampledValue)
+		AddTransactionData(meterValues ...types.MeterValue)
 		CalculateAvgPower() float64
 		CalculateEnergyConsumptionWithAvgPower() float64
 		CalculateEnergyConsumption() float64
@@ -50,7 +56,8 @@ func (session *Session) StartSession(transactionId string, tagId string) error {
 		return ErrSessionActive
 	}
 
-	if !strUtil.IsAlphanumeric(transactionId) {
+	// Transactions started while offline have a temporary, negative transaction id
+	if !strUtil.IsAlphanumeric(strings.TrimPrefix(transactionId, "-")) {
 		return ErrInvalidTransactionId
 	}
 
@@ -65,6 +72,7 @@ func (session *Session) StartSession(transactionId string, tagId string) error {
 	session.IsActive = true
 	session.Started = time.Now().Format(time.RFC3339)
 	session.Consumption = []types.MeterValue{}
+	session.TransactionData = nil
 	return nil
 }
 
@@ -87,6 +95,14 @@ func (session *Session) AddSampledValue(samples []types.SampledValue) {
 	}
 }
 
+// AddTransactionData Add the meter values to the transaction data of the Session, if it is active.
+func (session *Session) AddTransactionData(meterValues ...types.MeterValue) {
+	if session.IsActive {
+		log.Tracef("Added transaction data for session %s", session.TransactionId)
+		session.TransactionData = append(session.TransactionData, meterValues...)
+	}
+}
+
 // CalculateAvgPower calculate the average power for a session based on sampled values
 func (session *Session) CalculateAvgPower() float64 {
 	var (
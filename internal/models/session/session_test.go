@@ -65,6 +65,30 @@ func (s *SessionTestSuite) TestAddSampledValue() {
 	s.Require().EqualValues(expected, s.emptySession.Consumption)
 }
 
+func (s *SessionTestSuite) TestAddTransactionData() {
+	meterValue := types.MeterValue{
+		SampledValue: []types.SampledValue{
+			{
+				Value:     "123.21",
+				Context:   types.ReadingContextTransactionBegin,
+				Measurand: types.MeasurandEnergyActiveImportRegister,
+			},
+		},
+	}
+
+	// Session not active
+	s.emptySession.AddTransactionData(meterValue)
+	s.Require().Nil(s.emptySession.TransactionData)
+
+	// Session active
+	s.validSession.AddTransactionData(meterValue)
+	s.Require().EqualValues([]types.MeterValue{meterValue}, s.validSession.TransactionData)
+
+	// The transaction data is kept after the session ends
+	s.validSession.EndSession()
+	s.Require().EqualValues([]types.MeterValue{meterValue}, s.validSession.TransactionData)
+}
+
 func (s *SessionTestSuite) TestStartSession() {
 	var expectedSession = Session{
 		IsActive:      true,
@@ -86,6 +110,11 @@ func (s *SessionTestSuite) TestStartSession() {
 	s.Require().Error(err)
 	s.Require().EqualValues(s.emptySession, s.emptySession)
 
+	// Temporary transaction id
+	offlineSession := NewEmptySession()
+	err = offlineSession.StartSession("-1", "test1234")
+	s.Require().NoError(err)
+
 	// Ok case
 	err = s.emptySession.StartSession("test1234", "test1234")
 	s.Require().NoError(err)
@@ -1,11 +1,18 @@
 package settings
 
-import "github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+import (
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+)
 
 type (
 	AuthorizationFile struct {
-		Version       int               `fig:"Version" validation:"required" json:"version,omitempty" yaml:"version"`
-		MaxCachedTags int               `fig:"MaxCachedTags" validation:"required" json:"MaxCachedTags,omitempty" yaml:"MaxCachedTags"`
-		Tags          []types.IdTagInfo `fig:"Tags" json:"tags,omitempty" yaml:"tags"`
+		Version       int                      `json:"version,omitempty" yaml:"version"`
+		MaxCachedTags int                      `json:"MaxCachedTags,omitempty" yaml:"MaxCachedTags"`
+		Tags          []auth.AuthorizationData `json:"tags,omitempty" yaml:"tags"`
+	}
+
+	LocalAuthListFile struct {
+		Version int                      `json:"version" yaml:"version"`
+		Tags    []auth.AuthorizationData `json:"tags,omitempty" yaml:"tags"`
 	}
 )
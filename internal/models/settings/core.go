@@ -20,6 +20,8 @@ type (
 		BasicAuthUsername string   `fig:"basicAuthUser" json:"basicAuthUser,omitempty" yaml:"basicAuthUser" mapstructure:"basicAuthUser"`
 		BasicAuthPassword string   `fig:"basicAuthPass" json:"basicAuthPass,omitempty" yaml:"basicAuthPass" mapstructure:"basicAuthPass"`
 		MaxChargingTime   int      `fig:"MaxChargingTime" default:"180" json:"MaxChargingTime,omitempty" yaml:"MaxChargingTime" mapstructure:"MaxChargingTime"`
+		RebootCommand     string   `fig:"rebootCommand" json:"rebootCommand,omitempty" yaml:"rebootCommand" mapstructure:"rebootCommand"`
+		Availability      string   `fig:"availability" json:"availability,omitempty" yaml:"availability" mapstructure:"availability"`
 		OCPPInfo          OCPPInfo `fig:"ocpp" json:"ocpp" yaml:"ocpp" mapstructure:"ocpp"`
 	}
 
@@ -28,6 +30,7 @@ type (
 		CACertificatePath     string `fig:"CACertificatePath" json:"CACertificatePath,omitempty" yaml:"CACertificatePath" mapstructure:"CACertificatePath"`
 		ClientCertificatePath string `fig:"ClientCertificatePath" json:"ClientCertificatePath,omitempty" yaml:"ClientCertificatePath" mapstructure:"ClientCertificatePath"`
 		ClientKeyPath         string `fig:"ClientKeyPath" json:"ClientKeyPath,omitempty" yaml:"ClientKeyPath" mapstructure:"ClientKeyPath"`
+		CertificateStorePath  string `fig:"CertificateStorePath" json:"CertificateStorePath,omitempty" yaml:"CertificateStorePath" mapstructure:"CertificateStorePath"`
 	}
 
 	Logging struct {
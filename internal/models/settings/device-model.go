@@ -0,0 +1,10 @@
+package settings
+
+import "github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+
+type (
+	// DeviceModelFile contains the variables of the OCPP 2.0.1 device model with their attributes and characteristics.
+	DeviceModelFile struct {
+		Variables []ocpp201.ReportData `json:"variables,omitempty" yaml:"variables"`
+	}
+)
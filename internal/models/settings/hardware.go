@@ -45,6 +45,13 @@ type (
 		Consumption          float64 `fig:"Consumption" json:"consumption,omitempty" yaml:"consumption" mapstructure:"consumption"`
 		ShuntOffset          float64 `fig:"ShuntOffset" json:"ShuntOffset,omitempty" yaml:"ShuntOffset" mapstructure:"ShuntOffset"`
 		VoltageDividerOffset float64 `fig:"VoltageDividerOffset" json:"VoltageDividerOffset,omitempty" yaml:"VoltageDividerOffset" mapstructure:"VoltageDividerOffset"`
+		// EnergyRegister keeps the energy reading of the power meter across the restarts of the client.
+		EnergyRegister EnergyRegister `fig:"EnergyRegister" json:"energyRegister,omitempty" yaml:"energyRegister" mapstructure:"energyRegister"`
+	}
+
+	EnergyRegister struct {
+		// Energy is the total energy in Wh counted by the power meter.
+		Energy float64 `fig:"Energy" json:"energy,omitempty" yaml:"energy" mapstructure:"energy"`
 	}
 
 	PowerMeters struct {
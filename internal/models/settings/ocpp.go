@@ -2,6 +2,8 @@ package settings
 
 import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/auth"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
 )
 
 const (
@@ -22,20 +24,36 @@ type (
 	}
 
 	Connector struct {
-		EvseId      int        `fig:"EvseId" validate:"required" json:"EvseId,omitempty" yaml:"EvseId" mapstructure:"EvseId"`
-		ConnectorId int        `fig:"ConnectorId" validate:"required" json:"ConnectorId,omitempty" yaml:"ConnectorId" mapstructure:"ConnectorId"`
-		Type        string     `fig:"Type" validate:"required" json:"type,omitempty" yaml:"type" mapstructure:"type"`
-		Status      string     `fig:"Status" validation:"required" json:"status,omitempty" yaml:"status" mapstructure:"status"`
-		Session     Session    `fig:"Session" json:"session" yaml:"session" mapstructure:"session"`
-		Relay       Relay      `fig:"Relay" json:"relay" yaml:"relay" mapstructure:"relay"`
-		PowerMeter  PowerMeter `fig:"PowerMeter" json:"PowerMeter" yaml:"PowerMeter" mapstructure:"PowerMeter"`
+		EvseId       int        `fig:"EvseId" validate:"required" json:"EvseId,omitempty" yaml:"EvseId" mapstructure:"EvseId"`
+		ConnectorId  int        `fig:"ConnectorId" validate:"required" json:"ConnectorId,omitempty" yaml:"ConnectorId" mapstructure:"ConnectorId"`
+		Type         string     `fig:"Type" validate:"required" json:"type,omitempty" yaml:"type" mapstructure:"type"`
+		Status       string     `fig:"Status" validation:"required" json:"status,omitempty" yaml:"status" mapstructure:"status"`
+		Availability string     `fig:"Availability" json:"availability,omitempty" yaml:"availability" mapstructure:"availability"`
+		Session      Session    `fig:"Session" json:"session" yaml:"session" mapstructure:"session"`
+		Relay        Relay      `fig:"Relay" json:"relay" yaml:"relay" mapstructure:"relay"`
+		PowerMeter   PowerMeter `fig:"PowerMeter" json:"PowerMeter" yaml:"PowerMeter" mapstructure:"PowerMeter"`
+		// Transaction is the state of the ongoing OCPP 2.0.1 transaction, which is not stored in the Session.
+		Transaction *Transaction `fig:"Transaction" json:"transaction,omitempty" yaml:"transaction,omitempty" mapstructure:"transaction"`
 	}
 
 	Session struct {
-		IsActive      bool               `fig:"IsActive" json:"IsActive,omitempty" yaml:"IsActive" mapstructure:"IsActive"`
-		TransactionId string             `fig:"TransactionId" default:"" json:"TransactionId,omitempty" yaml:"TransactionId" mapstructure:"TransactionId"`
-		TagId         string             `fig:"TagId" default:"" json:"TagId,omitempty" yaml:"TagId" mapstructure:"TagId"`
-		Started       string             `fig:"Started" default:"" json:"started,omitempty" yaml:"started" mapstructure:"started"`
-		Consumption   []types.MeterValue `fig:"Consumption" json:"consumption,omitempty" yaml:"consumption" mapstructure:"consumption"`
+		IsActive        bool               `fig:"IsActive" json:"IsActive,omitempty" yaml:"IsActive" mapstructure:"IsActive"`
+		TransactionId   string             `fig:"TransactionId" default:"" json:"TransactionId,omitempty" yaml:"TransactionId" mapstructure:"TransactionId"`
+		TagId           string             `fig:"TagId" default:"" json:"TagId,omitempty" yaml:"TagId" mapstructure:"TagId"`
+		Started         string             `fig:"Started" default:"" json:"started,omitempty" yaml:"started" mapstructure:"started"`
+		MeterStart      int                `fig:"MeterStart" json:"meterStart,omitempty" yaml:"meterStart" mapstructure:"meterStart"`
+		Consumption     []types.MeterValue `fig:"Consumption" json:"consumption,omitempty" yaml:"consumption" mapstructure:"consumption"`
+		TransactionData []types.MeterValue `fig:"TransactionData" json:"transactionData,omitempty" yaml:"transactionData" mapstructure:"transactionData"`
+	}
+
+	Transaction struct {
+		TransactionId string          `fig:"TransactionId" json:"transactionId" yaml:"transactionId" mapstructure:"transactionId"`
+		IdToken       ocpp201.IdToken `fig:"IdToken" json:"idToken" yaml:"idToken" mapstructure:"idToken"`
+		// The IdTokens of the same group can stop the transaction
+		GroupIdToken *auth.Token `fig:"GroupIdToken" json:"groupIdToken,omitempty" yaml:"groupIdToken,omitempty" mapstructure:"groupIdToken"`
+		// The IdToken that stopped the transaction, if it's not the IdToken that started it
+		StopIdToken   *ocpp201.IdToken      `fig:"StopIdToken" json:"stopIdToken,omitempty" yaml:"stopIdToken,omitempty" mapstructure:"stopIdToken"`
+		ChargingState ocpp201.ChargingState `fig:"ChargingState" json:"chargingState,omitempty" yaml:"chargingState,omitempty" mapstructure:"chargingState"`
+		RemoteStartId *int                  `fig:"RemoteStartId" json:"remoteStartId,omitempty" yaml:"remoteStartId,omitempty" mapstructure:"remoteStartId"`
 	}
 )
@@ -0,0 +1,19 @@
+package settings
+
+import "time"
+
+type (
+	ReservationsFile struct {
+		Reservations []Reservation `json:"reservations,omitempty" yaml:"reservations"`
+	}
+
+	// Reservation is a reservation of a connector for the tag or the group of tags with the parentIdTag.
+	// A reservation with connector 0 reserves any connector of the charge point.
+	Reservation struct {
+		ReservationId int       `json:"reservationId" yaml:"reservationId"`
+		ConnectorId   int       `json:"connectorId" yaml:"connectorId"`
+		IdTag         string    `json:"idTag" yaml:"idTag"`
+		ParentIdTag   string    `json:"parentIdTag,omitempty" yaml:"parentIdTag"`
+		ExpiryDate    time.Time `json:"expiryDate" yaml:"expiryDate"`
+	}
+)
@@ -0,0 +1,30 @@
+package settings
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/ocpp201"
+)
+
+type (
+	TransactionQueueFile struct {
+		LastMessageId          int                  `json:"lastMessageId"`
+		LastLocalTransactionId int                  `json:"lastLocalTransactionId"`
+		TransactionIds         map[int]int          `json:"transactionIds,omitempty"`
+		Messages               []TransactionMessage `json:"messages,omitempty"`
+		// SequenceNumbers are the next sequence numbers of the ongoing OCPP 2.0.1 transactions.
+		SequenceNumbers map[string]int `json:"sequenceNumbers,omitempty"`
+	}
+
+	// TransactionMessage is a queued transaction related message. Only one of the requests is set.
+	TransactionMessage struct {
+		Id       int `json:"id"`
+		Attempts int `json:"attempts"`
+		// LocalTransactionId is the temporary transaction id assigned to the StartTransaction request.
+		LocalTransactionId int                           `json:"localTransactionId,omitempty"`
+		StartTransaction   *core.StartTransactionRequest `json:"startTransaction,omitempty"`
+		StopTransaction    *core.StopTransactionRequest  `json:"stopTransaction,omitempty"`
+		MeterValues        *core.MeterValuesRequest      `json:"meterValues,omitempty"`
+		// TransactionEvent is the OCPP 2.0.1 transaction message.
+		TransactionEvent *ocpp201.TransactionEventRequest `json:"transactionEvent,omitempty"`
+	}
+)
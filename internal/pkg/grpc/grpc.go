@@ -7,14 +7,14 @@ import (
 	"net"
 )
 
-func CreateAndRunGrpcServer(address string, sendChannel chan api.Message, receiveChannel chan api.Message) {
+func CreateAndRunGrpcServer(address string, isOnline func() bool, sendChannel chan api.Message, receiveChannel chan api.Message) {
 	grpcServer := grpc.NewServer()
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		log.WithError(err).Fatalf("Unable to listen to provided address: %s", address)
 	}
 
-	api.RegisterChargePointServer(grpcServer, api.NewApiServer(log.StandardLogger(), sendChannel, receiveChannel))
+	api.RegisterChargePointServer(grpcServer, api.NewApiServer(log.StandardLogger(), isOnline, sendChannel, receiveChannel))
 
 	err = grpcServer.Serve(listener)
 	if err != nil {
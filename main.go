@@ -18,6 +18,10 @@ const (
 	settingsFlag       = "settings"
 	connectorsFlag     = "connector-folder"
 	authFileFlag       = "auth"
+	localAuthListFlag  = "local-auth-list"
+	txQueueFlag        = "transaction-queue"
+	reservationsFlag   = "reservations"
+	deviceModelFlag    = "device-model"
 	ocppConfigPathFlag = "ocpp-config"
 )
 
@@ -27,6 +31,10 @@ var (
 	connectorsFolderPath  string
 	settingsFilePath      string
 	authFilePath          string
+	localAuthListFilePath string
+	txQueueFilePath       string
+	reservationsFilePath  string
+	deviceModelFilePath   string
 
 	rootCmd = &cobra.Command{
 		Use:   "chargepi",
@@ -45,7 +53,7 @@ func run(cmd *cobra.Command, args []string) {
 		connectors   = settings.GetConnectors(connectorsFolderPath)
 	)
 
-	chargepoint.Run(isDebug, mainSettings, connectors, configurationFilePath, authFilePath)
+	chargepoint.Run(isDebug, mainSettings, connectors, configurationFilePath, authFilePath, localAuthListFilePath, txQueueFilePath, reservationsFilePath, deviceModelFilePath)
 }
 
 func setupFlags() {
@@ -53,6 +61,10 @@ func setupFlags() {
 		workingDirectory, _   = os.Getwd()
 		connectorsFolderName  = fmt.Sprintf("%s/configs/connectors", workingDirectory)
 		defaultConfigFileName = fmt.Sprintf("%s/configs/configuration.%s", workingDirectory, "json")
+		defaultLocalListName  = fmt.Sprintf("%s/configs/local-auth-list.%s", workingDirectory, "json")
+		defaultTxQueueName    = fmt.Sprintf("%s/configs/transaction-queue.%s", workingDirectory, "json")
+		defaultReservations   = fmt.Sprintf("%s/configs/reservations.%s", workingDirectory, "json")
+		defaultDeviceModel    = fmt.Sprintf("%s/configs/device-model.%s", workingDirectory, "json")
 	)
 
 	// Set flags
@@ -60,6 +72,10 @@ func setupFlags() {
 	rootCmd.PersistentFlags().StringVar(&connectorsFolderPath, connectorsFlag, connectorsFolderName, "connector folder path")
 	rootCmd.PersistentFlags().StringVar(&configurationFilePath, ocppConfigPathFlag, defaultConfigFileName, "OCPP config file path")
 	rootCmd.PersistentFlags().StringVar(&authFilePath, authFileFlag, "", "authorization file path")
+	rootCmd.PersistentFlags().StringVar(&localAuthListFilePath, localAuthListFlag, defaultLocalListName, "local authorization list file path")
+	rootCmd.PersistentFlags().StringVar(&txQueueFilePath, txQueueFlag, defaultTxQueueName, "transaction queue file path")
+	rootCmd.PersistentFlags().StringVar(&reservationsFilePath, reservationsFlag, defaultReservations, "reservations file path")
+	rootCmd.PersistentFlags().StringVar(&deviceModelFilePath, deviceModelFlag, defaultDeviceModel, "OCPP 2.0.1 device model file path")
 	rootCmd.PersistentFlags().BoolP(debugFlag, "d", false, "debug mode")
 
 	// Api flags
@@ -76,6 +92,7 @@ func setupFlags() {
 
 func main() {
 	setupFlags()
+	setupCustomerInformationFlags()
 	err := rootCmd.Execute()
 	if err != nil {
 		log.WithError(err).Fatal("Unable to run")
@@ -9,6 +9,7 @@ import (
 	lSyslog "github.com/sirupsen/logrus/hooks/syslog"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"log/syslog"
+	"sync"
 	"time"
 )
 
@@ -26,7 +27,14 @@ const (
 	Gelf   = LogFormat("gelf")
 	Json   = LogFormat("json")
 
-	logFilePath = "/var/log/chargepi/chargepi.log"
+	LogFilePath         = "/var/log/chargepi/chargepi.log"
+	SecurityLogFilePath = "/var/log/chargepi/security.log"
+)
+
+var (
+	// fileWriter is the writer of the log file, if the file logging is enabled.
+	fileWriter   *rotatelogs.RotateLogs
+	fileWriterMu sync.Mutex
 )
 
 // Setup set up all logs
@@ -48,7 +56,7 @@ func Setup(logger *log.Logger, loggingConfig settings.Logging, isDebug bool) {
 	for _, logType := range loggingConfig.Type {
 		switch LogType(logType) {
 		case FileLogging:
-			fileLogging(logger, isDebug, logFilePath)
+			fileLogging(logger, isDebug, LogFilePath)
 			break
 		case RemoteLogging:
 			remoteLogging(logger, loggingConfig.Host, loggingConfig.Port, logFormat)
@@ -104,6 +112,10 @@ func fileLogging(logger *log.Logger, isDebug bool, path string) {
 		return
 	}
 
+	fileWriterMu.Lock()
+	fileWriter = writer
+	fileWriterMu.Unlock()
+
 	writerMap := make(lfshook.WriterMap)
 	writerMap[log.InfoLevel] = writer
 	writerMap[log.ErrorLevel] = writer
@@ -119,3 +131,21 @@ func fileLogging(logger *log.Logger, isDebug bool, path string) {
 
 	logger.AddHook(hook)
 }
+
+// RotateLogFile starts a new log file, so the previous files are not held open by the logger anymore.
+// Returns the name of the new log file or an empty string, if the file logging is not enabled.
+func RotateLogFile() (string, error) {
+	fileWriterMu.Lock()
+	defer fileWriterMu.Unlock()
+
+	if fileWriter == nil {
+		return "", nil
+	}
+
+	err := fileWriter.Rotate()
+	if err != nil {
+		return "", err
+	}
+
+	return fileWriter.CurrentFileName(), nil
+}
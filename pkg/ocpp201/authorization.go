@@ -0,0 +1,73 @@
+package ocpp201
+
+import "github.com/lorenzodonini/ocpp-go/ocpp"
+
+// -------------------- Authorization (CS -> CSMS) --------------------
+
+const (
+	AuthorizeFeatureName  = "Authorize"
+	ClearCacheFeatureName = "ClearCache"
+)
+
+type ClearCacheStatus string
+
+const (
+	ClearCacheStatusAccepted ClearCacheStatus = "Accepted"
+	ClearCacheStatusRejected ClearCacheStatus = "Rejected"
+)
+
+type (
+	// AuthorizationHandler handles the requests of the CSMS regarding the authorization cache.
+	AuthorizationHandler interface {
+		OnClearCache(request *ClearCacheRequest) (response *ClearCacheResponse, err error)
+	}
+
+	// AuthorizeRequest is sent by the charging station to check if the IdToken can start or stop a transaction.
+	AuthorizeRequest struct {
+		IdToken     IdToken `json:"idToken" validate:"required"`
+		Certificate string  `json:"certificate,omitempty" validate:"max=5500"`
+	}
+
+	AuthorizeResponse struct {
+		IdTokenInfo IdTokenInfo `json:"idTokenInfo" validate:"required"`
+	}
+
+	// ClearCacheRequest is sent by the CSMS to clear the authorization cache.
+	ClearCacheRequest struct {
+	}
+
+	ClearCacheResponse struct {
+		Status     ClearCacheStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+		StatusInfo *StatusInfo      `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+)
+
+func (r AuthorizeRequest) GetFeatureName() string {
+	return AuthorizeFeatureName
+}
+
+func (c AuthorizeResponse) GetFeatureName() string {
+	return AuthorizeFeatureName
+}
+
+func (r ClearCacheRequest) GetFeatureName() string {
+	return ClearCacheFeatureName
+}
+
+func (c ClearCacheResponse) GetFeatureName() string {
+	return ClearCacheFeatureName
+}
+
+func NewAuthorizeRequest(idToken IdToken) *AuthorizeRequest {
+	return &AuthorizeRequest{IdToken: idToken}
+}
+
+func NewClearCacheResponse(status ClearCacheStatus) *ClearCacheResponse {
+	return &ClearCacheResponse{Status: status}
+}
+
+var AuthorizationProfile = ocpp.NewProfile(
+	AuthorizationProfileName,
+	newFeature(AuthorizeFeatureName, AuthorizeRequest{}, AuthorizeResponse{}),
+	newFeature(ClearCacheFeatureName, ClearCacheRequest{}, ClearCacheResponse{}),
+)
@@ -0,0 +1,55 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Availability (CS -> CSMS) --------------------
+
+const StatusNotificationFeatureName = "StatusNotification"
+
+type ConnectorStatus string
+
+const (
+	ConnectorStatusAvailable   ConnectorStatus = "Available"
+	ConnectorStatusOccupied    ConnectorStatus = "Occupied"
+	ConnectorStatusReserved    ConnectorStatus = "Reserved"
+	ConnectorStatusUnavailable ConnectorStatus = "Unavailable"
+	ConnectorStatusFaulted     ConnectorStatus = "Faulted"
+)
+
+type (
+	// StatusNotificationRequest is sent by the charging station when the status of a connector changes.
+	StatusNotificationRequest struct {
+		Timestamp       *types.DateTime `json:"timestamp" validate:"required"`
+		ConnectorStatus ConnectorStatus `json:"connectorStatus" validate:"required,oneof=Available Occupied Reserved Unavailable Faulted"`
+		EvseId          int             `json:"evseId" validate:"gte=0"`
+		ConnectorId     int             `json:"connectorId" validate:"gte=0"`
+	}
+
+	StatusNotificationResponse struct {
+	}
+)
+
+func (r StatusNotificationRequest) GetFeatureName() string {
+	return StatusNotificationFeatureName
+}
+
+func (c StatusNotificationResponse) GetFeatureName() string {
+	return StatusNotificationFeatureName
+}
+
+func NewStatusNotificationRequest(timestamp *types.DateTime, status ConnectorStatus, evseId, connectorId int) *StatusNotificationRequest {
+	return &StatusNotificationRequest{
+		Timestamp:       timestamp,
+		ConnectorStatus: status,
+		EvseId:          evseId,
+		ConnectorId:     connectorId,
+	}
+}
+
+var AvailabilityProfile = ocpp.NewProfile(
+	AvailabilityProfileName,
+	newFeature(StatusNotificationFeatureName, StatusNotificationRequest{}, StatusNotificationResponse{}),
+)
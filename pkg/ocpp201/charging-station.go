@@ -0,0 +1,445 @@
+package ocpp201
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	log "github.com/sirupsen/logrus"
+	requestDispatcher "github.com/xBlaz3kx/ChargePi-go/pkg/request-dispatcher"
+	"sync"
+	"time"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+var (
+	ErrRequestTimeout = requestDispatcher.ErrRequestTimeout
+	ErrNotConnected   = errors.New("not connected to the CSMS")
+	ErrNoHandler      = errors.New("handler not set")
+)
+
+type (
+	// ChargingStation is an OCPP 2.0.1 client built on top of the OCPP-J layer, since the OCPP library only supports
+	// a draft of OCPP 2.0. The requests of the CSMS are dispatched to the handlers of the functional blocks. The requests
+	// to the CSMS are queued by the request dispatcher, which sends the next request only after the previous one is answered.
+	ChargingStation interface {
+		SetAuthorizationHandler(handler AuthorizationHandler)
+		SetLocalAuthListHandler(handler LocalAuthListHandler)
+		SetRemoteControlHandler(handler RemoteControlHandler)
+		SetDeviceModelHandler(handler DeviceModelHandler)
+		SetDisplayMessageHandler(handler DisplayMessageHandler)
+		SetDiagnosticsHandler(handler DiagnosticsHandler)
+		SetTariffCostHandler(handler TariffCostHandler)
+		SetDataTransferHandler(handler DataTransferHandler)
+		SetRequestTimeout(timeout time.Duration)
+		// SendRequest sends the request to the CSMS and waits for the response.
+		SendRequest(request ocpp.Request) (ocpp.Response, error)
+		// SendRequestAsync sends the request to the CSMS and calls the callback with the response in the background.
+		SendRequestAsync(request ocpp.Request, callback func(response ocpp.Response, err error)) error
+		Start(url string) error
+		Stop()
+		IsConnected() bool
+	}
+
+	// wsClient passes the messages to the OCPP-J client, unless they are responses to requests, which are not pending anymore.
+	wsClient struct {
+		ws.WsClient
+		state ocppj.ClientState
+	}
+
+	asyncResponse struct {
+		response ocpp.Response
+		err      error
+	}
+
+	chargingStationImpl struct {
+		client                ws.WsClient
+		endpoint              *ocppj.Client
+		dispatcher            requestDispatcher.Dispatcher
+		mu                    sync.Mutex
+		authorizationHandler  AuthorizationHandler
+		localAuthListHandler  LocalAuthListHandler
+		remoteControlHandler  RemoteControlHandler
+		deviceModelHandler    DeviceModelHandler
+		displayMessageHandler DisplayMessageHandler
+		diagnosticsHandler    DiagnosticsHandler
+		tariffCostHandler     TariffCostHandler
+		dataTransferHandler   DataTransferHandler
+	}
+)
+
+// NewChargingStation creates an OCPP 2.0.1 charging station with the id, which communicates through the websocket client.
+func NewChargingStation(id string, client ws.WsClient) ChargingStation {
+	if client == nil {
+		client = ws.NewClient()
+	}
+
+	client.AddOption(func(dialer *websocket.Dialer) {
+		for _, protocol := range dialer.Subprotocols {
+			if protocol == Subprotocol {
+				return
+			}
+		}
+
+		dialer.Subprotocols = append(dialer.Subprotocols, Subprotocol)
+	})
+
+	var (
+		state      = ocppj.NewClientState()
+		dispatcher = requestDispatcher.NewDispatcher()
+		station    = &chargingStationImpl{
+			client:     client,
+			dispatcher: dispatcher,
+			mu:         sync.Mutex{},
+		}
+	)
+
+	dispatcher.SetTimeout(defaultRequestTimeout)
+
+	station.endpoint = ocppj.NewClient(
+		id,
+		&wsClient{WsClient: client, state: state},
+		dispatcher,
+		state,
+		AuthorizationProfile,
+		AvailabilityProfile,
+		DataTransferProfile,
+		DiagnosticsProfile,
+		DisplayMessageProfile,
+		LocalAuthListProfile,
+		MeterValuesProfile,
+		ProvisioningProfile,
+		RemoteControlProfile,
+		TariffCostProfile,
+		TransactionsProfile,
+	)
+	station.endpoint.SetRequestHandler(station.onRequest)
+	station.endpoint.SetResponseHandler(station.onResponse)
+	station.endpoint.SetErrorHandler(station.onError)
+	return station
+}
+
+// SetMessageHandler sets the handler of the OCPP-J client. The responses to the requests, which are not pending anymore
+// (e.g. the request timed out), are dropped, and the messages which cannot be parsed are answered by the OCPP-J client.
+func (w *wsClient) SetMessageHandler(handler func(data []byte) error) {
+	w.WsClient.SetMessageHandler(func(data []byte) error {
+		arr, err := ocppj.ParseRawJsonMessage(data)
+		if err == nil && len(arr) >= 3 {
+			messageType, _ := arr[0].(float64)
+			uniqueId, _ := arr[1].(string)
+
+			switch ocppj.MessageType(messageType) {
+			case ocppj.CALL_RESULT, ocppj.CALL_ERROR:
+				if _, isPending := w.state.GetPendingRequest(uniqueId); !isPending {
+					log.Warnf("Received a response to an unknown request %s", uniqueId)
+					return nil
+				}
+			}
+		}
+
+		err = handler(data)
+		if err != nil {
+			log.WithError(err).Warn("Received an invalid message from the CSMS")
+		}
+
+		return nil
+	})
+}
+
+// SetAuthorizationHandler sets the handler for the ClearCache requests.
+func (c *chargingStationImpl) SetAuthorizationHandler(handler AuthorizationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authorizationHandler = handler
+}
+
+// SetLocalAuthListHandler sets the handler for the SendLocalList and GetLocalListVersion requests.
+func (c *chargingStationImpl) SetLocalAuthListHandler(handler LocalAuthListHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.localAuthListHandler = handler
+}
+
+// SetRemoteControlHandler sets the handler for the RequestStartTransaction and RequestStopTransaction requests.
+func (c *chargingStationImpl) SetRemoteControlHandler(handler RemoteControlHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remoteControlHandler = handler
+}
+
+// SetDeviceModelHandler sets the handler for the GetVariables, SetVariables, GetBaseReport and GetReport requests.
+func (c *chargingStationImpl) SetDeviceModelHandler(handler DeviceModelHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deviceModelHandler = handler
+}
+
+// SetDisplayMessageHandler sets the handler for the SetDisplayMessage, GetDisplayMessages and ClearDisplayMessage requests.
+func (c *chargingStationImpl) SetDisplayMessageHandler(handler DisplayMessageHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.displayMessageHandler = handler
+}
+
+// SetDiagnosticsHandler sets the handler for the SetVariableMonitoring, ClearVariableMonitoring, SetMonitoringBase and
+// CustomerInformation requests.
+func (c *chargingStationImpl) SetDiagnosticsHandler(handler DiagnosticsHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnosticsHandler = handler
+}
+
+// SetTariffCostHandler sets the handler for the CostUpdated requests.
+func (c *chargingStationImpl) SetTariffCostHandler(handler TariffCostHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tariffCostHandler = handler
+}
+
+// SetDataTransferHandler sets the handler for the DataTransfer requests.
+func (c *chargingStationImpl) SetDataTransferHandler(handler DataTransferHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dataTransferHandler = handler
+}
+
+// SetRequestTimeout sets how long the charging station waits for the response of the CSMS. Must be called before Start.
+func (c *chargingStationImpl) SetRequestTimeout(timeout time.Duration) {
+	c.dispatcher.SetTimeout(timeout)
+}
+
+// Start connects to the CSMS. The id of the charging station is appended to the url.
+func (c *chargingStationImpl) Start(url string) error {
+	return c.endpoint.Start(url)
+}
+
+// Stop disconnects from the CSMS. The requests waiting for the response are cancelled.
+func (c *chargingStationImpl) Stop() {
+	c.endpoint.Stop()
+	c.dispatcher.CancelRequests(ErrNotConnected)
+}
+
+func (c *chargingStationImpl) IsConnected() bool {
+	return c.client.IsConnected()
+}
+
+func (c *chargingStationImpl) SendRequest(request ocpp.Request) (ocpp.Response, error) {
+	responseChannel := make(chan asyncResponse, 1)
+
+	err := c.SendRequestAsync(request, func(response ocpp.Response, err error) {
+		responseChannel <- asyncResponse{response: response, err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-responseChannel
+	return result.response, result.err
+}
+
+func (c *chargingStationImpl) SendRequestAsync(request ocpp.Request, callback func(response ocpp.Response, err error)) error {
+	if !c.client.IsConnected() {
+		return ErrNotConnected
+	}
+
+	return c.dispatcher.SendRequestAsync(c.endpoint, request, callback)
+}
+
+func (c *chargingStationImpl) onResponse(response ocpp.Response, requestId string) {
+	c.dispatcher.HandleResponse(requestId, response, nil)
+}
+
+func (c *chargingStationImpl) onError(err *ocpp.Error, details interface{}) {
+	c.dispatcher.HandleResponse(err.MessageId, nil, err)
+}
+
+// onRequest handles the request in the background, since the handlers might send requests to the CSMS.
+func (c *chargingStationImpl) onRequest(request ocpp.Request, requestId string, action string) {
+	go c.handleRequest(request, requestId)
+}
+
+func (c *chargingStationImpl) handleRequest(request ocpp.Request, requestId string) {
+	response, err := c.dispatch(request)
+
+	var ocppErr *ocpp.Error
+	switch {
+	case errors.Is(err, ErrNoHandler):
+		err = c.endpoint.SendError(requestId, ocppj.NotSupported, err.Error(), nil)
+	case errors.As(err, &ocppErr):
+		// The handlers can reject the request with an OCPP error code
+		err = c.endpoint.SendError(requestId, ocppErr.Code, ocppErr.Description, nil)
+	case err != nil:
+		err = c.endpoint.SendError(requestId, ocppj.InternalError, err.Error(), nil)
+	default:
+		err = c.endpoint.SendResponse(requestId, response)
+		if err != nil {
+			log.WithError(err).Errorf("Invalid response to the request %s", requestId)
+			err = c.endpoint.SendError(requestId, ocppj.InternalError, err.Error(), nil)
+		}
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("Unable to respond to the request %s", requestId)
+	}
+}
+
+func (c *chargingStationImpl) dispatch(request ocpp.Request) (ocpp.Response, error) {
+	c.mu.Lock()
+	authorizationHandler := c.authorizationHandler
+	localAuthListHandler := c.localAuthListHandler
+	remoteControlHandler := c.remoteControlHandler
+	deviceModelHandler := c.deviceModelHandler
+	displayMessageHandler := c.displayMessageHandler
+	diagnosticsHandler := c.diagnosticsHandler
+	tariffCostHandler := c.tariffCostHandler
+	dataTransferHandler := c.dataTransferHandler
+	c.mu.Unlock()
+
+	log.Debugf("Received %s request", request.GetFeatureName())
+
+	// The responses are returned as interfaces only if they are not nil, otherwise the CallResult would contain a nil payload
+	switch request := request.(type) {
+	case *ClearCacheRequest:
+		if authorizationHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := authorizationHandler.OnClearCache(request)
+		return toResponse(response, response == nil, err)
+	case *SendLocalListRequest:
+		if localAuthListHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := localAuthListHandler.OnSendLocalList(request)
+		return toResponse(response, response == nil, err)
+	case *GetLocalListVersionRequest:
+		if localAuthListHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := localAuthListHandler.OnGetLocalListVersion(request)
+		return toResponse(response, response == nil, err)
+	case *RequestStartTransactionRequest:
+		if remoteControlHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := remoteControlHandler.OnRequestStartTransaction(request)
+		return toResponse(response, response == nil, err)
+	case *RequestStopTransactionRequest:
+		if remoteControlHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := remoteControlHandler.OnRequestStopTransaction(request)
+		return toResponse(response, response == nil, err)
+	case *GetVariablesRequest:
+		if deviceModelHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := deviceModelHandler.OnGetVariables(request)
+		return toResponse(response, response == nil, err)
+	case *SetVariablesRequest:
+		if deviceModelHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := deviceModelHandler.OnSetVariables(request)
+		return toResponse(response, response == nil, err)
+	case *GetBaseReportRequest:
+		if deviceModelHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := deviceModelHandler.OnGetBaseReport(request)
+		return toResponse(response, response == nil, err)
+	case *GetReportRequest:
+		if deviceModelHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := deviceModelHandler.OnGetReport(request)
+		return toResponse(response, response == nil, err)
+	case *SetDisplayMessageRequest:
+		if displayMessageHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := displayMessageHandler.OnSetDisplayMessage(request)
+		return toResponse(response, response == nil, err)
+	case *GetDisplayMessagesRequest:
+		if displayMessageHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := displayMessageHandler.OnGetDisplayMessages(request)
+		return toResponse(response, response == nil, err)
+	case *ClearDisplayMessageRequest:
+		if displayMessageHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := displayMessageHandler.OnClearDisplayMessage(request)
+		return toResponse(response, response == nil, err)
+	case *SetVariableMonitoringRequest:
+		if diagnosticsHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := diagnosticsHandler.OnSetVariableMonitoring(request)
+		return toResponse(response, response == nil, err)
+	case *ClearVariableMonitoringRequest:
+		if diagnosticsHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := diagnosticsHandler.OnClearVariableMonitoring(request)
+		return toResponse(response, response == nil, err)
+	case *SetMonitoringBaseRequest:
+		if diagnosticsHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := diagnosticsHandler.OnSetMonitoringBase(request)
+		return toResponse(response, response == nil, err)
+	case *CustomerInformationRequest:
+		if diagnosticsHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := diagnosticsHandler.OnCustomerInformation(request)
+		return toResponse(response, response == nil, err)
+	case *CostUpdatedRequest:
+		if tariffCostHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := tariffCostHandler.OnCostUpdated(request)
+		return toResponse(response, response == nil, err)
+	case *DataTransferRequest:
+		if dataTransferHandler == nil {
+			return nil, ErrNoHandler
+		}
+
+		response, err := dataTransferHandler.OnDataTransfer(request)
+		return toResponse(response, response == nil, err)
+	default:
+		return nil, ErrNoHandler
+	}
+}
+
+func toResponse(response ocpp.Response, isNil bool, err error) (ocpp.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if isNil {
+		return nil, errors.New("empty response")
+	}
+
+	return response, nil
+}
@@ -0,0 +1,245 @@
+package ocpp201
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type (
+	wsClientMock struct {
+		mock.Mock
+		ws.WsClient
+		messageHandler func(data []byte) error
+		options        []func(dialer *websocket.Dialer)
+		written        chan []byte
+	}
+
+	remoteControlHandlerMock struct {
+		mock.Mock
+	}
+
+	chargingStationTestSuite struct {
+		suite.Suite
+		client          *wsClientMock
+		chargingStation ChargingStation
+	}
+)
+
+func (w *wsClientMock) SetMessageHandler(handler func(data []byte) error) {
+	w.messageHandler = handler
+}
+
+func (w *wsClientMock) SetDisconnectedHandler(handler func(err error)) {}
+
+func (w *wsClientMock) SetReconnectedHandler(handler func()) {}
+
+func (w *wsClientMock) AddOption(option interface{}) {
+	w.options = append(w.options, option.(func(dialer *websocket.Dialer)))
+}
+
+func (w *wsClientMock) Start(url string) error {
+	return w.Called(url).Error(0)
+}
+
+func (w *wsClientMock) Stop() {}
+
+func (w *wsClientMock) IsConnected() bool {
+	return true
+}
+
+func (w *wsClientMock) Write(data []byte) error {
+	w.written <- data
+	return nil
+}
+
+func (h *remoteControlHandlerMock) OnRequestStartTransaction(request *RequestStartTransactionRequest) (*RequestStartTransactionResponse, error) {
+	args := h.Called(request)
+	return args.Get(0).(*RequestStartTransactionResponse), args.Error(1)
+}
+
+func (h *remoteControlHandlerMock) OnRequestStopTransaction(request *RequestStopTransactionRequest) (*RequestStopTransactionResponse, error) {
+	args := h.Called(request)
+	return args.Get(0).(*RequestStopTransactionResponse), args.Error(1)
+}
+
+func (s *chargingStationTestSuite) SetupTest() {
+	s.client = &wsClientMock{written: make(chan []byte, 10)}
+	s.chargingStation = NewChargingStation("cs1", s.client)
+	// The dispatcher reads the timeout without synchronization, so it is set before the client is started
+	s.chargingStation.SetRequestTimeout(300 * time.Millisecond)
+
+	// The OCPP-J client handles the messages after it is started
+	s.client.On("Start", "ws://localhost:8080/cs1").Return(nil)
+	s.Require().NoError(s.chargingStation.Start("ws://localhost:8080"))
+}
+
+func (s *chargingStationTestSuite) readMessage() []interface{} {
+	select {
+	case data := <-s.client.written:
+		var message []interface{}
+		s.Require().NoError(json.Unmarshal(data, &message))
+		return message
+	case <-time.After(time.Second):
+		s.FailNow("no message was written")
+		return nil
+	}
+}
+
+func (s *chargingStationTestSuite) TestStart() {
+	s.client.AssertExpectations(s.T())
+
+	// The subprotocol is added only once
+	dialer := &websocket.Dialer{Subprotocols: []string{Subprotocol}}
+	for _, option := range s.client.options {
+		option(dialer)
+	}
+	s.Assert().EqualValues([]string{Subprotocol}, dialer.Subprotocols)
+}
+
+func (s *chargingStationTestSuite) TestHandleRequest() {
+	handler := new(remoteControlHandlerMock)
+	handler.On("OnRequestStopTransaction", &RequestStopTransactionRequest{TransactionId: "abcd"}).
+		Return(NewRequestStopTransactionResponse(RequestStartStopStatusAccepted), nil)
+	s.chargingStation.SetRemoteControlHandler(handler)
+
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1234","RequestStopTransaction",{"transactionId":"abcd"}]`)))
+
+	message := s.readMessage()
+	s.Assert().EqualValues(3, message[0])
+	s.Assert().EqualValues("1234", message[1])
+	s.Assert().EqualValues(map[string]interface{}{"status": "Accepted"}, message[2])
+	handler.AssertExpectations(s.T())
+
+	// Invalid payload
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1235","RequestStartTransaction",{"remoteStartId":1,"idToken":{"idToken":"1234","type":"Invalid"}}]`)))
+
+	message = s.readMessage()
+	s.Assert().EqualValues(4, message[0])
+	s.Assert().EqualValues("1235", message[1])
+
+	// Unsupported request
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1236","Reset",{"type":"Immediate"}]`)))
+
+	message = s.readMessage()
+	s.Assert().EqualValues(4, message[0])
+	s.Assert().EqualValues("1236", message[1])
+}
+
+func (s *chargingStationTestSuite) TestHandleRequestWithoutHandler() {
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1234","RequestStopTransaction",{"transactionId":"abcd"}]`)))
+
+	message := s.readMessage()
+	s.Assert().EqualValues(4, message[0])
+	s.Assert().EqualValues("NotSupported", message[2])
+}
+
+func (s *chargingStationTestSuite) TestSendRequest() {
+	go func() {
+		message := s.readMessage()
+		s.Assert().EqualValues(2, message[0])
+		s.Assert().EqualValues(StatusNotificationFeatureName, message[2])
+
+		_ = s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{}]`))
+	}()
+
+	response, err := s.chargingStation.SendRequest(NewStatusNotificationRequest(types.NewDateTime(time.Now()), ConnectorStatusAvailable, 1, 1))
+	s.Require().NoError(err)
+	s.Assert().IsType(&StatusNotificationResponse{}, response)
+
+	// Call error
+	go func() {
+		message := s.readMessage()
+		_ = s.client.messageHandler([]byte(`[4,"` + message[1].(string) + `","InternalError","error",{}]`))
+	}()
+
+	_, err = s.chargingStation.SendRequest(NewHeartbeatRequest())
+	var ocppErr *ocpp.Error
+	s.Require().ErrorAs(err, &ocppErr)
+	s.Assert().EqualValues("InternalError", ocppErr.Code)
+
+	// Invalid request
+	_, err = s.chargingStation.SendRequest(NewStatusNotificationRequest(types.NewDateTime(time.Now()), "Charging", 1, 1))
+	s.Assert().Error(err)
+
+	// No response
+	_, err = s.chargingStation.SendRequest(NewHeartbeatRequest())
+	s.Assert().ErrorIs(err, ErrRequestTimeout)
+	s.readMessage()
+
+	// The response to the request which timed out is dropped
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"unknown",{}]`)))
+}
+
+func (s *chargingStationTestSuite) TestSendRequestsInOrder() {
+	var (
+		responses = make(chan ocpp.Response, 2)
+		callback  = func(response ocpp.Response, err error) {
+			s.Assert().NoError(err)
+			responses <- response
+		}
+	)
+
+	s.Require().NoError(s.chargingStation.SendRequestAsync(NewHeartbeatRequest(), callback))
+	s.Require().NoError(s.chargingStation.SendRequestAsync(NewStatusNotificationRequest(types.NewDateTime(time.Now()), ConnectorStatusAvailable, 1, 1), callback))
+
+	// Only one request is sent until the CSMS responds
+	message := s.readMessage()
+	s.Assert().EqualValues(HeartbeatFeatureName, message[2])
+	select {
+	case <-s.client.written:
+		s.FailNow("the second request was sent before the response to the first one")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{"currentTime":"2023-01-01T00:00:00Z"}]`)))
+	s.Assert().IsType(&HeartbeatResponse{}, <-responses)
+
+	message = s.readMessage()
+	s.Assert().EqualValues(StatusNotificationFeatureName, message[2])
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{}]`)))
+	s.Assert().IsType(&StatusNotificationResponse{}, <-responses)
+}
+
+func (s *chargingStationTestSuite) TestSendRequestAsync() {
+	var (
+		responses = make(chan ocpp.Response, 1)
+		request   = NewTransactionEventRequest(
+			TransactionEventStarted,
+			types.NewDateTime(time.Now()),
+			TriggerReasonAuthorized,
+			Transaction{TransactionId: "abcd", ChargingState: ChargingStateCharging},
+		)
+	)
+
+	s.Require().NoError(s.chargingStation.SendRequestAsync(request, func(response ocpp.Response, err error) {
+		s.Assert().NoError(err)
+		responses <- response
+	}))
+
+	message := s.readMessage()
+	s.Assert().EqualValues(TransactionEventFeatureName, message[2])
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{"totalCost":1.5}]`)))
+
+	select {
+	case response := <-responses:
+		s.Require().IsType(&TransactionEventResponse{}, response)
+		s.Assert().EqualValues(1.5, *response.(*TransactionEventResponse).TotalCost)
+	case <-time.After(time.Second):
+		s.Fail("no response")
+	}
+
+	// Invalid requests are not sent
+	request.TriggerReason = "Invalid"
+	s.Assert().Error(s.chargingStation.SendRequestAsync(request, nil))
+}
+
+func TestChargingStation(t *testing.T) {
+	suite.Run(t, new(chargingStationTestSuite))
+}
@@ -0,0 +1,59 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+)
+
+// -------------------- Data transfer (CS -> CSMS, CSMS -> CS) --------------------
+
+const DataTransferFeatureName = "DataTransfer"
+
+type DataTransferStatus string
+
+const (
+	DataTransferStatusAccepted         DataTransferStatus = "Accepted"
+	DataTransferStatusRejected         DataTransferStatus = "Rejected"
+	DataTransferStatusUnknownMessageId DataTransferStatus = "UnknownMessageId"
+	DataTransferStatusUnknownVendorId  DataTransferStatus = "UnknownVendorId"
+)
+
+type (
+	// DataTransferHandler handles the vendor specific data sent by the CSMS.
+	DataTransferHandler interface {
+		OnDataTransfer(request *DataTransferRequest) (response *DataTransferResponse, err error)
+	}
+
+	// DataTransferRequest carries the data of a function, which is not supported by OCPP. It is sent by either side.
+	DataTransferRequest struct {
+		MessageId string      `json:"messageId,omitempty" validate:"max=50"`
+		Data      interface{} `json:"data,omitempty"`
+		VendorId  string      `json:"vendorId" validate:"required,max=255"`
+	}
+
+	DataTransferResponse struct {
+		Status     DataTransferStatus `json:"status" validate:"required,oneof=Accepted Rejected UnknownMessageId UnknownVendorId"`
+		StatusInfo *StatusInfo        `json:"statusInfo,omitempty" validate:"omitempty"`
+		Data       interface{}        `json:"data,omitempty"`
+	}
+)
+
+func (r DataTransferRequest) GetFeatureName() string {
+	return DataTransferFeatureName
+}
+
+func (c DataTransferResponse) GetFeatureName() string {
+	return DataTransferFeatureName
+}
+
+func NewDataTransferRequest(vendorId, messageId string, data interface{}) *DataTransferRequest {
+	return &DataTransferRequest{VendorId: vendorId, MessageId: messageId, Data: data}
+}
+
+func NewDataTransferResponse(status DataTransferStatus, data interface{}) *DataTransferResponse {
+	return &DataTransferResponse{Status: status, Data: data}
+}
+
+var DataTransferProfile = ocpp.NewProfile(
+	DataTransferProfileName,
+	newFeature(DataTransferFeatureName, DataTransferRequest{}, DataTransferResponse{}),
+)
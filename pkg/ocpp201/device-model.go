@@ -0,0 +1,276 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Device model (CSMS -> CS) --------------------
+
+const (
+	GetVariablesFeatureName  = "GetVariables"
+	SetVariablesFeatureName  = "SetVariables"
+	GetBaseReportFeatureName = "GetBaseReport"
+	GetReportFeatureName     = "GetReport"
+	NotifyReportFeatureName  = "NotifyReport"
+)
+
+type (
+	AttributeType            string
+	Mutability               string
+	DataType                 string
+	GetVariableStatus        string
+	SetVariableStatus        string
+	ReportBase               string
+	GenericDeviceModelStatus string
+	ComponentCriterion       string
+)
+
+const (
+	AttributeTypeActual AttributeType = "Actual"
+	AttributeTypeTarget AttributeType = "Target"
+	AttributeTypeMinSet AttributeType = "MinSet"
+	AttributeTypeMaxSet AttributeType = "MaxSet"
+
+	MutabilityReadOnly  Mutability = "ReadOnly"
+	MutabilityWriteOnly Mutability = "WriteOnly"
+	MutabilityReadWrite Mutability = "ReadWrite"
+
+	DataTypeString       DataType = "string"
+	DataTypeDecimal      DataType = "decimal"
+	DataTypeInteger      DataType = "integer"
+	DataTypeDateTime     DataType = "dateTime"
+	DataTypeBoolean      DataType = "boolean"
+	DataTypeOptionList   DataType = "OptionList"
+	DataTypeSequenceList DataType = "SequenceList"
+	DataTypeMemberList   DataType = "MemberList"
+
+	GetVariableStatusAccepted                  GetVariableStatus = "Accepted"
+	GetVariableStatusRejected                  GetVariableStatus = "Rejected"
+	GetVariableStatusUnknownComponent          GetVariableStatus = "UnknownComponent"
+	GetVariableStatusUnknownVariable           GetVariableStatus = "UnknownVariable"
+	GetVariableStatusNotSupportedAttributeType GetVariableStatus = "NotSupportedAttributeType"
+
+	SetVariableStatusAccepted                  SetVariableStatus = "Accepted"
+	SetVariableStatusRejected                  SetVariableStatus = "Rejected"
+	SetVariableStatusUnknownComponent          SetVariableStatus = "UnknownComponent"
+	SetVariableStatusUnknownVariable           SetVariableStatus = "UnknownVariable"
+	SetVariableStatusNotSupportedAttributeType SetVariableStatus = "NotSupportedAttributeType"
+	SetVariableStatusRebootRequired            SetVariableStatus = "RebootRequired"
+
+	ReportBaseConfigurationInventory ReportBase = "ConfigurationInventory"
+	ReportBaseFullInventory          ReportBase = "FullInventory"
+	ReportBaseSummaryInventory       ReportBase = "SummaryInventory"
+
+	GenericDeviceModelStatusAccepted       GenericDeviceModelStatus = "Accepted"
+	GenericDeviceModelStatusRejected       GenericDeviceModelStatus = "Rejected"
+	GenericDeviceModelStatusNotSupported   GenericDeviceModelStatus = "NotSupported"
+	GenericDeviceModelStatusEmptyResultSet GenericDeviceModelStatus = "EmptyResultSet"
+
+	ComponentCriterionActive    ComponentCriterion = "Active"
+	ComponentCriterionAvailable ComponentCriterion = "Available"
+	ComponentCriterionEnabled   ComponentCriterion = "Enabled"
+	ComponentCriterionProblem   ComponentCriterion = "Problem"
+)
+
+type (
+	// DeviceModelHandler handles the requests of the CSMS to read and configure the device model.
+	DeviceModelHandler interface {
+		OnGetVariables(request *GetVariablesRequest) (response *GetVariablesResponse, err error)
+		OnSetVariables(request *SetVariablesRequest) (response *SetVariablesResponse, err error)
+		OnGetBaseReport(request *GetBaseReportRequest) (response *GetBaseReportResponse, err error)
+		OnGetReport(request *GetReportRequest) (response *GetReportResponse, err error)
+	}
+
+	// Component is a physical or logical part of the charging station. The components with an EVSE belong to the EVSE
+	// or the connector of the EVSE.
+	Component struct {
+		Name     string `json:"name" yaml:"name" validate:"required,max=50"`
+		Instance string `json:"instance,omitempty" yaml:"instance,omitempty" validate:"max=50"`
+		Evse     *EVSE  `json:"evse,omitempty" yaml:"evse,omitempty" validate:"omitempty"`
+	}
+
+	// Variable is a property of the component.
+	Variable struct {
+		Name     string `json:"name" yaml:"name" validate:"required,max=50"`
+		Instance string `json:"instance,omitempty" yaml:"instance,omitempty" validate:"max=50"`
+	}
+
+	VariableAttribute struct {
+		Type       AttributeType `json:"type,omitempty" yaml:"type,omitempty" validate:"omitempty,oneof=Actual Target MinSet MaxSet"`
+		Value      string        `json:"value,omitempty" yaml:"value,omitempty" validate:"max=2500"`
+		Mutability Mutability    `json:"mutability,omitempty" yaml:"mutability,omitempty" validate:"omitempty,oneof=ReadOnly WriteOnly ReadWrite"`
+		Persistent bool          `json:"persistent" yaml:"persistent"`
+		Constant   bool          `json:"constant" yaml:"constant"`
+	}
+
+	// VariableCharacteristics describe the values the variable accepts.
+	VariableCharacteristics struct {
+		Unit               string   `json:"unit,omitempty" yaml:"unit,omitempty" validate:"max=16"`
+		DataType           DataType `json:"dataType" yaml:"dataType" validate:"required,oneof=string decimal integer dateTime boolean OptionList SequenceList MemberList"`
+		MinLimit           *float64 `json:"minLimit,omitempty" yaml:"minLimit,omitempty"`
+		MaxLimit           *float64 `json:"maxLimit,omitempty" yaml:"maxLimit,omitempty"`
+		ValuesList         string   `json:"valuesList,omitempty" yaml:"valuesList,omitempty" validate:"max=1000"`
+		SupportsMonitoring bool     `json:"supportsMonitoring" yaml:"supportsMonitoring"`
+	}
+
+	// ReportData contains the attributes and the characteristics of a variable.
+	ReportData struct {
+		Component               Component                `json:"component" yaml:"component" validate:"required"`
+		Variable                Variable                 `json:"variable" yaml:"variable" validate:"required"`
+		VariableAttribute       []VariableAttribute      `json:"variableAttribute" yaml:"variableAttribute" validate:"required,min=1,max=4,dive"`
+		VariableCharacteristics *VariableCharacteristics `json:"variableCharacteristics,omitempty" yaml:"variableCharacteristics,omitempty" validate:"omitempty"`
+	}
+
+	ComponentVariable struct {
+		Component Component `json:"component" validate:"required"`
+		Variable  *Variable `json:"variable,omitempty" validate:"omitempty"`
+	}
+
+	GetVariableData struct {
+		AttributeType AttributeType `json:"attributeType,omitempty" validate:"omitempty,oneof=Actual Target MinSet MaxSet"`
+		Component     Component     `json:"component" validate:"required"`
+		Variable      Variable      `json:"variable" validate:"required"`
+	}
+
+	GetVariableResult struct {
+		AttributeStatus     GetVariableStatus `json:"attributeStatus" validate:"required,oneof=Accepted Rejected UnknownComponent UnknownVariable NotSupportedAttributeType"`
+		AttributeType       AttributeType     `json:"attributeType,omitempty" validate:"omitempty,oneof=Actual Target MinSet MaxSet"`
+		AttributeValue      string            `json:"attributeValue,omitempty" validate:"max=2500"`
+		Component           Component         `json:"component" validate:"required"`
+		Variable            Variable          `json:"variable" validate:"required"`
+		AttributeStatusInfo *StatusInfo       `json:"attributeStatusInfo,omitempty" validate:"omitempty"`
+	}
+
+	SetVariableData struct {
+		AttributeType  AttributeType `json:"attributeType,omitempty" validate:"omitempty,oneof=Actual Target MinSet MaxSet"`
+		AttributeValue string        `json:"attributeValue" validate:"max=1000"`
+		Component      Component     `json:"component" validate:"required"`
+		Variable       Variable      `json:"variable" validate:"required"`
+	}
+
+	SetVariableResult struct {
+		AttributeType       AttributeType     `json:"attributeType,omitempty" validate:"omitempty,oneof=Actual Target MinSet MaxSet"`
+		AttributeStatus     SetVariableStatus `json:"attributeStatus" validate:"required,oneof=Accepted Rejected UnknownComponent UnknownVariable NotSupportedAttributeType RebootRequired"`
+		Component           Component         `json:"component" validate:"required"`
+		Variable            Variable          `json:"variable" validate:"required"`
+		AttributeStatusInfo *StatusInfo       `json:"attributeStatusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// GetVariablesRequest is sent by the CSMS to read the attribute values of the variables.
+	GetVariablesRequest struct {
+		GetVariableData []GetVariableData `json:"getVariableData" validate:"required,min=1,dive"`
+	}
+
+	GetVariablesResponse struct {
+		GetVariableResult []GetVariableResult `json:"getVariableResult" validate:"required,min=1,dive"`
+	}
+
+	// SetVariablesRequest is sent by the CSMS to set the attribute values of the variables.
+	SetVariablesRequest struct {
+		SetVariableData []SetVariableData `json:"setVariableData" validate:"required,min=1,dive"`
+	}
+
+	SetVariablesResponse struct {
+		SetVariableResult []SetVariableResult `json:"setVariableResult" validate:"required,min=1,dive"`
+	}
+
+	// GetBaseReportRequest is sent by the CSMS to request a predefined report, which is sent with the NotifyReport requests.
+	GetBaseReportRequest struct {
+		RequestId  int        `json:"requestId"`
+		ReportBase ReportBase `json:"reportBase" validate:"required,oneof=ConfigurationInventory FullInventory SummaryInventory"`
+	}
+
+	GetBaseReportResponse struct {
+		Status     GenericDeviceModelStatus `json:"status" validate:"required,oneof=Accepted Rejected NotSupported EmptyResultSet"`
+		StatusInfo *StatusInfo              `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// GetReportRequest is sent by the CSMS to request a report of the components and the variables matching the criteria.
+	GetReportRequest struct {
+		RequestId         int                  `json:"requestId"`
+		ComponentCriteria []ComponentCriterion `json:"componentCriteria,omitempty" validate:"omitempty,max=4,dive,oneof=Active Available Enabled Problem"`
+		ComponentVariable []ComponentVariable  `json:"componentVariable,omitempty" validate:"omitempty,dive"`
+	}
+
+	GetReportResponse struct {
+		Status     GenericDeviceModelStatus `json:"status" validate:"required,oneof=Accepted Rejected NotSupported EmptyResultSet"`
+		StatusInfo *StatusInfo              `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// NotifyReportRequest contains a part of the requested report. The report is complete when ToBeContinued is false.
+	NotifyReportRequest struct {
+		RequestId     int             `json:"requestId"`
+		GeneratedAt   *types.DateTime `json:"generatedAt" validate:"required"`
+		ToBeContinued bool            `json:"tbc,omitempty"`
+		SequenceNo    int             `json:"seqNo" validate:"gte=0"`
+		ReportData    []ReportData    `json:"reportData,omitempty" validate:"omitempty,dive"`
+	}
+
+	NotifyReportResponse struct {
+	}
+)
+
+func (r GetVariablesRequest) GetFeatureName() string {
+	return GetVariablesFeatureName
+}
+
+func (c GetVariablesResponse) GetFeatureName() string {
+	return GetVariablesFeatureName
+}
+
+func (r SetVariablesRequest) GetFeatureName() string {
+	return SetVariablesFeatureName
+}
+
+func (c SetVariablesResponse) GetFeatureName() string {
+	return SetVariablesFeatureName
+}
+
+func (r GetBaseReportRequest) GetFeatureName() string {
+	return GetBaseReportFeatureName
+}
+
+func (c GetBaseReportResponse) GetFeatureName() string {
+	return GetBaseReportFeatureName
+}
+
+func (r GetReportRequest) GetFeatureName() string {
+	return GetReportFeatureName
+}
+
+func (c GetReportResponse) GetFeatureName() string {
+	return GetReportFeatureName
+}
+
+func (r NotifyReportRequest) GetFeatureName() string {
+	return NotifyReportFeatureName
+}
+
+func (c NotifyReportResponse) GetFeatureName() string {
+	return NotifyReportFeatureName
+}
+
+func NewGetVariablesResponse(results []GetVariableResult) *GetVariablesResponse {
+	return &GetVariablesResponse{GetVariableResult: results}
+}
+
+func NewSetVariablesResponse(results []SetVariableResult) *SetVariablesResponse {
+	return &SetVariablesResponse{SetVariableResult: results}
+}
+
+func NewGetBaseReportResponse(status GenericDeviceModelStatus) *GetBaseReportResponse {
+	return &GetBaseReportResponse{Status: status}
+}
+
+func NewGetReportResponse(status GenericDeviceModelStatus) *GetReportResponse {
+	return &GetReportResponse{Status: status}
+}
+
+func NewNotifyReportRequest(requestId int, generatedAt *types.DateTime, seqNo int, reportData []ReportData) *NotifyReportRequest {
+	return &NotifyReportRequest{
+		RequestId:   requestId,
+		GeneratedAt: generatedAt,
+		SequenceNo:  seqNo,
+		ReportData:  reportData,
+	}
+}
@@ -0,0 +1,285 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Diagnostics (CSMS -> CS) --------------------
+
+const (
+	SetVariableMonitoringFeatureName     = "SetVariableMonitoring"
+	ClearVariableMonitoringFeatureName   = "ClearVariableMonitoring"
+	SetMonitoringBaseFeatureName         = "SetMonitoringBase"
+	NotifyEventFeatureName               = "NotifyEvent"
+	CustomerInformationFeatureName       = "CustomerInformation"
+	NotifyCustomerInformationFeatureName = "NotifyCustomerInformation"
+)
+
+type (
+	MonitorType               string
+	MonitoringBase            string
+	SetMonitoringStatus       string
+	ClearMonitoringStatus     string
+	EventTrigger              string
+	EventNotificationType     string
+	CustomerInformationStatus string
+)
+
+const (
+	MonitorTypeUpperThreshold       MonitorType = "UpperThreshold"
+	MonitorTypeLowerThreshold       MonitorType = "LowerThreshold"
+	MonitorTypeDelta                MonitorType = "Delta"
+	MonitorTypePeriodic             MonitorType = "Periodic"
+	MonitorTypePeriodicClockAligned MonitorType = "PeriodicClockAligned"
+
+	MonitoringBaseAll            MonitoringBase = "All"
+	MonitoringBaseFactoryDefault MonitoringBase = "FactoryDefault"
+	MonitoringBaseHardWiredOnly  MonitoringBase = "HardWiredOnly"
+
+	SetMonitoringStatusAccepted               SetMonitoringStatus = "Accepted"
+	SetMonitoringStatusUnknownComponent       SetMonitoringStatus = "UnknownComponent"
+	SetMonitoringStatusUnknownVariable        SetMonitoringStatus = "UnknownVariable"
+	SetMonitoringStatusUnsupportedMonitorType SetMonitoringStatus = "UnsupportedMonitorType"
+	SetMonitoringStatusRejected               SetMonitoringStatus = "Rejected"
+	SetMonitoringStatusDuplicate              SetMonitoringStatus = "Duplicate"
+
+	ClearMonitoringStatusAccepted ClearMonitoringStatus = "Accepted"
+	ClearMonitoringStatusRejected ClearMonitoringStatus = "Rejected"
+	ClearMonitoringStatusNotFound ClearMonitoringStatus = "NotFound"
+
+	EventTriggerAlerting EventTrigger = "Alerting"
+	EventTriggerDelta    EventTrigger = "Delta"
+	EventTriggerPeriodic EventTrigger = "Periodic"
+
+	EventNotificationTypeHardWiredNotification EventNotificationType = "HardWiredNotification"
+	EventNotificationTypeHardWiredMonitor      EventNotificationType = "HardWiredMonitor"
+	EventNotificationTypePreconfiguredMonitor  EventNotificationType = "PreconfiguredMonitor"
+	EventNotificationTypeCustomMonitor         EventNotificationType = "CustomMonitor"
+
+	CustomerInformationStatusAccepted CustomerInformationStatus = "Accepted"
+	CustomerInformationStatusRejected CustomerInformationStatus = "Rejected"
+	CustomerInformationStatusInvalid  CustomerInformationStatus = "Invalid"
+)
+
+type (
+	// DiagnosticsHandler handles the requests of the CSMS configuring the monitoring of the variables and the requests
+	// for the customer information.
+	DiagnosticsHandler interface {
+		OnSetVariableMonitoring(request *SetVariableMonitoringRequest) (response *SetVariableMonitoringResponse, err error)
+		OnClearVariableMonitoring(request *ClearVariableMonitoringRequest) (response *ClearVariableMonitoringResponse, err error)
+		OnSetMonitoringBase(request *SetMonitoringBaseRequest) (response *SetMonitoringBaseResponse, err error)
+		OnCustomerInformation(request *CustomerInformationRequest) (response *CustomerInformationResponse, err error)
+	}
+
+	// CertificateHashData identifies a certificate by the hashes of its issuer and its serial number.
+	CertificateHashData struct {
+		HashAlgorithm  string `json:"hashAlgorithm" validate:"required,oneof=SHA256 SHA384 SHA512"`
+		IssuerNameHash string `json:"issuerNameHash" validate:"required,max=128"`
+		IssuerKeyHash  string `json:"issuerKeyHash" validate:"required,max=128"`
+		SerialNumber   string `json:"serialNumber" validate:"required,max=40"`
+	}
+
+	// SetMonitoringData describes a monitor of the variable. The Value is the threshold, the delta or the interval in
+	// seconds, depending on the type of the monitor. The monitor with the id replaces the existing monitor.
+	SetMonitoringData struct {
+		Id          *int        `json:"id,omitempty" validate:"omitempty"`
+		Transaction bool        `json:"transaction,omitempty"`
+		Value       float64     `json:"value"`
+		Type        MonitorType `json:"type" validate:"required,oneof=UpperThreshold LowerThreshold Delta Periodic PeriodicClockAligned"`
+		Severity    int         `json:"severity" validate:"min=0,max=9"`
+		Component   Component   `json:"component" validate:"required"`
+		Variable    Variable    `json:"variable" validate:"required"`
+	}
+
+	SetMonitoringResult struct {
+		Id         *int                `json:"id,omitempty" validate:"omitempty"`
+		Status     SetMonitoringStatus `json:"status" validate:"required,oneof=Accepted UnknownComponent UnknownVariable UnsupportedMonitorType Rejected Duplicate"`
+		Type       MonitorType         `json:"type" validate:"required,oneof=UpperThreshold LowerThreshold Delta Periodic PeriodicClockAligned"`
+		Severity   int                 `json:"severity" validate:"min=0,max=9"`
+		Component  Component           `json:"component" validate:"required"`
+		Variable   Variable            `json:"variable" validate:"required"`
+		StatusInfo *StatusInfo         `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	ClearMonitoringResult struct {
+		Status     ClearMonitoringStatus `json:"status" validate:"required,oneof=Accepted Rejected NotFound"`
+		Id         int                   `json:"id" validate:"gte=0"`
+		StatusInfo *StatusInfo           `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// EventData is an event of a monitored variable, e.g. a threshold was exceeded or the value changed.
+	EventData struct {
+		EventId               int                   `json:"eventId" validate:"gte=0"`
+		Timestamp             *types.DateTime       `json:"timestamp" validate:"required"`
+		Trigger               EventTrigger          `json:"trigger" validate:"required,oneof=Alerting Delta Periodic"`
+		Cause                 *int                  `json:"cause,omitempty" validate:"omitempty"`
+		ActualValue           string                `json:"actualValue" validate:"required,max=2500"`
+		TechCode              string                `json:"techCode,omitempty" validate:"max=50"`
+		TechInfo              string                `json:"techInfo,omitempty" validate:"max=500"`
+		Cleared               bool                  `json:"cleared,omitempty"`
+		TransactionId         string                `json:"transactionId,omitempty" validate:"max=36"`
+		Component             Component             `json:"component" validate:"required"`
+		VariableMonitoringId  *int                  `json:"variableMonitoringId,omitempty" validate:"omitempty"`
+		EventNotificationType EventNotificationType `json:"eventNotificationType" validate:"required,oneof=HardWiredNotification HardWiredMonitor PreconfiguredMonitor CustomMonitor"`
+		Variable              Variable              `json:"variable" validate:"required"`
+	}
+
+	// SetVariableMonitoringRequest is sent by the CSMS to add or replace the monitors of the variables.
+	SetVariableMonitoringRequest struct {
+		SetMonitoringData []SetMonitoringData `json:"setMonitoringData" validate:"required,min=1,dive"`
+	}
+
+	SetVariableMonitoringResponse struct {
+		SetMonitoringResult []SetMonitoringResult `json:"setMonitoringResult" validate:"required,min=1,dive"`
+	}
+
+	// ClearVariableMonitoringRequest is sent by the CSMS to remove the monitors with the ids.
+	ClearVariableMonitoringRequest struct {
+		Id []int `json:"id" validate:"required,min=1,dive,gte=0"`
+	}
+
+	ClearVariableMonitoringResponse struct {
+		ClearMonitoringResult []ClearMonitoringResult `json:"clearMonitoringResult" validate:"required,min=1,dive"`
+	}
+
+	// SetMonitoringBaseRequest is sent by the CSMS to choose which monitors are active.
+	SetMonitoringBaseRequest struct {
+		MonitoringBase MonitoringBase `json:"monitoringBase" validate:"required,oneof=All FactoryDefault HardWiredOnly"`
+	}
+
+	SetMonitoringBaseResponse struct {
+		Status     GenericDeviceModelStatus `json:"status" validate:"required,oneof=Accepted Rejected NotSupported EmptyResultSet"`
+		StatusInfo *StatusInfo              `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// NotifyEventRequest contains a part of the events. All events are sent when ToBeContinued is false.
+	NotifyEventRequest struct {
+		GeneratedAt   *types.DateTime `json:"generatedAt" validate:"required"`
+		ToBeContinued bool            `json:"tbc,omitempty"`
+		SequenceNo    int             `json:"seqNo" validate:"gte=0"`
+		EventData     []EventData     `json:"eventData" validate:"required,min=1,dive"`
+	}
+
+	NotifyEventResponse struct {
+	}
+
+	// CustomerInformationRequest is sent by the CSMS to get the report of the data stored about the customer, to clear
+	// the data, or both. The customer is identified by one of the IdToken, the CustomerIdentifier or the CustomerCertificate.
+	CustomerInformationRequest struct {
+		RequestId           int                  `json:"requestId" validate:"gte=0"`
+		Report              bool                 `json:"report"`
+		Clear               bool                 `json:"clear"`
+		CustomerIdentifier  string               `json:"customerIdentifier,omitempty" validate:"max=64"`
+		IdToken             *IdToken             `json:"idToken,omitempty" validate:"omitempty"`
+		CustomerCertificate *CertificateHashData `json:"customerCertificate,omitempty" validate:"omitempty"`
+	}
+
+	CustomerInformationResponse struct {
+		Status     CustomerInformationStatus `json:"status" validate:"required,oneof=Accepted Rejected Invalid"`
+		StatusInfo *StatusInfo               `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// NotifyCustomerInformationRequest contains a part of the customer information. All data is sent when ToBeContinued is false.
+	NotifyCustomerInformationRequest struct {
+		Data          string          `json:"data" validate:"required,max=512"`
+		ToBeContinued bool            `json:"tbc,omitempty"`
+		SequenceNo    int             `json:"seqNo" validate:"gte=0"`
+		GeneratedAt   *types.DateTime `json:"generatedAt" validate:"required"`
+		RequestId     int             `json:"requestId" validate:"gte=0"`
+	}
+
+	NotifyCustomerInformationResponse struct {
+	}
+)
+
+func (r SetVariableMonitoringRequest) GetFeatureName() string {
+	return SetVariableMonitoringFeatureName
+}
+
+func (c SetVariableMonitoringResponse) GetFeatureName() string {
+	return SetVariableMonitoringFeatureName
+}
+
+func (r ClearVariableMonitoringRequest) GetFeatureName() string {
+	return ClearVariableMonitoringFeatureName
+}
+
+func (c ClearVariableMonitoringResponse) GetFeatureName() string {
+	return ClearVariableMonitoringFeatureName
+}
+
+func (r SetMonitoringBaseRequest) GetFeatureName() string {
+	return SetMonitoringBaseFeatureName
+}
+
+func (c SetMonitoringBaseResponse) GetFeatureName() string {
+	return SetMonitoringBaseFeatureName
+}
+
+func (r NotifyEventRequest) GetFeatureName() string {
+	return NotifyEventFeatureName
+}
+
+func (c NotifyEventResponse) GetFeatureName() string {
+	return NotifyEventFeatureName
+}
+
+func (r CustomerInformationRequest) GetFeatureName() string {
+	return CustomerInformationFeatureName
+}
+
+func (c CustomerInformationResponse) GetFeatureName() string {
+	return CustomerInformationFeatureName
+}
+
+func (r NotifyCustomerInformationRequest) GetFeatureName() string {
+	return NotifyCustomerInformationFeatureName
+}
+
+func (c NotifyCustomerInformationResponse) GetFeatureName() string {
+	return NotifyCustomerInformationFeatureName
+}
+
+func NewSetVariableMonitoringResponse(results []SetMonitoringResult) *SetVariableMonitoringResponse {
+	return &SetVariableMonitoringResponse{SetMonitoringResult: results}
+}
+
+func NewClearVariableMonitoringResponse(results []ClearMonitoringResult) *ClearVariableMonitoringResponse {
+	return &ClearVariableMonitoringResponse{ClearMonitoringResult: results}
+}
+
+func NewSetMonitoringBaseResponse(status GenericDeviceModelStatus) *SetMonitoringBaseResponse {
+	return &SetMonitoringBaseResponse{Status: status}
+}
+
+func NewNotifyEventRequest(generatedAt *types.DateTime, seqNo int, eventData []EventData) *NotifyEventRequest {
+	return &NotifyEventRequest{
+		GeneratedAt: generatedAt,
+		SequenceNo:  seqNo,
+		EventData:   eventData,
+	}
+}
+
+func NewCustomerInformationResponse(status CustomerInformationStatus) *CustomerInformationResponse {
+	return &CustomerInformationResponse{Status: status}
+}
+
+func NewNotifyCustomerInformationRequest(requestId int, generatedAt *types.DateTime, seqNo int, data string) *NotifyCustomerInformationRequest {
+	return &NotifyCustomerInformationRequest{
+		Data:        data,
+		SequenceNo:  seqNo,
+		GeneratedAt: generatedAt,
+		RequestId:   requestId,
+	}
+}
+
+var DiagnosticsProfile = ocpp.NewProfile(
+	DiagnosticsProfileName,
+	newFeature(SetVariableMonitoringFeatureName, SetVariableMonitoringRequest{}, SetVariableMonitoringResponse{}),
+	newFeature(ClearVariableMonitoringFeatureName, ClearVariableMonitoringRequest{}, ClearVariableMonitoringResponse{}),
+	newFeature(SetMonitoringBaseFeatureName, SetMonitoringBaseRequest{}, SetMonitoringBaseResponse{}),
+	newFeature(NotifyEventFeatureName, NotifyEventRequest{}, NotifyEventResponse{}),
+	newFeature(CustomerInformationFeatureName, CustomerInformationRequest{}, CustomerInformationResponse{}),
+	newFeature(NotifyCustomerInformationFeatureName, NotifyCustomerInformationRequest{}, NotifyCustomerInformationResponse{}),
+)
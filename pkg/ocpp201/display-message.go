@@ -0,0 +1,170 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Display message (CSMS -> CS) --------------------
+
+const (
+	SetDisplayMessageFeatureName     = "SetDisplayMessage"
+	GetDisplayMessagesFeatureName    = "GetDisplayMessages"
+	ClearDisplayMessageFeatureName   = "ClearDisplayMessage"
+	NotifyDisplayMessagesFeatureName = "NotifyDisplayMessages"
+)
+
+type (
+	MessagePriority          string
+	MessageState             string
+	DisplayMessageStatus     string
+	GetDisplayMessagesStatus string
+	ClearMessageStatus       string
+)
+
+const (
+	MessagePriorityAlwaysFront MessagePriority = "AlwaysFront"
+	MessagePriorityInFront     MessagePriority = "InFront"
+	MessagePriorityNormalCycle MessagePriority = "NormalCycle"
+
+	MessageStateCharging    MessageState = "Charging"
+	MessageStateFaulted     MessageState = "Faulted"
+	MessageStateIdle        MessageState = "Idle"
+	MessageStateUnavailable MessageState = "Unavailable"
+
+	DisplayMessageStatusAccepted                  DisplayMessageStatus = "Accepted"
+	DisplayMessageStatusNotSupportedMessageFormat DisplayMessageStatus = "NotSupportedMessageFormat"
+	DisplayMessageStatusRejected                  DisplayMessageStatus = "Rejected"
+	DisplayMessageStatusNotSupportedPriority      DisplayMessageStatus = "NotSupportedPriority"
+	DisplayMessageStatusNotSupportedState         DisplayMessageStatus = "NotSupportedState"
+	DisplayMessageStatusUnknownTransaction        DisplayMessageStatus = "UnknownTransaction"
+
+	GetDisplayMessagesStatusAccepted GetDisplayMessagesStatus = "Accepted"
+	GetDisplayMessagesStatusUnknown  GetDisplayMessagesStatus = "Unknown"
+
+	ClearMessageStatusAccepted ClearMessageStatus = "Accepted"
+	ClearMessageStatusUnknown  ClearMessageStatus = "Unknown"
+)
+
+type (
+	// DisplayMessageHandler handles the requests of the CSMS managing the messages on the display of the charging station.
+	DisplayMessageHandler interface {
+		OnSetDisplayMessage(request *SetDisplayMessageRequest) (response *SetDisplayMessageResponse, err error)
+		OnGetDisplayMessages(request *GetDisplayMessagesRequest) (response *GetDisplayMessagesResponse, err error)
+		OnClearDisplayMessage(request *ClearDisplayMessageRequest) (response *ClearDisplayMessageResponse, err error)
+	}
+
+	// MessageInfo is a message displayed by the charging station. The message is displayed only in the state and
+	// between the start and the end date, if they are set. The message tied to a transaction is displayed only during
+	// the transaction.
+	MessageInfo struct {
+		Display       *Component      `json:"display,omitempty" validate:"omitempty"`
+		Id            int             `json:"id" validate:"gte=0"`
+		Priority      MessagePriority `json:"priority" validate:"required,oneof=AlwaysFront InFront NormalCycle"`
+		State         MessageState    `json:"state,omitempty" validate:"omitempty,oneof=Charging Faulted Idle Unavailable"`
+		StartDateTime *types.DateTime `json:"startDateTime,omitempty" validate:"omitempty"`
+		EndDateTime   *types.DateTime `json:"endDateTime,omitempty" validate:"omitempty"`
+		TransactionId string          `json:"transactionId,omitempty" validate:"max=36"`
+		Message       MessageContent  `json:"message" validate:"required"`
+	}
+
+	// SetDisplayMessageRequest is sent by the CSMS to add a message or to replace the message with the same id.
+	SetDisplayMessageRequest struct {
+		Message MessageInfo `json:"message" validate:"required"`
+	}
+
+	SetDisplayMessageResponse struct {
+		Status     DisplayMessageStatus `json:"status" validate:"required,oneof=Accepted NotSupportedMessageFormat Rejected NotSupportedPriority NotSupportedState UnknownTransaction"`
+		StatusInfo *StatusInfo          `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// GetDisplayMessagesRequest is sent by the CSMS to request the messages matching all the criteria, which are sent
+	// with the NotifyDisplayMessages requests.
+	GetDisplayMessagesRequest struct {
+		Id        []int           `json:"id,omitempty" validate:"omitempty,dive,gte=0"`
+		RequestId int             `json:"requestId"`
+		Priority  MessagePriority `json:"priority,omitempty" validate:"omitempty,oneof=AlwaysFront InFront NormalCycle"`
+		State     MessageState    `json:"state,omitempty" validate:"omitempty,oneof=Charging Faulted Idle Unavailable"`
+	}
+
+	GetDisplayMessagesResponse struct {
+		Status     GetDisplayMessagesStatus `json:"status" validate:"required,oneof=Accepted Unknown"`
+		StatusInfo *StatusInfo              `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// ClearDisplayMessageRequest is sent by the CSMS to remove the message.
+	ClearDisplayMessageRequest struct {
+		Id int `json:"id" validate:"gte=0"`
+	}
+
+	ClearDisplayMessageResponse struct {
+		Status     ClearMessageStatus `json:"status" validate:"required,oneof=Accepted Unknown"`
+		StatusInfo *StatusInfo        `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// NotifyDisplayMessagesRequest contains a part of the requested messages. All messages are sent when ToBeContinued is false.
+	NotifyDisplayMessagesRequest struct {
+		RequestId     int           `json:"requestId"`
+		ToBeContinued bool          `json:"tbc,omitempty"`
+		MessageInfo   []MessageInfo `json:"messageInfo,omitempty" validate:"omitempty,dive"`
+	}
+
+	NotifyDisplayMessagesResponse struct {
+	}
+)
+
+func (r SetDisplayMessageRequest) GetFeatureName() string {
+	return SetDisplayMessageFeatureName
+}
+
+func (c SetDisplayMessageResponse) GetFeatureName() string {
+	return SetDisplayMessageFeatureName
+}
+
+func (r GetDisplayMessagesRequest) GetFeatureName() string {
+	return GetDisplayMessagesFeatureName
+}
+
+func (c GetDisplayMessagesResponse) GetFeatureName() string {
+	return GetDisplayMessagesFeatureName
+}
+
+func (r ClearDisplayMessageRequest) GetFeatureName() string {
+	return ClearDisplayMessageFeatureName
+}
+
+func (c ClearDisplayMessageResponse) GetFeatureName() string {
+	return ClearDisplayMessageFeatureName
+}
+
+func (r NotifyDisplayMessagesRequest) GetFeatureName() string {
+	return NotifyDisplayMessagesFeatureName
+}
+
+func (c NotifyDisplayMessagesResponse) GetFeatureName() string {
+	return NotifyDisplayMessagesFeatureName
+}
+
+func NewSetDisplayMessageResponse(status DisplayMessageStatus) *SetDisplayMessageResponse {
+	return &SetDisplayMessageResponse{Status: status}
+}
+
+func NewGetDisplayMessagesResponse(status GetDisplayMessagesStatus) *GetDisplayMessagesResponse {
+	return &GetDisplayMessagesResponse{Status: status}
+}
+
+func NewClearDisplayMessageResponse(status ClearMessageStatus) *ClearDisplayMessageResponse {
+	return &ClearDisplayMessageResponse{Status: status}
+}
+
+func NewNotifyDisplayMessagesRequest(requestId int, messages []MessageInfo) *NotifyDisplayMessagesRequest {
+	return &NotifyDisplayMessagesRequest{RequestId: requestId, MessageInfo: messages}
+}
+
+var DisplayMessageProfile = ocpp.NewProfile(
+	DisplayMessageProfileName,
+	newFeature(SetDisplayMessageFeatureName, SetDisplayMessageRequest{}, SetDisplayMessageResponse{}),
+	newFeature(GetDisplayMessagesFeatureName, GetDisplayMessagesRequest{}, GetDisplayMessagesResponse{}),
+	newFeature(ClearDisplayMessageFeatureName, ClearDisplayMessageRequest{}, ClearDisplayMessageResponse{}),
+	newFeature(NotifyDisplayMessagesFeatureName, NotifyDisplayMessagesRequest{}, NotifyDisplayMessagesResponse{}),
+)
@@ -0,0 +1,89 @@
+package ocpp201
+
+import "github.com/lorenzodonini/ocpp-go/ocpp"
+
+// -------------------- Local authorization list management (CSMS -> CS) --------------------
+
+const (
+	SendLocalListFeatureName       = "SendLocalList"
+	GetLocalListVersionFeatureName = "GetLocalListVersion"
+)
+
+type (
+	UpdateType          string
+	SendLocalListStatus string
+)
+
+const (
+	UpdateTypeDifferential UpdateType = "Differential"
+	UpdateTypeFull         UpdateType = "Full"
+
+	SendLocalListStatusAccepted        SendLocalListStatus = "Accepted"
+	SendLocalListStatusFailed          SendLocalListStatus = "Failed"
+	SendLocalListStatusVersionMismatch SendLocalListStatus = "VersionMismatch"
+)
+
+type (
+	// LocalAuthListHandler handles the requests of the CSMS managing the local authorization list.
+	LocalAuthListHandler interface {
+		OnSendLocalList(request *SendLocalListRequest) (response *SendLocalListResponse, err error)
+		OnGetLocalListVersion(request *GetLocalListVersionRequest) (response *GetLocalListVersionResponse, err error)
+	}
+
+	// AuthorizationData is an entry of the local authorization list. The entry without the IdTokenInfo removes the IdToken
+	// from the list with a differential update.
+	AuthorizationData struct {
+		IdToken     IdToken      `json:"idToken" validate:"required"`
+		IdTokenInfo *IdTokenInfo `json:"idTokenInfo,omitempty" validate:"omitempty"`
+	}
+
+	// SendLocalListRequest is sent by the CSMS to replace or update the local authorization list.
+	SendLocalListRequest struct {
+		VersionNumber          int                 `json:"versionNumber" validate:"gte=0"`
+		UpdateType             UpdateType          `json:"updateType" validate:"required,oneof=Differential Full"`
+		LocalAuthorizationList []AuthorizationData `json:"localAuthorizationList,omitempty" validate:"omitempty,dive"`
+	}
+
+	SendLocalListResponse struct {
+		Status     SendLocalListStatus `json:"status" validate:"required,oneof=Accepted Failed VersionMismatch"`
+		StatusInfo *StatusInfo         `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// GetLocalListVersionRequest is sent by the CSMS to get the version of the local authorization list.
+	GetLocalListVersionRequest struct {
+	}
+
+	GetLocalListVersionResponse struct {
+		VersionNumber int `json:"versionNumber" validate:"gte=0"`
+	}
+)
+
+func (r SendLocalListRequest) GetFeatureName() string {
+	return SendLocalListFeatureName
+}
+
+func (c SendLocalListResponse) GetFeatureName() string {
+	return SendLocalListFeatureName
+}
+
+func (r GetLocalListVersionRequest) GetFeatureName() string {
+	return GetLocalListVersionFeatureName
+}
+
+func (c GetLocalListVersionResponse) GetFeatureName() string {
+	return GetLocalListVersionFeatureName
+}
+
+func NewSendLocalListResponse(status SendLocalListStatus) *SendLocalListResponse {
+	return &SendLocalListResponse{Status: status}
+}
+
+func NewGetLocalListVersionResponse(versionNumber int) *GetLocalListVersionResponse {
+	return &GetLocalListVersionResponse{VersionNumber: versionNumber}
+}
+
+var LocalAuthListProfile = ocpp.NewProfile(
+	LocalAuthListProfileName,
+	newFeature(SendLocalListFeatureName, SendLocalListRequest{}, SendLocalListResponse{}),
+	newFeature(GetLocalListVersionFeatureName, GetLocalListVersionRequest{}, GetLocalListVersionResponse{}),
+)
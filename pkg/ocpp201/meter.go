@@ -0,0 +1,36 @@
+package ocpp201
+
+import "github.com/lorenzodonini/ocpp-go/ocpp"
+
+// -------------------- Meter values (CS -> CSMS) --------------------
+
+const MeterValuesFeatureName = "MeterValues"
+
+type (
+	// MeterValuesRequest is sent by the charging station to report the meter values outside of a transaction.
+	// The meter values of a transaction are sent with the TransactionEventRequest.
+	MeterValuesRequest struct {
+		EvseId     int          `json:"evseId" validate:"gte=0"`
+		MeterValue []MeterValue `json:"meterValue" validate:"required,min=1,dive"`
+	}
+
+	MeterValuesResponse struct {
+	}
+)
+
+func (r MeterValuesRequest) GetFeatureName() string {
+	return MeterValuesFeatureName
+}
+
+func (c MeterValuesResponse) GetFeatureName() string {
+	return MeterValuesFeatureName
+}
+
+func NewMeterValuesRequest(evseId int, meterValues []MeterValue) *MeterValuesRequest {
+	return &MeterValuesRequest{EvseId: evseId, MeterValue: meterValues}
+}
+
+var MeterValuesProfile = ocpp.NewProfile(
+	MeterValuesProfileName,
+	newFeature(MeterValuesFeatureName, MeterValuesRequest{}, MeterValuesResponse{}),
+)
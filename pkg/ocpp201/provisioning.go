@@ -0,0 +1,108 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Provisioning (CS -> CSMS) --------------------
+
+const (
+	BootNotificationFeatureName = "BootNotification"
+	HeartbeatFeatureName        = "Heartbeat"
+)
+
+type (
+	BootReason         string
+	RegistrationStatus string
+)
+
+const (
+	BootReasonApplicationReset BootReason = "ApplicationReset"
+	BootReasonFirmwareUpdate   BootReason = "FirmwareUpdate"
+	BootReasonLocalReset       BootReason = "LocalReset"
+	BootReasonPowerUp          BootReason = "PowerUp"
+	BootReasonRemoteReset      BootReason = "RemoteReset"
+	BootReasonScheduledReset   BootReason = "ScheduledReset"
+	BootReasonTriggered        BootReason = "Triggered"
+	BootReasonUnknown          BootReason = "Unknown"
+	BootReasonWatchdog         BootReason = "Watchdog"
+
+	RegistrationStatusAccepted RegistrationStatus = "Accepted"
+	RegistrationStatusPending  RegistrationStatus = "Pending"
+	RegistrationStatusRejected RegistrationStatus = "Rejected"
+)
+
+type (
+	Modem struct {
+		Iccid string `json:"iccid,omitempty" validate:"max=20"`
+		Imsi  string `json:"imsi,omitempty" validate:"max=20"`
+	}
+
+	ChargingStationType struct {
+		SerialNumber    string `json:"serialNumber,omitempty" validate:"max=25"`
+		Model           string `json:"model" validate:"required,max=20"`
+		VendorName      string `json:"vendorName" validate:"required,max=50"`
+		FirmwareVersion string `json:"firmwareVersion,omitempty" validate:"max=50"`
+		Modem           *Modem `json:"modem,omitempty" validate:"omitempty"`
+	}
+
+	// BootNotificationRequest is sent by the charging station after the (re)connect, with the information about the station.
+	BootNotificationRequest struct {
+		Reason          BootReason          `json:"reason" validate:"required,oneof=ApplicationReset FirmwareUpdate LocalReset PowerUp RemoteReset ScheduledReset Triggered Unknown Watchdog"`
+		ChargingStation ChargingStationType `json:"chargingStation" validate:"required"`
+	}
+
+	BootNotificationResponse struct {
+		CurrentTime *types.DateTime    `json:"currentTime" validate:"required"`
+		Interval    int                `json:"interval" validate:"gte=0"`
+		Status      RegistrationStatus `json:"status" validate:"required,oneof=Accepted Pending Rejected"`
+		StatusInfo  *StatusInfo        `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// HeartbeatRequest lets the CSMS know the charging station is still connected.
+	HeartbeatRequest struct {
+	}
+
+	HeartbeatResponse struct {
+		CurrentTime *types.DateTime `json:"currentTime" validate:"required"`
+	}
+)
+
+func (r BootNotificationRequest) GetFeatureName() string {
+	return BootNotificationFeatureName
+}
+
+func (c BootNotificationResponse) GetFeatureName() string {
+	return BootNotificationFeatureName
+}
+
+func (r HeartbeatRequest) GetFeatureName() string {
+	return HeartbeatFeatureName
+}
+
+func (c HeartbeatResponse) GetFeatureName() string {
+	return HeartbeatFeatureName
+}
+
+func NewBootNotificationRequest(reason BootReason, model, vendorName string) *BootNotificationRequest {
+	return &BootNotificationRequest{
+		Reason:          reason,
+		ChargingStation: ChargingStationType{Model: model, VendorName: vendorName},
+	}
+}
+
+func NewHeartbeatRequest() *HeartbeatRequest {
+	return &HeartbeatRequest{}
+}
+
+var ProvisioningProfile = ocpp.NewProfile(
+	ProvisioningProfileName,
+	newFeature(BootNotificationFeatureName, BootNotificationRequest{}, BootNotificationResponse{}),
+	newFeature(HeartbeatFeatureName, HeartbeatRequest{}, HeartbeatResponse{}),
+	newFeature(GetVariablesFeatureName, GetVariablesRequest{}, GetVariablesResponse{}),
+	newFeature(SetVariablesFeatureName, SetVariablesRequest{}, SetVariablesResponse{}),
+	newFeature(GetBaseReportFeatureName, GetBaseReportRequest{}, GetBaseReportResponse{}),
+	newFeature(GetReportFeatureName, GetReportRequest{}, GetReportResponse{}),
+	newFeature(NotifyReportFeatureName, NotifyReportRequest{}, NotifyReportResponse{}),
+)
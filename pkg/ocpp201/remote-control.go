@@ -0,0 +1,80 @@
+package ocpp201
+
+import "github.com/lorenzodonini/ocpp-go/ocpp"
+
+// -------------------- Remote control (CSMS -> CS) --------------------
+
+const (
+	RequestStartTransactionFeatureName = "RequestStartTransaction"
+	RequestStopTransactionFeatureName  = "RequestStopTransaction"
+)
+
+type RequestStartStopStatus string
+
+const (
+	RequestStartStopStatusAccepted RequestStartStopStatus = "Accepted"
+	RequestStartStopStatusRejected RequestStartStopStatus = "Rejected"
+)
+
+type (
+	// RemoteControlHandler handles the remote start and stop requests of the CSMS.
+	RemoteControlHandler interface {
+		OnRequestStartTransaction(request *RequestStartTransactionRequest) (response *RequestStartTransactionResponse, err error)
+		OnRequestStopTransaction(request *RequestStopTransactionRequest) (response *RequestStopTransactionResponse, err error)
+	}
+
+	// RequestStartTransactionRequest is sent by the CSMS to start a transaction for the IdToken.
+	// The charging profile of the request is not supported.
+	RequestStartTransactionRequest struct {
+		EvseId        *int     `json:"evseId,omitempty" validate:"omitempty,gt=0"`
+		RemoteStartId int      `json:"remoteStartId" validate:"gte=0"`
+		IdToken       IdToken  `json:"idToken" validate:"required"`
+		GroupIdToken  *IdToken `json:"groupIdToken,omitempty" validate:"omitempty"`
+	}
+
+	RequestStartTransactionResponse struct {
+		Status        RequestStartStopStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+		TransactionId string                 `json:"transactionId,omitempty" validate:"max=36"`
+		StatusInfo    *StatusInfo            `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+
+	// RequestStopTransactionRequest is sent by the CSMS to stop the transaction.
+	RequestStopTransactionRequest struct {
+		TransactionId string `json:"transactionId" validate:"required,max=36"`
+	}
+
+	RequestStopTransactionResponse struct {
+		Status     RequestStartStopStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+		StatusInfo *StatusInfo            `json:"statusInfo,omitempty" validate:"omitempty"`
+	}
+)
+
+func (r RequestStartTransactionRequest) GetFeatureName() string {
+	return RequestStartTransactionFeatureName
+}
+
+func (c RequestStartTransactionResponse) GetFeatureName() string {
+	return RequestStartTransactionFeatureName
+}
+
+func (r RequestStopTransactionRequest) GetFeatureName() string {
+	return RequestStopTransactionFeatureName
+}
+
+func (c RequestStopTransactionResponse) GetFeatureName() string {
+	return RequestStopTransactionFeatureName
+}
+
+func NewRequestStartTransactionResponse(status RequestStartStopStatus) *RequestStartTransactionResponse {
+	return &RequestStartTransactionResponse{Status: status}
+}
+
+func NewRequestStopTransactionResponse(status RequestStartStopStatus) *RequestStopTransactionResponse {
+	return &RequestStopTransactionResponse{Status: status}
+}
+
+var RemoteControlProfile = ocpp.NewProfile(
+	RemoteControlProfileName,
+	newFeature(RequestStartTransactionFeatureName, RequestStartTransactionRequest{}, RequestStartTransactionResponse{}),
+	newFeature(RequestStopTransactionFeatureName, RequestStopTransactionRequest{}, RequestStopTransactionResponse{}),
+)
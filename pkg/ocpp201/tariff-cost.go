@@ -0,0 +1,43 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+)
+
+// -------------------- Tariff and cost (CSMS -> CS) --------------------
+
+const CostUpdatedFeatureName = "CostUpdated"
+
+type (
+	// TariffCostHandler handles the running cost of the transactions sent by the CSMS.
+	TariffCostHandler interface {
+		OnCostUpdated(request *CostUpdatedRequest) (response *CostUpdatedResponse, err error)
+	}
+
+	// CostUpdatedRequest is sent by the CSMS with the running cost of the transaction, including taxes, in the
+	// currency of the TariffCostCtrlr.
+	CostUpdatedRequest struct {
+		TotalCost     float64 `json:"totalCost"`
+		TransactionId string  `json:"transactionId" validate:"required,max=36"`
+	}
+
+	CostUpdatedResponse struct {
+	}
+)
+
+func (r CostUpdatedRequest) GetFeatureName() string {
+	return CostUpdatedFeatureName
+}
+
+func (c CostUpdatedResponse) GetFeatureName() string {
+	return CostUpdatedFeatureName
+}
+
+func NewCostUpdatedResponse() *CostUpdatedResponse {
+	return &CostUpdatedResponse{}
+}
+
+var TariffCostProfile = ocpp.NewProfile(
+	TariffCostProfileName,
+	newFeature(CostUpdatedFeatureName, CostUpdatedRequest{}, CostUpdatedResponse{}),
+)
@@ -0,0 +1,127 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// -------------------- Transactions (CS -> CSMS) --------------------
+
+const TransactionEventFeatureName = "TransactionEvent"
+
+type (
+	TransactionEventType string
+	TriggerReason        string
+	ChargingState        string
+	StoppedReason        string
+)
+
+const (
+	TransactionEventStarted TransactionEventType = "Started"
+	TransactionEventUpdated TransactionEventType = "Updated"
+	TransactionEventEnded   TransactionEventType = "Ended"
+
+	TriggerReasonAuthorized           TriggerReason = "Authorized"
+	TriggerReasonCablePluggedIn       TriggerReason = "CablePluggedIn"
+	TriggerReasonChargingRateChanged  TriggerReason = "ChargingRateChanged"
+	TriggerReasonChargingStateChanged TriggerReason = "ChargingStateChanged"
+	TriggerReasonDeauthorized         TriggerReason = "Deauthorized"
+	TriggerReasonEnergyLimitReached   TriggerReason = "EnergyLimitReached"
+	TriggerReasonEVCommunicationLost  TriggerReason = "EVCommunicationLost"
+	TriggerReasonEVConnectTimeout     TriggerReason = "EVConnectTimeout"
+	TriggerReasonMeterValueClock      TriggerReason = "MeterValueClock"
+	TriggerReasonMeterValuePeriodic   TriggerReason = "MeterValuePeriodic"
+	TriggerReasonTimeLimitReached     TriggerReason = "TimeLimitReached"
+	TriggerReasonTrigger              TriggerReason = "Trigger"
+	TriggerReasonUnlockCommand        TriggerReason = "UnlockCommand"
+	TriggerReasonStopAuthorized       TriggerReason = "StopAuthorized"
+	TriggerReasonEVDeparted           TriggerReason = "EVDeparted"
+	TriggerReasonEVDetected           TriggerReason = "EVDetected"
+	TriggerReasonRemoteStop           TriggerReason = "RemoteStop"
+	TriggerReasonRemoteStart          TriggerReason = "RemoteStart"
+	TriggerReasonAbnormalCondition    TriggerReason = "AbnormalCondition"
+	TriggerReasonSignedDataReceived   TriggerReason = "SignedDataReceived"
+	TriggerReasonResetCommand         TriggerReason = "ResetCommand"
+
+	ChargingStateCharging      ChargingState = "Charging"
+	ChargingStateEVConnected   ChargingState = "EVConnected"
+	ChargingStateSuspendedEV   ChargingState = "SuspendedEV"
+	ChargingStateSuspendedEVSE ChargingState = "SuspendedEVSE"
+	ChargingStateIdle          ChargingState = "Idle"
+
+	StoppedReasonDeAuthorized       StoppedReason = "DeAuthorized"
+	StoppedReasonEmergencyStop      StoppedReason = "EmergencyStop"
+	StoppedReasonEnergyLimitReached StoppedReason = "EnergyLimitReached"
+	StoppedReasonEVDisconnected     StoppedReason = "EVDisconnected"
+	StoppedReasonGroundFault        StoppedReason = "GroundFault"
+	StoppedReasonImmediateReset     StoppedReason = "ImmediateReset"
+	StoppedReasonLocal              StoppedReason = "Local"
+	StoppedReasonLocalOutOfCredit   StoppedReason = "LocalOutOfCredit"
+	StoppedReasonMasterPass         StoppedReason = "MasterPass"
+	StoppedReasonOther              StoppedReason = "Other"
+	StoppedReasonOvercurrentFault   StoppedReason = "OvercurrentFault"
+	StoppedReasonPowerLoss          StoppedReason = "PowerLoss"
+	StoppedReasonPowerQuality       StoppedReason = "PowerQuality"
+	StoppedReasonReboot             StoppedReason = "Reboot"
+	StoppedReasonRemote             StoppedReason = "Remote"
+	StoppedReasonSOCLimitReached    StoppedReason = "SOCLimitReached"
+	StoppedReasonStoppedByEV        StoppedReason = "StoppedByEV"
+	StoppedReasonTimeLimitReached   StoppedReason = "TimeLimitReached"
+	StoppedReasonTimeout            StoppedReason = "Timeout"
+)
+
+type (
+	Transaction struct {
+		TransactionId     string        `json:"transactionId" validate:"required,max=36"`
+		ChargingState     ChargingState `json:"chargingState,omitempty" validate:"omitempty,oneof=Charging EVConnected SuspendedEV SuspendedEVSE Idle"`
+		TimeSpentCharging *int          `json:"timeSpentCharging,omitempty" validate:"omitempty,gte=0"`
+		StoppedReason     StoppedReason `json:"stoppedReason,omitempty" validate:"omitempty,oneof=DeAuthorized EmergencyStop EnergyLimitReached EVDisconnected GroundFault ImmediateReset Local LocalOutOfCredit MasterPass Other OvercurrentFault PowerLoss PowerQuality Reboot Remote SOCLimitReached StoppedByEV TimeLimitReached Timeout"`
+		RemoteStartId     *int          `json:"remoteStartId,omitempty" validate:"omitempty"`
+	}
+
+	// TransactionEventRequest is sent by the charging station when the transaction starts, is updated or ends.
+	// The sequence number increases with every event of the charging station, so the CSMS can detect missing events.
+	TransactionEventRequest struct {
+		EventType          TransactionEventType `json:"eventType" validate:"required,oneof=Started Updated Ended"`
+		Timestamp          *types.DateTime      `json:"timestamp" validate:"required"`
+		TriggerReason      TriggerReason        `json:"triggerReason" validate:"required,oneof=Authorized CablePluggedIn ChargingRateChanged ChargingStateChanged Deauthorized EnergyLimitReached EVCommunicationLost EVConnectTimeout MeterValueClock MeterValuePeriodic TimeLimitReached Trigger UnlockCommand StopAuthorized EVDeparted EVDetected RemoteStop RemoteStart AbnormalCondition SignedDataReceived ResetCommand"`
+		SequenceNo         int                  `json:"seqNo" validate:"gte=0"`
+		Offline            bool                 `json:"offline,omitempty"`
+		NumberOfPhasesUsed *int                 `json:"numberOfPhasesUsed,omitempty" validate:"omitempty,gte=0"`
+		CableMaxCurrent    *int                 `json:"cableMaxCurrent,omitempty"`
+		ReservationId      *int                 `json:"reservationId,omitempty"`
+		TransactionInfo    Transaction          `json:"transactionInfo" validate:"required"`
+		IdToken            *IdToken             `json:"idToken,omitempty" validate:"omitempty"`
+		Evse               *EVSE                `json:"evse,omitempty" validate:"omitempty"`
+		MeterValue         []MeterValue         `json:"meterValue,omitempty" validate:"omitempty,dive"`
+	}
+
+	TransactionEventResponse struct {
+		TotalCost              *float64        `json:"totalCost,omitempty" validate:"omitempty,gte=0"`
+		ChargingPriority       *int            `json:"chargingPriority,omitempty" validate:"omitempty,min=-9,max=9"`
+		IdTokenInfo            *IdTokenInfo    `json:"idTokenInfo,omitempty" validate:"omitempty"`
+		UpdatedPersonalMessage *MessageContent `json:"updatedPersonalMessage,omitempty" validate:"omitempty"`
+	}
+)
+
+func (r TransactionEventRequest) GetFeatureName() string {
+	return TransactionEventFeatureName
+}
+
+func (c TransactionEventResponse) GetFeatureName() string {
+	return TransactionEventFeatureName
+}
+
+func NewTransactionEventRequest(eventType TransactionEventType, timestamp *types.DateTime, reason TriggerReason, transactionInfo Transaction) *TransactionEventRequest {
+	return &TransactionEventRequest{
+		EventType:       eventType,
+		Timestamp:       timestamp,
+		TriggerReason:   reason,
+		TransactionInfo: transactionInfo,
+	}
+}
+
+var TransactionsProfile = ocpp.NewProfile(
+	TransactionsProfileName,
+	newFeature(TransactionEventFeatureName, TransactionEventRequest{}, TransactionEventResponse{}),
+)
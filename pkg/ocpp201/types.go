@@ -0,0 +1,166 @@
+package ocpp201
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"reflect"
+)
+
+// Subprotocol is the websocket subprotocol of OCPP 2.0.1.
+const Subprotocol = "ocpp2.0.1"
+
+// Names of the functional blocks of OCPP 2.0.1. Every block is a separate profile.
+const (
+	AuthorizationProfileName  = "Authorization"
+	AvailabilityProfileName   = "Availability"
+	DataTransferProfileName   = "DataTransfer"
+	DiagnosticsProfileName    = "Diagnostics"
+	DisplayMessageProfileName = "DisplayMessage"
+	LocalAuthListProfileName  = "LocalAuthorizationListManagement"
+	MeterValuesProfileName    = "MeterValues"
+	ProvisioningProfileName   = "Provisioning"
+	RemoteControlProfileName  = "RemoteControl"
+	TariffCostProfileName     = "TariffAndCost"
+	TransactionsProfileName   = "Transactions"
+)
+
+type (
+	IdTokenType         string
+	AuthorizationStatus string
+	MessageFormat       string
+	ReadingContext      string
+	Measurand           string
+	Phase               string
+	Location            string
+
+	AdditionalInfo struct {
+		AdditionalIdToken string `json:"additionalIdToken" validate:"required,max=36"`
+		Type              string `json:"type" validate:"required,max=50"`
+	}
+
+	// IdToken identifies the user or the device that authorizes the transaction.
+	IdToken struct {
+		IdToken        string           `json:"idToken" validate:"max=36"`
+		Type           IdTokenType      `json:"type" validate:"required,oneof=Central eMAID ISO14443 ISO15693 KeyCode Local MacAddress NoAuthorization"`
+		AdditionalInfo []AdditionalInfo `json:"additionalInfo,omitempty" validate:"omitempty,dive"`
+	}
+
+	MessageContent struct {
+		Format   MessageFormat `json:"format" validate:"required,oneof=ASCII HTML URI UTF8"`
+		Language string        `json:"language,omitempty" validate:"max=8"`
+		Content  string        `json:"content" validate:"required,max=512"`
+	}
+
+	// IdTokenInfo contains the authorization status of the IdToken and the information about the user.
+	IdTokenInfo struct {
+		Status              AuthorizationStatus `json:"status" validate:"required,oneof=Accepted Blocked ConcurrentTx Expired Invalid NoCredit NotAllowedTypeEVSE NotAtThisLocation NotAtThisTime Unknown"`
+		CacheExpiryDateTime *types.DateTime     `json:"cacheExpiryDateTime,omitempty" validate:"omitempty"`
+		ChargingPriority    int                 `json:"chargingPriority,omitempty" validate:"min=-9,max=9"`
+		Language1           string              `json:"language1,omitempty" validate:"max=8"`
+		EvseId              []int               `json:"evseId,omitempty" validate:"omitempty,dive,gte=0"`
+		Language2           string              `json:"language2,omitempty" validate:"max=8"`
+		GroupIdToken        *IdToken            `json:"groupIdToken,omitempty" validate:"omitempty"`
+		PersonalMessage     *MessageContent     `json:"personalMessage,omitempty" validate:"omitempty"`
+	}
+
+	// StatusInfo contains more information about the status in the response.
+	StatusInfo struct {
+		ReasonCode     string `json:"reasonCode" validate:"required,max=20"`
+		AdditionalInfo string `json:"additionalInfo,omitempty" validate:"max=512"`
+	}
+
+	// EVSE identifies the EVSE and optionally the connector of the EVSE. The id 0 refers to the whole charging station.
+	EVSE struct {
+		Id          int  `json:"id" validate:"gte=0"`
+		ConnectorId *int `json:"connectorId,omitempty" validate:"omitempty,gte=0"`
+	}
+
+	UnitOfMeasure struct {
+		Unit       string `json:"unit,omitempty" validate:"max=20"`
+		Multiplier int    `json:"multiplier,omitempty"`
+	}
+
+	SampledValue struct {
+		Value         float64        `json:"value"`
+		Context       ReadingContext `json:"context,omitempty" validate:"omitempty,oneof=Interruption.Begin Interruption.End Other Sample.Clock Sample.Periodic Transaction.Begin Transaction.End Trigger"`
+		Measurand     Measurand      `json:"measurand,omitempty" validate:"omitempty,max=50"`
+		Phase         Phase          `json:"phase,omitempty" validate:"omitempty,oneof=L1 L2 L3 N L1-N L2-N L3-N L1-L2 L2-L3 L3-L1"`
+		Location      Location       `json:"location,omitempty" validate:"omitempty,oneof=Body Cable EV Inlet Outlet"`
+		UnitOfMeasure *UnitOfMeasure `json:"unitOfMeasure,omitempty" validate:"omitempty"`
+	}
+
+	MeterValue struct {
+		Timestamp    types.DateTime `json:"timestamp" validate:"required"`
+		SampledValue []SampledValue `json:"sampledValue" validate:"required,min=1,dive"`
+	}
+
+	// feature is a generic ocpp.Feature implementation, since the features only differ in the name and the message types.
+	feature struct {
+		name         string
+		requestType  reflect.Type
+		responseType reflect.Type
+	}
+)
+
+const (
+	IdTokenTypeCentral         IdTokenType = "Central"
+	IdTokenTypeEMAID           IdTokenType = "eMAID"
+	IdTokenTypeISO14443        IdTokenType = "ISO14443"
+	IdTokenTypeISO15693        IdTokenType = "ISO15693"
+	IdTokenTypeKeyCode         IdTokenType = "KeyCode"
+	IdTokenTypeLocal           IdTokenType = "Local"
+	IdTokenTypeMacAddress      IdTokenType = "MacAddress"
+	IdTokenTypeNoAuthorization IdTokenType = "NoAuthorization"
+
+	AuthorizationStatusAccepted           AuthorizationStatus = "Accepted"
+	AuthorizationStatusBlocked            AuthorizationStatus = "Blocked"
+	AuthorizationStatusConcurrentTx       AuthorizationStatus = "ConcurrentTx"
+	AuthorizationStatusExpired            AuthorizationStatus = "Expired"
+	AuthorizationStatusInvalid            AuthorizationStatus = "Invalid"
+	AuthorizationStatusNoCredit           AuthorizationStatus = "NoCredit"
+	AuthorizationStatusNotAllowedTypeEVSE AuthorizationStatus = "NotAllowedTypeEVSE"
+	AuthorizationStatusNotAtThisLocation  AuthorizationStatus = "NotAtThisLocation"
+	AuthorizationStatusNotAtThisTime      AuthorizationStatus = "NotAtThisTime"
+	AuthorizationStatusUnknown            AuthorizationStatus = "Unknown"
+
+	MessageFormatASCII MessageFormat = "ASCII"
+	MessageFormatHTML  MessageFormat = "HTML"
+	MessageFormatURI   MessageFormat = "URI"
+	MessageFormatUTF8  MessageFormat = "UTF8"
+
+	ReadingContextInterruptionBegin ReadingContext = "Interruption.Begin"
+	ReadingContextInterruptionEnd   ReadingContext = "Interruption.End"
+	ReadingContextOther             ReadingContext = "Other"
+	ReadingContextSampleClock       ReadingContext = "Sample.Clock"
+	ReadingContextSamplePeriodic    ReadingContext = "Sample.Periodic"
+	ReadingContextTransactionBegin  ReadingContext = "Transaction.Begin"
+	ReadingContextTransactionEnd    ReadingContext = "Transaction.End"
+	ReadingContextTrigger           ReadingContext = "Trigger"
+
+	MeasurandEnergyActiveImportRegister Measurand = "Energy.Active.Import.Register"
+)
+
+// NewIdToken creates an IdToken of the type.
+func NewIdToken(idToken string, tokenType IdTokenType) IdToken {
+	return IdToken{IdToken: idToken, Type: tokenType}
+}
+
+func newFeature(name string, request, response interface{}) ocpp.Feature {
+	return feature{
+		name:         name,
+		requestType:  reflect.TypeOf(request),
+		responseType: reflect.TypeOf(response),
+	}
+}
+
+func (f feature) GetFeatureName() string {
+	return f.name
+}
+
+func (f feature) GetRequestType() reflect.Type {
+	return f.requestType
+}
+
+func (f feature) GetResponseType() reflect.Type {
+	return f.responseType
+}
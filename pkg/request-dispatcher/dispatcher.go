@@ -0,0 +1,201 @@
+package requestDispatcher
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+var ErrRequestTimeout = errors.New("request timed out")
+
+type (
+	// Callback is called with the response or the error of the request.
+	Callback func(response ocpp.Response, err error)
+
+	// Dispatcher is the dispatcher of an OCPP-J client, which sends the requests one by one, in the order they were queued,
+	// and calls the callbacks of the requests. The responses are matched to the requests by their unique id, so the
+	// requests of the OCPP library and the custom features (e.g. the Security Extension) can share the same queue.
+	Dispatcher interface {
+		ocppj.ClientDispatcher
+		// SendRequestAsync queues the request of the client. The callback is called in the background with the response
+		// passed to HandleResponse or with ErrRequestTimeout, if the central system does not respond in time.
+		SendRequestAsync(client *ocppj.Client, request ocpp.Request, callback Callback) error
+		// IsPending returns true if the request was sent with SendRequestAsync and is waiting for the response.
+		IsPending(requestId string) bool
+		// HandleResponse calls the callback of the request with the response or the error. Returns false if the request
+		// was not sent with SendRequestAsync.
+		HandleResponse(requestId string, response ocpp.Response, err error) bool
+		// RestorePendingRequest marks the request, which timed out, as pending again. The client can then parse the
+		// error response and notify its handlers, which are not notified about the timeouts otherwise.
+		RestorePendingRequest(requestId string, request ocpp.Request)
+		// CancelRequests calls the callbacks of all the queued requests with the error.
+		CancelRequests(err error)
+	}
+
+	dispatcherImpl struct {
+		*ocppj.DefaultClientDispatcher
+		mu    sync.Mutex
+		state ocppj.ClientState
+		// Callbacks of the requests, which were not assigned a unique id yet
+		queued            map[ocpp.Request]Callback
+		callbacks         map[string]Callback
+		restored          map[string]bool
+		onRequestCanceled func(requestId, action string, request ocpp.Request)
+	}
+)
+
+// NewDispatcher creates a dispatcher with an unlimited queue, which must be passed to the OCPP-J client.
+func NewDispatcher() Dispatcher {
+	dispatcher := &dispatcherImpl{
+		DefaultClientDispatcher: ocppj.NewDefaultClientDispatcher(ocppj.NewFIFOClientQueue(0)),
+		mu:                      sync.Mutex{},
+		queued:                  map[ocpp.Request]Callback{},
+		callbacks:               map[string]Callback{},
+		restored:                map[string]bool{},
+	}
+
+	dispatcher.DefaultClientDispatcher.SetOnRequestCanceled(dispatcher.requestCanceled)
+	return dispatcher
+}
+
+// SetPendingRequestState sets the state of the client, which holds the requests waiting for the response.
+func (d *dispatcherImpl) SetPendingRequestState(state ocppj.ClientState) {
+	d.mu.Lock()
+	d.state = state
+	d.mu.Unlock()
+
+	d.DefaultClientDispatcher.SetPendingRequestState(state)
+}
+
+// SetOnRequestCanceled sets the handler for the requests, which timed out and were not sent with SendRequestAsync.
+func (d *dispatcherImpl) SetOnRequestCanceled(handler func(requestId, action string, request ocpp.Request)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onRequestCanceled = handler
+}
+
+func (d *dispatcherImpl) SendRequestAsync(client *ocppj.Client, request ocpp.Request, callback Callback) error {
+	if callback == nil {
+		callback = func(response ocpp.Response, err error) {}
+	}
+
+	// The client creates the unique id, so the callback is assigned to the id when the client queues the request
+	d.mu.Lock()
+	d.queued[request] = callback
+	d.mu.Unlock()
+
+	err := client.SendRequest(request)
+
+	d.mu.Lock()
+	delete(d.queued, request)
+	d.mu.Unlock()
+
+	return err
+}
+
+// SendRequest queues the request of the client and assigns the callback to the unique id of the request.
+func (d *dispatcherImpl) SendRequest(req interface{}) error {
+	bundle, isBundle := req.(ocppj.RequestBundle)
+	if !isBundle {
+		return d.DefaultClientDispatcher.SendRequest(req)
+	}
+
+	d.mu.Lock()
+	if callback, isFound := d.queued[bundle.Call.Payload]; isFound {
+		delete(d.queued, bundle.Call.Payload)
+		d.callbacks[bundle.Call.UniqueId] = callback
+	}
+	d.mu.Unlock()
+
+	err := d.DefaultClientDispatcher.SendRequest(req)
+	if err != nil {
+		d.mu.Lock()
+		delete(d.callbacks, bundle.Call.UniqueId)
+		d.mu.Unlock()
+	}
+
+	return err
+}
+
+// CompleteRequest removes the request from the queue, so the next request can be sent.
+func (d *dispatcherImpl) CompleteRequest(requestId string) {
+	d.mu.Lock()
+	isRestored := d.restored[requestId]
+	delete(d.restored, requestId)
+	state := d.state
+	d.mu.Unlock()
+
+	// The request was already removed from the queue when it timed out
+	if isRestored {
+		if state != nil {
+			state.DeletePendingRequest(requestId)
+		}
+
+		return
+	}
+
+	d.DefaultClientDispatcher.CompleteRequest(requestId)
+}
+
+func (d *dispatcherImpl) IsPending(requestId string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, isFound := d.callbacks[requestId]
+	return isFound
+}
+
+func (d *dispatcherImpl) HandleResponse(requestId string, response ocpp.Response, err error) bool {
+	d.mu.Lock()
+	callback, isFound := d.callbacks[requestId]
+	delete(d.callbacks, requestId)
+	d.mu.Unlock()
+
+	if !isFound {
+		return false
+	}
+
+	// The callbacks might send requests and wait for the response
+	go callback(response, err)
+	return true
+}
+
+func (d *dispatcherImpl) RestorePendingRequest(requestId string, request ocpp.Request) {
+	d.mu.Lock()
+	d.restored[requestId] = true
+	state := d.state
+	d.mu.Unlock()
+
+	if state != nil {
+		state.AddPendingRequest(requestId, request)
+	}
+}
+
+func (d *dispatcherImpl) CancelRequests(err error) {
+	d.mu.Lock()
+	callbacks := d.callbacks
+	d.callbacks = map[string]Callback{}
+	d.mu.Unlock()
+
+	for _, callback := range callbacks {
+		go callback(nil, err)
+	}
+}
+
+func (d *dispatcherImpl) requestCanceled(requestId, action string, request ocpp.Request) {
+	log.Warnf("The central system did not respond to the request %s (%s)", requestId, action)
+
+	if d.HandleResponse(requestId, nil, ErrRequestTimeout) {
+		return
+	}
+
+	d.mu.Lock()
+	handler := d.onRequestCanceled
+	d.mu.Unlock()
+
+	if handler != nil {
+		handler(requestId, action, request)
+	}
+}
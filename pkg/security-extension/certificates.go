@@ -0,0 +1,143 @@
+package securityExtension
+
+// -------------------- Certificate management (CS -> CP and CP -> CS) --------------------
+
+const (
+	CertificateSignedFeatureName          = "CertificateSigned"
+	DeleteCertificateFeatureName          = "DeleteCertificate"
+	GetInstalledCertificateIdsFeatureName = "GetInstalledCertificateIds"
+	InstallCertificateFeatureName         = "InstallCertificate"
+	SignCertificateFeatureName            = "SignCertificate"
+)
+
+type (
+	CertificateSignedStatus       string
+	DeleteCertificateStatus       string
+	GetInstalledCertificateStatus string
+	InstallCertificateStatus      string
+)
+
+const (
+	CertificateSignedStatusAccepted CertificateSignedStatus = "Accepted"
+	CertificateSignedStatusRejected CertificateSignedStatus = "Rejected"
+
+	DeleteCertificateStatusAccepted DeleteCertificateStatus = "Accepted"
+	DeleteCertificateStatusFailed   DeleteCertificateStatus = "Failed"
+	DeleteCertificateStatusNotFound DeleteCertificateStatus = "NotFound"
+
+	GetInstalledCertificateStatusAccepted GetInstalledCertificateStatus = "Accepted"
+	GetInstalledCertificateStatusNotFound GetInstalledCertificateStatus = "NotFound"
+
+	InstallCertificateStatusAccepted InstallCertificateStatus = "Accepted"
+	InstallCertificateStatusFailed   InstallCertificateStatus = "Failed"
+	InstallCertificateStatusRejected InstallCertificateStatus = "Rejected"
+)
+
+type (
+	// CertificateSignedRequest is sent by the central system with the certificate chain, signed from the CSR of the charge point.
+	CertificateSignedRequest struct {
+		CertificateChain string `json:"certificateChain" validate:"required,max=10000"`
+	}
+
+	CertificateSignedConfirmation struct {
+		Status CertificateSignedStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+	}
+
+	// DeleteCertificateRequest is sent by the central system to delete an installed root certificate.
+	DeleteCertificateRequest struct {
+		CertificateHashData CertificateHashData `json:"certificateHashData" validate:"required"`
+	}
+
+	DeleteCertificateConfirmation struct {
+		Status DeleteCertificateStatus `json:"status" validate:"required,oneof=Accepted Failed NotFound"`
+	}
+
+	// GetInstalledCertificateIdsRequest is sent by the central system to get the installed root certificates of a type.
+	GetInstalledCertificateIdsRequest struct {
+		CertificateType CertificateUse `json:"certificateType" validate:"required,oneof=CentralSystemRootCertificate ManufacturerRootCertificate"`
+	}
+
+	GetInstalledCertificateIdsConfirmation struct {
+		Status              GetInstalledCertificateStatus `json:"status" validate:"required,oneof=Accepted NotFound"`
+		CertificateHashData []CertificateHashData         `json:"certificateHashData,omitempty" validate:"omitempty,dive"`
+	}
+
+	// InstallCertificateRequest is sent by the central system to install a new root certificate.
+	InstallCertificateRequest struct {
+		CertificateType CertificateUse `json:"certificateType" validate:"required,oneof=CentralSystemRootCertificate ManufacturerRootCertificate"`
+		Certificate     string         `json:"certificate" validate:"required,max=5500"`
+	}
+
+	InstallCertificateConfirmation struct {
+		Status InstallCertificateStatus `json:"status" validate:"required,oneof=Accepted Failed Rejected"`
+	}
+
+	// SignCertificateRequest is sent by the charge point with a CSR, which the central system should sign.
+	SignCertificateRequest struct {
+		Csr string `json:"csr" validate:"required,max=5500"`
+	}
+
+	SignCertificateConfirmation struct {
+		Status GenericStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+	}
+)
+
+func (r CertificateSignedRequest) GetFeatureName() string {
+	return CertificateSignedFeatureName
+}
+
+func (c CertificateSignedConfirmation) GetFeatureName() string {
+	return CertificateSignedFeatureName
+}
+
+func (r DeleteCertificateRequest) GetFeatureName() string {
+	return DeleteCertificateFeatureName
+}
+
+func (c DeleteCertificateConfirmation) GetFeatureName() string {
+	return DeleteCertificateFeatureName
+}
+
+func (r GetInstalledCertificateIdsRequest) GetFeatureName() string {
+	return GetInstalledCertificateIdsFeatureName
+}
+
+func (c GetInstalledCertificateIdsConfirmation) GetFeatureName() string {
+	return GetInstalledCertificateIdsFeatureName
+}
+
+func (r InstallCertificateRequest) GetFeatureName() string {
+	return InstallCertificateFeatureName
+}
+
+func (c InstallCertificateConfirmation) GetFeatureName() string {
+	return InstallCertificateFeatureName
+}
+
+func (r SignCertificateRequest) GetFeatureName() string {
+	return SignCertificateFeatureName
+}
+
+func (c SignCertificateConfirmation) GetFeatureName() string {
+	return SignCertificateFeatureName
+}
+
+func NewCertificateSignedConfirmation(status CertificateSignedStatus) *CertificateSignedConfirmation {
+	return &CertificateSignedConfirmation{Status: status}
+}
+
+func NewDeleteCertificateConfirmation(status DeleteCertificateStatus) *DeleteCertificateConfirmation {
+	return &DeleteCertificateConfirmation{Status: status}
+}
+
+func NewGetInstalledCertificateIdsConfirmation(status GetInstalledCertificateStatus) *GetInstalledCertificateIdsConfirmation {
+	return &GetInstalledCertificateIdsConfirmation{Status: status}
+}
+
+func NewInstallCertificateConfirmation(status InstallCertificateStatus) *InstallCertificateConfirmation {
+	return &InstallCertificateConfirmation{Status: status}
+}
+
+func NewSignCertificateRequest(csr string) *SignCertificateRequest {
+	return &SignCertificateRequest{Csr: csr}
+}
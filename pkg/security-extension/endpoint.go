@@ -0,0 +1,298 @@
+package securityExtension
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	log "github.com/sirupsen/logrus"
+	requestDispatcher "github.com/xBlaz3kx/ChargePi-go/pkg/request-dispatcher"
+	"sync"
+	"time"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+var (
+	ErrRequestTimeout = requestDispatcher.ErrRequestTimeout
+	ErrNotConnected   = errors.New("not connected to the central system")
+	ErrNoHandler      = errors.New("handler not set")
+)
+
+type (
+	// ChargePointHandler handles the requests of the Security Extension, sent by the central system.
+	ChargePointHandler interface {
+		OnCertificateSigned(request *CertificateSignedRequest) (confirmation *CertificateSignedConfirmation, err error)
+		OnDeleteCertificate(request *DeleteCertificateRequest) (confirmation *DeleteCertificateConfirmation, err error)
+		OnExtendedTriggerMessage(request *ExtendedTriggerMessageRequest) (confirmation *ExtendedTriggerMessageConfirmation, err error)
+		OnGetInstalledCertificateIds(request *GetInstalledCertificateIdsRequest) (confirmation *GetInstalledCertificateIdsConfirmation, err error)
+		OnGetLog(request *GetLogRequest) (confirmation *GetLogConfirmation, err error)
+		OnInstallCertificate(request *InstallCertificateRequest) (confirmation *InstallCertificateConfirmation, err error)
+		OnSignedUpdateFirmware(request *SignedUpdateFirmwareRequest) (confirmation *SignedUpdateFirmwareConfirmation, err error)
+	}
+
+	// Endpoint wraps the websocket client of the OCPP 1.6 charge point, since the OCPP library does not support the
+	// Security Extension. The endpoint creates the OCPP-J client with the Security Extension profile, which must be passed
+	// to the OCPP library together with the endpoint. The requests of the extension are queued with the requests of the
+	// OCPP library, while the messages of the extension sent by the central system are handled by the endpoint.
+	Endpoint interface {
+		ws.WsClient
+		GetClient() *ocppj.Client
+		SetHandler(handler ChargePointHandler)
+		SetRequestTimeout(timeout time.Duration)
+		SendRequest(request ocpp.Request) (ocpp.Response, error)
+	}
+
+	asyncResponse struct {
+		response ocpp.Response
+		err      error
+	}
+
+	endpointImpl struct {
+		ws.WsClient
+		client         *ocppj.Client
+		dispatcher     requestDispatcher.Dispatcher
+		mu             sync.Mutex
+		handler        ChargePointHandler
+		messageHandler func(data []byte) error
+	}
+)
+
+// NewEndpoint creates an endpoint for the websocket client and the OCPP-J client of the charge point with the id.
+func NewEndpoint(id string, client ws.WsClient) Endpoint {
+	endpoint := &endpointImpl{
+		WsClient:   client,
+		dispatcher: requestDispatcher.NewDispatcher(),
+		mu:         sync.Mutex{},
+	}
+
+	endpoint.dispatcher.SetTimeout(defaultRequestTimeout)
+	endpoint.dispatcher.SetOnRequestCanceled(endpoint.onRequestCanceled)
+	endpoint.client = ocppj.NewClient(
+		id,
+		endpoint,
+		endpoint.dispatcher,
+		ocppj.NewClientState(),
+		core.Profile,
+		localauth.Profile,
+		firmware.Profile,
+		reservation.Profile,
+		remotetrigger.Profile,
+		smartcharging.Profile,
+		Profile,
+	)
+
+	client.SetMessageHandler(endpoint.handleMessage)
+	return endpoint
+}
+
+// GetClient returns the OCPP-J client, which must be passed to the OCPP library.
+func (e *endpointImpl) GetClient() *ocppj.Client {
+	return e.client
+}
+
+// SetMessageHandler sets the handler of the OCPP-J client, which receives all the messages not handled by the endpoint.
+func (e *endpointImpl) SetMessageHandler(handler func(data []byte) error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messageHandler = handler
+}
+
+// SetHandler sets the handler for the requests of the Security Extension.
+func (e *endpointImpl) SetHandler(handler ChargePointHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.handler = handler
+}
+
+// SetRequestTimeout sets how long the charge point waits for the response of the central system. Must be called before
+// the charge point is started.
+func (e *endpointImpl) SetRequestTimeout(timeout time.Duration) {
+	e.dispatcher.SetTimeout(timeout)
+}
+
+// SendRequest queues a request of the Security Extension with the requests of the OCPP library and waits for the response.
+func (e *endpointImpl) SendRequest(request ocpp.Request) (ocpp.Response, error) {
+	if !e.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	responseChannel := make(chan asyncResponse, 1)
+	err := e.dispatcher.SendRequestAsync(e.client, request, func(response ocpp.Response, err error) {
+		responseChannel <- asyncResponse{response: response, err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-responseChannel
+	return result.response, result.err
+}
+
+// onRequestCanceled passes the timeout of a request of the OCPP library to the OCPP-J client as a CallError, since the
+// OCPP library is notified about the timeouts only by the dispatcher it creates itself.
+func (e *endpointImpl) onRequestCanceled(requestId, action string, request ocpp.Request) {
+	e.mu.Lock()
+	messageHandler := e.messageHandler
+	e.mu.Unlock()
+
+	if messageHandler == nil {
+		return
+	}
+
+	callError := e.client.CreateCallError(requestId, ocppj.GenericError, "request timed out, no response received from server", nil)
+	data, err := callError.MarshalJSON()
+	if err != nil {
+		log.WithError(err).Errorf("Cannot cancel the request %s", requestId)
+		return
+	}
+
+	e.dispatcher.RestorePendingRequest(requestId, request)
+
+	err = messageHandler(data)
+	if err != nil {
+		log.WithError(err).Errorf("Cannot cancel the request %s", requestId)
+	}
+}
+
+func (e *endpointImpl) handleMessage(data []byte) error {
+	arr, err := ocppj.ParseRawJsonMessage(data)
+	if err == nil && len(arr) >= 3 {
+		messageType, _ := arr[0].(float64)
+		uniqueId, _ := arr[1].(string)
+
+		switch ocppj.MessageType(messageType) {
+		case ocppj.CALL:
+			action, _ := arr[2].(string)
+			if Profile.SupportsFeature(action) {
+				return e.handleRequest(arr, uniqueId)
+			}
+		case ocppj.CALL_RESULT, ocppj.CALL_ERROR:
+			if e.dispatcher.IsPending(uniqueId) {
+				return e.handleResponse(arr, uniqueId)
+			}
+		}
+	}
+
+	e.mu.Lock()
+	messageHandler := e.messageHandler
+	e.mu.Unlock()
+
+	if messageHandler == nil {
+		return nil
+	}
+
+	return messageHandler(data)
+}
+
+// handleResponse completes the request of the Security Extension, so the dispatcher can send the next request.
+func (e *endpointImpl) handleResponse(arr []interface{}, uniqueId string) error {
+	message, err := e.client.ParseMessage(arr, e.client.RequestState)
+	e.dispatcher.CompleteRequest(uniqueId)
+
+	switch message := message.(type) {
+	case *ocppj.CallResult:
+		e.dispatcher.HandleResponse(uniqueId, message.Payload, nil)
+	case *ocppj.CallError:
+		e.dispatcher.HandleResponse(uniqueId, nil, ocpp.NewError(message.ErrorCode, message.ErrorDescription, message.UniqueId))
+	default:
+		if err == nil {
+			err = errors.New("unexpected response")
+		}
+
+		e.dispatcher.HandleResponse(uniqueId, nil, err)
+	}
+
+	return err
+}
+
+func (e *endpointImpl) handleRequest(arr []interface{}, uniqueId string) error {
+	message, err := e.client.ParseMessage(arr, e.client.RequestState)
+	if err != nil {
+		var ocppErr *ocpp.Error
+		if errors.As(err, &ocppErr) {
+			return e.client.SendError(uniqueId, ocppErr.Code, ocppErr.Description, nil)
+		}
+
+		return e.client.SendError(uniqueId, ocppj.FormationViolation, err.Error(), nil)
+	}
+
+	response, err := e.dispatch(message.(*ocppj.Call).Payload)
+	switch {
+	case errors.Is(err, ErrNoHandler):
+		return e.client.SendError(uniqueId, ocppj.NotSupported, err.Error(), nil)
+	case err != nil:
+		return e.client.SendError(uniqueId, ocppj.InternalError, err.Error(), nil)
+	}
+
+	err = e.client.SendResponse(uniqueId, response)
+	if err != nil {
+		return e.client.SendError(uniqueId, ocppj.InternalError, err.Error(), nil)
+	}
+
+	return nil
+}
+
+func (e *endpointImpl) dispatch(request ocpp.Request) (ocpp.Response, error) {
+	e.mu.Lock()
+	handler := e.handler
+	e.mu.Unlock()
+
+	if handler == nil {
+		return nil, ErrNoHandler
+	}
+
+	log.Debugf("Received %s request", request.GetFeatureName())
+
+	// The confirmations are returned as interfaces only if they are not nil, otherwise the CallResult would contain a nil payload
+	var (
+		response ocpp.Response
+		err      error
+	)
+
+	switch request := request.(type) {
+	case *CertificateSignedRequest:
+		confirmation, handlerErr := handler.OnCertificateSigned(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *DeleteCertificateRequest:
+		confirmation, handlerErr := handler.OnDeleteCertificate(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *ExtendedTriggerMessageRequest:
+		confirmation, handlerErr := handler.OnExtendedTriggerMessage(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *GetInstalledCertificateIdsRequest:
+		confirmation, handlerErr := handler.OnGetInstalledCertificateIds(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *GetLogRequest:
+		confirmation, handlerErr := handler.OnGetLog(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *InstallCertificateRequest:
+		confirmation, handlerErr := handler.OnInstallCertificate(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	case *SignedUpdateFirmwareRequest:
+		confirmation, handlerErr := handler.OnSignedUpdateFirmware(request)
+		response, err = toResponse(confirmation, confirmation == nil, handlerErr)
+	default:
+		return nil, ErrNoHandler
+	}
+
+	return response, err
+}
+
+func toResponse(response ocpp.Response, isNil bool, err error) (ocpp.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if isNil {
+		return nil, errors.New("empty response")
+	}
+
+	return response, nil
+}
@@ -0,0 +1,256 @@
+package securityExtension
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type (
+	wsClientMock struct {
+		mock.Mock
+		ws.WsClient
+		messageHandler func(data []byte) error
+		written        chan []byte
+	}
+
+	handlerMock struct {
+		mock.Mock
+	}
+
+	endpointTestSuite struct {
+		suite.Suite
+		client      *wsClientMock
+		endpoint    Endpoint
+		chargePoint ocpp16.ChargePoint
+	}
+)
+
+func (w *wsClientMock) SetMessageHandler(handler func(data []byte) error) {
+	w.messageHandler = handler
+}
+
+func (w *wsClientMock) SetDisconnectedHandler(handler func(err error)) {}
+
+func (w *wsClientMock) SetReconnectedHandler(handler func()) {}
+
+func (w *wsClientMock) AddOption(option interface{}) {
+	option.(func(dialer *websocket.Dialer))(&websocket.Dialer{})
+}
+
+func (w *wsClientMock) Start(url string) error {
+	return w.Called(url).Error(0)
+}
+
+func (w *wsClientMock) Stop() {}
+
+func (w *wsClientMock) IsConnected() bool {
+	return true
+}
+
+func (w *wsClientMock) Write(data []byte) error {
+	w.written <- data
+	return nil
+}
+
+func (h *handlerMock) OnCertificateSigned(request *CertificateSignedRequest) (*CertificateSignedConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*CertificateSignedConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnDeleteCertificate(request *DeleteCertificateRequest) (*DeleteCertificateConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*DeleteCertificateConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnExtendedTriggerMessage(request *ExtendedTriggerMessageRequest) (*ExtendedTriggerMessageConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*ExtendedTriggerMessageConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnGetInstalledCertificateIds(request *GetInstalledCertificateIdsRequest) (*GetInstalledCertificateIdsConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*GetInstalledCertificateIdsConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnGetLog(request *GetLogRequest) (*GetLogConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*GetLogConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnInstallCertificate(request *InstallCertificateRequest) (*InstallCertificateConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*InstallCertificateConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnSignedUpdateFirmware(request *SignedUpdateFirmwareRequest) (*SignedUpdateFirmwareConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*SignedUpdateFirmwareConfirmation), args.Error(1)
+}
+
+func (s *endpointTestSuite) SetupTest() {
+	s.client = &wsClientMock{written: make(chan []byte, 10)}
+	s.endpoint = NewEndpoint("cp1", s.client)
+	// The dispatcher reads the timeout without synchronization, so it is set before the client is started
+	s.endpoint.SetRequestTimeout(300 * time.Millisecond)
+	s.chargePoint = ocpp16.NewChargePoint("cp1", s.endpoint.GetClient(), s.endpoint)
+
+	s.client.On("Start", "ws://localhost:8080/cp1").Return(nil)
+	s.Require().NoError(s.chargePoint.Start("ws://localhost:8080"))
+}
+
+func (s *endpointTestSuite) readMessage() []interface{} {
+	select {
+	case data := <-s.client.written:
+		var message []interface{}
+		s.Require().NoError(json.Unmarshal(data, &message))
+		return message
+	case <-time.After(time.Second):
+		s.FailNow("no message was written")
+		return nil
+	}
+}
+
+func (s *endpointTestSuite) TestHandleRequest() {
+	handler := new(handlerMock)
+	handler.On("OnCertificateSigned", &CertificateSignedRequest{CertificateChain: "chain"}).
+		Return(NewCertificateSignedConfirmation(CertificateSignedStatusAccepted), nil)
+	s.endpoint.SetHandler(handler)
+
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1234","CertificateSigned",{"certificateChain":"chain"}]`)))
+
+	message := s.readMessage()
+	s.Assert().EqualValues(3, message[0])
+	s.Assert().EqualValues("1234", message[1])
+	s.Assert().EqualValues(map[string]interface{}{"status": "Accepted"}, message[2])
+	handler.AssertExpectations(s.T())
+
+	// Invalid payload
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1235","GetInstalledCertificateIds",{"certificateType":"Invalid"}]`)))
+
+	message = s.readMessage()
+	s.Assert().EqualValues(4, message[0])
+	s.Assert().EqualValues("1235", message[1])
+}
+
+func (s *endpointTestSuite) TestHandleRequestWithoutHandler() {
+	s.Require().NoError(s.client.messageHandler([]byte(`[2,"1234","CertificateSigned",{"certificateChain":"chain"}]`)))
+
+	message := s.readMessage()
+	s.Assert().EqualValues(4, message[0])
+	s.Assert().EqualValues("NotSupported", message[2])
+}
+
+func (s *endpointTestSuite) TestForwardMessage() {
+	var forwarded []byte
+	s.endpoint.SetMessageHandler(func(data []byte) error {
+		forwarded = data
+		return nil
+	})
+
+	// Messages of the other profiles are handled by the OCPP client
+	data := []byte(`[2,"1234","Reset",{"type":"Soft"}]`)
+	s.Require().NoError(s.client.messageHandler(data))
+	s.Assert().EqualValues(data, forwarded)
+
+	// Responses to requests not sent by the endpoint
+	data = []byte(`[3,"5678",{}]`)
+	s.Require().NoError(s.client.messageHandler(data))
+	s.Assert().EqualValues(data, forwarded)
+	s.Assert().Empty(s.client.written)
+}
+
+func (s *endpointTestSuite) TestSendRequest() {
+	go func() {
+		message := s.readMessage()
+		s.Assert().EqualValues(2, message[0])
+		s.Assert().EqualValues(SecurityEventNotificationFeatureName, message[2])
+
+		_ = s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{}]`))
+	}()
+
+	response, err := s.endpoint.SendRequest(NewSecurityEventNotificationRequest(StartupOfTheDevice, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+	s.Assert().IsType(&SecurityEventNotificationConfirmation{}, response)
+
+	// Call error
+	go func() {
+		message := s.readMessage()
+		_ = s.client.messageHandler([]byte(`[4,"` + message[1].(string) + `","InternalError","error",{}]`))
+	}()
+
+	_, err = s.endpoint.SendRequest(NewSignCertificateRequest("csr"))
+	var ocppErr *ocpp.Error
+	s.Require().ErrorAs(err, &ocppErr)
+	s.Assert().EqualValues("InternalError", ocppErr.Code)
+
+	// No response
+	_, err = s.endpoint.SendRequest(NewSignCertificateRequest("csr"))
+	s.Assert().ErrorIs(err, ErrRequestTimeout)
+}
+
+func (s *endpointTestSuite) TestSharedQueue() {
+	var (
+		heartbeatErr = make(chan error, 1)
+		securityErr  = make(chan error, 1)
+	)
+
+	go func() {
+		_, err := s.chargePoint.Heartbeat()
+		heartbeatErr <- err
+	}()
+
+	heartbeat := s.readMessage()
+	s.Assert().EqualValues(core.HeartbeatFeatureName, heartbeat[2])
+
+	go func() {
+		_, err := s.endpoint.SendRequest(NewSecurityEventNotificationRequest(StartupOfTheDevice, types.NewDateTime(time.Now())))
+		securityErr <- err
+	}()
+
+	// The request of the extension is sent after the response to the request of the OCPP library
+	select {
+	case <-s.client.written:
+		s.FailNow("the request was sent before the response to the previous one")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"` + heartbeat[1].(string) + `",{"currentTime":"2023-01-01T00:00:00Z"}]`)))
+	s.Require().NoError(<-heartbeatErr)
+
+	message := s.readMessage()
+	s.Assert().EqualValues(SecurityEventNotificationFeatureName, message[2])
+	s.Require().NoError(s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{}]`)))
+	s.Require().NoError(<-securityErr)
+}
+
+func (s *endpointTestSuite) TestLibraryRequestTimeout() {
+	// The OCPP library is notified about the timeout
+	_, err := s.chargePoint.Heartbeat()
+	var ocppErr *ocpp.Error
+	s.Require().ErrorAs(err, &ocppErr)
+	s.Assert().EqualValues(ocppj.GenericError, ocppErr.Code)
+	s.readMessage()
+
+	// The next request is sent
+	go func() {
+		message := s.readMessage()
+		_ = s.client.messageHandler([]byte(`[3,"` + message[1].(string) + `",{}]`))
+	}()
+
+	_, err = s.endpoint.SendRequest(NewSecurityEventNotificationRequest(StartupOfTheDevice, types.NewDateTime(time.Now())))
+	s.Require().NoError(err)
+}
+
+func TestEndpoint(t *testing.T) {
+	suite.Run(t, new(endpointTestSuite))
+}
@@ -0,0 +1,90 @@
+package securityExtension
+
+import "github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+
+// -------------------- Signed firmware update (CS -> CP and CP -> CS) --------------------
+
+const (
+	SignedUpdateFirmwareFeatureName             = "SignedUpdateFirmware"
+	SignedFirmwareStatusNotificationFeatureName = "SignedFirmwareStatusNotification"
+)
+
+type UpdateFirmwareStatus string
+
+const (
+	UpdateFirmwareStatusAccepted           UpdateFirmwareStatus = "Accepted"
+	UpdateFirmwareStatusRejected           UpdateFirmwareStatus = "Rejected"
+	UpdateFirmwareStatusAcceptedCanceled   UpdateFirmwareStatus = "AcceptedCanceled"
+	UpdateFirmwareStatusInvalidCertificate UpdateFirmwareStatus = "InvalidCertificate"
+	UpdateFirmwareStatusRevokedCertificate UpdateFirmwareStatus = "RevokedCertificate"
+
+	FirmwareStatusDownloaded                FirmwareStatus = "Downloaded"
+	FirmwareStatusDownloadFailed            FirmwareStatus = "DownloadFailed"
+	FirmwareStatusDownloading               FirmwareStatus = "Downloading"
+	FirmwareStatusDownloadScheduled         FirmwareStatus = "DownloadScheduled"
+	FirmwareStatusDownloadPaused            FirmwareStatus = "DownloadPaused"
+	FirmwareStatusIdle                      FirmwareStatus = "Idle"
+	FirmwareStatusInstallationFailed        FirmwareStatus = "InstallationFailed"
+	FirmwareStatusInstalling                FirmwareStatus = "Installing"
+	FirmwareStatusInstalled                 FirmwareStatus = "Installed"
+	FirmwareStatusInstallRebooting          FirmwareStatus = "InstallRebooting"
+	FirmwareStatusInstallScheduled          FirmwareStatus = "InstallScheduled"
+	FirmwareStatusInstallVerificationFailed FirmwareStatus = "InstallVerificationFailed"
+	FirmwareStatusInvalidSignature          FirmwareStatus = "InvalidSignature"
+	FirmwareStatusSignatureVerified         FirmwareStatus = "SignatureVerified"
+)
+
+type (
+	Firmware struct {
+		Location           string          `json:"location" validate:"required,max=512,url"`
+		RetrieveDateTime   *types.DateTime `json:"retrieveDateTime" validate:"required"`
+		InstallDateTime    *types.DateTime `json:"installDateTime,omitempty" validate:"omitempty"`
+		SigningCertificate string          `json:"signingCertificate" validate:"required,max=5500"`
+		Signature          string          `json:"signature" validate:"required,max=800"`
+	}
+
+	// SignedUpdateFirmwareRequest is sent by the central system to install a firmware, signed by the manufacturer.
+	SignedUpdateFirmwareRequest struct {
+		Retries       *int     `json:"retries,omitempty" validate:"omitempty,gte=0"`
+		RetryInterval *int     `json:"retryInterval,omitempty" validate:"omitempty,gte=0"`
+		RequestId     int      `json:"requestId" validate:"gte=0"`
+		Firmware      Firmware `json:"firmware" validate:"required"`
+	}
+
+	SignedUpdateFirmwareConfirmation struct {
+		Status UpdateFirmwareStatus `json:"status" validate:"required,oneof=Accepted Rejected AcceptedCanceled InvalidCertificate RevokedCertificate"`
+	}
+
+	// SignedFirmwareStatusNotificationRequest is sent by the charge point to report the progress of the signed firmware update.
+	SignedFirmwareStatusNotificationRequest struct {
+		Status    FirmwareStatus `json:"status" validate:"required,oneof=Downloaded DownloadFailed Downloading DownloadScheduled DownloadPaused Idle InstallationFailed Installing Installed InstallRebooting InstallScheduled InstallVerificationFailed InvalidSignature SignatureVerified"`
+		RequestId *int           `json:"requestId,omitempty" validate:"omitempty,gte=0"`
+	}
+
+	SignedFirmwareStatusNotificationConfirmation struct {
+	}
+)
+
+func (r SignedUpdateFirmwareRequest) GetFeatureName() string {
+	return SignedUpdateFirmwareFeatureName
+}
+
+func (c SignedUpdateFirmwareConfirmation) GetFeatureName() string {
+	return SignedUpdateFirmwareFeatureName
+}
+
+func (r SignedFirmwareStatusNotificationRequest) GetFeatureName() string {
+	return SignedFirmwareStatusNotificationFeatureName
+}
+
+func (c SignedFirmwareStatusNotificationConfirmation) GetFeatureName() string {
+	return SignedFirmwareStatusNotificationFeatureName
+}
+
+func NewSignedUpdateFirmwareConfirmation(status UpdateFirmwareStatus) *SignedUpdateFirmwareConfirmation {
+	return &SignedUpdateFirmwareConfirmation{Status: status}
+}
+
+func NewSignedFirmwareStatusNotificationRequest(status FirmwareStatus, requestId int) *SignedFirmwareStatusNotificationRequest {
+	return &SignedFirmwareStatusNotificationRequest{Status: status, RequestId: &requestId}
+}
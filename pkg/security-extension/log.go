@@ -0,0 +1,104 @@
+package securityExtension
+
+import "github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+
+// -------------------- Logs and security events (CS -> CP and CP -> CS) --------------------
+
+const (
+	GetLogFeatureName                    = "GetLog"
+	LogStatusNotificationFeatureName     = "LogStatusNotification"
+	SecurityEventNotificationFeatureName = "SecurityEventNotification"
+)
+
+type LogStatus string
+
+const (
+	LogStatusAccepted         LogStatus = "Accepted"
+	LogStatusRejected         LogStatus = "Rejected"
+	LogStatusAcceptedCanceled LogStatus = "AcceptedCanceled"
+
+	UploadLogStatusBadMessage            UploadLogStatus = "BadMessage"
+	UploadLogStatusIdle                  UploadLogStatus = "Idle"
+	UploadLogStatusNotSupportedOperation UploadLogStatus = "NotSupportedOperation"
+	UploadLogStatusPermissionDenied      UploadLogStatus = "PermissionDenied"
+	UploadLogStatusUploaded              UploadLogStatus = "Uploaded"
+	UploadLogStatusUploadFailure         UploadLogStatus = "UploadFailure"
+	UploadLogStatusUploading             UploadLogStatus = "Uploading"
+)
+
+type (
+	LogParameters struct {
+		RemoteLocation  string          `json:"remoteLocation" validate:"required,max=512,url"`
+		OldestTimestamp *types.DateTime `json:"oldestTimestamp,omitempty" validate:"omitempty"`
+		LatestTimestamp *types.DateTime `json:"latestTimestamp,omitempty" validate:"omitempty"`
+	}
+
+	// GetLogRequest is sent by the central system to upload the diagnostics or the security log to the remote location.
+	GetLogRequest struct {
+		Log           LogParameters `json:"log" validate:"required"`
+		LogType       LogType       `json:"logType" validate:"required,oneof=DiagnosticsLog SecurityLog"`
+		RequestId     int           `json:"requestId" validate:"gte=0"`
+		Retries       *int          `json:"retries,omitempty" validate:"omitempty,gte=0"`
+		RetryInterval *int          `json:"retryInterval,omitempty" validate:"omitempty,gte=0"`
+	}
+
+	GetLogConfirmation struct {
+		Status   LogStatus `json:"status" validate:"required,oneof=Accepted Rejected AcceptedCanceled"`
+		Filename string    `json:"filename,omitempty" validate:"omitempty,max=255"`
+	}
+
+	// LogStatusNotificationRequest is sent by the charge point to report the status of the log upload.
+	LogStatusNotificationRequest struct {
+		Status    UploadLogStatus `json:"status" validate:"required,oneof=BadMessage Idle NotSupportedOperation PermissionDenied Uploaded UploadFailure Uploading"`
+		RequestId *int            `json:"requestId,omitempty" validate:"omitempty,gte=0"`
+	}
+
+	LogStatusNotificationConfirmation struct {
+	}
+
+	// SecurityEventNotificationRequest is sent by the charge point to report a security event.
+	SecurityEventNotificationRequest struct {
+		Type      SecurityEvent   `json:"type" validate:"required,max=50"`
+		Timestamp *types.DateTime `json:"timestamp" validate:"required"`
+		TechInfo  string          `json:"techInfo,omitempty" validate:"omitempty,max=255"`
+	}
+
+	SecurityEventNotificationConfirmation struct {
+	}
+)
+
+func (r GetLogRequest) GetFeatureName() string {
+	return GetLogFeatureName
+}
+
+func (c GetLogConfirmation) GetFeatureName() string {
+	return GetLogFeatureName
+}
+
+func (r LogStatusNotificationRequest) GetFeatureName() string {
+	return LogStatusNotificationFeatureName
+}
+
+func (c LogStatusNotificationConfirmation) GetFeatureName() string {
+	return LogStatusNotificationFeatureName
+}
+
+func (r SecurityEventNotificationRequest) GetFeatureName() string {
+	return SecurityEventNotificationFeatureName
+}
+
+func (c SecurityEventNotificationConfirmation) GetFeatureName() string {
+	return SecurityEventNotificationFeatureName
+}
+
+func NewGetLogConfirmation(status LogStatus) *GetLogConfirmation {
+	return &GetLogConfirmation{Status: status}
+}
+
+func NewLogStatusNotificationRequest(status UploadLogStatus, requestId int) *LogStatusNotificationRequest {
+	return &LogStatusNotificationRequest{Status: status, RequestId: &requestId}
+}
+
+func NewSecurityEventNotificationRequest(event SecurityEvent, timestamp *types.DateTime) *SecurityEventNotificationRequest {
+	return &SecurityEventNotificationRequest{Type: event, Timestamp: timestamp}
+}
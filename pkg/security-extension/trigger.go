@@ -0,0 +1,45 @@
+package securityExtension
+
+// -------------------- Extended Trigger Message (CS -> CP) --------------------
+
+const ExtendedTriggerMessageFeatureName = "ExtendedTriggerMessage"
+
+type TriggerMessageStatus string
+
+const (
+	MessageTriggerBootNotification           MessageTrigger = "BootNotification"
+	MessageTriggerLogStatusNotification      MessageTrigger = "LogStatusNotification"
+	MessageTriggerFirmwareStatusNotification MessageTrigger = "FirmwareStatusNotification"
+	MessageTriggerHeartbeat                  MessageTrigger = "Heartbeat"
+	MessageTriggerMeterValues                MessageTrigger = "MeterValues"
+	MessageTriggerSignChargePointCertificate MessageTrigger = "SignChargePointCertificate"
+	MessageTriggerStatusNotification         MessageTrigger = "StatusNotification"
+
+	TriggerMessageStatusAccepted       TriggerMessageStatus = "Accepted"
+	TriggerMessageStatusRejected       TriggerMessageStatus = "Rejected"
+	TriggerMessageStatusNotImplemented TriggerMessageStatus = "NotImplemented"
+)
+
+type (
+	// ExtendedTriggerMessageRequest is sent by the central system to trigger a message, including the messages of the Security Extension.
+	ExtendedTriggerMessageRequest struct {
+		RequestedMessage MessageTrigger `json:"requestedMessage" validate:"required,oneof=BootNotification LogStatusNotification FirmwareStatusNotification Heartbeat MeterValues SignChargePointCertificate StatusNotification"`
+		ConnectorId      *int           `json:"connectorId,omitempty" validate:"omitempty,gt=0"`
+	}
+
+	ExtendedTriggerMessageConfirmation struct {
+		Status TriggerMessageStatus `json:"status" validate:"required,oneof=Accepted Rejected NotImplemented"`
+	}
+)
+
+func (r ExtendedTriggerMessageRequest) GetFeatureName() string {
+	return ExtendedTriggerMessageFeatureName
+}
+
+func (c ExtendedTriggerMessageConfirmation) GetFeatureName() string {
+	return ExtendedTriggerMessageFeatureName
+}
+
+func NewExtendedTriggerMessageConfirmation(status TriggerMessageStatus) *ExtendedTriggerMessageConfirmation {
+	return &ExtendedTriggerMessageConfirmation{Status: status}
+}
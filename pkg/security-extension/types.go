@@ -0,0 +1,113 @@
+package securityExtension
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"reflect"
+)
+
+// ProfileName is the name of the Security Extension profile, defined in the "Improved security for OCPP 1.6-J" whitepaper.
+const ProfileName = "Security"
+
+const (
+	AdditionalRootCertificateCheck = configuration.Key("AdditionalRootCertificateCheck")
+	AuthorizationKey               = configuration.Key("AuthorizationKey")
+	CertificateSignedMaxChainSize  = configuration.Key("CertificateSignedMaxChainSize")
+	CertificateStoreMaxLength      = configuration.Key("CertificateStoreMaxLength")
+	CpoName                        = configuration.Key("CpoName")
+	SecurityProfile                = configuration.Key("SecurityProfile")
+)
+
+type (
+	HashAlgorithm   string
+	CertificateUse  string
+	LogType         string
+	SecurityEvent   string
+	GenericStatus   string
+	MessageTrigger  string
+	UploadLogStatus string
+	FirmwareStatus  string
+
+	// CertificateHashData identifies a certificate with the hashes of the issuer's name and public key and the serial number.
+	CertificateHashData struct {
+		HashAlgorithm  HashAlgorithm `json:"hashAlgorithm" validate:"required,oneof=SHA256 SHA384 SHA512"`
+		IssuerNameHash string        `json:"issuerNameHash" validate:"required,max=128"`
+		IssuerKeyHash  string        `json:"issuerKeyHash" validate:"required,max=128"`
+		SerialNumber   string        `json:"serialNumber" validate:"required,max=40"`
+	}
+
+	// feature is a generic ocpp.Feature implementation, since the features of the extension only differ in the name and the message types.
+	feature struct {
+		name         string
+		requestType  reflect.Type
+		responseType reflect.Type
+	}
+)
+
+const (
+	SHA256 HashAlgorithm = "SHA256"
+	SHA384 HashAlgorithm = "SHA384"
+	SHA512 HashAlgorithm = "SHA512"
+
+	CentralSystemRootCertificate CertificateUse = "CentralSystemRootCertificate"
+	ManufacturerRootCertificate  CertificateUse = "ManufacturerRootCertificate"
+
+	DiagnosticsLog LogType = "DiagnosticsLog"
+	SecurityLog    LogType = "SecurityLog"
+
+	GenericStatusAccepted GenericStatus = "Accepted"
+	GenericStatusRejected GenericStatus = "Rejected"
+
+	// Security events sent by the charge point, defined in the whitepaper.
+	FirmwareUpdated                     SecurityEvent = "FirmwareUpdated"
+	FailedToAuthenticateAtCentralSystem SecurityEvent = "FailedToAuthenticateAtCentralSystem"
+	SettingSystemTime                   SecurityEvent = "SettingSystemTime"
+	StartupOfTheDevice                  SecurityEvent = "StartupOfTheDevice"
+	ResetOrReboot                       SecurityEvent = "ResetOrReboot"
+	SecurityLogWasCleared               SecurityEvent = "SecurityLogWasCleared"
+	ReconfigurationOfSecurityParameters SecurityEvent = "ReconfigurationOfSecurityParameters"
+	MemoryExhaustion                    SecurityEvent = "MemoryExhaustion"
+	InvalidMessages                     SecurityEvent = "InvalidMessages"
+	InvalidFirmwareSignature            SecurityEvent = "InvalidFirmwareSignature"
+	InvalidFirmwareSigningCertificate   SecurityEvent = "InvalidFirmwareSigningCertificate"
+	InvalidCentralSystemCertificate     SecurityEvent = "InvalidCentralSystemCertificate"
+	InvalidChargePointCertificate       SecurityEvent = "InvalidChargePointCertificate"
+	InvalidTLSVersion                   SecurityEvent = "InvalidTLSVersion"
+	InvalidTLSCipherSuite               SecurityEvent = "InvalidTLSCipherSuite"
+)
+
+func newFeature(name string, request, response interface{}) ocpp.Feature {
+	return feature{
+		name:         name,
+		requestType:  reflect.TypeOf(request),
+		responseType: reflect.TypeOf(response),
+	}
+}
+
+func (f feature) GetFeatureName() string {
+	return f.name
+}
+
+func (f feature) GetRequestType() reflect.Type {
+	return f.requestType
+}
+
+func (f feature) GetResponseType() reflect.Type {
+	return f.responseType
+}
+
+// Profile contains all the features of the Security Extension.
+var Profile = ocpp.NewProfile(
+	ProfileName,
+	newFeature(CertificateSignedFeatureName, CertificateSignedRequest{}, CertificateSignedConfirmation{}),
+	newFeature(DeleteCertificateFeatureName, DeleteCertificateRequest{}, DeleteCertificateConfirmation{}),
+	newFeature(ExtendedTriggerMessageFeatureName, ExtendedTriggerMessageRequest{}, ExtendedTriggerMessageConfirmation{}),
+	newFeature(GetInstalledCertificateIdsFeatureName, GetInstalledCertificateIdsRequest{}, GetInstalledCertificateIdsConfirmation{}),
+	newFeature(GetLogFeatureName, GetLogRequest{}, GetLogConfirmation{}),
+	newFeature(InstallCertificateFeatureName, InstallCertificateRequest{}, InstallCertificateConfirmation{}),
+	newFeature(LogStatusNotificationFeatureName, LogStatusNotificationRequest{}, LogStatusNotificationConfirmation{}),
+	newFeature(SecurityEventNotificationFeatureName, SecurityEventNotificationRequest{}, SecurityEventNotificationConfirmation{}),
+	newFeature(SignCertificateFeatureName, SignCertificateRequest{}, SignCertificateConfirmation{}),
+	newFeature(SignedFirmwareStatusNotificationFeatureName, SignedFirmwareStatusNotificationRequest{}, SignedFirmwareStatusNotificationConfirmation{}),
+	newFeature(SignedUpdateFirmwareFeatureName, SignedUpdateFirmwareRequest{}, SignedUpdateFirmwareConfirmation{}),
+)
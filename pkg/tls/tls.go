@@ -9,6 +9,18 @@ import (
 )
 
 func GetTLSClient(CACertificatePath, ClientCertificatePath, ClientKeyPath string) *ws.Client {
+	config := GetTLSConfig(CACertificatePath, ClientCertificatePath, ClientKeyPath)
+	if config == nil {
+		return nil
+	}
+
+	log.Debugf("Creating a TLS client")
+	return ws.NewTLSClient(config)
+}
+
+// GetTLSConfig creates the TLS configuration with the CA certificate and the client certificate. If any of the
+// certificates cannot be loaded, nil is returned.
+func GetTLSConfig(CACertificatePath, ClientCertificatePath, ClientKeyPath string) *tls.Config {
 	certPool, err := x509.SystemCertPool()
 	if err != nil {
 		log.WithError(err).Fatal("Cannot fetch certificate")
@@ -31,10 +43,38 @@ func GetTLSClient(CACertificatePath, ClientCertificatePath, ClientKeyPath string
 		return nil
 	}
 
-	log.Debugf("Creating a TLS client")
-	// Create client with TLS config
-	return ws.NewTLSClient(&tls.Config{
+	return &tls.Config{
 		RootCAs:      certPool,
 		Certificates: []tls.Certificate{certificate},
-	})
+	}
+}
+
+// GetCertificatePool returns the certificate pool with only the CA certificate, if it exists. The system certificates
+// are not trusted, so the central system must present a certificate signed by the configured or installed root certificates.
+func GetCertificatePool(CACertificatePath string) *x509.CertPool {
+	certPool := x509.NewCertPool()
+
+	caCert, err := ioutil.ReadFile(CACertificatePath)
+	if err == nil && !certPool.AppendCertsFromPEM(caCert) {
+		log.Warn("Unable to parse the CA certificate")
+	}
+
+	return certPool
+}
+
+// NewTLSConfig creates the TLS configuration, which verifies the server with the root certificates and requests
+// the client certificate on every handshake.
+func NewTLSConfig(rootCAs *x509.CertPool, getCertificate func() (*tls.Certificate, error)) *tls.Config {
+	config := &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if getCertificate != nil {
+		config.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return getCertificate()
+		}
+	}
+
+	return config
 }
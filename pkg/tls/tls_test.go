@@ -1,9 +1,12 @@
 package tls
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	assert2 "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"testing"
@@ -45,3 +48,31 @@ func Test_getTLSClient(t *testing.T) {
 	// Valid combination
 	assert.NotNil(GetTLSClient(CACertificatePath, ClientCertificatePath, ClientKeyPath))
 }
+
+func Test_getCertificatePool(t *testing.T) {
+	var (
+		require = require.New(t)
+		assert  = assert2.New(t)
+
+		script = fmt.Sprintf("%s/%s", scriptPath, scriptName)
+		cmd    = exec.Command("/bin/sh", script, scriptPath)
+	)
+
+	err := cmd.Run()
+	require.NoError(err)
+
+	// The system certificates are not trusted
+	assert.Empty(GetCertificatePool(InvalidCACertificatePath).Subjects())
+
+	// Only the CA certificate is trusted
+	caCert, err := ioutil.ReadFile(CACertificatePath)
+	require.NoError(err)
+
+	block, _ := pem.Decode(caCert)
+	require.NotNil(block)
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(err)
+
+	assert.Equal([][]byte{certificate.RawSubject}, GetCertificatePool(CACertificatePath).Subjects())
+}
@@ -0,0 +1,218 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFtpPort      = "21"
+	fileUnavailableCode = 550
+)
+
+var (
+	ErrInvalidPassiveResponse = errors.New("invalid passive mode response")
+	ErrInvalidArgument        = errors.New("line break in the command argument")
+)
+
+// ftpConn is a minimal FTP client, supporting passive mode binary transfers.
+type ftpConn struct {
+	conn     *textproto.Conn
+	host     string
+	deadline time.Time
+}
+
+// dialFtp connects to the server in the URL and logs in with the credentials from the URL or as an anonymous user.
+func dialFtp(ctx context.Context, u *url.URL) (*ftpConn, error) {
+	var (
+		dialer   = net.Dialer{}
+		host     = u.Hostname()
+		port     = u.Port()
+		username = "anonymous"
+		password = "anonymous"
+	)
+
+	if port == "" {
+		port = defaultFtpPort
+	}
+
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+
+	// The credentials are sent as command arguments
+	if hasLineBreak(username) || hasLineBreak(password) {
+		return nil, ErrInvalidArgument
+	}
+
+	netConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ftpConn{
+		conn: textproto.NewConn(netConn),
+		host: host,
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.deadline = deadline
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	_, _, err = c.conn.ReadResponse(2)
+	if err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+
+	code, _, err := c.cmd(0, "USER %s", username)
+	if err == nil && code == 331 {
+		_, _, err = c.cmd(2, "PASS %s", password)
+	}
+
+	if err == nil {
+		_, _, err = c.cmd(2, "TYPE I")
+	}
+
+	if err != nil {
+		_ = c.conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// cmd sends the command and reads the response. An expectCode of 0 accepts any successful response.
+func (c *ftpConn) cmd(expectCode int, format string, args ...interface{}) (int, string, error) {
+	err := c.conn.PrintfLine(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+
+	code, message, err := c.conn.ReadResponse(expectCode)
+	if err == nil && expectCode == 0 && code >= 400 {
+		return code, message, &textproto.Error{Code: code, Msg: message}
+	}
+
+	return code, message, err
+}
+
+// openDataConn enters passive mode and connects to the data port. The host from the response is ignored in favour
+// of the control connection host, since servers behind NAT often report their private address.
+func (c *ftpConn) openDataConn(ctx context.Context) (net.Conn, error) {
+	_, message, err := c.cmd(227, "PASV")
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := strings.Index(message, "("), strings.Index(message, ")")
+	if start < 0 || end < start {
+		return nil, ErrInvalidPassiveResponse
+	}
+
+	parts := strings.Split(message[start+1:end], ",")
+	if len(parts) != 6 {
+		return nil, ErrInvalidPassiveResponse
+	}
+
+	high, err1 := strconv.Atoi(strings.TrimSpace(parts[4]))
+	low, err2 := strconv.Atoi(strings.TrimSpace(parts[5]))
+	if err1 != nil || err2 != nil {
+		return nil, ErrInvalidPassiveResponse
+	}
+
+	dialer := net.Dialer{}
+	dataConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.host, strconv.Itoa(high<<8+low)))
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.deadline.IsZero() {
+		_ = dataConn.SetDeadline(c.deadline)
+	}
+
+	return dataConn, nil
+}
+
+// Retrieve downloads the file at the path. ErrFileNotFound is returned if the file is not available.
+func (c *ftpConn) Retrieve(ctx context.Context, path string, writer io.Writer) error {
+	if hasLineBreak(path) {
+		return ErrInvalidArgument
+	}
+
+	dataConn, err := c.openDataConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.cmd(1, "RETR %s", path)
+	if err != nil {
+		_ = dataConn.Close()
+
+		var protocolErr *textproto.Error
+		if errors.As(err, &protocolErr) && protocolErr.Code == fileUnavailableCode {
+			return fmt.Errorf("%w: %v", ErrFileNotFound, err)
+		}
+
+		return err
+	}
+
+	_, copyErr := io.Copy(writer, dataConn)
+	_ = dataConn.Close()
+
+	_, _, err = c.conn.ReadResponse(2)
+	if copyErr != nil {
+		return copyErr
+	}
+
+	return err
+}
+
+// Store uploads the contents of the reader to the path.
+func (c *ftpConn) Store(ctx context.Context, path string, reader io.Reader) error {
+	if hasLineBreak(path) {
+		return ErrInvalidArgument
+	}
+
+	dataConn, err := c.openDataConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.cmd(1, "STOR %s", path)
+	if err != nil {
+		_ = dataConn.Close()
+		return err
+	}
+
+	_, copyErr := io.Copy(dataConn, reader)
+	_ = dataConn.Close()
+
+	_, _, err = c.conn.ReadResponse(2)
+	if copyErr != nil {
+		return copyErr
+	}
+
+	return err
+}
+
+// hasLineBreak checks for CR or LF, which would end the command and allow injecting other commands.
+func hasLineBreak(argument string) bool {
+	return strings.ContainsAny(argument, "\r\n")
+}
+
+// Quit ends the session and closes the connection.
+func (c *ftpConn) Quit() {
+	_, _, _ = c.cmd(2, "QUIT")
+	_ = c.conn.Close()
+}
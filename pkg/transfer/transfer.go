@@ -0,0 +1,139 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var (
+	ErrUnsupportedScheme = errors.New("unsupported location scheme")
+	ErrInvalidLocation   = errors.New("invalid location")
+	ErrFileNotFound      = errors.New("file not found")
+)
+
+// Download fetches the file from the location and writes it to the writer. Supported schemes are http, https and ftp.
+// ErrFileNotFound is returned if the server does not have the file.
+func Download(ctx context.Context, location string, writer io.Writer) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return ErrInvalidLocation
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return httpDownload(ctx, location, writer)
+	case "ftp":
+		return ftpDownload(ctx, u, writer)
+	default:
+		return ErrUnsupportedScheme
+	}
+}
+
+// Upload sends the contents of the reader to the location as a file with the file name. Files are uploaded to FTP servers
+// in the location directory, while HTTP(S) uploads are sent as a multipart form with the "file" field.
+func Upload(ctx context.Context, location, fileName string, reader io.Reader) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return ErrInvalidLocation
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return httpUpload(ctx, location, fileName, reader)
+	case "ftp":
+		return ftpUpload(ctx, u, fileName, reader)
+	default:
+		return ErrUnsupportedScheme
+	}
+}
+
+func httpDownload(ctx context.Context, location string, writer io.Writer) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return fmt.Errorf("%w: %s", ErrFileNotFound, response.Status)
+	default:
+		return fmt.Errorf("download failed with status %s", response.Status)
+	}
+
+	_, err = io.Copy(writer, response.Body)
+	return err
+}
+
+func ftpDownload(ctx context.Context, u *url.URL, writer io.Writer) error {
+	conn, err := dialFtp(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+
+	return conn.Retrieve(ctx, u.Path, writer)
+}
+
+func httpUpload(ctx context.Context, location, fileName string, reader io.Reader) error {
+	var (
+		body, writer  = io.Pipe()
+		multipartBody = multipart.NewWriter(writer)
+		request, err  = http.NewRequestWithContext(ctx, http.MethodPost, location, body)
+	)
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", multipartBody.FormDataContentType())
+
+	// Stream the file instead of buffering it
+	go func() {
+		part, err := multipartBody.CreateFormFile("file", fileName)
+		if err == nil {
+			_, err = io.Copy(part, reader)
+		}
+
+		if err == nil {
+			err = multipartBody.Close()
+		}
+
+		_ = writer.CloseWithError(err)
+	}()
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("upload failed with status %s", response.Status)
+	}
+
+	return nil
+}
+
+func ftpUpload(ctx context.Context, u *url.URL, fileName string, reader io.Reader) error {
+	conn, err := dialFtp(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+
+	return conn.Store(ctx, path.Join(u.Path, fileName), reader)
+}
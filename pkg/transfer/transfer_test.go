@@ -0,0 +1,206 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fileContents = []byte("file contents")
+
+type TransferTestSuite struct {
+	suite.Suite
+}
+
+// serveFtp is a minimal FTP server that serves a single client session.
+func serveFtp(listener net.Listener, files map[string][]byte) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var (
+		reader      = bufio.NewReader(conn)
+		dataChannel net.Listener
+	)
+
+	_, _ = fmt.Fprint(conn, "220 Ready\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "USER":
+			_, _ = fmt.Fprint(conn, "331 Password required\r\n")
+		case "PASS":
+			_, _ = fmt.Fprint(conn, "230 Logged in\r\n")
+		case "TYPE":
+			_, _ = fmt.Fprint(conn, "200 Type set\r\n")
+		case "PASV":
+			dataChannel, _ = net.Listen("tcp", "127.0.0.1:0")
+			port := dataChannel.Addr().(*net.TCPAddr).Port
+			_, _ = fmt.Fprintf(conn, "227 Entering Passive Mode (10,0,0,1,%d,%d)\r\n", port>>8, port&0xff)
+		case "RETR":
+			contents, isFound := files[fields[1]]
+			if !isFound {
+				_, _ = fmt.Fprint(conn, "550 File not found\r\n")
+				_ = dataChannel.Close()
+				continue
+			}
+
+			dataConn, _ := dataChannel.Accept()
+			_, _ = fmt.Fprint(conn, "150 Opening data connection\r\n")
+			_, _ = dataConn.Write(contents)
+			_ = dataConn.Close()
+			_ = dataChannel.Close()
+			_, _ = fmt.Fprint(conn, "226 Transfer complete\r\n")
+		case "STOR":
+			dataConn, _ := dataChannel.Accept()
+			_, _ = fmt.Fprint(conn, "150 Opening data connection\r\n")
+			contents, _ := io.ReadAll(dataConn)
+			files[fields[1]] = contents
+			_ = dataConn.Close()
+			_ = dataChannel.Close()
+			_, _ = fmt.Fprint(conn, "226 Transfer complete\r\n")
+		case "QUIT":
+			_, _ = fmt.Fprint(conn, "221 Bye\r\n")
+			return
+		default:
+			_, _ = fmt.Fprint(conn, "502 Not implemented\r\n")
+		}
+	}
+}
+
+func (s *TransferTestSuite) TestHttpDownload() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/file" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(fileContents)
+	}))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	err := Download(context.Background(), server.URL+"/file", &buffer)
+	s.Require().NoError(err)
+	s.Require().EqualValues(fileContents, buffer.Bytes())
+
+	err = Download(context.Background(), server.URL+"/missing", &buffer)
+	s.Require().ErrorIs(err, ErrFileNotFound)
+}
+
+func (s *TransferTestSuite) TestFtpDownload() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer listener.Close()
+
+	go serveFtp(listener, map[string][]byte{"/file": fileContents})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var buffer bytes.Buffer
+	err = Download(ctx, fmt.Sprintf("ftp://user:password@%s/file", listener.Addr().String()), &buffer)
+	s.Require().NoError(err)
+	s.Require().EqualValues(fileContents, buffer.Bytes())
+}
+
+func (s *TransferTestSuite) TestFtpDownloadErrors() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer listener.Close()
+
+	// Two sessions are served
+	go func() {
+		files := map[string][]byte{"/file": fileContents}
+		serveFtp(listener, files)
+		serveFtp(listener, files)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The line break would end the RETR command and start a new one
+	var buffer bytes.Buffer
+	err = Download(ctx, fmt.Sprintf("ftp://%s/file%%0D%%0ADELE%%20file", listener.Addr().String()), &buffer)
+	s.Require().ErrorIs(err, ErrInvalidArgument)
+
+	err = Download(ctx, fmt.Sprintf("ftp://%s/missing", listener.Addr().String()), &buffer)
+	s.Require().ErrorIs(err, ErrFileNotFound)
+}
+
+func (s *TransferTestSuite) TestHttpUpload() {
+	var uploaded []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil || header.Filename != "file.tar.gz" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		uploaded, _ = io.ReadAll(file)
+	}))
+	defer server.Close()
+
+	err := Upload(context.Background(), server.URL, "file.tar.gz", bytes.NewReader(fileContents))
+	s.Require().NoError(err)
+	s.Require().EqualValues(fileContents, uploaded)
+}
+
+func (s *TransferTestSuite) TestFtpUpload() {
+	var (
+		files = map[string][]byte{}
+		done  = make(chan struct{})
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer listener.Close()
+
+	go func() {
+		serveFtp(listener, files)
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = Upload(ctx, fmt.Sprintf("ftp://%s/uploads", listener.Addr().String()), "file.tar.gz", bytes.NewReader(fileContents))
+	s.Require().NoError(err)
+
+	<-done
+	s.Require().EqualValues(fileContents, files["/uploads/file.tar.gz"])
+}
+
+func (s *TransferTestSuite) TestUnsupportedScheme() {
+	var buffer bytes.Buffer
+	err := Download(context.Background(), "sftp://localhost/file", &buffer)
+	s.Require().ErrorIs(err, ErrUnsupportedScheme)
+
+	err = Upload(context.Background(), "sftp://localhost/", "file", &buffer)
+	s.Require().ErrorIs(err, ErrUnsupportedScheme)
+}
+
+func TestTransfer(t *testing.T) {
+	suite.Run(t, new(TransferTestSuite))
+}
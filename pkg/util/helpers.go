@@ -21,18 +21,23 @@ func IsNilInterfaceOrPointer(sth interface{}) bool {
 
 // GetTypesToSample get the measurands to sample from the OCPP configuration.
 func GetTypesToSample() []types.Measurand {
-	var (
-		measurands []types.Measurand
-		// Get the types to sample
-		measurandsString, err = ocppConfigManager.GetConfigurationValue(v16.MeterValuesSampledData.String())
-	)
+	return GetMeasurands(v16.MeterValuesSampledData.String())
+}
+
+// GetMeasurands get the comma separated measurands from the OCPP configuration key.
+func GetMeasurands(key string) []types.Measurand {
+	var measurands []types.Measurand
 
+	measurandsString, err := ocppConfigManager.GetConfigurationValue(key)
 	if err != nil {
 		return measurands
 	}
 
 	for _, measurand := range strings.Split(measurandsString, ",") {
-		measurands = append(measurands, types.Measurand(measurand))
+		measurand = strings.TrimSpace(measurand)
+		if measurand != "" {
+			measurands = append(measurands, types.Measurand(measurand))
+		}
 	}
 
 	return measurands
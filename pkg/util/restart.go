@@ -0,0 +1,31 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+var ErrNoRebootCommand = errors.New("reboot command not configured")
+
+// RestartProcess replaces the current process with a new instance of the executable, keeping the arguments and the environment.
+func RestartProcess() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
+
+// Reboot runs the reboot command. The command is split into arguments on whitespace and is not run in a shell.
+func Reboot(command string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return ErrNoRebootCommand
+	}
+
+	return exec.Command(args[0], args[1:]...).Run()
+}
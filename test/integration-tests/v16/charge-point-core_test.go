@@ -7,6 +7,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	ocppVar "github.com/xBlaz3kx/ocppManager-go/v16"
 	"strings"
@@ -29,7 +30,8 @@ func (s *chargePointTestSuite) TestStartStopTransaction() {
 	conn.On("GetTransactionId").Return("1")
 	conn.On("GetConnectorId").Return(1)
 	conn.On("GetEvseId").Return(1)
-	conn.On("CalculateSessionAvgEnergyConsumption").Return(30.0)
+	conn.On("GetMeterReading").Return(30)
+	conn.On("GetSession").Return(session.Session{TransactionId: "1", MeterStart: 30})
 	conn.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
 	conn.On("IsAvailable").Return(true).Once()
 	conn.On("IsPreparing").Return(false)
@@ -38,12 +40,15 @@ func (s *chargePointTestSuite) TestStartStopTransaction() {
 	conn.On("IsUnavailable").Return(false)
 	conn.On("GetMaxChargingTime").Return(15)
 	conn.On("SetNotificationChannel", mock.Anything).Return()
+	// The central system replaces the temporary transaction id
+	conn.On("SetTransactionId", "1").Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
 	s.manager.On("FindConnector", 1, 1).Return(conn)
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", tagId).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
+	s.manager.On("FindConnectorWithTransactionId", "-1").Return(conn)
 	s.manager.On("StartChargingConnector").Return()
 	s.manager.On("StopChargingConnector").Return()
 	s.manager.On("StopAllConnectors").Return()
@@ -52,6 +57,7 @@ func (s *chargePointTestSuite) TestStartStopTransaction() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	chargePoint := s.setupChargePoint(ctx, nil, nil, s.manager)
@@ -111,7 +117,8 @@ func (s *chargePointTestSuite) TestStartStopTransactionWithReader() {
 	conn.On("GetTagId").Return(strings.ToUpper(tagId))
 	conn.On("GetConnectorId").Return(1)
 	conn.On("GetEvseId").Return(1)
-	conn.On("CalculateSessionAvgEnergyConsumption").Return(30.0)
+	conn.On("GetMeterReading").Return(30)
+	conn.On("GetSession").Return(session.Session{TransactionId: "1", MeterStart: 30})
 	conn.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
 	conn.On("IsAvailable").Return(true).Once()
 	conn.On("IsPreparing").Return(false)
@@ -120,12 +127,15 @@ func (s *chargePointTestSuite) TestStartStopTransactionWithReader() {
 	conn.On("IsUnavailable").Return(false)
 	conn.On("GetMaxChargingTime").Return(15)
 	conn.On("SetNotificationChannel", mock.Anything).Return()
+	// The central system replaces the temporary transaction id
+	conn.On("SetTransactionId", "1").Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
 	s.manager.On("FindConnector", 1, 1).Return(conn)
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", strings.ToUpper(tagId)).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
+	s.manager.On("FindConnectorWithTransactionId", "-1").Return(conn)
 	s.manager.On("StartChargingConnector").Return()
 	s.manager.On("StopChargingConnector").Return()
 	s.manager.On("StopAllConnectors").Return()
@@ -134,6 +144,7 @@ func (s *chargePointTestSuite) TestStartStopTransactionWithReader() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Mock tagReader
 	s.tagReader.On("ListenForTags").Return()
@@ -195,7 +206,8 @@ func (s *chargePointTestSuite) TestRemoteStartStopTransaction() {
 	conn.On("GetTagId").Return(strings.ToUpper(tagId))
 	conn.On("GetConnectorId").Return(1)
 	conn.On("GetEvseId").Return(1)
-	conn.On("CalculateSessionAvgEnergyConsumption").Return(30.0)
+	conn.On("GetMeterReading").Return(30)
+	conn.On("GetSession").Return(session.Session{TransactionId: "1", MeterStart: 30})
 	conn.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
 	conn.On("IsAvailable").Return(true).Once()
 	conn.On("IsPreparing").Return(false)
@@ -204,12 +216,15 @@ func (s *chargePointTestSuite) TestRemoteStartStopTransaction() {
 	conn.On("IsUnavailable").Return(false)
 	conn.On("GetMaxChargingTime").Return(15)
 	conn.On("SetNotificationChannel", mock.Anything).Return()
+	// The central system replaces the temporary transaction id
+	conn.On("SetTransactionId", "1").Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
 	s.manager.On("FindConnector", 1, 1).Return(conn)
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", strings.ToUpper(tagId)).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
+	s.manager.On("FindConnectorWithTransactionId", "-1").Return(conn)
 	s.manager.On("StartChargingConnector").Return()
 	s.manager.On("StopChargingConnector").Return()
 	s.manager.On("StopAllConnectors").Return()
@@ -218,6 +233,7 @@ func (s *chargePointTestSuite) TestRemoteStartStopTransaction() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	cp := s.setupChargePoint(ctx, nil, nil, s.manager)
@@ -271,6 +287,7 @@ func (s *chargePointTestSuite) TestGetAndChangeConfiguration() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	cp := s.setupChargePoint(ctx, nil, nil, s.manager)
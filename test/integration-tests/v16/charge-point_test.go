@@ -15,13 +15,17 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/reservations"
 	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	transactionQueue "github.com/xBlaz3kx/ChargePi-go/internal/components/transaction-queue"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	"github.com/xBlaz3kx/ocppManager-go/configuration"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -68,23 +72,36 @@ type chargePointTestSuite struct {
 	manager       *test.ManagerMock
 }
 
-func (s *chargePointTestSuite) SetupTest() {
+func (s *chargePointTestSuite) SetupSuite() {
 	log.SetLevel(log.DebugLevel)
 
+	// The central system is started once, as it cannot be stopped
 	s.csMock = new(centralSystemV16Mock)
+	go s.setupCentralSystem(s.csMock)
+}
+
+func (s *chargePointTestSuite) SetupTest() {
 	s.tagReader = new(test.ReaderMock)
 	s.display = new(test.DisplayMock)
 	s.manager = new(test.ManagerMock)
 
-	// Setup OCPP configuration manager
+	// Setup OCPP configuration manager with a copy of the configuration, as the tests change it
+	ocppConfiguration, err := os.ReadFile(ocppConfigurationFilePath)
+	s.Require().NoError(err)
+
+	ocppConfigurationFile := filepath.Join(s.T().TempDir(), "configuration.json")
+	s.Require().NoError(os.WriteFile(ocppConfigurationFile, ocppConfiguration, 0644))
+
 	setting.SetupOcppConfigurationManager(
-		ocppConfigurationFilePath,
+		ocppConfigurationFile,
 		configuration.OCPP16,
 		core.ProfileName,
 		reservation.ProfileName)
+}
 
-	// Start the central system
-	go s.setupCentralSystem(s.csMock)
+func (s *chargePointTestSuite) TearDownTest() {
+	// Let the central system drop the connection of the previous charge point
+	time.Sleep(time.Second)
 }
 
 func (s *chargePointTestSuite) setupCentralSystem(cs *centralSystemV16Mock) {
@@ -169,7 +186,10 @@ func (s *chargePointTestSuite) setupChargePoint(ctx context.Context, lcd display
 	cp := v16.NewChargePoint(
 		connectorManager,
 		scheduler.GetScheduler(),
-		auth.NewAuthCache("./auth.json"),
+		auth.NewAuthCache(filepath.Join(s.T().TempDir(), "auth.json")),
+		auth.NewLocalAuthList(filepath.Join(s.T().TempDir(), "local-auth-list.json")),
+		transactionQueue.NewQueue(""),
+		reservations.NewManager(""),
 		v16.WithDisplay(ctx, lcd),
 		v16.WithReader(ctx, reader),
 		v16.WithLogger(log.StandardLogger()),
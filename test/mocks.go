@@ -231,22 +231,45 @@ func (m *ConnectorMock) GetEvseId() int {
 	return args.Int(0)
 }
 
-func (m *ConnectorMock) CalculateSessionAvgEnergyConsumption() float64 {
+func (m *ConnectorMock) GetMeterReading() int {
 	args := m.Called()
-	return args.Get(0).(float64)
+	return args.Int(0)
 }
 
 func (m *ConnectorMock) SamplePowerMeter(measurands []types.Measurand) {
 	m.Called(measurands)
 }
 
+func (m *ConnectorMock) GetMeterValue(measurands []types.Measurand, readingContext types.ReadingContext) *types.MeterValue {
+	args := m.Called(measurands, readingContext)
+	if args.Get(0) != nil {
+		return args.Get(0).(*types.MeterValue)
+	}
+
+	return nil
+}
+
+func (m *ConnectorMock) AddTransactionData(meterValues ...types.MeterValue) {
+	m.Called(meterValues)
+}
+
 func (m *ConnectorMock) SetStatus(status core.ChargePointStatus, errCode core.ChargePointErrorCode) {
 	m.Called(status, errCode)
 }
 
+func (m *ConnectorMock) SetAvailability(availability core.AvailabilityType) bool {
+	args := m.Called(availability)
+	return args.Bool(0)
+}
+
+func (m *ConnectorMock) GetAvailability() core.AvailabilityType {
+	args := m.Called()
+	return core.AvailabilityType(args.String(0))
+}
+
 func (m *ConnectorMock) GetStatus() (core.ChargePointStatus, core.ChargePointErrorCode) {
 	args := m.Called()
-	return core.ChargePointStatus(args.String(0)), core.ChargePointErrorCode(args.String(1))
+	return args.Get(0).(core.ChargePointStatus), args.Get(1).(core.ChargePointErrorCode)
 }
 
 func (m *ConnectorMock) IsAvailable() bool {
@@ -274,6 +297,11 @@ func (m *ConnectorMock) IsUnavailable() bool {
 	return args.Bool(0)
 }
 
+func (m *ConnectorMock) IsSuspended() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
 func (m *ConnectorMock) GetPowerMeter() powerMeter.PowerMeter {
 	args := m.Called()
 	return args.Get(0).(powerMeter.PowerMeter)
@@ -284,6 +312,33 @@ func (m *ConnectorMock) GetMaxChargingTime() int {
 	return args.Int(0)
 }
 
+func (m *ConnectorMock) SetTransactionId(transactionId string) {
+	m.Called(transactionId)
+}
+
+func (m *ConnectorMock) GetSession() session.Session {
+	args := m.Called()
+	return args.Get(0).(session.Session)
+}
+
+func (m *ConnectorMock) SetChargingLimit(limit *float64) {
+	m.Called(limit)
+}
+
+func (m *ConnectorMock) GetChargingLimit() *float64 {
+	args := m.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).(*float64)
+	}
+
+	return nil
+}
+
+func (m *ConnectorMock) SuspendCharging() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 /*------------------ Indicator mock ------------------*/
 
 func (i *IndicatorMock) DisplayColor(index int, colorHex uint32) error {
@@ -336,6 +391,11 @@ func (p *PowerMeterMock) GetRMSVoltage() float64 {
 	return args.Get(0).(float64)
 }
 
+func (p *PowerMeterMock) GetTemperature() float64 {
+	args := p.Called()
+	return args.Get(0).(float64)
+}
+
 /*---------------------- Relay Mock ----------------------*/
 
 func (r *RelayMock) Enable() {